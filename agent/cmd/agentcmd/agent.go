@@ -20,10 +20,12 @@ import (
 	knotscriptling "github.com/paularlott/knot/internal/scriptling"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/syslogd"
+	"github.com/paularlott/knot/internal/tracing"
 
 	"github.com/paularlott/cli"
 	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/scriptling/object"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var agentServerCmd = &cli.Command{
@@ -136,6 +138,33 @@ var agentServerCmd = &cli.Command{
 			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_API_PORT"},
 			DefaultValue: 12201,
 		},
+		&cli.BoolFlag{
+			Name:         "metrics",
+			Usage:        "Expose Prometheus metrics on /metrics of the API port.",
+			ConfigPath:   []string{"agent.metrics"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_AGENT_METRICS"},
+			DefaultValue: false,
+		},
+		&cli.BoolFlag{
+			Name:         "tracing-enabled",
+			Usage:        "Export OpenTelemetry traces over OTLP/HTTP.",
+			ConfigPath:   []string{"agent.tracing.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_AGENT_TRACING_ENABLED"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:       "tracing-endpoint",
+			Usage:      "The OTLP/HTTP collector URL e.g. http://localhost:4318, if not given the standard OTEL_EXPORTER_OTLP_* variables are used.",
+			ConfigPath: []string{"agent.tracing.endpoint"},
+			EnvVars:    []string{config.CONFIG_ENV_PREFIX + "_AGENT_TRACING_ENDPOINT"},
+		},
+		&cli.Float64Flag{
+			Name:         "tracing-sample-ratio",
+			Usage:        "The fraction of new traces to record, between 0 and 1.",
+			ConfigPath:   []string{"agent.tracing.sample_ratio"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_AGENT_TRACING_SAMPLE_RATIO"},
+			DefaultValue: 1.0,
+		},
 		&cli.BoolFlag{
 			Name:         "dns-resolver",
 			Usage:        "Run a resident DNS resolver on 127.0.0.1:53 that forwards queries to the knot server's DNS (KNOT_SERVER_DNS).",
@@ -201,6 +230,13 @@ var agentServerCmd = &cli.Command{
 			}
 		}
 
+		shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing, "knot-agent",
+			attribute.String("knot.space_id", cfg.SpaceID),
+		)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to initialize tracing")
+		}
+
 		// Open agent connection to the server
 		agentClient := agent_client.NewAgentClient(cfg.Endpoint, cfg.SpaceID)
 		agent_client.SetAgentClient(agentClient)
@@ -271,6 +307,11 @@ var agentServerCmd = &cli.Command{
 		if stopResolver != nil {
 			stopResolver()
 		}
+
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		shutdownTracing(flushCtx)
+		cancel()
+
 		fmt.Println("\r")
 		logger.Info("shutdown")
 
@@ -349,6 +390,12 @@ func buildAgentConfig(cmd *cli.Command) *config.AgentConfig {
 		DisableSpaceIO:       cmd.GetBool("disable-space-io"),
 		MethodsFile:          cmd.GetString("methods-file"),
 		DNSResolver:          cmd.GetBool("dns-resolver"),
+		Metrics:              cmd.GetBool("metrics"),
+		Tracing: config.TracingConfig{
+			Enabled:     cmd.GetBool("tracing-enabled"),
+			Endpoint:    cmd.GetString("tracing-endpoint"),
+			SampleRatio: cmd.GetFloat64("tracing-sample-ratio"),
+		},
 		Port: config.PortConfig{
 			CodeServer: cmd.GetInt("code-server-port"),
 			VNCHttp:    cmd.GetInt("vnc-http-port"),
@@ -67,7 +67,7 @@ func tunnelBaseFlags() []cli.Flag {
 // false, giving foreground-only operation — there is no agent to own a daemon
 // tunnel on a workstation.
 func newWebTunnelCmd(name, protocol, description string, withDaemon bool) *cli.Command {
-	flags := tunnelBaseFlags()
+	flags := append(tunnelBaseFlags(), cmdutil.TunnelPolicyFlags()...)
 	if withDaemon {
 		flags = append(flags, &cli.BoolFlag{
 			Name:  "daemon",
@@ -104,11 +104,16 @@ func newWebTunnelCmd(name, protocol, description string, withDaemon bool) *cli.C
 				return fmt.Errorf("Invalid name, must be all lowercase and only contain letters, numbers and dashes")
 			}
 
+			policy, err := cmdutil.TunnelPolicyFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
 			if withDaemon && cmd.GetBool("daemon") {
-				return startDaemonTunnel(protocol, uint16(port), tunnelName, cmd)
+				return startDaemonTunnel(protocol, uint16(port), tunnelName, policy, cmd)
 			}
 
-			return runForegroundTunnel(ctx, cmd, protocol, uint16(port), tunnelName)
+			return runForegroundTunnel(ctx, cmd, protocol, uint16(port), tunnelName, policy)
 		},
 	}
 }
@@ -143,7 +148,7 @@ var (
 
 // runForegroundTunnel opens a foreground web tunnel in this process and blocks
 // until Ctrl-C. Shared by the agent and desktop binaries.
-func runForegroundTunnel(ctx context.Context, cmd *cli.Command, protocol string, port uint16, name string) error {
+func runForegroundTunnel(ctx context.Context, cmd *cli.Command, protocol string, port uint16, name string, policy *tunnel_server.TunnelPolicy) error {
 	cfg := cmdutil.GetServerAddr(cmd)
 	if cfg == nil {
 		return fmt.Errorf("no server configured")
@@ -160,6 +165,7 @@ func runForegroundTunnel(ctx context.Context, cmd *cli.Command, protocol string,
 			TunnelName:    name,
 			TlsName:       cmd.GetString("port-tls-name"),
 			TlsSkipVerify: cmd.GetBool("port-tls-skip-verify"),
+			Policy:        policy,
 		},
 	)
 	if err := client.ConnectAndServe(); err != nil {
@@ -184,7 +190,7 @@ func runForegroundTunnel(ctx context.Context, cmd *cli.Command, protocol string,
 	return nil
 }
 
-func startDaemonTunnel(protocol string, port uint16, name string, cmd *cli.Command) error {
+func startDaemonTunnel(protocol string, port uint16, name string, policy *tunnel_server.TunnelPolicy, cmd *cli.Command) error {
 	if !agentlink.IsAgentRunning() {
 		return fmt.Errorf("agent not running, --daemon requires the knot agent to be running")
 	}
@@ -195,6 +201,7 @@ func startDaemonTunnel(protocol string, port uint16, name string, cmd *cli.Comma
 		Name:          name,
 		TlsName:       cmd.GetString("port-tls-name"),
 		TlsSkipVerify: cmd.GetBool("port-tls-skip-verify"),
+		Policy:        policy,
 	}
 
 	var response agentlink.StartTunnelResponse
@@ -6,6 +6,14 @@ type AuthLoginRequest struct {
 	Password string `json:"password"`
 	Email    string `json:"email"`
 	TOTPCode string `json:"totp_code"`
+
+	// OIDC login, an authorization code obtained with PKCE from the provider
+	// is exchanged by the server in place of the email and password.
+	Provider     string `json:"provider,omitempty"`
+	Code         string `json:"code,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
+	LoginState   string `json:"login_state,omitempty"` // from StartProviderLogin, or sent as the cookie it set
 }
 
 type AuthLoginResponse struct {
@@ -22,6 +30,21 @@ type UsingTOTPResponse struct {
 	UsingTOTP bool `json:"using_totp"`
 }
 
+type AuthProviderInfo struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Issuer      string `json:"issuer"`
+	ClientId    string `json:"client_id"`
+}
+
+// AuthProviderLoginStart is the state and nonce to send to the provider in the
+// authorization request, and the signed login state to send back with the code.
+type AuthProviderLoginStart struct {
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	LoginState string `json:"login_state"`
+}
+
 func (c *ApiClient) Login(ctx context.Context, email string, password string, totpCode string) (*AuthLoginResponse, int, error) {
 	request := AuthLoginRequest{
 		Email:    email,
@@ -38,6 +61,49 @@ func (c *ApiClient) Login(ctx context.Context, email string, password string, to
 	return &response, code, nil
 }
 
+// StartProviderLogin returns the state and nonce for an OIDC login.
+func (c *ApiClient) StartProviderLogin(ctx context.Context, provider string) (*AuthProviderLoginStart, int, error) {
+	response := AuthProviderLoginStart{}
+
+	code, err := c.httpClient.Post(ctx, "/api/auth/providers/"+provider+"/login", nil, &response, 200)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &response, code, nil
+}
+
+// LoginWithProvider exchanges an OIDC authorization code for a session, the
+// login state is the one returned by StartProviderLogin.
+func (c *ApiClient) LoginWithProvider(ctx context.Context, provider, authCode, codeVerifier, redirectURI, loginState string) (*AuthLoginResponse, int, error) {
+	request := AuthLoginRequest{
+		Provider:     provider,
+		Code:         authCode,
+		CodeVerifier: codeVerifier,
+		RedirectURI:  redirectURI,
+		LoginState:   loginState,
+	}
+	response := AuthLoginResponse{}
+
+	code, err := c.httpClient.Post(ctx, "/api/auth", &request, &response, 200)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &response, code, nil
+}
+
+func (c *ApiClient) GetAuthProviders(ctx context.Context) ([]AuthProviderInfo, int, error) {
+	response := []AuthProviderInfo{}
+
+	code, err := c.httpClient.Get(ctx, "/api/auth/providers", &response)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return response, code, nil
+}
+
 func (c *ApiClient) Logout(ctx context.Context) error {
 	response := AuthLogoutResponse{}
 
@@ -3,12 +3,15 @@ package apiclient
 import "context"
 
 type GroupInfo struct {
-	Id           string `json:"group_id"`
-	Name         string `json:"name"`
-	MaxSpaces    uint32 `json:"max_spaces"`
-	ComputeUnits uint32 `json:"compute_units"`
-	StorageUnits uint32 `json:"storage_units"`
-	MaxTunnels   uint32 `json:"max_tunnels"`
+	Id                 string   `json:"group_id"`
+	Name               string   `json:"name"`
+	MaxSpaces          uint32   `json:"max_spaces"`
+	ComputeUnits       uint32   `json:"compute_units"`
+	StorageUnits       uint32   `json:"storage_units"`
+	MaxTunnels         uint32   `json:"max_tunnels"`
+	DailyTokenBudget   uint64   `json:"daily_token_budget"`
+	MonthlyTokenBudget uint64   `json:"monthly_token_budget"`
+	AllowedModels      []string `json:"allowed_models"`
 }
 
 type GroupInfoList struct {
@@ -17,11 +20,14 @@ type GroupInfoList struct {
 }
 
 type GroupRequest struct {
-	Name         string `json:"name"`
-	MaxSpaces    uint32 `json:"max_spaces"`
-	ComputeUnits uint32 `json:"compute_units"`
-	StorageUnits uint32 `json:"storage_units"`
-	MaxTunnels   uint32 `json:"max_tunnels"`
+	Name               string   `json:"name"`
+	MaxSpaces          uint32   `json:"max_spaces"`
+	ComputeUnits       uint32   `json:"compute_units"`
+	StorageUnits       uint32   `json:"storage_units"`
+	MaxTunnels         uint32   `json:"max_tunnels"`
+	DailyTokenBudget   uint64   `json:"daily_token_budget"`
+	MonthlyTokenBudget uint64   `json:"monthly_token_budget"`
+	AllowedModels      []string `json:"allowed_models"`
 }
 
 type GroupResponse struct {
@@ -0,0 +1,63 @@
+package apiclient
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+type LLMUsageTotals struct {
+	Requests         uint64 `json:"requests"`
+	PromptTokens     uint64 `json:"prompt_tokens"`
+	CompletionTokens uint64 `json:"completion_tokens"`
+	TotalTokens      uint64 `json:"total_tokens"`
+}
+
+type LLMUsageUserInfo struct {
+	UserId   string `json:"user_id"`
+	Username string `json:"username"`
+	Model    string `json:"model"`
+	LLMUsageTotals
+}
+
+type LLMUsageGroupInfo struct {
+	GroupId            string `json:"group_id"`
+	Name               string `json:"name"`
+	DailyTokenBudget   uint64 `json:"daily_token_budget"`
+	MonthlyTokenBudget uint64 `json:"monthly_token_budget"`
+	UsedToday          uint64 `json:"used_today"`
+	UsedThisMonth      uint64 `json:"used_this_month"`
+	LLMUsageTotals
+}
+
+type LLMUsageModelInfo struct {
+	Model string `json:"model"`
+	LLMUsageTotals
+}
+
+// LLMUsageReport holds the token usage between two days, by user and model,
+// by group and by model. Group usage today and this month is always for the
+// current period so it can be compared with the budgets.
+type LLMUsageReport struct {
+	From   time.Time           `json:"from"`
+	To     time.Time           `json:"to"`
+	Total  LLMUsageTotals      `json:"total"`
+	Users  []LLMUsageUserInfo  `json:"users"`
+	Groups []LLMUsageGroupInfo `json:"groups"`
+	Models []LLMUsageModelInfo `json:"models"`
+}
+
+func (c *ApiClient) GetLLMUsage(ctx context.Context, from, to time.Time) (*LLMUsageReport, int, error) {
+	response := &LLMUsageReport{}
+
+	query := url.Values{}
+	query.Set("from", from.UTC().Format(time.RFC3339))
+	query.Set("to", to.UTC().Format(time.RFC3339))
+
+	code, err := c.httpClient.Get(ctx, "/api/llm-usage?"+query.Encode(), response)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return response, code, nil
+}
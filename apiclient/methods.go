@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/util/rest"
 )
 
 type MethodList struct {
@@ -43,6 +45,43 @@ func (c *ApiClient) CallMethod(ctx context.Context, request *methods.JSONRPCRequ
 	return &response, nil
 }
 
+// CallMethodStream calls a method and passes each progress notification sent
+// by a streaming method to onProgress as it arrives. Methods that don't stream
+// return just the response.
+func (c *ApiClient) CallMethodStream(ctx context.Context, request *methods.JSONRPCRequest, onProgress func(*methods.Progress)) (*methods.JSONRPCResponse, error) {
+	client, ok := c.httpClient.(*rest.HTTPClient)
+	if !ok {
+		return c.CallMethod(ctx, request)
+	}
+
+	// Each event is either a progress notification or the response
+	type streamEvent struct {
+		methods.JSONRPCResponse
+		Method string            `json:"method"`
+		Params *methods.Progress `json:"params"`
+	}
+
+	var response *methods.JSONRPCResponse
+	err := rest.StreamData(client, ctx, http.MethodPost, "/api/methods/call", request, func(event *streamEvent) (bool, error) {
+		if event.Method == methods.ProgressMethod {
+			if event.Params != nil && onProgress != nil {
+				onProgress(event.Params)
+			}
+			return false, nil
+		}
+
+		response = &event.JSONRPCResponse
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, errors.New("no response received")
+	}
+	return response, nil
+}
+
 // CallMethodBatch sends a JSON-RPC batch (array of requests) and returns the
 // array of responses. Each item in items is sent as-is. The HTTP layer detects
 // the array shape on the server side and routes each item independently.
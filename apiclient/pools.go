@@ -1,13 +1,19 @@
 package apiclient
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
 
 type PoolRequest struct {
-	Name            string `json:"name"`
-	TemplateId      string `json:"template_id"`
-	StartupScriptId string `json:"startup_script_id"`
-	DesiredCount    int    `json:"desired_count"`
-	Active          bool   `json:"active"`
+	Name            string              `json:"name"`
+	TemplateId      string              `json:"template_id"`
+	StartupScriptId string              `json:"startup_script_id"`
+	DesiredCount    int                 `json:"desired_count"`
+	Autoscale       model.PoolAutoscale `json:"autoscale"`
+	Active          bool                `json:"active"`
 }
 
 type PoolSetSizeRequest struct {
@@ -15,50 +21,55 @@ type PoolSetSizeRequest struct {
 }
 
 type PoolUpdateRequest struct {
-	Name            *string `json:"name,omitempty"`
-	TemplateId      *string `json:"template_id,omitempty"`
-	StartupScriptId *string `json:"startup_script_id,omitempty"`
-	DesiredCount    *int    `json:"desired_count,omitempty"`
-	Active          *bool   `json:"active,omitempty"`
+	Name            *string              `json:"name,omitempty"`
+	TemplateId      *string              `json:"template_id,omitempty"`
+	StartupScriptId *string              `json:"startup_script_id,omitempty"`
+	DesiredCount    *int                 `json:"desired_count,omitempty"`
+	Autoscale       *model.PoolAutoscale `json:"autoscale,omitempty"`
+	Active          *bool                `json:"active,omitempty"`
 }
 
 type PoolUtilization struct {
-	CombinedRPS      float64 `json:"combined_rps"`
-	MethodRPS        float64 `json:"method_rps"`
-	HTTPRPS          float64 `json:"http_rps"`
-	TCPRPS           float64 `json:"tcp_rps"`
-	MethodInflight   int     `json:"method_inflight"`
-	AvgCPUPercent    float64 `json:"avg_cpu_percent"`
-	AvgMemoryPercent float64 `json:"avg_memory_percent"`
+	CombinedRPS      float64            `json:"combined_rps"`
+	MethodRPS        float64            `json:"method_rps"`
+	HTTPRPS          float64            `json:"http_rps"`
+	TCPRPS           float64            `json:"tcp_rps"`
+	MethodInflight   int                `json:"method_inflight"`
+	AvgCPUPercent    float64            `json:"avg_cpu_percent"`
+	AvgMemoryPercent float64            `json:"avg_memory_percent"`
+	AvgCustomMetrics map[string]float64 `json:"avg_custom_metrics,omitempty"`
 }
 
 type PoolMemberInfo struct {
-	Id             string  `json:"space_id"`
-	Name           string  `json:"name"`
-	State          string  `json:"state"`
-	CombinedRPS    float64 `json:"combined_rps"`
-	MethodRPS      float64 `json:"method_rps"`
-	HTTPRPS        float64 `json:"http_rps"`
-	TCPRPS         float64 `json:"tcp_rps"`
-	MethodInflight int     `json:"method_inflight"`
-	CPUPercent     float64 `json:"cpu_percent"`
-	MemoryPercent  float64 `json:"memory_percent"`
-	Healthy        bool    `json:"healthy"`
-	IsPending      bool    `json:"is_pending"`
-	IsDeleting     bool    `json:"is_deleting"`
-	IsDeployed     bool    `json:"is_deployed"`
+	Id             string             `json:"space_id"`
+	Name           string             `json:"name"`
+	State          string             `json:"state"`
+	CombinedRPS    float64            `json:"combined_rps"`
+	MethodRPS      float64            `json:"method_rps"`
+	HTTPRPS        float64            `json:"http_rps"`
+	TCPRPS         float64            `json:"tcp_rps"`
+	MethodInflight int                `json:"method_inflight"`
+	CPUPercent     float64            `json:"cpu_percent"`
+	MemoryPercent  float64            `json:"memory_percent"`
+	CustomMetrics  map[string]float64 `json:"custom_metrics,omitempty"`
+	Healthy        bool               `json:"healthy"`
+	IsPending      bool               `json:"is_pending"`
+	IsDeleting     bool               `json:"is_deleting"`
+	IsDeployed     bool               `json:"is_deployed"`
 }
 
 type PoolInfo struct {
-	Id              string           `json:"pool_id"`
-	Name            string           `json:"name"`
-	TemplateId      string           `json:"template_id"`
-	StartupScriptId string           `json:"startup_script_id"`
-	DesiredCount    int              `json:"desired_count"`
-	AliveMembers    int              `json:"alive_members"`
-	Active          bool             `json:"active"`
-	Utilization     PoolUtilization  `json:"utilization"`
-	Members         []PoolMemberInfo `json:"members"`
+	Id              string              `json:"pool_id"`
+	Name            string              `json:"name"`
+	TemplateId      string              `json:"template_id"`
+	StartupScriptId string              `json:"startup_script_id"`
+	DesiredCount    int                 `json:"desired_count"`
+	Autoscale       model.PoolAutoscale `json:"autoscale"`
+	LastScaledAt    *time.Time          `json:"last_scaled_at,omitempty"`
+	AliveMembers    int                 `json:"alive_members"`
+	Active          bool                `json:"active"`
+	Utilization     PoolUtilization     `json:"utilization"`
+	Members         []PoolMemberInfo    `json:"members"`
 }
 
 type PoolList struct {
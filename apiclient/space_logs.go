@@ -0,0 +1,67 @@
+package apiclient
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type SpaceLogEntry struct {
+	Id        string    `json:"log_id"`
+	Level     string    `json:"level"`
+	Source    string    `json:"source"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SpaceLogList holds a page of log lines, newest first. Next is the cursor to
+// pass as Before for the next older page, empty when there are no more.
+type SpaceLogList struct {
+	Logs []SpaceLogEntry `json:"logs"`
+	Next string          `json:"next,omitempty"`
+}
+
+// SpaceLogQuery filters the log lines returned by GetSpaceLogs, empty fields
+// match everything.
+type SpaceLogQuery struct {
+	From   time.Time
+	To     time.Time
+	Level  string // minimum severity: debug, info or error
+	Source string
+	Query  string
+	Before string
+	Limit  int
+}
+
+func (c *ApiClient) GetSpaceLogs(ctx context.Context, spaceId string, query *SpaceLogQuery) (*SpaceLogList, int, error) {
+	params := url.Values{}
+	if !query.From.IsZero() {
+		params.Set("from", query.From.Format(time.RFC3339))
+	}
+	if !query.To.IsZero() {
+		params.Set("to", query.To.Format(time.RFC3339))
+	}
+	if query.Level != "" {
+		params.Set("level", query.Level)
+	}
+	if query.Source != "" {
+		params.Set("source", query.Source)
+	}
+	if query.Query != "" {
+		params.Set("q", query.Query)
+	}
+	if query.Before != "" {
+		params.Set("before", query.Before)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	response := &SpaceLogList{}
+	code, err := c.httpClient.Get(ctx, "/api/spaces/"+spaceId+"/logs?"+params.Encode(), response)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
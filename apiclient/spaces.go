@@ -153,11 +153,12 @@ type SpaceDefinition struct {
 }
 
 type SpaceResourceUsage struct {
-	CPUPercent       float64 `json:"cpu_percent"`
-	MemoryUsedBytes  uint64  `json:"memory_used_bytes"`
-	MemoryLimitBytes uint64  `json:"memory_limit_bytes"`
-	DiskUsedBytes    uint64  `json:"disk_used_bytes"`
-	DiskLimitBytes   uint64  `json:"disk_limit_bytes"`
+	CPUPercent       float64            `json:"cpu_percent"`
+	MemoryUsedBytes  uint64             `json:"memory_used_bytes"`
+	MemoryLimitBytes uint64             `json:"memory_limit_bytes"`
+	DiskUsedBytes    uint64             `json:"disk_used_bytes"`
+	DiskLimitBytes   uint64             `json:"disk_limit_bytes"`
+	CustomMetrics    map[string]float64 `json:"custom_metrics,omitempty"`
 }
 
 type SpaceActivityUsage struct {
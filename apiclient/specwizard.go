@@ -1,6 +1,10 @@
 package apiclient
 
-import "context"
+import (
+	"context"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
 
 // This file defines the unified JSON schema used by the template spec wizard.
 // The wizard operates on UnifiedSpec regardless of the underlying platform
@@ -18,10 +22,16 @@ import "context"
 //     config) survive because the patcher only touches the fields it knows.
 
 // SpecParseRequest is the input to POST /api/spec/parse.
+//
+// Format selects the source format of Job. Empty parses the platform's native
+// spec; "devcontainer" and "compose" import a devcontainer.json or compose
+// file, with Service choosing the compose service when there are several.
 type SpecParseRequest struct {
 	Platform string `json:"platform"`
 	Job      string `json:"job"`
 	Volumes  string `json:"volumes"`
+	Format   string `json:"format,omitempty"`
+	Service  string `json:"service,omitempty"`
 }
 
 // SpecParseResponse is the output of POST /api/spec/parse.
@@ -44,6 +54,36 @@ type SpecParseResponse struct {
 	// AdvancedReason explains what was detected.
 	FullyRepresentable bool   `json:"fully_representable"`
 	AdvancedReason     string `json:"advanced_reason,omitempty"`
+
+	// Import is set when the request imported a devcontainer.json or compose
+	// file.
+	Import *SpecImportReport `json:"import,omitempty"`
+}
+
+// SpecImportReport describes what an import produced beyond the UnifiedSpec:
+// the suggested template name, the ports to expose through knot, a startup
+// script built from the lifecycle commands and everything that couldn't be
+// mapped.
+type SpecImportReport struct {
+	Format        string               `json:"format"`
+	Name          string               `json:"name,omitempty"`
+	Ports         []model.TemplatePort `json:"ports,omitempty"`
+	StartupScript string               `json:"startup_script,omitempty"`
+	Unmapped      []SpecImportIssue    `json:"unmapped,omitempty"`
+}
+
+// SpecImportIssue is one source field the import couldn't map.
+type SpecImportIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ParseSpec parses a native spec, or imports a devcontainer.json / compose
+// file, into a UnifiedSpec.
+func (c *ApiClient) ParseSpec(ctx context.Context, request *SpecParseRequest) (*SpecParseResponse, int, error) {
+	response := &SpecParseResponse{}
+	code, err := c.httpClient.Post(ctx, "/api/spec/parse", request, response, 200)
+	return response, code, err
 }
 
 // SpecBuildRequest is the input to POST /api/spec/build.
@@ -65,6 +105,14 @@ type SpecBuildResponse struct {
 	Volumes string `json:"volumes"`
 }
 
+// BuildSpec renders a UnifiedSpec into the platform's native spec, patching
+// the original spec when one is given.
+func (c *ApiClient) BuildSpec(ctx context.Context, request *SpecBuildRequest) (*SpecBuildResponse, int, error) {
+	response := &SpecBuildResponse{}
+	code, err := c.httpClient.Post(ctx, "/api/spec/build", request, response, 200)
+	return response, code, err
+}
+
 // CapabilityEntry is one Linux capability in the wizard's picker catalog.
 // Name is the canonical CAP_UPPER_SNAKE form; Common marks the capabilities
 // dev spaces most often need so the UI can float them to the top of the list.
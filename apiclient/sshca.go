@@ -0,0 +1,45 @@
+package apiclient
+
+import (
+	"context"
+	"time"
+)
+
+// SSHCAResponse describes the server's SSH certificate authority.
+type SSHCAResponse struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"` // CA public key in authorized_keys format
+	MaxTTL    int    `json:"max_ttl"`    // maximum user certificate lifetime in minutes
+}
+
+type SSHCertRequest struct {
+	PublicKey string `json:"public_key"`
+	TTL       int    `json:"ttl"` // lifetime in minutes, 0 for the maximum
+}
+
+type SSHCertResponse struct {
+	Certificate string    `json:"certificate"` // user certificate in authorized_keys format
+	Principal   string    `json:"principal"`
+	ValidBefore time.Time `json:"valid_before"`
+}
+
+// GetSSHCA returns the server's SSH certificate authority.
+func (c *ApiClient) GetSSHCA(ctx context.Context) (*SSHCAResponse, int, error) {
+	response := &SSHCAResponse{}
+	code, err := c.httpClient.Get(ctx, "/api/ssh/ca", response)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
+
+// SignSSHCert issues a short lived certificate for publicKey to log in to the
+// space over SSH.
+func (c *ApiClient) SignSSHCert(ctx context.Context, spaceId string, request *SSHCertRequest) (*SSHCertResponse, int, error) {
+	response := &SSHCertResponse{}
+	code, err := c.httpClient.Post(ctx, "/api/spaces/"+spaceId+"/ssh-cert", request, response, 200)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
@@ -50,6 +50,8 @@ type TemplateExport struct {
 	DisableUserActivity bool           `yaml:"disable_user_activity,omitempty"`
 	Ports               []model.TemplatePort `yaml:"ports,omitempty"`
 
+	IdlePolicy *TemplateExportIdlePolicy `yaml:"idle_policy,omitempty"`
+
 	Job     string `yaml:"job,omitempty"`
 	Volumes string `yaml:"volumes,omitempty"`
 }
@@ -60,9 +62,18 @@ type TemplateExportFeatures struct {
 	WithCodeServer    bool `yaml:"with_code_server,omitempty"`
 	WithSSH           bool `yaml:"with_ssh,omitempty"`
 	WithRunCommand    bool `yaml:"with_run_command,omitempty"`
+	DisableSSHLocalForward bool `yaml:"disable_ssh_local_forward,omitempty"`
+	WithSSHReverseForward  bool `yaml:"with_ssh_reverse_forward,omitempty"`
+	WithTerminalRecording  bool `yaml:"with_terminal_recording,omitempty"`
 	AllowNodeMigration bool `yaml:"allow_node_migration,omitempty"`
 }
 
+type TemplateExportIdlePolicy struct {
+	Timeout      uint32  `yaml:"timeout"`
+	CPUThreshold float64 `yaml:"cpu_threshold,omitempty"`
+	WarnBefore   uint32  `yaml:"warn_before,omitempty"`
+}
+
 type TemplateExportScheduleDay struct {
 	Enabled bool   `yaml:"enabled,omitempty"`
 	From    string `yaml:"from,omitempty"`
@@ -125,6 +136,9 @@ func (e *TemplateExport) ToCreateRequest() *TemplateCreateRequest {
 		WithCodeServer:             e.Features.WithCodeServer,
 		WithSSH:                    e.Features.WithSSH,
 		WithRunCommand:             e.Features.WithRunCommand,
+		DisableSSHLocalForward:     e.Features.DisableSSHLocalForward,
+		WithSSHReverseForward:      e.Features.WithSSHReverseForward,
+		WithTerminalRecording:      e.Features.WithTerminalRecording,
 		AllowNodeMigration:         e.Features.AllowNodeMigration,
 		StartupScriptId:            e.StartupScript,
 		ShutdownScriptId:           e.ShutdownScript,
@@ -146,6 +160,14 @@ func (e *TemplateExport) ToCreateRequest() *TemplateCreateRequest {
 		Ports:                      defaultPorts(e.Ports),
 	}
 	req.CustomFields = defaultCustomFields(e.CustomFields)
+	if e.IdlePolicy != nil {
+		req.IdlePolicy = model.TemplateIdlePolicy{
+			Enabled:      true,
+			Timeout:      e.IdlePolicy.Timeout,
+			CPUThreshold: e.IdlePolicy.CPUThreshold,
+			WarnBefore:   e.IdlePolicy.WarnBefore,
+		}
+	}
 	if len(e.Schedule) > 0 {
 		req.Schedule = make([]TemplateDetailsDay, len(e.Schedule))
 		for i, s := range e.Schedule {
@@ -192,9 +214,19 @@ func ExportFromDetails(d *TemplateDetails) *TemplateExport {
 			WithCodeServer:     d.WithCodeServer,
 			WithSSH:            d.WithSSH,
 			WithRunCommand:     d.WithRunCommand,
+			DisableSSHLocalForward: d.DisableSSHLocalForward,
+			WithSSHReverseForward:  d.WithSSHReverseForward,
+			WithTerminalRecording:  d.WithTerminalRecording,
 			AllowNodeMigration: d.AllowNodeMigration,
 		},
 	}
+	if d.IdlePolicy.Enabled {
+		exp.IdlePolicy = &TemplateExportIdlePolicy{
+			Timeout:      d.IdlePolicy.Timeout,
+			CPUThreshold: d.IdlePolicy.CPUThreshold,
+			WarnBefore:   d.IdlePolicy.WarnBefore,
+		}
+	}
 	if len(d.CustomFields) > 0 {
 		exp.CustomFields = make([]TemplateExportCustomField, len(d.CustomFields))
 		for i, cf := range d.CustomFields {
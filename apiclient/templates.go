@@ -13,77 +13,85 @@ type CustomFieldDef struct {
 }
 
 type TemplateCreateRequest struct {
-	Name                     string               `json:"name"`
-	Job                      string               `json:"job"`
-	Description              string               `json:"description"`
-	Volumes                  string               `json:"volumes"`
-	Groups                   []string             `json:"groups"`
-	Platform                 string               `json:"platform"`
-	Active                   bool                 `json:"active"`
-	WithTerminal             bool                 `json:"with_terminal"`
-	WithVSCodeTunnel         bool                 `json:"with_vscode_tunnel"`
-	WithCodeServer           bool                 `json:"with_code_server"`
-	WithSSH                  bool                 `json:"with_ssh"`
-	WithRunCommand           bool                 `json:"with_run_command"`
-	AllowNodeMigration       bool                 `json:"allow_node_migration"`
-	StartupScriptId          string               `json:"startup_script_id"`
-	ShutdownScriptId         string               `json:"shutdown_script_id"`
-	ScheduleEnabled          bool                 `json:"schedule_enabled"`
-	AutoStart                bool                 `json:"auto_start"`
-	Schedule                 []TemplateDetailsDay `json:"schedule"`
-	ComputeUnits             uint32               `json:"compute_units"`
-	StorageUnits             uint32               `json:"storage_units"`
-	Zones                    []string             `json:"zones"`
-	MaxUptime                uint32               `json:"max_uptime"`
-	MaxUptimeUnit            string               `json:"max_uptime_unit"`
-	IconURL                  string               `json:"icon_url"`
-	CustomFields             []CustomFieldDef     `json:"custom_fields"`
-	HealthCheckType          string               `json:"health_check_type"`
-	HealthCheckConfig        string               `json:"health_check_config"`
-	HealthCheckSkipSSLVerify bool                 `json:"health_check_skip_ssl_verify"`
-	HealthCheckTimeout       uint32               `json:"health_check_timeout"`
-	HealthCheckInterval      uint32               `json:"health_check_interval"`
-	HealthCheckMaxFailures   uint32               `json:"health_check_max_failures"`
-	HealthCheckAutoRestart   bool                 `json:"health_check_auto_restart"`
-	DisableUserActivity      bool                 `json:"disable_user_activity"`
-	Ports                    []model.TemplatePort `json:"ports"`
+	Name                     string                   `json:"name"`
+	Job                      string                   `json:"job"`
+	Description              string                   `json:"description"`
+	Volumes                  string                   `json:"volumes"`
+	Groups                   []string                 `json:"groups"`
+	Platform                 string                   `json:"platform"`
+	Active                   bool                     `json:"active"`
+	WithTerminal             bool                     `json:"with_terminal"`
+	WithVSCodeTunnel         bool                     `json:"with_vscode_tunnel"`
+	WithCodeServer           bool                     `json:"with_code_server"`
+	WithSSH                  bool                     `json:"with_ssh"`
+	WithRunCommand           bool                     `json:"with_run_command"`
+	DisableSSHLocalForward   bool                     `json:"disable_ssh_local_forward"`
+	WithSSHReverseForward    bool                     `json:"with_ssh_reverse_forward"`
+	WithTerminalRecording    bool                     `json:"with_terminal_recording"`
+	AllowNodeMigration       bool                     `json:"allow_node_migration"`
+	StartupScriptId          string                   `json:"startup_script_id"`
+	ShutdownScriptId         string                   `json:"shutdown_script_id"`
+	ScheduleEnabled          bool                     `json:"schedule_enabled"`
+	AutoStart                bool                     `json:"auto_start"`
+	Schedule                 []TemplateDetailsDay     `json:"schedule"`
+	ComputeUnits             uint32                   `json:"compute_units"`
+	StorageUnits             uint32                   `json:"storage_units"`
+	Zones                    []string                 `json:"zones"`
+	MaxUptime                uint32                   `json:"max_uptime"`
+	MaxUptimeUnit            string                   `json:"max_uptime_unit"`
+	IconURL                  string                   `json:"icon_url"`
+	CustomFields             []CustomFieldDef         `json:"custom_fields"`
+	HealthCheckType          string                   `json:"health_check_type"`
+	HealthCheckConfig        string                   `json:"health_check_config"`
+	HealthCheckSkipSSLVerify bool                     `json:"health_check_skip_ssl_verify"`
+	HealthCheckTimeout       uint32                   `json:"health_check_timeout"`
+	HealthCheckInterval      uint32                   `json:"health_check_interval"`
+	HealthCheckMaxFailures   uint32                   `json:"health_check_max_failures"`
+	HealthCheckAutoRestart   bool                     `json:"health_check_auto_restart"`
+	DisableUserActivity      bool                     `json:"disable_user_activity"`
+	Ports                    []model.TemplatePort     `json:"ports"`
+	IdlePolicy               model.TemplateIdlePolicy `json:"idle_policy"`
 }
 
 type TemplateUpdateRequest struct {
-	Name                     string               `json:"name"`
-	Job                      string               `json:"job"`
-	Description              string               `json:"description"`
-	Volumes                  string               `json:"volumes"`
-	Groups                   []string             `json:"groups"`
-	Active                   bool                 `json:"active"`
-	Platform                 string               `json:"platform"`
-	WithTerminal             bool                 `json:"with_terminal"`
-	WithVSCodeTunnel         bool                 `json:"with_vscode_tunnel"`
-	WithCodeServer           bool                 `json:"with_code_server"`
-	WithSSH                  bool                 `json:"with_ssh"`
-	WithRunCommand           bool                 `json:"with_run_command"`
-	AllowNodeMigration       bool                 `json:"allow_node_migration"`
-	StartupScriptId          string               `json:"startup_script_id"`
-	ShutdownScriptId         string               `json:"shutdown_script_id"`
-	ScheduleEnabled          bool                 `json:"schedule_enabled"`
-	AutoStart                bool                 `json:"auto_start"`
-	Schedule                 []TemplateDetailsDay `json:"schedule"`
-	ComputeUnits             uint32               `json:"compute_units"`
-	StorageUnits             uint32               `json:"storage_units"`
-	Zones                    []string             `json:"zones"`
-	MaxUptime                uint32               `json:"max_uptime"`
-	MaxUptimeUnit            string               `json:"max_uptime_unit"`
-	IconURL                  string               `json:"icon_url"`
-	CustomFields             []CustomFieldDef     `json:"custom_fields"`
-	HealthCheckType          string               `json:"health_check_type"`
-	HealthCheckConfig        string               `json:"health_check_config"`
-	HealthCheckSkipSSLVerify bool                 `json:"health_check_skip_ssl_verify"`
-	HealthCheckTimeout       uint32               `json:"health_check_timeout"`
-	HealthCheckInterval      uint32               `json:"health_check_interval"`
-	HealthCheckMaxFailures   uint32               `json:"health_check_max_failures"`
-	HealthCheckAutoRestart   bool                 `json:"health_check_auto_restart"`
-	DisableUserActivity      bool                 `json:"disable_user_activity"`
-	Ports                    []model.TemplatePort `json:"ports"`
+	Name                     string                   `json:"name"`
+	Job                      string                   `json:"job"`
+	Description              string                   `json:"description"`
+	Volumes                  string                   `json:"volumes"`
+	Groups                   []string                 `json:"groups"`
+	Active                   bool                     `json:"active"`
+	Platform                 string                   `json:"platform"`
+	WithTerminal             bool                     `json:"with_terminal"`
+	WithVSCodeTunnel         bool                     `json:"with_vscode_tunnel"`
+	WithCodeServer           bool                     `json:"with_code_server"`
+	WithSSH                  bool                     `json:"with_ssh"`
+	WithRunCommand           bool                     `json:"with_run_command"`
+	DisableSSHLocalForward   bool                     `json:"disable_ssh_local_forward"`
+	WithSSHReverseForward    bool                     `json:"with_ssh_reverse_forward"`
+	WithTerminalRecording    bool                     `json:"with_terminal_recording"`
+	AllowNodeMigration       bool                     `json:"allow_node_migration"`
+	StartupScriptId          string                   `json:"startup_script_id"`
+	ShutdownScriptId         string                   `json:"shutdown_script_id"`
+	ScheduleEnabled          bool                     `json:"schedule_enabled"`
+	AutoStart                bool                     `json:"auto_start"`
+	Schedule                 []TemplateDetailsDay     `json:"schedule"`
+	ComputeUnits             uint32                   `json:"compute_units"`
+	StorageUnits             uint32                   `json:"storage_units"`
+	Zones                    []string                 `json:"zones"`
+	MaxUptime                uint32                   `json:"max_uptime"`
+	MaxUptimeUnit            string                   `json:"max_uptime_unit"`
+	IconURL                  string                   `json:"icon_url"`
+	CustomFields             []CustomFieldDef         `json:"custom_fields"`
+	HealthCheckType          string                   `json:"health_check_type"`
+	HealthCheckConfig        string                   `json:"health_check_config"`
+	HealthCheckSkipSSLVerify bool                     `json:"health_check_skip_ssl_verify"`
+	HealthCheckTimeout       uint32                   `json:"health_check_timeout"`
+	HealthCheckInterval      uint32                   `json:"health_check_interval"`
+	HealthCheckMaxFailures   uint32                   `json:"health_check_max_failures"`
+	HealthCheckAutoRestart   bool                     `json:"health_check_auto_restart"`
+	DisableUserActivity      bool                     `json:"disable_user_activity"`
+	Ports                    []model.TemplatePort     `json:"ports"`
+	IdlePolicy               model.TemplateIdlePolicy `json:"idle_policy"`
 }
 
 type TemplateCreateResponse struct {
@@ -92,27 +100,28 @@ type TemplateCreateResponse struct {
 }
 
 type TemplateInfo struct {
-	Id                 string               `json:"template_id"`
-	Name               string               `json:"name"`
-	Description        string               `json:"description"`
-	Usage              int                  `json:"usage"`
-	Deployed           int                  `json:"deployed"`
-	Groups             []string             `json:"groups"`
-	Platform           string               `json:"platform"`
-	Active             bool                 `json:"active"`
-	IsManaged          bool                 `json:"is_managed"`
-	AllowNodeMigration bool                 `json:"allow_node_migration"`
-	ScheduleEnabled    bool                 `json:"schedule_enabled"`
-	AutoStart          bool                 `json:"auto_start"`
-	ComputeUnits       uint32               `json:"compute_units"`
-	StorageUnits       uint32               `json:"storage_units"`
-	Schedule           []TemplateDetailsDay `json:"schedule"`
-	Zones              []string             `json:"zones"`
-	MaxUptime          uint32               `json:"max_uptime"`
-	MaxUptimeUnit      string               `json:"max_uptime_unit"`
-	IconURL            string               `json:"icon_url"`
-	Ports              []model.TemplatePort `json:"ports"`
-	CustomFields       []CustomFieldDef     `json:"custom_fields"`
+	Id                 string                   `json:"template_id"`
+	Name               string                   `json:"name"`
+	Description        string                   `json:"description"`
+	Usage              int                      `json:"usage"`
+	Deployed           int                      `json:"deployed"`
+	Groups             []string                 `json:"groups"`
+	Platform           string                   `json:"platform"`
+	Active             bool                     `json:"active"`
+	IsManaged          bool                     `json:"is_managed"`
+	AllowNodeMigration bool                     `json:"allow_node_migration"`
+	ScheduleEnabled    bool                     `json:"schedule_enabled"`
+	AutoStart          bool                     `json:"auto_start"`
+	ComputeUnits       uint32                   `json:"compute_units"`
+	StorageUnits       uint32                   `json:"storage_units"`
+	Schedule           []TemplateDetailsDay     `json:"schedule"`
+	Zones              []string                 `json:"zones"`
+	MaxUptime          uint32                   `json:"max_uptime"`
+	MaxUptimeUnit      string                   `json:"max_uptime_unit"`
+	IconURL            string                   `json:"icon_url"`
+	Ports              []model.TemplatePort     `json:"ports"`
+	IdlePolicy         model.TemplateIdlePolicy `json:"idle_policy"`
+	CustomFields       []CustomFieldDef         `json:"custom_fields"`
 }
 
 type TemplateList struct {
@@ -127,45 +136,49 @@ type TemplateDetailsDay struct {
 }
 
 type TemplateDetails struct {
-	TemplateId               string               `json:"template_id"`
-	Name                     string               `json:"name"`
-	Job                      string               `json:"job"`
-	Description              string               `json:"description"`
-	Volumes                  string               `json:"volumes"`
-	Usage                    int                  `json:"usage"`
-	Hash                     string               `json:"hash"`
-	Deployed                 int                  `json:"deployed"`
-	Groups                   []string             `json:"groups"`
-	Platform                 string               `json:"platform"`
-	Active                   bool                 `json:"active"`
-	IsManaged                bool                 `json:"is_managed"`
-	WithTerminal             bool                 `json:"with_terminal"`
-	WithVSCodeTunnel         bool                 `json:"with_vscode_tunnel"`
-	WithCodeServer           bool                 `json:"with_code_server"`
-	WithSSH                  bool                 `json:"with_ssh"`
-	WithRunCommand           bool                 `json:"with_run_command"`
-	AllowNodeMigration       bool                 `json:"allow_node_migration"`
-	StartupScriptId          string               `json:"startup_script_id"`
-	ShutdownScriptId         string               `json:"shutdown_script_id"`
-	ComputeUnits             uint32               `json:"compute_units"`
-	StorageUnits             uint32               `json:"storage_units"`
-	ScheduleEnabled          bool                 `json:"schedule_enabled"`
-	AutoStart                bool                 `json:"auto_start"`
-	Schedule                 []TemplateDetailsDay `json:"schedule"`
-	Zones                    []string             `json:"zones"`
-	MaxUptime                uint32               `json:"max_uptime"`
-	MaxUptimeUnit            string               `json:"max_uptime_unit"`
-	IconURL                  string               `json:"icon_url"`
-	CustomFields             []CustomFieldDef     `json:"custom_fields"`
-	HealthCheckType          string               `json:"health_check_type"`
-	HealthCheckConfig        string               `json:"health_check_config"`
-	HealthCheckSkipSSLVerify bool                 `json:"health_check_skip_ssl_verify"`
-	HealthCheckTimeout       uint32               `json:"health_check_timeout"`
-	HealthCheckInterval      uint32               `json:"health_check_interval"`
-	HealthCheckMaxFailures   uint32               `json:"health_check_max_failures"`
-	HealthCheckAutoRestart   bool                 `json:"health_check_auto_restart"`
-	DisableUserActivity      bool                 `json:"disable_user_activity"`
-	Ports                    []model.TemplatePort `json:"ports"`
+	TemplateId               string                   `json:"template_id"`
+	Name                     string                   `json:"name"`
+	Job                      string                   `json:"job"`
+	Description              string                   `json:"description"`
+	Volumes                  string                   `json:"volumes"`
+	Usage                    int                      `json:"usage"`
+	Hash                     string                   `json:"hash"`
+	Deployed                 int                      `json:"deployed"`
+	Groups                   []string                 `json:"groups"`
+	Platform                 string                   `json:"platform"`
+	Active                   bool                     `json:"active"`
+	IsManaged                bool                     `json:"is_managed"`
+	WithTerminal             bool                     `json:"with_terminal"`
+	WithVSCodeTunnel         bool                     `json:"with_vscode_tunnel"`
+	WithCodeServer           bool                     `json:"with_code_server"`
+	WithSSH                  bool                     `json:"with_ssh"`
+	WithRunCommand           bool                     `json:"with_run_command"`
+	DisableSSHLocalForward   bool                     `json:"disable_ssh_local_forward"`
+	WithSSHReverseForward    bool                     `json:"with_ssh_reverse_forward"`
+	WithTerminalRecording    bool                     `json:"with_terminal_recording"`
+	AllowNodeMigration       bool                     `json:"allow_node_migration"`
+	StartupScriptId          string                   `json:"startup_script_id"`
+	ShutdownScriptId         string                   `json:"shutdown_script_id"`
+	ComputeUnits             uint32                   `json:"compute_units"`
+	StorageUnits             uint32                   `json:"storage_units"`
+	ScheduleEnabled          bool                     `json:"schedule_enabled"`
+	AutoStart                bool                     `json:"auto_start"`
+	Schedule                 []TemplateDetailsDay     `json:"schedule"`
+	Zones                    []string                 `json:"zones"`
+	MaxUptime                uint32                   `json:"max_uptime"`
+	MaxUptimeUnit            string                   `json:"max_uptime_unit"`
+	IconURL                  string                   `json:"icon_url"`
+	CustomFields             []CustomFieldDef         `json:"custom_fields"`
+	HealthCheckType          string                   `json:"health_check_type"`
+	HealthCheckConfig        string                   `json:"health_check_config"`
+	HealthCheckSkipSSLVerify bool                     `json:"health_check_skip_ssl_verify"`
+	HealthCheckTimeout       uint32                   `json:"health_check_timeout"`
+	HealthCheckInterval      uint32                   `json:"health_check_interval"`
+	HealthCheckMaxFailures   uint32                   `json:"health_check_max_failures"`
+	HealthCheckAutoRestart   bool                     `json:"health_check_auto_restart"`
+	DisableUserActivity      bool                     `json:"disable_user_activity"`
+	Ports                    []model.TemplatePort     `json:"ports"`
+	IdlePolicy               model.TemplateIdlePolicy `json:"idle_policy"`
 }
 
 func (c *ApiClient) GetTemplates(ctx context.Context) (*TemplateList, int, error) {
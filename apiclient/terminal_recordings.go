@@ -0,0 +1,48 @@
+package apiclient
+
+import (
+	"context"
+	"time"
+)
+
+type TerminalRecordingInfo struct {
+	Id        string    `json:"recording_id"`
+	SpaceId   string    `json:"space_id"`
+	Username  string    `json:"username"`
+	Source    string    `json:"source"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  float64   `json:"duration"` // seconds
+	Size      int       `json:"size"`     // uncompressed bytes
+	Truncated bool      `json:"truncated"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type TerminalRecordingList struct {
+	Count      int                     `json:"count"`
+	Recordings []TerminalRecordingInfo `json:"recordings"`
+}
+
+// TerminalRecording is a recording along with its asciicast v2 content.
+type TerminalRecording struct {
+	TerminalRecordingInfo
+	Cast string `json:"cast"`
+}
+
+// GetTerminalRecordings lists the recordings held for a space, newest first.
+func (c *ApiClient) GetTerminalRecordings(ctx context.Context, spaceId string) (*TerminalRecordingList, int, error) {
+	response := &TerminalRecordingList{}
+	code, err := c.httpClient.Get(ctx, "/api/spaces/"+spaceId+"/recordings", response)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
+
+func (c *ApiClient) GetTerminalRecording(ctx context.Context, spaceId string, recordingId string) (*TerminalRecording, int, error) {
+	response := &TerminalRecording{}
+	code, err := c.httpClient.Get(ctx, "/api/spaces/"+spaceId+"/recordings/"+recordingId, response)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
@@ -0,0 +1,47 @@
+package apiclient
+
+import (
+	"context"
+	"time"
+)
+
+type TerminalSessionAttachment struct {
+	Id         string    `json:"attachment_id"`
+	UserId     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	ReadOnly   bool      `json:"read_only"`
+	AttachedAt time.Time `json:"attached_at"`
+}
+
+type TerminalSessionInfo struct {
+	Name      string                      `json:"name"`
+	Shell     string                      `json:"shell"`
+	CreatedBy string                      `json:"created_by"`
+	CreatedAt time.Time                   `json:"created_at"`
+	Attached  []TerminalSessionAttachment `json:"attached"`
+}
+
+type TerminalSessionList struct {
+	Count    int                   `json:"count"`
+	Sessions []TerminalSessionInfo `json:"sessions"`
+}
+
+// GetTerminalSessions lists the shared terminal sessions running in a space.
+func (c *ApiClient) GetTerminalSessions(ctx context.Context, spaceId string) (*TerminalSessionList, int, error) {
+	response := &TerminalSessionList{}
+	code, err := c.httpClient.Get(ctx, "/api/spaces/"+spaceId+"/terminal-sessions", response)
+	if err != nil {
+		return nil, code, err
+	}
+	return response, code, nil
+}
+
+// DetachTerminalSession disconnects a user from a shared terminal session.
+func (c *ApiClient) DetachTerminalSession(ctx context.Context, spaceId string, session string, attachmentId string) (int, error) {
+	return c.httpClient.Delete(ctx, "/api/spaces/"+spaceId+"/terminal-sessions/"+session+"/attachments/"+attachmentId, nil, nil, 200)
+}
+
+// CloseTerminalSession ends a shared terminal session and its shell.
+func (c *ApiClient) CloseTerminalSession(ctx context.Context, spaceId string, session string) (int, error) {
+	return c.httpClient.Delete(ctx, "/api/spaces/"+spaceId+"/terminal-sessions/"+session, nil, nil, 200)
+}
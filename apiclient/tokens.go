@@ -12,16 +12,27 @@ type TokenInfo struct {
 	// Scopes restricts which endpoints the token can reach.
 	// nil/empty = unrestricted. Non-empty = limited to the listed scopes.
 	Scopes []string `json:"scopes,omitempty"`
+	// Spaces and Stacks restrict the token to the named spaces and stacks.
+	Spaces []string `json:"spaces,omitempty"`
+	Stacks []string `json:"stacks,omitempty"`
+	// SourceCIDR restricts the addresses the token can be used from.
+	SourceCIDR string `json:"source_cidr,omitempty"`
 }
 
 type CreateTokenRequest struct {
-	Name   string   `json:"name"`
-	Scopes []string `json:"scopes,omitempty"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes,omitempty"`
+	Spaces     []string `json:"spaces,omitempty"`
+	Stacks     []string `json:"stacks,omitempty"`
+	SourceCIDR string   `json:"source_cidr,omitempty"`
 }
 
 type UpdateTokenRequest struct {
-	Name   *string   `json:"name,omitempty"`
-	Scopes *[]string `json:"scopes,omitempty"`
+	Name       *string   `json:"name,omitempty"`
+	Scopes     *[]string `json:"scopes,omitempty"`
+	Spaces     *[]string `json:"spaces,omitempty"`
+	Stacks     *[]string `json:"stacks,omitempty"`
+	SourceCIDR *string   `json:"source_cidr,omitempty"`
 }
 
 type CreateTokenResponse struct {
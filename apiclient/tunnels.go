@@ -1,10 +1,45 @@
 package apiclient
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 type TunnelInfo struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
+	Name    string       `json:"name"`
+	Address string       `json:"address"`
+	Access  TunnelAccess `json:"access"`
+}
+
+// TunnelAccess describes the access policy of a web tunnel, secrets are not
+// included.
+type TunnelAccess struct {
+	RequireLogin bool       `json:"require_login"`
+	BasicAuth    bool       `json:"basic_auth"`
+	BearerToken  bool       `json:"bearer_token"`
+	AllowedIPs   []string   `json:"allowed_ips"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+// TunnelRequest is a request captured by the tunnel server along with the
+// response returned through the tunnel. Bodies are truncated to a fixed size.
+type TunnelRequest struct {
+	Id                    int64               `json:"id"`
+	ReplayOf              int64               `json:"replay_of,omitempty"`
+	Time                  time.Time           `json:"time"`
+	RemoteAddr            string              `json:"remote_addr"`
+	Method                string              `json:"method"`
+	URL                   string              `json:"url"`
+	RequestHeaders        map[string][]string `json:"request_headers"`
+	RequestBody           string              `json:"request_body"`
+	RequestBodyTruncated  bool                `json:"request_body_truncated"`
+	Status                int                 `json:"status"`
+	ResponseHeaders       map[string][]string `json:"response_headers"`
+	ResponseBody          string              `json:"response_body"`
+	ResponseBodyTruncated bool                `json:"response_body_truncated"`
+	DurationMs            int64               `json:"duration_ms"`
+	Error                 string              `json:"error,omitempty"`
 }
 
 type TunnelServerInfo struct {
@@ -34,12 +69,54 @@ func (c *ApiClient) GetTunnelServerInfo(ctx context.Context) (*TunnelServerInfo,
 	return response, code, nil
 }
 
+// GetTunnelRequests returns the recent requests captured for a tunnel, newest first.
+func (c *ApiClient) GetTunnelRequests(ctx context.Context, tunnelName string) ([]TunnelRequest, int, error) {
+	response := []TunnelRequest{}
+
+	code, err := c.httpClient.Get(ctx, "/api/tunnels/"+tunnelName+"/requests", &response)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return response, code, nil
+}
+
+// ClearTunnelRequests discards the requests captured for a tunnel.
+func (c *ApiClient) ClearTunnelRequests(ctx context.Context, tunnelName string) (int, error) {
+	return c.httpClient.Delete(ctx, "/api/tunnels/"+tunnelName+"/requests", nil, nil, 200)
+}
+
+// ReplayTunnelRequest sends a captured request through the tunnel again and
+// returns the new capture.
+func (c *ApiClient) ReplayTunnelRequest(ctx context.Context, tunnelName string, requestId int64) (*TunnelRequest, int, error) {
+	response := &TunnelRequest{}
+
+	code, err := c.httpClient.Post(ctx, fmt.Sprintf("/api/tunnels/%s/requests/%d/replay", tunnelName, requestId), nil, response, 200)
+	if err != nil {
+		return nil, code, err
+	}
+
+	return response, code, nil
+}
+
 // --- Remote web-tunnel management (drives a space's agent-owned tunnels) ---
 
 type SpaceTunnelStartRequest struct {
-	Protocol string `json:"protocol"`
-	Port     uint16 `json:"port"`
-	Name     string `json:"name"`
+	Protocol string             `json:"protocol"`
+	Port     uint16             `json:"port"`
+	Name     string             `json:"name"`
+	Policy   *SpaceTunnelPolicy `json:"policy,omitempty"`
+}
+
+// SpaceTunnelPolicy is the access policy to start a tunnel with, nil leaves
+// the tunnel open.
+type SpaceTunnelPolicy struct {
+	RequireLogin      bool       `json:"require_login,omitempty"`
+	BasicAuthUser     string     `json:"basic_auth_user,omitempty"`
+	BasicAuthPassword string     `json:"basic_auth_password,omitempty"`
+	BearerToken       string     `json:"bearer_token,omitempty"`
+	AllowedIPs        []string   `json:"allowed_ips,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
 }
 
 type SpaceTunnelStartResponse struct {
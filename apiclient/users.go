@@ -36,6 +36,14 @@ type UserResponse struct {
 	UsedComputeUnits           uint32     `json:"used_compute_units"`
 	UsedStorageUnits           uint32     `json:"used_storage_units"`
 	UsedTunnels                uint32     `json:"used_tunnels"`
+
+	ExternalAuthProviders map[string]UserAuthProvider `json:"external_auth_providers"`
+	HasPassword           bool                        `json:"has_password"`
+}
+
+// UserAuthProvider is an external identity linked to a user.
+type UserAuthProvider struct {
+	Username string `json:"username"`
 }
 
 type CreateUserRequest struct {
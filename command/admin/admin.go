@@ -170,6 +170,7 @@ var AdminCmd = &cli.Command{
 		ResetTOTPCmd,
 		BackupCmd,
 		RestoreCmd,
+		MigrateDbCmd,
 		RefreshBaseImagesCmd,
 	},
 	PreRun: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
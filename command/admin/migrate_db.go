@@ -0,0 +1,187 @@
+package commands_admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/backup"
+
+	"github.com/paularlott/cli"
+	cli_toml "github.com/paularlott/cli/toml"
+)
+
+var MigrateDbCmd = &cli.Command{
+	Name:  "migrate-db",
+	Usage: "Copy the database to another driver",
+	Description: `Copy every entity from one database to another, e.g. from BadgerDB to MySQL.
+
+The source and destination are knot configuration files, the database settings are read from the server.mysql, server.badgerdb and server.redis sections. Both servers must be stopped and both must use the same server.encrypt key.
+
+If the migration is interrupted, run the same command again to resume from the checkpoint file.`,
+	MaxArgs: cli.NoArgs,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "The configuration file of the database to copy from.",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "The configuration file of the database to copy to.",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:         "checkpoint",
+			Usage:        "The file used to track progress so an interrupted migration can resume.",
+			DefaultValue: "knot-migrate.checkpoint",
+		},
+		&cli.StringFlag{
+			Name:  "only",
+			Usage: "Comma separated list of entities to copy, defaults to everything.\n\nEntities: " + entityList(),
+		},
+		&cli.StringFlag{
+			Name:  "exclude",
+			Usage: "Comma separated list of entities to skip.",
+		},
+	},
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		kinds, err := backup.ParseKinds(cmd.GetString("only"), cmd.GetString("exclude"))
+		if err != nil {
+			return fmt.Errorf("Error: %w", err)
+		}
+
+		fromCfg, err := loadDatabaseConfig(cmd.GetString("from"))
+		if err != nil {
+			return fmt.Errorf("Error loading source configuration: %w", err)
+		}
+		toCfg, err := loadDatabaseConfig(cmd.GetString("to"))
+		if err != nil {
+			return fmt.Errorf("Error loading destination configuration: %w", err)
+		}
+
+		// Encrypted fields are copied as stored so both sides need the same key
+		if fromCfg.EncryptionKey == "" {
+			fromCfg.EncryptionKey = cmd.GetString("encrypt")
+		}
+		if toCfg.EncryptionKey == "" {
+			toCfg.EncryptionKey = fromCfg.EncryptionKey
+		}
+		if fromCfg.EncryptionKey != toCfg.EncryptionKey {
+			return fmt.Errorf("Error: The source and destination must use the same encryption key.")
+		}
+
+		fmt.Println("Connecting to source database...")
+		src, err := database.Open(fromCfg)
+		if err != nil {
+			return fmt.Errorf("Error connecting to source database: %w", err)
+		}
+
+		fmt.Println("Connecting to destination database...")
+		dst, err := database.Open(toCfg)
+		if err != nil {
+			return fmt.Errorf("Error connecting to destination database: %w", err)
+		}
+
+		// The drivers read the encryption key and audit retention when saving
+		config.SetServerConfig(toCfg)
+
+		summary, err := backup.Migrate(src, dst, &backup.MigrateOptions{
+			Kinds:          kinds,
+			CheckpointFile: cmd.GetString("checkpoint"),
+			OnKind: func(e backup.Entity, resumed int) {
+				if resumed > 0 {
+					fmt.Printf("Copying %s again, %d were copied before the interruption...\n", e.Label(), resumed)
+				} else {
+					fmt.Printf("Copying %s...\n", e.Label())
+				}
+			},
+		})
+
+		if len(summary) > 0 {
+			fmt.Printf("\n%-20s %8s %8s %12s  %s\n", "ENTITY", "COPIED", "SOURCE", "DESTINATION", "STATUS")
+			for _, s := range summary {
+				status := "ok"
+				if s.Skipped != "" {
+					status = "skipped, " + s.Skipped
+				} else if !s.Verified() {
+					status = "MISMATCH"
+				}
+				fmt.Printf("%-20s %8d %8d %12d  %s\n", s.Kind, s.Copied, s.Source, s.Destination, status)
+			}
+			fmt.Println()
+		}
+
+		if err != nil {
+			return fmt.Errorf("Error migrating database: %w", err)
+		}
+
+		fmt.Println("Database migration completed successfully.")
+		return nil
+	},
+}
+
+// loadDatabaseConfig reads the database settings from a knot configuration
+// file, missing settings take the same defaults as the server.
+func loadDatabaseConfig(path string) (*config.ServerConfig, error) {
+	file := cli_toml.NewConfigFile(&path, nil)
+	if err := file.LoadData(); err != nil {
+		return nil, err
+	}
+	typed := cli.NewTypedConfigFile(file)
+
+	str := func(key string, def string) string {
+		if _, ok := typed.GetValue(key); ok {
+			return typed.GetString(key)
+		}
+		return def
+	}
+	num := func(key string, def int) int {
+		if _, ok := typed.GetValue(key); ok {
+			return typed.GetInt(key)
+		}
+		return def
+	}
+
+	redisHosts := typed.GetStringSlice("server.redis.hosts")
+	if len(redisHosts) == 0 {
+		redisHosts = []string{"localhost:6379"}
+	}
+
+	cfg := &config.ServerConfig{
+		EncryptionKey: typed.GetString("server.encrypt"),
+		MySQL: config.MySQLConfig{
+			Enabled:               typed.GetBool("server.mysql.enabled"),
+			Host:                  str("server.mysql.host", "localhost"),
+			Port:                  num("server.mysql.port", 3306),
+			User:                  str("server.mysql.user", "root"),
+			Password:              typed.GetString("server.mysql.password"),
+			Database:              str("server.mysql.database", "knot"),
+			ConnectionMaxIdle:     num("server.mysql.connection_max_idle", 10),
+			ConnectionMaxOpen:     num("server.mysql.connection_max_open", 100),
+			ConnectionMaxLifetime: num("server.mysql.connection_max_lifetime", 5),
+		},
+		BadgerDB: config.BadgerDBConfig{
+			Enabled: typed.GetBool("server.badgerdb.enabled"),
+			Path:    str("server.badgerdb.path", "./badger"),
+		},
+		Redis: config.RedisConfig{
+			Enabled:    typed.GetBool("server.redis.enabled"),
+			Hosts:      redisHosts,
+			Password:   typed.GetString("server.redis.password"),
+			DB:         typed.GetInt("server.redis.db"),
+			MasterName: typed.GetString("server.redis.master_name"),
+			KeyPrefix:  typed.GetString("server.redis.key_prefix"),
+		},
+		Audit: config.AuditConfig{
+			Retention: num("server.audit_retention", 90),
+		},
+	}
+
+	if !cfg.MySQL.Enabled && !cfg.BadgerDB.Enabled && !cfg.Redis.Enabled {
+		return nil, fmt.Errorf("no database enabled in %s", path)
+	}
+
+	return cfg, nil
+}
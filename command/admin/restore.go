@@ -1,15 +1,15 @@
 package commands_admin
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database"
-	"github.com/paularlott/knot/internal/util/crypt"
+	"github.com/paularlott/knot/internal/database/backup"
 
 	"github.com/paularlott/cli"
 )
@@ -17,7 +17,7 @@ import (
 var RestoreCmd = &cli.Command{
 	Name:        "restore",
 	Usage:       "Restore a backup file",
-	Description: "Restore the database from a backup file.",
+	Description: "Restore the database from a backup file.\n\nEntities: " + entityList(),
 	Arguments: []cli.Argument{
 		&cli.StringArg{
 			Name:     "backupfile",
@@ -27,6 +27,20 @@ var RestoreCmd = &cli.Command{
 	},
 	MaxArgs: cli.NoArgs,
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "only",
+			Usage:   "Comma separated list of entities to restore, defaults to everything in the backup.",
+			EnvVars: []string{config.CONFIG_ENV_PREFIX + "_RESTORE_ONLY"},
+		},
+		&cli.StringFlag{
+			Name:    "exclude",
+			Usage:   "Comma separated list of entities to skip.",
+			EnvVars: []string{config.CONFIG_ENV_PREFIX + "_RESTORE_EXCLUDE"},
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Show what would be created or updated without changing the database.",
+		},
 		&cli.StringFlag{
 			Name:    "encrypt-key",
 			Aliases: []string{"e"},
@@ -36,164 +50,77 @@ var RestoreCmd = &cli.Command{
 	},
 	Run: func(ctx context.Context, cmd *cli.Command) error {
 		inputFile := cmd.GetStringArg("backupfile")
-		key := cmd.GetString("encrypt-key")
-		if key != "" && len(key) != 32 {
-			return fmt.Errorf("Error: Encrypt key must be 32 bytes long.")
-		}
+		dryRun := cmd.GetBool("dry-run")
 
-		fmt.Println("Restoring database from file: ", inputFile)
-		db := database.GetInstance()
-		backupData := backupData{}
-
-		// Load the backup file
-		data, err := os.ReadFile(inputFile)
+		kinds, err := backup.ParseKinds(cmd.GetString("only"), cmd.GetString("exclude"))
 		if err != nil {
-			return fmt.Errorf("Error loading backup file: %w", err)
+			return fmt.Errorf("Error: %w", err)
 		}
 
-		if key != "" {
-			// Decrypt the backup file
-			data = []byte(crypt.Decrypt(key, string(data)))
+		key := cmd.GetString("encrypt-key")
+		if key != "" && len(key) != 32 {
+			return fmt.Errorf("Error: Encrypt key must be 32 bytes long.")
 		}
 
-		err = json.Unmarshal(data, &backupData)
+		file, err := os.Open(inputFile)
 		if err != nil {
-			return fmt.Errorf("Error unmarshalling backup file: %w", err)
-		}
-
-		fmt.Println("Restoring audit logs...")
-		for _, auditLog := range backupData.AuditLogs {
-			err := db.SaveAuditLog(auditLog)
-			if err != nil {
-				return fmt.Errorf("Error restoring audit log: %w", err)
-			}
-			fmt.Println("Restored audit log: ", auditLog.Event)
-		}
-
-		fmt.Println("Restoring configuration values...")
-		for _, cfgValue := range backupData.CfgValues {
-			err := db.SaveCfgValue(cfgValue)
-			if err != nil {
-				return fmt.Errorf("Error restoring configuration value: %w", err)
-			}
-			fmt.Println("Restored configuration value: ", cfgValue.Name)
-		}
-
-		fmt.Println("Restoring templates...")
-		for _, template := range backupData.Templates {
-			err := db.SaveTemplate(template, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring template: %w", err)
-			}
-			fmt.Println("Restored template: ", template.Name)
-		}
-
-		fmt.Println("Restoring template variables...")
-		for _, variable := range backupData.TemplateVars {
-			err := db.SaveTemplateVar(variable)
-			if err != nil {
-				return fmt.Errorf("Error restoring template variable: %w", err)
-			}
-			fmt.Println("Restored template variable: ", variable.Name)
-		}
-
-		fmt.Println("Restoring volumes...")
-		for _, volume := range backupData.Volumes {
-			err := db.SaveVolume(volume, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring volume: %w", err)
-			}
-			fmt.Println("Restored volume: ", volume.Name)
-		}
-
-		fmt.Println("Restoring groups...")
-		for _, group := range backupData.Groups {
-			err := db.SaveGroup(group)
-			if err != nil {
-				return fmt.Errorf("Error restoring group: %w", err)
-			}
-			fmt.Println("Restored group: ", group.Name)
+			return fmt.Errorf("Error loading backup file: %w", err)
 		}
+		defer file.Close()
 
-		fmt.Println("Restoring roles...")
-		for _, role := range backupData.Roles {
-			err := db.SaveRole(role)
-			if err != nil {
-				return fmt.Errorf("Error restoring role: %w", err)
-			}
-			fmt.Println("Restored role: ", role.Name)
+		if dryRun {
+			fmt.Println("Comparing database with backup file: ", inputFile)
+		} else {
+			fmt.Println("Restoring database from file: ", inputFile)
 		}
 
-		fmt.Println("Restoring scripts...")
-		for _, script := range backupData.Scripts {
-			err := db.SaveScript(script, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring script: %w", err)
-			}
-			fmt.Println("Restored script: ", script.Name)
-		}
+		opts := &backup.Options{
+			Kinds:  kinds,
+			Key:    key,
+			DryRun: dryRun,
+			OnKind: func(e backup.Entity) {
+				if !dryRun {
+					fmt.Printf("Restoring %s...\n", e.Label())
+				}
+			},
+			OnRecord: func(e backup.Entity, change backup.Change, name string) {
+				if !dryRun {
+					if change != backup.ChangeUnchanged {
+						fmt.Printf("Restored %s: %s\n", strings.TrimSuffix(e.Label(), "s"), name)
+					}
+					return
+				}
 
-		fmt.Println("Restoring skills...")
-		for _, skill := range backupData.Skills {
-			err := db.SaveSkill(skill, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring skill: %w", err)
-			}
-			fmt.Println("Restored skill: ", skill.Name)
+				switch change {
+				case backup.ChangeCreate:
+					fmt.Printf("+ %s %s\n", e.Kind(), name)
+				case backup.ChangeUpdate:
+					fmt.Printf("~ %s %s\n", e.Kind(), name)
+				}
+			},
 		}
 
-		fmt.Println("Restoring slash commands...")
-		for _, command := range backupData.Commands {
-			err := db.SaveCommand(command, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring command: %w", err)
-			}
-			fmt.Println("Restored command: ", command.Name)
+		summary, err := backup.Restore(database.GetInstance(), bufio.NewReader(file), opts)
+		if err != nil {
+			return fmt.Errorf("Error restoring backup: %w", err)
 		}
 
-		fmt.Println("Restoring responses...")
-		for _, response := range backupData.Responses {
-			err := db.SaveResponse(response)
-			if err != nil {
-				return fmt.Errorf("Error restoring response: %w", err)
-			}
-			fmt.Println("Restored response: ", response.Id)
+		fmt.Printf("\n%-20s %8s %8s %10s\n", "ENTITY", "CREATE", "UPDATE", "UNCHANGED")
+		for _, s := range summary {
+			fmt.Printf("%-20s %8d %8d %10d\n", s.Kind, s.Created, s.Updated, s.Unchanged)
 		}
+		fmt.Println()
 
-		fmt.Println("Restoring users...")
-		for _, user := range backupData.Users {
-			err := db.SaveUser(user.User, nil)
-			if err != nil {
-				return fmt.Errorf("Error restoring user: %w", err)
-			}
-			fmt.Println("Restored user: ", user.User.Username)
-
-			// Restore user tokens
-			fmt.Println("Restoring tokens for user: ", user.User.Username)
-			for _, token := range user.Tokens {
-				err = db.SaveToken(token)
-				if err != nil {
-					return fmt.Errorf("Error restoring token for user: %w", err)
-				}
-				fmt.Println("Restored token for user: ", user.User.Username, token.Name)
-			}
-
-			fmt.Println("Restoring spaces for user: ", user.User.Username)
-			for _, space := range user.Spaces {
-				// If started at isn't set then use now
-				if space.StartedAt.IsZero() {
-					space.StartedAt = time.Now().UTC()
-				}
-
-				err := db.SaveSpace(space, nil)
-				if err != nil {
-					return fmt.Errorf("Error restoring space: %w", err)
-				}
-				fmt.Println("Restored space: ", space.Name)
-			}
+		if dryRun {
+			fmt.Println("Dry run complete, no changes were made.")
+		} else {
+			fmt.Println("Database restore completed successfully.")
 		}
-
-		fmt.Println("Database restore completed successfully.")
 		return nil
 	},
 }
+
+// entityList returns the entity names accepted by --only and --exclude.
+func entityList() string {
+	return strings.Join(backup.Kinds(), ", ")
+}
@@ -0,0 +1,81 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paularlott/cli"
+	"github.com/paularlott/knot/internal/tunnel_server"
+)
+
+// TunnelPolicyFlags are the flags setting the access policy of a web tunnel.
+func TunnelPolicyFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "require-login",
+			Usage: "Require visitors to log in to knot before accessing the tunnel.",
+		},
+		&cli.StringFlag{
+			Name:  "basic-auth",
+			Usage: "Require HTTP basic authentication, given as <user>:<password>.",
+		},
+		&cli.StringFlag{
+			Name:  "bearer-token",
+			Usage: "Require requests to present this token in an Authorization: Bearer header.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "allow-ip",
+			Usage: "Only allow requests from this IP address or CIDR, can be given multiple times or as a comma separated list.",
+		},
+		&cli.StringFlag{
+			Name:  "expires",
+			Usage: "Close the tunnel after this duration, e.g. 30m or 8h.",
+		},
+	}
+}
+
+// TunnelPolicyFromFlags builds the access policy from the command flags, nil
+// is returned if no restrictions are given.
+func TunnelPolicyFromFlags(cmd *cli.Command) (*tunnel_server.TunnelPolicy, error) {
+	policy := &tunnel_server.TunnelPolicy{
+		RequireLogin: cmd.GetBool("require-login"),
+		BearerToken:  cmd.GetString("bearer-token"),
+	}
+
+	if basicAuth := cmd.GetString("basic-auth"); basicAuth != "" {
+		user, password, ok := strings.Cut(basicAuth, ":")
+		if !ok || user == "" || password == "" {
+			return nil, fmt.Errorf("Invalid basic auth, must be given as <user>:<password>")
+		}
+		policy.BasicAuthUser = user
+		policy.BasicAuthPassword = password
+	}
+
+	for _, allow := range cmd.GetStringSlice("allow-ip") {
+		for _, entry := range strings.Split(allow, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				policy.AllowedIPs = append(policy.AllowedIPs, entry)
+			}
+		}
+	}
+
+	if expires := cmd.GetString("expires"); expires != "" {
+		duration, err := time.ParseDuration(expires)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("Invalid expiry duration %s", expires)
+		}
+		expiresAt := time.Now().Add(duration).UTC()
+		policy.ExpiresAt = &expiresAt
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid access policy: %w", err)
+	}
+
+	if policy.IsOpen() {
+		return nil, nil
+	}
+
+	return policy, nil
+}
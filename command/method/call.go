@@ -63,12 +63,13 @@ func callSingle(ctx context.Context, client *apiclient.ApiClient, methodName, pa
 		return fmt.Errorf("invalid params JSON: %w", err)
 	}
 
-	response, err := client.CallMethod(ctx, &methods.JSONRPCRequest{
+	// Progress from streaming methods goes to stderr so stdout only holds the response
+	response, err := client.CallMethodStream(ctx, &methods.JSONRPCRequest{
 		JSONRPC: "2.0",
 		Method:  methodName,
 		Params:  raw,
 		ID:      1,
-	})
+	}, printProgress)
 	if err != nil {
 		return err
 	}
@@ -80,6 +81,28 @@ func callSingle(ctx context.Context, client *apiclient.ApiClient, methodName, pa
 	return nil
 }
 
+// printProgress writes a progress notification to stderr, partial output is
+// written as is.
+func printProgress(progress *methods.Progress) {
+	if progress.Message != "" {
+		if progress.Total > 0 {
+			fmt.Fprintf(os.Stderr, "[%g/%g] %s\n", progress.Progress, progress.Total, progress.Message)
+		} else {
+			fmt.Fprintln(os.Stderr, progress.Message)
+		}
+	}
+
+	switch data := progress.Data.(type) {
+	case nil:
+	case string:
+		fmt.Fprint(os.Stderr, data)
+	default:
+		if encoded, err := json.Marshal(data); err == nil {
+			fmt.Fprintln(os.Stderr, string(encoded))
+		}
+	}
+}
+
 func callBatch(ctx context.Context, client *apiclient.ApiClient, methodName, params string) error {
 	if params == "" {
 		return fmt.Errorf("--batch requires a JSON array of params objects")
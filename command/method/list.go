@@ -69,6 +69,7 @@ func showMethodDetail(methods []methods.MethodInfo, name string) error {
 			fmt.Printf("Groups:        %v\n", m.Groups)
 		}
 		fmt.Printf("MCP Tool:      %s\n", boolStr(m.MCPTool, "Yes", "No"))
+		fmt.Printf("Streaming:     %s\n", boolStr(m.Streaming, "Yes", "No"))
 		if m.ProviderCount > 1 {
 			fmt.Printf("Providers:     %d spaces\n", m.ProviderCount)
 		}
@@ -0,0 +1,117 @@
+package command_pool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paularlott/cli"
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/command/cmdutil"
+)
+
+var AutoscaleCmd = &cli.Command{
+	Name:        "autoscale",
+	Usage:       "Configure autoscaling for a pool",
+	Description: "Enable, update or disable metrics-driven autoscaling for a pool. Settings not given keep their current value; the leader's sweep resizes the pool between the minimum and maximum to hold the CPU, requests per second and application metric targets. Application metrics are those pushed by the spaces to the agent's OTLP /v1/metrics endpoint.",
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "pool",
+			Usage:    "The name or ID of the pool",
+			Required: true,
+		},
+	},
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "min",
+			Usage: "The minimum number of spaces.",
+		},
+		&cli.IntFlag{
+			Name:  "max",
+			Usage: "The maximum number of spaces.",
+		},
+		&cli.Float64Flag{
+			Name:  "target-cpu",
+			Usage: "The average CPU percent to hold the members at, 0 to ignore CPU.",
+		},
+		&cli.Float64Flag{
+			Name:  "target-rps",
+			Usage: "The requests per second each member should serve, 0 to ignore requests.",
+		},
+		&cli.StringFlag{
+			Name:  "target-metric",
+			Usage: "The application metric to scale on, empty to ignore application metrics.",
+		},
+		&cli.Float64Flag{
+			Name:  "target-metric-value",
+			Usage: "The average value of the application metric to hold the members at.",
+		},
+		&cli.Uint32Flag{
+			Name:  "scale-up-cooldown",
+			Usage: "Seconds to wait after scaling before scaling up again.",
+		},
+		&cli.Uint32Flag{
+			Name:  "scale-down-cooldown",
+			Usage: "Seconds to wait after scaling before scaling down again.",
+		},
+		&cli.BoolFlag{
+			Name:  "disable",
+			Usage: "Turn autoscaling off, the pool keeps its current size.",
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		poolName := cmd.GetStringArg("pool")
+
+		client, err := cmdutil.GetClient(cmd)
+		if err != nil {
+			return fmt.Errorf("Failed to create API client: %w", err)
+		}
+
+		pool, code, err := client.GetPool(context.Background(), poolName)
+		if err != nil {
+			return fmt.Errorf("Error getting pool: %w (code %d)", err, code)
+		}
+
+		autoscale := pool.Autoscale
+		autoscale.Enabled = !cmd.GetBool("disable")
+		if cmd.HasFlag("min") {
+			autoscale.MinCount = cmd.GetInt("min")
+		}
+		if cmd.HasFlag("max") {
+			autoscale.MaxCount = cmd.GetInt("max")
+		}
+		if cmd.HasFlag("target-cpu") {
+			autoscale.TargetCPUPercent = cmd.GetFloat64("target-cpu")
+		}
+		if cmd.HasFlag("target-rps") {
+			autoscale.TargetRPSPerMember = cmd.GetFloat64("target-rps")
+		}
+		if cmd.HasFlag("target-metric") {
+			autoscale.TargetMetric = cmd.GetString("target-metric")
+			if autoscale.TargetMetric == "" {
+				autoscale.TargetMetricValue = 0
+			}
+		}
+		if cmd.HasFlag("target-metric-value") {
+			autoscale.TargetMetricValue = cmd.GetFloat64("target-metric-value")
+		}
+		if cmd.HasFlag("scale-up-cooldown") {
+			autoscale.ScaleUpCooldown = cmd.GetUint32("scale-up-cooldown")
+		}
+		if cmd.HasFlag("scale-down-cooldown") {
+			autoscale.ScaleDownCooldown = cmd.GetUint32("scale-down-cooldown")
+		}
+
+		code, err = client.PatchPool(context.Background(), poolName, &apiclient.PoolUpdateRequest{Autoscale: &autoscale})
+		if err != nil {
+			return fmt.Errorf("Error updating pool autoscaling: %w (code %d)", err, code)
+		}
+
+		if !autoscale.Enabled {
+			fmt.Printf("Autoscaling disabled for pool %q.\n", poolName)
+			return nil
+		}
+		fmt.Printf("Pool %q autoscales between %d and %d spaces.\n", poolName, autoscale.MinCount, autoscale.MaxCount)
+		return nil
+	},
+}
@@ -37,13 +37,17 @@ var ListCmd = &cli.Command{
 		}
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "NAME\tSTATE\tALIVE/DESIRED\tTEMPLATE")
+		fmt.Fprintln(w, "NAME\tSTATE\tALIVE/DESIRED\tAUTOSCALE\tTEMPLATE")
 		for _, p := range pools.Pools {
 			state := "stopped"
 			if p.Active {
 				state = "active"
 			}
-			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", p.Name, state, p.AliveMembers, p.DesiredCount, p.TemplateId)
+			autoscale := "off"
+			if p.Autoscale.Enabled {
+				autoscale = fmt.Sprintf("%d-%d", p.Autoscale.MinCount, p.Autoscale.MaxCount)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%s\n", p.Name, state, p.AliveMembers, p.DesiredCount, autoscale, p.TemplateId)
 		}
 		w.Flush()
 		return nil
@@ -46,5 +46,6 @@ var PoolCmd = &cli.Command{
 		StopCmd,
 		DeleteCmd,
 		SetSizeCmd,
+		AutoscaleCmd,
 	},
 }
@@ -25,16 +25,20 @@ import (
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/dns"
+	"github.com/paularlott/knot/internal/ldap"
 	knotlmchatkit "github.com/paularlott/knot/internal/lmchatkit"
 	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/methods"
 	"github.com/paularlott/knot/internal/middleware"
+	"github.com/paularlott/knot/internal/oidc"
 	"github.com/paularlott/knot/internal/openai"
 	"github.com/paularlott/knot/internal/proxy"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/specwizard"
+	"github.com/paularlott/knot/internal/sshca"
 	"github.com/paularlott/knot/internal/sse"
 	"github.com/paularlott/knot/internal/systemprompt"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/tunnel_server"
 	"github.com/paularlott/knot/internal/util"
 	"github.com/paularlott/knot/internal/util/audit"
@@ -47,6 +51,7 @@ import (
 	"github.com/paularlott/lmchatkit"
 	"github.com/paularlott/mcp"
 	ai "github.com/paularlott/mcp/ai"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var ServerCmd = &cli.Command{
@@ -99,6 +104,27 @@ var ServerCmd = &cli.Command{
 			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_WEBGL"},
 			DefaultValue: true,
 		},
+		&cli.IntFlag{
+			Name:         "terminal-recording-retention",
+			Usage:        "The number of days to keep terminal session recordings.",
+			ConfigPath:   []string{"server.terminal.recording_retention"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_TERMINAL_RECORDING_RETENTION"},
+			DefaultValue: 30,
+		},
+		&cli.IntFlag{
+			Name:         "terminal-recording-max-size",
+			Usage:        "The maximum size of a terminal session recording in MB.",
+			ConfigPath:   []string{"server.terminal.recording_max_size"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_TERMINAL_RECORDING_MAX_SIZE"},
+			DefaultValue: 16,
+		},
+		&cli.IntFlag{
+			Name:         "space-log-retention",
+			Usage:        "The number of days to keep space logs, 0 to only keep recent logs in memory.",
+			ConfigPath:   []string{"server.space_log_retention"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_SPACE_LOG_RETENTION"},
+			DefaultValue: 7,
+		},
 		&cli.StringFlag{
 			Name:         "download-path",
 			Usage:        "The path to serve download files from if set.",
@@ -202,6 +228,39 @@ var ServerCmd = &cli.Command{
 			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_AUDIT_STREAM"},
 			DefaultValue: "audit",
 		},
+		&cli.BoolFlag{
+			Name:         "metrics-enabled",
+			Usage:        "Expose Prometheus metrics on /metrics.",
+			ConfigPath:   []string{"server.metrics.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_METRICS_ENABLED"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:       "metrics-token",
+			Usage:      "Bearer token Prometheus must send to scrape /metrics, required when metrics are enabled.",
+			ConfigPath: []string{"server.metrics.token"},
+			EnvVars:    []string{config.CONFIG_ENV_PREFIX + "_METRICS_TOKEN"},
+		},
+		&cli.BoolFlag{
+			Name:         "tracing-enabled",
+			Usage:        "Export OpenTelemetry traces over OTLP/HTTP.",
+			ConfigPath:   []string{"server.tracing.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_TRACING_ENABLED"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:       "tracing-endpoint",
+			Usage:      "The OTLP/HTTP collector URL e.g. http://localhost:4318, if not given the standard OTEL_EXPORTER_OTLP_* variables are used.",
+			ConfigPath: []string{"server.tracing.endpoint"},
+			EnvVars:    []string{config.CONFIG_ENV_PREFIX + "_TRACING_ENDPOINT"},
+		},
+		&cli.Float64Flag{
+			Name:         "tracing-sample-ratio",
+			Usage:        "The fraction of new traces to record, between 0 and 1.",
+			ConfigPath:   []string{"server.tracing.sample_ratio"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_TRACING_SAMPLE_RATIO"},
+			DefaultValue: 1.0,
+		},
 		&cli.IntFlag{
 			Name:         "mcp-tool-timeout",
 			Usage:        "The maximum execution time in seconds for MCP tool calls (allows for LLM operations with tool calling).",
@@ -223,6 +282,12 @@ var ServerCmd = &cli.Command{
 			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_AUTH_IP_RATE_LIMITING"},
 			DefaultValue: true,
 		},
+		&cli.StringSliceFlag{
+			Name:       "trusted-proxies",
+			Usage:      "CIDRs of reverse proxies whose X-Forwarded-For header is trusted for the client address, can be given multiple times.",
+			ConfigPath: []string{"server.trusted_proxies"},
+			EnvVars:    []string{config.CONFIG_ENV_PREFIX + "_TRUSTED_PROXIES"},
+		},
 		&cli.StringFlag{
 			Name:         "public-files-path",
 			Usage:        "The path to the a directory to serve as /public-files.",
@@ -389,6 +454,138 @@ var ServerCmd = &cli.Command{
 			DefaultValue: "Knot",
 		},
 
+		// LDAP flags
+		&cli.BoolFlag{
+			Name:         "ldap-enabled",
+			Usage:        "Authenticate users against an LDAP or Active Directory server.",
+			ConfigPath:   []string{"server.ldap.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_ENABLED"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:         "ldap-url",
+			Usage:        "The LDAP server URL, ldaps://host:636 or ldap://host:389 with StartTLS.",
+			ConfigPath:   []string{"server.ldap.url"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_URL"},
+			DefaultValue: "",
+		},
+		&cli.BoolFlag{
+			Name:         "ldap-start-tls",
+			Usage:        "Upgrade ldap:// connections with StartTLS.",
+			ConfigPath:   []string{"server.ldap.start_tls"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_START_TLS"},
+			DefaultValue: true,
+		},
+		&cli.BoolFlag{
+			Name:         "ldap-skip-verify",
+			Usage:        "Skip TLS verification when talking to the LDAP server.",
+			ConfigPath:   []string{"server.ldap.skip_verify"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_SKIP_VERIFY"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:         "ldap-bind-dn",
+			Usage:        "The DN of the service account used to search the directory.",
+			ConfigPath:   []string{"server.ldap.bind_dn"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_BIND_DN"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-bind-password",
+			Usage:        "The password of the service account used to search the directory.",
+			ConfigPath:   []string{"server.ldap.bind_password"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_BIND_PASSWORD"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-base-dn",
+			Usage:        "The DN to search for users under.",
+			ConfigPath:   []string{"server.ldap.base_dn"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_BASE_DN"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-user-filter",
+			Usage:        "The filter to find a user, {email} is replaced by the email address they sign in with.",
+			ConfigPath:   []string{"server.ldap.user_filter"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_USER_FILTER"},
+			DefaultValue: "(&(objectClass=person)(mail={email}))",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-username-attribute",
+			Usage:        "The attribute holding the username for new users.",
+			ConfigPath:   []string{"server.ldap.username_attribute"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_USERNAME_ATTRIBUTE"},
+			DefaultValue: "uid",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-email-attribute",
+			Usage:        "The attribute holding the user's email address.",
+			ConfigPath:   []string{"server.ldap.email_attribute"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_EMAIL_ATTRIBUTE"},
+			DefaultValue: "mail",
+		},
+		&cli.StringFlag{
+			Name:         "ldap-group-attribute",
+			Usage:        "The attribute listing the DNs of the user's groups.",
+			ConfigPath:   []string{"server.ldap.group_attribute"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_GROUP_ATTRIBUTE"},
+			DefaultValue: "memberOf",
+		},
+		&cli.BoolFlag{
+			Name:         "ldap-allow-signup",
+			Usage:        "Create users on their first LDAP login.",
+			ConfigPath:   []string{"server.ldap.allow_signup"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_ALLOW_SIGNUP"},
+			DefaultValue: false,
+		},
+		&cli.BoolFlag{
+			Name:         "ldap-link-by-email",
+			Usage:        "Link a first LDAP login to the existing user with the same email.",
+			ConfigPath:   []string{"server.ldap.link_by_email"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_LINK_BY_EMAIL"},
+			DefaultValue: false,
+		},
+		&cli.IntFlag{
+			Name:         "ldap-sync-interval",
+			Usage:        "Minutes between directory syncs of LDAP users, 0 to disable.",
+			ConfigPath:   []string{"server.ldap.sync_interval"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_LDAP_SYNC_INTERVAL"},
+			DefaultValue: 60,
+		},
+
+		// SCIM flags
+		&cli.BoolFlag{
+			Name:         "scim-enabled",
+			Usage:        "Enable SCIM 2.0 user and group provisioning on /scim/v2.",
+			ConfigPath:   []string{"server.scim.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_SCIM_ENABLED"},
+			DefaultValue: false,
+		},
+
+		// SSH certificate authority flags
+		&cli.BoolFlag{
+			Name:         "ssh-ca-enabled",
+			Usage:        "Issue SSH certificates for space access and sign space host keys.",
+			ConfigPath:   []string{"server.ssh_ca.enabled"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_SSH_CA_ENABLED"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:         "ssh-ca-key-file",
+			Usage:        "The file with the SSH CA private key, if not given the key is derived from the encryption key.",
+			ConfigPath:   []string{"server.ssh_ca.key_file"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_SSH_CA_KEY_FILE"},
+			DefaultValue: "",
+		},
+		&cli.IntFlag{
+			Name:         "ssh-ca-cert-ttl",
+			Usage:        "The maximum lifetime of SSH user certificates in minutes.",
+			ConfigPath:   []string{"server.ssh_ca.cert_ttl"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_SSH_CA_CERT_TTL"},
+			DefaultValue: 60,
+		},
+
 		// TLS flags
 		&cli.StringFlag{
 			Name:         "cert-file",
@@ -456,6 +653,71 @@ var ServerCmd = &cli.Command{
 			DefaultValue: "",
 		},
 
+		// Kubernetes flags
+		&cli.StringFlag{
+			Name:         "kubernetes-host",
+			Usage:        "The address of the Kubernetes API server, the in-cluster service account is used if not given.",
+			ConfigPath:   []string{"server.kubernetes.host"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_HOST"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-token",
+			Usage:        "The bearer token to use for Kubernetes API requests.",
+			ConfigPath:   []string{"server.kubernetes.token"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_TOKEN"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-ca-cert",
+			Usage:        "The CA certificate file used to verify the Kubernetes API server.",
+			ConfigPath:   []string{"server.kubernetes.ca_cert"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_CA_CERT"},
+			DefaultValue: "",
+		},
+		&cli.BoolFlag{
+			Name:         "kubernetes-skip-tls-verify",
+			Usage:        "Skip TLS verification when talking to the Kubernetes API server.",
+			ConfigPath:   []string{"server.kubernetes.skip_tls_verify"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_SKIP_TLS_VERIFY"},
+			DefaultValue: false,
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-namespace",
+			Usage:        "The namespace to deploy spaces into when the namespace mode is fixed.",
+			ConfigPath:   []string{"server.kubernetes.namespace"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_NAMESPACE"},
+			DefaultValue: "default",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-namespace-mode",
+			Usage:        "How spaces are assigned to namespaces, one of fixed, user or template.",
+			ConfigPath:   []string{"server.kubernetes.namespace_mode"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_NAMESPACE_MODE"},
+			DefaultValue: "fixed",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-namespace-prefix",
+			Usage:        "The prefix for namespaces created per user or per template.",
+			ConfigPath:   []string{"server.kubernetes.namespace_prefix"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_NAMESPACE_PREFIX"},
+			DefaultValue: "knot-",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-cpu-per-compute-unit",
+			Usage:        "The CPU request given to a space for each template compute unit, e.g. 250m.",
+			ConfigPath:   []string{"server.kubernetes.cpu_per_compute_unit"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_CPU_PER_COMPUTE_UNIT"},
+			DefaultValue: "",
+		},
+		&cli.StringFlag{
+			Name:         "kubernetes-memory-per-compute-unit",
+			Usage:        "The memory request given to a space for each template compute unit, e.g. 512Mi.",
+			ConfigPath:   []string{"server.kubernetes.memory_per_compute_unit"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_KUBERNETES_MEMORY_PER_COMPUTE_UNIT"},
+			DefaultValue: "",
+		},
+
 		// MySQL flags
 		&cli.BoolFlag{
 			Name:         "mysql-enabled",
@@ -805,9 +1067,22 @@ var ServerCmd = &cli.Command{
 			logger.Fatal("agent endpoint not given")
 		}
 
+		// The metrics name users and spaces so they are never served openly
+		if cfg.Metrics.Enabled && cfg.Metrics.Token == "" {
+			logger.Fatal("metrics are enabled but no metrics token given")
+		}
+
 		logger.Info("starting knot version", "version", build.Version)
 		logger.Info("starting on", "listen", listen)
 
+		// Start exporting traces before anything can start a span
+		shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing, "knot-server",
+			attribute.String("knot.zone", cfg.Zone),
+		)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to initialize tracing")
+		}
+
 		// Initialize the API helpers
 		service.SetUserService(api_utils.NewApiUtilsUsers())
 		service.SetContainerService(containerHelper.NewContainerHelper())
@@ -824,6 +1099,10 @@ var ServerCmd = &cli.Command{
 			service.StartConversationRetentionSweep()
 		}
 
+		if cfg.Metrics.Enabled {
+			api.StartMetricsRefresh(cfg.Zone)
+		}
+
 		// Load roles into memory cache
 		roles, err := database.GetInstance().GetRoles()
 		if err != nil {
@@ -890,6 +1169,12 @@ var ServerCmd = &cli.Command{
 		routes := http.NewServeMux()
 		rest.SetAPIMux(routes)
 
+		oidc.Init(cfg.OIDC.Providers)
+		ldap.StartSync()
+		if err := sshca.Init(cfg); err != nil {
+			logger.WithError(err).Fatal("failed to initialize SSH certificate authority")
+		}
+
 		api.ApiRoutes(routes)
 		proxy.Routes(routes, cfg)
 		web.Routes(routes, cfg)
@@ -905,6 +1190,7 @@ var ServerCmd = &cli.Command{
 			// Record the server so other packages (e.g. script CRUD handlers) can
 			// emit listChanged notifications when the tool set changes.
 			internal_mcp.SetServer(mcpServer)
+			service.SetSpaceListChangedHandler(internal_mcp.NotifyResourcesChanged)
 			if !mcpEnabled {
 				logger.Debug("MCP chat-only mode")
 			} else {
@@ -915,6 +1201,7 @@ var ServerCmd = &cli.Command{
 		// If AI chat enabled then initialize chat service
 		// Note: ChatEnabled now implies OpenAI endpoints are also enabled for web chat
 		var openAIClient ai.Client
+		var chatService *chat.Service
 		if chatEnabled || openaiEndpointEnabled {
 			logger.Info("AI chat enabled")
 
@@ -928,7 +1215,8 @@ var ServerCmd = &cli.Command{
 
 			// Initialize chat service with main MCP server
 			// The OpenAI client will use forced on-demand mode via context
-			chatService, err := chat.NewService(cfg.Chat, mcpServer)
+			var err error
+			chatService, err = chat.NewService(cfg.Chat, mcpServer)
 			if err != nil {
 				logger.WithError(err).Fatal("failed to create chat service:")
 			}
@@ -969,7 +1257,7 @@ var ServerCmd = &cli.Command{
 				return nil
 			}
 
-			openaiService := openai.NewService(openAIClient, cfg.Chat.SystemPrompt, cfg.Chat.Model)
+			openaiService := openai.NewService(openAIClient, cfg.Chat.SystemPrompt, chatService.GetRouter().DefaultModel())
 			// Apply MCP server context middleware AFTER auth middleware (so user is available in context)
 			routes.Handle("GET /v1/models", middleware.ApiAuth(middleware.ApiPermissionUseWebAssistant(middleware.HandlerToHandlerFunc(middleware.MCPServerContext(mcpServer, scriptToolsProvider)(http.HandlerFunc(openaiService.HandleGetModels))))))
 			routes.Handle("POST /v1/chat/completions", middleware.ApiAuth(middleware.ApiPermissionUseWebAssistant(middleware.HandlerToHandlerFunc(middleware.MCPServerContext(mcpServer, scriptToolsProvider)(http.HandlerFunc(openaiService.HandleChatCompletions))))))
@@ -979,13 +1267,17 @@ var ServerCmd = &cli.Command{
 			routes.Handle("POST /v1/responses/{response_id}/cancel", middleware.ApiAuth(middleware.ApiPermissionUseWebAssistant(middleware.HandlerToHandlerFunc(middleware.MCPServerContext(mcpServer, scriptToolsProvider)(http.HandlerFunc(openaiService.HandleCancelResponse))))))
 
 			// Mount lmchatkit UI — uses lmchatkit.StandardHost (same as
-			// llmrouter) with the LLM endpoint configured in [server.chat].
+			// llmrouter) talking to the chat router's loopback proxy.
 			// StandardHost streams the raw OpenAI response via
 			// TranslateOpenAIStream, which is required because the MCP AI
 			// client suppresses tool-call delta chunks when MCP servers are
 			// present. Per-user tools are injected by AuthMiddleware.
-			chatHost := knotlmchatkit.NewHost(cfg.Chat, mcpServer, scriptToolsProvider)
+			chatHost, err := knotlmchatkit.NewHost(cfg.Chat, chatService.GetRouter(), mcpServer, scriptToolsProvider)
+			if err != nil {
+				logger.WithError(err).Fatal("failed to create chat host:")
+			}
 			chatAuthMiddleware := knotlmchatkit.AuthMiddleware(
+				chatService.GetRouter(),
 				func(next http.Handler) http.Handler {
 					return middleware.ApiAuth(middleware.ApiPermissionUseWebAssistant(middleware.HandlerToHandlerFunc(next)))
 				},
@@ -1093,6 +1385,8 @@ var ServerCmd = &cli.Command{
 				} else {
 					if r.URL.Path == "/health" {
 						web.HandleHealthPage(w, r)
+					} else if r.URL.Path == "/metrics" && cfg.Metrics.Enabled {
+						api.HandleMetrics(w, r)
 					} else {
 						http.NotFound(w, r)
 					}
@@ -1105,6 +1399,10 @@ var ServerCmd = &cli.Command{
 			router = appRoutes
 		}
 
+		if cfg.Tracing.Enabled {
+			router = tracing.Middleware(router)
+		}
+
 		var tlsConfig *tls.Config = nil
 
 		// If server should use TLS
@@ -1300,6 +1598,11 @@ var ServerCmd = &cli.Command{
 		// used by scriptling.plugin scopes.
 		service.ClosePluginManager()
 
+		// Flush any spans still waiting to be exported
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		shutdownTracing(ctx)
+		cancel()
+
 		fmt.Print("\r")
 		logger.Info("shutdown")
 		return nil
@@ -1360,12 +1663,20 @@ func buildServerConfig(cmd *cli.Command) *config.ServerConfig {
 		TunnelDomain:       cmd.GetString("tunnel-domain"),
 		TunnelServer:       cmd.GetString("tunnel-server"),
 		TerminalWebGL:      cmd.GetBool("terminal-webgl"),
+		TerminalRecording: config.TerminalRecordingConfig{
+			Retention: cmd.GetInt("terminal-recording-retention"),
+			MaxSize:   cmd.GetInt("terminal-recording-max-size"),
+		},
+		SpaceLogs: config.SpaceLogConfig{
+			Retention: cmd.GetInt("space-log-retention"),
+		},
 		EncryptionKey:      cmd.GetString("encrypt"),
 		Zone:               zone,
 		Hostname:           hostname,
 		Timezone:           cmd.GetString("timezone"),
 		LeafNode:           cmd.GetString("origin-server") != "" && cmd.GetString("origin-token") != "",
 		AuthIPRateLimiting: cmd.GetBool("auth-ip-rate-limiting"),
+		TrustedProxies:     cmd.GetStringSlice("trusted-proxies"),
 		DNSEnabled:         cmd.GetBool("dns-enabled"),
 		DNSListen:          cmd.GetString("dns-listen"),
 		Nameservers:        cmd.GetStringSlice("nameservers"),
@@ -1379,6 +1690,82 @@ func buildServerConfig(cmd *cli.Command) *config.ServerConfig {
 			Window:  cmd.GetInt("totp-window"),
 			Issuer:  cmd.GetString("totp-issuer"),
 		},
+		OIDC: func() config.OIDCConfig {
+			oidcConfig := config.OIDCConfig{}
+
+			// Providers are only read from the TOML configuration
+			if cmd.ConfigFile.FileUsed() != "" {
+				typedConfig := cli.NewTypedConfigFile(cmd.ConfigFile)
+				for _, provider := range typedConfig.GetObjectSlice("server.oidc.providers") {
+					providerConfig := config.OIDCProviderConfig{
+						Id:           provider.GetString("id"),
+						Name:         provider.GetString("name"),
+						Issuer:       provider.GetString("issuer"),
+						ClientId:     provider.GetString("client_id"),
+						ClientSecret: provider.GetString("client_secret"),
+						Scopes:       provider.GetStringSlice("scopes"),
+						GroupsClaim:  provider.GetString("groups_claim"),
+						AllowSignup:  provider.GetBool("allow_signup"),
+						LinkByEmail:  provider.GetBool("link_by_email"),
+					}
+					for _, mapping := range provider.GetObjectSlice("group_mappings") {
+						providerConfig.GroupMappings = append(providerConfig.GroupMappings, config.OIDCGroupMapping{
+							Claim:  mapping.GetString("claim"),
+							Groups: mapping.GetStringSlice("groups"),
+							Roles:  mapping.GetStringSlice("roles"),
+						})
+					}
+					if providerConfig.Id == "" || providerConfig.Issuer == "" || providerConfig.ClientId == "" {
+						logger.Warn("ignoring OIDC provider without id, issuer or client_id", "id", providerConfig.Id)
+						continue
+					}
+					oidcConfig.Providers = append(oidcConfig.Providers, providerConfig)
+				}
+			}
+
+			return oidcConfig
+		}(),
+		LDAP: config.LDAPConfig{
+			Enabled:           cmd.GetBool("ldap-enabled"),
+			URL:               cmd.GetString("ldap-url"),
+			StartTLS:          cmd.GetBool("ldap-start-tls"),
+			SkipVerify:        cmd.GetBool("ldap-skip-verify"),
+			BindDN:            cmd.GetString("ldap-bind-dn"),
+			BindPassword:      cmd.GetString("ldap-bind-password"),
+			BaseDN:            cmd.GetString("ldap-base-dn"),
+			UserFilter:        cmd.GetString("ldap-user-filter"),
+			UsernameAttribute: cmd.GetString("ldap-username-attribute"),
+			EmailAttribute:    cmd.GetString("ldap-email-attribute"),
+			GroupAttribute:    cmd.GetString("ldap-group-attribute"),
+			AllowSignup:       cmd.GetBool("ldap-allow-signup"),
+			LinkByEmail:       cmd.GetBool("ldap-link-by-email"),
+			SyncInterval:      cmd.GetInt("ldap-sync-interval"),
+			GroupMappings: func() []config.LDAPGroupMapping {
+				var mappings []config.LDAPGroupMapping
+
+				// Mappings are only read from the TOML configuration
+				if cmd.ConfigFile.FileUsed() != "" {
+					typedConfig := cli.NewTypedConfigFile(cmd.ConfigFile)
+					for _, mapping := range typedConfig.GetObjectSlice("server.ldap.group_mappings") {
+						mappings = append(mappings, config.LDAPGroupMapping{
+							Group:  mapping.GetString("group"),
+							Groups: mapping.GetStringSlice("groups"),
+							Roles:  mapping.GetStringSlice("roles"),
+						})
+					}
+				}
+
+				return mappings
+			}(),
+		},
+		SCIM: config.SCIMConfig{
+			Enabled: cmd.GetBool("scim-enabled"),
+		},
+		SSHCA: config.SSHCAConfig{
+			Enabled: cmd.GetBool("ssh-ca-enabled"),
+			KeyFile: cmd.GetString("ssh-ca-key-file"),
+			CertTTL: cmd.GetInt("ssh-ca-cert-ttl"),
+		},
 		UI: config.UIConfig{
 			HideSupportLinks:   cmd.GetBool("hide-support-links"),
 			HideAPITokens:      cmd.GetBool("hide-api-tokens"),
@@ -1425,6 +1812,15 @@ func buildServerConfig(cmd *cli.Command) *config.ServerConfig {
 			Routing:     cmd.GetString("audit-routing"),
 			AuditStream: cmd.GetString("audit-stream"),
 		},
+		Tracing: config.TracingConfig{
+			Enabled:     cmd.GetBool("tracing-enabled"),
+			Endpoint:    cmd.GetString("tracing-endpoint"),
+			SampleRatio: cmd.GetFloat64("tracing-sample-ratio"),
+		},
+		Metrics: config.MetricsConfig{
+			Enabled: cmd.GetBool("metrics-enabled"),
+			Token:   cmd.GetString("metrics-token"),
+		},
 		Docker: config.DockerConfig{
 			Host: cmd.GetString("docker-host"),
 		},
@@ -1440,6 +1836,17 @@ func buildServerConfig(cmd *cli.Command) *config.ServerConfig {
 			DC:     envFallback(cmd.GetString("nomad-dc"), "NOMAD_DC"),
 			Region: envFallback(cmd.GetString("nomad-region"), "NOMAD_REGION"),
 		},
+		Kubernetes: config.KubernetesConfig{
+			Host:                 cmd.GetString("kubernetes-host"),
+			Token:                cmd.GetString("kubernetes-token"),
+			CACertFile:           cmd.GetString("kubernetes-ca-cert"),
+			SkipTLSVerify:        cmd.GetBool("kubernetes-skip-tls-verify"),
+			Namespace:            cmd.GetString("kubernetes-namespace"),
+			NamespaceMode:        cmd.GetString("kubernetes-namespace-mode"),
+			NamespacePrefix:      cmd.GetString("kubernetes-namespace-prefix"),
+			CPUPerComputeUnit:    cmd.GetString("kubernetes-cpu-per-compute-unit"),
+			MemoryPerComputeUnit: cmd.GetString("kubernetes-memory-per-compute-unit"),
+		},
 		TLS: config.TLSConfig{
 			CertFile:    cmd.GetString("cert-file"),
 			KeyFile:     cmd.GetString("key-file"),
@@ -1522,6 +1929,38 @@ func buildServerConfig(cmd *cli.Command) *config.ServerConfig {
 				chatCfg.BaseURL = "http://127.0.0.1:11434/v1"
 			}
 
+			// Named providers and model aliases are only read from the TOML configuration
+			if cmd.ConfigFile.FileUsed() != "" {
+				typedConfig := cli.NewTypedConfigFile(cmd.ConfigFile)
+				for _, provider := range typedConfig.GetObjectSlice("server.chat.providers") {
+					providerConfig := config.ChatProviderConfig{
+						Name:     provider.GetString("name"),
+						Provider: provider.GetString("provider"),
+						APIKey:   provider.GetString("api_key"),
+						BaseURL:  provider.GetString("base_url"),
+						Timeout:  time.Duration(provider.GetInt("timeout")) * time.Second,
+					}
+					if providerConfig.Name == "" || providerConfig.Provider == "" {
+						logger.Warn("ignoring chat provider without name or provider", "name", providerConfig.Name)
+						continue
+					}
+					chatCfg.Providers = append(chatCfg.Providers, providerConfig)
+				}
+				for _, alias := range typedConfig.GetObjectSlice("server.chat.models") {
+					modelConfig := config.ChatModelConfig{
+						Alias:    alias.GetString("alias"),
+						Provider: alias.GetString("provider"),
+						Model:    alias.GetString("model"),
+						Fallback: alias.GetString("fallback"),
+					}
+					if modelConfig.Alias == "" {
+						logger.Warn("ignoring chat model without alias", "model", modelConfig.Model)
+						continue
+					}
+					chatCfg.Models = append(chatCfg.Models, modelConfig)
+				}
+			}
+
 			return chatCfg
 		}(),
 		LocalContainerRuntimePref: cmd.GetStringSlice("local-container-runtime-pref"),
@@ -5,17 +5,19 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/paularlott/cli"
+	"github.com/paularlott/knot/apiclient"
 	"github.com/paularlott/knot/command/cmdutil"
 )
 
 var LogsCmd = &cli.Command{
 	Name:        "logs",
 	Usage:       "Show the logs from a space",
-	Description: "Display the logs for a space.",
+	Description: "Display the logs for a space.\n\nStored logs are searched when the server keeps space logs, so history is available after the space or server restarts. Use --since and --until to select a time range, given as a duration back from now (e.g. 2h) or an RFC3339 time.",
 	Arguments: []cli.Argument{
 		&cli.StringArg{
 			Name:     "space",
@@ -31,6 +33,32 @@ var LogsCmd = &cli.Command{
 			Usage:        "Follow the logs.",
 			DefaultValue: false,
 		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Show logs newer than a duration (e.g. 30m) or RFC3339 time.",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "Show logs older than a duration (e.g. 30m) or RFC3339 time.",
+		},
+		&cli.StringFlag{
+			Name:  "level",
+			Usage: "Minimum level to show, one of debug, info or error.",
+		},
+		&cli.StringFlag{
+			Name:  "source",
+			Usage: "Only show logs from this source.",
+		},
+		&cli.StringFlag{
+			Name:  "grep",
+			Usage: "Only show logs containing this text.",
+		},
+		&cli.IntFlag{
+			Name:         "limit",
+			Aliases:      []string{"n"},
+			Usage:        "The maximum number of lines of history to show.",
+			DefaultValue: 1000,
+		},
 	},
 	Run: func(ctx context.Context, cmd *cli.Command) error {
 		spaceName := cmd.GetStringArg("space")
@@ -40,10 +68,40 @@ var LogsCmd = &cli.Command{
 			return fmt.Errorf("Failed to create API client: %w", err)
 		}
 
+		spaceId, err := resolveSpaceID(ctx, client, spaceName)
+		if err != nil {
+			return err
+		}
+
+		query := &apiclient.SpaceLogQuery{
+			Level:  cmd.GetString("level"),
+			Source: cmd.GetString("source"),
+			Query:  cmd.GetString("grep"),
+		}
+		if query.From, err = parseLogTime(cmd.GetString("since")); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		if query.To, err = parseLogTime(cmd.GetString("until")); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+
+		if !follow {
+			return printLogHistory(ctx, client, spaceId, query, cmd.GetInt("limit"))
+		}
+
 		// Get server info from client
 		baseURL := client.GetBaseURL()
 		token := client.GetAuthToken()
-		wsURL := "ws" + baseURL[4:] + fmt.Sprintf("/logs/%s/stream", spaceName)
+		params := url.Values{}
+		for key, value := range map[string]string{"level": query.Level, "source": query.Source, "q": query.Query} {
+			if value != "" {
+				params.Set(key, value)
+			}
+		}
+		if !query.From.IsZero() {
+			params.Set("from", query.From.Format(time.RFC3339))
+		}
+		wsURL := "ws" + baseURL[4:] + fmt.Sprintf("/logs/%s/stream?%s", spaceId, params.Encode())
 		header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer %s", token)}}
 
 		// Connect to the websocket at /logs/<spaceId>/stream and print the logs
@@ -69,8 +127,8 @@ var LogsCmd = &cli.Command{
 			}
 
 			// if message is just a byte of 0 then end of history
-			if len(message) == 1 && message[0] == 0 && !follow {
-				break
+			if len(message) == 1 && message[0] == 0 {
+				continue
 			}
 
 			fmt.Print(string(message))
@@ -78,3 +136,64 @@ var LogsCmd = &cli.Command{
 		return nil
 	},
 }
+
+// printLogHistory pages back through the stored logs until limit lines are
+// found, then prints them oldest first.
+func printLogHistory(ctx context.Context, client *apiclient.ApiClient, spaceId string, query *apiclient.SpaceLogQuery, limit int) error {
+	var lines []apiclient.SpaceLogEntry
+	for limit < 1 || len(lines) < limit {
+		query.Limit = 1000
+		if limit > 0 {
+			query.Limit = min(query.Limit, limit-len(lines))
+		}
+
+		page, code, err := client.GetSpaceLogs(ctx, spaceId, query)
+		if err != nil {
+			if code == http.StatusForbidden {
+				return fmt.Errorf("no permission to view logs")
+			}
+			return fmt.Errorf("error getting logs: %w", err)
+		}
+
+		lines = append(lines, page.Logs...)
+		if page.Next == "" {
+			break
+		}
+		query.Before = page.Next
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		fmt.Print(formatLogLine(&lines[i]))
+	}
+	return nil
+}
+
+func formatLogLine(entry *apiclient.SpaceLogEntry) string {
+	line := "\033[90m" + entry.CreatedAt.Local().Format("02 Jan 06 15:04:05 MST") + "\033[0m "
+	if entry.Source != "" {
+		line += "\033[93m" + entry.Source + "\033[0m "
+	}
+
+	switch entry.Level {
+	case "error":
+		line += "\033[91mERR\033[0m "
+	case "info":
+		line += "\033[92mINF\033[0m "
+	default:
+		line += "\033[94mDBG\033[0m "
+	}
+
+	return line + entry.Message + "\n"
+}
+
+// parseLogTime accepts a duration back from now or an RFC3339 time, empty
+// returns the zero time.
+func parseLogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -61,6 +61,8 @@ var SpacesCmd = &cli.Command{
 		SedCmd,
 		DeleteFileCmd,
 		PortCmd,
+		TerminalCmd,
+		TerminalSessionCmd,
 		TunnelCmd,
 		SetFieldCmd,
 		GetFieldCmd,
@@ -0,0 +1,183 @@
+package command_spaces
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/paularlott/knot/command/cmdutil"
+
+	"github.com/gorilla/websocket"
+	"github.com/paularlott/cli"
+	"golang.org/x/term"
+)
+
+// terminalDetachKey ends the local connection without exiting the shell,
+// Ctrl+] as used by telnet.
+const terminalDetachKey = 0x1d
+
+var TerminalCmd = &cli.Command{
+	Name:  "terminal",
+	Usage: "Open a terminal in a space",
+	Description: `Open an interactive terminal in a space.
+
+With --session the terminal attaches to a named shared session, starting it if it isn't running. Shared sessions keep running when everyone disconnects and can be joined by other users the space is shared with, use --read-only to watch without typing.
+
+Press Ctrl+] to disconnect.`,
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "space",
+			Usage:    "The name of the space",
+			Required: true,
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "session",
+			Usage: "The name of the shared session to attach to.",
+		},
+		&cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "Attach to the shared session as a viewer.",
+		},
+	},
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		spaceName := cmd.GetStringArg("space")
+		session := cmd.GetString("session")
+		readOnly := cmd.GetBool("read-only")
+
+		if readOnly && session == "" {
+			return fmt.Errorf("--read-only requires --session")
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("a terminal is required")
+		}
+
+		client, err := cmdutil.GetClient(cmd)
+		if err != nil {
+			return fmt.Errorf("Failed to create API client: %w", err)
+		}
+
+		space, err := client.GetSpaceByName(ctx, spaceName)
+		if err != nil {
+			return fmt.Errorf("Space not found: %s", spaceName)
+		}
+
+		shell := space.Shell
+		if shell == "" {
+			shell = "bash"
+		}
+
+		query := url.Values{}
+		if session != "" {
+			query.Set("session", session)
+			if readOnly {
+				query.Set("read_only", "true")
+			}
+		}
+
+		baseURL := client.GetBaseURL()
+		wsURL := "ws" + baseURL[4:] + fmt.Sprintf("/proxy/spaces/%s/terminal/%s", space.SpaceId, shell)
+		if len(query) > 0 {
+			wsURL += "?" + query.Encode()
+		}
+		header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer %s", client.GetAuthToken())}}
+
+		dialer := websocket.DefaultDialer
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: cmd.GetBool("tls-skip-verify")}
+		dialer.HandshakeTimeout = 5 * time.Second
+		ws, response, err := dialer.Dial(wsURL, header)
+		if err != nil {
+			if response != nil && response.StatusCode == http.StatusUnauthorized {
+				return fmt.Errorf("failed to authenticate with server, check remote token")
+			} else if response != nil && response.StatusCode == http.StatusForbidden {
+				return fmt.Errorf("no permission to use the terminal")
+			} else if response != nil && response.StatusCode == http.StatusNotFound {
+				return fmt.Errorf("space is not running or has no terminal")
+			}
+			return fmt.Errorf("Error connecting to websocket: %w", err)
+		}
+		defer ws.Close()
+
+		state, err := term.MakeRaw(int(os.Stdin.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to set terminal mode: %w", err)
+		}
+		defer term.Restore(int(os.Stdin.Fd()), state)
+
+		// The websocket is written from the resize and input loops, resizes go
+		// as binary messages and input as text
+		type wsMessage struct {
+			messageType int
+			data        []byte
+		}
+		writes := make(chan wsMessage, 16)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			for {
+				_, message, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				os.Stdout.Write(message)
+			}
+		}()
+
+		sendSize := func() {
+			width, height, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil {
+				return
+			}
+			size, _ := json.Marshal(map[string]int{"cols": width, "rows": height})
+			writes <- wsMessage{websocket.BinaryMessage, append([]byte{1}, size...)}
+		}
+
+		resized := make(chan os.Signal, 1)
+		notifyResize(resized)
+		go func() {
+			for range resized {
+				sendSize()
+			}
+		}()
+		sendSize()
+
+		go func() {
+			buffer := make([]byte, 1024)
+			for {
+				n, err := os.Stdin.Read(buffer)
+				if err != nil {
+					ws.Close()
+					return
+				}
+				for i := 0; i < n; i++ {
+					if buffer[i] == terminalDetachKey {
+						ws.Close()
+						return
+					}
+				}
+				if !readOnly {
+					writes <- wsMessage{websocket.TextMessage, append([]byte(nil), buffer[:n]...)}
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return nil
+			case message := <-writes:
+				if err := ws.WriteMessage(message.messageType, message.data); err != nil {
+					return nil
+				}
+			}
+		}
+	},
+}
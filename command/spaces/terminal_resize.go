@@ -0,0 +1,13 @@
+//go:build !windows
+
+package command_spaces
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func notifyResize(ch chan os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
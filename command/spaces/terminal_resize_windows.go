@@ -0,0 +1,9 @@
+//go:build windows
+
+package command_spaces
+
+import "os"
+
+// notifyResize does nothing on Windows which has no resize signal, the size is
+// only sent when the terminal opens.
+func notifyResize(ch chan os.Signal) {}
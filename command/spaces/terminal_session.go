@@ -0,0 +1,157 @@
+package command_spaces
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paularlott/knot/command/cmdutil"
+
+	"github.com/paularlott/cli"
+)
+
+// TerminalSessionCmd is the `knot space terminal-session` group, managing the
+// shared terminal sessions running in a space.
+var TerminalSessionCmd = &cli.Command{
+	Name:        "terminal-session",
+	Usage:       "Manage shared terminal sessions",
+	Description: "List the shared terminal sessions in a space, disconnect users from them or close them.",
+	MaxArgs:     cli.NoArgs,
+	Commands: []*cli.Command{
+		TerminalSessionListCmd,
+		TerminalSessionKickCmd,
+		TerminalSessionCloseCmd,
+	},
+}
+
+var TerminalSessionListCmd = &cli.Command{
+	Name:        "list",
+	Usage:       "List shared terminal sessions",
+	Description: "List the shared terminal sessions in a space and the users attached to them.",
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "space",
+			Usage:    "The name of the space",
+			Required: true,
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		spaceName := cmd.GetStringArg("space")
+
+		client, err := cmdutil.GetClient(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		response, code, err := client.GetTerminalSessions(ctx, spaceName)
+		if err == nil && code != 200 {
+			err = fmt.Errorf("unexpected status code: %d", code)
+		}
+		if err != nil {
+			return terminalSessionError(code, "list terminal sessions", err)
+		}
+
+		if len(response.Sessions) == 0 {
+			fmt.Printf("No shared terminal sessions in space '%s'.\n", spaceName)
+			return nil
+		}
+
+		for _, session := range response.Sessions {
+			fmt.Printf("%s (started by %s, %s)\n", session.Name, session.CreatedBy, session.CreatedAt.Local().Format("02 Jan 06 15:04 MST"))
+			for _, attached := range session.Attached {
+				mode := "read-write"
+				if attached.ReadOnly {
+					mode = "viewer"
+				}
+				fmt.Printf("  %s  %s (%s)\n", attached.Id, attached.Username, mode)
+			}
+		}
+
+		return nil
+	},
+}
+
+var TerminalSessionKickCmd = &cli.Command{
+	Name:        "kick",
+	Usage:       "Disconnect a user from a shared terminal session",
+	Description: "Disconnect a user from a shared terminal session, the session keeps running. Attachment IDs are shown by the list command.",
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "space",
+			Usage:    "The name of the space",
+			Required: true,
+		},
+		&cli.StringArg{
+			Name:     "session",
+			Usage:    "The name of the session",
+			Required: true,
+		},
+		&cli.StringArg{
+			Name:     "attachment",
+			Usage:    "The attachment ID of the user to disconnect",
+			Required: true,
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		client, err := cmdutil.GetClient(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		code, err := client.DetachTerminalSession(ctx, cmd.GetStringArg("space"), cmd.GetStringArg("session"), cmd.GetStringArg("attachment"))
+		if err != nil {
+			return terminalSessionError(code, "disconnect user", err)
+		}
+
+		fmt.Println("User disconnected from the session.")
+		return nil
+	},
+}
+
+var TerminalSessionCloseCmd = &cli.Command{
+	Name:        "close",
+	Usage:       "Close a shared terminal session",
+	Description: "End a shared terminal session and its shell, disconnecting everyone attached.",
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "space",
+			Usage:    "The name of the space",
+			Required: true,
+		},
+		&cli.StringArg{
+			Name:     "session",
+			Usage:    "The name of the session",
+			Required: true,
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		client, err := cmdutil.GetClient(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to create API client: %w", err)
+		}
+
+		code, err := client.CloseTerminalSession(ctx, cmd.GetStringArg("space"), cmd.GetStringArg("session"))
+		if err != nil {
+			return terminalSessionError(code, "close session", err)
+		}
+
+		fmt.Println("Session closed.")
+		return nil
+	},
+}
+
+func terminalSessionError(code int, action string, err error) error {
+	switch code {
+	case 401:
+		return fmt.Errorf("failed to authenticate with server, check token")
+	case 403:
+		return fmt.Errorf("no permission to %s", action)
+	case 404:
+		return fmt.Errorf("space or session not found")
+	case 503:
+		return fmt.Errorf("space is not running")
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
@@ -54,6 +54,7 @@ func newSpaceTunnelStartCmd(name, protocol string) *cli.Command {
 			},
 		},
 		MaxArgs: cli.NoArgs,
+		Flags:   cmdutil.TunnelPolicyFlags(),
 		Run: func(ctx context.Context, cmd *cli.Command) error {
 			return runSpaceTunnelStart(ctx, cmd, protocol)
 		},
@@ -78,6 +79,11 @@ func runSpaceTunnelStart(ctx context.Context, cmd *cli.Command, protocol string)
 		return fmt.Errorf("invalid name, must be all lowercase and only contain letters, numbers and dashes")
 	}
 
+	policy, err := cmdutil.TunnelPolicyFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
 	client, err := cmdutil.GetClient(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to create API client: %w", err)
@@ -92,6 +98,7 @@ func runSpaceTunnelStart(ctx context.Context, cmd *cli.Command, protocol string)
 		Protocol: protocol,
 		Port:     uint16(port),
 		Name:     name,
+		Policy:   policy.Spec(),
 	})
 	if err != nil {
 		return spaceApiError(code, err, "start tunnel")
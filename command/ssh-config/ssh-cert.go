@@ -0,0 +1,144 @@
+package command_ssh_config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/config"
+
+	"github.com/paularlott/cli"
+)
+
+var SshCertCmd = &cli.Command{
+	Name:  "ssh-cert",
+	Usage: "Issue an SSH certificate for a space",
+	Description: `Ask the server to sign your SSH public key with its certificate authority, giving a short lived certificate to log in to the space.
+
+The certificate is written where the entries added by ssh-config update look for it.`,
+	Arguments: []cli.Argument{
+		&cli.StringArg{
+			Name:     "space",
+			Usage:    "The name of the space to issue the certificate for",
+			Required: true,
+		},
+	},
+	MaxArgs: cli.NoArgs,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "key",
+			Aliases: []string{"k"},
+			Usage:   "The public key to sign, defaults to the first of ~/.ssh/id_ed25519.pub, id_ecdsa.pub and id_rsa.pub.",
+		},
+		&cli.IntFlag{
+			Name:  "ttl",
+			Usage: "The lifetime of the certificate in minutes, defaults to the server maximum.",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "The file to write the certificate to, - for stdout.",
+		},
+		&cli.BoolFlag{
+			Name:         "tls-skip-verify",
+			Usage:        "Skip TLS verification when talking to server.",
+			ConfigPath:   []string{"tls.skip_verify"},
+			EnvVars:      []string{config.CONFIG_ENV_PREFIX + "_TLS_SKIP_VERIFY"},
+			DefaultValue: true,
+		},
+		&cli.StringFlag{
+			Name:         "alias",
+			Aliases:      []string{"a"},
+			Usage:        "The server alias to use.",
+			DefaultValue: "default",
+		},
+	},
+	Run: func(ctx context.Context, cmd *cli.Command) error {
+		spaceName := cmd.GetStringArg("space")
+		alias := cmd.GetString("alias")
+		cfg := config.GetServerAddr(alias, cmd)
+		client, err := apiclient.NewClient(cfg.HttpServer, cfg.ApiToken, cmd.GetBool("tls-skip-verify"))
+		if err != nil {
+			return fmt.Errorf("Failed to create API client: %w", err)
+		}
+
+		keyFile := cmd.GetString("key")
+		if keyFile == "" {
+			if keyFile, err = defaultPublicKey(); err != nil {
+				return err
+			}
+		}
+		publicKey, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("Failed to read public key: %w", err)
+		}
+
+		response, _, err := client.SignSSHCert(ctx, spaceName, &apiclient.SSHCertRequest{
+			PublicKey: string(publicKey),
+			TTL:       cmd.GetInt("ttl"),
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to issue certificate: %w", err)
+		}
+
+		output := cmd.GetString("output")
+		if output == "-" {
+			fmt.Println(response.Certificate)
+			return nil
+		}
+		if output == "" {
+			if output, err = certificateFile(alias, spaceName); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0700); err != nil {
+			return fmt.Errorf("Failed to create certificate directory: %w", err)
+		}
+		if err := os.WriteFile(output, []byte(response.Certificate+"\n"), 0600); err != nil {
+			return fmt.Errorf("Failed to write certificate: %w", err)
+		}
+
+		fmt.Printf("Certificate for %s written to %s, valid until %s\n", spaceName, output, response.ValidBefore.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+func defaultPublicKey() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"} {
+		keyFile := filepath.Join(home, ".ssh", name)
+		if _, err := os.Stat(keyFile); err == nil {
+			return keyFile, nil
+		}
+	}
+	return "", fmt.Errorf("No SSH public key found, use --key to give one")
+}
+
+// knotSSHDir returns the directory holding the known hosts and certificates
+// for the server alias.
+func knotSSHDir(alias string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "knot", alias), nil
+}
+
+func certificateFile(alias, spaceName string) (string, error) {
+	dir, err := knotSSHDir(alias)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, spaceName+"-cert.pub"), nil
+}
+
+// sshConfigPath quotes a path for use in .ssh/config.
+func sshConfigPath(path string) string {
+	return `"` + strings.ReplaceAll(path, `"`, ``) + `"`
+}
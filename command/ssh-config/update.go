@@ -3,7 +3,9 @@ package command_ssh_config
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/paularlott/knot/apiclient"
 	"github.com/paularlott/knot/internal/config"
@@ -50,6 +52,25 @@ var SshConfigUpdateCmd = &cli.Command{
 			return fmt.Errorf("Error getting spaces: %w", err)
 		}
 
+		// If the server is an SSH CA then verify hosts against it rather than
+		// accepting any host key, failing to get it leaves checking disabled
+		knownHostsFile := ""
+		if ca, _, err := client.GetSSHCA(context.Background()); err == nil && ca.Enabled {
+			dir, err := knotSSHDir(alias)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return fmt.Errorf("Failed to create %s: %w", dir, err)
+			}
+
+			// Spaces are verified by ID through HostKeyAlias, so the CA can vouch for any name
+			knownHostsFile = filepath.Join(dir, "known_hosts")
+			if err := updateKnownHosts(knownHostsFile, ca.PublicKey); err != nil {
+				return fmt.Errorf("Failed to write %s: %w", knownHostsFile, err)
+			}
+		}
+
 		// If a config file was given then we need to use it
 		configFile := ""
 		if cmd.HasFlag("config") {
@@ -76,9 +97,21 @@ var SshConfigUpdateCmd = &cli.Command{
 
 				sshConfig += "Host knot." + space.Name + machineAlias + "\n"
 				sshConfig += "  HostName knot." + space.Name + machineAlias + "\n"
-				sshConfig += "  StrictHostKeyChecking=no\n"
-				sshConfig += "  LogLevel ERROR\n"
-				sshConfig += "  UserKnownHostsFile=/dev/null\n"
+				if knownHostsFile != "" {
+					certFile, err := certificateFile(alias, space.Name)
+					if err != nil {
+						return err
+					}
+					sshConfig += "  HostKeyAlias " + space.Id + "\n"
+					sshConfig += "  StrictHostKeyChecking accept-new\n"
+					sshConfig += "  UserKnownHostsFile " + sshConfigPath(knownHostsFile) + "\n"
+					sshConfig += "  CertificateFile " + sshConfigPath(certFile) + "\n"
+					sshConfig += "  LogLevel ERROR\n"
+				} else {
+					sshConfig += "  StrictHostKeyChecking=no\n"
+					sshConfig += "  LogLevel ERROR\n"
+					sshConfig += "  UserKnownHostsFile=/dev/null\n"
+				}
 				if cmd.GetBool("agent-forwarding") {
 					sshConfig += "  ForwardAgent yes\n"
 				}
@@ -91,3 +124,21 @@ var SshConfigUpdateCmd = &cli.Command{
 		return nil
 	},
 }
+
+// updateKnownHosts sets the CA trusted for host certificates, keeping the
+// keys accepted from spaces whose SSH server doesn't present a certificate.
+func updateKnownHosts(knownHostsFile, caPublicKey string) error {
+	lines := []string{"@cert-authority * " + caPublicKey}
+
+	existing, err := os.ReadFile(knownHostsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "@cert-authority ") {
+			lines = append(lines, line)
+		}
+	}
+
+	return os.WriteFile(knownHostsFile, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
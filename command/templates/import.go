@@ -14,8 +14,8 @@ import (
 
 var ImportCmd = &cli.Command{
 	Name:        "import",
-	Usage:       "Import a template from portable YAML",
-	Description: "Imports a template from a YAML file (or stdin). Creates a new template on the server. Scripts are resolved by name.",
+	Usage:       "Import a template from portable YAML, devcontainer.json or a compose file",
+	Description: "Imports a template from a file (or stdin). Creates a new template on the server, or updates the template with the same name. Scripts are resolved by name.\n\nWith --format devcontainer or compose the file is converted into a template spec for --platform. Commands run after the container is created become a startup script named <name>-startup, and anything that couldn't be converted is listed.",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:    "file",
@@ -26,6 +26,20 @@ var ImportCmd = &cli.Command{
 			Name:  "name",
 			Usage: "Override the template name from the YAML file.",
 		},
+		&cli.StringFlag{
+			Name:         "format",
+			Usage:        "The input format, one of template, devcontainer or compose.",
+			DefaultValue: "template",
+		},
+		&cli.StringFlag{
+			Name:  "service",
+			Usage: "The compose service to import when the file defines several.",
+		},
+		&cli.StringFlag{
+			Name:         "platform",
+			Usage:        "The platform of the imported template, one of container, docker, podman, apple, nomad or kubernetes.",
+			DefaultValue: "container",
+		},
 	},
 	MaxArgs: cli.NoArgs,
 	Run: func(ctx context.Context, cmd *cli.Command) error {
@@ -55,8 +69,19 @@ var ImportCmd = &cli.Command{
 			}
 		}
 
-		// If --name override, patch the YAML before sending.
 		nameOverride := cmd.GetString("name")
+		switch format := cmd.GetString("format"); format {
+		case "template":
+		case "devcontainer", "compose":
+			data, err = convertImport(ctx, client, format, cmd.GetString("service"), cmd.GetString("platform"), nameOverride, data)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q, use template, devcontainer or compose", format)
+		}
+
+		// If --name override, patch the YAML before sending.
 		if nameOverride != "" {
 			data = patchYamlName(data, nameOverride)
 		}
@@ -75,6 +100,105 @@ var ImportCmd = &cli.Command{
 	},
 }
 
+// convertImport converts a devcontainer.json or compose file into portable
+// template YAML. The startup script is written to the server first so the
+// template import can resolve it by name.
+func convertImport(ctx context.Context, client *apiclient.ApiClient, format, service, platform, name string, data []byte) ([]byte, error) {
+	parsed, _, err := client.ParseSpec(ctx, &apiclient.SpecParseRequest{
+		Platform: platform,
+		Job:      string(data),
+		Format:   format,
+		Service:  service,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", format, err)
+	}
+	report := parsed.Import
+
+	if name == "" {
+		name = report.Name
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the %s file doesn't name the template, use --name", format)
+	}
+
+	built, _, err := client.BuildSpec(ctx, &apiclient.SpecBuildRequest{
+		Platform: platform,
+		Spec:     parsed.Spec,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the template spec: %w", err)
+	}
+
+	export := apiclient.TemplateExport{
+		Name:     name,
+		Platform: platform,
+		Active:   true,
+		Features: apiclient.TemplateExportFeatures{WithTerminal: true},
+		Ports:    report.Ports,
+		Job:      built.Job,
+		Volumes:  built.Volumes,
+	}
+
+	if report.StartupScript != "" {
+		export.StartupScript = name + "-startup"
+		if err := writeStartupScript(ctx, client, export.StartupScript, format, report.StartupScript); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(report.Unmapped) > 0 {
+		fmt.Printf("The following %s settings were not imported:\n", format)
+		for _, issue := range report.Unmapped {
+			fmt.Printf("  %s: %s\n", issue.Field, issue.Message)
+		}
+	}
+
+	text, err := export.String()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// writeStartupScript creates the global startup script or replaces the content
+// of an existing one with the same name.
+func writeStartupScript(ctx context.Context, client *apiclient.ApiClient, name, format, content string) error {
+	existing, err := client.GetScriptDetailsByName(ctx, name)
+	if err != nil || existing.Id == "" {
+		_, err = client.CreateScript(ctx, apiclient.ScriptCreateRequest{
+			Name:        name,
+			Description: "Startup script imported from " + format,
+			Content:     content,
+			Active:      true,
+			ScriptType:  "script",
+		})
+		if err != nil {
+			return fmt.Errorf("error creating startup script: %w", err)
+		}
+		fmt.Printf("Startup script %s created\n", name)
+		return nil
+	}
+
+	err = client.UpdateScript(ctx, existing.Id, apiclient.ScriptUpdateRequest{
+		Name:               name,
+		Description:        existing.Description,
+		Content:            content,
+		Groups:             existing.Groups,
+		Zones:              existing.Zones,
+		Active:             existing.Active,
+		ScriptType:         existing.ScriptType,
+		MCPInputSchemaToml: existing.MCPInputSchemaToml,
+		MCPKeywords:        existing.MCPKeywords,
+		Discoverable:       existing.Discoverable,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating startup script: %w", err)
+	}
+	fmt.Printf("Startup script %s updated\n", name)
+	return nil
+}
+
 // patchYamlName replaces or inserts the top-level name: field in the YAML text.
 // This is a simple text replacement to avoid a full YAML round-trip.
 func patchYamlName(data []byte, name string) []byte {
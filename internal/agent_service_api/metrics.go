@@ -0,0 +1,27 @@
+package agent_service_api
+
+import (
+	"net/http"
+
+	"github.com/paularlott/knot/build"
+	"github.com/paularlott/knot/internal/metrics"
+)
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshot := agentClient.Metrics()
+	space := []string{"space_id", agentClient.GetSpaceId()}
+
+	m := metrics.NewWriter()
+	m.Gauge("knot_agent_build_info", "Version of the knot agent.", 1, append(space, "version", build.Version)...)
+	m.Gauge("knot_agent_connected_servers", "Knot servers the agent is connected to.", float64(snapshot.ConnectedServers), space...)
+	m.Gauge("knot_agent_active_sessions", "Terminal, SSH and proxy sessions open through the agent.", float64(snapshot.ActiveSessions), space...)
+	m.Counter("knot_agent_method_calls_total", "Method calls served by the space.", float64(snapshot.MethodCallsTotal), space...)
+	m.Counter("knot_agent_http_requests_total", "HTTP requests proxied into the space.", float64(snapshot.HTTPRequestsTotal), space...)
+	m.Counter("knot_agent_tcp_connections_total", "TCP connections proxied into the space.", float64(snapshot.TCPConnectionsTotal), space...)
+	m.Gauge("knot_agent_cpu_percent", "CPU use of the space at the last state report.", snapshot.CPUPercent, space...)
+	m.Gauge("knot_agent_memory_used_bytes", "Memory used by the space.", float64(snapshot.MemoryUsedBytes), space...)
+	m.Gauge("knot_agent_memory_limit_bytes", "Memory limit of the space.", float64(snapshot.MemoryLimitBytes), space...)
+	m.Gauge("knot_agent_disk_used_bytes", "Disk used by the space.", float64(snapshot.DiskUsedBytes), space...)
+	m.Gauge("knot_agent_disk_limit_bytes", "Disk limit of the space.", float64(snapshot.DiskLimitBytes), space...)
+	m.Serve(w)
+}
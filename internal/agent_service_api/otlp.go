@@ -0,0 +1,272 @@
+package agent_service_api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+
+	"github.com/paularlott/knot/internal/log"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	otlpMaxBodySize      = 8 * 1024 * 1024
+	otlpMaxMetricNameLen = 128
+)
+
+type otlpLogMessage struct {
+	service string
+	level   msg.LogLevel
+	message string
+	at      time.Time
+}
+
+// Handler for OTLP/HTTP log exports in JSON or protobuf.
+// The service is taken from the service.name resource attribute if present
+// else it is set to "otel", severities are mapped onto the agent log levels.
+func handleOTLPLogs(w http.ResponseWriter, r *http.Request) {
+	var request collogspb.ExportLogsServiceRequest
+	if !decodeOTLPRequest(w, r, &request) {
+		return
+	}
+
+	for _, logMsg := range otlpLogMessages(&request) {
+		agentClient.SendLogMessageAt(logMsg.service, logMsg.level, logMsg.message, logMsg.at)
+	}
+
+	writeOTLPResponse(w, r, &collogspb.ExportLogsServiceResponse{})
+}
+
+// Handler for OTLP/HTTP metric exports in JSON or protobuf.
+// Gauges and cumulative sums are recorded against the space and reported with
+// its state, other metric types are ignored.
+func handleOTLPMetrics(w http.ResponseWriter, r *http.Request) {
+	var request colmetricspb.ExportMetricsServiceRequest
+	if !decodeOTLPRequest(w, r, &request) {
+		return
+	}
+
+	for name, value := range otlpMetricValues(&request) {
+		agentClient.SetCustomMetric(name, value)
+	}
+
+	writeOTLPResponse(w, r, &colmetricspb.ExportMetricsServiceResponse{})
+}
+
+func otlpLogMessages(request *collogspb.ExportLogsServiceRequest) []otlpLogMessage {
+	var messages []otlpLogMessage
+	for _, resourceLogs := range request.GetResourceLogs() {
+		service := otlpAttribute(resourceLogs.GetResource().GetAttributes(), "service.name")
+		if service == "" {
+			service = "otel"
+		}
+
+		for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+			for _, record := range scopeLogs.GetLogRecords() {
+				message := otlpValueString(record.GetBody())
+				if message == "" {
+					continue
+				}
+
+				at := time.Now()
+				if record.GetTimeUnixNano() > 0 {
+					at = time.Unix(0, int64(record.GetTimeUnixNano()))
+				} else if record.GetObservedTimeUnixNano() > 0 {
+					at = time.Unix(0, int64(record.GetObservedTimeUnixNano()))
+				}
+
+				messages = append(messages, otlpLogMessage{
+					service: service,
+					level:   otlpLogLevel(record.GetSeverityNumber(), record.GetSeverityText()),
+					message: message,
+					at:      at,
+				})
+			}
+		}
+	}
+	return messages
+}
+
+// otlpLogLevel maps an OTLP severity onto the agent log levels, warnings are
+// treated as errors as they are for GELF. Records without a severity number
+// fall back to the severity text.
+func otlpLogLevel(severity logspb.SeverityNumber, text string) msg.LogLevel {
+	switch {
+	case severity >= logspb.SeverityNumber_SEVERITY_NUMBER_WARN:
+		return msg.LogLevelError
+	case severity >= logspb.SeverityNumber_SEVERITY_NUMBER_INFO:
+		return msg.LogLevelInfo
+	case severity > logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED:
+		return msg.LogLevelDebug
+	}
+
+	switch strings.ToLower(text) {
+	case "trace", "debug":
+		return msg.LogLevelDebug
+	case "warn", "warning", "error", "fatal", "critical":
+		return msg.LogLevelError
+	default:
+		return msg.LogLevelInfo
+	}
+}
+
+// otlpMetricValues returns the latest value of each gauge and cumulative sum in
+// the request, data points with different attributes are added together so each
+// metric is a single value for the space. Delta sums only carry the change
+// since the last export so they are skipped.
+func otlpMetricValues(request *colmetricspb.ExportMetricsServiceRequest) map[string]float64 {
+	values := make(map[string]float64)
+	for _, resourceMetrics := range request.GetResourceMetrics() {
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			for _, metric := range scopeMetrics.GetMetrics() {
+				name := metric.GetName()
+				if name == "" || len(name) > otlpMaxMetricNameLen {
+					continue
+				}
+
+				var points []*metricspb.NumberDataPoint
+				switch data := metric.GetData().(type) {
+				case *metricspb.Metric_Gauge:
+					points = data.Gauge.GetDataPoints()
+				case *metricspb.Metric_Sum:
+					if data.Sum.GetAggregationTemporality() != metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+						log.Debug("otlp: skipping non-cumulative sum", "metric", name)
+						continue
+					}
+					points = data.Sum.GetDataPoints()
+				default:
+					continue
+				}
+				if len(points) == 0 {
+					continue
+				}
+
+				var total float64
+				for _, point := range points {
+					switch value := point.GetValue().(type) {
+					case *metricspb.NumberDataPoint_AsDouble:
+						total += value.AsDouble
+					case *metricspb.NumberDataPoint_AsInt:
+						total += float64(value.AsInt)
+					}
+				}
+				values[name] = total
+			}
+		}
+	}
+	return values
+}
+
+func otlpAttribute(attributes []*commonpb.KeyValue, key string) string {
+	for _, attribute := range attributes {
+		if attribute.GetKey() == key {
+			return otlpValueString(attribute.GetValue())
+		}
+	}
+	return ""
+}
+
+func otlpValueString(value *commonpb.AnyValue) string {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(v.DoubleValue, 'g', -1, 64)
+	case *commonpb.AnyValue_BytesValue:
+		return string(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		items := make([]string, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			items = append(items, otlpValueString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *commonpb.AnyValue_KvlistValue:
+		items := make([]string, 0, len(v.KvlistValue.GetValues()))
+		for _, item := range v.KvlistValue.GetValues() {
+			items = append(items, fmt.Sprintf("%s=%s", item.GetKey(), otlpValueString(item.GetValue())))
+		}
+		return strings.Join(items, " ")
+	default:
+		return ""
+	}
+}
+
+func isOTLPProtobuf(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	return strings.TrimSpace(contentType) == "application/x-protobuf"
+}
+
+// decodeOTLPRequest reads an OTLP/HTTP request body, optionally gzip
+// compressed, writing an error response if it can't be decoded.
+func decodeOTLPRequest(w http.ResponseWriter, r *http.Request, request proto.Message) bool {
+	var body io.Reader = http.MaxBytesReader(w, r.Body, otlpMaxBodySize)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return false
+		}
+		defer reader.Close()
+		body = io.LimitReader(reader, otlpMaxBodySize)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+
+	if isOTLPProtobuf(r) {
+		err = proto.Unmarshal(data, request)
+	} else {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, request)
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to decode otlp request:")
+		http.Error(w, "invalid otlp request", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// writeOTLPResponse replies with an empty export response in the encoding of
+// the request.
+func writeOTLPResponse(w http.ResponseWriter, r *http.Request, response proto.Message) {
+	var data []byte
+	var err error
+	if isOTLPProtobuf(r) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		data, err = proto.Marshal(response)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		data, err = protojson.Marshal(response)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,118 @@
+package agent_service_api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPLogLevel(t *testing.T) {
+	tests := []struct {
+		severity logspb.SeverityNumber
+		text     string
+		want     msg.LogLevel
+	}{
+		{logspb.SeverityNumber_SEVERITY_NUMBER_TRACE, "", msg.LogLevelDebug},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG4, "", msg.LogLevelDebug},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "", msg.LogLevelInfo},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_INFO4, "", msg.LogLevelInfo},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "", msg.LogLevelError},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4, "", msg.LogLevelError},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "DEBUG", msg.LogLevelDebug},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "Warning", msg.LogLevelError},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "", msg.LogLevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := otlpLogLevel(tt.severity, tt.text); got != tt.want {
+			t.Errorf("otlpLogLevel(%v, %q) = %v, want %v", tt.severity, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeOTLPLogsJSON(t *testing.T) {
+	body := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"checkout"}}]},
+		"scopeLogs":[{"logRecords":[
+			{"timeUnixNano":"1700000000000000000","severityNumber":17,"body":{"stringValue":"payment failed"},"traceId":"5b8efff798038103d269b633813fc60c"},
+			{"severityText":"debug","body":{"kvlistValue":{"values":[{"key":"order","value":{"intValue":"42"}}]}}},
+			{"severityNumber":9}
+		]}]}]}`
+
+	r := httptest.NewRequest("POST", "/v1/logs", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	var request collogspb.ExportLogsServiceRequest
+	if !decodeOTLPRequest(w, r, &request) {
+		t.Fatalf("decode failed: %s", w.Body.String())
+	}
+
+	messages := otlpLogMessages(&request)
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0].service != "checkout" || messages[0].level != msg.LogLevelError || messages[0].message != "payment failed" {
+		t.Errorf("unexpected first message %+v", messages[0])
+	}
+	if !messages[0].at.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("got time %v", messages[0].at)
+	}
+	if messages[1].level != msg.LogLevelDebug || messages[1].message != "order=42" {
+		t.Errorf("unexpected second message %+v", messages[1])
+	}
+}
+
+func TestDecodeOTLPMetricsProtobuf(t *testing.T) {
+	body := `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[
+		{"name":"queue_depth","gauge":{"dataPoints":[{"asInt":"7","attributes":[{"key":"queue","value":{"stringValue":"a"}}]},{"asInt":"5","attributes":[{"key":"queue","value":{"stringValue":"b"}}]}]}},
+		{"name":"jobs_total","sum":{"aggregationTemporality":2,"isMonotonic":true,"dataPoints":[{"asDouble":12.5}]}},
+		{"name":"jobs_delta","sum":{"aggregationTemporality":1,"isMonotonic":true,"dataPoints":[{"asDouble":2}]}},
+		{"name":"latency","histogram":{"dataPoints":[{"count":"3","sum":1.5}]}}
+	]}]}]}`
+
+	// Round trip through JSON to build the protobuf body an exporter would send
+	r := httptest.NewRequest("POST", "/v1/metrics", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	var fromJSON colmetricspb.ExportMetricsServiceRequest
+	if !decodeOTLPRequest(httptest.NewRecorder(), r, &fromJSON) {
+		t.Fatal("json decode failed")
+	}
+	data, err := proto.Marshal(&fromJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest("POST", "/v1/metrics", bytes.NewReader(data))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	var request colmetricspb.ExportMetricsServiceRequest
+	if !decodeOTLPRequest(httptest.NewRecorder(), r, &request) {
+		t.Fatal("protobuf decode failed")
+	}
+
+	values := otlpMetricValues(&request)
+	if len(values) != 2 || values["queue_depth"] != 12 || values["jobs_total"] != 12.5 {
+		t.Errorf("unexpected metric values %v", values)
+	}
+}
+
+func TestDecodeOTLPRequestInvalid(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/logs", bytes.NewBufferString("not otlp"))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	var request collogspb.ExportLogsServiceRequest
+	if decodeOTLPRequest(w, r, &request) {
+		t.Fatal("expected decode to fail")
+	}
+	if w.Code != 400 {
+		t.Errorf("got status %d, want 400", w.Code)
+	}
+}
@@ -26,6 +26,11 @@ func ListenAndServe(agent *agent_client.AgentClient) {
 		router.HandleFunc("POST /gelf", handleGelf)
 		router.HandleFunc("POST /loki/api/v1/push", handleLoki)
 		router.HandleFunc("POST /event", handleEvent)
+		router.HandleFunc("POST /v1/logs", handleOTLPLogs)
+		router.HandleFunc("POST /v1/metrics", handleOTLPMetrics)
+		if cfg.Metrics {
+			router.HandleFunc("GET /metrics", handleMetrics)
+		}
 
 		// Run the http server
 		server := &http.Server{
@@ -277,6 +277,8 @@ func (s *agentServer) ConnectAndServe() {
 				s.agentClient.withCodeServer = response.WithCodeServer && cfg.Port.CodeServer > 0
 				s.agentClient.withSSH = response.WithSSH && s.agentClient.sshPort > 0
 				s.agentClient.withRunCommand = response.WithRunCommand && !cfg.DisableSpaceIO
+				s.agentClient.terminalRecording = response.TerminalRecording
+				s.agentClient.recordingMaxSize = response.TerminalRecordingMaxSize
 
 				// If ssh port given then test if to start the ssh server
 				if s.agentClient.withSSH {
@@ -286,7 +288,11 @@ func (s *agentServer) ConnectAndServe() {
 					// Test if the ssh port is open
 					conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", s.agentClient.sshPort))
 					if err != nil {
-						sshd.ListenAndServe(s.agentClient.sshPort, response.SSHHostSigner)
+						sshd.SetForwardHook(s.agentClient.ReportSSHForward)
+						if s.agentClient.terminalRecording {
+							sshd.SetRecording(s.agentClient.recordingMaxSize, s.agentClient.sendSSHRecording)
+						}
+						sshd.ListenAndServe(s.agentClient.sshPort, response.SSHHostSigner, response.SSHHostCertificate)
 						s.agentClient.usingInternalSSH = true
 					} else {
 						log.Info("using external ssh server on port", "port", s.agentClient.sshPort)
@@ -323,18 +329,19 @@ func (s *agentServer) ConnectAndServe() {
 			})
 
 			// Save the keys and github usernames
+			githubUsernames := util.GitHubKeyEntries(response.GitHubUsernames, response.GitHubKeyOptions)
 			s.agentClient.lastPublicSSHKeys = response.SSHKeys
 			s.agentClient.lastPrivateSSHKey = response.SSHPrivateKey
-			s.agentClient.lastGitHubUsernames = response.GitHubUsernames
+			s.agentClient.lastGitHubUsernames = githubUsernames
 
 			// Update the authorized keys file, private key & shell
 			if s.agentClient.usingInternalSSH {
-				if err := sshd.UpdateAuthorizedKeys(response.SSHKeys, response.GitHubUsernames); err != nil {
+				if err := sshd.UpdateAuthorizedKeys(response.SSHKeys, githubUsernames); err != nil {
 					log.WithError(err).Error("updating internal SSH server keys:")
 				}
 				sshd.SetShell(response.Shell)
 			} else if cfg.UpdateAuthorizedKeys && s.agentClient.withSSH {
-				if err := util.UpdateAuthorizedKeys(response.SSHKeys, response.GitHubUsernames); err != nil {
+				if err := util.UpdateAuthorizedKeys(response.SSHKeys, githubUsernames); err != nil {
 					log.WithError(err).Error("updating authorized keys:")
 				}
 			}
@@ -491,21 +498,22 @@ func (s *agentServer) handleAgentClientStream(stream net.Conn) {
 		// Test if the keys have changed
 		s.agentClient.keysMutex.Lock()
 
-		authKeysChanged := !reflect.DeepEqual(updateAuthorizedKeys.SSHKeys, s.agentClient.lastPublicSSHKeys) || !reflect.DeepEqual(updateAuthorizedKeys.GitHubUsernames, s.agentClient.lastGitHubUsernames)
+		githubUsernames := util.GitHubKeyEntries(updateAuthorizedKeys.GitHubUsernames, updateAuthorizedKeys.GitHubKeyOptions)
+		authKeysChanged := !reflect.DeepEqual(updateAuthorizedKeys.SSHKeys, s.agentClient.lastPublicSSHKeys) || !reflect.DeepEqual(githubUsernames, s.agentClient.lastGitHubUsernames)
 		privateKeyChanged := strings.TrimSpace(updateAuthorizedKeys.SSHPrivateKey) != strings.TrimSpace(s.agentClient.lastPrivateSSHKey)
 
 		if authKeysChanged || privateKeyChanged {
 			s.agentClient.lastPublicSSHKeys = updateAuthorizedKeys.SSHKeys
 			s.agentClient.lastPrivateSSHKey = updateAuthorizedKeys.SSHPrivateKey
-			s.agentClient.lastGitHubUsernames = updateAuthorizedKeys.GitHubUsernames
+			s.agentClient.lastGitHubUsernames = githubUsernames
 
 			if authKeysChanged {
 				if s.agentClient.usingInternalSSH {
-					if err := sshd.UpdateAuthorizedKeys(updateAuthorizedKeys.SSHKeys, updateAuthorizedKeys.GitHubUsernames); err != nil {
+					if err := sshd.UpdateAuthorizedKeys(updateAuthorizedKeys.SSHKeys, githubUsernames); err != nil {
 						log.WithError(err).Error("updating internal SSH server keys:")
 					}
 				} else if cfg.UpdateAuthorizedKeys && s.agentClient.withSSH {
-					if err := util.UpdateAuthorizedKeys(updateAuthorizedKeys.SSHKeys, updateAuthorizedKeys.GitHubUsernames); err != nil {
+					if err := util.UpdateAuthorizedKeys(updateAuthorizedKeys.SSHKeys, githubUsernames); err != nil {
 						log.WithError(err).Error("updating authorized keys:")
 					}
 				}
@@ -538,16 +546,43 @@ func (s *agentServer) handleAgentClientStream(stream net.Conn) {
 		}
 
 		if s.agentClient.withTerminal {
-			startTerminal(stream, terminal.Shell)
+			defer s.agentClient.beginInteractiveSession()()
+			if terminal.Session != "" {
+				s.agentClient.attachSharedTerminal(stream, terminal, s)
+			} else {
+				recorder := s.agentClient.newTerminalRecorder(terminal.Shell)
+				startTerminal(stream, terminal.Shell, recorder)
+				s.agentClient.sendTerminalRecording(recorder, s, terminal.RecordingId)
+			}
+		}
+
+	case byte(msg.CmdTerminalSessionList):
+		var request msg.TerminalSessionListRequest
+		if err := msg.ReadMessage(stream, &request); err != nil {
+			log.WithError(err).Error("reading terminal session list message:")
+			return
 		}
 
+		handleTerminalSessionList(stream)
+
+	case byte(msg.CmdTerminalSessionDetach):
+		var request msg.TerminalSessionDetachRequest
+		if err := msg.ReadMessage(stream, &request); err != nil {
+			log.WithError(err).Error("reading terminal session detach message:")
+			return
+		}
+
+		handleTerminalSessionDetach(stream, request)
+
 	case byte(msg.CmdVSCodeTunnelTerminal):
 		if s.agentClient.withVSCodeTunnel {
+			defer s.agentClient.beginInteractiveSession()()
 			startVSCodeTunnelTerminal(stream)
 		}
 
 	case byte(msg.CmdCodeServer):
 		if s.agentClient.withCodeServer {
+			defer s.agentClient.beginInteractiveSession()()
 			agentproxy.ProxyTcp(stream, fmt.Sprintf("%d", cfg.Port.CodeServer))
 		}
 
@@ -567,6 +602,9 @@ func (s *agentServer) handleAgentClientStream(stream net.Conn) {
 		   		} */
 
 		s.agentClient.tcpConnectionsTotal.Add(1)
+		if s.agentClient.withSSH && int(tcpPort.Port) == s.agentClient.sshPort {
+			defer s.agentClient.beginInteractiveSession()()
+		}
 		agentproxy.ProxyTcp(stream, fmt.Sprintf("%d", tcpPort.Port))
 
 	case byte(msg.CmdProxyVNC):
@@ -47,10 +47,13 @@ type AgentClient struct {
 	withCodeServer         bool
 	withSSH                bool
 	withRunCommand         bool
+	terminalRecording      bool
+	recordingMaxSize       int
 	httpPortMap            map[string]string
 	httpsPortMap           map[string]string
 	tcpPortMap             map[string]string
 	logChannel             chan *msg.LogMessage
+	logSeq                 atomic.Uint64
 
 	// Health check config — received from server at registration
 	healthCheckMu            sync.RWMutex
@@ -73,10 +76,21 @@ type AgentClient struct {
 	activityRenameCount   uint32
 	activityDistinctPaths uint32
 	lastActivityAtUnix    int64
+	activeSessions        atomic.Int32
 	methodCallsTotal      atomic.Uint64
 	httpRequestsTotal     atomic.Uint64
 	tcpConnectionsTotal   atomic.Uint64
 
+	// Last resource usage collected by reportState, served by Metrics so a
+	// scrape doesn't reset the CPU measurement window
+	usageMu   sync.RWMutex
+	lastUsage ResourceUsage
+
+	// Application metrics pushed to the service API, sent with each state
+	// report
+	customMetricsMu sync.Mutex
+	customMetrics   map[string]customMetric
+
 	methodMu     sync.RWMutex
 	methodServer *methodServerProcess
 
@@ -107,6 +121,7 @@ func NewAgentClient(defaultServerAddress, spaceId string) *AgentClient {
 		httpPortMap:          make(map[string]string),
 		httpsPortMap:         make(map[string]string),
 		tcpPortMap:           make(map[string]string),
+		customMetrics:        make(map[string]customMetric),
 		logChannel:           make(chan *msg.LogMessage, logChannelBufferSize),
 		healthy:              true,
 	}
@@ -276,6 +291,25 @@ func (c *AgentClient) GetServerURL() string {
 	return c.serverURL
 }
 
+// beginInteractiveSession records the start of a terminal, SSH or code-server
+// connection so the server does not treat the space as idle while it is open.
+// The returned function must be called when the connection ends.
+func (c *AgentClient) beginInteractiveSession() func() {
+	c.activeSessions.Add(1)
+	c.touchActivity()
+
+	return func() {
+		c.activeSessions.Add(-1)
+		c.touchActivity()
+	}
+}
+
+func (c *AgentClient) touchActivity() {
+	c.activityMu.Lock()
+	c.lastActivityAtUnix = time.Now().UTC().Unix()
+	c.activityMu.Unlock()
+}
+
 func (c *AgentClient) snapshotActivityState() (uint32, uint32, uint32, uint32, uint32, int64) {
 	c.activityMu.RLock()
 	defer c.activityMu.RUnlock()
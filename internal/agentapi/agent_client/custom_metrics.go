@@ -0,0 +1,56 @@
+package agent_client
+
+import "time"
+
+const (
+	maxCustomMetrics     = 100             // Metrics beyond this are dropped until older ones expire
+	customMetricLifetime = 5 * time.Minute // Metrics not updated within this time are no longer reported
+)
+
+type customMetric struct {
+	value     float64
+	updatedAt time.Time
+}
+
+// SetCustomMetric records the latest value of an application metric, it's
+// reported to the servers with the space's state until it goes stale.
+func (c *AgentClient) SetCustomMetric(name string, value float64) {
+	c.customMetricsMu.Lock()
+	defer c.customMetricsMu.Unlock()
+
+	if _, ok := c.customMetrics[name]; !ok && len(c.customMetrics) >= maxCustomMetrics {
+		c.expireCustomMetricsLocked()
+		if len(c.customMetrics) >= maxCustomMetrics {
+			return
+		}
+	}
+
+	c.customMetrics[name] = customMetric{value: value, updatedAt: time.Now()}
+}
+
+// CustomMetrics returns the current value of each application metric that
+// hasn't gone stale, nil if there are none.
+func (c *AgentClient) CustomMetrics() map[string]float64 {
+	c.customMetricsMu.Lock()
+	defer c.customMetricsMu.Unlock()
+
+	c.expireCustomMetricsLocked()
+	if len(c.customMetrics) == 0 {
+		return nil
+	}
+
+	metrics := make(map[string]float64, len(c.customMetrics))
+	for name, metric := range c.customMetrics {
+		metrics[name] = metric.value
+	}
+	return metrics
+}
+
+func (c *AgentClient) expireCustomMetricsLocked() {
+	cutoff := time.Now().Add(-customMetricLifetime)
+	for name, metric := range c.customMetrics {
+		if metric.updatedAt.Before(cutoff) {
+			delete(c.customMetrics, name)
+		}
+	}
+}
@@ -31,6 +31,12 @@ func (c *AgentClient) initLogMessages() {
 }
 
 func (c *AgentClient) SendLogMessage(service string, level msg.LogLevel, message string) error {
+	return c.SendLogMessageAt(service, level, message, time.Now())
+}
+
+// SendLogMessageAt sends a log line that was written at the given time, for
+// sources that timestamp their own records.
+func (c *AgentClient) SendLogMessageAt(service string, level msg.LogLevel, message string, at time.Time) error {
 	// replace all \n without a \r with \r\n
 	message = strings.ReplaceAll(message, "\n", "\r\n")
 
@@ -42,7 +48,8 @@ func (c *AgentClient) SendLogMessage(service string, level msg.LogLevel, message
 		Service: service,
 		Level:   level,
 		Message: message,
-		Date:    time.Now(),
+		Date:    at,
+		Seq:     c.logSeq.Add(1),
 	}:
 	default:
 		// Queue full, drop message
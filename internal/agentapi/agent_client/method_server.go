@@ -1,30 +1,43 @@
 package agent_client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os/exec"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/paularlott/jsonrpc"
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	defaultMethodTimeoutSeconds = 30
+	methodServerShutdownTimeout = 5 * time.Second
+	progressQueueSize           = 256
 )
 
-// methodServerProcess owns the long-running stdio method server. The JSON-RPC
+// methodServerProcess owns the method server. For stdio servers the JSON-RPC
 // framing, response correlation, subprocess lifecycle and reaping are handled
-// by the jsonrpc package (NewProcessTransport + Client); this struct holds the
-// client, the per-server concurrency policy and the closed signal used to bail
-// out of serial-mode acquire promptly when the process dies.
+// by the jsonrpc package (NewStreamTransport + Client, the output is filtered
+// for progress notifications first); http and unix servers
+// use the jsonrpc HTTP transport against an existing listener. This struct
+// holds the client, the per-server concurrency policy and the closed signal
+// used to bail out of serial-mode acquire promptly when the server goes away.
 type methodServerProcess struct {
 	reg *methods.Registration
 
@@ -38,18 +51,33 @@ type methodServerProcess struct {
 	// (concurrent mode). A buffered channel of size 1 enforces serial mode.
 	semaphore chan struct{}
 
-	// closed is signalled once the subprocess has exited (via the jsonrpc
-	// OnExit hook) or the server has been shut down, so serial-mode acquire
+	// closed is signalled once the subprocess has exited (via the onExit
+	// hook) or the server has been shut down, so serial-mode acquire
 	// can fail fast instead of waiting for the per-call timeout.
 	closed    chan struct{}
 	closeOnce sync.Once
+
+	// unhealthy is set by the health probe of an http or unix server while its
+	// listener is unreachable, the methods are unpublished until it recovers.
+	// Stdio servers are never marked unhealthy.
+	unhealthy atomic.Bool
+
+	// progress maps the progress token of each in-flight streaming call to
+	// the relay delivering its notifications.
+	progress    sync.Map
+	progressSeq atomic.Uint64
 }
 
 func (c *AgentClient) RegisterMethods(reg *methods.Registration) error {
 	if reg == nil {
 		return fmt.Errorf("registration is required")
 	}
-	if reg.Server.Command == "" {
+	switch {
+	case reg.Server.Type == methods.ServerTypeHTTP && reg.Server.URL == "":
+		return fmt.Errorf("server url is required")
+	case reg.Server.Type == methods.ServerTypeUnix && reg.Server.Socket == "":
+		return fmt.Errorf("server socket is required")
+	case !reg.Server.IsRemote() && reg.Server.Command == "":
 		return fmt.Errorf("server command is required")
 	}
 	if err := c.startMethodServer(reg); err != nil {
@@ -119,6 +147,15 @@ func (c *AgentClient) republishMethods() {
 	if reg == nil {
 		return
 	}
+
+	// An unreachable http or unix server is republished by its health probe
+	c.methodMu.RLock()
+	server := c.methodServer
+	c.methodMu.RUnlock()
+	if server != nil && server.unhealthy.Load() {
+		return
+	}
+
 	if err := c.publishMethods(reg); err != nil {
 		log.WithError(err).Warn("republishMethods: failed to re-publish after reconnect")
 		return
@@ -129,17 +166,15 @@ func (c *AgentClient) republishMethods() {
 func (c *AgentClient) startMethodServer(reg *methods.Registration) error {
 	c.stopMethodServer()
 
+	if reg.Server.IsRemote() {
+		return c.startRemoteMethodServer(reg)
+	}
+
 	// stderr is forwarded to the knot server as log lines, one SendLogMessage
 	// per newline-delimited chunk (matching the previous pumpStderr behaviour).
 	logWriter := &stderrLogWriter{c: c}
 
-	server := &methodServerProcess{
-		reg:    reg,
-		closed: make(chan struct{}),
-	}
-	if reg.Server.Mode == methods.ModeSerial {
-		server.semaphore = make(chan struct{}, 1)
-	}
+	server := newMethodServer(reg)
 
 	// onExit runs once when the subprocess exits — whether it crashes
 	// mid-session or is shut down by Close. It mirrors the cmd.Wait goroutine
@@ -170,15 +205,12 @@ func (c *AgentClient) startMethodServer(reg *methods.Registration) error {
 		}
 	}
 
-	transport, err := jsonrpc.NewProcessTransport(
-		reg.Server.Command, reg.Server.Args,
-		jsonrpc.WithStderr(logWriter),
-		jsonrpc.WithOnExit(onExit),
-	)
+	stdin, stdout, closeFn, err := spawnMethodServer(reg, logWriter, onExit)
 	if err != nil {
 		return err
 	}
 
+	transport := jsonrpc.NewStreamTransport(server.progressReader(stdout), stdin, jsonrpc.WithCloseFunc(closeFn))
 	server.client = jsonrpc.NewClient(transport)
 	server.transport = transport
 
@@ -189,6 +221,59 @@ func (c *AgentClient) startMethodServer(reg *methods.Registration) error {
 	return nil
 }
 
+// newMethodServer creates the server state shared by all server types.
+func newMethodServer(reg *methods.Registration) *methodServerProcess {
+	server := &methodServerProcess{
+		reg:    reg,
+		closed: make(chan struct{}),
+	}
+	if reg.Server.Mode == methods.ModeSerial {
+		server.semaphore = make(chan struct{}, 1)
+	}
+	return server
+}
+
+// spawnMethodServer starts a stdio method server, it matches
+// jsonrpc.NewProcessTransport but leaves the output with the caller so it can
+// be filtered. closeFn closes stdin, waits for the process to exit and kills it
+// if it doesn't, onExit runs once the process has been reaped.
+func spawnMethodServer(reg *methods.Registration, stderr io.Writer, onExit func(error)) (io.WriteCloser, io.Reader, func() error, error) {
+	cmd := exec.Command(reg.Server.Command, reg.Server.Args...)
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to start method server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		exited <- err
+		onExit(err)
+	}()
+
+	closeFn := func() error {
+		_ = stdin.Close()
+		select {
+		case err := <-exited:
+			return err
+		case <-time.After(methodServerShutdownTimeout):
+			_ = cmd.Process.Kill()
+			return <-exited
+		}
+	}
+
+	return stdin, stdout, closeFn, nil
+}
+
 func (c *AgentClient) stopMethodServer() {
 	c.methodMu.Lock()
 	server := c.methodServer
@@ -201,8 +286,8 @@ func (c *AgentClient) stopMethodServer() {
 	server.markClosed()
 	if server.client != nil {
 		// Close closes stdin (signalling the child to exit), waits up to the
-		// jsonrpc shutdown timeout, then kills if needed. The OnExit hook
-		// above runs as the child is reaped.
+		// shutdown timeout, then kills if needed. The onExit hook above runs
+		// as the child is reaped.
 		_ = server.client.Close()
 	}
 }
@@ -265,12 +350,23 @@ func (c *AgentClient) publishMethods(reg *methods.Registration) error {
 // time. The caller's original id is preserved on the returned response — the
 // jsonrpc client generates its own wire ids, so they never need to be unique.
 func (c *AgentClient) CallMethod(req msg.CallMethodRequest) methods.JSONRPCResponse {
+	return c.CallMethodStream(req, nil)
+}
+
+// CallMethodStream is CallMethod for a streaming method, a progress token is
+// added to the params as _meta.progressToken and onProgress receives each
+// progress notification the method server sends with it. Params that are not
+// a JSON object can't carry the token, the call then runs without progress.
+func (c *AgentClient) CallMethodStream(req msg.CallMethodRequest, onProgress func(*methods.Progress)) methods.JSONRPCResponse {
 	c.methodMu.RLock()
 	server := c.methodServer
 	c.methodMu.RUnlock()
 	if server == nil || server.client == nil {
 		return methodError(req.ID, -32000, "no method server available")
 	}
+	if server.unhealthy.Load() {
+		return methodError(req.ID, -32000, "method server unavailable")
+	}
 
 	timeout := server.reg.Server.Timeout
 	if timeout <= 0 {
@@ -278,8 +374,24 @@ func (c *AgentClient) CallMethod(req msg.CallMethodRequest) methods.JSONRPCRespo
 	}
 
 	// One deadline gates both the serial-slot acquire and the call itself, so
-	// the total time can never exceed the configured per-call timeout.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	// the total time can never exceed the configured per-call timeout. A
+	// streaming call instead times out once it goes quiet, each progress
+	// notification restarts the timer.
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if onProgress == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+		idle := time.AfterFunc(time.Duration(timeout)*time.Second, cancel)
+		defer idle.Stop()
+
+		relay := onProgress
+		onProgress = func(progress *methods.Progress) {
+			idle.Reset(time.Duration(timeout) * time.Second)
+			relay(progress)
+		}
+	}
 	defer cancel()
 
 	release, acquireErr := server.acquire(ctx)
@@ -288,8 +400,20 @@ func (c *AgentClient) CallMethod(req msg.CallMethodRequest) methods.JSONRPCRespo
 	}
 	defer release()
 
+	params := req.Params
+	if onProgress != nil {
+		token := strconv.FormatUint(server.progressSeq.Add(1), 10)
+		if withToken, ok := withProgressToken(params, token); ok {
+			params = withToken
+			relay := newProgressRelay(onProgress)
+			server.progress.Store(token, relay)
+			defer relay.close()
+			defer server.progress.Delete(token)
+		}
+	}
+
 	var rawResult json.RawMessage
-	err := server.client.Call(ctx, req.Method, req.Params, &rawResult)
+	err := server.client.Call(ctx, req.Method, params, &rawResult)
 	if err == nil {
 		c.methodCallsTotal.Add(1)
 		return methods.JSONRPCResponse{
@@ -322,19 +446,50 @@ func (c *AgentClient) SendNotification(req msg.CallMethodRequest) {
 	c.methodMu.RLock()
 	server := c.methodServer
 	c.methodMu.RUnlock()
-	if server == nil || server.client == nil {
+	if server == nil || server.client == nil || server.unhealthy.Load() {
 		return
 	}
 	_ = server.client.Notify(context.Background(), req.Method, req.Params)
 }
 
 func handleCallMethodExecution(stream net.Conn, agentClient *AgentClient, call msg.CallMethodRequest) {
+	_, span := tracing.StartKind(tracing.FromCarrier(context.Background(), call.TraceContext), "agent.handle_call_method", trace.SpanKindServer,
+		attribute.String("knot.method", call.Method),
+	)
+	defer span.End()
+
 	if call.IsNotification {
 		agentClient.SendNotification(call)
 		return
 	}
-	response := agentClient.CallMethod(call)
-	_ = msg.WriteMessage(stream, &msg.CallMethodResponse{Response: response})
+	if !call.Stream {
+		response := agentClient.CallMethod(call)
+		if response.Error != nil {
+			span.SetStatus(codes.Error, response.Error.Message)
+		}
+		_ = msg.WriteMessage(stream, &msg.CallMethodResponse{Response: response})
+		return
+	}
+
+	// Notifications can arrive on their own goroutines, the lock keeps the
+	// frames whole and stops any arriving after the response being written
+	var mu sync.Mutex
+	done := false
+	response := agentClient.CallMethodStream(call, func(progress *methods.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			_ = msg.WriteMessage(stream, &msg.CallMethodStreamFrame{Progress: progress})
+		}
+	})
+
+	mu.Lock()
+	done = true
+	mu.Unlock()
+	if response.Error != nil {
+		span.SetStatus(codes.Error, response.Error.Message)
+	}
+	_ = msg.WriteMessage(stream, &msg.CallMethodStreamFrame{Response: &response})
 }
 
 // handleCallMethodBatchExecution forwards each item in the batch to the method
@@ -381,12 +536,154 @@ func (s *methodServerProcess) acquire(ctx context.Context) (func(), error) {
 	}
 }
 
+// progressReader returns the method server's output with the progress
+// notifications removed. They are relayed as they are read, so they keep their
+// order and always arrive before the response to their call.
+func (s *methodServerProcess) progressReader(stdout io.Reader) io.Reader {
+	return &progressFilter{reader: bufio.NewReader(stdout), server: s}
+}
+
+type progressFilter struct {
+	reader  *bufio.Reader
+	server  *methodServerProcess
+	pending []byte
+	err     error
+}
+
+func (f *progressFilter) Read(p []byte) (int, error) {
+	for len(f.pending) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+
+		line, err := f.reader.ReadBytes('\n')
+		f.err = err
+		if len(line) > 0 && !f.server.relayProgress(line) {
+			f.pending = line
+		}
+	}
+
+	n := copy(p, f.pending)
+	f.pending = f.pending[n:]
+	return n, nil
+}
+
+// relayProgress passes a progress notification to the streaming call holding
+// its token, false is returned if the line isn't a progress notification.
+func (s *methodServerProcess) relayProgress(line []byte) bool {
+	if !bytes.Contains(line, []byte(methods.ProgressMethod)) {
+		return false
+	}
+
+	var notification struct {
+		Method string           `json:"method"`
+		ID     json.RawMessage  `json:"id"`
+		Params methods.Progress `json:"params"`
+	}
+	if err := json.Unmarshal(line, &notification); err != nil || notification.Method != methods.ProgressMethod || notification.ID != nil {
+		return false
+	}
+
+	progress := notification.Params
+	if relay, ok := s.progress.Load(fmt.Sprint(progress.ProgressToken)); ok {
+		progress.ProgressToken = nil
+		relay.(*progressRelay).send(&progress)
+	}
+	return true
+}
+
+// progressRelay hands the progress of a streaming call to its own goroutine,
+// so a slow caller never holds up the reader shared by every call to the
+// method server.
+type progressRelay struct {
+	mu      sync.Mutex
+	queue   chan *methods.Progress
+	done    chan struct{}
+	closed  bool
+	dropped int
+}
+
+func newProgressRelay(onProgress func(*methods.Progress)) *progressRelay {
+	r := &progressRelay{
+		queue: make(chan *methods.Progress, progressQueueSize),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(r.done)
+		for progress := range r.queue {
+			onProgress(progress)
+		}
+	}()
+	return r
+}
+
+// send queues a notification without blocking, it is dropped if the queue is
+// full.
+func (r *progressRelay) send(progress *methods.Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- progress:
+	default:
+		r.dropped++
+	}
+}
+
+// close stops the relay once the queued notifications are delivered, so they
+// still arrive before the response to the call.
+func (r *progressRelay) close() {
+	r.mu.Lock()
+	r.closed = true
+	close(r.queue)
+	dropped := r.dropped
+	r.mu.Unlock()
+
+	<-r.done
+	if dropped > 0 {
+		log.Warn("method progress fell behind, notifications dropped", "dropped", dropped)
+	}
+}
+
+// withProgressToken adds _meta.progressToken to object params, false is
+// returned if the params are not an object.
+func withProgressToken(params json.RawMessage, token string) (json.RawMessage, bool) {
+	fields := map[string]json.RawMessage{}
+	if trimmed := bytes.TrimSpace(params); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			return params, false
+		}
+	}
+
+	meta := map[string]any{}
+	if raw, ok := fields["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return params, false
+		}
+	}
+	meta["progressToken"] = token
+
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return params, false
+	}
+	fields["_meta"] = rawMeta
+
+	result, err := json.Marshal(fields)
+	if err != nil {
+		return params, false
+	}
+	return result, true
+}
+
 func (s *methodServerProcess) markClosed() {
 	s.closeOnce.Do(func() { close(s.closed) })
 }
 
 // shutdown is used by tests to fail a wired-up (pipe) server. For a real
-// process, closing the client drives the child down and the OnExit hook
+// process, closing the client drives the child down and the onExit hook
 // performs the full cleanup.
 func (s *methodServerProcess) shutdown() {
 	s.markClosed()
@@ -420,10 +717,10 @@ func toJSONResult(raw json.RawMessage) any {
 	return v
 }
 
-// stderrLogWriter is an io.Writer fed to jsonrpc.WithStderr. It buffers child
+// stderrLogWriter is the stderr of the method server process. It buffers child
 // stderr bytes and emits one SendLogMessage per newline-delimited line, the
 // same shape the previous pumpStderr produced. flush emits any trailing
-// partial line and is called from the OnExit hook once the child has exited.
+// partial line and is called from the onExit hook once the child has exited.
 type stderrLogWriter struct {
 	c   *AgentClient
 	mu  sync.Mutex
@@ -0,0 +1,196 @@
+package agent_client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/paularlott/jsonrpc"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/methods"
+)
+
+const healthProbeTimeout = 5 * time.Second
+
+// remoteEndpoint describes how to reach an http or unix method server.
+type remoteEndpoint struct {
+	httpClient *http.Client
+	rpcURL     string
+	baseURL    *url.URL
+	network    string
+	address    string
+}
+
+func newRemoteEndpoint(server *methods.ServerConfig) (*remoteEndpoint, error) {
+	switch server.Type {
+	case methods.ServerTypeHTTP:
+		u, err := url.Parse(server.URL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid server url %q", server.URL)
+		}
+
+		address := u.Host
+		if u.Port() == "" {
+			if u.Scheme == "https" {
+				address = net.JoinHostPort(u.Hostname(), "443")
+			} else {
+				address = net.JoinHostPort(u.Hostname(), "80")
+			}
+		}
+
+		return &remoteEndpoint{
+			httpClient: &http.Client{},
+			rpcURL:     server.URL,
+			baseURL:    u,
+			network:    "tcp",
+			address:    address,
+		}, nil
+
+	case methods.ServerTypeUnix:
+		socket := server.Socket
+		path := server.Path
+		if path == "" {
+			path = "/"
+		}
+
+		// The host is ignored, every connection is made to the socket
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+
+		base := &url.URL{Scheme: "http", Host: "unix"}
+		return &remoteEndpoint{
+			httpClient: &http.Client{Transport: transport},
+			rpcURL:     base.JoinPath(path).String(),
+			baseURL:    base,
+			network:    "unix",
+			address:    socket,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported server type %q", server.Type)
+}
+
+// probe checks the method server is reachable. With a health path a GET must
+// return a 2xx status, otherwise a connection must be accepted.
+func (e *remoteEndpoint) probe(ctx context.Context, server *methods.ServerConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	if server.HealthPath == "" {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, e.network, e.address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	probeURL := *e.baseURL
+	probeURL.Path = server.HealthPath
+	probeURL.RawQuery = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range server.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// startRemoteMethodServer connects to an existing http or unix listener. The
+// listener must pass its health probe before the methods are published, after
+// that the probe runs every health_interval seconds and the methods are
+// unpublished while the listener is down.
+func (c *AgentClient) startRemoteMethodServer(reg *methods.Registration) error {
+	endpoint, err := newRemoteEndpoint(&reg.Server)
+	if err != nil {
+		return err
+	}
+
+	if err := endpoint.probe(context.Background(), &reg.Server); err != nil {
+		return fmt.Errorf("method server not reachable: %w", err)
+	}
+
+	opts := []jsonrpc.HTTPOption{jsonrpc.WithHTTPClient(endpoint.httpClient)}
+	for key, value := range reg.Server.Headers {
+		opts = append(opts, jsonrpc.WithHeader(key, value))
+	}
+
+	server := newMethodServer(reg)
+	server.client = jsonrpc.NewClient(jsonrpc.NewHTTPTransport(endpoint.rpcURL, opts...))
+
+	c.methodMu.Lock()
+	c.methodServer = server
+	c.methodMu.Unlock()
+
+	go c.probeMethodServer(server, endpoint)
+
+	return nil
+}
+
+// probeMethodServer runs the health probe until the server is stopped.
+func (c *AgentClient) probeMethodServer(server *methodServerProcess, endpoint *remoteEndpoint) {
+	interval := time.Duration(server.reg.Server.HealthInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-server.closed:
+			return
+		case <-ticker.C:
+		}
+
+		err := endpoint.probe(context.Background(), &server.reg.Server)
+
+		// Ignore the result if the server was replaced while probing
+		c.methodMu.RLock()
+		current := c.methodServer == server
+		c.methodMu.RUnlock()
+		if !current {
+			return
+		}
+
+		if err != nil {
+			if !server.unhealthy.Swap(true) {
+				log.WithError(err).Warn("method server unreachable, unpublishing methods", "type", server.reg.Server.Type)
+				c.unregisterMethods()
+			}
+			continue
+		}
+
+		if server.unhealthy.Load() {
+			if err := c.publishMethods(server.reg); err != nil {
+				log.WithError(err).Warn("method server recovered but methods could not be republished")
+				continue
+			}
+			server.unhealthy.Store(false)
+			log.Info("method server recovered, methods republished", "type", server.reg.Server.Type)
+		}
+	}
+}
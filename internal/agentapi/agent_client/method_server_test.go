@@ -3,7 +3,10 @@ package agent_client
 import (
 	"encoding/json"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -57,16 +60,10 @@ func newFakeMethodServer(t *testing.T, handler func(req methods.JSONRPCRequest)
 // by the given pipes (no real os/exec process), so CallMethod can be exercised
 // in isolation.
 func attachServer(client *AgentClient, reg *methods.Registration, stdin io.WriteCloser, stdout io.ReadCloser) *methodServerProcess {
-	transport := jsonrpc.NewStreamTransport(stdout, stdin)
-	server := &methodServerProcess{
-		reg:       reg,
-		client:    jsonrpc.NewClient(transport),
-		transport: transport,
-		closed:    make(chan struct{}),
-	}
-	if reg.Server.Mode == methods.ModeSerial {
-		server.semaphore = make(chan struct{}, 1)
-	}
+	server := newMethodServer(reg)
+	transport := jsonrpc.NewStreamTransport(server.progressReader(stdout), stdin)
+	server.client = jsonrpc.NewClient(transport)
+	server.transport = transport
 	client.methodMu.Lock()
 	client.methodServer = server
 	client.methodMu.Unlock()
@@ -224,3 +221,196 @@ func TestCallMethodFailsAfterShutdown(t *testing.T) {
 		t.Fatal("expected error after shutdown")
 	}
 }
+
+func TestRemoteMethodServerUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "rpc.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("POST /rpc", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req methods.JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(methods.JSONRPCResponse{JSONRPC: "2.0", Result: "pong:" + req.Method, ID: req.ID})
+	})
+	httpServer := &http.Server{Handler: mux}
+	go httpServer.Serve(listener)
+	defer httpServer.Close()
+
+	client := NewAgentClient("test:0", "space")
+	reg := &methods.Registration{Server: methods.ServerConfig{
+		Type:       methods.ServerTypeUnix,
+		Socket:     socket,
+		Path:       "/rpc",
+		HealthPath: "/healthz",
+		Headers:    map[string]string{"X-Token": "abc"},
+		Mode:       methods.ModeSerial,
+		Timeout:    5,
+	}}
+	if err := client.startMethodServer(reg); err != nil {
+		t.Fatalf("startMethodServer: %v", err)
+	}
+	defer client.stopMethodServer()
+
+	resp := client.CallMethod(msg.CallMethodRequest{Method: "ping", ID: 7})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+	if resp.Result != "pong:ping" || resp.ID != 7 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestRemoteMethodServerUnreachable(t *testing.T) {
+	client := NewAgentClient("test:0", "space")
+	reg := &methods.Registration{Server: methods.ServerConfig{
+		Type:   methods.ServerTypeUnix,
+		Socket: filepath.Join(t.TempDir(), "missing.sock"),
+	}}
+	if err := client.startMethodServer(reg); err == nil {
+		client.stopMethodServer()
+		t.Fatal("expected an error for an unreachable method server")
+	}
+}
+
+func TestCallMethodStreamRelaysProgress(t *testing.T) {
+	stdinRead, stdin, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	stdout, stdoutWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+
+	// The fake server reports progress for the token it was given and then
+	// returns the result
+	go func() {
+		defer stdinRead.Close()
+		defer stdoutWrite.Close()
+		decoder := json.NewDecoder(stdinRead)
+		encoder := json.NewEncoder(stdoutWrite)
+		var req struct {
+			Method string `json:"method"`
+			Params struct {
+				Name string `json:"name"`
+				Meta struct {
+					ProgressToken string `json:"progressToken"`
+				} `json:"_meta"`
+			} `json:"params"`
+			ID json.RawMessage `json:"id"`
+		}
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+		for i := 1; i <= 2; i++ {
+			_ = encoder.Encode(methods.ProgressNotification(&methods.Progress{
+				ProgressToken: req.Params.Meta.ProgressToken,
+				Progress:      float64(i),
+				Total:         2,
+				Data:          "line",
+			}))
+		}
+		_ = encoder.Encode(map[string]any{"jsonrpc": "2.0", "result": "done:" + req.Params.Name, "id": req.ID})
+	}()
+
+	client := NewAgentClient("test:0", "space")
+	reg := &methods.Registration{Server: methods.ServerConfig{Mode: methods.ModeConcurrent, Timeout: 5}}
+	server := attachServer(client, reg, stdin, stdout)
+	defer server.shutdown()
+	defer stdin.Close()
+
+	var mu sync.Mutex
+	var received []*methods.Progress
+	resp := client.CallMethodStream(msg.CallMethodRequest{Method: "export", Params: json.RawMessage(`{"name":"notes"}`), ID: 3}, func(p *methods.Progress) {
+		mu.Lock()
+		received = append(received, p)
+		mu.Unlock()
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+	if resp.Result != "done:notes" || resp.ID != 3 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected 2 progress notifications, got %d", len(received))
+	}
+	for i, p := range received {
+		if p.Progress != float64(i+1) || p.Total != 2 || p.Data != "line" || p.ProgressToken != nil {
+			t.Errorf("unexpected progress %+v", p)
+		}
+	}
+}
+
+func TestWithProgressToken(t *testing.T) {
+	for _, params := range []string{``, `null`, `{}`, `{"a":1}`, `{"_meta":{"trace":"x"}}`} {
+		raw, ok := withProgressToken(json.RawMessage(params), "7")
+		if !ok {
+			t.Fatalf("expected token to be added to %q", params)
+		}
+		var decoded struct {
+			Meta map[string]any `json:"_meta"`
+		}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			t.Fatalf("unmarshal %s: %v", raw, err)
+		}
+		if decoded.Meta["progressToken"] != "7" {
+			t.Errorf("missing token in %s", raw)
+		}
+	}
+
+	if _, ok := withProgressToken(json.RawMessage(`[1,2]`), "7"); ok {
+		t.Errorf("expected array params to be left alone")
+	}
+}
+
+func TestProgressRelayDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var received []float64
+	relay := newProgressRelay(func(p *methods.Progress) {
+		<-release
+		received = append(received, p.Progress)
+	})
+
+	// The caller is stuck on the first notification, sends must still return
+	sent := make(chan struct{})
+	go func() {
+		for i := 1; i <= progressQueueSize+10; i++ {
+			relay.send(&methods.Progress{Progress: float64(i)})
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("send blocked on a slow caller")
+	}
+
+	close(release)
+	relay.close()
+	if len(received) == 0 || len(received) > progressQueueSize+1 {
+		t.Fatalf("unexpected number of notifications delivered: %d", len(received))
+	}
+	for i, p := range received {
+		if p != float64(i+1) {
+			t.Fatalf("notifications delivered out of order: %v", received)
+		}
+	}
+	relay.send(&methods.Progress{Progress: 0})
+}
@@ -0,0 +1,40 @@
+package agent_client
+
+// ResourceUsage is the space's resource usage as last reported to the servers.
+type ResourceUsage struct {
+	CPUPercent       float64
+	MemoryUsedBytes  uint64
+	MemoryLimitBytes uint64
+	DiskUsedBytes    uint64
+	DiskLimitBytes   uint64
+}
+
+// AgentMetrics is a point in time view of the agent's counters.
+type AgentMetrics struct {
+	ResourceUsage
+	ConnectedServers    int
+	ActiveSessions      int32
+	MethodCallsTotal    uint64
+	HTTPRequestsTotal   uint64
+	TCPConnectionsTotal uint64
+}
+
+// Metrics returns the agent's counters and last resource usage.
+func (c *AgentClient) Metrics() AgentMetrics {
+	c.usageMu.RLock()
+	usage := c.lastUsage
+	c.usageMu.RUnlock()
+
+	c.serverListMutex.RLock()
+	servers := len(c.serverList)
+	c.serverListMutex.RUnlock()
+
+	return AgentMetrics{
+		ResourceUsage:       usage,
+		ConnectedServers:    servers,
+		ActiveSessions:      c.activeSessions.Load(),
+		MethodCallsTotal:    c.methodCallsTotal.Load(),
+		HTTPRequestsTotal:   c.httpRequestsTotal.Load(),
+		TCPConnectionsTotal: c.tcpConnectionsTotal.Load(),
+	}
+}
@@ -0,0 +1,96 @@
+package agent_client
+
+import (
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/asciicast"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/sshd"
+)
+
+// newTerminalRecorder returns a recorder for a web terminal, or nil if the
+// template doesn't record sessions. The browser sends its size once connected.
+func (c *AgentClient) newTerminalRecorder(shell string) *asciicast.Recorder {
+	if !c.terminalRecording {
+		return nil
+	}
+	return asciicast.NewRecorder(80, 24, shell, c.recordingMaxSize)
+}
+
+// sendTerminalRecording finishes a web terminal recording and sends it to the
+// server that opened the terminal, only it knows the user the recording ID
+// belongs to.
+func (c *AgentClient) sendTerminalRecording(recorder *asciicast.Recorder, server *agentServer, recordingId string) {
+	if recorder == nil || recordingId == "" {
+		return
+	}
+
+	recording, err := recorder.Finish()
+	if err != nil {
+		log.WithError(err).Error("failed to finish terminal recording")
+		return
+	}
+
+	if !server.sendRecording(&msg.TerminalRecording{
+		Source:      model.TerminalRecordingSourceWeb,
+		RecordingId: recordingId,
+		StartedAt:   recording.StartedAt,
+		Duration:    recording.Duration,
+		Size:        recording.Size,
+		Truncated:   recording.Truncated,
+		Data:        recording.Data,
+	}) {
+		log.Error("unable to send terminal recording to the server", "server", server.address)
+	}
+}
+
+func (c *AgentClient) sendSSHRecording(recording *sshd.SessionRecording) {
+	c.SendRecording(&msg.TerminalRecording{
+		Source:      model.TerminalRecordingSourceSSH,
+		KeyId:       recording.KeyId,
+		Fingerprint: recording.Fingerprint,
+		StartedAt:   recording.StartedAt,
+		Duration:    recording.Duration,
+		Size:        recording.Size,
+		Truncated:   recording.Truncated,
+		Data:        recording.Data,
+	})
+}
+
+// SendRecording sends a finished session recording to any server.
+func (c *AgentClient) SendRecording(recording *msg.TerminalRecording) {
+	c.serverListMutex.RLock()
+	servers := make([]*agentServer, 0, len(c.serverList))
+	for _, server := range c.serverList {
+		servers = append(servers, server)
+	}
+	c.serverListMutex.RUnlock()
+
+	for _, server := range servers {
+		if server.sendRecording(recording) {
+			return
+		}
+	}
+
+	log.Error("unable to send terminal recording to any server")
+}
+
+// sendRecording sends a finished session recording to the server, returning
+// false if it couldn't be sent.
+func (s *agentServer) sendRecording(recording *msg.TerminalRecording) bool {
+	s.agentClient.serverListMutex.RLock()
+	muxSession := s.muxSession
+	s.agentClient.serverListMutex.RUnlock()
+
+	if muxSession == nil || muxSession.IsClosed() {
+		return false
+	}
+
+	conn, err := muxSession.Open()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	return msg.SendTerminalRecording(conn, recording) == nil
+}
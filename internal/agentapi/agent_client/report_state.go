@@ -46,9 +46,19 @@ func (c *AgentClient) reportState() {
 		var hasVSCodeTunnel bool = false
 		var vscodeTunnelName string = ""
 		cpuPercent, memoryUsedBytes, memoryLimitBytes, diskUsedBytes, diskLimitBytes := c.collectResourceUsage()
+		c.usageMu.Lock()
+		c.lastUsage = ResourceUsage{
+			CPUPercent:       cpuPercent,
+			MemoryUsedBytes:  memoryUsedBytes,
+			MemoryLimitBytes: memoryLimitBytes,
+			DiskUsedBytes:    diskUsedBytes,
+			DiskLimitBytes:   diskLimitBytes,
+		}
+		c.usageMu.Unlock()
 		activityWriteCount, activityCreateCount, activityDeleteCount, activityRenameCount, activityDistinctPaths, lastActivityAtUnix := c.snapshotActivityState()
 		activityBucketStartUnix := time.Now().UTC().Truncate(time.Minute).Unix()
 		activityBucketFinalized := false
+		customMetrics := c.CustomMetrics()
 
 		// If sshPort > 0 then check the health of sshd (until confirmed live, then assume it stays live)
 		if c.withSSH && c.sshPort > 0 && sshAlivePort == 0 {
@@ -167,7 +177,7 @@ func (c *AgentClient) reportState() {
 				healthy := c.healthy
 				c.healthMu.RUnlock()
 
-				reply, err := msg.SendState(server.reportingConn, codeServerAlive, sshAlivePort, vncAliveHttpPort, c.withTerminal, &c.tcpPortMap, &webPorts, hasVSCodeTunnel, vscodeTunnelName, healthy, cpuPercent, memoryUsedBytes, memoryLimitBytes, diskUsedBytes, diskLimitBytes, activityWriteCount, activityCreateCount, activityDeleteCount, activityRenameCount, activityDistinctPaths, activityBucketStartUnix, activityBucketFinalized, lastActivityAtUnix, c.methodCallsTotal.Load(), c.httpRequestsTotal.Load(), c.tcpConnectionsTotal.Load())
+				reply, err := msg.SendState(server.reportingConn, codeServerAlive, sshAlivePort, vncAliveHttpPort, c.withTerminal, &c.tcpPortMap, &webPorts, hasVSCodeTunnel, vscodeTunnelName, healthy, cpuPercent, memoryUsedBytes, memoryLimitBytes, diskUsedBytes, diskLimitBytes, activityWriteCount, activityCreateCount, activityDeleteCount, activityRenameCount, activityDistinctPaths, activityBucketStartUnix, activityBucketFinalized, lastActivityAtUnix, c.activeSessions.Load(), c.methodCallsTotal.Load(), c.httpRequestsTotal.Load(), c.tcpConnectionsTotal.Load(), customMetrics)
 				if err != nil {
 					log.Error("failed to send state to server", "server", server.address)
 					server.reportingConn.Close()
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util"
 
 	"github.com/paularlott/knot/internal/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func handleRunCommandExecution(stream net.Conn, runCmd msg.RunCommandMessage) {
@@ -37,9 +39,12 @@ func handleRunCommandExecution(stream net.Conn, runCmd msg.RunCommandMessage) {
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(tracing.FromCarrier(context.Background(), runCmd.TraceContext), timeout)
 	defer cancel()
 
+	ctx, span := tracing.StartKind(ctx, "agent.handle_run_command", trace.SpanKindServer)
+	defer span.End()
+
 	// Find the best available shell (reusing the same logic as the SSH server)
 	selectedShell := util.CheckShells("bash")
 	if selectedShell == "" {
@@ -59,6 +64,7 @@ func handleRunCommandExecution(stream net.Conn, runCmd msg.RunCommandMessage) {
 	log.Debug("executing shell command", "shell", selectedShell, "shell_command", shellCmd, "workdir", runCmd.Workdir)
 
 	output, err := cmd.CombinedOutput()
+	tracing.RecordError(span, err)
 
 	log.Debug("raw command output", "raw_output_bytes", len(output), "raw_output", string(output))
 	if err != nil {
@@ -0,0 +1,290 @@
+package agent_client
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/asciicast"
+	"github.com/paularlott/knot/internal/log"
+
+	"github.com/creack/pty"
+	"github.com/google/uuid"
+)
+
+const (
+	sharedTerminalScrollback   = 64 * 1024 // output replayed to users as they attach
+	sharedTerminalWriteTimeout = 10 * time.Second
+)
+
+type sharedTerminalClient struct {
+	info msg.TerminalSessionAttachment
+	conn net.Conn
+}
+
+// sharedTerminal is a named shell that outlives the connections attached to
+// it, output goes to every attached user while only read-write users can
+// type or resize the terminal.
+type sharedTerminal struct {
+	name        string
+	shell       string
+	createdBy   string
+	createdAt   time.Time
+	server      *agentServer // server that opened the session, sent the recording
+	recordingId string
+	cmd         *exec.Cmd
+	tty         *os.File
+	recorder    *asciicast.Recorder
+	mutex       sync.Mutex
+	closed      bool
+	scrollback  []byte
+	clients     map[string]*sharedTerminalClient
+}
+
+var (
+	sharedTerminalsMutex sync.Mutex
+	sharedTerminals      = make(map[string]*sharedTerminal)
+)
+
+// attachSharedTerminal connects conn to the named session, starting the
+// session if it doesn't exist, and returns once the user detaches.
+func (c *AgentClient) attachSharedTerminal(conn net.Conn, request msg.Terminal, server *agentServer) {
+	session, err := c.getSharedTerminal(request, server)
+	if err != nil {
+		conn.Write([]byte(err.Error()))
+		return
+	}
+
+	client := session.attach(conn, request)
+	if client == nil {
+		conn.Write([]byte("Session has ended"))
+		return
+	}
+	defer session.detach(client.info.Id)
+
+	readTerminalInput(conn, func(data []byte) error {
+		if client.info.ReadOnly {
+			return nil
+		}
+		_, err := session.tty.Write(data)
+		return err
+	}, func(size msg.TerminalWindowSize) {
+		if !client.info.ReadOnly {
+			session.resize(size)
+		}
+	})
+}
+
+func (c *AgentClient) getSharedTerminal(request msg.Terminal, server *agentServer) (*sharedTerminal, error) {
+	sharedTerminalsMutex.Lock()
+	defer sharedTerminalsMutex.Unlock()
+
+	if session, ok := sharedTerminals[request.Session]; ok {
+		return session, nil
+	}
+
+	cmd, tty, err := startShell(request.Shell)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &sharedTerminal{
+		name:        request.Session,
+		shell:       request.Shell,
+		createdBy:   request.Username,
+		createdAt:   time.Now().UTC(),
+		server:      server,
+		recordingId: request.RecordingId,
+		cmd:         cmd,
+		tty:         tty,
+		recorder:    c.newTerminalRecorder(request.Session),
+		clients:     make(map[string]*sharedTerminalClient),
+	}
+	sharedTerminals[session.name] = session
+
+	log.Info("started shared terminal session", "session", session.name, "user", session.createdBy)
+
+	go c.runSharedTerminal(session)
+
+	return session, nil
+}
+
+// runSharedTerminal copies the shell output to the attached users until the
+// shell exits, then ends the session.
+func (c *AgentClient) runSharedTerminal(session *sharedTerminal) {
+	buffer := make([]byte, 2048)
+	for {
+		n, err := session.tty.Read(buffer)
+		if err != nil {
+			break
+		}
+		session.broadcast(buffer[:n])
+	}
+
+	sharedTerminalsMutex.Lock()
+	delete(sharedTerminals, session.name)
+	sharedTerminalsMutex.Unlock()
+
+	session.mutex.Lock()
+	session.closed = true
+	for id, client := range session.clients {
+		client.conn.Close()
+		delete(session.clients, id)
+	}
+	session.mutex.Unlock()
+
+	stopShell(session.cmd, session.tty)
+	c.sendTerminalRecording(session.recorder, session.server, session.recordingId)
+
+	log.Info("shared terminal session ended", "session", session.name)
+}
+
+func (session *sharedTerminal) broadcast(data []byte) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.scrollback = append(session.scrollback, data...)
+	if len(session.scrollback) > sharedTerminalScrollback {
+		session.scrollback = session.scrollback[len(session.scrollback)-sharedTerminalScrollback:]
+	}
+	if session.recorder != nil {
+		session.recorder.Output(data)
+	}
+
+	for id, client := range session.clients {
+		if err := writeSharedTerminal(client.conn, data); err != nil {
+			log.WithError(err).Debug("dropping shared terminal client", "session", session.name, "user", client.info.Username)
+			client.conn.Close()
+			delete(session.clients, id)
+		}
+	}
+}
+
+// attach replays the recent output to conn and adds it to the session, nil is
+// returned if the session has already ended.
+func (session *sharedTerminal) attach(conn net.Conn, request msg.Terminal) *sharedTerminalClient {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.closed {
+		return nil
+	}
+
+	client := &sharedTerminalClient{
+		info: msg.TerminalSessionAttachment{
+			Id:         uuid.NewString(),
+			UserId:     request.UserId,
+			Username:   request.Username,
+			ReadOnly:   request.ReadOnly,
+			AttachedAt: time.Now().UTC(),
+		},
+		conn: conn,
+	}
+
+	if len(session.scrollback) > 0 {
+		if err := writeSharedTerminal(conn, session.scrollback); err != nil {
+			return nil
+		}
+	}
+	session.clients[client.info.Id] = client
+
+	return client
+}
+
+// detach disconnects a user from the session, the session keeps running.
+func (session *sharedTerminal) detach(id string) bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	client, ok := session.clients[id]
+	if ok {
+		client.conn.Close()
+		delete(session.clients, id)
+	}
+	return ok
+}
+
+func (session *sharedTerminal) resize(size msg.TerminalWindowSize) {
+	if err := pty.Setsize(session.tty, &pty.Winsize{Cols: size.Cols, Rows: size.Rows}); err != nil {
+		log.WithError(err).Error("failed to resize tty:")
+	}
+	if session.recorder != nil {
+		session.recorder.Resize(int(size.Cols), int(size.Rows))
+	}
+}
+
+func (session *sharedTerminal) info() msg.TerminalSessionInfo {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	info := msg.TerminalSessionInfo{
+		Name:      session.name,
+		Shell:     session.shell,
+		CreatedBy: session.createdBy,
+		CreatedAt: session.createdAt,
+		Attached:  make([]msg.TerminalSessionAttachment, 0, len(session.clients)),
+	}
+	for _, client := range session.clients {
+		info.Attached = append(info.Attached, client.info)
+	}
+	sort.Slice(info.Attached, func(i, j int) bool {
+		return info.Attached[i].AttachedAt.Before(info.Attached[j].AttachedAt)
+	})
+
+	return info
+}
+
+func writeSharedTerminal(conn net.Conn, data []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(sharedTerminalWriteTimeout))
+	_, err := conn.Write(data)
+	return err
+}
+
+func handleTerminalSessionList(stream net.Conn) {
+	sharedTerminalsMutex.Lock()
+	sessions := make([]*sharedTerminal, 0, len(sharedTerminals))
+	for _, session := range sharedTerminals {
+		sessions = append(sessions, session)
+	}
+	sharedTerminalsMutex.Unlock()
+
+	response := msg.TerminalSessionListResponse{
+		Sessions: make([]msg.TerminalSessionInfo, len(sessions)),
+	}
+	for i, session := range sessions {
+		response.Sessions[i] = session.info()
+	}
+	sort.Slice(response.Sessions, func(i, j int) bool {
+		return response.Sessions[i].Name < response.Sessions[j].Name
+	})
+
+	msg.WriteMessage(stream, &response)
+}
+
+func handleTerminalSessionDetach(stream net.Conn, request msg.TerminalSessionDetachRequest) {
+	sharedTerminalsMutex.Lock()
+	session, ok := sharedTerminals[request.Session]
+	sharedTerminalsMutex.Unlock()
+
+	if !ok {
+		msg.WriteMessage(stream, &msg.TerminalSessionDetachResponse{Success: false, Error: "Session not found"})
+		return
+	}
+
+	if request.AttachmentId == "" {
+		// Ending the shell closes the session and all attached users
+		if err := session.cmd.Process.Kill(); err != nil {
+			msg.WriteMessage(stream, &msg.TerminalSessionDetachResponse{Success: false, Error: err.Error()})
+			return
+		}
+	} else if !session.detach(request.AttachmentId) {
+		msg.WriteMessage(stream, &msg.TerminalSessionDetachResponse{Success: false, Error: "Attachment not found"})
+		return
+	}
+
+	msg.WriteMessage(stream, &msg.TerminalSessionDetachResponse{Success: true})
+}
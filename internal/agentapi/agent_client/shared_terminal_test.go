@@ -0,0 +1,113 @@
+package agent_client
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+)
+
+func newTestSharedTerminal() *sharedTerminal {
+	return &sharedTerminal{
+		name:    "pair",
+		clients: make(map[string]*sharedTerminalClient),
+	}
+}
+
+// readPipe reads n bytes from conn, failing the test if they don't arrive.
+func readPipe(t *testing.T, conn net.Conn, n int) []byte {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data := make([]byte, n)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatalf("reading from client: %v", err)
+	}
+	return data
+}
+
+func TestSharedTerminalBroadcast(t *testing.T) {
+	session := newTestSharedTerminal()
+
+	local1, remote1 := net.Pipe()
+	local2, remote2 := net.Pipe()
+	defer local1.Close()
+	defer local2.Close()
+
+	owner := session.attach(remote1, msg.Terminal{Username: "owner"})
+	viewer := session.attach(remote2, msg.Terminal{Username: "viewer", ReadOnly: true})
+	if owner == nil || viewer == nil {
+		t.Fatal("attach should succeed on a running session")
+	}
+
+	go session.broadcast([]byte("hello"))
+	if got := readPipe(t, local1, 5); string(got) != "hello" {
+		t.Errorf("owner got %q", got)
+	}
+	if got := readPipe(t, local2, 5); string(got) != "hello" {
+		t.Errorf("viewer got %q", got)
+	}
+
+	readOnly := map[string]bool{}
+	for _, attached := range session.info().Attached {
+		readOnly[attached.Username] = attached.ReadOnly
+	}
+	if len(readOnly) != 2 || readOnly["owner"] || !readOnly["viewer"] {
+		t.Errorf("unexpected attachments %+v", readOnly)
+	}
+}
+
+func TestSharedTerminalReplaysScrollback(t *testing.T) {
+	session := newTestSharedTerminal()
+	session.broadcast(bytes.Repeat([]byte("x"), sharedTerminalScrollback))
+	session.broadcast([]byte("tail"))
+
+	if len(session.scrollback) != sharedTerminalScrollback {
+		t.Fatalf("scrollback should be capped, got %d bytes", len(session.scrollback))
+	}
+
+	local, remote := net.Pipe()
+	defer local.Close()
+
+	attached := make(chan *sharedTerminalClient)
+	go func() { attached <- session.attach(remote, msg.Terminal{Username: "late"}) }()
+
+	got := readPipe(t, local, sharedTerminalScrollback)
+	if !bytes.HasSuffix(got, []byte("tail")) {
+		t.Error("a late joiner should see the most recent output")
+	}
+	if <-attached == nil {
+		t.Error("attach should succeed")
+	}
+}
+
+func TestSharedTerminalDetach(t *testing.T) {
+	session := newTestSharedTerminal()
+
+	local, remote := net.Pipe()
+	defer local.Close()
+
+	client := session.attach(remote, msg.Terminal{Username: "viewer", ReadOnly: true})
+	if !session.detach(client.info.Id) {
+		t.Fatal("detach should find the attachment")
+	}
+	if session.detach(client.info.Id) {
+		t.Error("detaching twice should fail")
+	}
+
+	// The kicked user's connection is closed
+	local.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := local.Read(make([]byte, 1)); err == nil {
+		t.Error("connection should be closed after detach")
+	}
+
+	session.closed = true
+	local2, remote2 := net.Pipe()
+	defer local2.Close()
+	if session.attach(remote2, msg.Terminal{}) != nil {
+		t.Error("attach should fail once the session has ended")
+	}
+}
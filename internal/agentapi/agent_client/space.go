@@ -7,6 +7,7 @@ import (
 
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/sshd"
 )
 
 func (c *AgentClient) SendSpaceNote(note string) error {
@@ -245,3 +246,29 @@ func (c *AgentClient) NotifyPortForwardChanged() {
 		}
 	}
 }
+
+// ReportSSHForward tells the server about a port forward opened over SSH so
+// it can be audited.
+func (c *AgentClient) ReportSSHForward(event sshd.ForwardEvent) {
+	c.serverListMutex.RLock()
+	defer c.serverListMutex.RUnlock()
+
+	for _, server := range c.serverList {
+		if server.muxSession != nil && !server.muxSession.IsClosed() {
+			conn, err := server.muxSession.Open()
+			if err != nil {
+				continue
+			}
+			msg.SendSSHForward(conn, &msg.SSHForward{
+				Reverse:     event.Reverse,
+				Host:        event.Host,
+				Port:        event.Port,
+				KeyId:       event.KeyId,
+				Fingerprint: event.Fingerprint,
+				RemoteAddr:  event.RemoteAddr,
+			})
+			conn.Close()
+			return
+		}
+	}
+}
@@ -2,11 +2,13 @@ package agent_client
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 	"os"
 	"os/exec"
 
 	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/asciicast"
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/util"
 
@@ -14,52 +16,62 @@ import (
 	"github.com/paularlott/knot/internal/log"
 )
 
-func startTerminal(conn net.Conn, shell string) {
+func startTerminal(conn net.Conn, shell string, recorder *asciicast.Recorder) {
+	cmd, tty, err := startShell(shell)
+	if err != nil {
+		conn.Write([]byte(err.Error()))
+		return
+	}
+
+	// Kill the process and clean up
+	defer func() {
+		stopShell(cmd, tty)
+		if err := conn.Close(); err != nil {
+			log.Error("unable to close connection")
+		}
+	}()
+
+	runTerminal(conn, tty, recorder)
+}
+
+// startShell starts a login shell in the user's home directory on a new pty.
+func startShell(shell string) (*exec.Cmd, *os.File, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.WithError(err).Error("failed to get home directory:")
-		conn.Write([]byte("Failed to get home directory"))
-		return
+		return nil, nil, errors.New("Failed to get home directory")
 	}
 
 	// Check requested shell exists, if not find one
 	selectedShell := util.CheckShells(shell)
 	if selectedShell == "" {
 		log.Error("no valid shell found")
-		conn.Write([]byte("No valid shell found"))
-		return
+		return nil, nil, errors.New("No valid shell found")
 	}
 
-	var tty *os.File
-	var cmd *exec.Cmd
-
-	cmd = exec.Command(selectedShell, "-l")
+	cmd := exec.Command(selectedShell, "-l")
 	cmd.Dir = home
 	cmd.Env = os.Environ()
 
-	if tty, err = pty.Start(cmd); err != nil {
+	tty, err := pty.Start(cmd)
+	if err != nil {
 		log.WithError(err).Error("failed to start shell:")
-		conn.Write([]byte("Failed to start shell"))
-		return
+		return nil, nil, errors.New("Failed to start shell")
 	}
 
-	// Kill the process and clean up
-	defer func() {
-		if err := cmd.Process.Kill(); err != nil {
-			log.Error("unable to kill shell")
-		}
-		if _, err := cmd.Process.Wait(); err != nil {
-			log.Error("unable to wait for shell to exit")
-		}
-		if err := tty.Close(); err != nil {
-			log.Error("unable to close tty")
-		}
-		if err := conn.Close(); err != nil {
-			log.Error("unable to close connection")
-		}
-	}()
+	return cmd, tty, nil
+}
 
-	runTerminal(conn, tty)
+func stopShell(cmd *exec.Cmd, tty *os.File) {
+	if err := cmd.Process.Kill(); err != nil {
+		log.Error("unable to kill shell")
+	}
+	if _, err := cmd.Process.Wait(); err != nil {
+		log.Error("unable to wait for shell to exit")
+	}
+	if err := tty.Close(); err != nil {
+		log.Error("unable to close tty")
+	}
 }
 
 func startVSCodeTunnelTerminal(conn net.Conn) {
@@ -99,10 +111,10 @@ func startVSCodeTunnelTerminal(conn net.Conn) {
 		}
 	}()
 
-	runTerminal(conn, tty)
+	runTerminal(conn, tty, nil)
 }
 
-func runTerminal(conn net.Conn, tty *os.File) {
+func runTerminal(conn net.Conn, tty *os.File, recorder *asciicast.Recorder) {
 
 	// tty to net
 	go func() {
@@ -116,6 +128,9 @@ func runTerminal(conn net.Conn, tty *os.File) {
 				conn.Close()
 				return
 			}
+			if recorder != nil {
+				recorder.Output(buffer[:readLength])
+			}
 			if _, err := conn.Write(buffer[:readLength]); err != nil {
 				log.Error("failed to send bytes to terminal", "readLength", readLength)
 				continue
@@ -124,6 +139,22 @@ func runTerminal(conn net.Conn, tty *os.File) {
 	}()
 
 	// net to tty
+	readTerminalInput(conn, func(data []byte) error {
+		_, err := tty.Write(data)
+		return err
+	}, func(size msg.TerminalWindowSize) {
+		if err := pty.Setsize(tty, &pty.Winsize{Cols: size.Cols, Rows: size.Rows}); err != nil {
+			log.WithError(err).Error("failed to resize tty:")
+		}
+		if recorder != nil {
+			recorder.Resize(int(size.Cols), int(size.Rows))
+		}
+	})
+}
+
+// readTerminalInput reads the data and resize messages sent by the terminal
+// client until the connection closes or input can't be written.
+func readTerminalInput(conn net.Conn, input func([]byte) error, resize func(msg.TerminalWindowSize)) {
 	for {
 
 		// Read the command bytes from the connection
@@ -157,7 +188,7 @@ func runTerminal(conn net.Conn, tty *os.File) {
 				totalRead += uint32(n)
 			}
 
-			if _, err := tty.Write(payloadBuf); err != nil {
+			if err := input(payloadBuf); err != nil {
 				log.Error("failed to write bytes to tty", "payloadSize", payloadSize)
 				return
 			}
@@ -169,9 +200,7 @@ func runTerminal(conn net.Conn, tty *os.File) {
 				return
 			}
 
-			if err := pty.Setsize(tty, &pty.Winsize{Cols: terminalResize.Cols, Rows: terminalResize.Rows}); err != nil {
-				log.WithError(err).Error("failed to resize tty:")
-			}
+			resize(terminalResize)
 		} else {
 			log.Error("unknown command:", "cmdTypeBuf0", cmdTypeBuf[0])
 			return
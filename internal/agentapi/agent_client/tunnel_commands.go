@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/agenttunnel"
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/tunnel_server"
 )
 
 // handleTunnelStartExecution handles the tunnel start command from the server.
@@ -21,9 +22,15 @@ func handleTunnelStartExecution(stream net.Conn, tunnelCmd msg.TunnelStartReques
 		return
 	}
 
+	policy, err := tunnel_server.PolicyFromSpec(tunnelCmd.Policy)
+	if err != nil {
+		msg.WriteMessage(stream, &msg.TunnelStartResponse{Success: false, Error: err.Error()})
+		return
+	}
+
 	cfg := config.GetAgentConfig()
 
-	url, err := agenttunnel.CreateWebTunnel(tunnelCmd.Name, tunnelCmd.Protocol, tunnelCmd.Port, tunnelCmd.TlsName, tunnelCmd.TlsSkipVerify, server, token, cfg.TLS.SkipVerify)
+	url, err := agenttunnel.CreateWebTunnel(tunnelCmd.Name, tunnelCmd.Protocol, tunnelCmd.Port, tunnelCmd.TlsName, tunnelCmd.TlsSkipVerify, policy, server, token, cfg.TLS.SkipVerify)
 	if err != nil {
 		log.WithError(err).Error("Failed to create tunnel")
 		msg.WriteMessage(stream, &msg.TunnelStartResponse{Success: false, Error: err.Error()})
@@ -16,6 +16,7 @@ import (
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/spaceusage"
 	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/sshca"
 	"github.com/paularlott/knot/internal/tunnel_server"
 	"github.com/paularlott/knot/internal/util"
 	"github.com/paularlott/knot/internal/util/crypt"
@@ -113,6 +114,7 @@ func handleAgentConnection(conn net.Conn) {
 
 	// Create a new session and start listening
 	session = NewSession(registerMsg.SpaceId, registerMsg.Version)
+	session.TerminalRecording = template.WithTerminalRecording && config.GetServerConfig().TerminalRecording.Retention > 0
 	clearAgentLossFailures(registerMsg.SpaceId)
 	sessionMutex.Lock()
 	sessions[registerMsg.SpaceId] = session
@@ -131,30 +133,39 @@ func handleAgentConnection(conn net.Conn) {
 	response.Success = true
 	response.Shell = space.Shell
 	response.SSHHostSigner = space.SSHHostSigner
+	if hostCert, err := sshca.SignHostCert(space); err != nil {
+		logger.WithError(err).Warn("unable to sign SSH host certificate", "space", space.Id)
+	} else {
+		response.SSHHostCertificate = hostCert
+	}
 	response.WithTerminal = template.WithTerminal
 	response.WithVSCodeTunnel = template.WithVSCodeTunnel
 	response.WithCodeServer = template.WithCodeServer
 	response.WithSSH = template.WithSSH
 	response.WithRunCommand = template.WithRunCommand
+	if session.TerminalRecording {
+		response.TerminalRecording = true
+		response.TerminalRecordingMaxSize = config.GetServerConfig().TerminalRecording.MaxSize * 1024 * 1024
+	}
 
-	if user.SSHPublicKey != "" {
-		response.SSHKeys = append(response.SSHKeys, util.SplitSSHPublicKeys(user.SSHPublicKey)...)
+	// Keys carry options limiting port forwarding to what each user may do
+	keys, githubUsernames, githubKeyOptions := template.SSHAuthorizedKeys(user)
+	response.SSHKeys = append(response.SSHKeys, keys...)
+	response.GitHubUsernames = append(response.GitHubUsernames, githubUsernames...)
+	response.GitHubKeyOptions = append(response.GitHubKeyOptions, githubKeyOptions...)
+	if caKey := sshca.AuthorizedKey(space.Id); caKey != "" {
+		response.SSHKeys = append(response.SSHKeys, util.AddSSHKeyOptions(caKey, template.SSHKeyOptions(nil)))
 	}
 	response.SSHPrivateKey = util.DecryptSSHPrivateKey(config.GetServerConfig().EncryptionKey, user.SSHPrivateKey)
-	if user.GitHubUsername != "" {
-		response.GitHubUsernames = append(response.GitHubUsernames, user.GitHubUsername)
-	}
 
 	// If space shared then get the keys from shared users
 	for _, sharedUserId := range space.SharedUserIds() {
 		sharedUser, err := db.GetUser(sharedUserId)
 		if err == nil {
-			if sharedUser.SSHPublicKey != "" {
-				response.SSHKeys = append(response.SSHKeys, util.SplitSSHPublicKeys(sharedUser.SSHPublicKey)...)
-			}
-			if sharedUser.GitHubUsername != "" {
-				response.GitHubUsernames = append(response.GitHubUsernames, sharedUser.GitHubUsername)
-			}
+			keys, githubUsernames, githubKeyOptions := template.SSHAuthorizedKeys(sharedUser)
+			response.SSHKeys = append(response.SSHKeys, keys...)
+			response.GitHubUsernames = append(response.GitHubUsernames, githubUsernames...)
+			response.GitHubKeyOptions = append(response.GitHubKeyOptions, githubKeyOptions...)
 		}
 	}
 
@@ -307,6 +318,8 @@ func handleAgentSession(stream net.Conn, session *Session) {
 				session.ActivityRenameCount = state.ActivityRenameCount
 				session.ActivityDistinctPaths = state.ActivityDistinctPaths
 				session.LastActivityAtUnix = state.LastActivityAtUnix
+				session.ActiveSessions = state.ActiveSessions
+				session.CustomMetrics = state.CustomMetrics
 				now := time.Now().UTC()
 				lastStateAt := session.GetLastStateAt()
 				if !lastStateAt.IsZero() {
@@ -368,6 +381,8 @@ func handleAgentSession(stream net.Conn, session *Session) {
 			}
 			session.LogHistoryMutex.Unlock()
 
+			queueSpaceLog(session.Id, &logMsg)
+
 			// Notify all log sinks
 			session.LogListenersMutex.RLock()
 			for _, c := range session.LogListeners {
@@ -641,6 +656,14 @@ func handleAgentSession(stream net.Conn, session *Session) {
 				sse.PublishPortForwardChanged(session.Id, space.UserId)
 			}
 
+		case byte(msg.CmdSSHForward):
+			handleSSHForward(stream, session)
+			return
+
+		case byte(msg.CmdTerminalRecording):
+			handleTerminalRecording(stream, session)
+			return
+
 		default:
 			log.Error("unknown command from agent:", "cmd", cmd)
 			return
@@ -0,0 +1,93 @@
+package agent_server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+type idleAction int
+
+const (
+	idleActionNone idleAction = iota
+	idleActionWarn
+	idleActionStop
+)
+
+// idleState tracks how long a space has been idle for between schedule checks.
+type idleState struct {
+	idleSince          time.Time
+	lastActivityAtUnix int64
+	warned             bool
+}
+
+var (
+	idleMutex  = sync.Mutex{}
+	idleStates = make(map[string]*idleState)
+)
+
+// sessionIsBusy reports whether the last state reported by the agent shows the
+// space in use: an open terminal, SSH or code-server connection, file activity
+// in the current bucket or CPU usage at or above the policy threshold.
+func sessionIsBusy(session *Session, policy *model.TemplateIdlePolicy) bool {
+	if session.ActiveSessions > 0 {
+		return true
+	}
+
+	if session.ActivityWriteCount > 0 || session.ActivityCreateCount > 0 || session.ActivityDeleteCount > 0 || session.ActivityRenameCount > 0 {
+		return true
+	}
+
+	return policy.CPUThreshold > 0 && session.CPUPercent >= policy.CPUThreshold
+}
+
+// evaluateIdle updates the idle tracking for the session and returns the action
+// to take along with how long the space has been idle.
+func evaluateIdle(session *Session, policy *model.TemplateIdlePolicy, now time.Time) (idleAction, time.Duration) {
+	timeout := policy.TimeoutDuration()
+
+	idleMutex.Lock()
+	defer idleMutex.Unlock()
+
+	if timeout == 0 {
+		delete(idleStates, session.Id)
+		return idleActionNone, 0
+	}
+
+	state, ok := idleStates[session.Id]
+	if !ok {
+		state = &idleState{
+			idleSince:          now,
+			lastActivityAtUnix: session.LastActivityAtUnix,
+		}
+		idleStates[session.Id] = state
+	}
+
+	if sessionIsBusy(session, policy) || session.LastActivityAtUnix != state.lastActivityAtUnix {
+		state.idleSince = now
+		state.lastActivityAtUnix = session.LastActivityAtUnix
+		state.warned = false
+		return idleActionNone, 0
+	}
+
+	idleFor := now.Sub(state.idleSince)
+	if idleFor >= timeout {
+		return idleActionStop, idleFor
+	}
+
+	if warnAfter := policy.WarnDuration(); warnAfter > 0 && idleFor >= warnAfter && !state.warned {
+		state.warned = true
+		return idleActionWarn, idleFor
+	}
+
+	return idleActionNone, idleFor
+}
+
+// forgetIdleState drops the idle tracking for a space, called when the agent
+// session goes away or the space is stopped.
+func forgetIdleState(spaceId string) {
+	idleMutex.Lock()
+	delete(idleStates, spaceId)
+	idleMutex.Unlock()
+}
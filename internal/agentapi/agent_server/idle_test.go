@@ -0,0 +1,89 @@
+package agent_server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestEvaluateIdle(t *testing.T) {
+	policy := &model.TemplateIdlePolicy{Enabled: true, Timeout: 45, CPUThreshold: 5, WarnBefore: 10}
+	session := &Session{Id: "idle-test-space"}
+	defer forgetIdleState(session.Id)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if action, _ := evaluateIdle(session, policy, start); action != idleActionNone {
+		t.Fatalf("first evaluation should start tracking, got %v", action)
+	}
+
+	if action, _ := evaluateIdle(session, policy, start.Add(20*time.Minute)); action != idleActionNone {
+		t.Fatalf("expected no action after 20 minutes, got %v", action)
+	}
+
+	if action, _ := evaluateIdle(session, policy, start.Add(36*time.Minute)); action != idleActionWarn {
+		t.Fatalf("expected warning after 36 minutes, got %v", action)
+	}
+
+	if action, _ := evaluateIdle(session, policy, start.Add(40*time.Minute)); action != idleActionNone {
+		t.Fatalf("warning should only be raised once, got %v", action)
+	}
+
+	action, idleFor := evaluateIdle(session, policy, start.Add(46*time.Minute))
+	if action != idleActionStop {
+		t.Fatalf("expected stop after 46 minutes, got %v", action)
+	}
+	if idleFor != 46*time.Minute {
+		t.Fatalf("expected idle for 46m, got %v", idleFor)
+	}
+}
+
+func TestEvaluateIdleResetsOnActivity(t *testing.T) {
+	policy := &model.TemplateIdlePolicy{Enabled: true, Timeout: 30, CPUThreshold: 5}
+	session := &Session{Id: "idle-reset-space"}
+	defer forgetIdleState(session.Id)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	evaluateIdle(session, policy, start)
+
+	tests := []struct {
+		name   string
+		update func(s *Session)
+		reset  func(s *Session)
+	}{
+		{"interactive session", func(s *Session) { s.ActiveSessions = 1 }, func(s *Session) { s.ActiveSessions = 0 }},
+		{"cpu above threshold", func(s *Session) { s.CPUPercent = 12 }, func(s *Session) { s.CPUPercent = 0 }},
+		{"file writes", func(s *Session) { s.ActivityWriteCount = 3 }, func(s *Session) { s.ActivityWriteCount = 0 }},
+		{"last activity changed", func(s *Session) { s.LastActivityAtUnix++ }, func(s *Session) {}},
+	}
+
+	now := start
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(29 * time.Minute)
+			tt.update(session)
+			if action, _ := evaluateIdle(session, policy, now); action != idleActionNone {
+				t.Fatalf("busy space should not be stopped, got %v", action)
+			}
+			tt.reset(session)
+
+			if action, idleFor := evaluateIdle(session, policy, now.Add(29*time.Minute)); action != idleActionNone || idleFor != 29*time.Minute {
+				t.Fatalf("idle timer should restart after activity, got %v after %v", action, idleFor)
+			}
+			now = now.Add(29 * time.Minute)
+		})
+	}
+}
+
+func TestEvaluateIdleDisabledPolicy(t *testing.T) {
+	session := &Session{Id: "idle-disabled-space"}
+	defer forgetIdleState(session.Id)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := &model.TemplateIdlePolicy{Enabled: false, Timeout: 1}
+	evaluateIdle(session, policy, start)
+	if action, _ := evaluateIdle(session, policy, start.Add(24*time.Hour)); action != idleActionNone {
+		t.Fatalf("disabled policy should never stop a space, got %v", action)
+	}
+}
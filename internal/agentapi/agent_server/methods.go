@@ -100,6 +100,42 @@ func (s *Session) SendCallMethod(call *msg.CallMethodRequest, timeoutSeconds int
 	return &response, nil
 }
 
+// SendCallMethodStream calls a streaming method, onProgress is called for each
+// progress notification the method sends before the response arrives. The
+// timeout is an idle timeout, it restarts with each notification so a method
+// can stream for as long as it keeps reporting progress.
+func (s *Session) SendCallMethodStream(call *msg.CallMethodRequest, timeoutSeconds int, onProgress func(*methods.Progress)) (*msg.CallMethodResponse, error) {
+	conn, err := s.MuxSession.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	call.Stream = true
+	if err := msg.WriteCommand(conn, msg.CmdCallMethod); err != nil {
+		return nil, err
+	}
+	if err := msg.WriteMessage(conn, call); err != nil {
+		return nil, err
+	}
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+
+	for {
+		var frame msg.CallMethodStreamFrame
+		if err := msg.ReadMessageWithTimeout(conn, &frame, time.Duration(timeoutSeconds)*time.Second); err != nil {
+			return nil, err
+		}
+		if frame.Response != nil {
+			return &msg.CallMethodResponse{Response: *frame.Response}, nil
+		}
+		if frame.Progress != nil && onProgress != nil {
+			onProgress(frame.Progress)
+		}
+	}
+}
+
 // SendNotificationMethod forwards a notification to the agent without waiting
 // for a JSON-RPC response.
 func (s *Session) SendNotificationMethod(call *msg.CallMethodRequest) error {
@@ -1,6 +1,7 @@
 package agent_server
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -41,6 +42,7 @@ var (
 type stopListItem struct {
 	space   *model.Space
 	session *Session
+	idleFor time.Duration
 }
 
 func checkStaleSessions() {
@@ -256,7 +258,7 @@ func restartAgentLostSpace(space *model.Space, template *model.Template, runtime
 	sse.PublishSpaceChanged(space.Id, space.UserId)
 	service.CheckSpaceLifecycleEvents(&oldSpace, space)
 
-	return service.GetContainerService().StartSpace(space, template, user)
+	return service.GetContainerService().StartSpace(context.Background(), space, template, user)
 }
 
 // Periodically check to see if the space has a schedule which requires it be stopped
@@ -274,7 +276,14 @@ func checkSchedules() {
 
 			db := database.GetInstance()
 
+			// Every server in the zone holds a session for the agent and tracks
+			// its idle time, only the zone leader raises the warning
+			transport := service.GetTransport()
+			isLeader := transport == nil || transport.IsLeader()
+
+			now := time.Now().UTC()
 			sessionStopList := make([]*stopListItem, 0)
+			idleStopList := make([]*stopListItem, 0)
 			sessionMutex.RLock()
 			for _, session := range sessions {
 				space, err := db.GetSpace(session.Id)
@@ -292,6 +301,30 @@ func checkSchedules() {
 						space:   space,
 						session: session,
 					})
+					continue
+				}
+
+				if !space.IsDeployed || space.IsPending || space.IsDeleting {
+					continue
+				}
+
+				action, idleFor := evaluateIdle(session, &template.IdlePolicy, now)
+				switch action {
+				case idleActionWarn:
+					if isLeader {
+						service.RaiseSystemEvent("space.idle_warning", space.Id, space.UserId, map[string]interface{}{
+							"space_name":   space.Name,
+							"space_id":     space.Id,
+							"idle_minutes": int(idleFor.Minutes()),
+							"stop_at":      now.Add(template.IdlePolicy.TimeoutDuration() - idleFor).Format(time.RFC3339Nano),
+						})
+					}
+				case idleActionStop:
+					idleStopList = append(idleStopList, &stopListItem{
+						space:   space,
+						session: session,
+						idleFor: idleFor,
+					})
 				}
 			}
 			sessionMutex.RUnlock()
@@ -303,6 +336,31 @@ func checkSchedules() {
 			}
 			sessionStopList = nil
 
+			// Stop idle spaces, every server in the zone holds a session for the
+			// agent so re-read the space and skip it if another server got there first
+			for _, item := range idleStopList {
+				forgetIdleState(item.space.Id)
+
+				space, err := db.GetSpace(item.space.Id)
+				if err != nil || !space.IsDeployed || space.IsPending || space.IsDeleting {
+					continue
+				}
+
+				logger.Info("stopping idle space", "space_id", space.Id, "idle_for", item.idleFor.String())
+				if err := service.GetContainerService().StopSpace(space); err != nil {
+					logger.WithError(err).Error("failed to stop idle space", "space_id", space.Id)
+					continue
+				}
+
+				service.RaiseSystemEvent("space.idle_stopped", space.Id, space.UserId, map[string]interface{}{
+					"space_name":   space.Name,
+					"space_id":     space.Id,
+					"idle_minutes": int(item.idleFor.Minutes()),
+					"stopped_at":   time.Now().UTC().Format(time.RFC3339Nano),
+				})
+			}
+			idleStopList = nil
+
 			// Look for spaces that need to be started
 			spaces, err := db.GetSpaces()
 			if err != nil {
@@ -347,13 +405,13 @@ func checkSchedules() {
 						}
 
 						transport := service.GetTransport()
-						unlockToken := transport.LockResource(space.Id)
+						unlockToken := transport.LockResource(context.Background(), space.Id)
 						if unlockToken == "" {
 							logger.Error("failed to lock space")
 							continue
 						}
-						service.GetContainerService().StartSpace(space, template, user)
-						transport.UnlockResource(space.Id, unlockToken)
+						service.GetContainerService().StartSpace(context.Background(), space, template, user)
+						transport.UnlockResource(context.Background(), space.Id, unlockToken)
 					}
 				}
 			}
@@ -371,7 +429,7 @@ func shouldRestartOnAgentLoss(template *model.Template) bool {
 		template.HealthCheckAutoRestart &&
 		template.HealthCheckType != "" &&
 		template.HealthCheckType != model.HealthCheckNone &&
-		(template.IsLocalContainer() || template.Platform == model.PlatformNomad)
+		(template.IsLocalContainer() || template.Platform == model.PlatformNomad || template.Platform == model.PlatformKubernetes)
 }
 
 func agentLossMaxFailures(template *model.Template) uint32 {
@@ -426,9 +484,10 @@ func ListenAndServe(listen string, tlsConfig *tls.Config) {
 		return nil
 	})
 
-	// Start the session garbage collector & schedule checker
+	// Start the session garbage collector, schedule checker & space log writer
 	checkSchedules()
 	checkStaleSessions()
+	writeSpaceLogs()
 
 	logger.Info("listening for agents on", "listen", listen)
 
@@ -534,6 +593,7 @@ func removeSession(spaceId string, expected *Session, markUnhealthy bool, queueR
 	}
 
 	if removed {
+		forgetIdleState(spaceId)
 		methods.DefaultRegistry().UnregisterSpace(spaceId)
 		service.GetEventDispatcher().UnregisterSubscriptions(spaceId)
 
@@ -585,6 +645,18 @@ func GetSession(spaceId string) *Session {
 	return nil
 }
 
+// GetSessions returns the agent sessions connected to this server.
+func GetSessions() []*Session {
+	sessionMutex.RLock()
+	defer sessionMutex.RUnlock()
+
+	list := make([]*Session, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, session)
+	}
+	return list
+}
+
 func GetPoolSessionState(spaceId string) *service.PoolSessionState {
 	session := GetSession(spaceId)
 	if session == nil {
@@ -597,5 +669,6 @@ func GetPoolSessionState(spaceId string) *service.PoolSessionState {
 		MethodRPS:        session.MethodRPS,
 		HTTPRPS:          session.HTTPRPS,
 		TCPRPS:           session.TCPRPS,
+		CustomMetrics:    session.CustomMetrics,
 	}
 }
@@ -36,12 +36,14 @@ type Session struct {
 	ActivityRenameCount   uint32
 	ActivityDistinctPaths uint32
 	LastActivityAtUnix    int64
+	ActiveSessions        int32
 	MethodCallsTotal      uint64
 	HTTPRequestsTotal     uint64
 	TCPConnectionsTotal   uint64
 	MethodRPS             float64
 	HTTPRPS               float64
 	TCPRPS                float64
+	CustomMetrics         map[string]float64
 	LastStateAt           time.Time
 	lastStateAtMu         sync.Mutex
 	LastPingAt            time.Time
@@ -49,6 +51,14 @@ type Session struct {
 	MuxSession            *yamux.Session
 	logger                logger.Logger
 
+	// TerminalRecording is set if the agent records terminals and sends the
+	// recordings back
+	TerminalRecording bool
+
+	// The users of the web terminals opened through this server
+	terminalUsersMutex sync.Mutex
+	terminalUsers      map[string]terminalUser
+
 	// The log history
 	LogHistoryMutex *sync.RWMutex
 	LogHistory      []*msg.LogMessage
@@ -189,7 +199,7 @@ func (s *Session) Ping() bool {
 	return true
 }
 
-func (s *Session) SendUpdateAuthorizedKeys(sshKeys []string, sshPrivateKey string, githubUsernames []string) error {
+func (s *Session) SendUpdateAuthorizedKeys(sshKeys []string, sshPrivateKey string, githubUsernames []string, githubKeyOptions []string) error {
 	conn, err := s.MuxSession.Open()
 	if err != nil {
 		return err
@@ -205,9 +215,10 @@ func (s *Session) SendUpdateAuthorizedKeys(sshKeys []string, sshPrivateKey strin
 
 	// Write the update authorized keys message
 	err = msg.WriteMessage(conn, &msg.UpdateAuthorizedKeys{
-		SSHKeys:         sshKeys,
-		SSHPrivateKey:   sshPrivateKey,
-		GitHubUsernames: githubUsernames,
+		SSHKeys:          sshKeys,
+		SSHPrivateKey:    sshPrivateKey,
+		GitHubUsernames:  githubUsernames,
+		GitHubKeyOptions: githubKeyOptions,
 	})
 	if err != nil {
 		s.logger.WithError(err).Error("writing update authorized keys message:")
@@ -440,6 +451,17 @@ func (s *Session) SendDeleteFile(d *msg.DeleteFileMessage) (chan *msg.DeleteFile
 	return sendSingleShot[msg.DeleteFileMessage, msg.DeleteFileResponse](s, msg.CmdDeleteFile, "delete file", d)
 }
 
+// SendTerminalSessionList asks the agent for its shared terminal sessions.
+func (s *Session) SendTerminalSessionList() (chan *msg.TerminalSessionListResponse, error) {
+	return sendSingleShot[msg.TerminalSessionListRequest, msg.TerminalSessionListResponse](s, msg.CmdTerminalSessionList, "terminal session list", &msg.TerminalSessionListRequest{})
+}
+
+// SendTerminalSessionDetach detaches a user from a shared terminal session,
+// or ends the session.
+func (s *Session) SendTerminalSessionDetach(d *msg.TerminalSessionDetachRequest) (chan *msg.TerminalSessionDetachResponse, error) {
+	return sendSingleShot[msg.TerminalSessionDetachRequest, msg.TerminalSessionDetachResponse](s, msg.CmdTerminalSessionDetach, "terminal session detach", d)
+}
+
 func (s *Session) SendPortForward(portCmd *msg.PortForwardRequest) (*msg.PortForwardResponse, error) {
 	conn, err := s.MuxSession.Open()
 	if err != nil {
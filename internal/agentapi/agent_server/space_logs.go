@@ -0,0 +1,141 @@
+package agent_server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+const (
+	SPACE_LOG_FLUSH_INTERVAL = 2 * time.Second
+	SPACE_LOG_MAX_PENDING    = 10000 // Lines dropped if the database falls this far behind
+)
+
+var (
+	spaceLogMutex   sync.Mutex
+	spaceLogPending []*model.SpaceLogEntry
+	spaceLogDropped int
+)
+
+// queueSpaceLog queues a log line from an agent for writing to the database,
+// lines are written in batches so busy spaces don't write per line.
+func queueSpaceLog(spaceId string, logMsg *msg.LogMessage) {
+	if config.GetServerConfig().SpaceLogs.Retention < 1 {
+		return
+	}
+
+	entry := model.NewSpaceLogEntry(spaceId, SpaceLogLevel(logMsg.Level), logMsg.Service, logMsg.Message, logMsg.Date, logMsg.Seq)
+
+	spaceLogMutex.Lock()
+	if len(spaceLogPending) < SPACE_LOG_MAX_PENDING {
+		spaceLogPending = append(spaceLogPending, entry)
+	} else {
+		spaceLogDropped++
+	}
+	spaceLogMutex.Unlock()
+}
+
+func writeSpaceLogs() {
+	if config.GetServerConfig().SpaceLogs.Retention < 1 {
+		return
+	}
+
+	logger := log.WithGroup("agent")
+	logger.Info("starting space log writer")
+
+	go func() {
+		ticker := time.NewTicker(SPACE_LOG_FLUSH_INTERVAL)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			spaceLogMutex.Lock()
+			entries := spaceLogPending
+			dropped := spaceLogDropped
+			spaceLogPending = nil
+			spaceLogDropped = 0
+			spaceLogMutex.Unlock()
+
+			if dropped > 0 {
+				logger.Warn("space log writer fell behind, lines dropped", "lines", dropped)
+			}
+
+			if len(entries) == 0 {
+				continue
+			}
+
+			if err := database.GetInstance().SaveSpaceLogs(entries); err != nil {
+				logger.WithError(err).Error("failed to save space logs", "lines", len(entries))
+			}
+		}
+	}()
+}
+
+// SpaceLogLevel converts an agent log level to the stored level.
+func SpaceLogLevel(level msg.LogLevel) string {
+	switch level {
+	case msg.LogLevelError:
+		return model.SpaceLogLevelError
+	case msg.LogLevelInfo:
+		return model.SpaceLogLevelInfo
+	default:
+		return model.SpaceLogLevelDebug
+	}
+}
+
+// LogMessageFromEntry converts a stored log line back to the agent form used
+// by the log streams.
+func LogMessageFromEntry(entry *model.SpaceLogEntry) *msg.LogMessage {
+	level := msg.LogLevelDebug
+	switch entry.Level {
+	case model.SpaceLogLevelError:
+		level = msg.LogLevelError
+	case model.SpaceLogLevelInfo:
+		level = msg.LogLevelInfo
+	}
+
+	return &msg.LogMessage{
+		Level:   level,
+		Service: entry.Source,
+		Message: entry.Message,
+		Date:    entry.CreatedAt,
+	}
+}
+
+// QuerySpaceLogs returns the log lines of a space newest first. Without
+// persistent storage the recent lines held by the agent session are searched
+// instead, their ids are derived from the lines so they are the same on every
+// call and can be used as the cursor.
+func QuerySpaceLogs(spaceId string, filter *model.SpaceLogFilter, limit int) ([]*model.SpaceLogEntry, error) {
+	if config.GetServerConfig().SpaceLogs.Retention > 0 {
+		return database.GetInstance().GetSpaceLogs(spaceId, filter, limit)
+	}
+
+	session := GetSession(spaceId)
+	if session == nil {
+		return nil, nil
+	}
+
+	session.LogHistoryMutex.RLock()
+	defer session.LogHistoryMutex.RUnlock()
+
+	var entries []*model.SpaceLogEntry
+	for i := len(session.LogHistory) - 1; i >= 0; i-- {
+		logMsg := session.LogHistory[i]
+		entry := model.NewSpaceLogEntry(spaceId, SpaceLogLevel(logMsg.Level), logMsg.Service, logMsg.Message, logMsg.Date, logMsg.Seq)
+		if !entry.MatchesFilter(filter) {
+			continue
+		}
+
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,84 @@
+package agent_server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util"
+	"github.com/paularlott/knot/internal/util/audit"
+
+	"github.com/paularlott/knot/internal/log"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// handleSSHForward records a port forward opened through the agent's SSH
+// server in the audit log.
+func handleSSHForward(stream net.Conn, session *Session) {
+	var forward msg.SSHForward
+	if err := msg.ReadMessage(stream, &forward); err != nil {
+		log.WithError(err).Error("reading ssh forward message:")
+		return
+	}
+
+	db := database.GetInstance()
+	space, err := db.GetSpace(session.Id)
+	if err != nil || space == nil {
+		log.Error("unknown space:", "agent", session.Id)
+		return
+	}
+
+	actor := sshKeyUser(db, space, forward.KeyId, forward.Fingerprint)
+
+	event := model.AuditEventSSHLocalForward
+	direction := "to"
+	if forward.Reverse {
+		event = model.AuditEventSSHReverseForward
+		direction = "from"
+	}
+
+	audit.Log(
+		actor,
+		model.AuditActorTypeUser,
+		event,
+		fmt.Sprintf("Forwarded port %s %s:%d in space %s", direction, forward.Host, forward.Port, space.Name),
+		&map[string]interface{}{
+			"space_id":    space.Id,
+			"space_name":  space.Name,
+			"host":        forward.Host,
+			"port":        forward.Port,
+			"key_id":      forward.KeyId,
+			"fingerprint": forward.Fingerprint,
+			"IP":          forward.RemoteAddr,
+		},
+	)
+}
+
+// sshKeyUser finds the user an SSH session authenticated as, from the
+// certificate key ID or by matching the key against those of users with
+// access to the space, falling back to the key fingerprint.
+func sshKeyUser(db database.DbDriver, space *model.Space, keyId, fingerprint string) string {
+	if keyId, ok := strings.CutPrefix(keyId, "knot:"); ok {
+		if username, _, ok := strings.Cut(keyId, ":"); ok {
+			return username
+		}
+	}
+
+	for _, userId := range append([]string{space.UserId}, space.SharedUserIds()...) {
+		user, err := db.GetUser(userId)
+		if err != nil || user == nil {
+			continue
+		}
+		for _, key := range util.SplitSSHPublicKeys(user.SSHPublicKey) {
+			publicKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(key))
+			if err == nil && gossh.FingerprintSHA256(publicKey) == fingerprint {
+				return user.Username
+			}
+		}
+	}
+
+	return fingerprint
+}
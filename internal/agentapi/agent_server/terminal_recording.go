@@ -0,0 +1,132 @@
+package agent_server
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/asciicast"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/audit"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+// terminalUserTTL limits how long the user of a web terminal is kept waiting
+// for its recording, terminals that aren't recorded never send one.
+const terminalUserTTL = 7 * 24 * time.Hour
+
+type terminalUser struct {
+	username string
+	openedAt time.Time
+}
+
+// AddTerminalUser remembers the user opening a web terminal and returns the
+// recording ID the agent sends back with the terminal's recording.
+func (s *Session) AddTerminalUser(username string) string {
+	s.terminalUsersMutex.Lock()
+	defer s.terminalUsersMutex.Unlock()
+
+	now := time.Now()
+	if s.terminalUsers == nil {
+		s.terminalUsers = make(map[string]terminalUser)
+	}
+	for id, user := range s.terminalUsers {
+		if now.Sub(user.openedAt) > terminalUserTTL {
+			delete(s.terminalUsers, id)
+		}
+	}
+
+	id := uuid.New().String()
+	s.terminalUsers[id] = terminalUser{username: username, openedAt: now}
+	return id
+}
+
+// takeTerminalUser returns and forgets the user of the web terminal with the
+// recording ID.
+func (s *Session) takeTerminalUser(id string) (string, bool) {
+	s.terminalUsersMutex.Lock()
+	defer s.terminalUsersMutex.Unlock()
+
+	user, ok := s.terminalUsers[id]
+	delete(s.terminalUsers, id)
+	return user.username, ok
+}
+
+// handleTerminalRecording stores a finished session recording from the agent
+// and records it in the audit log. The user, size and template setting are
+// all checked on the server as the agent runs inside the space.
+func handleTerminalRecording(stream net.Conn, session *Session) {
+	var recordingMsg msg.TerminalRecording
+	if err := msg.ReadMessageWithTimeout(stream, &recordingMsg, time.Minute); err != nil {
+		log.WithError(err).Error("reading terminal recording message:")
+		return
+	}
+
+	cfg := config.GetServerConfig()
+	if cfg.TerminalRecording.Retention < 1 {
+		return
+	}
+
+	db := database.GetInstance()
+	space, err := db.GetSpace(session.Id)
+	if err != nil || space == nil {
+		log.Error("unknown space:", "agent", session.Id)
+		return
+	}
+
+	template, err := db.GetTemplate(space.TemplateId)
+	if err != nil || template == nil || !template.WithTerminalRecording {
+		log.Warn("dropping terminal recording, template doesn't record sessions", "space_id", space.Id)
+		return
+	}
+
+	var username string
+	source := recordingMsg.Source
+	if source == model.TerminalRecordingSourceSSH {
+		username = sshKeyUser(db, space, recordingMsg.KeyId, recordingMsg.Fingerprint)
+	} else {
+		var ok bool
+		source = model.TerminalRecordingSourceWeb
+		if username, ok = session.takeTerminalUser(recordingMsg.RecordingId); !ok {
+			log.Warn("dropping terminal recording for a terminal not opened by this server", "space_id", space.Id)
+			return
+		}
+	}
+
+	data, size, truncated, err := asciicast.Limit(recordingMsg.Data, cfg.TerminalRecording.MaxSize*1024*1024)
+	if err != nil {
+		log.WithError(err).Error("invalid terminal recording:", "space_id", space.Id)
+		return
+	}
+
+	recording := model.NewTerminalRecording(space.Id, username, source, recordingMsg.StartedAt, cfg.TerminalRecording.Retention)
+	recording.Duration = recordingMsg.Duration.Seconds()
+	recording.Size = size
+	recording.Truncated = truncated || recordingMsg.Truncated
+	recording.Data = data
+	if err := db.SaveTerminalRecording(recording); err != nil {
+		log.WithError(err).Error("saving terminal recording:", "space_id", space.Id)
+		return
+	}
+
+	audit.Log(
+		username,
+		model.AuditActorTypeUser,
+		model.AuditEventSpaceRecording,
+		fmt.Sprintf("Recorded %s session in space %s", recording.Source, space.Name),
+		&map[string]interface{}{
+			"space_id":     space.Id,
+			"space_name":   space.Name,
+			"recording_id": recording.Id,
+			"source":       recording.Source,
+			"started_at":   recording.StartedAt,
+			"duration":     recording.Duration,
+			"truncated":    recording.Truncated,
+		},
+	)
+}
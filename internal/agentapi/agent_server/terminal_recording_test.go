@@ -0,0 +1,28 @@
+package agent_server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTerminalUsers(t *testing.T) {
+	session := NewSession("space-1", "1.0.0")
+
+	id := session.AddTerminalUser("alice")
+	if username, ok := session.takeTerminalUser(id); !ok || username != "alice" {
+		t.Errorf("takeTerminalUser() = %q, %v, expected alice", username, ok)
+	}
+	if _, ok := session.takeTerminalUser(id); ok {
+		t.Error("expected a recording ID to be used once")
+	}
+	if _, ok := session.takeTerminalUser("unknown"); ok {
+		t.Error("expected an unknown recording ID to be refused")
+	}
+
+	stale := session.AddTerminalUser("bob")
+	session.terminalUsers[stale] = terminalUser{username: "bob", openedAt: time.Now().Add(-terminalUserTTL - time.Minute)}
+	session.AddTerminalUser("carol")
+	if _, ok := session.takeTerminalUser(stale); ok {
+		t.Error("expected stale terminal users to be dropped")
+	}
+}
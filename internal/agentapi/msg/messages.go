@@ -57,6 +57,10 @@ const (
 	CmdPeerRequestIntro
 	CmdThrottlePort
 	CmdPortForwardNotify
+	CmdSSHForward
+	CmdTerminalRecording
+	CmdTerminalSessionList
+	CmdTerminalSessionDetach
 )
 
 func WriteCommand(conn net.Conn, cmdType CmdType) error {
@@ -20,6 +20,7 @@ type LogMessage struct {
 	Service string
 	Message string
 	Date    time.Time
+	Seq     uint64 // Numbers the lines of an agent so servers give a line the same id
 }
 
 func SendLogMessage(conn net.Conn, message *LogMessage) error {
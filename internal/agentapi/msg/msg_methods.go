@@ -20,12 +20,26 @@ type CallMethodRequest struct {
 	Params         json.RawMessage `json:"params,omitempty" msgpack:"params,omitempty"`
 	ID             any             `json:"id,omitempty" msgpack:"id,omitempty"`
 	IsNotification bool            `json:"-" msgpack:"is_notification,omitempty"`
+	// Stream asks the agent to relay progress notifications from the method
+	// server as CallMethodStreamFrame messages.
+	Stream bool `json:"-" msgpack:"stream,omitempty"`
+	// TraceContext carries the caller's trace so the agent's span joins it.
+	TraceContext map[string]string `json:"-" msgpack:"trace_context,omitempty"`
 }
 
 type CallMethodResponse struct {
 	Response methods.JSONRPCResponse `json:"response" msgpack:"response"`
 }
 
+// CallMethodStreamFrame is the agent's reply to a streaming call, any number
+// of progress frames are followed by one frame holding the response. The
+// response field matches CallMethodResponse so a reply from an agent without
+// streaming support still decodes.
+type CallMethodStreamFrame struct {
+	Progress *methods.Progress        `json:"progress,omitempty" msgpack:"progress,omitempty"`
+	Response *methods.JSONRPCResponse `json:"response,omitempty" msgpack:"response,omitempty"`
+}
+
 // CallMethodBatchItem is one call inside a batch sent from the knot server to
 // the agent. The server has already routed and rewritten Method to local_name.
 type CallMethodBatchItem struct {
@@ -16,13 +16,17 @@ type RegisterResponse struct {
 	SSHKeys                  []string
 	SSHPrivateKey            string
 	GitHubUsernames          []string
+	GitHubKeyOptions         []string // key options for each of GitHubUsernames
 	Shell                    string
 	SSHHostSigner            string
+	SSHHostCertificate       string // host certificate for SSHHostSigner, empty if the server isn't an SSH CA
 	WithTerminal             bool
 	WithVSCodeTunnel         bool
 	WithCodeServer           bool
 	WithSSH                  bool
 	WithRunCommand           bool
+	TerminalRecording        bool // record terminal and SSH sessions
+	TerminalRecordingMaxSize int  // maximum recording size in bytes
 	Freeze                   bool
 	AgentToken               string
 	ServerURL                string
@@ -1,10 +1,11 @@
 package msg
 
 type RunCommandMessage struct {
-	Command string   `msgpack:"command"`
-	Args    []string `msgpack:"args"`
-	Timeout int      `msgpack:"timeout"`
-	Workdir string   `msgpack:"workdir"`
+	Command      string            `msgpack:"command"`
+	Args         []string          `msgpack:"args"`
+	Timeout      int               `msgpack:"timeout"`
+	Workdir      string            `msgpack:"workdir"`
+	TraceContext map[string]string `msgpack:"trace_context,omitempty"`
 }
 
 type RunCommandResponse struct {
@@ -0,0 +1,34 @@
+package msg
+
+import (
+	"net"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+// SSHForward reports a port forward opened through the agent's SSH server.
+type SSHForward struct {
+	Reverse     bool
+	Host        string
+	Port        uint32
+	KeyId       string // certificate key ID, empty for plain keys
+	Fingerprint string
+	RemoteAddr  string
+}
+
+func SendSSHForward(conn net.Conn, forward *SSHForward) error {
+	logger := log.WithGroup("agent")
+	err := WriteCommand(conn, CmdSSHForward)
+	if err != nil {
+		logger.WithError(err).Error("writing ssh forward command")
+		return err
+	}
+
+	err = WriteMessage(conn, forward)
+	if err != nil {
+		logger.WithError(err).Error("writing ssh forward message")
+		return err
+	}
+
+	return nil
+}
@@ -1,5 +1,7 @@
 package msg
 
+import "time"
+
 const (
 	MSG_TERMINAL_DATA = iota
 	MSG_TERMINAL_RESIZE
@@ -7,7 +9,12 @@ const (
 
 // message for terminal
 type Terminal struct {
-	Shell string
+	Shell       string
+	Username    string // user opening the terminal
+	UserId      string
+	Session     string // name of the shared session to attach to, empty for a private terminal
+	ReadOnly    bool   // attach to the shared session as a viewer
+	RecordingId string // returned with the terminal's recording so the server knows the user
 }
 
 type TerminalWindowSize struct {
@@ -16,3 +23,37 @@ type TerminalWindowSize struct {
 	X    uint16
 	Y    uint16
 }
+
+type TerminalSessionAttachment struct {
+	Id         string    `json:"attachment_id" msgpack:"attachment_id"`
+	UserId     string    `json:"user_id" msgpack:"user_id"`
+	Username   string    `json:"username" msgpack:"username"`
+	ReadOnly   bool      `json:"read_only" msgpack:"read_only"`
+	AttachedAt time.Time `json:"attached_at" msgpack:"attached_at"`
+}
+
+type TerminalSessionInfo struct {
+	Name      string                      `json:"name" msgpack:"name"`
+	Shell     string                      `json:"shell" msgpack:"shell"`
+	CreatedBy string                      `json:"created_by" msgpack:"created_by"`
+	CreatedAt time.Time                   `json:"created_at" msgpack:"created_at"`
+	Attached  []TerminalSessionAttachment `json:"attached" msgpack:"attached"`
+}
+
+type TerminalSessionListRequest struct{}
+
+type TerminalSessionListResponse struct {
+	Sessions []TerminalSessionInfo `json:"sessions" msgpack:"sessions"`
+}
+
+// TerminalSessionDetachRequest detaches one user from a shared session, or
+// ends the session if AttachmentId is empty.
+type TerminalSessionDetachRequest struct {
+	Session      string `json:"session" msgpack:"session"`
+	AttachmentId string `json:"attachment_id" msgpack:"attachment_id"`
+}
+
+type TerminalSessionDetachResponse struct {
+	Success bool   `json:"success" msgpack:"success"`
+	Error   string `json:"error" msgpack:"error"`
+}
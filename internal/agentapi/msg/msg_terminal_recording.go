@@ -0,0 +1,39 @@
+package msg
+
+import (
+	"net"
+	"time"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+// TerminalRecording carries a finished terminal session recording from the
+// agent to the server, Data is the gzip compressed asciicast.
+type TerminalRecording struct {
+	Source      string
+	RecordingId string // server's ID for the web terminal, see Terminal
+	KeyId       string // certificate key ID for SSH sessions
+	Fingerprint string // key fingerprint for SSH sessions
+	StartedAt   time.Time
+	Duration    time.Duration
+	Size        int
+	Truncated   bool
+	Data        []byte
+}
+
+func SendTerminalRecording(conn net.Conn, recording *TerminalRecording) error {
+	logger := log.WithGroup("agent")
+	err := WriteCommand(conn, CmdTerminalRecording)
+	if err != nil {
+		logger.WithError(err).Error("writing terminal recording command")
+		return err
+	}
+
+	err = WriteMessage(conn, recording)
+	if err != nil {
+		logger.WithError(err).Error("writing terminal recording message")
+		return err
+	}
+
+	return nil
+}
@@ -1,17 +1,22 @@
 package msg
 
-import "net"
+import (
+	"net"
+
+	"github.com/paularlott/knot/apiclient"
+)
 
 // Web tunnel management (server -> agent). These drive the agent's
 // agent-owned tunnel registry (the same one the in-space agentlink path uses),
 // letting a desktop client manage a space's web tunnels remotely.
 
 type TunnelStartRequest struct {
-	Protocol      string `json:"protocol" msgpack:"protocol"`
-	Port          uint16 `json:"port" msgpack:"port"`
-	Name          string `json:"name" msgpack:"name"`
-	TlsName       string `json:"tls_name,omitempty" msgpack:"tls_name,omitempty"`
-	TlsSkipVerify bool   `json:"tls_skip_verify,omitempty" msgpack:"tls_skip_verify,omitempty"`
+	Protocol      string                       `json:"protocol" msgpack:"protocol"`
+	Port          uint16                       `json:"port" msgpack:"port"`
+	Name          string                       `json:"name" msgpack:"name"`
+	TlsName       string                       `json:"tls_name,omitempty" msgpack:"tls_name,omitempty"`
+	TlsSkipVerify bool                         `json:"tls_skip_verify,omitempty" msgpack:"tls_skip_verify,omitempty"`
+	Policy        *apiclient.SpaceTunnelPolicy `json:"policy,omitempty" msgpack:"policy,omitempty"`
 }
 
 type TunnelStartResponse struct {
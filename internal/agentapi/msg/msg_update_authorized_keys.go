@@ -2,7 +2,8 @@ package msg
 
 // message sent to update the authorized keys within an agent
 type UpdateAuthorizedKeys struct {
-	SSHKeys         []string
-	SSHPrivateKey   string
-	GitHubUsernames []string
+	SSHKeys          []string
+	SSHPrivateKey    string
+	GitHubUsernames  []string
+	GitHubKeyOptions []string // key options for each of GitHubUsernames
 }
@@ -26,7 +26,7 @@ func handleStartTunnel(conn net.Conn, msg *CommandMsg) {
 
 	cfg := config.GetAgentConfig()
 
-	url, err := agenttunnel.CreateWebTunnel(request.Name, request.Protocol, request.Port, request.TlsName, request.TlsSkipVerify, server, token, cfg.TLS.SkipVerify)
+	url, err := agenttunnel.CreateWebTunnel(request.Name, request.Protocol, request.Port, request.TlsName, request.TlsSkipVerify, request.Policy, server, token, cfg.TLS.SkipVerify)
 	if err != nil {
 		log.WithError(err).Error("Failed to create tunnel")
 		sendMsg(conn, CommandNil, StartTunnelResponse{Success: false, Error: err.Error()})
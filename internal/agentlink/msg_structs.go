@@ -1,6 +1,9 @@
 package agentlink
 
-import "github.com/paularlott/knot/internal/methods"
+import (
+	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/tunnel_server"
+)
 
 type ConnectResponse struct {
 	Success bool   `msgpack:"s"`
@@ -103,11 +106,12 @@ type LogRequest struct {
 }
 
 type StartTunnelRequest struct {
-	Protocol      string `json:"protocol" msgpack:"protocol"`
-	Port          uint16 `json:"port" msgpack:"port"`
-	Name          string `json:"name" msgpack:"name"`
-	TlsName       string `json:"tls_name,omitempty" msgpack:"tls_name,omitempty"`
-	TlsSkipVerify bool   `json:"tls_skip_verify,omitempty" msgpack:"tls_skip_verify,omitempty"`
+	Protocol      string                      `json:"protocol" msgpack:"protocol"`
+	Port          uint16                      `json:"port" msgpack:"port"`
+	Name          string                      `json:"name" msgpack:"name"`
+	TlsName       string                      `json:"tls_name,omitempty" msgpack:"tls_name,omitempty"`
+	TlsSkipVerify bool                        `json:"tls_skip_verify,omitempty" msgpack:"tls_skip_verify,omitempty"`
+	Policy        *tunnel_server.TunnelPolicy `json:"policy,omitempty" msgpack:"policy,omitempty"`
 }
 
 type StartTunnelResponse struct {
@@ -13,8 +13,9 @@ import (
 // serverURL is normalised (scheme added if missing, trailing slash trimmed) and
 // the WS URL is derived from it. This is shared by the agentlink (in-space CLI)
 // and the server-relay (remote desktop) control paths so both populate the same
-// registry identically.
-func CreateWebTunnel(name, protocol string, port uint16, tlsName string, tlsSkipVerify bool, serverURL, token string, skipTLSVerify bool) (string, error) {
+// registry identically. policy restricts access to the tunnel, nil leaves it
+// open.
+func CreateWebTunnel(name, protocol string, port uint16, tlsName string, tlsSkipVerify bool, policy *tunnel_server.TunnelPolicy, serverURL, token string, skipTLSVerify bool) (string, error) {
 	if protocol != "http" && protocol != "https" {
 		return "", fmt.Errorf("invalid protocol, must be http or https")
 	}
@@ -24,6 +25,11 @@ func CreateWebTunnel(name, protocol string, port uint16, tlsName string, tlsSkip
 	if port < 1 {
 		return "", fmt.Errorf("invalid port")
 	}
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return "", err
+		}
+	}
 	if IsTunneled(name) {
 		return "", fmt.Errorf("a tunnel with this name already exists")
 	}
@@ -42,6 +48,7 @@ func CreateWebTunnel(name, protocol string, port uint16, tlsName string, tlsSkip
 		TunnelName:    name,
 		TlsName:       tlsName,
 		TlsSkipVerify: tlsSkipVerify,
+		Policy:        policy,
 	})
 	if err := client.ConnectAndServe(); err != nil {
 		return "", fmt.Errorf("failed to create tunnel: %w", err)
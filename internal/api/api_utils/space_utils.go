@@ -48,6 +48,7 @@ func GetLatestSpaceResourceUsage(spaceId string) *apiclient.SpaceResourceUsage {
 		MemoryLimitBytes: sample.MemoryLimitBytes,
 		DiskUsedBytes:    sample.DiskUsedBytes,
 		DiskLimitBytes:   sample.DiskLimitBytes,
+		CustomMetrics:    sample.CustomMetrics,
 	}
 }
 
@@ -241,6 +242,7 @@ func GetSpaceDetails(spaceId string, user *model.User) (*apiclient.SpaceDefiniti
 			MemoryLimitBytes: state.MemoryLimitBytes,
 			DiskUsedBytes:    state.DiskUsedBytes,
 			DiskLimitBytes:   state.DiskLimitBytes,
+			CustomMetrics:    state.CustomMetrics,
 		}
 	} else {
 		response.ResourceUsage = GetLatestSpaceResourceUsage(space.Id)
@@ -71,6 +71,9 @@ func GetTemplateDetails(templateId string, user *model.User) (*apiclient.Templat
 		WithCodeServer:           template.WithCodeServer,
 		WithSSH:                  template.WithSSH,
 		WithRunCommand:           template.WithRunCommand,
+		DisableSSHLocalForward:   template.DisableSSHLocalForward,
+		WithSSHReverseForward:    template.WithSSHReverseForward,
+		WithTerminalRecording:    template.WithTerminalRecording,
 		AllowNodeMigration:       template.AllowNodeMigration,
 		StartupScriptId:          template.StartupScriptId,
 		ShutdownScriptId:         template.ShutdownScriptId,
@@ -93,6 +96,7 @@ func GetTemplateDetails(templateId string, user *model.User) (*apiclient.Templat
 		HealthCheckAutoRestart:   template.HealthCheckAutoRestart,
 		DisableUserActivity:      template.DisableUserActivity,
 		Ports:                    template.Ports,
+		IdlePolicy:               template.IdlePolicy,
 	}
 
 	// Handle schedule
@@ -1,6 +1,7 @@
 package api_utils
 
 import (
+	"slices"
 	"time"
 
 	"github.com/paularlott/gossip/hlc"
@@ -10,6 +11,7 @@ import (
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/sshca"
 	"github.com/paularlott/knot/internal/util"
 
 	"github.com/paularlott/knot/internal/log"
@@ -181,6 +183,22 @@ func (auu *ApiUtilsUsers) UpdateSpacesSSHKey(user *model.User) {
 	log.Debug("Finished updating agent SSH key for user", "user", user.Id)
 }
 
+// UpdateRoleSSHKeys updates the SSH keys in the spaces of the users with the
+// role, the key options follow the forwarding permissions of the roles.
+func (auu *ApiUtilsUsers) UpdateRoleSSHKeys(roleId string) {
+	users, err := database.GetInstance().GetUsers()
+	if err != nil {
+		log.WithError(err).Error("Failed to get users to update SSH keys", "role", roleId)
+		return
+	}
+
+	for _, user := range users {
+		if user.Active && !user.IsDeleted && slices.Contains(user.Roles, roleId) {
+			auu.UpdateSpacesSSHKey(user)
+		}
+	}
+}
+
 func (auu *ApiUtilsUsers) UpdateSpaceSSHKeys(space *model.Space, user *model.User) {
 	db := database.GetInstance()
 	cfg := config.GetServerConfig()
@@ -207,6 +225,7 @@ func (auu *ApiUtilsUsers) UpdateSpaceSSHKeys(space *model.Space, user *model.Use
 		if agentState.SSHPort > 0 {
 			keys := []string{}
 			usernames := []string{}
+			keyOptions := []string{}
 			// Add the owning user's keys. The private key is owner-only and is not copied from shared users.
 			owner := user
 			if space.UserId != user.Id {
@@ -218,12 +237,10 @@ func (auu *ApiUtilsUsers) UpdateSpaceSSHKeys(space *model.Space, user *model.Use
 				}
 			}
 			sshPrivateKey := util.DecryptSSHPrivateKey(cfg.EncryptionKey, owner.SSHPrivateKey)
-			if owner.SSHPublicKey != "" {
-				keys = append(keys, util.SplitSSHPublicKeys(owner.SSHPublicKey)...)
-			}
-			if owner.GitHubUsername != "" {
-				usernames = append(usernames, owner.GitHubUsername)
-			}
+			ownerKeys, ownerUsernames, ownerKeyOptions := template.SSHAuthorizedKeys(owner)
+			keys = append(keys, ownerKeys...)
+			usernames = append(usernames, ownerUsernames...)
+			keyOptions = append(keyOptions, ownerKeyOptions...)
 
 			// If space is shared then get the other users keys
 			for _, sharedUserId := range space.SharedUserIds() {
@@ -233,17 +250,19 @@ func (auu *ApiUtilsUsers) UpdateSpaceSSHKeys(space *model.Space, user *model.Use
 
 				other, err := db.GetUser(sharedUserId)
 				if err == nil && other != nil {
-					if other.SSHPublicKey != "" {
-						keys = append(keys, util.SplitSSHPublicKeys(other.SSHPublicKey)...)
-					}
-					if other.GitHubUsername != "" {
-						usernames = append(usernames, other.GitHubUsername)
-					}
+					otherKeys, otherUsernames, otherKeyOptions := template.SSHAuthorizedKeys(other)
+					keys = append(keys, otherKeys...)
+					usernames = append(usernames, otherUsernames...)
+					keyOptions = append(keyOptions, otherKeyOptions...)
 				}
 			}
 
+			if caKey := sshca.AuthorizedKey(space.Id); caKey != "" {
+				keys = append(keys, util.AddSSHKeyOptions(caKey, template.SSHKeyOptions(nil)))
+			}
+
 			log.Debug("Sending SSH public key to agent", "sending", space.Id)
-			if err := agentState.SendUpdateAuthorizedKeys(keys, sshPrivateKey, usernames); err != nil {
+			if err := agentState.SendUpdateAuthorizedKeys(keys, sshPrivateKey, usernames, keyOptions); err != nil {
 				log.WithError(err).Debug("Failed to send SSH public key to agent:")
 			}
 		}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
@@ -11,8 +13,10 @@ import (
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/ldap"
 	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/knot/internal/middleware"
+	"github.com/paularlott/knot/internal/oidc"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 	"github.com/paularlott/knot/internal/totp"
@@ -159,7 +163,9 @@ func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
 
 	cfg := config.GetServerConfig()
 
-	if cfg.AuthIPRateLimiting {
+	// OIDC logins have no email to limit by until the code is exchanged, so
+	// they're always limited by IP
+	if cfg.AuthIPRateLimiting || request.Provider != "" {
 		// Apply rate limiting by IP
 		ipLimiter := getIPLimiter(clientIP)
 		if !ipLimiter.Allow() {
@@ -169,6 +175,12 @@ func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// OIDC logins exchange a code instead of checking a password
+	if request.Provider != "" {
+		handleProviderAuthorization(w, r, &request, clientIP)
+		return
+	}
+
 	// Apply rate limiting by email
 	emailLimiter := getEmailLimiter(request.Email)
 	if !emailLimiter.Allow() {
@@ -183,9 +195,16 @@ func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the user & check the password
+	// Get the user & check the password, directory users and unknown emails
+	// are checked against the directory when LDAP is enabled, as are users
+	// without a local password if they may be linked by email
 	user, err := db.GetUserByEmail(request.Email)
-	if err != nil || !user.Active || !user.CheckPassword(request.Password) {
+	if ldap.Enabled() && (err != nil || ldap.IsDirectoryUser(user) || (user.Password == "" && cfg.LDAP.LinkByEmail)) {
+		user, err = authenticateLDAP(r, request.Email, request.Password)
+	} else if err == nil && (!user.Active || !user.CheckPassword(request.Password)) {
+		err = ldap.ErrInvalidCredentials
+	}
+	if err != nil {
 		code := http.StatusUnauthorized
 
 		audit.LogWithRequest(r,
@@ -249,21 +268,7 @@ func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
 
 	// Only create the cookie for web auth
 	if r.URL.Path == "/api/auth/web" {
-		cfg := config.GetServerConfig()
-		// Drop any stale cookies first (e.g. a host-only cookie from before
-		// wildcard-domain widening) so they can't shadow the fresh session.
-		middleware.DeleteSessionCookie(w)
-		cookie := &http.Cookie{
-			Name:     model.WebSessionCookie,
-			Value:    session.Id,
-			Path:     "/",
-			Domain:   cfg.SessionCookieDomain(),
-			HttpOnly: true,
-			Secure:   cfg.TLS.UseTLS,
-			SameSite: http.SameSiteLaxMode,
-		}
-
-		http.SetCookie(w, cookie)
+		middleware.SetSessionCookie(w, session)
 	}
 
 	audit.LogWithRequest(r,
@@ -288,6 +293,179 @@ func HandleAuthorization(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// authenticateLDAP checks the password with a bind as the directory user,
+// creating or updating the knot user to match the directory.
+func authenticateLDAP(r *http.Request, email, password string) (*model.User, error) {
+	entry, err := ldap.Authenticate(email, password)
+	if err != nil {
+		if !errors.Is(err, ldap.ErrInvalidCredentials) && !errors.Is(err, ldap.ErrUserNotFound) {
+			log.Error("ldap: unable to authenticate", "email", email, "error", err)
+		}
+		return nil, err
+	}
+
+	user, created, err := ldap.LoginUser(entry)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		audit.LogWithRequest(r,
+			ldap.ProviderId,
+			model.AuditActorTypeSystem,
+			model.AuditEventUserCreate,
+			fmt.Sprintf("Created user %s (%s) on first login", user.Username, user.Email),
+			&map[string]interface{}{
+				"agent":           r.UserAgent(),
+				"IP":              r.RemoteAddr,
+				"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+				"user_id":         user.Id,
+				"user_name":       user.Username,
+				"user_email":      user.Email,
+			},
+		)
+	}
+
+	return user, nil
+}
+
+// handleProviderAuthorization signs in with an authorization code the API
+// client obtained from an OIDC provider using PKCE.
+func handleProviderAuthorization(w http.ResponseWriter, r *http.Request, request *apiclient.AuthLoginRequest, clientIP string) {
+	provider := oidc.GetProvider(request.Provider)
+	if provider == nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: oidc.ErrUnknownProvider.Error()})
+		return
+	}
+	if request.Code == "" || request.CodeVerifier == "" || request.RedirectURI == "" {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "code, code_verifier and redirect_uri are required"})
+		return
+	}
+
+	auditFailed := func(actor string, err error) {
+		audit.LogWithRequest(r,
+			actor,
+			model.AuditActorTypeUser,
+			model.AuditEventAuthFailed,
+			"",
+			&map[string]interface{}{
+				"agent":    r.UserAgent(),
+				"IP":       clientIP,
+				"provider": provider.Id(),
+				"error":    err.Error(),
+			},
+		)
+	}
+
+	loginState, err := oidc.ConsumeAPILoginState(w, r, provider.Id(), request.LoginState)
+	if err != nil {
+		auditFailed(provider.Id(), err)
+		rest.WriteResponse(http.StatusUnauthorized, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), request.RedirectURI, request.Code, request.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Warn("oidc: login failed", "provider", provider.Id(), "error", err)
+		auditFailed(provider.Id(), err)
+		rest.WriteResponse(http.StatusUnauthorized, w, r, ErrorResponse{Error: "invalid authorization code"})
+		return
+	}
+
+	user, created, err := provider.LoginUser(identity, "")
+	if err != nil {
+		auditFailed(identity.Email, err)
+		rest.WriteResponse(http.StatusUnauthorized, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if created {
+		audit.LogWithRequest(r,
+			provider.Id(),
+			model.AuditActorTypeSystem,
+			model.AuditEventUserCreate,
+			fmt.Sprintf("Created user %s (%s) on first login", user.Username, user.Email),
+			&map[string]interface{}{
+				"agent":      r.UserAgent(),
+				"IP":         clientIP,
+				"user_id":    user.Id,
+				"user_name":  user.Username,
+				"user_email": user.Email,
+			},
+		)
+	}
+
+	session := model.NewSession(r, user.Id)
+	if err := database.GetSessionStorage().SaveSession(session); err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+	service.GetTransport().GossipSession(session)
+	sse.PublishSessionsChanged("")
+
+	if r.URL.Path == "/api/auth/web" {
+		middleware.SetSessionCookie(w, session)
+	}
+
+	audit.LogWithRequest(r,
+		user.Email,
+		model.AuditActorTypeUser,
+		model.AuditEventAuthOk,
+		"",
+		&map[string]interface{}{
+			"agent":    r.UserAgent(),
+			"IP":       clientIP,
+			"provider": provider.Id(),
+		},
+	)
+
+	rest.WriteResponse(http.StatusOK, w, r, apiclient.AuthLoginResponse{
+		Status: true,
+		Token:  session.Id,
+	})
+}
+
+// Lists the OIDC providers, for clients running their own authorization code
+// flow before calling POST /api/auth.
+func HandleGetAuthProviders(w http.ResponseWriter, r *http.Request) {
+	providers := []apiclient.AuthProviderInfo{}
+	for _, provider := range oidc.GetProviders() {
+		providers = append(providers, apiclient.AuthProviderInfo{
+			Id:          provider.Id(),
+			DisplayName: provider.DisplayName(),
+			Issuer:      provider.Issuer(),
+			ClientId:    provider.ClientId(),
+		})
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, providers)
+}
+
+// Starts an OIDC login for an API client, the nonce is kept in the signed
+// login state so it can be checked against the ID token on any server.
+func HandleStartProviderLogin(w http.ResponseWriter, r *http.Request) {
+	provider := oidc.GetProvider(r.PathValue("provider_id"))
+	if provider == nil {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: oidc.ErrUnknownProvider.Error()})
+		return
+	}
+
+	loginState, err := oidc.NewAPILoginState(provider.Id())
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+	signed, err := loginState.SetAPICookie(w)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, apiclient.AuthProviderLoginStart{
+		State:      loginState.State,
+		Nonce:      loginState.Nonce,
+		LoginState: signed,
+	})
+}
+
 func HandleLogout(w http.ResponseWriter, r *http.Request) {
 	result := false
 	value := r.Context().Value("session")
@@ -11,6 +11,7 @@ import (
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	knotlmchatkit "github.com/paularlott/knot/internal/lmchatkit"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 	"github.com/paularlott/knot/internal/util"
@@ -259,6 +260,7 @@ func HandleCreateCommand(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipCommand(command)
 	sse.PublishSlashCommandsChanged(command.Id)
+	internal_mcp.NotifyPromptsChanged()
 	knotlmchatkit.BroadcastCommandEvent()
 
 	audit.LogWithRequest(r,
@@ -375,6 +377,7 @@ func HandleUpdateCommand(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipCommand(command)
 	sse.PublishSlashCommandsChanged(command.Id)
+	internal_mcp.NotifyPromptsChanged()
 	knotlmchatkit.BroadcastCommandEvent()
 
 	audit.LogWithRequest(r,
@@ -453,6 +456,7 @@ func HandleDeleteCommand(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipCommand(command)
 	sse.PublishSlashCommandsDeleted(command.Id)
+	internal_mcp.NotifyPromptsChanged()
 	knotlmchatkit.BroadcastCommandEvent()
 
 	audit.LogWithRequest(r,
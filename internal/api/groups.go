@@ -34,12 +34,15 @@ func HandleGetGroups(w http.ResponseWriter, r *http.Request) {
 		}
 
 		g := apiclient.GroupInfo{
-			Id:           group.Id,
-			Name:         group.Name,
-			MaxSpaces:    group.MaxSpaces,
-			ComputeUnits: group.ComputeUnits,
-			StorageUnits: group.StorageUnits,
-			MaxTunnels:   group.MaxTunnels,
+			Id:                 group.Id,
+			Name:               group.Name,
+			MaxSpaces:          group.MaxSpaces,
+			ComputeUnits:       group.ComputeUnits,
+			StorageUnits:       group.StorageUnits,
+			MaxTunnels:         group.MaxTunnels,
+			DailyTokenBudget:   group.DailyTokenBudget,
+			MonthlyTokenBudget: group.MonthlyTokenBudget,
+			AllowedModels:      group.AllowedModels,
 		}
 		data.Groups = append(data.Groups, g)
 		data.Count++
@@ -83,6 +86,12 @@ func HandleUpdateGroup(w http.ResponseWriter, r *http.Request) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid tunnel limit"})
 		return
 	}
+	for _, modelName := range request.AllowedModels {
+		if !validate.Required(modelName) || !validate.MaxLength(modelName, 255) {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid allowed model"})
+			return
+		}
+	}
 
 	db := database.GetInstance()
 	user := r.Context().Value("user").(*model.User)
@@ -99,6 +108,9 @@ func HandleUpdateGroup(w http.ResponseWriter, r *http.Request) {
 	group.ComputeUnits = request.ComputeUnits
 	group.StorageUnits = request.StorageUnits
 	group.MaxTunnels = request.MaxTunnels
+	group.DailyTokenBudget = request.DailyTokenBudget
+	group.MonthlyTokenBudget = request.MonthlyTokenBudget
+	group.AllowedModels = request.AllowedModels
 	group.UpdatedAt = hlc.Now()
 	group.UpdatedUserId = user.Id
 
@@ -158,8 +170,17 @@ func HandleCreateGroup(w http.ResponseWriter, r *http.Request) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid tunnel limit"})
 		return
 	}
+	for _, modelName := range request.AllowedModels {
+		if !validate.Required(modelName) || !validate.MaxLength(modelName, 255) {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid allowed model"})
+			return
+		}
+	}
 
 	group := model.NewGroup(request.Name, user.Id, request.MaxSpaces, request.ComputeUnits, request.StorageUnits, request.MaxTunnels)
+	group.DailyTokenBudget = request.DailyTokenBudget
+	group.MonthlyTokenBudget = request.MonthlyTokenBudget
+	group.AllowedModels = request.AllowedModels
 
 	err = database.GetInstance().SaveGroup(group)
 	if err != nil {
@@ -258,12 +279,15 @@ func HandleGetGroup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := apiclient.GroupInfo{
-		Id:           group.Id,
-		Name:         group.Name,
-		MaxSpaces:    group.MaxSpaces,
-		ComputeUnits: group.ComputeUnits,
-		StorageUnits: group.StorageUnits,
-		MaxTunnels:   group.MaxTunnels,
+		Id:                 group.Id,
+		Name:               group.Name,
+		MaxSpaces:          group.MaxSpaces,
+		ComputeUnits:       group.ComputeUnits,
+		StorageUnits:       group.StorageUnits,
+		MaxTunnels:         group.MaxTunnels,
+		DailyTokenBudget:   group.DailyTokenBudget,
+		MonthlyTokenBudget: group.MonthlyTokenBudget,
+		AllowedModels:      group.AllowedModels,
 	}
 
 	rest.WriteResponse(http.StatusOK, w, r, data)
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/llmusage"
+	"github.com/paularlott/knot/internal/util/rest"
+)
+
+// HandleGetLLMUsage reports the AI token usage between the from and to query
+// times, the report defaults to the current month.
+func HandleGetLLMUsage(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	from := model.LLMUsageMonth(now)
+	to := now
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid from time"})
+			return
+		}
+		from = t.UTC()
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid to time"})
+			return
+		}
+		to = t.UTC()
+	}
+	if to.Before(from) {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "The to time must be after the from time"})
+		return
+	}
+
+	db := database.GetInstance()
+
+	// Load the current month as well so group usage can be compared with the budgets
+	loadFrom := from
+	if month := model.LLMUsageMonth(now); month.Before(loadFrom) {
+		loadFrom = month
+	}
+	usage, err := db.GetLLMUsageRange(loadFrom, now)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	users, err := db.GetUsers()
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	groups, err := db.GetGroups()
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, llmusage.BuildReport(usage, users, groups, from, to, now))
+}
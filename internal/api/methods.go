@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/paularlott/knot/internal/agentapi/agent_server"
@@ -12,7 +13,10 @@ import (
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util/rest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -53,7 +57,9 @@ func HandleGetMethods(w http.ResponseWriter, r *http.Request) {
 //
 // Single requests return a single JSON-RPC response object. Batch requests
 // return a JSON array of responses. Notifications produce no response entry.
-// If all items in a batch are notifications the HTTP response is 204.
+// If all items in a batch are notifications the HTTP response is 204. A single
+// request sent with Accept: text/event-stream is answered with server-sent
+// events carrying the progress of a streaming method and then the response.
 func HandleCallMethod(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value("user").(*model.User)
 
@@ -104,10 +110,17 @@ func handleSingleCall(w http.ResponseWriter, r *http.Request, body []byte, user
 		return
 	}
 
+	ctx, span := tracing.StartKind(r.Context(), "agent.call_method", trace.SpanKindClient,
+		attribute.String("knot.method", request.Method),
+		attribute.String("knot.space_id", entry.SpaceID),
+	)
+	defer span.End()
+
 	callReq := &msg.CallMethodRequest{
-		Method: localName,
-		Params: request.Params,
-		ID:     request.ID,
+		Method:       localName,
+		Params:       request.Params,
+		ID:           request.ID,
+		TraceContext: tracing.Carrier(ctx),
 	}
 
 	if isNotification {
@@ -116,14 +129,48 @@ func handleSingleCall(w http.ResponseWriter, r *http.Request, body []byte, user
 		return
 	}
 
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		streamSingleCall(w, r, session, entry, callReq)
+		return
+	}
+
 	response, err := session.SendCallMethod(callReq, entry.Server.Timeout)
 	if err != nil {
+		tracing.RecordError(span, err)
 		writeJSONRPCError(w, r, -32000, err.Error(), request.ID)
 		return
 	}
 	_ = rest.WriteResponse(http.StatusOK, w, r, response.Response)
 }
 
+// streamSingleCall replies to a call with server-sent events. The progress of
+// a streaming method is sent as notifications/progress JSON-RPC notifications
+// with the request id as the progress token, the final event is the response.
+func streamSingleCall(w http.ResponseWriter, r *http.Request, session *agent_server.Session, entry *methods.Entry, callReq *msg.CallMethodRequest) {
+	stream := rest.NewStreamWriter(w, r)
+	defer stream.Close()
+
+	var response *msg.CallMethodResponse
+	var err error
+	if entry.Streaming {
+		response, err = session.SendCallMethodStream(callReq, entry.Server.Timeout, func(progress *methods.Progress) {
+			progress.ProgressToken = callReq.ID
+			_ = stream.WriteChunk(methods.ProgressNotification(progress))
+		})
+	} else {
+		response, err = session.SendCallMethod(callReq, entry.Server.Timeout)
+	}
+	if err != nil {
+		_ = stream.WriteChunk(methods.JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error:   &methods.JSONRPCError{Code: -32000, Message: err.Error()},
+			ID:      callReq.ID,
+		})
+		return
+	}
+	_ = stream.WriteChunk(response.Response)
+}
+
 // --------------------------------------------------------------------
 // Batch
 // --------------------------------------------------------------------
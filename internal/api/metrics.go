@@ -0,0 +1,219 @@
+package api
+
+import (
+	"crypto/subtle"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/build"
+	"github.com/paularlott/knot/internal/agentapi/agent_server"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/metrics"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/tunnel_server"
+)
+
+// spaceMetricsRefresh is how often the space metrics are collected, they list
+// every space and user so aren't read from the database on each scrape.
+const spaceMetricsRefresh = 30 * time.Second
+
+type gaugeSample struct {
+	name   string
+	help   string
+	value  float64
+	labels []string
+}
+
+var (
+	spaceMetricsMu sync.RWMutex
+	spaceMetrics   []gaugeSample
+)
+
+// HandleMetrics serves the server's metrics in the Prometheus text format.
+// Counts are read from the local server's state, so each server in a cluster
+// should be scraped. The metrics name users and spaces so the token is always
+// required.
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	cfg := config.GetServerConfig()
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if cfg.Metrics.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Metrics.Token)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m := metrics.NewWriter()
+	m.Gauge("knot_build_info", "Version of the knot server.", 1, "version", build.Version, "zone", cfg.Zone)
+
+	spaceMetricsMu.RLock()
+	for _, s := range spaceMetrics {
+		m.Gauge(s.name, s.help, s.value, s.labels...)
+	}
+	spaceMetricsMu.RUnlock()
+
+	m.Gauge("knot_agent_sessions", "Agent sessions connected to this server.", float64(len(agent_server.GetSessions())))
+
+	dispatcher := service.GetEventDispatcher()
+	sinkStats := dispatcher.SinkStats()
+	for _, stats := range sinkStats {
+		m.Gauge("knot_event_sink_queue_depth", "Events waiting in the sink's delivery queue.", float64(stats.QueueDepth), "sink_id", stats.SinkId, "sink", stats.SinkName, "type", stats.SinkType)
+	}
+	for _, stats := range sinkStats {
+		for _, result := range []struct {
+			name  string
+			count uint64
+		}{
+			{"delivered", stats.Delivered},
+			{"retried", stats.Retried},
+			{"given_up", stats.GivenUp},
+			{"dropped", stats.Dropped},
+		} {
+			m.Counter("knot_event_sink_deliveries_total", "Event sink delivery attempts by result.", float64(result.count), "sink_id", stats.SinkId, "sink", stats.SinkName, "result", result.name)
+		}
+	}
+	for status, count := range dispatcher.InFlightCounts() {
+		m.Gauge("knot_event_deliveries_in_flight", "Event deliveries being tracked by status.", float64(count), "status", status)
+	}
+
+	for _, stats := range methods.DefaultRegistry().Stats() {
+		m.Gauge("knot_method_registrations", "Spaces serving each method.", float64(stats.Registrations), "method", stats.Name)
+		m.Gauge("knot_method_in_flight", "Method calls in flight.", float64(stats.InFlight), "method", stats.Name)
+	}
+
+	tunnels := tunnel_server.CountTunnels()
+	for _, tunnelType := range []string{"web", "port"} {
+		m.Gauge("knot_tunnels", "Tunnels open on this server.", float64(tunnels[tunnelType]), "type", tunnelType)
+	}
+
+	if transport := service.GetTransport(); transport != nil {
+		nodes := make(map[[2]string]int)
+		for _, node := range transport.Nodes() {
+			nodes[[2]string{node.GetObservedState().String(), node.Metadata.GetString("zone")}]++
+		}
+		for key, count := range nodes {
+			m.Gauge("knot_cluster_nodes", "Cluster members by gossip state and zone.", float64(count), "state", key[0], "zone", key[1])
+		}
+		leader := 0.0
+		if transport.IsLeader() {
+			leader = 1
+		}
+		m.Gauge("knot_cluster_leader", "1 if this server is the cluster leader.", leader)
+	}
+
+	m.Serve(w)
+}
+
+// StartMetricsRefresh collects the space metrics in the background, scrapes
+// serve the latest collection.
+func StartMetricsRefresh(zone string) {
+	go func() {
+		refreshSpaceMetrics(zone)
+		ticker := time.NewTicker(spaceMetricsRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshSpaceMetrics(zone)
+		}
+	}()
+}
+
+func refreshSpaceMetrics(zone string) {
+	samples, err := collectSpaceMetrics(zone)
+	if err != nil {
+		log.WithError(err).Error("failed to collect space metrics")
+		return
+	}
+
+	spaceMetricsMu.Lock()
+	spaceMetrics = samples
+	spaceMetricsMu.Unlock()
+}
+
+// collectSpaceMetrics returns the space counts and, for spaces deployed in
+// this zone, the resource usage from their latest minute usage sample.
+func collectSpaceMetrics(zone string) ([]gaugeSample, error) {
+	db := database.GetInstance()
+	spaces, err := db.GetSpaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []gaugeSample
+	gauge := func(name, help string, value float64, labels ...string) {
+		samples = append(samples, gaugeSample{name: name, help: help, value: value, labels: labels})
+	}
+
+	templateNames := make(map[string]string)
+	if templates, err := db.GetTemplates(); err == nil {
+		for _, template := range templates {
+			templateNames[template.Id] = template.Name
+		}
+	}
+	userNames := make(map[string]string)
+	if users, err := db.GetUsers(); err == nil {
+		for _, user := range users {
+			userNames[user.Id] = user.Username
+		}
+	}
+
+	type spaceKey struct{ zone, template, state string }
+	counts := make(map[spaceKey]int)
+	now := time.Now().UTC()
+	for _, space := range spaces {
+		if space.IsDeleted {
+			continue
+		}
+
+		state := "stopped"
+		switch {
+		case space.IsDeleting:
+			state = "deleting"
+		case space.IsPending:
+			state = "pending"
+		case space.IsDeployed:
+			state = "running"
+		}
+		counts[spaceKey{space.Zone, templateNames[space.TemplateId], state}]++
+
+		if !space.IsDeployed || (space.Zone != "" && space.Zone != zone) {
+			continue
+		}
+		sample := latestUsageSample(db, space.Id, now)
+		if sample == nil {
+			continue
+		}
+		labels := []string{"space_id", space.Id, "space", space.Name, "user", userNames[space.UserId], "zone", space.Zone, "template", templateNames[space.TemplateId]}
+		gauge("knot_space_cpu_percent", "CPU use of the space over its latest usage sample.", sample.CPUPercent, labels...)
+		gauge("knot_space_memory_used_bytes", "Memory used by the space.", float64(sample.MemoryUsedBytes), labels...)
+		gauge("knot_space_memory_limit_bytes", "Memory limit of the space.", float64(sample.MemoryLimitBytes), labels...)
+		gauge("knot_space_disk_used_bytes", "Disk used by the space.", float64(sample.DiskUsedBytes), labels...)
+		gauge("knot_space_disk_limit_bytes", "Disk limit of the space.", float64(sample.DiskLimitBytes), labels...)
+		for _, name := range slices.Sorted(maps.Keys(sample.CustomMetrics)) {
+			gauge("knot_space_custom_metric", "Application metrics reported by the space over OTLP.", sample.CustomMetrics[name], append(labels, "metric", name)...)
+		}
+	}
+
+	for key, count := range counts {
+		gauge("knot_spaces", "Spaces by zone, template and state.", float64(count), "zone", key.zone, "template", key.template, "state", key.state)
+	}
+
+	return samples, nil
+}
+
+// latestUsageSample returns the space's usage sample for the current minute,
+// falling back to the previous minute early in a bucket.
+func latestUsageSample(db database.DbDriver, spaceId string, now time.Time) *model.SpaceUsageSample {
+	for _, at := range []time.Time{now, now.Add(-time.Minute)} {
+		id := model.SpaceUsageSampleIdForKind(spaceId, model.SpaceUsageBucketMinute, at)
+		if sample, err := db.GetSpaceUsageSample(id); err == nil && sample != nil {
+			return sample
+		}
+	}
+	return nil
+}
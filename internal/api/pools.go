@@ -47,6 +47,7 @@ func HandleCreatePool(w http.ResponseWriter, r *http.Request) {
 	}
 	pool := model.NewPoolDefinition(request.Name, request.TemplateId, request.StartupScriptId, request.DesiredCount, user.Id)
 	pool.Active = request.Active
+	pool.Autoscale = request.Autoscale
 	if err := service.GetPoolService().Create(pool, user); err != nil {
 		if pool.Id != "" && !pool.IsDeleted {
 			rest.WriteResponse(http.StatusCreated, w, r, apiclient.PoolCreateResponse{Status: true, Id: pool.Id, Message: err.Error()})
@@ -89,6 +90,13 @@ func HandleUpdatePool(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if request.Autoscale != nil && *request.Autoscale != pool.Autoscale {
+		if err := service.GetPoolService().SetAutoscale(pool, *request.Autoscale, user); err != nil {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
 	if request.Active != nil && *request.Active != pool.Active {
 		if *request.Active {
 			if err := service.GetPoolService().Start(pool, user); err != nil {
@@ -75,6 +75,8 @@ func HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	forwardingChanged := model.SSHForwardingChanged(role, &model.Role{Permissions: request.Permissions})
+
 	role.Name = request.Name
 	role.Permissions = request.Permissions
 	role.UpdatedUserId = user.Id
@@ -104,6 +106,10 @@ func HandleUpdateRole(w http.ResponseWriter, r *http.Request) {
 	service.GetTransport().GossipRole(role)
 	sse.PublishRolesChanged(role.Id)
 
+	if forwardingChanged {
+		go service.GetUserService().UpdateRoleSSHKeys(role.Id)
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -209,6 +215,8 @@ func HandleDeleteRole(w http.ResponseWriter, r *http.Request) {
 	service.GetTransport().GossipRole(role)
 	sse.PublishRolesDeleted(role.Id)
 
+	go service.GetUserService().UpdateRoleSSHKeys(role.Id)
+
 	w.WriteHeader(http.StatusOK)
 }
 
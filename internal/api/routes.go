@@ -4,8 +4,10 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/middleware"
 	"github.com/paularlott/knot/internal/oauth2"
+	"github.com/paularlott/knot/internal/scim"
 )
 
 func ApiRoutes(router *http.ServeMux) {
@@ -15,6 +17,11 @@ func ApiRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /api/search", middleware.ApiAuth(HandleSearch))
 	router.HandleFunc("POST /api/auth/logout", middleware.ApiAuth(HandleLogout))
 
+	// Metrics, guarded by the scrape token rather than user auth
+	if config.GetServerConfig().Metrics.Enabled {
+		router.HandleFunc("GET /metrics", HandleMetrics)
+	}
+
 	// Users
 	router.HandleFunc("GET /api/users", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSpaces(HandleGetUsers)))
 	router.HandleFunc("POST /api/users", middleware.ApiAuth(middleware.ApiPermissionManageUsers(HandleCreateUser)))
@@ -26,6 +33,7 @@ func ApiRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /api/users/{user_id}", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleGetUser)))
 	router.HandleFunc("PUT /api/users/{user_id}", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleUpdateUser)))
 	router.HandleFunc("DELETE /api/users/{user_id}", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleDeleteUser)))
+	router.HandleFunc("DELETE /api/users/{user_id}/auth-provider/{provider_id}", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleDeleteUserAuthProvider)))
 	router.HandleFunc("GET /api/users/{user_id}/quota", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleGetUserQuota)))
 	router.HandleFunc("GET /api/users/{user_id}/permissions", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleGetUserPermissions)))
 	router.HandleFunc("GET /api/users/{user_id}/has-permission", middleware.ApiAuth(middleware.ApiPermissionManageUsersOrSelf(HandleGetUserHasPermission)))
@@ -95,6 +103,16 @@ func ApiRoutes(router *http.ServeMux) {
 	router.HandleFunc("POST /api/spaces/{space_id}/files/edit", middleware.ApiAuth(middleware.ApiPermissionCopyFiles(HandleEditFile)))
 	router.HandleFunc("POST /api/spaces/{space_id}/files/delete", middleware.ApiAuth(middleware.ApiPermissionCopyFiles(HandleDeleteSpaceFile)))
 	router.HandleFunc("POST /api/spaces/{space_id}/run-command", middleware.ApiAuth(middleware.ApiPermissionRunCommands(HandleRunCommand)))
+	router.HandleFunc("POST /api/spaces/{space_id}/ssh-cert", middleware.ApiAuth(HandleSignSSHCert))
+	router.HandleFunc("GET /api/spaces/{space_id}/logs", middleware.ApiAuth(middleware.ApiPermissionUseLogs(HandleGetSpaceLogs)))
+	router.HandleFunc("GET /api/spaces/{space_id}/recordings", middleware.ApiAuth(middleware.ApiPermissionViewAuditLogs(HandleGetTerminalRecordings)))
+	router.HandleFunc("GET /api/spaces/{space_id}/recordings/{recording_id}", middleware.ApiAuth(middleware.ApiPermissionViewAuditLogs(HandleGetTerminalRecording)))
+	router.HandleFunc("GET /api/spaces/{space_id}/terminal-sessions", middleware.ApiAuth(HandleGetTerminalSessions))
+	router.HandleFunc("DELETE /api/spaces/{space_id}/terminal-sessions/{session}", middleware.ApiAuth(HandleCloseTerminalSession))
+	router.HandleFunc("DELETE /api/spaces/{space_id}/terminal-sessions/{session}/attachments/{attachment_id}", middleware.ApiAuth(HandleDetachTerminalSession))
+
+	// SSH certificate authority
+	router.HandleFunc("GET /api/ssh/ca", middleware.ApiAuth(HandleGetSSHCA))
 
 	// Pools
 	router.HandleFunc("GET /api/pools", middleware.ApiAuth(HandleGetPools))
@@ -199,11 +217,17 @@ func ApiRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /api/tunnels", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleGetTunnels)))
 	router.HandleFunc("GET /api/tunnels/server-info", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleGetTunnelServerInfo)))
 	router.HandleFunc("DELETE /api/tunnels/{tunnel_name}", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleDeleteTunnel)))
+	router.HandleFunc("GET /api/tunnels/{tunnel_name}/requests", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleGetTunnelRequests)))
+	router.HandleFunc("DELETE /api/tunnels/{tunnel_name}/requests", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleClearTunnelRequests)))
+	router.HandleFunc("POST /api/tunnels/{tunnel_name}/requests/{request_id}/replay", middleware.ApiAuth(middleware.ApiPermissionUseTunnels(HandleReplayTunnelRequest)))
 
 	// Audit Logs
 	router.HandleFunc("GET /api/audit-logs", middleware.ApiAuth(middleware.ApiPermissionViewAuditLogs(HandleGetAuditLogs)))
 	router.HandleFunc("GET /api/audit-logs/export", middleware.ApiAuth(middleware.ApiPermissionDownloadAuditLogs(HandleExportAuditLogs)))
 
+	// AI Usage
+	router.HandleFunc("GET /api/llm-usage", middleware.ApiAuth(middleware.ApiPermissionViewAIUsage(HandleGetLLMUsage)))
+
 	// Cluster Information
 	router.HandleFunc("GET /api/cluster-info", middleware.ApiAuth(middleware.ApiPermissionViewClusterInfo(HandleGetClusterInfo)))
 	router.HandleFunc("GET /api/cluster/node", HandleGetClusterNode)
@@ -215,6 +239,13 @@ func ApiRoutes(router *http.ServeMux) {
 	router.HandleFunc("POST /api/auth", HandleAuthorization)
 	router.HandleFunc("POST /api/auth/web", HandleAuthorization)
 	router.HandleFunc("GET /api/auth/using-totp", HandleUsingTotp)
+	router.HandleFunc("GET /api/auth/providers", HandleGetAuthProviders)
+	router.HandleFunc("POST /api/auth/providers/{provider_id}/login", HandleStartProviderLogin)
+
+	// SCIM provisioning
+	if config.GetServerConfig().SCIM.Enabled {
+		scim.Routes(router)
+	}
 
 	// OAuth2 routes
 	router.HandleFunc("GET /authorize", middleware.WebAuth(oauth2.HandleAuthorize))
@@ -12,6 +12,7 @@ import (
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 	"github.com/paularlott/knot/internal/util"
@@ -249,6 +250,7 @@ func HandleCreateSkill(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipSkill(skill)
 	sse.PublishSkillsChanged(skill.Id)
+	internal_mcp.NotifyPromptsChanged()
 
 	audit.LogWithRequest(r,
 		user.Username,
@@ -359,6 +361,7 @@ func HandleUpdateSkill(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipSkill(skill)
 	sse.PublishSkillsChanged(skill.Id)
+	internal_mcp.NotifyPromptsChanged()
 
 	audit.LogWithRequest(r,
 		user.Username,
@@ -436,6 +439,7 @@ func HandleDeleteSkill(w http.ResponseWriter, r *http.Request) {
 
 	service.GetTransport().GossipSkill(skill)
 	sse.PublishSkillsDeleted(skill.Id)
+	internal_mcp.NotifyPromptsChanged()
 
 	audit.LogWithRequest(r,
 		user.Username,
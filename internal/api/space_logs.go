@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/agentapi/agent_server"
+	"github.com/paularlott/knot/internal/api/api_utils"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/rest"
+)
+
+const (
+	spaceLogDefaultLimit = 500
+	spaceLogMaxLimit     = 5000
+)
+
+func HandleGetSpaceLogs(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*model.User)
+
+	space, err := api_utils.GetAccessibleSpace(r.PathValue("space_id"), user)
+	if err != nil {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter, err := model.SpaceLogFilterFromQuery(r.URL.Query())
+	if err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = spaceLogDefaultLimit
+	}
+	limit = min(limit, spaceLogMaxLimit)
+
+	entries, err := agent_server.QuerySpaceLogs(space.Id, filter, limit)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := apiclient.SpaceLogList{
+		Logs: make([]apiclient.SpaceLogEntry, len(entries)),
+	}
+	for i, entry := range entries {
+		response.Logs[i] = apiclient.SpaceLogEntry{
+			Id:        entry.Id,
+			Level:     entry.Level,
+			Source:    entry.Source,
+			Message:   entry.Message,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+
+	// A full page may have older entries behind it
+	if len(entries) == limit {
+		response.Next = entries[len(entries)-1].Id
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, response)
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util/rest"
 	"github.com/paularlott/knot/internal/util/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RunCommandRequest struct {
@@ -75,12 +78,18 @@ func HandleRunCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx, span := tracing.StartKind(r.Context(), "agent.run_command", trace.SpanKindClient,
+		attribute.String("knot.space_id", space.Id),
+	)
+	defer span.End()
+
 	// Send run command message
 	runCmd := &msg.RunCommandMessage{
-		Command: req.Command,
-		Args:    req.Args,
-		Timeout: req.Timeout,
-		Workdir: req.Workdir,
+		Command:      req.Command,
+		Args:         req.Args,
+		Timeout:      req.Timeout,
+		Workdir:      req.Workdir,
+		TraceContext: tracing.Carrier(ctx),
 	}
 
 	responseChannel, err := session.SendRunCommand(runCmd)
@@ -35,6 +35,7 @@ func HandleGetSpaceUsageCurrent(w http.ResponseWriter, r *http.Request) {
 				MemoryLimitBytes: state.MemoryLimitBytes,
 				DiskUsedBytes:    state.DiskUsedBytes,
 				DiskLimitBytes:   state.DiskLimitBytes,
+				CustomMetrics:    state.CustomMetrics,
 			}
 			// Only present the reading as live if a real state report has
 			// arrived within the liveness window. A wedged state-report loop
@@ -176,6 +177,7 @@ func pointFromSpaceUsageSample(sample *model.SpaceUsageSample) apiclient.SpaceUs
 			MemoryLimitBytes: sample.MemoryLimitBytes,
 			DiskUsedBytes:    sample.DiskUsedBytes,
 			DiskLimitBytes:   sample.DiskLimitBytes,
+			CustomMetrics:    sample.CustomMetrics,
 		},
 	}
 }
@@ -199,4 +201,12 @@ func mergeSpaceResourceUsage(target *apiclient.SpaceResourceUsage, sample *model
 	if sample.DiskLimitBytes > target.DiskLimitBytes {
 		target.DiskLimitBytes = sample.DiskLimitBytes
 	}
+	for name, value := range sample.CustomMetrics {
+		if target.CustomMetrics == nil {
+			target.CustomMetrics = make(map[string]float64)
+		}
+		if existing, ok := target.CustomMetrics[name]; !ok || value > existing {
+			target.CustomMetrics[name] = value
+		}
+	}
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -16,14 +17,18 @@ import (
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/health"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
+	"github.com/paularlott/knot/internal/middleware"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/spaceutil"
 	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util/audit"
 	"github.com/paularlott/knot/internal/util/rest"
 	"github.com/paularlott/knot/internal/util/validate"
 
 	"github.com/paularlott/knot/internal/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func assignedNodeOffline(nodeId string) bool {
@@ -69,6 +74,10 @@ func HandleGetSpaces(w http.ResponseWriter, r *http.Request) {
 	cfg := config.GetServerConfig()
 	db := database.GetInstance()
 	for _, space := range spaces {
+		// Tokens restricted to named spaces only list those spaces
+		if !middleware.TokenAllowsSpace(r, space) {
+			continue
+		}
 
 		var templateName string
 		var templatePlatform string
@@ -194,6 +203,7 @@ func HandleGetSpaces(w http.ResponseWriter, r *http.Request) {
 				MemoryLimitBytes: state.MemoryLimitBytes,
 				DiskUsedBytes:    state.DiskUsedBytes,
 				DiskLimitBytes:   state.DiskLimitBytes,
+				CustomMetrics:    state.CustomMetrics,
 			}
 
 			// If template is manual then force IsDeployed to true as agent is live
@@ -460,16 +470,22 @@ func HandleSpaceStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx, span := tracing.Start(r.Context(), "space.start",
+		attribute.String("knot.space_id", spaceId),
+		attribute.String("knot.space_name", space.Name),
+	)
+	defer span.End()
+
 	// Acquire lock after forwarding check
 	transport := service.GetTransport()
 	if transport != nil {
-		unlockToken := transport.LockResource(spaceId)
+		unlockToken := transport.LockResource(ctx, spaceId)
 		if unlockToken == "" {
 			logger.Error("failed to lock space")
 			rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: "Failed to lock space"})
 			return
 		}
-		defer transport.UnlockResource(spaceId, unlockToken)
+		defer transport.UnlockResource(ctx, spaceId, unlockToken)
 	}
 	if err != nil {
 		logger.WithError(err).Error("get space failed")
@@ -546,7 +562,8 @@ func HandleSpaceStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := service.GetContainerService().StartSpace(space, template, user); err != nil {
+	if err := service.GetContainerService().StartSpace(ctx, space, template, user); err != nil {
+		tracing.RecordError(span, err)
 		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -1395,6 +1412,7 @@ func HandleSpaceAddShare(w http.ResponseWriter, r *http.Request) {
 
 	// Publish SSE event for both owner and shared user
 	sse.PublishSpaceChangedWithShares(space.Id, space.UserId, space.SharedUserIds(), nil)
+	internal_mcp.NotifyResourcesChanged()
 
 	audit.LogWithRequest(r,
 		user.Username,
@@ -1502,6 +1520,7 @@ func HandleSpaceRemoveShare(w http.ResponseWriter, r *http.Request) {
 	service.GetUserService().UpdateSpaceSSHKeys(space, user)
 
 	sse.PublishSpaceChangedWithShares(space.Id, space.UserId, space.SharedUserIds(), previousSharedUserIds)
+	internal_mcp.NotifyResourcesChanged()
 
 	audit.LogWithRequest(r,
 		user.Username,
@@ -1575,7 +1594,7 @@ func startStackTier(tier []*model.Space, user *model.User) error {
 				results <- result{space.Name, fmt.Errorf("template not found for space %q: %w", space.Name, err)}
 				return
 			}
-			if err := service.GetContainerService().StartSpace(space, tmpl, user); err != nil {
+			if err := service.GetContainerService().StartSpace(context.Background(), space, tmpl, user); err != nil {
 				results <- result{space.Name, fmt.Errorf("failed to start space %q: %w", space.Name, err)}
 				return
 			}
@@ -68,7 +68,8 @@ func HandleGetCapabilities(w http.ResponseWriter, r *http.Request) {
 // HandleSpecParse converts a native template spec into the unified JSON the
 // wizard edits. Returns wizardable=false if the spec is too complex for the
 // wizard (multi-task Nomad job, unparseable HCL, unsupported driver, etc.);
-// the UI uses that flag to disable the wizard button.
+// the UI uses that flag to disable the wizard button. With a format set the
+// job is a devcontainer.json or compose file to import instead.
 func HandleSpecParse(w http.ResponseWriter, r *http.Request) {
 	request := apiclient.SpecParseRequest{}
 	if err := rest.DecodeRequestBody(w, r, &request); err != nil {
@@ -76,6 +77,23 @@ func HandleSpecParse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Imports produce a fresh spec, so everything they map is representable
+	if request.Format != "" {
+		spec, report, err := specwizard.Import(request.Format, request.Job, request.Service)
+		if err != nil {
+			rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		rest.WriteResponse(http.StatusOK, w, r, apiclient.SpecParseResponse{
+			Wizardable:         true,
+			Spec:               spec,
+			FullyRepresentable: true,
+			Import:             report,
+		})
+		return
+	}
+
 	spec, wizardable, reason := specwizard.Parse(request.Platform, request.Job, request.Volumes, defaultHCLParser())
 
 	resp := apiclient.SpecParseResponse{
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/sshca"
+	"github.com/paularlott/knot/internal/util/audit"
+	"github.com/paularlott/knot/internal/util/rest"
+	"github.com/paularlott/knot/internal/util/validate"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func HandleGetSSHCA(w http.ResponseWriter, r *http.Request) {
+	rest.WriteResponse(http.StatusOK, w, r, &apiclient.SSHCAResponse{
+		Enabled:   sshca.Enabled(),
+		PublicKey: sshca.PublicKey(),
+		MaxTTL:    int(sshca.MaxTTL() / time.Minute),
+	})
+}
+
+// HandleSignSSHCert issues a user certificate for logging in to a space the
+// user owns or has been shared.
+func HandleSignSSHCert(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space_id")
+	user := r.Context().Value("user").(*model.User)
+	db := database.GetInstance()
+
+	if !user.HasPermission(model.PermissionUseSSH) {
+		rest.WriteResponse(http.StatusForbidden, w, r, ErrorResponse{Error: "No permission to use SSH"})
+		return
+	}
+	if !sshca.Enabled() {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: sshca.ErrDisabled.Error()})
+		return
+	}
+
+	var request apiclient.SSHCertRequest
+	if err := rest.DecodeRequestBody(w, r, &request); err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if request.TTL < 0 {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid TTL"})
+		return
+	}
+
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(strings.TrimSpace(request.PublicKey)))
+	if err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid public key"})
+		return
+	}
+
+	// Support lookup by both ID and name
+	var space *model.Space
+	if validate.UUID(spaceId) {
+		space, err = db.GetSpace(spaceId)
+	} else {
+		space, err = db.GetSpaceByName(user.Id, spaceId)
+	}
+	if err != nil || space == nil || space.IsDeleted {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: "Space not found"})
+		return
+	}
+
+	if space.UserId != user.Id && !space.IsSharedWith(user.Id) {
+		rest.WriteResponse(http.StatusForbidden, w, r, ErrorResponse{Error: "No permission to access this space"})
+		return
+	}
+
+	cert, err := sshca.SignUserCert(publicKey, user, space, time.Duration(request.TTL)*time.Minute)
+	if err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	validBefore := time.Unix(int64(cert.ValidBefore), 0).UTC()
+	audit.LogWithRequest(r,
+		user.Username,
+		model.AuditActorTypeUser,
+		model.AuditEventSSHCertIssue,
+		fmt.Sprintf("Issued SSH certificate for space %s", space.Name),
+		&map[string]interface{}{
+			"agent":           r.UserAgent(),
+			"IP":              r.RemoteAddr,
+			"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+			"space_id":        space.Id,
+			"space_name":      space.Name,
+			"key_id":          cert.KeyId,
+			"serial":          cert.Serial,
+			"fingerprint":     gossh.FingerprintSHA256(publicKey),
+			"valid_before":    validBefore.Format(time.RFC3339),
+		},
+	)
+
+	rest.WriteResponse(http.StatusOK, w, r, &apiclient.SSHCertResponse{
+		Certificate: strings.TrimSpace(string(gossh.MarshalAuthorizedKey(cert))),
+		Principal:   space.Id,
+		ValidBefore: validBefore,
+	})
+}
@@ -145,6 +145,9 @@ func buildTemplateExportDetails(template *model.Template, db database.DbDriver)
 		WithCodeServer:             template.WithCodeServer,
 		WithSSH:                    template.WithSSH,
 		WithRunCommand:             template.WithRunCommand,
+		DisableSSHLocalForward:     template.DisableSSHLocalForward,
+		WithSSHReverseForward:      template.WithSSHReverseForward,
+		WithTerminalRecording:      template.WithTerminalRecording,
 		AllowNodeMigration:         template.AllowNodeMigration,
 		HealthCheckType:            template.HealthCheckType,
 		HealthCheckConfig:          template.HealthCheckConfig,
@@ -155,6 +158,7 @@ func buildTemplateExportDetails(template *model.Template, db database.DbDriver)
 		HealthCheckAutoRestart:     template.HealthCheckAutoRestart,
 		DisableUserActivity:        template.DisableUserActivity,
 		Ports:                      template.Ports,
+		IdlePolicy:                 template.IdlePolicy,
 	}
 	if len(template.CustomFields) > 0 {
 		details.CustomFields = make([]apiclient.CustomFieldDef, len(template.CustomFields))
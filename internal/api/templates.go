@@ -86,6 +86,7 @@ func HandleGetTemplates(w http.ResponseWriter, r *http.Request) {
 		templateData.MaxUptimeUnit = template.MaxUptimeUnit
 		templateData.IconURL = template.IconURL
 		templateData.Ports = template.Ports
+		templateData.IdlePolicy = template.IdlePolicy
 
 		templateData.CustomFields = make([]apiclient.CustomFieldDef, len(template.CustomFields))
 		for i, field := range template.CustomFields {
@@ -140,8 +141,9 @@ func HandleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		request.ScheduleEnabled = false
 		request.MaxUptimeUnit = "disabled"
 		request.AllowNodeMigration = false
+		request.IdlePolicy = model.TemplateIdlePolicy{}
 	}
-	if request.Platform == model.PlatformNomad {
+	if request.Platform == model.PlatformNomad || request.Platform == model.PlatformKubernetes {
 		request.AllowNodeMigration = false
 	}
 
@@ -195,7 +197,11 @@ func HandleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	template.HealthCheckMaxFailures = request.HealthCheckMaxFailures
 	template.HealthCheckAutoRestart = request.HealthCheckAutoRestart
 	template.DisableUserActivity = request.DisableUserActivity
+	template.DisableSSHLocalForward = request.DisableSSHLocalForward
+	template.WithSSHReverseForward = request.WithSSHReverseForward
+	template.WithTerminalRecording = request.WithTerminalRecording
 	template.Ports = request.Ports
+	template.IdlePolicy = request.IdlePolicy
 
 	// Convert schedule
 	template.Schedule = make([]model.TemplateScheduleDays, 7)
@@ -254,8 +260,9 @@ func HandleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 		request.ScheduleEnabled = false
 		request.MaxUptimeUnit = "disabled"
 		request.AllowNodeMigration = false
+		request.IdlePolicy = model.TemplateIdlePolicy{}
 	}
-	if request.Platform == model.PlatformNomad {
+	if request.Platform == model.PlatformNomad || request.Platform == model.PlatformKubernetes {
 		request.AllowNodeMigration = false
 	}
 
@@ -321,7 +328,11 @@ func HandleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 	template.HealthCheckMaxFailures = request.HealthCheckMaxFailures
 	template.HealthCheckAutoRestart = request.HealthCheckAutoRestart
 	template.DisableUserActivity = request.DisableUserActivity
+	template.DisableSSHLocalForward = request.DisableSSHLocalForward
+	template.WithSSHReverseForward = request.WithSSHReverseForward
+	template.WithTerminalRecording = request.WithTerminalRecording
 	template.Ports = request.Ports
+	template.IdlePolicy = request.IdlePolicy
 
 	templateService := service.GetTemplateService()
 	err = templateService.CreateTemplate(template, user)
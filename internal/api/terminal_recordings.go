@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/asciicast"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/rest"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+func terminalRecordingInfo(recording *model.TerminalRecording) apiclient.TerminalRecordingInfo {
+	return apiclient.TerminalRecordingInfo{
+		Id:        recording.Id,
+		SpaceId:   recording.SpaceId,
+		Username:  recording.Username,
+		Source:    recording.Source,
+		StartedAt: recording.StartedAt,
+		Duration:  recording.Duration,
+		Size:      recording.Size,
+		Truncated: recording.Truncated,
+		ExpiresAt: recording.ExpiresAt,
+	}
+}
+
+func HandleGetTerminalRecordings(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space_id")
+	if !validate.UUID(spaceId) {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid space ID"})
+		return
+	}
+
+	recordings, err := database.GetInstance().GetTerminalRecordingsForSpace(spaceId)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := apiclient.TerminalRecordingList{
+		Count:      len(recordings),
+		Recordings: make([]apiclient.TerminalRecordingInfo, len(recordings)),
+	}
+	for i, recording := range recordings {
+		response.Recordings[i] = terminalRecordingInfo(recording)
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, response)
+}
+
+func HandleGetTerminalRecording(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space_id")
+	recordingId := r.PathValue("recording_id")
+	if !validate.UUID(spaceId) || !validate.UUID(recordingId) {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid space or recording ID"})
+		return
+	}
+
+	recording, err := database.GetInstance().GetTerminalRecording(recordingId)
+	if err != nil || recording == nil || recording.SpaceId != spaceId {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: "Recording not found"})
+		return
+	}
+
+	cast, err := asciicast.Decompress(recording.Data)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, apiclient.TerminalRecording{
+		TerminalRecordingInfo: terminalRecordingInfo(recording),
+		Cast:                  string(cast),
+	})
+}
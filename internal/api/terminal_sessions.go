@@ -0,0 +1,163 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/agentapi/agent_server"
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/audit"
+	"github.com/paularlott/knot/internal/util/rest"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+// resolveSpaceForTerminalSessions loads the space and its agent session for
+// the shared terminal handlers. Listing is open to anyone who can use the
+// space, managing attached users needs ownerOnly and is limited to the space
+// owner and space managers. On failure the HTTP response is written and nil
+// returned.
+func resolveSpaceForTerminalSessions(w http.ResponseWriter, r *http.Request, ownerOnly bool) (*model.Space, *agent_server.Session) {
+	user := r.Context().Value("user").(*model.User)
+	spaceId := r.PathValue("space_id")
+
+	if !user.HasPermission(model.PermissionUseWebTerminal) {
+		rest.WriteResponse(http.StatusForbidden, w, r, ErrorResponse{Error: "No permission to use the web terminal"})
+		return nil, nil
+	}
+
+	db := database.GetInstance()
+	var space *model.Space
+	var err error
+	if validate.UUID(spaceId) {
+		space, err = db.GetSpace(spaceId)
+	} else {
+		space, err = db.GetSpaceByName(user.Id, spaceId)
+	}
+	if err != nil || space == nil || space.IsDeleted {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: "Space not found"})
+		return nil, nil
+	}
+
+	isManager := space.UserId == user.Id || user.HasPermission(model.PermissionManageSpaces)
+	if !isManager && (ownerOnly || !space.IsSharedWith(user.Id)) {
+		rest.WriteResponse(http.StatusForbidden, w, r, ErrorResponse{Error: "No permission to manage terminal sessions in this space"})
+		return nil, nil
+	}
+
+	session := agent_server.GetSession(space.Id)
+	if session == nil {
+		rest.WriteResponse(http.StatusServiceUnavailable, w, r, ErrorResponse{Error: "Agent session not found for space"})
+		return nil, nil
+	}
+
+	return space, session
+}
+
+func HandleGetTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	_, session := resolveSpaceForTerminalSessions(w, r, false)
+	if session == nil {
+		return
+	}
+
+	ch, err := session.SendTerminalSessionList()
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: fmt.Sprintf("Failed to send terminal session list to agent: %v", err)})
+		return
+	}
+	resp := <-ch
+	if resp == nil {
+		rest.WriteResponse(http.StatusServiceUnavailable, w, r, ErrorResponse{Error: "No response from agent"})
+		return
+	}
+
+	response := apiclient.TerminalSessionList{
+		Count:    len(resp.Sessions),
+		Sessions: make([]apiclient.TerminalSessionInfo, len(resp.Sessions)),
+	}
+	for i, s := range resp.Sessions {
+		info := apiclient.TerminalSessionInfo{
+			Name:      s.Name,
+			Shell:     s.Shell,
+			CreatedBy: s.CreatedBy,
+			CreatedAt: s.CreatedAt,
+			Attached:  make([]apiclient.TerminalSessionAttachment, len(s.Attached)),
+		}
+		for j, a := range s.Attached {
+			info.Attached[j] = apiclient.TerminalSessionAttachment{
+				Id:         a.Id,
+				UserId:     a.UserId,
+				Username:   a.Username,
+				ReadOnly:   a.ReadOnly,
+				AttachedAt: a.AttachedAt,
+			}
+		}
+		response.Sessions[i] = info
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, response)
+}
+
+func HandleDetachTerminalSession(w http.ResponseWriter, r *http.Request) {
+	sendTerminalSessionDetach(w, r, &msg.TerminalSessionDetachRequest{
+		Session:      r.PathValue("session"),
+		AttachmentId: r.PathValue("attachment_id"),
+	})
+}
+
+func HandleCloseTerminalSession(w http.ResponseWriter, r *http.Request) {
+	sendTerminalSessionDetach(w, r, &msg.TerminalSessionDetachRequest{
+		Session: r.PathValue("session"),
+	})
+}
+
+func sendTerminalSessionDetach(w http.ResponseWriter, r *http.Request, request *msg.TerminalSessionDetachRequest) {
+	if !validate.Name(request.Session) || (request.AttachmentId != "" && !validate.UUID(request.AttachmentId)) {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid session or attachment"})
+		return
+	}
+
+	space, session := resolveSpaceForTerminalSessions(w, r, true)
+	if session == nil {
+		return
+	}
+
+	ch, err := session.SendTerminalSessionDetach(request)
+	if err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: fmt.Sprintf("Failed to send terminal session detach to agent: %v", err)})
+		return
+	}
+	resp := <-ch
+	if resp == nil {
+		rest.WriteResponse(http.StatusServiceUnavailable, w, r, ErrorResponse{Error: "No response from agent"})
+		return
+	}
+	if !resp.Success {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: resp.Error})
+		return
+	}
+
+	user := r.Context().Value("user").(*model.User)
+	event := model.AuditEventTerminalSessionDetach
+	details := fmt.Sprintf("Detached from terminal session %s in space %s", request.Session, space.Name)
+	if request.AttachmentId == "" {
+		event = model.AuditEventTerminalSessionClose
+		details = fmt.Sprintf("Closed terminal session %s in space %s", request.Session, space.Name)
+	}
+	audit.LogWithRequest(r,
+		user.Username,
+		model.AuditActorTypeUser,
+		event,
+		details,
+		&map[string]interface{}{
+			"space_id":      space.Id,
+			"space_name":    space.Name,
+			"session":       request.Session,
+			"attachment_id": request.AttachmentId,
+		},
+	)
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"net/netip"
+	"slices"
 
 	"github.com/paularlott/gossip/hlc"
 	"github.com/paularlott/knot/apiclient"
@@ -33,6 +35,9 @@ func HandleGetTokens(w http.ResponseWriter, r *http.Request) {
 			Name:         token.Name,
 			ExpiresAfter: token.ExpiresAfter,
 			Scopes:       token.Scopes,
+			Spaces:       token.Spaces,
+			Stacks:       token.Stacks,
+			SourceCIDR:   token.SourceCIDR,
 		})
 	}
 
@@ -85,22 +90,16 @@ func HandleCreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate scopes (if provided). nil/empty = unrestricted (backward
-	// compatible). Non-empty = each entry must be a known scope.
-	scopes := request.Scopes
-	if len(scopes) > 0 {
-		for _, s := range scopes {
-			if !model.IsKnownTokenScope(s) {
-				rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{
-					Error: fmt.Sprintf("Unknown token scope: %s", s),
-				})
-				return
-			}
-		}
+	token := model.NewToken(request.Name, user.Id)
+	token.Scopes = request.Scopes
+	token.Spaces = request.Spaces
+	token.Stacks = request.Stacks
+	token.SourceCIDR = request.SourceCIDR
+	if err := validateTokenRestrictions(r, token); err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	token := model.NewToken(request.Name, user.Id)
-	token.Scopes = scopes
 	err = database.GetInstance().SaveToken(token)
 	if err != nil {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
@@ -149,19 +148,24 @@ func HandleUpdateToken(w http.ResponseWriter, r *http.Request) {
 		token.Name = *request.Name
 	}
 
+	// nil (omitted) preserves the existing value, an empty value (explicitly
+	// provided) clears the restriction
 	if request.Scopes != nil {
-		// Validate each scope. An empty slice (explicitly provided) clears
-		// scopes → unrestricted. nil (omitted) preserves existing scopes.
-		for _, s := range *request.Scopes {
-			if !model.IsKnownTokenScope(s) {
-				rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{
-					Error: fmt.Sprintf("Unknown token scope: %s", s),
-				})
-				return
-			}
-		}
 		token.Scopes = *request.Scopes
 	}
+	if request.Spaces != nil {
+		token.Spaces = *request.Spaces
+	}
+	if request.Stacks != nil {
+		token.Stacks = *request.Stacks
+	}
+	if request.SourceCIDR != nil {
+		token.SourceCIDR = *request.SourceCIDR
+	}
+	if err := validateTokenRestrictions(r, token); err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
 
 	token.UpdatedAt = hlc.Now()
 	if err := db.SaveToken(token); err != nil {
@@ -174,3 +178,50 @@ func HandleUpdateToken(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// validateTokenRestrictions checks the token's scopes, space restriction and
+// source CIDR, normalising the CIDR. nil/empty scopes are unrestricted
+// (backward compatible). A request authenticated with a restricted token can
+// only give tokens the same or narrower access.
+func validateTokenRestrictions(r *http.Request, token *model.Token) error {
+	for _, s := range token.Scopes {
+		if !model.IsKnownTokenScope(s) {
+			return fmt.Errorf("Unknown token scope: %s", s)
+		}
+	}
+	for _, name := range token.Spaces {
+		if !validate.Name(name) {
+			return fmt.Errorf("Invalid space name: %s", name)
+		}
+	}
+	for _, name := range token.Stacks {
+		if !validate.Name(name) {
+			return fmt.Errorf("Invalid stack name: %s", name)
+		}
+	}
+	if token.HasSpaceRestriction() {
+		for _, s := range []string{model.ScopeMCP, model.ScopeMethods, model.ScopeAssistant} {
+			if slices.Contains(token.Scopes, s) {
+				return fmt.Errorf("Scope %s cannot be used by a token restricted to named spaces", s)
+			}
+		}
+	}
+
+	if token.SourceCIDR != "" {
+		prefix, err := netip.ParsePrefix(token.SourceCIDR)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(token.SourceCIDR)
+			if addrErr != nil {
+				return fmt.Errorf("Invalid source CIDR: %s", token.SourceCIDR)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		token.SourceCIDR = prefix.Masked().String()
+	}
+
+	if caller, _ := r.Context().Value("access_token").(*model.Token); caller != nil && !caller.Covers(token) {
+		return fmt.Errorf("Token cannot be given more access than the token used to create it")
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"sort"
+	"strconv"
 
 	"github.com/paularlott/knot/apiclient"
 	"github.com/paularlott/knot/internal/config"
@@ -18,13 +20,18 @@ func HandleGetTunnels(w http.ResponseWriter, r *http.Request) {
 	tunnels := tunnel_server.GetTunnelsForUser(user.Id)
 	cfg := config.GetServerConfig()
 
-	sort.Strings(tunnels)
+	names := make([]string, 0, len(tunnels))
+	for name := range tunnels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	tunnelList := make([]apiclient.TunnelInfo, len(tunnels))
-	for i, tunnel := range tunnels {
+	tunnelList := make([]apiclient.TunnelInfo, len(names))
+	for i, tunnel := range names {
 		tunnelList[i] = apiclient.TunnelInfo{
 			Name:    user.Username + "--" + tunnel,
 			Address: "https://" + user.Username + "--" + tunnel + cfg.TunnelDomain,
+			Access:  tunnels[tunnel],
 		}
 	}
 
@@ -58,3 +65,60 @@ func HandleDeleteTunnel(w http.ResponseWriter, r *http.Request) {
 		rest.WriteResponse(http.StatusOK, w, r, ErrorResponse{Error: "tunnel deleted"})
 	}
 }
+
+func HandleGetTunnelRequests(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*model.User)
+
+	requests, err := tunnel_server.GetTunnelRequests(user.Id, r.PathValue("tunnel_name"))
+	if err != nil {
+		rest.WriteResponse(tunnelInspectStatus(err), w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, requests)
+}
+
+func HandleClearTunnelRequests(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*model.User)
+
+	if err := tunnel_server.ClearTunnelRequests(user.Id, r.PathValue("tunnel_name")); err != nil {
+		rest.WriteResponse(tunnelInspectStatus(err), w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func HandleReplayTunnelRequest(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*model.User)
+
+	requestId, err := strconv.ParseInt(r.PathValue("request_id"), 10, 64)
+	if err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "invalid request_id"})
+		return
+	}
+
+	result, err := tunnel_server.ReplayTunnelRequest(r.Context(), user.Id, r.PathValue("tunnel_name"), requestId)
+	if err != nil {
+		rest.WriteResponse(tunnelInspectStatus(err), w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rest.WriteResponse(http.StatusOK, w, r, result)
+}
+
+// tunnelInspectStatus maps the errors from the tunnel inspect functions to a
+// response status, captured requests are held per server so a tunnel that
+// isn't connected here is a misdirected request rather than a missing one.
+func tunnelInspectStatus(err error) int {
+	switch {
+	case errors.Is(err, tunnel_server.ErrTunnelNotOnServer):
+		return http.StatusMisdirectedRequest
+	case errors.Is(err, tunnel_server.ErrTunnelNotFound), errors.Is(err, tunnel_server.ErrRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tunnel_server.ErrBodyTruncated):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
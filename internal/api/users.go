@@ -236,6 +236,11 @@ func HandleGetUser(w http.ResponseWriter, r *http.Request) {
 		UsedComputeUnits:           usage.ComputeUnits,
 		UsedStorageUnits:           usage.StorageUnits,
 		UsedTunnels:                tunnel_server.CountUserTunnels(user.Id),
+		ExternalAuthProviders:      make(map[string]apiclient.UserAuthProvider),
+		HasPassword:                user.Password != "",
+	}
+	for providerId, provider := range user.ExternalAuthProviders {
+		userData.ExternalAuthProviders[providerId] = apiclient.UserAuthProvider{Username: provider.Username}
 	}
 
 	if current {
@@ -732,6 +737,64 @@ func HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// HandleDeleteUserAuthProvider unlinks an external login provider from a user.
+// Users can't unlink their own provider until they have a password to sign in
+// with instead.
+func HandleDeleteUserAuthProvider(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+	activeUser := r.Context().Value("user").(*model.User)
+	userId := r.PathValue("user_id")
+	providerId := r.PathValue("provider_id")
+
+	var user *model.User
+	var err error
+	if validate.UUID(userId) {
+		user, err = db.GetUser(userId)
+	} else {
+		user, err = db.GetUserByUsername(userId)
+	}
+	if err != nil {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: fmt.Sprintf("user %s not found", userId)})
+		return
+	}
+
+	if _, ok := user.ExternalAuthProviders[providerId]; !ok {
+		rest.WriteResponse(http.StatusNotFound, w, r, ErrorResponse{Error: fmt.Sprintf("provider %s is not linked", providerId)})
+		return
+	}
+	if user.Id == activeUser.Id && user.Password == "" {
+		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Set a password before unlinking"})
+		return
+	}
+
+	delete(user.ExternalAuthProviders, providerId)
+	user.UpdatedAt = hlc.Now()
+	if err := db.SaveUser(user, []string{"ExternalAuthProviders", "UpdatedAt"}); err != nil {
+		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: err.Error()})
+		return
+	}
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	audit.LogWithRequest(r,
+		activeUser.Username,
+		model.AuditActorTypeUser,
+		model.AuditEventUserUpdate,
+		fmt.Sprintf("Unlinked %s from user %s (%s)", providerId, user.Username, user.Email),
+		&map[string]interface{}{
+			"agent":           r.UserAgent(),
+			"IP":              r.RemoteAddr,
+			"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+			"user_id":         user.Id,
+			"user_name":       user.Username,
+			"user_email":      user.Email,
+			"provider":        providerId,
+		},
+	)
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func HandleGetUserQuota(w http.ResponseWriter, r *http.Request) {
 	db := database.GetInstance()
 	userId := r.PathValue("user_id")
@@ -103,7 +103,7 @@ func HandleUpdateVolume(w http.ResponseWriter, r *http.Request) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Volume definition must be less than 10MB"})
 		return
 	}
-	if !validate.OneOf(request.Platform, []string{model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer}) {
+	if !validate.OneOf(request.Platform, []string{model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer, model.PlatformKubernetes}) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid platform name given"})
 		return
 	}
@@ -165,7 +165,7 @@ func HandleCreateVolume(w http.ResponseWriter, r *http.Request) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Volume definition must be less than 10MB"})
 		return
 	}
-	if !validate.OneOf(request.Platform, []string{model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer}) {
+	if !validate.OneOf(request.Platform, []string{model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer, model.PlatformKubernetes}) {
 		rest.WriteResponse(http.StatusBadRequest, w, r, ErrorResponse{Error: "Invalid platform name given"})
 		return
 	}
@@ -335,7 +335,7 @@ func HandleGetVolume(w http.ResponseWriter, r *http.Request) {
 
 func HandleGetVolumeNodes(w http.ResponseWriter, r *http.Request) {
 	platform := r.URL.Query().Get("platform")
-	if platform == "" || platform == model.PlatformNomad {
+	if platform == "" || platform == model.PlatformNomad || platform == model.PlatformKubernetes {
 		rest.WriteResponse(http.StatusOK, w, r, []AvailableNode{})
 		return
 	}
@@ -428,7 +428,7 @@ func HandleVolumeStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// For local container platforms, resolve the node to run on
-	if volume.Platform != model.PlatformNomad {
+	if volume.Platform != model.PlatformNomad && volume.Platform != model.PlatformKubernetes {
 		if volume.NodeId == "" {
 			fakeTemplate := &model.Template{Platform: volume.Platform}
 			nodeId, err := service.SelectNodeForSpace(fakeTemplate, "")
@@ -450,12 +450,12 @@ func HandleVolumeStart(w http.ResponseWriter, r *http.Request) {
 	resolvedNodeId := volume.NodeId
 
 	transport := service.GetTransport()
-	unlockToken := transport.LockResource(volume.Id)
+	unlockToken := transport.LockResource(r.Context(), volume.Id)
 	if unlockToken == "" {
 		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: "Failed to lock volume"})
 		return
 	}
-	defer transport.UnlockResource(volume.Id, unlockToken)
+	defer transport.UnlockResource(r.Context(), volume.Id, unlockToken)
 
 	volume, err = db.GetVolume(volume.Id)
 	if err != nil {
@@ -533,12 +533,12 @@ func HandleVolumeStop(w http.ResponseWriter, r *http.Request) {
 
 	// Use the resolved volume ID for locking
 	transport := service.GetTransport()
-	unlockToken := transport.LockResource(volume.Id)
+	unlockToken := transport.LockResource(r.Context(), volume.Id)
 	if unlockToken == "" {
 		rest.WriteResponse(http.StatusInternalServerError, w, r, ErrorResponse{Error: "Failed to lock volume"})
 		return
 	}
-	defer transport.UnlockResource(volume.Id, unlockToken)
+	defer transport.UnlockResource(r.Context(), volume.Id, unlockToken)
 
 	// Reload to ensure we have the latest state after locking
 	volume, err = db.GetVolume(volume.Id)
@@ -0,0 +1,201 @@
+// Package asciicast records terminal sessions in the asciicast v2 format, a
+// JSON header line followed by one JSON array per event.
+package asciicast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	EventOutput = "o"
+	EventResize = "r"
+)
+
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recording is a finished session, Data holds the gzip compressed cast.
+type Recording struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Size      int // uncompressed size of the cast
+	Truncated bool
+	Data      []byte
+}
+
+// Recorder collects the output of a terminal session, once the cast reaches
+// maxSize further events are dropped and the recording marked truncated.
+type Recorder struct {
+	mutex     sync.Mutex
+	started   time.Time
+	buffer    bytes.Buffer
+	maxSize   int
+	truncated bool
+	pending   []byte // incomplete UTF-8 sequence held for the next output
+}
+
+// NewRecorder starts a recording of a width by height terminal.
+func NewRecorder(width, height int, title string, maxSize int) *Recorder {
+	recorder := &Recorder{
+		started: time.Now(),
+		maxSize: maxSize,
+	}
+
+	header, _ := json.Marshal(&Header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: recorder.started.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	recorder.buffer.Write(header)
+	recorder.buffer.WriteByte('\n')
+
+	return recorder
+}
+
+// Output records data written to the terminal.
+func (recorder *Recorder) Output(data []byte) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	// Events are JSON strings so hold back a rune split across reads
+	data = append(recorder.pending, data...)
+	recorder.pending = nil
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				recorder.pending = bytes.Clone(data[len(data)-i:])
+				data = data[:len(data)-i]
+			}
+			break
+		}
+	}
+
+	if len(data) > 0 {
+		recorder.event(EventOutput, string(data))
+	}
+}
+
+// Write records p as output, letting the recorder sit behind an io.MultiWriter.
+func (recorder *Recorder) Write(p []byte) (int, error) {
+	recorder.Output(p)
+	return len(p), nil
+}
+
+// Resize records the terminal changing size.
+func (recorder *Recorder) Resize(width, height int) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.event(EventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+func (recorder *Recorder) event(code, data string) {
+	if recorder.truncated {
+		return
+	}
+
+	line, err := json.Marshal([]interface{}{time.Since(recorder.started).Seconds(), code, data})
+	if err != nil {
+		return
+	}
+	if recorder.maxSize > 0 && recorder.buffer.Len()+len(line)+1 > recorder.maxSize {
+		recorder.truncated = true
+		return
+	}
+	recorder.buffer.Write(line)
+	recorder.buffer.WriteByte('\n')
+}
+
+// Finish ends the recording and compresses the cast.
+func (recorder *Recorder) Finish() (*Recording, error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	data, err := compress(recorder.buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recording{
+		StartedAt: recorder.started,
+		Duration:  time.Since(recorder.started),
+		Size:      recorder.buffer.Len(),
+		Truncated: recorder.truncated,
+		Data:      data,
+	}, nil
+}
+
+// Limit checks compressed recording data against maxSize, returning the data
+// with events past maxSize dropped, the uncompressed size and whether events
+// were dropped. A maxSize of 0 or less doesn't limit the recording.
+func Limit(data []byte, maxSize int) ([]byte, int, bool, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer reader.Close()
+
+	var limited io.Reader = reader
+	if maxSize > 0 {
+		limited = io.LimitReader(reader, int64(maxSize)+1)
+	}
+	cast, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if maxSize <= 0 || len(cast) <= maxSize {
+		return data, len(cast), false, nil
+	}
+
+	// Keep the whole events that fit, the header must always be kept
+	end := bytes.LastIndexByte(cast[:maxSize], '\n')
+	if end < 0 {
+		return nil, 0, false, fmt.Errorf("recording header exceeds %d bytes", maxSize)
+	}
+	cast = cast[:end+1]
+
+	data, err = compress(cast)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return data, len(cast), true, nil
+}
+
+func compress(cast []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(cast); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// Decompress returns the cast held in the compressed recording data.
+func Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,123 @@
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func decode(t *testing.T, recording *Recording) (Header, [][]interface{}) {
+	t.Helper()
+
+	data, err := Decompress(recording.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != recording.Size {
+		t.Errorf("Size %d, expected %d", recording.Size, len(data))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan()
+	var header Header
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+
+	var events [][]interface{}
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	return header, events
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder(80, 24, "dev", 0)
+	recorder.Output([]byte("hello "))
+	recorder.Resize(120, 40)
+
+	// A multi-byte rune split across two reads
+	euro := []byte("€")
+	recorder.Output(euro[:1])
+	recorder.Output(euro[1:])
+
+	recording, err := recorder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, events := decode(t, recording)
+
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Title != "dev" {
+		t.Errorf("Unexpected header %+v", header)
+	}
+
+	expected := [][2]string{{"o", "hello "}, {"r", "120x40"}, {"o", "€"}}
+	if len(events) != len(expected) {
+		t.Fatalf("Unexpected events %v", events)
+	}
+	for i, event := range events {
+		if event[1] != expected[i][0] || event[2] != expected[i][1] {
+			t.Errorf("Event %d is %v, expected %v", i, event, expected[i])
+		}
+	}
+	if recording.Truncated {
+		t.Error("Recording should not be truncated")
+	}
+}
+
+func TestRecorderTruncated(t *testing.T) {
+	recorder := NewRecorder(80, 24, "", 200)
+	for i := 0; i < 10; i++ {
+		recorder.Output([]byte("0123456789"))
+	}
+
+	recording, err := recorder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recording.Truncated || recording.Size > 200 {
+		t.Errorf("Recording should be truncated at the maximum size, got %d bytes", recording.Size)
+	}
+	if _, events := decode(t, recording); len(events) == 0 {
+		t.Error("Events before the limit should be kept")
+	}
+}
+
+func TestLimit(t *testing.T) {
+	recorder := NewRecorder(80, 24, "", 0)
+	for i := 0; i < 10; i++ {
+		recorder.Output([]byte("0123456789"))
+	}
+	recording, err := recorder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, size, truncated, err := Limit(recording.Data, 0)
+	if err != nil || truncated || size != recording.Size || !bytes.Equal(data, recording.Data) {
+		t.Errorf("Unlimited recording should be unchanged, got %d bytes truncated %v: %v", size, truncated, err)
+	}
+
+	data, size, truncated, err = Limit(recording.Data, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated || size > 200 {
+		t.Errorf("Recording should be truncated at the maximum size, got %d bytes", size)
+	}
+	if _, events := decode(t, &Recording{Data: data, Size: size}); len(events) == 0 {
+		t.Error("Events before the limit should be kept")
+	}
+
+	if _, _, _, err := Limit(recording.Data, 10); err == nil {
+		t.Error("Expected an error when the header exceeds the maximum size")
+	}
+	if _, _, _, err := Limit([]byte("not gzip"), 200); err == nil {
+		t.Error("Expected an error for data that isn't compressed")
+	}
+}
@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+// ModelNotAllowedError is returned when none of the groups of the user allow
+// the requested model.
+type ModelNotAllowedError struct {
+	Model string
+}
+
+func (e *ModelNotAllowedError) Error() string {
+	return fmt.Sprintf("the AI model %s is not allowed", e.Model)
+}
+
+// CheckModelAllowed returns a ModelNotAllowedError if the user may not use the
+// model. Users outside of any group with a model list may use every model,
+// otherwise the model must be listed by one of their groups.
+func CheckModelAllowed(user *model.User, modelName string) error {
+	if user == nil || len(user.Groups) == 0 {
+		return nil
+	}
+
+	db := database.GetInstance()
+
+	var groups []*model.Group
+	for _, groupId := range user.Groups {
+		group, err := db.GetGroup(groupId)
+		if err != nil || group == nil || group.IsDeleted {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	if !modelAllowed(groups, modelName) {
+		return &ModelNotAllowedError{Model: modelName}
+	}
+	return nil
+}
+
+func modelAllowed(groups []*model.Group, modelName string) bool {
+	restricted := false
+	for _, group := range groups {
+		if len(group.AllowedModels) == 0 {
+			continue
+		}
+		if slices.Contains(group.AllowedModels, modelName) {
+			return true
+		}
+		restricted = true
+	}
+	return !restricted
+}
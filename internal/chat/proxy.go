@@ -0,0 +1,353 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/llmusage"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/util/rest"
+
+	ai "github.com/paularlott/mcp/ai"
+	"github.com/paularlott/mcp/pool"
+)
+
+// maxProxyBodySize limits the size of a chat completion request accepted by
+// the proxy.
+const maxProxyBodySize = 32 * 1024 * 1024
+
+// proxyUserHeader carries the signed ID of the user a proxy request is for.
+const proxyUserHeader = "X-Knot-Proxy-User"
+
+// defaultBaseURLs are used for OpenAI compatible providers without a base URL.
+var defaultBaseURLs = map[ai.Provider]string{
+	ai.ProviderOpenAI:  "https://api.openai.com/v1",
+	ai.ProviderOllama:  "https://ollama.com/v1",
+	ai.ProviderZAi:     "https://api.z.ai/api/paas/v4",
+	ai.ProviderMistral: "https://api.mistral.ai/v1",
+}
+
+// StartProxy starts an OpenAI compatible endpoint on the loopback interface
+// that forwards raw chat completion requests to the provider of the requested
+// model, following the same routes and fallbacks as the router. It is used by
+// the chat UI which needs the unmodified provider stream.
+//
+// Only OpenAI compatible providers can be reached through the proxy, requests
+// must be made with a context from WithProxyUser. Returns the base URL of the
+// proxy and the bearer token it accepts.
+func (r *Router) StartProxy() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	r.proxySecret = make([]byte, 32)
+	if _, err := rand.Read(r.proxySecret); err != nil {
+		return "", "", err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to start the chat proxy: %w", err)
+	}
+	r.proxyAddr = listener.Addr().String()
+
+	// The chat UI reaches the proxy through the shared MCP HTTP pool, its
+	// requests pick up the signed user header there
+	pool.SetPool(&proxyPool{router: r, next: pool.GetPool()})
+
+	clients := make(map[string]*http.Client, len(r.providerConfigs))
+	for name, provider := range r.providerConfigs {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = provider.Timeout
+		clients[name] = &http.Client{Transport: transport}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, req *http.Request) {
+		auth := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			rest.WriteResponse(http.StatusUnauthorized, w, req, map[string]string{"error": "Unauthorized"})
+			return
+		}
+
+		r.proxyChatCompletion(w, req, clients)
+	})
+
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("chat: proxy stopped")
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), token, nil
+}
+
+// WithProxyUser returns a context whose requests to the proxy are made on
+// behalf of the user. Each request carries the user's ID in a header signed
+// with the proxy's secret, so the proxy can check their model and budget and
+// record the tokens used.
+func (r *Router) WithProxyUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, proxyUserKey{}, user.Id)
+}
+
+type proxyUserKey struct{}
+
+// signProxyUser returns the proxy user header value for the user.
+func (r *Router) signProxyUser(userId string) string {
+	mac := hmac.New(sha256.New, r.proxySecret)
+	mac.Write([]byte(userId))
+	return userId + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyProxyUser returns the user ID from a proxy user header, false if the
+// signature doesn't match.
+func (r *Router) verifyProxyUser(value string) (string, bool) {
+	userId, _, ok := strings.Cut(value, ".")
+	if !ok || userId == "" {
+		return "", false
+	}
+	return userId, hmac.Equal([]byte(r.signProxyUser(userId)), []byte(value))
+}
+
+// proxyPool hands out clients that sign the user into requests to the proxy.
+type proxyPool struct {
+	router *Router
+	next   pool.HTTPPool
+}
+
+func (p *proxyPool) GetHTTPClient() *http.Client {
+	client := *p.next.GetHTTPClient()
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.Transport = &proxyTransport{router: p.router, next: transport}
+	return &client
+}
+
+// proxyTransport adds the signed user header to requests made to the proxy
+// with a context from WithProxyUser.
+type proxyTransport struct {
+	router *Router
+	next   http.RoundTripper
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if userId, ok := req.Context().Value(proxyUserKey{}).(string); ok && req.URL.Host == t.router.proxyAddr {
+		req = req.Clone(req.Context())
+		req.Header.Set(proxyUserHeader, t.router.signProxyUser(userId))
+	}
+	return t.next.RoundTrip(req)
+}
+
+func (r *Router) proxyChatCompletion(w http.ResponseWriter, req *http.Request, clients map[string]*http.Client) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxProxyBodySize))
+	if err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, req, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		rest.WriteResponse(http.StatusBadRequest, w, req, map[string]string{"error": "Invalid request body"})
+		return
+	}
+	modelName, _ := payload["model"].(string)
+	if modelName == "" {
+		modelName = r.defaultModel
+	}
+
+	userId, ok := r.verifyProxyUser(req.Header.Get(proxyUserHeader))
+	if !ok {
+		rest.WriteResponse(http.StatusUnauthorized, w, req, map[string]string{"error": "Unauthorized"})
+		return
+	}
+	user, err := database.GetInstance().GetUser(userId)
+	if err != nil || user == nil || user.IsDeleted || !user.Active {
+		rest.WriteResponse(http.StatusUnauthorized, w, req, map[string]string{"error": "Unauthorized"})
+		return
+	}
+	if err := CheckModelAllowed(user, modelName); err != nil {
+		rest.WriteResponse(http.StatusForbidden, w, req, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := llmusage.CheckBudget(user); err != nil {
+		rest.WriteResponse(http.StatusTooManyRequests, w, req, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Ask for the usage of streamed completions so the tokens can be recorded
+	if stream, _ := payload["stream"].(bool); stream && payload["stream_options"] == nil {
+		payload["stream_options"] = map[string]any{"include_usage": true}
+	}
+
+	routes := r.resolveFor(user, modelName)
+	for i, rt := range routes {
+		last := i == len(routes)-1
+		provider := r.providerConfigs[rt.provider]
+
+		baseURL := provider.BaseURL
+		if baseURL == "" {
+			baseURL = defaultBaseURLs[ai.Provider(provider.Provider)]
+		}
+		if baseURL == "" {
+			if last {
+				rest.WriteResponse(http.StatusBadGateway, w, req, map[string]string{"error": fmt.Sprintf("provider %s is not OpenAI compatible", rt.provider)})
+				return
+			}
+			continue
+		}
+
+		payload["model"] = rt.model
+		routedBody, err := json.Marshal(payload)
+		if err != nil {
+			rest.WriteResponse(http.StatusBadRequest, w, req, map[string]string{"error": "Invalid request body"})
+			return
+		}
+
+		upstreamReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/chat/completions", bytes.NewReader(routedBody))
+		if err != nil {
+			rest.WriteResponse(http.StatusInternalServerError, w, req, map[string]string{"error": err.Error()})
+			return
+		}
+		upstreamReq.Header.Set("Content-Type", "application/json")
+		if accept := req.Header.Get("Accept"); accept != "" {
+			upstreamReq.Header.Set("Accept", accept)
+		}
+		if provider.APIKey != "" {
+			upstreamReq.Header.Set("Authorization", "Bearer "+provider.APIKey)
+		}
+
+		resp, err := clients[rt.provider].Do(upstreamReq)
+		if err != nil {
+			if !last && shouldFallback(req.Context(), err) {
+				log.WithError(err).Warn("chat: provider failed, falling back", "model", rt.alias, "provider", rt.provider, "fallback", routes[i+1].alias)
+				continue
+			}
+			rest.WriteResponse(http.StatusBadGateway, w, req, map[string]string{"error": err.Error()})
+			return
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError && !last && req.Context().Err() == nil {
+			resp.Body.Close()
+			log.Warn("chat: provider failed, falling back", "model", rt.alias, "provider", rt.provider, "status", resp.StatusCode, "fallback", routes[i+1].alias)
+			continue
+		}
+
+		usage := &proxyUsage{stream: strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")}
+		copyResponse(req.Context(), w, resp, usage)
+		if resp.StatusCode == http.StatusOK {
+			usage.flush()
+			llmusage.RecordResponse(user.Id, rt.alias, usage.model, usage.usage)
+		}
+		return
+	}
+}
+
+// copyResponse writes the provider response to the client flushing as it goes
+// so streamed responses are passed through unchanged, a copy is written to
+// usage.
+func copyResponse(ctx context.Context, w http.ResponseWriter, resp *http.Response, usage io.Writer) {
+	defer resp.Body.Close()
+
+	for _, header := range []string{"Content-Type", "Cache-Control"} {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for ctx.Err() == nil {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			usage.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// proxyUsage finds the model and token usage in a chat completion response,
+// either a JSON body or the chunks of an event stream.
+type proxyUsage struct {
+	stream bool
+	buf    []byte
+	model  string
+	usage  *ai.Usage
+}
+
+func (u *proxyUsage) Write(p []byte) (int, error) {
+	if len(u.buf)+len(p) > maxProxyBodySize {
+		u.buf = u.buf[:0]
+		return len(p), nil
+	}
+	u.buf = append(u.buf, p...)
+
+	// Each event of a stream is on one line, a JSON body is parsed once complete
+	for u.stream {
+		i := bytes.IndexByte(u.buf, '\n')
+		if i < 0 {
+			break
+		}
+		u.parse(u.buf[:i])
+		u.buf = u.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush parses what remains once the response is complete.
+func (u *proxyUsage) flush() {
+	u.parse(u.buf)
+	u.buf = nil
+}
+
+func (u *proxyUsage) parse(data []byte) {
+	data = bytes.TrimSpace(data)
+	if u.stream {
+		data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("data:")))
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return
+	}
+
+	var chunk struct {
+		Model string    `json:"model"`
+		Usage *ai.Usage `json:"usage"`
+	}
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	if chunk.Model != "" {
+		u.model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		u.usage = chunk.Usage
+	}
+}
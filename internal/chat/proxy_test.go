@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+
+	"github.com/paularlott/mcp/pool"
+)
+
+func TestProxyUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+		model  string
+		total  int
+	}{
+		{
+			name:  "json",
+			body:  "{\n  \"model\": \"gpt-4o\",\n  \"usage\": {\"prompt_tokens\": 10, \"completion_tokens\": 5, \"total_tokens\": 15}\n}",
+			model: "gpt-4o",
+			total: 15,
+		},
+		{
+			name:   "stream",
+			stream: true,
+			body: "data: {\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+				"data: {\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":3,\"total_tokens\":10}}\n\n" +
+				"data: [DONE]\n\n",
+			model: "gpt-4o",
+			total: 10,
+		},
+		{
+			name:   "stream without usage",
+			stream: true,
+			body:   "data: {\"model\":\"qwen\",\"choices\":[]}\n\ndata: [DONE]\n\n",
+			model:  "qwen",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := &proxyUsage{stream: tt.stream}
+
+			// Split the body to check chunks spanning writes are parsed
+			for _, part := range []string{tt.body[:len(tt.body)/3], tt.body[len(tt.body)/3:]} {
+				usage.Write([]byte(part))
+			}
+			usage.flush()
+
+			if usage.model != tt.model {
+				t.Errorf("model = %q, want %q", usage.model, tt.model)
+			}
+			total := 0
+			if usage.usage != nil {
+				total = usage.usage.TotalTokens
+			}
+			if total != tt.total {
+				t.Errorf("total tokens = %d, want %d", total, tt.total)
+			}
+		})
+	}
+}
+
+func TestProxyUserHeader(t *testing.T) {
+	r := &Router{proxySecret: []byte("secret")}
+
+	seen := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		seen <- req.Header.Get(proxyUserHeader)
+	}))
+	defer server.Close()
+	r.proxyAddr = strings.TrimPrefix(server.URL, "http://")
+
+	client := (&proxyPool{router: r, next: pool.GetPool()}).GetHTTPClient()
+	for _, user := range []*model.User{{Id: "u1"}, {Id: "u2"}} {
+		req, err := http.NewRequestWithContext(r.WithProxyUser(context.Background(), user), http.MethodPost, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if userId, ok := r.verifyProxyUser(<-seen); !ok || userId != user.Id {
+			t.Errorf("proxy saw user %q (%v), want %s", userId, ok, user.Id)
+		}
+	}
+
+	// Requests without a user carry no header
+	resp, err := client.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if header := <-seen; header != "" {
+		t.Errorf("unexpected header %q on a request without a user", header)
+	}
+}
+
+func TestVerifyProxyUser(t *testing.T) {
+	r := &Router{proxySecret: []byte("secret")}
+	other := &Router{proxySecret: []byte("other")}
+
+	signed := r.signProxyUser("u1")
+	if userId, ok := r.verifyProxyUser(signed); !ok || userId != "u1" {
+		t.Errorf("verifyProxyUser(%q) = %q, %v", signed, userId, ok)
+	}
+
+	_, sig, _ := strings.Cut(signed, ".")
+	for _, value := range []string{"", "u1", "u1.", "u2." + sig, other.signProxyUser("u1")} {
+		if _, ok := r.verifyProxyUser(value); ok {
+			t.Errorf("verifyProxyUser(%q) accepted", value)
+		}
+	}
+}
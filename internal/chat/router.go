@@ -0,0 +1,367 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+
+	"github.com/paularlott/mcp"
+	ai "github.com/paularlott/mcp/ai"
+	mcpopenai "github.com/paularlott/mcp/ai/openai"
+)
+
+// DefaultProviderName is the name of the provider configured by the chat
+// provider, API key and base URL settings.
+const DefaultProviderName = "default"
+
+// maxFallbacks limits how many fallback aliases are followed for one request.
+const maxFallbacks = 4
+
+type route struct {
+	alias    string
+	provider string
+	model    string
+	fallback string
+}
+
+// Router is an AI client that sends each request to the provider of the
+// requested model alias, trying the fallback alias when the provider fails
+// with a server error or times out. Models that aren't aliases are sent to the
+// default provider unchanged.
+type Router struct {
+	providers       map[string]ai.Client
+	providerConfigs map[string]config.ChatProviderConfig
+	defaultProvider string
+	defaultModel    string
+	routes          map[string]*route
+	aliases         []string
+
+	// proxyAddr is the address of the proxy and proxySecret signs the user
+	// of each request made to it
+	proxyAddr   string
+	proxySecret []byte
+}
+
+func NewRouter(cfg config.ChatConfig, mcpServer *mcp.Server) (*Router, error) {
+	r := &Router{
+		providers:       make(map[string]ai.Client),
+		providerConfigs: make(map[string]config.ChatProviderConfig),
+		defaultModel:    cfg.Model,
+		routes:          make(map[string]*route),
+	}
+
+	providers := cfg.Providers
+	if cfg.Provider != "" {
+		providers = append([]config.ChatProviderConfig{{
+			Name:     DefaultProviderName,
+			Provider: cfg.Provider,
+			APIKey:   cfg.APIKey,
+			BaseURL:  cfg.BaseURL,
+		}}, providers...)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no chat provider configured")
+	}
+
+	for _, provider := range providers {
+		if _, ok := r.providers[provider.Name]; ok {
+			return nil, fmt.Errorf("duplicate chat provider %s", provider.Name)
+		}
+
+		client, err := ai.NewClient(ai.Config{
+			Config: mcpopenai.Config{
+				APIKey:         provider.APIKey,
+				BaseURL:        provider.BaseURL,
+				LocalServer:    mcpServer,
+				RequestTimeout: provider.Timeout,
+			},
+			Provider: ai.Provider(provider.Provider),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AI client for provider %s: %w", provider.Name, err)
+		}
+
+		r.providers[provider.Name] = client
+		r.providerConfigs[provider.Name] = provider
+		if r.defaultProvider == "" {
+			r.defaultProvider = provider.Name
+		}
+	}
+
+	for _, alias := range cfg.Models {
+		rt := &route{
+			alias:    alias.Alias,
+			provider: alias.Provider,
+			model:    alias.Model,
+			fallback: alias.Fallback,
+		}
+		if rt.provider == "" {
+			rt.provider = r.defaultProvider
+		}
+		if rt.model == "" {
+			rt.model = rt.alias
+		}
+		if _, ok := r.providers[rt.provider]; !ok {
+			return nil, fmt.Errorf("model %s uses unknown chat provider %s", rt.alias, rt.provider)
+		}
+		if _, ok := r.routes[rt.alias]; ok {
+			return nil, fmt.Errorf("duplicate chat model %s", rt.alias)
+		}
+
+		r.routes[rt.alias] = rt
+		r.aliases = append(r.aliases, rt.alias)
+	}
+
+	for _, rt := range r.routes {
+		if rt.fallback != "" && r.routes[rt.fallback] == nil {
+			return nil, fmt.Errorf("model %s falls back to unknown model %s", rt.alias, rt.fallback)
+		}
+	}
+
+	return r, nil
+}
+
+// resolve returns the routes to try in order for the model, the first is the
+// model itself followed by its fallbacks.
+func (r *Router) resolve(modelName string) []*route {
+	if modelName == "" {
+		modelName = r.defaultModel
+	}
+
+	rt := r.routes[modelName]
+	if rt == nil {
+		return []*route{{alias: modelName, provider: r.defaultProvider, model: modelName}}
+	}
+
+	routes := []*route{rt}
+	seen := map[string]bool{rt.alias: true}
+	for rt.fallback != "" && !seen[rt.fallback] && len(routes) <= maxFallbacks {
+		rt = r.routes[rt.fallback]
+		seen[rt.alias] = true
+		routes = append(routes, rt)
+	}
+	return routes
+}
+
+// resolveFor is resolve for the user, fallbacks to models the user may not use
+// are skipped.
+func (r *Router) resolveFor(user *model.User, modelName string) []*route {
+	routes := r.resolve(modelName)
+	allowed := routes[:1]
+	for _, rt := range routes[1:] {
+		if CheckModelAllowed(user, rt.alias) == nil {
+			allowed = append(allowed, rt)
+		}
+	}
+	return allowed
+}
+
+// shouldFallback reports whether a failed request should be retried on the
+// next provider, only server errors and timeouts are retried and never once
+// the caller has gone away.
+func shouldFallback(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *mcpopenai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsServerError()
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// checkModel returns a ModelNotAllowedError if the user in the context may not
+// use the model.
+func (r *Router) checkModel(ctx context.Context, modelName string) error {
+	if modelName == "" {
+		modelName = r.defaultModel
+	}
+
+	user, _ := ctx.Value("user").(*model.User)
+	return CheckModelAllowed(user, modelName)
+}
+
+// DefaultModel returns the model used by requests that don't name one.
+func (r *Router) DefaultModel() string {
+	return r.defaultModel
+}
+
+func (r *Router) Provider() string {
+	return r.providers[r.defaultProvider].Provider()
+}
+
+func (r *Router) SupportsCapability(cap string) bool {
+	return r.providers[r.defaultProvider].SupportsCapability(cap)
+}
+
+// Aliases returns the configured model aliases in configuration order.
+func (r *Router) Aliases() []string {
+	return r.aliases
+}
+
+// GetModels lists the model aliases the user may use, if no aliases are
+// configured the models of the default provider are listed instead.
+func (r *Router) GetModels(ctx context.Context) (*ai.ModelsResponse, error) {
+	user, _ := ctx.Value("user").(*model.User)
+
+	var models *ai.ModelsResponse
+	if len(r.aliases) == 0 {
+		var err error
+		models, err = r.providers[r.defaultProvider].GetModels(ctx)
+		if err != nil || models == nil {
+			return models, err
+		}
+	} else {
+		models = &ai.ModelsResponse{Object: "list", Data: make([]ai.Model, 0, len(r.aliases))}
+		for _, alias := range r.aliases {
+			models.Data = append(models.Data, ai.Model{
+				ID:      alias,
+				Object:  "model",
+				OwnedBy: r.routes[alias].provider,
+			})
+		}
+	}
+
+	allowed := models.Data[:0]
+	for _, m := range models.Data {
+		if CheckModelAllowed(user, m.ID) == nil {
+			allowed = append(allowed, m)
+		}
+	}
+	models.Data = allowed
+
+	return models, nil
+}
+
+func (r *Router) ChatCompletion(ctx context.Context, req ai.ChatCompletionRequest) (*ai.ChatCompletionResponse, error) {
+	if err := r.checkModel(ctx, req.Model); err != nil {
+		return nil, err
+	}
+
+	user, _ := ctx.Value("user").(*model.User)
+	routes := r.resolveFor(user, req.Model)
+	for i, rt := range routes {
+		routed := req
+		routed.Model = rt.model
+
+		response, err := r.providers[rt.provider].ChatCompletion(ctx, routed)
+		if err == nil || i == len(routes)-1 || !shouldFallback(ctx, err) {
+			return response, err
+		}
+
+		log.WithError(err).Warn("chat: provider failed, falling back", "model", rt.alias, "provider", rt.provider, "fallback", routes[i+1].alias)
+	}
+
+	return nil, fmt.Errorf("no chat provider for model %s", req.Model)
+}
+
+// StreamChatCompletion streams from the provider of the model, the fallback is
+// only tried if the provider fails before the first chunk is received.
+func (r *Router) StreamChatCompletion(ctx context.Context, req ai.ChatCompletionRequest) *ai.ChatStream {
+	responseChan := make(chan ai.ChatCompletionResponse, 50)
+	errorChan := make(chan error, 1)
+	stream := mcpopenai.NewChatStream(ctx, responseChan, errorChan)
+
+	if err := r.checkModel(ctx, req.Model); err != nil {
+		errorChan <- err
+		close(errorChan)
+		close(responseChan)
+		stream.SetRetryMetadata(nil)
+		return stream
+	}
+
+	user, _ := ctx.Value("user").(*model.User)
+	routes := r.resolveFor(user, req.Model)
+	go func() {
+		defer close(responseChan)
+		defer close(errorChan)
+
+		for i, rt := range routes {
+			routed := req
+			routed.Model = rt.model
+
+			upstream := r.providers[rt.provider].StreamChatCompletion(ctx, routed)
+			received := false
+			for upstream.Next() {
+				received = true
+				select {
+				case responseChan <- upstream.Current():
+				case <-ctx.Done():
+				}
+			}
+
+			err := upstream.Err()
+			if err != nil && !received && i < len(routes)-1 && shouldFallback(ctx, err) {
+				log.WithError(err).Warn("chat: provider failed, falling back", "model", rt.alias, "provider", rt.provider, "fallback", routes[i+1].alias)
+				continue
+			}
+
+			if err != nil {
+				errorChan <- err
+			}
+			stream.SetRetryMetadata(upstream.Retry())
+			return
+		}
+	}()
+
+	return stream
+}
+
+func (r *Router) CreateEmbedding(ctx context.Context, req ai.EmbeddingRequest) (*ai.EmbeddingResponse, error) {
+	if err := r.checkModel(ctx, req.Model); err != nil {
+		return nil, err
+	}
+
+	rt := r.resolve(req.Model)[0]
+	req.Model = rt.model
+	return r.providers[rt.provider].CreateEmbedding(ctx, req)
+}
+
+// The Responses API keeps state with the provider so it always uses the
+// default provider.
+
+func (r *Router) CreateResponse(ctx context.Context, req ai.CreateResponseRequest) (*ai.ResponseObject, error) {
+	return r.providers[r.defaultProvider].CreateResponse(ctx, req)
+}
+
+func (r *Router) StreamResponse(ctx context.Context, req ai.CreateResponseRequest) *ai.ResponseStream {
+	return r.providers[r.defaultProvider].StreamResponse(ctx, req)
+}
+
+func (r *Router) GetResponse(ctx context.Context, id string) (*ai.ResponseObject, error) {
+	return r.providers[r.defaultProvider].GetResponse(ctx, id)
+}
+
+func (r *Router) CancelResponse(ctx context.Context, id string) (*ai.ResponseObject, error) {
+	return r.providers[r.defaultProvider].CancelResponse(ctx, id)
+}
+
+func (r *Router) DeleteResponse(ctx context.Context, id string) error {
+	return r.providers[r.defaultProvider].DeleteResponse(ctx, id)
+}
+
+func (r *Router) CompactResponse(ctx context.Context, id string) (*ai.ResponseObject, error) {
+	return r.providers[r.defaultProvider].CompactResponse(ctx, id)
+}
+
+func (r *Router) Close() error {
+	var errs []error
+	for _, client := range r.providers {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
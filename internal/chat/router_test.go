@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+
+	ai "github.com/paularlott/mcp/ai"
+	mcpopenai "github.com/paularlott/mcp/ai/openai"
+)
+
+type fakeClient struct {
+	ai.Client
+	err   error
+	calls []string
+}
+
+func (c *fakeClient) ChatCompletion(ctx context.Context, req ai.ChatCompletionRequest) (*ai.ChatCompletionResponse, error) {
+	c.calls = append(c.calls, req.Model)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ai.ChatCompletionResponse{Model: req.Model}, nil
+}
+
+func testRouter(local, hosted *fakeClient) *Router {
+	return &Router{
+		providers:       map[string]ai.Client{"local": local, "hosted": hosted},
+		defaultProvider: "local",
+		defaultModel:    "fast",
+		routes: map[string]*route{
+			"fast":  {alias: "fast", provider: "local", model: "qwen", fallback: "smart"},
+			"smart": {alias: "smart", provider: "hosted", model: "gpt", fallback: "fast"},
+		},
+		aliases: []string{"fast", "smart"},
+	}
+}
+
+func TestRouter_resolve(t *testing.T) {
+	r := testRouter(&fakeClient{}, &fakeClient{})
+
+	tests := []struct {
+		model string
+		want  []string
+	}{
+		{"", []string{"fast", "smart"}},
+		{"fast", []string{"fast", "smart"}},
+		{"smart", []string{"smart", "fast"}},
+		{"other", []string{"other"}},
+	}
+	for _, tt := range tests {
+		routes := r.resolve(tt.model)
+		got := make([]string, len(routes))
+		for i, rt := range routes {
+			got[i] = rt.alias
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("resolve(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+
+	if rt := r.resolve("other")[0]; rt.provider != "local" || rt.model != "other" {
+		t.Errorf("expected unknown models on the default provider unchanged, got %+v", rt)
+	}
+}
+
+func TestRouter_ChatCompletionFallback(t *testing.T) {
+	serverErr := &mcpopenai.APIError{StatusCode: http.StatusBadGateway, Type: "server_error"}
+	badRequest := &mcpopenai.APIError{StatusCode: http.StatusBadRequest, Type: "invalid_request_error"}
+
+	tests := []struct {
+		name       string
+		localErr   error
+		wantErr    bool
+		wantHosted int
+	}{
+		{"success", nil, false, 0},
+		{"server error", serverErr, false, 1},
+		{"timeout", context.DeadlineExceeded, false, 1},
+		{"client error", badRequest, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, hosted := &fakeClient{err: tt.localErr}, &fakeClient{}
+			r := testRouter(local, hosted)
+
+			response, err := r.ChatCompletion(context.Background(), ai.ChatCompletionRequest{Model: "fast"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(local.calls) != 1 || local.calls[0] != "qwen" {
+				t.Errorf("expected one call for qwen on the local provider, got %v", local.calls)
+			}
+			if len(hosted.calls) != tt.wantHosted {
+				t.Errorf("expected %d calls on the hosted provider, got %v", tt.wantHosted, hosted.calls)
+			}
+			if tt.wantHosted > 0 && response.Model != "gpt" {
+				t.Errorf("expected the fallback model, got %s", response.Model)
+			}
+		})
+	}
+}
+
+func TestShouldFallback_callerGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if shouldFallback(ctx, context.DeadlineExceeded) {
+		t.Error("expected no fallback once the caller has gone")
+	}
+	if shouldFallback(context.Background(), errors.New("boom")) {
+		t.Error("expected no fallback for other errors")
+	}
+}
+
+func TestModelAllowed(t *testing.T) {
+	open := &model.Group{Id: "g1"}
+	fast := &model.Group{Id: "g2", AllowedModels: []string{"fast"}}
+	smart := &model.Group{Id: "g3", AllowedModels: []string{"smart"}}
+
+	tests := []struct {
+		name   string
+		groups []*model.Group
+		model  string
+		want   bool
+	}{
+		{"no groups", nil, "smart", true},
+		{"unrestricted group", []*model.Group{open}, "smart", true},
+		{"listed", []*model.Group{fast}, "fast", true},
+		{"not listed", []*model.Group{fast}, "smart", false},
+		{"listed by another group", []*model.Group{open, fast, smart}, "smart", true},
+		{"restricted with open group", []*model.Group{open, fast}, "smart", false},
+	}
+	for _, tt := range tests {
+		if got := modelAllowed(tt.groups, tt.model); got != tt.want {
+			t.Errorf("%s: modelAllowed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
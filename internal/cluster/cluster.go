@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"errors"
 	"math"
 	"net/http"
@@ -22,11 +23,14 @@ import (
 	"github.com/paularlott/knot/internal/dns"
 	"github.com/paularlott/knot/internal/middleware"
 	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util/crypt"
 
 	"github.com/google/uuid"
 	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Cluster struct {
@@ -181,6 +185,8 @@ func NewCluster(
 		cluster.gossipCluster.HandleFunc(ConversationGossipMsg, cluster.handleConversationGossip)
 		cluster.gossipCluster.HandleFuncWithReply(MCPServerFullSyncMsg, cluster.handleMCPServerFullSync)
 		cluster.gossipCluster.HandleFunc(MCPServerGossipMsg, cluster.handleMCPServerGossip)
+		cluster.gossipCluster.HandleFuncWithReply(LLMUsageFullSyncMsg, cluster.handleLLMUsageFullSync)
+		cluster.gossipCluster.HandleFunc(LLMUsageGossipMsg, cluster.handleLLMUsageGossip)
 		if cluster.sessionGossip {
 			cluster.gossipCluster.HandleFuncWithReply(SessionFullSyncMsg, cluster.handleSessionFullSync)
 			cluster.gossipCluster.HandleFunc(SessionGossipMsg, cluster.handleSessionGossip)
@@ -221,6 +227,7 @@ func NewCluster(
 			cluster.gossipInFlight()
 			cluster.gossipConversations()
 			cluster.gossipMCPServers()
+			cluster.gossipLLMUsage()
 			if cluster.sessionGossip {
 				cluster.gossipSessions()
 			}
@@ -456,6 +463,10 @@ func (c *Cluster) Start(peers []string, originServer string, originToken string)
 						c.logger.WithError(err).Error("failed to sync MCP servers with node")
 					}
 
+					if err := c.DoLLMUsageFullSync(node); err != nil {
+						c.logger.WithError(err).Error("failed to sync LLM usage with node")
+					}
+
 					if c.sessionGossip {
 						if err := c.DoSessionFullSync(node); err != nil {
 							c.logger.WithError(err).Error("failed to sync sessions with node")
@@ -538,22 +549,30 @@ func (c *Cluster) GetLocalNodeId() (string, error) {
 	return nodeIdCfg.Value, nil
 }
 
-func (c *Cluster) LockResource(resourceId string) string {
+func (c *Cluster) LockResource(ctx context.Context, resourceId string) string {
 	// If in cluster mode and not the leader then we have to ask the leader to lock the resource
 	if c.election != nil && c.electionRunning && !c.election.IsLeader() {
 		c.logger.Debug("asking leader to lock resource")
 
 		leaderNode := c.election.GetLeader()
 		if leaderNode != nil {
+			ctx, span := tracing.StartKind(ctx, "cluster.lock_resource", trace.SpanKindClient,
+				attribute.String("knot.resource_id", resourceId),
+				attribute.String("knot.leader_id", leaderNode.ID.String()),
+			)
 			request := &ResourceLockRequestMsg{
-				ResourceId: resourceId,
+				ResourceId:   resourceId,
+				TraceContext: tracing.Carrier(ctx),
 			}
 			response := &ResourceLockResponseMsg{}
 			if err := c.gossipCluster.SendToWithResponse(leaderNode, ResourceLockMsg, request, response); err != nil {
 				c.logger.WithError(err).Error("failed to request resource lock from leader")
+				tracing.End(span, err)
 				return ""
 			}
 
+			span.SetAttributes(attribute.Bool("knot.locked", response.UnlockToken != ""))
+			span.End()
 			return response.UnlockToken
 		}
 	}
@@ -583,20 +602,27 @@ func (c *Cluster) lockResourceLocally(resourceId string) string {
 	return lock.UnlockToken
 }
 
-func (c *Cluster) UnlockResource(resourceId, unlockToken string) {
+func (c *Cluster) UnlockResource(ctx context.Context, resourceId, unlockToken string) {
 	// If in cluster mode and not the leader then we have to ask the leader to unlock the resource
 	if c.election != nil && c.electionRunning && !c.election.IsLeader() {
 		c.logger.Debug("asking leader to unlock resource")
 
 		leaderNode := c.election.GetLeader()
 		if leaderNode != nil {
+			ctx, span := tracing.StartKind(ctx, "cluster.unlock_resource", trace.SpanKindProducer,
+				attribute.String("knot.resource_id", resourceId),
+				attribute.String("knot.leader_id", leaderNode.ID.String()),
+			)
 			request := &ResourceUnlockRequestMsg{
-				ResourceId:  resourceId,
-				UnlockToken: unlockToken,
+				ResourceId:   resourceId,
+				UnlockToken:  unlockToken,
+				TraceContext: tracing.Carrier(ctx),
 			}
-			if err := c.gossipCluster.SendTo(leaderNode, ResourceUnlockMsg, request); err != nil {
+			err := c.gossipCluster.SendTo(leaderNode, ResourceUnlockMsg, request)
+			if err != nil {
 				c.logger.WithError(err).Error("failed to request resource unlock from leader")
 			}
+			tracing.End(span, err)
 
 			return
 		}
@@ -633,9 +659,15 @@ func (c *Cluster) handleResourceLock(sender *gossip.Node, packet *gossip.Packet)
 		return nil, err
 	}
 
+	_, span := tracing.StartKind(tracing.FromCarrier(context.Background(), request.TraceContext), "cluster.handle_lock_resource", trace.SpanKindServer,
+		attribute.String("knot.resource_id", request.ResourceId),
+	)
+	defer span.End()
+
 	response := &ResourceLockResponseMsg{
 		UnlockToken: c.lockResourceLocally(request.ResourceId),
 	}
+	span.SetAttributes(attribute.Bool("knot.locked", response.UnlockToken != ""))
 
 	// Return the full dataset directly as response
 	return response, nil
@@ -655,7 +687,11 @@ func (c *Cluster) handleResourceUnlock(sender *gossip.Node, packet *gossip.Packe
 		return err
 	}
 
+	_, span := tracing.StartKind(tracing.FromCarrier(context.Background(), request.TraceContext), "cluster.handle_unlock_resource", trace.SpanKindConsumer,
+		attribute.String("knot.resource_id", request.ResourceId),
+	)
 	c.unlockResourceLocally(request.ResourceId, request.UnlockToken)
+	span.End()
 
 	return nil
 }
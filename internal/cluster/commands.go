@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/cluster/leafmsg"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/sse"
 )
 
@@ -102,6 +103,7 @@ func (c *Cluster) mergeCommands(commands []*model.Command) error {
 		localCommandsMap[command.Id] = command
 	}
 
+	changed := false
 	for _, command := range commands {
 		if localCommand, ok := localCommandsMap[command.Id]; ok {
 			if command.UpdatedAt.After(localCommand.UpdatedAt) {
@@ -109,6 +111,7 @@ func (c *Cluster) mergeCommands(commands []*model.Command) error {
 					c.logger.Error("Failed to update command", "error", err, "name", command.Name)
 				}
 
+				changed = true
 				if command.IsDeleted {
 					sse.PublishSlashCommandsDeleted(command.Id)
 				} else {
@@ -122,10 +125,15 @@ func (c *Cluster) mergeCommands(commands []*model.Command) error {
 
 			if !command.IsDeleted {
 				sse.PublishSlashCommandsChanged(command.Id)
+				changed = true
 			}
 		}
 	}
 
+	if changed {
+		internal_mcp.NotifyPromptsChanged()
+	}
+
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/uuid"
@@ -36,6 +37,7 @@ func (nonLeaderTransport) GossipUser(*model.User)                         {}
 func (nonLeaderTransport) GossipToken(*model.Token)                       {}
 func (nonLeaderTransport) GossipVolume(*model.Volume)                     {}
 func (nonLeaderTransport) GossipSpaceUsageSample(*model.SpaceUsageSample) {}
+func (nonLeaderTransport) GossipLLMUsage(*model.LLMUsage)                 {}
 func (nonLeaderTransport) GossipAuditLog(*model.AuditLogEntry)            {}
 func (nonLeaderTransport) GossipSession(*model.Session)                   {}
 func (nonLeaderTransport) GossipScript(*model.Script)                     {}
@@ -52,8 +54,8 @@ func (nonLeaderTransport) GossipPoolUndrain(string)                       {}
 func (nonLeaderTransport) BroadcastEvent(*service.EventEnvelope)          {}
 func (nonLeaderTransport) GetAgentEndpoints() []string                    { return nil }
 func (nonLeaderTransport) GetTunnelServers() []string                     { return nil }
-func (nonLeaderTransport) LockResource(string) string                     { return "" }
-func (nonLeaderTransport) UnlockResource(string, string)                  {}
+func (nonLeaderTransport) LockResource(context.Context, string) string    { return "" }
+func (nonLeaderTransport) UnlockResource(context.Context, string, string) {}
 func (nonLeaderTransport) Nodes() []*gossip.Node                          { return nil }
 func (nonLeaderTransport) GetNodeByIDString(string) *gossip.Node          { return nil }
 func (nonLeaderTransport) EnqueueSpaceCleanup(*model.Space)               {}
@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/paularlott/gossip"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (c *Cluster) handleLLMUsageFullSync(sender *gossip.Node, packet *gossip.Packet) (interface{}, error) {
+	c.logger.Debug("Received LLM usage full sync request")
+
+	usage := []*model.LLMUsage{}
+	if err := packet.Unmarshal(&usage); err != nil {
+		c.logger.WithError(err).Error("Failed to unmarshal LLM usage full sync request")
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	existing, err := database.GetInstance().GetLLMUsageRange(now.Add(-model.LLMUsageRetention), now)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := c.mergeLLMUsage(usage); err != nil {
+			c.logger.WithError(err).Error("Failed to merge LLM usage")
+		}
+	}()
+
+	return existing, nil
+}
+
+func (c *Cluster) handleLLMUsageGossip(sender *gossip.Node, packet *gossip.Packet) error {
+	c.logger.Trace("Received LLM usage gossip request")
+
+	usage := []*model.LLMUsage{}
+	if err := packet.Unmarshal(&usage); err != nil {
+		c.logger.WithError(err).Error("Failed to unmarshal LLM usage gossip request")
+		return err
+	}
+
+	go func() {
+		if err := c.mergeLLMUsage(usage); err != nil {
+			c.logger.WithError(err).Error("Failed to merge LLM usage")
+		}
+	}()
+
+	return nil
+}
+
+func (c *Cluster) GossipLLMUsage(usage *model.LLMUsage) {
+	if c.gossipCluster != nil {
+		rows := []*model.LLMUsage{usage}
+		c.gossipCluster.Send(LLMUsageGossipMsg, &rows)
+	}
+}
+
+func (c *Cluster) DoLLMUsageFullSync(node *gossip.Node) error {
+	if c.gossipCluster == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	usage, err := database.GetInstance().GetLLMUsageRange(now.Add(-model.LLMUsageRetention), now)
+	if err != nil {
+		return err
+	}
+
+	if err := c.gossipCluster.SendToWithResponse(node, LLMUsageFullSyncMsg, &usage, &usage); err != nil {
+		return err
+	}
+
+	return c.mergeLLMUsage(usage)
+}
+
+// mergeLLMUsage keeps the newest copy of each row, rows only ever have a
+// single writer so the newest copy holds the highest counts.
+func (c *Cluster) mergeLLMUsage(usage []*model.LLMUsage) error {
+	db := database.GetInstance()
+	for _, row := range usage {
+		if row == nil || row.UserId == "" {
+			continue
+		}
+
+		existing, err := db.GetLLMUsage(row.Id)
+		if err == nil && existing != nil && !row.UpdatedAt.After(existing.UpdatedAt) {
+			continue
+		}
+
+		if err := db.SaveLLMUsage(row); err != nil {
+			c.logger.WithError(err).Error("Failed to save LLM usage", "llm_usage_id", row.Id)
+		}
+	}
+	return nil
+}
+
+// gossipLLMUsage shares the usage of the current month, which is all that
+// budgets are checked against.
+func (c *Cluster) gossipLLMUsage() {
+	if c.gossipCluster == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	usage, err := database.GetInstance().GetLLMUsageRange(model.LLMUsageMonth(now), now)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to get LLM usage")
+		return
+	}
+
+	rand.Shuffle(len(usage), func(i, j int) {
+		usage[i], usage[j] = usage[j], usage[i]
+	})
+
+	batchSize := c.gossipCluster.CalcPayloadSize(len(usage))
+	if batchSize > 0 {
+		c.logger.Trace("Gossipping LLM usage", "batch_size", batchSize, "total", len(usage))
+		rows := usage[:batchSize]
+		c.gossipCluster.Send(LLMUsageGossipMsg, &rows)
+	}
+}
@@ -51,4 +51,6 @@ const (
 	ConversationGossipMsg
 	MCPServerFullSyncMsg
 	MCPServerGossipMsg
+	LLMUsageFullSyncMsg
+	LLMUsageGossipMsg
 )
@@ -15,7 +15,8 @@ const (
 )
 
 type ResourceLockRequestMsg struct {
-	ResourceId string
+	ResourceId   string
+	TraceContext map[string]string
 }
 
 type ResourceLockResponseMsg struct {
@@ -23,8 +24,9 @@ type ResourceLockResponseMsg struct {
 }
 
 type ResourceUnlockRequestMsg struct {
-	ResourceId  string
-	UnlockToken string
+	ResourceId   string
+	UnlockToken  string
+	TraceContext map[string]string
 }
 
 type ResourceLock struct {
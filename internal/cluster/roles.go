@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/cluster/leafmsg"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 )
 
@@ -130,6 +131,10 @@ func (c *Cluster) mergeRoles(roles []*model.Role) error {
 					model.SaveRoleToCache(role)
 					sse.PublishRolesChanged(role.Id)
 				}
+
+				if role.IsDeleted || model.SSHForwardingChanged(localRole, role) {
+					go service.GetUserService().UpdateRoleSSHKeys(role.Id)
+				}
 			}
 		} else {
 			// If the role doesn't exist locally, create it (even if deleted) to prevent resurrection
@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/cluster/leafmsg"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/sse"
 )
 
@@ -102,6 +103,7 @@ func (c *Cluster) mergeSkills(skills []*model.Skill) error {
 		localSkillsMap[skill.Id] = skill
 	}
 
+	changed := false
 	for _, skill := range skills {
 		if localSkill, ok := localSkillsMap[skill.Id]; ok {
 			if skill.UpdatedAt.After(localSkill.UpdatedAt) {
@@ -109,6 +111,7 @@ func (c *Cluster) mergeSkills(skills []*model.Skill) error {
 					c.logger.Error("Failed to update skill", "error", err, "name", skill.Name)
 				}
 
+				changed = true
 				if skill.IsDeleted {
 					sse.PublishSkillsDeleted(skill.Id)
 				} else {
@@ -122,10 +125,15 @@ func (c *Cluster) mergeSkills(skills []*model.Skill) error {
 
 			if !skill.IsDeleted {
 				sse.PublishSkillsChanged(skill.Id)
+				changed = true
 			}
 		}
 	}
 
+	if changed {
+		internal_mcp.NotifyPromptsChanged()
+	}
+
 	return nil
 }
 
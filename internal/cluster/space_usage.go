@@ -135,6 +135,7 @@ func mergeIncomingSpaceUsageSnapshot(target, incoming *model.SpaceUsageSample) {
 	target.ActivitySpaceStops = maxUint32(target.ActivitySpaceStops, incoming.ActivitySpaceStops)
 	target.ActivitySpaceCreates = maxUint32(target.ActivitySpaceCreates, incoming.ActivitySpaceCreates)
 	target.ActivitySpaceDeletes = maxUint32(target.ActivitySpaceDeletes, incoming.ActivitySpaceDeletes)
+	target.MergeCustomMetrics(incoming.CustomMetrics)
 
 	if incoming.LastActivityAt != nil && (target.LastActivityAt == nil || incoming.LastActivityAt.After(*target.LastActivityAt)) {
 		lastActivityAt := incoming.LastActivityAt.UTC()
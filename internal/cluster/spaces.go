@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/container/helper"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	internal_mcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 )
@@ -149,6 +150,7 @@ func (c *Cluster) mergeSpaces(spaces []*model.Space) error {
 	}
 
 	// Merge the spaces
+	listChanged := false
 	for _, space := range spaces {
 		if localSpace, ok := localSpacesMap[space.Id]; ok {
 			// If the remote space is newer than the local space then use its data
@@ -209,6 +211,10 @@ func (c *Cluster) mergeSpaces(spaces []*model.Space) error {
 				} else if stateChanged {
 					sse.PublishSpaceChanged(space.Id, space.UserId)
 				}
+
+				if space.IsDeleted != localSpace.IsDeleted || space.Name != localSpace.Name || space.SharedWithUserId != localSpace.SharedWithUserId {
+					listChanged = true
+				}
 			}
 		} else {
 			// If the space doesn't exist locally, create it (even if deleted) to prevent resurrection
@@ -220,10 +226,15 @@ func (c *Cluster) mergeSpaces(spaces []*model.Space) error {
 				// Usage history ages out via retention and local reapers.
 			} else {
 				sse.PublishSpaceChanged(space.Id, space.UserId)
+				listChanged = true
 			}
 		}
 	}
 
+	if listChanged {
+		internal_mcp.NotifyResourcesChanged()
+	}
+
 	return nil
 }
 
@@ -12,6 +12,8 @@ type AgentConfig struct {
 	DisableSpaceIO       bool
 	MethodsFile          string
 	DNSResolver          bool
+	Metrics              bool
+	Tracing              TracingConfig
 	Port                 PortConfig
 	TLS                  TLSConfig
 }
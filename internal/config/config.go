@@ -3,6 +3,7 @@ package config
 import (
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/paularlott/cli"
 	"github.com/paularlott/knot/internal/dns"
@@ -27,12 +28,15 @@ type ServerConfig struct {
 	TunnelDomain              string
 	TunnelServer              string
 	TerminalWebGL             bool
+	TerminalRecording         TerminalRecordingConfig
+	SpaceLogs                 SpaceLogConfig
 	EncryptionKey             string
 	Zone                      string
 	Hostname                  string
 	Timezone                  string
 	LeafNode                  bool
 	AuthIPRateLimiting        bool
+	TrustedProxies            []string
 	DNSEnabled                bool
 	DNSListen                 string
 	Nameservers               []string
@@ -40,16 +44,23 @@ type ServerConfig struct {
 	MCPToolTimeout            int
 	Origin                    OriginConfig
 	TOTP                      TOTPConfig
+	OIDC                      OIDCConfig
+	LDAP                      LDAPConfig
+	SCIM                      SCIMConfig
+	SSHCA                     SSHCAConfig
 	UI                        UIConfig
 	Cluster                   ClusterConfig
 	MySQL                     MySQLConfig
 	BadgerDB                  BadgerDBConfig
 	Redis                     RedisConfig
 	Audit                     AuditConfig
+	Metrics                   MetricsConfig
+	Tracing                   TracingConfig
 	LogOutput                 LogOutputConfig
 	Docker                    DockerConfig
 	Podman                    PodmanConfig
 	Nomad                     NomadConfig
+	Kubernetes                KubernetesConfig
 	TLS                       TLSConfig
 	MCP                       MCPConfig
 	Chat                      ChatConfig
@@ -98,6 +109,75 @@ type TOTPConfig struct {
 	Issuer  string
 }
 
+// OIDCConfig lists the OpenID Connect providers offered on the login page.
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `toml:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	Id            string             `toml:"id"`     // used in the callback URL and as the user's external provider key
+	Name          string             `toml:"name"`   // shown on the login button
+	Issuer        string             `toml:"issuer"` // discovery is read from <issuer>/.well-known/openid-configuration
+	ClientId      string             `toml:"client_id"`
+	ClientSecret  string             `toml:"client_secret"`  // empty for public clients
+	Scopes        []string           `toml:"scopes"`         // requested in addition to openid, defaults to profile and email
+	GroupsClaim   string             `toml:"groups_claim"`   // ID token claim holding the user's IdP groups, defaults to groups
+	AllowSignup   bool               `toml:"allow_signup"`   // create users on their first login
+	LinkByEmail   bool               `toml:"link_by_email"`  // link a first login to the existing user with the same verified email
+	GroupMappings []OIDCGroupMapping `toml:"group_mappings"` // IdP groups mapped to knot groups and roles
+}
+
+// OIDCGroupMapping grants knot groups and roles, by name or ID, to members of
+// an IdP group. Groups and roles named in any mapping are managed by the
+// provider and are added or removed on every login, others are left alone.
+type OIDCGroupMapping struct {
+	Claim  string   `toml:"claim"`
+	Groups []string `toml:"groups"`
+	Roles  []string `toml:"roles"`
+}
+
+// LDAPConfig authenticates users against an LDAP or Active Directory server
+// and periodically syncs their directory groups.
+type LDAPConfig struct {
+	Enabled           bool               `toml:"enabled"`
+	URL               string             `toml:"url"`       // ldaps://host:636, or ldap://host:389 with StartTLS
+	StartTLS          bool               `toml:"start_tls"` // upgrade ldap:// connections, ignored for ldaps://
+	SkipVerify        bool               `toml:"skip_verify"`
+	BindDN            string             `toml:"bind_dn"` // service account used to search for users
+	BindPassword      string             `toml:"bind_password"`
+	BaseDN            string             `toml:"base_dn"`
+	UserFilter        string             `toml:"user_filter"` // {email} is replaced by the escaped login email
+	UsernameAttribute string             `toml:"username_attribute"`
+	EmailAttribute    string             `toml:"email_attribute"`
+	GroupAttribute    string             `toml:"group_attribute"`
+	AllowSignup       bool               `toml:"allow_signup"`  // create users on their first login
+	LinkByEmail       bool               `toml:"link_by_email"` // link a first login to the existing user with the same email
+	SyncInterval      int                `toml:"sync_interval"` // minutes between directory syncs, 0 disables
+	GroupMappings     []LDAPGroupMapping `toml:"group_mappings"`
+}
+
+// LDAPGroupMapping grants knot groups and roles, by name or ID, to members of
+// a directory group given by its DN or CN. As with OIDC the groups and roles
+// named in any mapping are managed by the directory.
+type LDAPGroupMapping struct {
+	Group  string   `toml:"group"`
+	Groups []string `toml:"groups"`
+	Roles  []string `toml:"roles"`
+}
+
+// SCIMConfig enables the SCIM 2.0 provisioning endpoints under /scim/v2.
+type SCIMConfig struct {
+	Enabled bool `toml:"enabled"`
+}
+
+// SSHCAConfig makes the server an SSH certificate authority, spaces trust
+// user certificates it issues and present host certificates it signs.
+type SSHCAConfig struct {
+	Enabled bool
+	KeyFile string // CA private key, derived from the encryption key if not given
+	CertTTL int    // maximum lifetime of user certificates in minutes
+}
+
 type UIConfig struct {
 	HideSupportLinks   bool
 	HideAPITokens      bool
@@ -152,12 +232,36 @@ type RedisConfig struct {
 	KeyPrefix  string
 }
 
+// TerminalRecordingConfig controls the recordings of templates with terminal
+// recording enabled.
+type TerminalRecordingConfig struct {
+	Retention int // days to keep recordings
+	MaxSize   int // maximum size of a recording in MB, later output is dropped
+}
+
+// SpaceLogConfig controls the storage of the logs collected from spaces.
+type SpaceLogConfig struct {
+	Retention int // days to keep space logs, 0 keeps them in memory only
+}
+
 type AuditConfig struct {
 	Retention   int
 	Routing     string // "internal" | "external" | "both"
 	AuditStream string // stream label for external log driver, defaults to "audit"
 }
 
+type MetricsConfig struct {
+	Enabled bool
+	Token   string // bearer token required to scrape
+}
+
+// TracingConfig controls OTLP trace export, shared by the server and agent.
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string  // OTLP/HTTP collector URL, empty for the OTEL_EXPORTER_OTLP_* environment
+	SampleRatio float64 // fraction of new traces to record, traces started by a caller follow its decision
+}
+
 type LogOutputConfig struct {
 	URL      string
 	Format   string
@@ -183,6 +287,21 @@ type NomadConfig struct {
 	Region string // Nomad region, exposed as ${{ .nomad.region }}. Defaults to NOMAD_REGION.
 }
 
+// KubernetesConfig is the connection to the Kubernetes API server used by the
+// kubernetes platform. When Host is empty the in-cluster service account is
+// used.
+type KubernetesConfig struct {
+	Host                 string
+	Token                string
+	CACertFile           string
+	SkipTLSVerify        bool
+	Namespace            string // namespace used when NamespaceMode is "fixed"
+	NamespaceMode        string // "fixed", "user" or "template"
+	NamespacePrefix      string // prefix for the per user / per template namespaces
+	CPUPerComputeUnit    string // cpu request per template compute unit, e.g. 250m
+	MemoryPerComputeUnit string // memory request per template compute unit, e.g. 512Mi
+}
+
 type MCPRemoteServerConfig struct {
 	Namespace      string   `toml:"namespace"`
 	URL            string   `toml:"url"`             // HTTP(S) URL of the remote server (empty for stdio)
@@ -213,6 +332,25 @@ type ChatConfig struct {
 	SystemPrompt     string
 	ReasoningEffort  string
 	UIStyle          string
+	Providers        []ChatProviderConfig // named providers, the provider above is named "default"
+	Models           []ChatModelConfig    // model aliases listed by /v1/models
+}
+
+// ChatProviderConfig is a named LLM provider that model aliases are routed to.
+type ChatProviderConfig struct {
+	Name     string        `toml:"name"`
+	Provider string        `toml:"provider"` // openai, claude, gemini, ollama, zai or mistral
+	APIKey   string        `toml:"api_key"`
+	BaseURL  string        `toml:"base_url"`
+	Timeout  time.Duration `toml:"timeout"` // per request, 0 uses the client default
+}
+
+// ChatModelConfig maps a model alias to a model of a named provider.
+type ChatModelConfig struct {
+	Alias    string `toml:"alias"`
+	Provider string `toml:"provider"` // provider name, defaults to "default"
+	Model    string `toml:"model"`    // model name sent to the provider, defaults to the alias
+	Fallback string `toml:"fallback"` // alias tried when the provider fails with a 5xx error or times out
 }
 
 // Global configuration instance
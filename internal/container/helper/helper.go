@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/paularlott/knot/internal/container"
 	"github.com/paularlott/knot/internal/container/apple"
 	"github.com/paularlott/knot/internal/container/docker"
+	"github.com/paularlott/knot/internal/container/kubernetes"
 	"github.com/paularlott/knot/internal/container/nomad"
 	"github.com/paularlott/knot/internal/container/podman"
 	"github.com/paularlott/knot/internal/container/runtime"
@@ -20,8 +22,10 @@ import (
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/spaceutil"
 	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/tracing"
 
 	"github.com/paularlott/knot/internal/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Helper struct {
@@ -56,6 +60,8 @@ func (h *Helper) createClient(platform string) (container.ContainerManager, erro
 		return client, nil
 	case model.PlatformNomad:
 		return nomad.NewClient()
+	case model.PlatformKubernetes:
+		return kubernetes.NewClient()
 	case model.PlatformApple:
 		client := apple.NewClient()
 		if client == nil {
@@ -144,7 +150,13 @@ func (h *Helper) DeleteVolume(volume *model.Volume) error {
 	return nil
 }
 
-func (h *Helper) StartSpace(space *model.Space, template *model.Template, user *model.User) error {
+func (h *Helper) StartSpace(ctx context.Context, space *model.Space, template *model.Template, user *model.User) (err error) {
+	ctx, span := tracing.Start(ctx, "container.start_space",
+		attribute.String("knot.space_id", space.Id),
+		attribute.String("knot.platform", template.Platform),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	db := database.GetInstance()
 
 	// Mark the space as pending and save it
@@ -191,14 +203,18 @@ func (h *Helper) StartSpace(space *model.Space, template *model.Template, user *
 	}
 
 	// Create volumes
+	_, volumeSpan := tracing.Start(ctx, "container.create_space_volumes")
 	err = containerClient.CreateSpaceVolumes(user, template, space, vars)
+	tracing.End(volumeSpan, err)
 	if err != nil {
 		log.WithError(err).Error("StartSpace")
 		return err
 	}
 
 	// Start the job
+	_, jobSpan := tracing.Start(ctx, "container.create_space_job")
 	err = containerClient.CreateSpaceJob(user, template, space, vars)
+	tracing.End(jobSpan, err)
 	if err != nil {
 		log.WithError(err).Error("StartSpace")
 		return err
@@ -326,7 +342,7 @@ func (h *Helper) RestartSpace(space *model.Space) error {
 
 	if err := containerClient.DeleteSpaceJob(space, func() {
 		// Start the container again
-		h.StartSpace(space, template, user)
+		h.StartSpace(context.Background(), space, template, user)
 	}); err != nil {
 		space.IsPending = false
 		space.UpdatedAt = hlc.Now()
@@ -540,7 +556,7 @@ func (h *Helper) CleanupOnBoot() {
 		if template.Platform == model.PlatformContainer {
 			runtimeKey = runtime.DetectLocalContainerRuntime(cfg.LocalContainerRuntimePref)
 		}
-		if template.Platform == model.PlatformNomad {
+		if template.Platform == model.PlatformNomad || template.Platform == model.PlatformKubernetes {
 			runtimeKey = template.Platform + ":" + spaceutil.NormalizeNomadNamespace(space.NomadNamespace)
 		}
 
@@ -0,0 +1,168 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// resourcePaths maps the object kinds knot manages to their namespaced API
+// collection.
+var resourcePaths = map[string]string{
+	"Pod":                   "/api/v1/namespaces/%s/pods",
+	"Service":               "/api/v1/namespaces/%s/services",
+	"ConfigMap":             "/api/v1/namespaces/%s/configmaps",
+	"Secret":                "/api/v1/namespaces/%s/secrets",
+	"PersistentVolumeClaim": "/api/v1/namespaces/%s/persistentvolumeclaims",
+	"StatefulSet":           "/apis/apps/v1/namespaces/%s/statefulsets",
+}
+
+// spaceObjectKinds are the kinds removed when a space is stopped, volume
+// claims are kept until the space is deleted.
+var spaceObjectKinds = []string{"StatefulSet", "Pod", "Service", "ConfigMap", "Secret"}
+
+type objectList struct {
+	Items []map[string]interface{} `json:"items"`
+}
+
+func resourcePath(kind, namespace string) (string, error) {
+	path, ok := resourcePaths[kind]
+	if !ok {
+		return "", fmt.Errorf("unsupported kind: %s", kind)
+	}
+	return fmt.Sprintf(path, url.PathEscape(namespace)), nil
+}
+
+func (client *KubernetesClient) createObject(ctx context.Context, namespace string, object map[string]interface{}) error {
+	kind, _ := object["kind"].(string)
+	name := objectName(object)
+
+	path, err := resourcePath(kind, namespace)
+	if err != nil {
+		return err
+	}
+
+	code, err := client.httpClient.Post(ctx, path, object, nil, 0)
+	if err == nil {
+		return nil
+	}
+	if code != http.StatusConflict {
+		return err
+	}
+
+	// The object survived an earlier run so replace it, pods can't be replaced
+	// and must be gone before the space starts again
+	if kind == "Pod" {
+		return fmt.Errorf("pod %s/%s already exists", namespace, name)
+	}
+
+	var existing map[string]interface{}
+	code, err = client.httpClient.Get(ctx, path+"/"+url.PathEscape(name), &existing)
+	if err != nil {
+		return err
+	}
+	if code != http.StatusOK {
+		return fmt.Errorf("failed to read %s %s/%s: %d", kind, namespace, name, code)
+	}
+
+	if metadata, ok := existing["metadata"].(map[string]interface{}); ok {
+		mapValue(object, "metadata")["resourceVersion"] = metadata["resourceVersion"]
+	}
+
+	_, err = client.httpClient.Put(ctx, path+"/"+url.PathEscape(name), object, nil, http.StatusOK)
+	return err
+}
+
+// deleteObject removes an object along with its dependents, a missing object
+// is not an error.
+func (client *KubernetesClient) deleteObject(ctx context.Context, kind, namespace, name string) error {
+	path, err := resourcePath(kind, namespace)
+	if err != nil {
+		return err
+	}
+
+	code, err := client.httpClient.Delete(
+		ctx,
+		path+"/"+url.PathEscape(name),
+		map[string]interface{}{
+			"kind":              "DeleteOptions",
+			"apiVersion":        "v1",
+			"propagationPolicy": "Background",
+		},
+		nil,
+		0,
+	)
+	if err != nil && code == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (client *KubernetesClient) listObjects(ctx context.Context, kind, namespace string, labels map[string]string) ([]map[string]interface{}, error) {
+	path, err := resourcePath(kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(labels) > 0 {
+		path += "?labelSelector=" + url.QueryEscape(labelSelector(labels))
+	}
+
+	var response objectList
+	code, err := client.httpClient.Get(ctx, path, &response)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("failed to list %s in %s: %d", kind, namespace, code)
+	}
+
+	return response.Items, nil
+}
+
+// ensureNamespace creates the namespace if it doesn't already exist.
+func (client *KubernetesClient) ensureNamespace(ctx context.Context, namespace string) error {
+	var response map[string]interface{}
+	code, err := client.httpClient.Get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace), &response)
+	if err == nil && code == http.StatusOK {
+		return nil
+	}
+
+	client.logger.Info("creating namespace", "namespace", namespace)
+
+	code, err = client.httpClient.Post(
+		ctx,
+		"/api/v1/namespaces",
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name":   namespace,
+				"labels": map[string]interface{}{LabelManagedBy: managedByValue},
+			},
+		},
+		nil,
+		0,
+	)
+	if err != nil && code != http.StatusConflict {
+		return err
+	}
+
+	return nil
+}
+
+func labelSelector(labels map[string]string) string {
+	selector := make([]string, 0, len(labels))
+	for key, value := range labels {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ",")
+}
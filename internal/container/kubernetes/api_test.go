@@ -0,0 +1,276 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+// fakeAPIServer is a minimal in-memory Kubernetes API server, objects are
+// keyed by collection path and name.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	objects map[string]map[string]map[string]interface{}
+	version int
+}
+
+func newFakeAPIServer(t *testing.T) (*fakeAPIServer, *KubernetesClient) {
+	t.Helper()
+
+	fake := &fakeAPIServer{objects: make(map[string]map[string]map[string]interface{})}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client, err := newClient(config.KubernetesConfig{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return fake, client
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Split the path into the collection and optional object name
+	collection, name := r.URL.Path, ""
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if (parts[0] == "api" && len(parts)%2 == 0) || (parts[0] == "apis" && len(parts)%2 == 1) {
+		name = parts[len(parts)-1]
+		collection = "/" + strings.Join(parts[:len(parts)-1], "/")
+	}
+
+	objects := f.objects[collection]
+	if objects == nil {
+		objects = make(map[string]map[string]interface{})
+		f.objects[collection] = objects
+	}
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		selector := r.URL.Query().Get("labelSelector")
+		items := []map[string]interface{}{}
+		for _, object := range objects {
+			if matchesSelector(object, selector) {
+				items = append(items, object)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+
+	case r.Method == http.MethodGet:
+		if object, ok := objects[name]; ok {
+			writeJSON(w, http.StatusOK, object)
+		} else {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"kind": "Status"})
+		}
+
+	case r.Method == http.MethodPost || r.Method == http.MethodPut:
+		var object map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+			writeJSON(w, http.StatusBadRequest, nil)
+			return
+		}
+		name := objectName(object)
+		if _, exists := objects[name]; exists && r.Method == http.MethodPost {
+			writeJSON(w, http.StatusConflict, map[string]interface{}{"kind": "Status"})
+			return
+		}
+		f.version++
+		mapValue(object, "metadata")["resourceVersion"] = f.version
+		objects[name] = object
+		code := http.StatusCreated
+		if r.Method == http.MethodPut {
+			code = http.StatusOK
+		}
+		writeJSON(w, code, object)
+
+	case r.Method == http.MethodDelete:
+		if _, ok := objects[name]; !ok {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"kind": "Status"})
+			return
+		}
+		delete(objects, name)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "Status"})
+	}
+}
+
+func (f *fakeAPIServer) get(collection, name string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects[collection][name]
+}
+
+func (f *fakeAPIServer) put(collection string, object map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects[collection] == nil {
+		f.objects[collection] = make(map[string]map[string]interface{})
+	}
+	f.objects[collection][objectName(object)] = object
+}
+
+func matchesSelector(object map[string]interface{}, selector string) bool {
+	if selector == "" {
+		return true
+	}
+	labels := mapValue(mapValue(object, "metadata"), "labels")
+	for _, term := range strings.Split(selector, ",") {
+		key, value, _ := strings.Cut(term, "=")
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestApplyAndDeleteManifest(t *testing.T) {
+	fake, client := newFakeAPIServer(t)
+	ctx := context.Background()
+
+	m, err := parseManifest(testManifest)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	m.prepare("space-1", "knot-alice")
+
+	if err := client.applyManifest(ctx, m); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if fake.get("/apis/apps/v1/namespaces/knot-alice/statefulsets", "dev") == nil {
+		t.Fatal("statefulset not created")
+	}
+	if fake.get("/api/v1/namespaces/knot-alice/services", "dev-web") == nil {
+		t.Fatal("service not created")
+	}
+
+	// Applying again replaces the existing objects
+	if err := client.applyManifest(ctx, m); err != nil {
+		t.Fatalf("second apply failed: %v", err)
+	}
+
+	// A claim belonging to the space must survive deleting its objects
+	fake.put("/api/v1/namespaces/knot-alice/persistentvolumeclaims", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "home", "labels": map[string]interface{}{LabelSpaceId: "space-1"}},
+	})
+
+	if err := client.deleteSpaceObjects(ctx, "knot-alice", "space-1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if fake.get("/apis/apps/v1/namespaces/knot-alice/statefulsets", "dev") != nil {
+		t.Error("statefulset not deleted")
+	}
+	if fake.get("/api/v1/namespaces/knot-alice/services", "dev-web") != nil {
+		t.Error("service not deleted")
+	}
+	if fake.get("/api/v1/namespaces/knot-alice/persistentvolumeclaims", "home") == nil {
+		t.Error("volume claim should be kept")
+	}
+
+	// Deleting a missing object isn't an error
+	if err := client.deleteObject(ctx, "Pod", "knot-alice", "missing"); err != nil {
+		t.Errorf("unexpected error deleting missing object: %v", err)
+	}
+}
+
+func TestCreateObject_existingPod(t *testing.T) {
+	_, client := newFakeAPIServer(t)
+	ctx := context.Background()
+
+	pod := map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "dev"}}
+	if err := client.createObject(ctx, "default", pod); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if err := client.createObject(ctx, "default", pod); err == nil {
+		t.Error("expected an error when the pod already exists")
+	}
+}
+
+func TestSpaceState(t *testing.T) {
+	fake, client := newFakeAPIServer(t)
+	ctx := context.Background()
+
+	pod := func(name, spaceId, phase string) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": map[string]interface{}{LabelSpaceId: spaceId, LabelManagedBy: managedByValue},
+			},
+			"status": map[string]interface{}{"phase": phase},
+		}
+	}
+	fake.put("/api/v1/namespaces/default/pods", pod("a", "space-a", "Running"))
+	fake.put("/api/v1/namespaces/default/pods", pod("b", "space-b", "Pending"))
+	fake.put("/api/v1/namespaces/default/pods", pod("c", "space-c", "Succeeded"))
+
+	tests := []struct {
+		spaceId         string
+		running, exists bool
+	}{
+		{"space-a", true, true},
+		{"space-b", false, true},
+		{"space-c", false, false},
+		{"space-d", false, false},
+	}
+	for _, tt := range tests {
+		running, exists, err := client.spaceState(ctx, "default", tt.spaceId)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.spaceId, err)
+		}
+		if running != tt.running || exists != tt.exists {
+			t.Errorf("%s: got running=%v exists=%v, want running=%v exists=%v", tt.spaceId, running, exists, tt.running, tt.exists)
+		}
+	}
+
+	refs, err := client.ListRunningSpaceRuntimeRefs([]string{"", "default"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(refs) != 1 || !refs["default\x00space-a"] {
+		t.Errorf("unexpected runtime refs: %v", refs)
+	}
+}
+
+func TestEnsureNamespaceAndVolumeClaim(t *testing.T) {
+	fake, client := newFakeAPIServer(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := client.ensureNamespace(ctx, "knot-alice"); err != nil {
+			t.Fatalf("ensure namespace failed: %v", err)
+		}
+	}
+	if fake.get("/api/v1/namespaces", "knot-alice") == nil {
+		t.Fatal("namespace not created")
+	}
+
+	volume := &model.KubernetesVolume{Name: "home", Namespace: "knot-alice", Size: "10Gi", StorageClass: "fast", AccessModes: []string{"ReadWriteOnce"}}
+	for i := 0; i < 2; i++ {
+		if err := client.createVolumeClaim(ctx, volume, "space-1"); err != nil {
+			t.Fatalf("create claim failed: %v", err)
+		}
+	}
+
+	claim := fake.get("/api/v1/namespaces/knot-alice/persistentvolumeclaims", "home")
+	if claim == nil {
+		t.Fatal("claim not created")
+	}
+	if mapValue(claim, "spec")["storageClassName"] != "fast" {
+		t.Errorf("unexpected claim spec: %v", claim["spec"])
+	}
+	if mapValue(mapValue(claim, "metadata"), "labels")[LabelSpaceId] != "space-1" {
+		t.Errorf("claim missing space label: %v", claim["metadata"])
+	}
+}
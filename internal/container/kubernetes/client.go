@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/util/rest"
+	"github.com/paularlott/logger"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// Labels added to every object created for a space
+	LabelSpaceId   = "knot.space-id"
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	managedByValue = "knot"
+
+	NamespaceModeFixed    = "fixed"
+	NamespaceModeUser     = "user"
+	NamespaceModeTemplate = "template"
+)
+
+type KubernetesClient struct {
+	httpClient rest.RESTClient
+	logger     logger.Logger
+	cfg        config.KubernetesConfig
+}
+
+func NewClient() (*KubernetesClient, error) {
+	return newClient(config.GetServerConfig().Kubernetes)
+}
+
+func newClient(cfg config.KubernetesConfig) (*KubernetesClient, error) {
+	host := cfg.Host
+	token := cfg.Token
+	caCertFile := cfg.CACertFile
+
+	// Without a host use the service account of the pod knot is running in
+	if host == "" {
+		serviceHost := os.Getenv("KUBERNETES_SERVICE_HOST")
+		if serviceHost == "" {
+			return nil, fmt.Errorf("kubernetes host not configured and not running in a cluster")
+		}
+		host = "https://" + net.JoinHostPort(serviceHost, os.Getenv("KUBERNETES_SERVICE_PORT"))
+
+		if token == "" {
+			data, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read service account token: %w", err)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if caCertFile == "" {
+			caCertFile = filepath.Join(serviceAccountDir, "ca.crt")
+		}
+	}
+
+	hc, err := rest.NewClient(host, token, cfg.SkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	if caCertFile != "" && !cfg.SkipTLSVerify {
+		data, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA certificate: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caCertFile)
+		}
+		hc.HTTPClient.Transport.(*http.Transport).TLSClientConfig.RootCAs = pool
+	}
+
+	hc.SetAccept(rest.ContentTypeJSON)
+
+	if cfg.Namespace == "" {
+		cfg.Namespace = "default"
+	}
+
+	return &KubernetesClient{
+		httpClient: hc,
+		logger:     log.WithGroup("kubernetes"),
+		cfg:        cfg,
+	}, nil
+}
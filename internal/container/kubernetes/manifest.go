@@ -0,0 +1,299 @@
+package kubernetes
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paularlott/knot/internal/container"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workloadKinds are the kinds that run the space, a manifest has exactly one.
+var workloadKinds = map[string]bool{"Pod": true, "StatefulSet": true}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// manifest is a parsed space manifest, one or more YAML documents holding the
+// workload and any supporting objects.
+type manifest struct {
+	objects  []map[string]interface{}
+	workload map[string]interface{}
+}
+
+// ValidateManifest checks that data is a manifest knot can deploy.
+func ValidateManifest(data string) error {
+	_, err := parseManifest(data)
+	return err
+}
+
+func parseManifest(data string) (*manifest, error) {
+	m := &manifest{}
+
+	decoder := yaml.NewDecoder(strings.NewReader(data))
+	for {
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(object) == 0 {
+			continue
+		}
+
+		kind, _ := object["kind"].(string)
+		if !isSpaceObjectKind(kind) {
+			return nil, fmt.Errorf("unsupported kind %q, expected one of %s", kind, strings.Join(spaceObjectKinds, ", "))
+		}
+		if objectName(object) == "" {
+			return nil, fmt.Errorf("%s must set metadata.name", kind)
+		}
+
+		if workloadKinds[kind] {
+			if m.workload != nil {
+				return nil, fmt.Errorf("manifest must contain exactly one Pod or StatefulSet")
+			}
+			m.workload = object
+		}
+		m.objects = append(m.objects, object)
+	}
+
+	if m.workload == nil {
+		return nil, fmt.Errorf("manifest must contain a Pod or StatefulSet")
+	}
+	if len(m.containers()) == 0 {
+		return nil, fmt.Errorf("%s must define at least one container", objectName(m.workload))
+	}
+
+	return m, nil
+}
+
+// namespace returns the namespace set on the workload, empty if not set.
+func (m *manifest) namespace() string {
+	namespace, _ := mapValue(m.workload, "metadata")["namespace"].(string)
+	return namespace
+}
+
+// podSpec returns the pod spec of the workload.
+func (m *manifest) podSpec() map[string]interface{} {
+	if m.workload["kind"] == "StatefulSet" {
+		return mapValue(mapValue(mapValue(m.workload, "spec"), "template"), "spec")
+	}
+	return mapValue(m.workload, "spec")
+}
+
+func (m *manifest) containers() []map[string]interface{} {
+	items, _ := m.podSpec()["containers"].([]interface{})
+
+	containers := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if c, ok := item.(map[string]interface{}); ok {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+// prepare places every object in the namespace and labels it with the space
+// so it can be found again when the space is stopped.
+func (m *manifest) prepare(spaceId, namespace string) {
+	labels := map[string]interface{}{
+		LabelSpaceId:   spaceId,
+		LabelManagedBy: managedByValue,
+	}
+
+	for _, object := range m.objects {
+		metadata := mapValue(object, "metadata")
+		metadata["namespace"] = namespace
+		mergeLabels(metadata, labels)
+	}
+
+	// Pods created by a StatefulSet need the labels too
+	if m.workload["kind"] == "StatefulSet" {
+		mergeLabels(mapValue(mapValue(mapValue(m.workload, "spec"), "template"), "metadata"), labels)
+	}
+}
+
+// injectEnv sets the KEY=value entries on every container, replacing any
+// variable of the same name.
+func (m *manifest) injectEnv(env []string) {
+	for _, c := range m.containers() {
+		items, _ := c["env"].([]interface{})
+
+		for _, ev := range container.ParseEnvStrings(env) {
+			replaced := false
+			for _, item := range items {
+				if existing, ok := item.(map[string]interface{}); ok && existing["name"] == ev.Key {
+					existing["value"] = ev.Value
+					delete(existing, "valueFrom")
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				items = append(items, map[string]interface{}{"name": ev.Key, "value": ev.Value})
+			}
+		}
+
+		c["env"] = items
+	}
+}
+
+// injectDNS points the pod at the given nameservers only, keeping any search
+// domains or options from the template.
+func (m *manifest) injectDNS(servers []string) {
+	if len(servers) == 0 {
+		return
+	}
+
+	spec := m.podSpec()
+	spec["dnsPolicy"] = "None"
+
+	nameservers := make([]interface{}, len(servers))
+	for i, server := range servers {
+		nameservers[i] = server
+	}
+	mapValue(spec, "dnsConfig")["nameservers"] = nameservers
+}
+
+// applyRequests sets the resource requests on the first container, requests
+// already given by the template are left alone.
+func (m *manifest) applyRequests(requests map[string]string) {
+	if len(requests) == 0 {
+		return
+	}
+
+	resources := mapValue(mapValue(m.containers()[0], "resources"), "requests")
+	for name, value := range requests {
+		if _, ok := resources[name]; !ok {
+			resources[name] = value
+		}
+	}
+}
+
+// computeUnitRequests converts the template compute units to cpu and memory
+// requests using the per unit sizes from the server config.
+func computeUnitRequests(units uint32, cpuPerUnit, memoryPerUnit string) (map[string]string, error) {
+	requests := make(map[string]string)
+	if units == 0 {
+		return requests, nil
+	}
+
+	if cpuPerUnit != "" {
+		millis, err := parseCPUMillis(cpuPerUnit)
+		if err != nil {
+			return nil, err
+		}
+		requests["cpu"] = fmt.Sprintf("%dm", millis*int64(units))
+	}
+
+	if memoryPerUnit != "" {
+		bytes, err := parseMemoryBytes(memoryPerUnit)
+		if err != nil {
+			return nil, err
+		}
+		total := bytes * int64(units)
+		if total%(1<<20) == 0 {
+			requests["memory"] = fmt.Sprintf("%dMi", total/(1<<20))
+		} else {
+			requests["memory"] = strconv.FormatInt(total, 10)
+		}
+	}
+
+	return requests, nil
+}
+
+// parseCPUMillis parses a Kubernetes cpu quantity, e.g. 250m or 1.5.
+func parseCPUMillis(quantity string) (int64, error) {
+	quantity = strings.TrimSpace(quantity)
+	if value, ok := strings.CutSuffix(quantity, "m"); ok {
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || millis <= 0 {
+			return 0, fmt.Errorf("invalid cpu quantity: %s", quantity)
+		}
+		return millis, nil
+	}
+
+	cores, err := strconv.ParseFloat(quantity, 64)
+	if err != nil || cores <= 0 {
+		return 0, fmt.Errorf("invalid cpu quantity: %s", quantity)
+	}
+	return int64(math.Round(cores * 1000)), nil
+}
+
+var memorySuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1000}, {"M", 1000 * 1000}, {"G", 1000 * 1000 * 1000}, {"T", 1000 * 1000 * 1000 * 1000},
+}
+
+// parseMemoryBytes parses a Kubernetes memory quantity, e.g. 512Mi or 1G.
+func parseMemoryBytes(quantity string) (int64, error) {
+	quantity = strings.TrimSpace(quantity)
+
+	multiplier := int64(1)
+	value := quantity
+	for _, s := range memorySuffixes {
+		if v, ok := strings.CutSuffix(quantity, s.suffix); ok {
+			value = v
+			multiplier = s.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory quantity: %s", quantity)
+	}
+	return n * multiplier, nil
+}
+
+// namespaceName turns name into a valid namespace name.
+func namespaceName(prefix, name string) string {
+	ns := invalidNameChars.ReplaceAllString(strings.ToLower(prefix+name), "-")
+	if len(ns) > 63 {
+		ns = ns[:63]
+	}
+	return strings.Trim(ns, "-")
+}
+
+func isSpaceObjectKind(kind string) bool {
+	for _, k := range spaceObjectKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func objectName(object map[string]interface{}) string {
+	name, _ := mapValue(object, "metadata")["name"].(string)
+	return name
+}
+
+// mapValue returns the map held under key, creating it if missing.
+func mapValue(m map[string]interface{}, key string) map[string]interface{} {
+	if value, ok := m[key].(map[string]interface{}); ok {
+		return value
+	}
+
+	value := make(map[string]interface{})
+	m[key] = value
+	return value
+}
+
+func mergeLabels(metadata map[string]interface{}, labels map[string]interface{}) {
+	existing := mapValue(metadata, "labels")
+	for key, value := range labels {
+		existing[key] = value
+	}
+}
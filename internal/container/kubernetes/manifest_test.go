@@ -0,0 +1,187 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+const testManifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: dev-web
+spec:
+  ports:
+    - port: 80
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: dev
+spec:
+  template:
+    spec:
+      containers:
+        - name: space
+          image: ubuntu:24.04
+          env:
+            - name: KNOT_USER
+              value: old
+          resources:
+            requests:
+              memory: 2Gi
+`
+
+func TestParseManifest(t *testing.T) {
+	m, err := parseManifest(testManifest)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(m.objects) != 2 {
+		t.Errorf("expected 2 objects, got %d", len(m.objects))
+	}
+	if objectName(m.workload) != "dev" {
+		t.Errorf("expected workload dev, got %q", objectName(m.workload))
+	}
+	if len(m.containers()) != 1 {
+		t.Errorf("expected 1 container, got %d", len(m.containers()))
+	}
+}
+
+func TestParseManifest_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"no workload", "kind: Service\nmetadata:\n  name: web\n", "must contain a Pod or StatefulSet"},
+		{"two workloads", "kind: Pod\nmetadata:\n  name: a\nspec:\n  containers:\n    - name: a\n---\nkind: Pod\nmetadata:\n  name: b\nspec:\n  containers:\n    - name: b\n", "exactly one"},
+		{"unsupported kind", "kind: Deployment\nmetadata:\n  name: web\n", "unsupported kind"},
+		{"missing name", "kind: Pod\nspec:\n  containers:\n    - name: a\n", "metadata.name"},
+		{"no containers", "kind: Pod\nmetadata:\n  name: a\nspec: {}\n", "at least one container"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManifest(tt.manifest)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestManifestPrepare(t *testing.T) {
+	m, err := parseManifest(testManifest)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	m.prepare("space-1", "knot-alice")
+	m.injectEnv([]string{"KNOT_USER=alice", "KNOT_SPACEID=space-1"})
+	m.injectDNS([]string{"10.0.0.1"})
+	m.applyRequests(map[string]string{"cpu": "500m", "memory": "1Gi"})
+
+	for _, object := range m.objects {
+		metadata := mapValue(object, "metadata")
+		if metadata["namespace"] != "knot-alice" {
+			t.Errorf("%s: expected namespace knot-alice, got %v", objectName(object), metadata["namespace"])
+		}
+		if mapValue(metadata, "labels")[LabelSpaceId] != "space-1" {
+			t.Errorf("%s: missing space label", objectName(object))
+		}
+	}
+
+	template := mapValue(mapValue(m.workload, "spec"), "template")
+	if mapValue(mapValue(template, "metadata"), "labels")[LabelSpaceId] != "space-1" {
+		t.Error("pod template missing space label")
+	}
+
+	env, _ := m.containers()[0]["env"].([]interface{})
+	if len(env) != 2 {
+		t.Fatalf("expected 2 env vars, got %v", env)
+	}
+	if env[0].(map[string]interface{})["value"] != "alice" {
+		t.Errorf("expected KNOT_USER to be replaced, got %v", env[0])
+	}
+
+	if m.podSpec()["dnsPolicy"] != "None" {
+		t.Error("expected dnsPolicy None")
+	}
+
+	requests := mapValue(mapValue(m.containers()[0], "resources"), "requests")
+	if requests["cpu"] != "500m" || requests["memory"] != "2Gi" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
+
+func TestComputeUnitRequests(t *testing.T) {
+	requests, err := computeUnitRequests(4, "250m", "512Mi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests["cpu"] != "1000m" || requests["memory"] != "2048Mi" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	requests, err = computeUnitRequests(2, "0.5", "1G")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests["cpu"] != "1000m" || requests["memory"] != "2000000000" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	if requests, _ := computeUnitRequests(0, "250m", "512Mi"); len(requests) != 0 {
+		t.Errorf("expected no requests for zero units, got %v", requests)
+	}
+
+	if _, err := computeUnitRequests(1, "lots", ""); err == nil {
+		t.Error("expected error for invalid cpu quantity")
+	}
+}
+
+func TestNamespaceName(t *testing.T) {
+	tests := []struct {
+		prefix, name, want string
+	}{
+		{"knot-", "alice", "knot-alice"},
+		{"knot-", "Alice.Smith", "knot-alice-smith"},
+		{"", "-dev_env-", "dev-env"},
+		{"knot-", strings.Repeat("a", 70), "knot-" + strings.Repeat("a", 58)},
+	}
+
+	for _, tt := range tests {
+		if got := namespaceName(tt.prefix, tt.name); got != tt.want {
+			t.Errorf("namespaceName(%q, %q) = %q, want %q", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpaceNamespace(t *testing.T) {
+	user := &model.User{Id: "0190c2a4-7b7e-7c6e-9f0a-1b2c3d4e5f60", Username: "bob.smith"}
+	template := &model.Template{Id: "0190c2a4-7b7e-7c6e-9f0a-1b2c3d4e5f61", Name: "Dev"}
+	client := &KubernetesClient{cfg: config.KubernetesConfig{Namespace: "default", NamespaceMode: NamespaceModeUser, NamespacePrefix: "knot-"}}
+
+	if got := client.spaceNamespace(user, template, &model.Space{}); got != "knot-"+user.Id {
+		t.Errorf("expected the user ID namespace, got %q", got)
+	}
+
+	client.cfg.NamespaceMode = NamespaceModeTemplate
+	if got := client.spaceNamespace(user, template, &model.Space{}); got != "knot-"+template.Id {
+		t.Errorf("expected the template ID namespace, got %q", got)
+	}
+
+	// Spaces keep the namespace of their volume claims
+	space := &model.Space{VolumeData: map[string]model.SpaceVolume{"home": {Id: "home", Namespace: "knot-bob-smith", Type: volumeTypePVC}}}
+	if got := client.spaceNamespace(user, template, space); got != "knot-bob-smith" {
+		t.Errorf("expected the namespace of the volume claims, got %q", got)
+	}
+
+	space.NomadNamespace = "knot-deployed"
+	if got := client.spaceNamespace(user, template, space); got != "knot-deployed" {
+		t.Errorf("expected the deployed namespace, got %q", got)
+	}
+}
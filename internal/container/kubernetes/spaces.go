@@ -0,0 +1,508 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/container"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+)
+
+const spaceMonitorTimeout = 30 * time.Minute
+
+// volumeDeleteWaitTimeout is the maximum time to wait for the pods of a space
+// to terminate before deleting its volume claims, claims in use by a pod are
+// not removed until the pod has gone.
+const volumeDeleteWaitTimeout = 5 * time.Minute
+
+func (client *KubernetesClient) CreateSpaceVolumes(user *model.User, template *model.Template, space *model.Space, variables map[string]interface{}) error {
+	db := database.GetInstance()
+
+	volumes, err := model.LoadKubernetesVolumesFromYaml(template.Volumes, template, space, user, variables)
+	if err != nil {
+		return err
+	}
+
+	if len(volumes.Volumes) == 0 && len(space.VolumeData) == 0 {
+		client.logger.Debug("no volumes to create")
+		return nil
+	}
+
+	// Store initial volume data to detect changes
+	initialVolumeData := make(map[string]model.SpaceVolume)
+	for k, v := range space.VolumeData {
+		initialVolumeData[k] = v
+	}
+
+	defer func() {
+		// Only save and publish if volumes actually changed
+		volumesChanged := len(initialVolumeData) != len(space.VolumeData)
+		if !volumesChanged {
+			for k, v := range space.VolumeData {
+				if initialV, ok := initialVolumeData[k]; !ok || v != initialV {
+					volumesChanged = true
+					break
+				}
+			}
+		}
+
+		if volumesChanged {
+			space.UpdatedAt = hlc.Now()
+			if err := db.SaveSpace(space, []string{"VolumeData", "UpdatedAt"}); err != nil {
+				client.logger.Error("saving space error", "space_id", space.Id)
+			}
+			if transport := service.GetTransport(); transport != nil {
+				transport.GossipSpace(space)
+			}
+			sse.PublishSpaceChanged(space.Id, space.UserId)
+		}
+	}()
+
+	client.logger.Debug("checking for required volumes")
+
+	ctx := context.Background()
+	namespace := client.spaceNamespace(user, template, space)
+	required := make(map[string]bool)
+
+	for _, volume := range volumes.Volumes {
+		required[volume.Name] = true
+
+		// Existing claims stay in the namespace they were created in as moving
+		// them would lose the data
+		if data, ok := space.VolumeData[volume.Name]; ok {
+			if volume.Namespace != "" && volume.Namespace != data.Namespace {
+				client.logger.Warn("keeping volume in its original namespace", "volume_id", volume.Name, "namespace", data.Namespace)
+			}
+			continue
+		}
+
+		if volume.Namespace == "" {
+			volume.Namespace = namespace
+			if err := client.prepareNamespace(ctx, namespace); err != nil {
+				return err
+			}
+		}
+
+		if err := client.createVolumeClaim(ctx, &volume, space.Id); err != nil {
+			return err
+		}
+
+		space.VolumeData[volume.Name] = model.SpaceVolume{
+			Id:        volume.Name,
+			Namespace: volume.Namespace,
+			Type:      volumeTypePVC,
+		}
+	}
+
+	// Find the claims deployed in the space but no longer in the template definition and remove them
+	var cleanupErr error
+	for key, volume := range space.VolumeData {
+		if required[volume.Id] {
+			continue
+		}
+
+		if err := client.deleteObject(ctx, "PersistentVolumeClaim", volume.Namespace, volume.Id); err != nil {
+			client.logger.WithError(err).Error("deleting volume", "volume_id", volume.Id)
+			if cleanupErr == nil {
+				cleanupErr = err
+			}
+			continue
+		}
+
+		delete(space.VolumeData, key)
+	}
+
+	client.logger.Debug("volumes checked")
+
+	return cleanupErr
+}
+
+func (client *KubernetesClient) DeleteSpaceVolumes(space *model.Space) error {
+	db := database.GetInstance()
+
+	client.logger.Debug("deleting volumes")
+
+	if len(space.VolumeData) == 0 {
+		client.logger.Debug("no volumes to delete")
+		return nil
+	}
+
+	if space.NomadNamespace != "" {
+		client.waitForSpaceStopped(space)
+	}
+
+	defer func() {
+		space.UpdatedAt = hlc.Now()
+		db.SaveSpace(space, []string{"VolumeData", "UpdatedAt"})
+		if transport := service.GetTransport(); transport != nil {
+			transport.GossipSpace(space)
+		}
+		sse.PublishSpaceChanged(space.Id, space.UserId)
+	}()
+
+	// Delete all claims, continuing past errors so that one failure doesn't
+	// leave the rest behind; failed claims stay in VolumeData to be retried.
+	var firstErr error
+	for key, volume := range space.VolumeData {
+		if err := client.deleteObject(context.Background(), "PersistentVolumeClaim", volume.Namespace, volume.Id); err != nil {
+			client.logger.WithError(err).Error("deleting volume", "volume_id", volume.Id)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		delete(space.VolumeData, key)
+	}
+
+	client.logger.Debug("volumes deleted")
+
+	return firstErr
+}
+
+func (client *KubernetesClient) CreateSpaceJob(user *model.User, template *model.Template, space *model.Space, variables map[string]interface{}) error {
+	db := database.GetInstance()
+	cfg := config.GetServerConfig()
+	ctx := context.Background()
+
+	client.logger.Debug("creating space job", "space_id", space.Id)
+
+	manifestYAML, err := model.ResolveVariables(template.Job, template, space, user, variables)
+	if err != nil {
+		return err
+	}
+
+	m, err := parseManifest(manifestYAML)
+	if err != nil {
+		client.logger.WithError(err).Error("creating space job, parse error", "space_id", space.Id)
+		return err
+	}
+
+	// Inject port env vars from the template
+	m.injectEnv(container.BuildPortEnvVars(template))
+
+	// When agent DNS is enabled point the pod at the agent resolver
+	agentDNS, err := container.BuildAgentDNSInjection()
+	if err != nil {
+		return err
+	}
+	m.injectEnv(agentDNS.Env)
+	m.injectDNS(agentDNS.DNS)
+
+	requests, err := computeUnitRequests(template.ComputeUnits, client.cfg.CPUPerComputeUnit, client.cfg.MemoryPerComputeUnit)
+	if err != nil {
+		return err
+	}
+	m.applyRequests(requests)
+
+	// A namespace in the manifest wins over the configured namespace mode
+	namespace := m.namespace()
+	if namespace == "" {
+		namespace = client.spaceNamespace(user, template, space)
+		if err := client.prepareNamespace(ctx, namespace); err != nil {
+			return err
+		}
+	}
+	m.prepare(space.Id, namespace)
+
+	space.NomadNamespace = namespace
+	space.ContainerId = objectName(m.workload)
+
+	if err := client.applyManifest(ctx, m); err != nil {
+		client.logger.WithError(err).Error("creating space job", "space_id", space.Id)
+		return err
+	}
+
+	// Record deploying
+	space.IsPending = true
+	space.IsDeployed = false
+	space.IsDeleting = false
+	space.TemplateHash = template.Hash
+	space.Zone = cfg.Zone
+	space.StartedAt = time.Now().UTC()
+	space.UpdatedAt = hlc.Now()
+	err = db.SaveSpace(space, []string{"NomadNamespace", "ContainerId", "IsPending", "IsDeployed", "IsDeleting", "TemplateHash", "Zone", "UpdatedAt", "StartedAt"})
+	if err != nil {
+		client.logger.Error("creating space job error", "space_id", space.Id)
+		return err
+	}
+
+	if transport := service.GetTransport(); transport != nil {
+		transport.GossipSpace(space)
+	}
+	sse.PublishSpaceChanged(space.Id, space.UserId)
+	client.MonitorSpaceState(space, nil)
+
+	return nil
+}
+
+func (client *KubernetesClient) DeleteSpaceJob(space *model.Space, onStopped func()) error {
+	client.logger.Debug("deleting space job", "space_id", space.Id, "space", space.ContainerId)
+
+	if err := client.deleteSpaceObjects(context.Background(), space.NomadNamespace, space.Id); err != nil {
+		client.logger.WithError(err).Debug("deleting space job error", "space_id", space.Id)
+		return err
+	}
+
+	// Record stopping
+	space.IsPending = true
+	space.UpdatedAt = hlc.Now()
+
+	db := database.GetInstance()
+	if err := db.SaveSpace(space, []string{"IsPending", "UpdatedAt"}); err != nil {
+		client.logger.Debug("deleting space job error", "space_id", space.Id)
+		return err
+	}
+
+	if transport := service.GetTransport(); transport != nil {
+		transport.GossipSpace(space)
+	}
+	sse.PublishSpaceChanged(space.Id, space.UserId)
+	client.MonitorSpaceState(space, onStopped)
+
+	return nil
+}
+
+// CleanupSpaceArtifacts is a no-op, Kubernetes spaces keep no state on the
+// knot nodes.
+func (client *KubernetesClient) CleanupSpaceArtifacts(space *model.Space) error {
+	return nil
+}
+
+func (client *KubernetesClient) StopSpaceRuntime(space *model.Space) error {
+	if space.NomadNamespace == "" {
+		return nil
+	}
+
+	return client.deleteSpaceObjects(context.Background(), space.NomadNamespace, space.Id)
+}
+
+// ListRunningSpaceRuntimeRefs returns the spaces with a running pod, keyed by
+// namespace and space ID.
+func (client *KubernetesClient) ListRunningSpaceRuntimeRefs(namespaces []string) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	seenNamespaces := make(map[string]bool)
+
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			namespace = client.cfg.Namespace
+		}
+		if seenNamespaces[namespace] {
+			continue
+		}
+		seenNamespaces[namespace] = true
+
+		pods, err := client.listObjects(context.Background(), "Pod", namespace, map[string]string{LabelManagedBy: managedByValue})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range pods {
+			spaceId, _ := mapValue(mapValue(pod, "metadata"), "labels")[LabelSpaceId].(string)
+			if spaceId != "" && podRunning(pod) {
+				refs[namespace+"\x00"+spaceId] = true
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+func (client *KubernetesClient) MonitorSpaceState(space *model.Space, onDone func()) {
+	go func() {
+		client.logger.Info("watching space status for change", "space", space.ContainerId, "namespace", space.NomadNamespace)
+
+		// Startup can include large image pulls, so keep watching for a while
+		ctx, cancel := context.WithTimeout(context.Background(), spaceMonitorTimeout)
+		defer cancel()
+		oldSpace := *space
+
+		for {
+			select {
+			case <-ctx.Done():
+				client.logger.Warn("space monitoring cancelled due to timeout", "space_id", space.Id, "timeout", spaceMonitorTimeout)
+				return
+			default:
+			}
+
+			running, exists, err := client.spaceState(ctx, space.NomadNamespace, space.Id)
+			if err != nil {
+				client.logger.WithError(err).Error("reading space state error", "space", space.ContainerId)
+			} else if running && space.IsPending && !space.IsDeployed {
+				client.logger.Info("space is running", "space", space.ContainerId)
+
+				space.IsPending = false
+				space.IsDeployed = true
+				break
+			} else if !exists {
+				client.logger.Info("space is stopped", "space", space.ContainerId)
+
+				space.IsPending = false
+				space.IsDeployed = false
+				break
+			}
+
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		client.logger.Info("update space status", "space", space.ContainerId)
+		space.UpdatedAt = hlc.Now()
+		err := database.GetInstance().SaveSpace(space, []string{"IsPending", "IsDeployed", "UpdatedAt"})
+		if err != nil {
+			client.logger.Error("updating space status error", "space", space.ContainerId)
+		}
+		if transport := service.GetTransport(); transport != nil {
+			transport.GossipSpace(space)
+		}
+		sse.PublishSpaceChanged(space.Id, space.UserId)
+		service.CheckSpaceLifecycleEvents(&oldSpace, space)
+
+		if onDone != nil {
+			onDone()
+		}
+	}()
+}
+
+// waitForSpaceStopped polls until the space has no objects left or the
+// timeout expires.
+func (client *KubernetesClient) waitForSpaceStopped(space *model.Space) {
+	client.logger.Debug("waiting for space to stop before volume deletion", "space_id", space.Id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), volumeDeleteWaitTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			client.logger.Warn("timed out waiting for space to stop before volume deletion", "space_id", space.Id)
+			return
+		default:
+		}
+
+		_, exists, err := client.spaceState(ctx, space.NomadNamespace, space.Id)
+		if err == nil && !exists {
+			return
+		}
+		if err != nil {
+			client.logger.WithError(err).Warn("error checking space state before volume deletion", "space_id", space.Id)
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// spaceNamespace returns the namespace for a space under the configured
+// namespace mode, named from the user or template ID as names can change. A
+// space that has been deployed or has volume claims keeps its namespace.
+func (client *KubernetesClient) spaceNamespace(user *model.User, template *model.Template, space *model.Space) string {
+	if space.NomadNamespace != "" {
+		return space.NomadNamespace
+	}
+	for _, volume := range space.VolumeData {
+		if volume.Type == volumeTypePVC && volume.Namespace != "" {
+			return volume.Namespace
+		}
+	}
+
+	switch client.cfg.NamespaceMode {
+	case NamespaceModeUser:
+		return namespaceName(client.cfg.NamespacePrefix, user.Id)
+	case NamespaceModeTemplate:
+		return namespaceName(client.cfg.NamespacePrefix, template.Id)
+	default:
+		return client.cfg.Namespace
+	}
+}
+
+// prepareNamespace creates the per user or per template namespace, the fixed
+// namespace is expected to exist.
+func (client *KubernetesClient) prepareNamespace(ctx context.Context, namespace string) error {
+	if client.cfg.NamespaceMode != NamespaceModeUser && client.cfg.NamespaceMode != NamespaceModeTemplate {
+		return nil
+	}
+	return client.ensureNamespace(ctx, namespace)
+}
+
+// applyManifest creates the supporting objects and then the workload.
+func (client *KubernetesClient) applyManifest(ctx context.Context, m *manifest) error {
+	namespace := m.namespace()
+
+	for _, object := range m.objects {
+		if workloadKinds[object["kind"].(string)] {
+			continue
+		}
+		if err := client.createObject(ctx, namespace, object); err != nil {
+			return err
+		}
+	}
+
+	return client.createObject(ctx, namespace, m.workload)
+}
+
+// deleteSpaceObjects removes everything labelled with the space except for
+// its volume claims.
+func (client *KubernetesClient) deleteSpaceObjects(ctx context.Context, namespace, spaceId string) error {
+	labels := map[string]string{LabelSpaceId: spaceId}
+
+	var firstErr error
+	for _, kind := range spaceObjectKinds {
+		objects, err := client.listObjects(ctx, kind, namespace, labels)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		for _, object := range objects {
+			if err := client.deleteObject(ctx, kind, namespace, objectName(object)); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// spaceState reports if a pod of the space is running and if the space still
+// has a workload or live pods.
+func (client *KubernetesClient) spaceState(ctx context.Context, namespace, spaceId string) (running bool, exists bool, err error) {
+	labels := map[string]string{LabelSpaceId: spaceId}
+
+	sets, err := client.listObjects(ctx, "StatefulSet", namespace, labels)
+	if err != nil {
+		return false, false, err
+	}
+	exists = len(sets) > 0
+
+	pods, err := client.listObjects(ctx, "Pod", namespace, labels)
+	if err != nil {
+		return false, false, err
+	}
+
+	for _, pod := range pods {
+		phase, _ := mapValue(pod, "status")["phase"].(string)
+		if phase != "Succeeded" && phase != "Failed" {
+			exists = true
+		}
+		if podRunning(pod) {
+			running = true
+		}
+	}
+
+	return running, exists, nil
+}
+
+// podRunning is true if the pod is running and not being deleted.
+func podRunning(pod map[string]interface{}) bool {
+	if _, deleting := mapValue(pod, "metadata")["deletionTimestamp"]; deleting {
+		return false
+	}
+	phase, _ := mapValue(pod, "status")["phase"].(string)
+	return phase == "Running"
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+const volumeTypePVC = "pvc"
+
+func (client *KubernetesClient) CreateVolume(vol *model.Volume, variables map[string]interface{}) error {
+	volumes, err := vol.GetKubernetesVolume(variables)
+	if err != nil {
+		return err
+	}
+
+	// If not exactly 1 volume then fail
+	if len(volumes.Volumes) != 1 {
+		return fmt.Errorf("volume definition must contain exactly 1 volume")
+	}
+
+	volume := volumes.Volumes[0]
+	if volume.Namespace == "" {
+		volume.Namespace = client.cfg.Namespace
+	}
+
+	client.logger.Debug("creating volume:", "volume_id", volume.Name)
+
+	if err := client.createVolumeClaim(context.Background(), &volume, ""); err != nil {
+		return err
+	}
+
+	client.logger.Debug("volumes created")
+
+	return nil
+}
+
+func (client *KubernetesClient) DeleteVolume(vol *model.Volume, variables map[string]interface{}) error {
+	volumes, err := vol.GetKubernetesVolume(variables)
+	if err != nil {
+		return err
+	}
+
+	// If not exactly 1 volume then fail
+	if len(volumes.Volumes) != 1 {
+		return fmt.Errorf("volume definition must contain exactly 1 volume")
+	}
+
+	volume := volumes.Volumes[0]
+	if volume.Namespace == "" {
+		volume.Namespace = client.cfg.Namespace
+	}
+
+	client.logger.Debug("deleting volume:", "volume", volume.Name)
+
+	if err := client.deleteObject(context.Background(), "PersistentVolumeClaim", volume.Namespace, volume.Name); err != nil {
+		return err
+	}
+
+	client.logger.Debug("volume deleted")
+
+	return nil
+}
+
+// createVolumeClaim creates a persistent volume claim, an existing claim is
+// kept so the data on it survives.
+func (client *KubernetesClient) createVolumeClaim(ctx context.Context, volume *model.KubernetesVolume, spaceId string) error {
+	labels := map[string]interface{}{LabelManagedBy: managedByValue}
+	if spaceId != "" {
+		labels[LabelSpaceId] = spaceId
+	}
+
+	accessModes := make([]interface{}, len(volume.AccessModes))
+	for i, mode := range volume.AccessModes {
+		accessModes[i] = mode
+	}
+
+	spec := map[string]interface{}{
+		"accessModes": accessModes,
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"storage": volume.Size},
+		},
+	}
+	if volume.StorageClass != "" {
+		spec["storageClassName"] = volume.StorageClass
+	}
+
+	path, err := resourcePath("PersistentVolumeClaim", volume.Namespace)
+	if err != nil {
+		return err
+	}
+
+	code, err := client.httpClient.Post(
+		ctx,
+		path,
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"name":      volume.Name,
+				"namespace": volume.Namespace,
+				"labels":    labels,
+			},
+			"spec": spec,
+		},
+		nil,
+		0,
+	)
+	if err != nil && code != http.StatusConflict {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/paularlott/knot/internal/util/crypt"
+)
+
+const (
+	Format  = "knot-backup"
+	Version = 2
+
+	archiveMagic = "KNOTBAK2"
+)
+
+var ErrEncrypted = errors.New("backup is encrypted, an encryption key is required")
+
+// Header is the first line of an archive and describes its contents.
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Kinds     []string  `json:"kinds"`
+}
+
+// Record is a single entity within an archive.
+type Record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Writer streams records to an archive.
+//
+// The archive is the magic string and an encryption flag followed by a gzip
+// compressed JSON lines body, optionally wrapped in an encrypted stream. The
+// first line is the header and each following line a record.
+type Writer struct {
+	stream *crypt.StreamWriter
+	gz     *gzip.Writer
+	enc    *json.Encoder
+}
+
+// NewWriter starts a new archive on w, if key is not empty the body is
+// encrypted with it.
+func NewWriter(w io.Writer, key string, header *Header) (*Writer, error) {
+	flag := byte(0)
+	if key != "" {
+		flag = 1
+	}
+	if _, err := w.Write(append([]byte(archiveMagic), flag)); err != nil {
+		return nil, err
+	}
+
+	aw := &Writer{}
+	if key != "" {
+		stream, err := crypt.NewStreamWriter(key, w)
+		if err != nil {
+			return nil, err
+		}
+		aw.stream = stream
+		w = stream
+	}
+
+	aw.gz = gzip.NewWriter(w)
+	aw.enc = json.NewEncoder(aw.gz)
+
+	header.Format = Format
+	header.Version = Version
+	if header.CreatedAt.IsZero() {
+		header.CreatedAt = time.Now().UTC()
+	}
+	if err := aw.enc.Encode(header); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// Write adds an entity of the given kind to the archive.
+func (w *Writer) Write(kind string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", kind, err)
+	}
+
+	return w.enc.Encode(&Record{Kind: kind, Data: data})
+}
+
+// Close flushes the archive, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+
+	if w.stream != nil {
+		return w.stream.Close()
+	}
+
+	return nil
+}
+
+// Reader reads records from an archive, version 1 backups written before the
+// archive format are read through the same interface.
+type Reader struct {
+	header  Header
+	dec     *json.Decoder
+	gz      *gzip.Reader
+	legacy  []*Record
+	legacyN int
+}
+
+// NewReader opens the archive in r, key is required if the archive is encrypted.
+func NewReader(r io.Reader, key string) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	prefix, err := br.Peek(len(archiveMagic) + 1)
+	if err != nil || string(prefix[:len(archiveMagic)]) != archiveMagic {
+		return newLegacyReader(br, key)
+	}
+
+	encrypted := prefix[len(archiveMagic)] == 1
+	br.Discard(len(prefix))
+
+	var body io.Reader = br
+	if encrypted {
+		if key == "" {
+			return nil, ErrEncrypted
+		}
+
+		stream, err := crypt.NewStreamReader(key, br)
+		if err != nil {
+			return nil, err
+		}
+		body = stream
+	}
+
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	ar := &Reader{
+		dec: json.NewDecoder(gz),
+		gz:  gz,
+	}
+	if err := ar.dec.Decode(&ar.header); err != nil {
+		return nil, fmt.Errorf("failed to read backup header: %w", err)
+	}
+
+	if ar.header.Format != Format {
+		return nil, fmt.Errorf("unknown backup format %q", ar.header.Format)
+	}
+	if ar.header.Version > Version {
+		return nil, fmt.Errorf("backup version %d is newer than supported version %d", ar.header.Version, Version)
+	}
+
+	return ar, nil
+}
+
+// Header returns the archive header.
+func (r *Reader) Header() *Header {
+	return &r.header
+}
+
+// Next returns the next record, io.EOF is returned after the last record.
+func (r *Reader) Next() (*Record, error) {
+	if r.dec == nil {
+		if r.legacyN >= len(r.legacy) {
+			return nil, io.EOF
+		}
+		r.legacyN++
+		return r.legacy[r.legacyN-1], nil
+	}
+
+	var record Record
+	if err := r.dec.Decode(&record); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read backup record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Close releases the reader, it does not close the underlying reader.
+func (r *Reader) Close() error {
+	if r.gz != nil {
+		return r.gz.Close()
+	}
+	return nil
+}
+
+// isEmptyJSON reports whether a raw value is missing or null.
+func isEmptyJSON(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) == 0 || string(data) == "null"
+}
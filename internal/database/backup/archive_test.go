@@ -0,0 +1,162 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/crypt"
+)
+
+func writeTestArchive(t *testing.T, key string, count int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, key, &Header{Kinds: []string{KindGroups, KindAuditLogs}})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	if err := w.Write(KindGroups, &model.Group{Id: "g1", Name: "developers"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		if err := w.Write(KindAuditLogs, &model.AuditLogEntry{Id: int64(i), Event: "Login"}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func readAllRecords(t *testing.T, r *Reader) []*Record {
+	t.Helper()
+
+	var records []*Record
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	for _, key := range []string{"", crypt.CreateKey()} {
+		data := writeTestArchive(t, key, 5000)
+
+		r, err := NewReader(bytes.NewReader(data), key)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+
+		header := r.Header()
+		if header.Format != Format || header.Version != Version {
+			t.Errorf("Unexpected header %+v", header)
+		}
+		if len(header.Kinds) != 2 {
+			t.Errorf("Expected 2 kinds in header, got %d", len(header.Kinds))
+		}
+
+		records := readAllRecords(t, r)
+		if len(records) != 5001 {
+			t.Fatalf("Expected 5001 records, got %d", len(records))
+		}
+
+		var group model.Group
+		if err := json.Unmarshal(records[0].Data, &group); err != nil {
+			t.Fatalf("Failed to decode group: %v", err)
+		}
+		if records[0].Kind != KindGroups || group.Name != "developers" {
+			t.Errorf("Unexpected first record %s %+v", records[0].Kind, group)
+		}
+	}
+}
+
+func TestArchiveEncryptedRequiresKey(t *testing.T) {
+	data := writeTestArchive(t, crypt.CreateKey(), 1)
+
+	if _, err := NewReader(bytes.NewReader(data), ""); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected ErrEncrypted, got %v", err)
+	}
+
+	if bytes.Contains(data, []byte("developers")) {
+		t.Error("Encrypted archive contains plain text")
+	}
+}
+
+func TestArchiveReadsLegacyBackup(t *testing.T) {
+	legacy := map[string]any{
+		"Templates": []*model.Template{{Id: "t1", Name: "ubuntu"}},
+		"Groups":    []*model.Group{{Id: "g1", Name: "developers"}},
+		"Users": []map[string]any{
+			{
+				"User":   &model.User{Id: "u1", Username: "alice"},
+				"Tokens": []*model.Token{{Id: "k1", UserId: "u1"}},
+				"Spaces": []*model.Space{{Id: "s1", UserId: "u1"}},
+			},
+		},
+	}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if r.Header().Version != 1 {
+		t.Errorf("Expected legacy version 1, got %d", r.Header().Version)
+	}
+
+	var kinds []string
+	for _, record := range readAllRecords(t, r) {
+		kinds = append(kinds, record.Kind)
+	}
+
+	// Records are returned in restore order regardless of the layout of the file
+	expected := []string{KindGroups, KindUsers, KindTokens, KindTemplates, KindSpaces}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected kinds %v, got %v", expected, kinds)
+	}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Errorf("Expected kinds %v, got %v", expected, kinds)
+			break
+		}
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := ParseKinds("templates, Stacks,audit-logs", "")
+	if err != nil {
+		t.Fatalf("ParseKinds failed: %v", err)
+	}
+	if len(kinds) != 3 || !kinds[KindTemplates] || !kinds[KindStacks] || !kinds[KindAuditLogs] {
+		t.Errorf("Unexpected kinds %v", kinds)
+	}
+
+	kinds, err = ParseKinds("", "audit_logs")
+	if err != nil {
+		t.Fatalf("ParseKinds failed: %v", err)
+	}
+	if len(kinds) != len(Kinds())-1 || kinds[KindAuditLogs] {
+		t.Errorf("Expected every kind except audit logs, got %v", kinds)
+	}
+
+	if _, err := ParseKinds("templates,widgets", ""); err == nil {
+		t.Error("Expected error for unknown kind")
+	}
+}
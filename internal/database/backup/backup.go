@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/paularlott/knot/internal/database"
+)
+
+// Options controls which entities are backed up or restored.
+type Options struct {
+	// Kinds selects the entity kinds to process, nil selects everything.
+	Kinds map[string]bool
+
+	// Filter limits the entities included in a backup.
+	Filter Filter
+
+	// Key encrypts or decrypts the archive, must be 32 bytes if set.
+	Key string
+
+	// DryRun reports the changes a restore would make without writing them.
+	DryRun bool
+
+	// OnKind is called as each entity kind is started.
+	OnKind func(e Entity)
+
+	// OnRecord is called for each record restored.
+	OnRecord func(e Entity, change Change, name string)
+}
+
+func (o *Options) selected(kind string) bool {
+	return o.Kinds == nil || o.Kinds[kind]
+}
+
+// KindSummary counts the records processed for an entity kind.
+type KindSummary struct {
+	Kind      string
+	Label     string
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Total returns the number of records processed.
+func (s *KindSummary) Total() int {
+	return s.Created + s.Updated + s.Unchanged
+}
+
+// Backup streams the selected entities from db to w, returning the number of
+// records written per kind.
+func Backup(db database.DbDriver, w io.Writer, opts *Options) ([]*KindSummary, error) {
+	header := &Header{}
+	for _, e := range entities {
+		if opts.selected(e.Kind()) {
+			header.Kinds = append(header.Kinds, e.Kind())
+		}
+	}
+
+	aw, err := NewWriter(w, opts.Key, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary []*KindSummary
+	for _, e := range entities {
+		if !opts.selected(e.Kind()) {
+			continue
+		}
+
+		if opts.OnKind != nil {
+			opts.OnKind(e)
+		}
+
+		kindSummary := &KindSummary{Kind: e.Kind(), Label: e.Label()}
+		summary = append(summary, kindSummary)
+
+		err := e.Each(db, &opts.Filter, func(v any) error {
+			kindSummary.Created++
+			return aw.Write(e.Kind(), v)
+		})
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, aw.Close()
+}
+
+// Restore reads an archive from r and writes the selected entities to db. The
+// archive is processed a record at a time so its size is not limited by memory.
+func Restore(db database.DbDriver, r io.Reader, opts *Options) ([]*KindSummary, error) {
+	ar, err := NewReader(r, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer ar.Close()
+
+	byKind := make(map[string]Entity, len(entities))
+	for _, e := range entities {
+		byKind[e.Kind()] = e
+	}
+
+	var summary []*KindSummary
+	var current *KindSummary
+	for {
+		record, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		e, ok := byKind[record.Kind]
+		if !ok {
+			return summary, fmt.Errorf("backup contains unknown entity %q", record.Kind)
+		}
+
+		if !opts.selected(record.Kind) {
+			continue
+		}
+
+		if current == nil || current.Kind != record.Kind {
+			if opts.OnKind != nil {
+				opts.OnKind(e)
+			}
+
+			current = &KindSummary{Kind: e.Kind(), Label: e.Label()}
+			summary = append(summary, current)
+		}
+
+		change, name, err := e.Restore(db, record.Data, opts.DryRun)
+		if err != nil {
+			return summary, err
+		}
+
+		switch change {
+		case ChangeCreate:
+			current.Created++
+		case ChangeUpdate:
+			current.Updated++
+		default:
+			current.Unchanged++
+		}
+
+		if opts.OnRecord != nil {
+			opts.OnRecord(e, change, name)
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,680 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+const (
+	KindCfgValues     = "cfg_values"
+	KindGroups        = "groups"
+	KindRoles         = "roles"
+	KindUsers         = "users"
+	KindTokens        = "tokens"
+	KindTemplateVars  = "template_vars"
+	KindVolumes       = "volumes"
+	KindScripts       = "scripts"
+	KindSkills        = "skills"
+	KindCommands      = "commands"
+	KindTemplates     = "templates"
+	KindStacks        = "stacks"
+	KindPools         = "pools"
+	KindSpaces        = "spaces"
+	KindSpaceUsage    = "space_usage"
+	KindRecordings    = "terminal_recordings"
+	KindSpaceLogs     = "space_logs"
+	KindEventSinks    = "event_sinks"
+	KindMCPServers    = "mcp_servers"
+	KindConversations = "conversations"
+	KindResponses     = "responses"
+	KindLLMUsage      = "llm_usage"
+	KindAuditLogs     = "audit_logs"
+)
+
+const (
+	auditLogPageSize = 1000
+	spaceLogPageSize = 1000
+)
+
+// Change describes what restoring a record does to the database.
+type Change string
+
+const (
+	ChangeCreate    Change = "create"
+	ChangeUpdate    Change = "update"
+	ChangeUnchanged Change = "unchanged"
+)
+
+// Entity is a persisted type that can be exported to and restored from an
+// archive.
+type Entity interface {
+	Kind() string
+	Label() string
+
+	// Each calls fn for every stored entity that passes the filter.
+	Each(db database.DbDriver, filter *Filter, fn func(v any) error) error
+
+	// Count returns the number of stored entities that pass the filter.
+	Count(db database.DbDriver, filter *Filter) (int, error)
+
+	// Restore writes the record to the database, when dryRun is set only the
+	// change that would be made is reported.
+	Restore(db database.DbDriver, data json.RawMessage, dryRun bool) (Change, string, error)
+
+	// Save writes an entity returned by Each to the database as is.
+	Save(db database.DbDriver, v any) error
+}
+
+// Filter limits which entities are included.
+type Filter struct {
+	// UserId limits user owned entities to the given user.
+	UserId string
+
+	// TemplateName limits templates to the given template.
+	TemplateName string
+}
+
+type entity[T any] struct {
+	kind  string
+	label string
+	list  func(db database.DbDriver, filter *Filter) ([]*T, error)
+	each  func(db database.DbDriver, filter *Filter, fn func(v *T) error) error // pages through entities too many to list
+	get   func(db database.DbDriver, v *T) (*T, error)
+	save  func(db database.DbDriver, v *T) error
+	name  func(v *T) string
+	owner func(v *T) string
+}
+
+func (e *entity[T]) Kind() string {
+	return e.kind
+}
+
+func (e *entity[T]) Label() string {
+	return e.label
+}
+
+func (e *entity[T]) Each(db database.DbDriver, filter *Filter, fn func(v any) error) error {
+	visit := func(item *T) error {
+		if e.owner != nil && filter != nil && filter.UserId != "" && e.owner(item) != filter.UserId {
+			return nil
+		}
+		return fn(item)
+	}
+
+	if e.each != nil {
+		return e.each(db, filter, visit)
+	}
+
+	items, err := e.list(db, filter)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", e.label, err)
+	}
+
+	for _, item := range items {
+		if err := visit(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *entity[T]) Count(db database.DbDriver, filter *Filter) (int, error) {
+	count := 0
+	err := e.Each(db, filter, func(v any) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func (e *entity[T]) Restore(db database.DbDriver, data json.RawMessage, dryRun bool) (Change, string, error) {
+	v := new(T)
+	if err := json.Unmarshal(data, v); err != nil {
+		return "", "", fmt.Errorf("failed to decode %s: %w", e.label, err)
+	}
+
+	name := e.name(v)
+	change := ChangeCreate
+	if e.get != nil {
+		if existing, err := e.get(db, v); err == nil && existing != nil {
+			change = ChangeUpdate
+			if sameJSON(existing, v) {
+				change = ChangeUnchanged
+			}
+		}
+	}
+
+	if !dryRun && change != ChangeUnchanged {
+		if err := e.save(db, v); err != nil {
+			return "", name, fmt.Errorf("failed to restore %s %s: %w", e.label, name, err)
+		}
+	}
+
+	return change, name, nil
+}
+
+func (e *entity[T]) Save(db database.DbDriver, v any) error {
+	item := v.(*T)
+	if err := e.save(db, item); err != nil {
+		return fmt.Errorf("failed to save %s %s: %w", e.label, e.name(item), err)
+	}
+	return nil
+}
+
+// sameJSON compares two values by their JSON encoding, this is what is stored
+// in the archive so it is the only form guaranteed to round trip.
+func sameJSON(a, b any) bool {
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aData, bData)
+}
+
+// entities lists every persisted type in restore order, entities that are
+// referenced by others come first.
+var entities = []Entity{
+	&entity[model.CfgValue]{
+		kind:  KindCfgValues,
+		label: "configuration values",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.CfgValue, error) { return db.GetCfgValues() },
+		get:   func(db database.DbDriver, v *model.CfgValue) (*model.CfgValue, error) { return db.GetCfgValue(v.Name) },
+		save:  func(db database.DbDriver, v *model.CfgValue) error { return db.SaveCfgValue(v) },
+		name:  func(v *model.CfgValue) string { return v.Name },
+	},
+	&entity[model.Group]{
+		kind:  KindGroups,
+		label: "groups",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Group, error) { return db.GetGroups() },
+		get:   func(db database.DbDriver, v *model.Group) (*model.Group, error) { return db.GetGroup(v.Id) },
+		save:  func(db database.DbDriver, v *model.Group) error { return db.SaveGroup(v) },
+		name:  func(v *model.Group) string { return v.Name },
+	},
+	&entity[model.Role]{
+		kind:  KindRoles,
+		label: "roles",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Role, error) { return db.GetRoles() },
+		get:   func(db database.DbDriver, v *model.Role) (*model.Role, error) { return db.GetRole(v.Id) },
+		save:  func(db database.DbDriver, v *model.Role) error { return db.SaveRole(v) },
+		name:  func(v *model.Role) string { return v.Name },
+	},
+	&entity[model.User]{
+		kind:  KindUsers,
+		label: "users",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.User, error) { return db.GetUsers() },
+		get:   func(db database.DbDriver, v *model.User) (*model.User, error) { return db.GetUser(v.Id) },
+		save:  func(db database.DbDriver, v *model.User) error { return db.SaveUser(v, nil) },
+		name:  func(v *model.User) string { return v.Username },
+		owner: func(v *model.User) string { return v.Id },
+	},
+	&entity[model.Token]{
+		kind:  KindTokens,
+		label: "tokens",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Token, error) { return db.GetTokens() },
+		get:   func(db database.DbDriver, v *model.Token) (*model.Token, error) { return db.GetToken(v.Id) },
+		save:  func(db database.DbDriver, v *model.Token) error { return db.SaveToken(v) },
+		name:  func(v *model.Token) string { return v.Name },
+		owner: func(v *model.Token) string { return v.UserId },
+	},
+	&entity[model.TemplateVar]{
+		kind:  KindTemplateVars,
+		label: "template variables",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.TemplateVar, error) { return db.GetTemplateVars() },
+		get: func(db database.DbDriver, v *model.TemplateVar) (*model.TemplateVar, error) {
+			return db.GetTemplateVar(v.Id)
+		},
+		save: func(db database.DbDriver, v *model.TemplateVar) error { return db.SaveTemplateVar(v) },
+		name: func(v *model.TemplateVar) string { return v.Name },
+	},
+	&entity[model.Volume]{
+		kind:  KindVolumes,
+		label: "volumes",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Volume, error) { return db.GetVolumes() },
+		get:   func(db database.DbDriver, v *model.Volume) (*model.Volume, error) { return db.GetVolume(v.Id) },
+		save:  func(db database.DbDriver, v *model.Volume) error { return db.SaveVolume(v, nil) },
+		name:  func(v *model.Volume) string { return v.Name },
+	},
+	&entity[model.Script]{
+		kind:  KindScripts,
+		label: "scripts",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Script, error) { return db.GetScripts() },
+		get:   func(db database.DbDriver, v *model.Script) (*model.Script, error) { return db.GetScript(v.Id) },
+		save:  func(db database.DbDriver, v *model.Script) error { return db.SaveScript(v, nil) },
+		name:  func(v *model.Script) string { return v.Name },
+	},
+	&entity[model.Skill]{
+		kind:  KindSkills,
+		label: "skills",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Skill, error) { return db.GetSkills() },
+		get:   func(db database.DbDriver, v *model.Skill) (*model.Skill, error) { return db.GetSkill(v.Id) },
+		save:  func(db database.DbDriver, v *model.Skill) error { return db.SaveSkill(v, nil) },
+		name:  func(v *model.Skill) string { return v.Name },
+	},
+	&entity[model.Command]{
+		kind:  KindCommands,
+		label: "slash commands",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Command, error) { return db.GetCommands() },
+		get:   func(db database.DbDriver, v *model.Command) (*model.Command, error) { return db.GetCommand(v.Id) },
+		save:  func(db database.DbDriver, v *model.Command) error { return db.SaveCommand(v, nil) },
+		name:  func(v *model.Command) string { return v.Name },
+	},
+	&entity[model.Template]{
+		kind:  KindTemplates,
+		label: "templates",
+		list: func(db database.DbDriver, filter *Filter) ([]*model.Template, error) {
+			templates, err := db.GetTemplates()
+			if err != nil || filter == nil || filter.TemplateName == "" {
+				return templates, err
+			}
+
+			filtered := make([]*model.Template, 0, 1)
+			for _, t := range templates {
+				if t.Name == filter.TemplateName {
+					filtered = append(filtered, t)
+				}
+			}
+			return filtered, nil
+		},
+		get:  func(db database.DbDriver, v *model.Template) (*model.Template, error) { return db.GetTemplate(v.Id) },
+		save: func(db database.DbDriver, v *model.Template) error { return db.SaveTemplate(v, nil) },
+		name: func(v *model.Template) string { return v.Name },
+	},
+	&entity[model.StackDefinition]{
+		kind:  KindStacks,
+		label: "stack definitions",
+		list: func(db database.DbDriver, _ *Filter) ([]*model.StackDefinition, error) {
+			return db.GetStackDefinitions()
+		},
+		get: func(db database.DbDriver, v *model.StackDefinition) (*model.StackDefinition, error) {
+			return db.GetStackDefinition(v.Id)
+		},
+		save: func(db database.DbDriver, v *model.StackDefinition) error { return db.SaveStackDefinition(v, nil) },
+		name: func(v *model.StackDefinition) string { return v.Name },
+	},
+	&entity[model.PoolDefinition]{
+		kind:  KindPools,
+		label: "pool definitions",
+		list: func(db database.DbDriver, _ *Filter) ([]*model.PoolDefinition, error) {
+			return db.GetPoolDefinitions()
+		},
+		get: func(db database.DbDriver, v *model.PoolDefinition) (*model.PoolDefinition, error) {
+			return db.GetPoolDefinition(v.Id)
+		},
+		save: func(db database.DbDriver, v *model.PoolDefinition) error { return db.SavePoolDefinition(v, nil) },
+		name: func(v *model.PoolDefinition) string { return v.Name },
+	},
+	&entity[model.Space]{
+		kind:  KindSpaces,
+		label: "spaces",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Space, error) { return db.GetSpaces() },
+		get:   func(db database.DbDriver, v *model.Space) (*model.Space, error) { return db.GetSpace(v.Id) },
+		save: func(db database.DbDriver, v *model.Space) error {
+			// If started at isn't set then use now
+			if v.StartedAt.IsZero() {
+				v.StartedAt = time.Now().UTC()
+			}
+			return db.SaveSpace(v, nil)
+		},
+		name:  func(v *model.Space) string { return v.Name },
+		owner: func(v *model.Space) string { return v.UserId },
+	},
+	&entity[model.SpaceUsageSample]{
+		kind:  KindSpaceUsage,
+		label: "space usage samples",
+		each: func(db database.DbDriver, _ *Filter, fn func(v *model.SpaceUsageSample) error) error {
+			spaces, err := db.GetSpaces()
+			if err != nil {
+				return fmt.Errorf("failed to get spaces: %w", err)
+			}
+
+			// One page per space and bucket size rather than every sample at once
+			to := time.Now().UTC().Add(24 * time.Hour)
+			for _, space := range spaces {
+				for _, bucketKind := range []string{model.SpaceUsageBucketMinute, model.SpaceUsageBucketDay} {
+					samples, err := db.GetSpaceUsageSamples(space.Id, bucketKind, time.Time{}, to)
+					if err != nil {
+						return fmt.Errorf("failed to get space usage samples: %w", err)
+					}
+					for _, sample := range samples {
+						if err := fn(sample); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
+		},
+		get: func(db database.DbDriver, v *model.SpaceUsageSample) (*model.SpaceUsageSample, error) {
+			return db.GetSpaceUsageSample(v.Id)
+		},
+		save:  func(db database.DbDriver, v *model.SpaceUsageSample) error { return db.SaveSpaceUsageSample(v) },
+		name:  func(v *model.SpaceUsageSample) string { return v.Id },
+		owner: func(v *model.SpaceUsageSample) string { return v.UserId },
+	},
+	&entity[terminalRecording]{
+		kind:  KindRecordings,
+		label: "terminal recordings",
+		list: func(db database.DbDriver, filter *Filter) ([]*terminalRecording, error) {
+			spaces, err := db.GetSpaces()
+			if err != nil {
+				return nil, err
+			}
+
+			var recordings []*terminalRecording
+			for _, space := range spaces {
+				if filter != nil && filter.UserId != "" && space.UserId != filter.UserId {
+					continue
+				}
+
+				listed, err := db.GetTerminalRecordingsForSpace(space.Id)
+				if err != nil {
+					return nil, err
+				}
+				for _, r := range listed {
+					recording, err := getTerminalRecording(db, r.Id)
+					if err != nil {
+						return nil, err
+					}
+					recordings = append(recordings, recording)
+				}
+			}
+			return recordings, nil
+		},
+		get: func(db database.DbDriver, v *terminalRecording) (*terminalRecording, error) {
+			return getTerminalRecording(db, v.Id)
+		},
+		save: func(db database.DbDriver, v *terminalRecording) error {
+			recording := v.TerminalRecording
+			recording.Data = v.Data
+			return db.SaveTerminalRecording(&recording)
+		},
+		name: func(v *terminalRecording) string { return v.Id },
+	},
+	&spaceLogEntity{},
+	&entity[model.EventSink]{
+		kind:  KindEventSinks,
+		label: "event sinks",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.EventSink, error) { return db.GetEventSinks() },
+		get:   func(db database.DbDriver, v *model.EventSink) (*model.EventSink, error) { return db.GetEventSink(v.Id) },
+		save:  func(db database.DbDriver, v *model.EventSink) error { return db.SaveEventSink(v, nil) },
+		name:  func(v *model.EventSink) string { return v.Name },
+	},
+	&entity[model.MCPServer]{
+		kind:  KindMCPServers,
+		label: "MCP servers",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.MCPServer, error) { return db.GetMCPServers() },
+		get:   func(db database.DbDriver, v *model.MCPServer) (*model.MCPServer, error) { return db.GetMCPServer(v.Id) },
+		save:  func(db database.DbDriver, v *model.MCPServer) error { return db.SaveMCPServer(v, nil) },
+		name:  func(v *model.MCPServer) string { return v.Namespace },
+	},
+	&entity[model.Conversation]{
+		kind:  KindConversations,
+		label: "conversations",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Conversation, error) { return db.GetConversations() },
+		get: func(db database.DbDriver, v *model.Conversation) (*model.Conversation, error) {
+			return db.GetConversation(v.UserId, v.Id)
+		},
+		save:  func(db database.DbDriver, v *model.Conversation) error { return db.SaveConversation(v) },
+		name:  func(v *model.Conversation) string { return v.Title },
+		owner: func(v *model.Conversation) string { return v.UserId },
+	},
+	&entity[model.Response]{
+		kind:  KindResponses,
+		label: "responses",
+		list:  func(db database.DbDriver, _ *Filter) ([]*model.Response, error) { return db.GetResponses() },
+		get:   func(db database.DbDriver, v *model.Response) (*model.Response, error) { return db.GetResponse(v.Id) },
+		save:  func(db database.DbDriver, v *model.Response) error { return db.SaveResponse(v) },
+		name:  func(v *model.Response) string { return v.Id },
+	},
+	&entity[model.LLMUsage]{
+		kind:  KindLLMUsage,
+		label: "AI token usage",
+		list: func(db database.DbDriver, _ *Filter) ([]*model.LLMUsage, error) {
+			return db.GetLLMUsageRange(time.Time{}, time.Now().UTC().Add(24*time.Hour))
+		},
+		get:   func(db database.DbDriver, v *model.LLMUsage) (*model.LLMUsage, error) { return db.GetLLMUsage(v.Id) },
+		save:  func(db database.DbDriver, v *model.LLMUsage) error { return db.SaveLLMUsage(v) },
+		name:  func(v *model.LLMUsage) string { return v.Id },
+		owner: func(v *model.LLMUsage) string { return v.UserId },
+	},
+	&auditLogEntity{},
+}
+
+// terminalRecording carries the cast of a recording in the archive, the model
+// leaves it out of its JSON so it isn't sent with recording listings.
+type terminalRecording struct {
+	model.TerminalRecording
+	Data []byte `json:"data"`
+}
+
+func getTerminalRecording(db database.DbDriver, id string) (*terminalRecording, error) {
+	recording, err := db.GetTerminalRecording(id)
+	if err != nil || recording == nil {
+		return nil, err
+	}
+	return &terminalRecording{TerminalRecording: *recording, Data: recording.Data}, nil
+}
+
+// auditLogEntity pages through the audit log rather than loading it in one go
+// as it can be far larger than anything else in the database.
+type auditLogEntity struct{}
+
+func (e *auditLogEntity) Kind() string {
+	return KindAuditLogs
+}
+
+func (e *auditLogEntity) Label() string {
+	return "audit logs"
+}
+
+func (e *auditLogEntity) Each(db database.DbDriver, _ *Filter, fn func(v any) error) error {
+	var after *model.AuditLogEntry
+	for {
+		entries, err := db.GetAuditLogsAfter(after, auditLogPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to get audit logs: %w", err)
+		}
+
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < auditLogPageSize {
+			return nil
+		}
+		after = entries[len(entries)-1]
+	}
+}
+
+func (e *auditLogEntity) Count(db database.DbDriver, _ *Filter) (int, error) {
+	_, count, err := db.GetAuditLogs(nil, 0, 1)
+	return count, err
+}
+
+// Restore creates the entry unless it is already stored, audit logs are
+// append only so an existing entry is never updated.
+func (e *auditLogEntity) Restore(db database.DbDriver, data json.RawMessage, dryRun bool) (Change, string, error) {
+	var entry model.AuditLogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", "", fmt.Errorf("failed to decode audit log: %w", err)
+	}
+
+	exists, err := db.HasAuditLogEntry(&entry)
+	if err != nil {
+		return "", entry.Event, fmt.Errorf("failed to check audit log: %w", err)
+	}
+	if exists {
+		return ChangeUnchanged, entry.Event, nil
+	}
+
+	if !dryRun {
+		if err := db.SaveAuditLog(&entry); err != nil {
+			return "", entry.Event, fmt.Errorf("failed to restore audit log: %w", err)
+		}
+	}
+
+	return ChangeCreate, entry.Event, nil
+}
+
+func (e *auditLogEntity) Save(db database.DbDriver, v any) error {
+	entry := v.(*model.AuditLogEntry)
+	if exists, err := db.HasAuditLogEntry(entry); err != nil || exists {
+		return err
+	}
+
+	if err := db.SaveAuditLog(entry); err != nil {
+		return fmt.Errorf("failed to save audit log: %w", err)
+	}
+	return nil
+}
+
+// spaceLogEntity pages through the log of each space, like the audit log it
+// can hold far more entries than is sensible to load at once.
+type spaceLogEntity struct{}
+
+func (e *spaceLogEntity) Kind() string {
+	return KindSpaceLogs
+}
+
+func (e *spaceLogEntity) Label() string {
+	return "space logs"
+}
+
+func (e *spaceLogEntity) Each(db database.DbDriver, filter *Filter, fn func(v any) error) error {
+	spaces, err := db.GetSpaces()
+	if err != nil {
+		return fmt.Errorf("failed to get spaces: %w", err)
+	}
+
+	for _, space := range spaces {
+		if filter != nil && filter.UserId != "" && space.UserId != filter.UserId {
+			continue
+		}
+
+		// Entries are returned newest first, the oldest is the cursor for the next page
+		logFilter := &model.SpaceLogFilter{}
+		for {
+			entries, err := db.GetSpaceLogs(space.Id, logFilter, spaceLogPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to get space logs: %w", err)
+			}
+
+			for _, entry := range entries {
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+
+			if len(entries) < spaceLogPageSize {
+				break
+			}
+			logFilter.Before = entries[len(entries)-1].Id
+		}
+	}
+
+	return nil
+}
+
+func (e *spaceLogEntity) Count(db database.DbDriver, filter *Filter) (int, error) {
+	count := 0
+	err := e.Each(db, filter, func(v any) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Restore always writes the entry, entries are only ever added and their ids
+// are unique so writing one that exists leaves it unchanged.
+func (e *spaceLogEntity) Restore(db database.DbDriver, data json.RawMessage, dryRun bool) (Change, string, error) {
+	var entry model.SpaceLogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", "", fmt.Errorf("failed to decode space log: %w", err)
+	}
+
+	if !dryRun {
+		if err := db.SaveSpaceLogs([]*model.SpaceLogEntry{&entry}); err != nil {
+			return "", entry.Id, fmt.Errorf("failed to restore space log: %w", err)
+		}
+	}
+
+	return ChangeCreate, entry.Id, nil
+}
+
+func (e *spaceLogEntity) Save(db database.DbDriver, v any) error {
+	if err := db.SaveSpaceLogs([]*model.SpaceLogEntry{v.(*model.SpaceLogEntry)}); err != nil {
+		return fmt.Errorf("failed to save space log: %w", err)
+	}
+	return nil
+}
+
+// Kinds returns the name of every entity kind in restore order.
+func Kinds() []string {
+	kinds := make([]string, len(entities))
+	for i, e := range entities {
+		kinds[i] = e.Kind()
+	}
+	return kinds
+}
+
+// Entities returns the entities in restore order.
+func Entities() []Entity {
+	return entities
+}
+
+// ParseKinds converts a comma separated list of kinds into a set, an empty
+// list selects every kind. Kinds may use - or _ as a separator.
+func ParseKinds(only string, exclude string) (map[string]bool, error) {
+	selected := make(map[string]bool)
+
+	onlyKinds, err := splitKinds(only)
+	if err != nil {
+		return nil, err
+	}
+	if len(onlyKinds) == 0 {
+		onlyKinds = Kinds()
+	}
+	for _, kind := range onlyKinds {
+		selected[kind] = true
+	}
+
+	excludeKinds, err := splitKinds(exclude)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range excludeKinds {
+		delete(selected, kind)
+	}
+
+	return selected, nil
+}
+
+func splitKinds(list string) ([]string, error) {
+	var kinds []string
+	for _, kind := range strings.Split(list, ",") {
+		kind = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(kind)), "-", "_")
+		if kind == "" {
+			continue
+		}
+
+		if entityByKind(kind) == nil {
+			return nil, fmt.Errorf("unknown entity %q, valid entities are %s", kind, strings.Join(Kinds(), ", "))
+		}
+
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,32 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestTerminalRecordingKeepsData(t *testing.T) {
+	recording := &terminalRecording{
+		TerminalRecording: model.TerminalRecording{Id: "r1", SpaceId: "s1", Username: "alice"},
+		Data:              []byte{0x1f, 0x8b, 0x08},
+	}
+
+	data, err := json.Marshal(recording)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var restored terminalRecording
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if restored.Id != "r1" || restored.SpaceId != "s1" || restored.Username != "alice" {
+		t.Errorf("Expected the recording fields to round trip, got %+v", restored.TerminalRecording)
+	}
+	if !bytes.Equal(restored.Data, recording.Data) {
+		t.Errorf("Expected the cast data to round trip, got %v", restored.Data)
+	}
+}
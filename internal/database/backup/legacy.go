@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/paularlott/knot/internal/util/crypt"
+)
+
+// legacyData is the single JSON document written by version 1 backups.
+type legacyData struct {
+	Templates    []json.RawMessage
+	TemplateVars []json.RawMessage
+	Volumes      []json.RawMessage
+	Groups       []json.RawMessage
+	Roles        []json.RawMessage
+	Users        []struct {
+		User   json.RawMessage
+		Tokens []json.RawMessage
+		Spaces []json.RawMessage
+	}
+	Scripts   []json.RawMessage
+	Skills    []json.RawMessage
+	Commands  []json.RawMessage
+	Responses []json.RawMessage
+	CfgValues []json.RawMessage
+	AuditLogs []json.RawMessage
+}
+
+// newLegacyReader loads a version 1 backup, these were written in one piece so
+// have to be read into memory, and presents the content as records in restore
+// order.
+func newLegacyReader(r io.Reader, key string) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if key != "" {
+		data = []byte(crypt.Decrypt(key, string(data)))
+	}
+
+	var legacy legacyData
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to read backup, if the backup is encrypted an encryption key is required: %w", err)
+	}
+
+	byKind := map[string][]json.RawMessage{
+		KindCfgValues:    legacy.CfgValues,
+		KindGroups:       legacy.Groups,
+		KindRoles:        legacy.Roles,
+		KindTemplateVars: legacy.TemplateVars,
+		KindVolumes:      legacy.Volumes,
+		KindScripts:      legacy.Scripts,
+		KindSkills:       legacy.Skills,
+		KindCommands:     legacy.Commands,
+		KindTemplates:    legacy.Templates,
+		KindResponses:    legacy.Responses,
+		KindAuditLogs:    legacy.AuditLogs,
+	}
+	for _, u := range legacy.Users {
+		if !isEmptyJSON(u.User) {
+			byKind[KindUsers] = append(byKind[KindUsers], u.User)
+		}
+		byKind[KindTokens] = append(byKind[KindTokens], u.Tokens...)
+		byKind[KindSpaces] = append(byKind[KindSpaces], u.Spaces...)
+	}
+
+	ar := &Reader{
+		header: Header{
+			Format:  Format,
+			Version: 1,
+		},
+	}
+	for _, kind := range Kinds() {
+		items, ok := byKind[kind]
+		if !ok {
+			continue
+		}
+
+		ar.header.Kinds = append(ar.header.Kinds, kind)
+		for _, item := range items {
+			if !isEmptyJSON(item) {
+				ar.legacy = append(ar.legacy, &Record{Kind: kind, Data: item})
+			}
+		}
+	}
+
+	return ar, nil
+}
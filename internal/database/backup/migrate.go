@@ -0,0 +1,220 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/paularlott/knot/internal/database"
+)
+
+const checkpointInterval = 100
+
+// MigrateOptions controls a copy between two databases.
+type MigrateOptions struct {
+	// Kinds selects the entity kinds to copy, nil selects everything.
+	Kinds map[string]bool
+
+	// CheckpointFile records progress so an interrupted migration can resume,
+	// it is removed once the migration has been verified.
+	CheckpointFile string
+
+	// OnKind is called as each entity kind is started, resumed is the number
+	// of records copied by a previous run, they are copied again.
+	OnKind func(e Entity, resumed int)
+}
+
+// MigrateSummary reports the records copied for an entity kind.
+type MigrateSummary struct {
+	Kind        string
+	Label       string
+	Copied      int
+	Source      int
+	Destination int
+	Skipped     string
+}
+
+// Verified reports whether the destination holds as many records as the source.
+func (s *MigrateSummary) Verified() bool {
+	return s.Skipped != "" || s.Source == s.Destination
+}
+
+type checkpointKind struct {
+	Copied   int  `json:"copied"`
+	Complete bool `json:"complete"`
+}
+
+type checkpoint struct {
+	Kinds map[string]*checkpointKind `json:"kinds"`
+}
+
+func loadCheckpoint(path string) (*checkpoint, bool, error) {
+	cp := &checkpoint{Kinds: make(map[string]*checkpointKind)}
+	if path == "" {
+		return cp, false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, false, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if cp.Kinds == nil {
+		cp.Kinds = make(map[string]*checkpointKind)
+	}
+
+	return cp, true, nil
+}
+
+func (cp *checkpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so an interruption never leaves a partial checkpoint
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Migrate copies every selected entity from src to dst through the driver
+// interface, entities are passed between the drivers unchanged so timestamps
+// and encrypted fields are preserved. Record counts are compared per kind once
+// the copy completes.
+//
+// A fresh migration requires an empty destination. If the checkpoint file
+// exists the migration resumes, completed kinds are skipped and the kind in
+// progress is copied again from the start. Drivers don't list records in a
+// stable order so resuming by position could skip records, saving a record
+// that was already copied overwrites it so nothing is duplicated.
+func Migrate(src database.DbDriver, dst database.DbDriver, opts *MigrateOptions) ([]*MigrateSummary, error) {
+	cp, resuming, err := loadCheckpoint(opts.CheckpointFile)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := func(kind string) bool {
+		return opts.Kinds == nil || opts.Kinds[kind]
+	}
+
+	if !resuming {
+		for _, e := range entities {
+			if !selected(e.Kind()) {
+				continue
+			}
+
+			count, err := e.Count(dst, nil)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, fmt.Errorf("destination database is not empty, it already holds %d %s", count, e.Label())
+			}
+		}
+	}
+
+	var summary []*MigrateSummary
+	for _, e := range entities {
+		if !selected(e.Kind()) {
+			continue
+		}
+
+		kindSummary := &MigrateSummary{Kind: e.Kind(), Label: e.Label()}
+		summary = append(summary, kindSummary)
+
+		// Drivers without an audit log accept and discard entries
+		if e.Kind() == KindAuditLogs && (!src.HasAuditLog() || !dst.HasAuditLog()) {
+			kindSummary.Skipped = "audit log not supported by driver"
+			continue
+		}
+
+		progress, ok := cp.Kinds[e.Kind()]
+		if !ok {
+			progress = &checkpointKind{}
+			cp.Kinds[e.Kind()] = progress
+		}
+		if progress.Complete {
+			kindSummary.Copied = progress.Copied
+			continue
+		}
+
+		if opts.OnKind != nil {
+			opts.OnKind(e, progress.Copied)
+		}
+
+		progress.Copied = 0
+		err := e.Each(src, nil, func(v any) error {
+			if err := e.Save(dst, v); err != nil {
+				return err
+			}
+
+			progress.Copied++
+			kindSummary.Copied++
+			if progress.Copied%checkpointInterval == 0 {
+				return cp.save(opts.CheckpointFile)
+			}
+			return nil
+		})
+		if err != nil {
+			if saveErr := cp.save(opts.CheckpointFile); saveErr != nil {
+				return summary, errors.Join(err, saveErr)
+			}
+			return summary, err
+		}
+
+		progress.Complete = true
+		if err := cp.save(opts.CheckpointFile); err != nil {
+			return summary, err
+		}
+	}
+
+	verified := true
+	for _, s := range summary {
+		if s.Skipped != "" {
+			continue
+		}
+
+		e := entityByKind(s.Kind)
+		if s.Source, err = e.Count(src, nil); err != nil {
+			return summary, err
+		}
+		if s.Destination, err = e.Count(dst, nil); err != nil {
+			return summary, err
+		}
+
+		verified = verified && s.Verified()
+	}
+
+	if !verified {
+		return summary, errors.New("record counts differ between the source and destination")
+	}
+
+	if opts.CheckpointFile != "" {
+		os.Remove(opts.CheckpointFile)
+	}
+
+	return summary, nil
+}
+
+func entityByKind(kind string) Entity {
+	for _, e := range entities {
+		if e.Kind() == kind {
+			return e
+		}
+	}
+	return nil
+}
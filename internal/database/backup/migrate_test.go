@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.checkpoint")
+
+	cp, resuming, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint failed: %v", err)
+	}
+	if resuming {
+		t.Error("Expected a fresh checkpoint when the file does not exist")
+	}
+
+	cp.Kinds[KindUsers] = &checkpointKind{Copied: 10, Complete: true}
+	cp.Kinds[KindSpaces] = &checkpointKind{Copied: 250}
+	if err := cp.save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	cp, resuming, err = loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint failed: %v", err)
+	}
+	if !resuming {
+		t.Error("Expected to resume from the saved checkpoint")
+	}
+	if users := cp.Kinds[KindUsers]; users == nil || !users.Complete || users.Copied != 10 {
+		t.Errorf("Unexpected users progress %+v", users)
+	}
+	if spaces := cp.Kinds[KindSpaces]; spaces == nil || spaces.Complete || spaces.Copied != 250 {
+		t.Errorf("Unexpected spaces progress %+v", spaces)
+	}
+}
+
+func TestMigrateSummaryVerified(t *testing.T) {
+	if !(&MigrateSummary{Source: 3, Destination: 3}).Verified() {
+		t.Error("Expected matching counts to verify")
+	}
+	if (&MigrateSummary{Source: 3, Destination: 2}).Verified() {
+		t.Error("Expected differing counts to fail verification")
+	}
+	if !(&MigrateSummary{Source: 3, Skipped: "not supported"}).Verified() {
+		t.Error("Expected skipped kinds to verify")
+	}
+}
+
+// groupsDB holds groups in a map so they are listed in a different order on
+// each call, as with Redis SCAN.
+type groupsDB struct {
+	database.DbDriver // embedded nil so we only override what we use
+
+	groups    map[string]*model.Group
+	saves     int
+	failAfter int
+}
+
+func (db *groupsDB) GetGroups() ([]*model.Group, error) {
+	groups := make([]*model.Group, 0, len(db.groups))
+	for _, group := range db.groups {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (db *groupsDB) GetGroup(id string) (*model.Group, error) {
+	return db.groups[id], nil
+}
+
+func (db *groupsDB) SaveGroup(group *model.Group) error {
+	if db.failAfter > 0 && db.saves >= db.failAfter {
+		return errors.New("connection lost")
+	}
+	db.saves++
+	db.groups[group.Id] = group
+	return nil
+}
+
+func TestMigrateResume(t *testing.T) {
+	src := &groupsDB{groups: make(map[string]*model.Group)}
+	for i := range 50 {
+		id := fmt.Sprintf("group-%02d", i)
+		src.groups[id] = &model.Group{Id: id, Name: id}
+	}
+	dst := &groupsDB{groups: make(map[string]*model.Group), failAfter: 20}
+
+	opts := &MigrateOptions{
+		Kinds:          map[string]bool{KindGroups: true},
+		CheckpointFile: filepath.Join(t.TempDir(), "migrate.checkpoint"),
+	}
+	if _, err := Migrate(src, dst, opts); err == nil {
+		t.Fatal("Expected the interrupted migration to fail")
+	}
+	if len(dst.groups) != 20 {
+		t.Fatalf("Expected 20 groups copied before the interruption, got %d", len(dst.groups))
+	}
+
+	dst.failAfter = 0
+	summary, err := Migrate(src, dst, opts)
+	if err != nil {
+		t.Fatalf("Resumed migration failed: %v", err)
+	}
+	if len(dst.groups) != len(src.groups) {
+		t.Errorf("Expected %d groups after resuming, got %d", len(src.groups), len(dst.groups))
+	}
+	for id := range src.groups {
+		if dst.groups[id] == nil {
+			t.Errorf("Group %s was not copied", id)
+		}
+	}
+	if len(summary) != 1 || !summary[0].Verified() {
+		t.Errorf("Expected a verified summary, got %+v", summary)
+	}
+}
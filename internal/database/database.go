@@ -13,6 +13,7 @@ import (
 	"github.com/paularlott/knot/internal/database/model"
 
 	"github.com/google/uuid"
+	"github.com/paularlott/gossip/hlc"
 	"github.com/paularlott/knot/internal/log"
 )
 
@@ -56,6 +57,20 @@ type DbDriver interface {
 	GetSpaceUsageSample(id string) (*model.SpaceUsageSample, error)
 	GetSpaceUsageSamples(spaceId string, bucketKind string, from time.Time, to time.Time) ([]*model.SpaceUsageSample, error)
 
+	// Terminal Recordings, listings don't load the recording data
+	SaveTerminalRecording(recording *model.TerminalRecording) error
+	GetTerminalRecording(id string) (*model.TerminalRecording, error)
+	GetTerminalRecordingsForSpace(spaceId string) ([]*model.TerminalRecording, error)
+
+	// Space Logs, returned newest first
+	SaveSpaceLogs(entries []*model.SpaceLogEntry) error
+	GetSpaceLogs(spaceId string, filter *model.SpaceLogFilter, limit int) ([]*model.SpaceLogEntry, error)
+
+	// LLM Usage, one row per node, user, model and day
+	SaveLLMUsage(usage *model.LLMUsage) error
+	GetLLMUsage(id string) (*model.LLMUsage, error)
+	GetLLMUsageRange(from time.Time, to time.Time) ([]*model.LLMUsage, error)
+
 	// Pools
 	SavePoolDefinition(pool *model.PoolDefinition, updateFields []string) error
 	DeletePoolDefinition(pool *model.PoolDefinition) error
@@ -165,6 +180,8 @@ type DbDriver interface {
 	SaveAuditLog(auditLog *model.AuditLogEntry) error
 	GetAuditLogs(filter *model.AuditLogFilter, offset int, limit int) ([]*model.AuditLogEntry, int, error)
 	GetAuditLogsForExport(filter *model.AuditLogFilter) ([]*model.AuditLogEntry, error)
+	GetAuditLogsAfter(after *model.AuditLogEntry, limit int) ([]*model.AuditLogEntry, error)
+	HasAuditLogEntry(entry *model.AuditLogEntry) (bool, error)
 
 	// Stack Definitions
 	SaveStackDefinition(def *model.StackDefinition, updateFields []string) error
@@ -272,9 +289,45 @@ func initDrivers() {
 				logger.Fatal("failed to save node_id", "error", err)
 			}
 		}
+
+		// Roles able to use SSH could forward ports before the permission existed
+		if granted, err := dbInstance.GetCfgValue("ssh_local_forward_granted"); err != nil || granted == nil {
+			grantSSHLocalForward()
+		}
 	})
 }
 
+// grantSSHLocalForward gives the roles that can use SSH the local port
+// forwarding permission once, so upgrading doesn't break remote editors.
+func grantSSHLocalForward() {
+	logger := log.WithGroup("db")
+
+	roles, err := dbInstance.GetRoles()
+	if err != nil {
+		logger.Error("failed to load roles", "error", err)
+		return
+	}
+
+	for _, role := range roles {
+		if !role.IsDeleted && role.GrantSSHLocalForward() {
+			role.UpdatedAt = hlc.Now()
+			if err := dbInstance.SaveRole(role); err != nil {
+				logger.Error("failed to grant local port forwarding", "error", err, "role", role.Name)
+				return
+			}
+			logger.Info("granted local port forwarding", "role", role.Name)
+		}
+	}
+
+	err = dbInstance.SaveCfgValue(&model.CfgValue{
+		Name:  "ssh_local_forward_granted",
+		Value: "true",
+	})
+	if err != nil {
+		logger.Error("failed to save ssh_local_forward_granted", "error", err)
+	}
+}
+
 // Returns the database driver and on first call initializes it
 func GetInstance() DbDriver {
 	initDrivers()
@@ -296,6 +349,33 @@ func IsSessionDriverShared() bool {
 	return false
 }
 
+// Open connects a new driver for the database described by cfg without
+// touching the global instance, used when working with two databases at once.
+// The drivers read their connection settings from the server configuration so
+// cfg is made current while connecting.
+func Open(cfg *config.ServerConfig) (DbDriver, error) {
+	var driver DbDriver
+	if cfg.MySQL.Enabled {
+		driver = &driver_mysql.MySQLDriver{}
+	} else if cfg.BadgerDB.Enabled {
+		driver = &driver_badgerdb.BadgerDbDriver{}
+	} else if cfg.Redis.Enabled {
+		driver = &driver_redis.RedisDbDriver{}
+	} else {
+		return nil, errors.New("no database enabled")
+	}
+
+	previous := config.GetServerConfig()
+	config.SetServerConfig(cfg)
+	defer config.SetServerConfig(previous)
+
+	if err := driver.Connect(); err != nil {
+		return nil, err
+	}
+
+	return driver, nil
+}
+
 func GetUserUsage(userId string, inZone string) (*model.Usage, error) {
 	db := GetInstance()
 
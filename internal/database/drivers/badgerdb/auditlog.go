@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,10 +23,30 @@ func (db *BadgerDbDriver) SaveAuditLog(auditLog *model.AuditLogEntry) error {
 		return nil
 	}
 
+	key, err := auditLogKey(auditLog.When)
+	if err != nil {
+		return err
+	}
+
+	err = db.connection.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(auditLog)
+		if err != nil {
+			return err
+		}
+
+		return txn.SetEntry(badger.NewEntry(key, data))
+	})
+
+	return err
+}
+
+// auditLogKey returns the key of the audit log entry written at when, keys
+// sort in time order.
+func auditLogKey(when time.Time) ([]byte, error) {
 	keyTimeBuffer := new(bytes.Buffer)
 	err := binary.Write(keyTimeBuffer, binary.BigEndian, []byte("AuditLogs:"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = binary.Write(keyTimeBuffer, binary.BigEndian, struct {
@@ -33,28 +54,19 @@ func (db *BadgerDbDriver) SaveAuditLog(auditLog *model.AuditLogEntry) error {
 		Month, Day, Hour, Minute, Second int8
 		Nanosecond                       int64
 	}{
-		int16(auditLog.When.Year()),
-		int8(auditLog.When.Month()),
-		int8(auditLog.When.Day()),
-		int8(auditLog.When.Hour()),
-		int8(auditLog.When.Minute()),
-		int8(auditLog.When.Second()),
-		auditLog.When.UnixMicro(),
+		int16(when.Year()),
+		int8(when.Month()),
+		int8(when.Day()),
+		int8(when.Hour()),
+		int8(when.Minute()),
+		int8(when.Second()),
+		when.UnixMicro(),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = db.connection.Update(func(txn *badger.Txn) error {
-		data, err := json.Marshal(auditLog)
-		if err != nil {
-			return err
-		}
-
-		return txn.SetEntry(badger.NewEntry(keyTimeBuffer.Bytes(), data))
-	})
-
-	return err
+	return keyTimeBuffer.Bytes(), nil
 }
 
 func (db *BadgerDbDriver) GetAuditLogs(filter *model.AuditLogFilter, offset, limit int) ([]*model.AuditLogEntry, int, error) {
@@ -167,3 +179,63 @@ func (db *BadgerDbDriver) deleteAuditLogs() error {
 		return nil
 	})
 }
+
+// GetAuditLogsAfter pages through the audit log oldest first by key, entries
+// written while paging are picked up by later pages rather than shifting them.
+func (db *BadgerDbDriver) GetAuditLogsAfter(after *model.AuditLogEntry, limit int) ([]*model.AuditLogEntry, error) {
+	var auditLogs []*model.AuditLogEntry
+
+	prefix := []byte("AuditLogs:")
+	seek := prefix
+	var afterKey []byte
+	if after != nil {
+		var err error
+		if afterKey, err = auditLogKey(after.When); err != nil {
+			return nil, err
+		}
+		seek = afterKey
+	}
+
+	err := db.connection.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(seek); it.ValidForPrefix(prefix) && len(auditLogs) < limit; it.Next() {
+			if afterKey != nil && bytes.Compare(it.Item().Key(), afterKey) <= 0 {
+				continue
+			}
+
+			var entry model.AuditLogEntry
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal audit log entry: %w", err)
+			}
+			auditLogs = append(auditLogs, &entry)
+		}
+		return nil
+	})
+
+	return auditLogs, err
+}
+
+// HasAuditLogEntry reports whether an entry is stored for the time of entry,
+// entries are keyed by time so there can only be one.
+func (db *BadgerDbDriver) HasAuditLogEntry(entry *model.AuditLogEntry) (bool, error) {
+	key, err := auditLogKey(entry.When)
+	if err != nil {
+		return false, err
+	}
+
+	err = db.connection.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
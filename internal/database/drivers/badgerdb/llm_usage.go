@@ -0,0 +1,87 @@
+package driver_badgerdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+func (db *BadgerDbDriver) SaveLLMUsage(usage *model.LLMUsage) error {
+	return db.connection.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+
+		ttl := time.Until(usage.Day.Add(model.LLMUsageRetention))
+		if ttl <= 0 {
+			return nil
+		}
+
+		return txn.SetEntry(badger.NewEntry([]byte(fmt.Sprintf("LLMUsage:%s", usage.Id)), data).WithTTL(ttl))
+	})
+}
+
+func (db *BadgerDbDriver) GetLLMUsage(id string) (*model.LLMUsage, error) {
+	var usage *model.LLMUsage
+
+	err := db.connection.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(fmt.Sprintf("LLMUsage:%s", id)))
+		if err != nil {
+			return err
+		}
+
+		obj := &model.LLMUsage{}
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, obj)
+		}); err != nil {
+			return err
+		}
+
+		usage = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (db *BadgerDbDriver) GetLLMUsageRange(from time.Time, to time.Time) ([]*model.LLMUsage, error) {
+	var usage []*model.LLMUsage
+	from = model.LLMUsageDay(from)
+	err := db.connection.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte("LLMUsage:")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			obj := &model.LLMUsage{}
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, obj)
+			}); err != nil {
+				return err
+			}
+			if obj.Day.Before(from) || obj.Day.After(to.UTC()) {
+				continue
+			}
+			usage = append(usage, obj)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Day.Before(usage[j].Day)
+	})
+	return usage, nil
+}
@@ -0,0 +1,91 @@
+package driver_badgerdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+func (db *BadgerDbDriver) SaveSpaceLogs(entries []*model.SpaceLogEntry) error {
+	cfg := config.GetServerConfig()
+	if cfg.SpaceLogs.Retention < 1 {
+		return nil
+	}
+
+	retention := time.Duration(cfg.SpaceLogs.Retention) * 24 * time.Hour
+
+	return db.connection.Update(func(txn *badger.Txn) error {
+		for _, entry := range entries {
+			ttl := time.Until(entry.CreatedAt.Add(retention))
+			if ttl <= 0 {
+				continue
+			}
+
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+
+			key := []byte(fmt.Sprintf("SpaceLogs:%s:%s", entry.SpaceId, entry.Id))
+			if err := txn.SetEntry(badger.NewEntry(key, data).WithTTL(ttl)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (db *BadgerDbDriver) GetSpaceLogs(spaceId string, filter *model.SpaceLogFilter, limit int) ([]*model.SpaceLogEntry, error) {
+	var entries []*model.SpaceLogEntry
+
+	err := db.connection.View(func(txn *badger.Txn) error {
+		prefix := []byte(fmt.Sprintf("SpaceLogs:%s:", spaceId))
+
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// Start from the cursor or the newest entry
+		seek := append(append([]byte{}, prefix...), 0xFF)
+		if filter != nil && filter.Before != "" {
+			seek = append(append([]byte{}, prefix...), filter.Before...)
+		}
+
+		for it.Seek(seek); it.ValidForPrefix(prefix); it.Next() {
+			var entry model.SpaceLogEntry
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal space log entry: %w", err)
+			}
+
+			// Entries are in time order so nothing older can match
+			if filter != nil && filter.From != nil && entry.CreatedAt.Before(*filter.From) {
+				break
+			}
+
+			if !entry.MatchesFilter(filter) {
+				continue
+			}
+
+			entries = append(entries, &entry)
+			if limit > 0 && len(entries) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
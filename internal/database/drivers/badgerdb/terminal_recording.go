@@ -0,0 +1,117 @@
+package driver_badgerdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+func (db *BadgerDbDriver) SaveTerminalRecording(recording *model.TerminalRecording) error {
+	return db.connection.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(recording)
+		if err != nil {
+			return err
+		}
+
+		ttl := time.Until(recording.ExpiresAt)
+		if ttl <= 0 {
+			return nil
+		}
+
+		entry := badger.NewEntry([]byte(fmt.Sprintf("TerminalRecording:%s", recording.Id)), data).WithTTL(ttl)
+		if err := txn.SetEntry(entry); err != nil {
+			return err
+		}
+
+		entry = badger.NewEntry([]byte(fmt.Sprintf("TerminalRecordingData:%s", recording.Id)), recording.Data).WithTTL(ttl)
+		if err := txn.SetEntry(entry); err != nil {
+			return err
+		}
+
+		idx := badger.NewEntry([]byte(fmt.Sprintf("TerminalRecordingsBySpace:%s:%s", recording.SpaceId, recording.Id)), []byte(recording.Id)).WithTTL(ttl)
+		return txn.SetEntry(idx)
+	})
+}
+
+func (db *BadgerDbDriver) GetTerminalRecording(id string) (*model.TerminalRecording, error) {
+	var recording *model.TerminalRecording
+
+	err := db.connection.View(func(txn *badger.Txn) error {
+		obj, err := getTerminalRecording(txn, id)
+		if err != nil {
+			return err
+		}
+
+		item, err := txn.Get([]byte(fmt.Sprintf("TerminalRecordingData:%s", id)))
+		if err != nil {
+			return err
+		}
+		if obj.Data, err = item.ValueCopy(nil); err != nil {
+			return err
+		}
+
+		recording = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recording, nil
+}
+
+func (db *BadgerDbDriver) GetTerminalRecordingsForSpace(spaceId string) ([]*model.TerminalRecording, error) {
+	var recordings []*model.TerminalRecording
+
+	err := db.connection.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(fmt.Sprintf("TerminalRecordingsBySpace:%s:", spaceId))
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var recordingId string
+			if err := it.Item().Value(func(val []byte) error {
+				recordingId = string(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			recording, err := getTerminalRecording(txn, recordingId)
+			if err != nil {
+				continue
+			}
+			recordings = append(recordings, recording)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+func getTerminalRecording(txn *badger.Txn, id string) (*model.TerminalRecording, error) {
+	item, err := txn.Get([]byte(fmt.Sprintf("TerminalRecording:%s", id)))
+	if err != nil {
+		return nil, err
+	}
+
+	recording := &model.TerminalRecording{}
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, recording)
+	}); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
@@ -108,3 +108,41 @@ func (db *MySQLDriver) GetAuditLogsForExport(filter *model.AuditLogFilter) ([]*m
 	}
 	return auditLogs, nil
 }
+
+// GetAuditLogsAfter pages through the audit log oldest first by id, entries
+// written while paging are picked up by later pages rather than shifting them.
+func (db *MySQLDriver) GetAuditLogsAfter(after *model.AuditLogEntry, limit int) ([]*model.AuditLogEntry, error) {
+	var lastId int64
+	if after != nil {
+		lastId = after.Id
+	}
+
+	var auditLogs []*model.AuditLogEntry
+	err := db.read("audit_logs", &auditLogs, nil, fmt.Sprintf("audit_log_id > ? ORDER BY audit_log_id ASC LIMIT %d", limit), lastId)
+	if err != nil {
+		return nil, err
+	}
+	return auditLogs, nil
+}
+
+// HasAuditLogEntry reports whether the entry is already stored, matching on the
+// id and time or, for entries from a driver without ids, the time and event.
+func (db *MySQLDriver) HasAuditLogEntry(entry *model.AuditLogEntry) (bool, error) {
+	var count int
+	var err error
+	if entry.Id > 0 {
+		err = db.connection.QueryRow(
+			"SELECT COUNT(*) FROM audit_logs WHERE audit_log_id = ? AND ABS(TIMESTAMPDIFF(MICROSECOND, created_at, ?)) <= 1",
+			entry.Id, entry.When.UTC(),
+		).Scan(&count)
+	} else {
+		err = db.connection.QueryRow(
+			"SELECT COUNT(*) FROM audit_logs WHERE ABS(TIMESTAMPDIFF(MICROSECOND, created_at, ?)) <= 1 AND actor = ? AND event = ?",
+			entry.When.UTC(), entry.Actor, entry.Event,
+		).Scan(&count)
+	}
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -110,6 +110,8 @@ name VARCHAR(64) NOT NULL,
 template_id CHAR(36) NOT NULL,
 startup_script_id CHAR(36) DEFAULT '',
 desired_count INT UNSIGNED NOT NULL DEFAULT 1,
+autoscale JSON DEFAULT NULL,
+last_scaled_at TIMESTAMP(6) NULL DEFAULT NULL,
 active TINYINT(1) NOT NULL DEFAULT 1,
 zone VARCHAR(64) DEFAULT '',
 is_deleted TINYINT(1) NOT NULL DEFAULT 0,
@@ -148,6 +150,7 @@ activity_space_stops INT UNSIGNED NOT NULL DEFAULT 0,
 activity_space_creates INT UNSIGNED NOT NULL DEFAULT 0,
 activity_space_deletes INT UNSIGNED NOT NULL DEFAULT 0,
 last_activity_at TIMESTAMP(6) DEFAULT NULL,
+custom_metrics JSON DEFAULT NULL,
 created_at TIMESTAMP(6),
 updated_at BIGINT UNSIGNED DEFAULT 0,
 INDEX idx_space_usage_space_time (space_id, bucket_kind, bucket_start),
@@ -158,6 +161,59 @@ INDEX idx_space_usage_bucket (bucket_kind, bucket_start)
 		return err
 	}
 
+	db.logger.Debug("ensuring terminal recordings table exists")
+	_, err = db.connection.Exec(`CREATE TABLE IF NOT EXISTS terminal_recordings (
+recording_id CHAR(36) PRIMARY KEY,
+space_id CHAR(36) NOT NULL,
+username VARCHAR(255) NOT NULL DEFAULT '',
+source VARCHAR(16) NOT NULL DEFAULT '',
+started_at TIMESTAMP(6) NOT NULL,
+duration DOUBLE NOT NULL DEFAULT 0,
+size INT UNSIGNED NOT NULL DEFAULT 0,
+truncated TINYINT(1) NOT NULL DEFAULT 0,
+expires_at TIMESTAMP(6) NOT NULL,
+data LONGBLOB,
+INDEX idx_terminal_recordings_space (space_id, started_at),
+INDEX idx_terminal_recordings_expires (expires_at)
+)`)
+	if err != nil {
+		return err
+	}
+
+	db.logger.Debug("ensuring space logs table exists")
+	_, err = db.connection.Exec(`CREATE TABLE IF NOT EXISTS space_logs (
+log_id CHAR(36) PRIMARY KEY,
+space_id CHAR(36) NOT NULL,
+level VARCHAR(8) NOT NULL DEFAULT '',
+source VARCHAR(255) NOT NULL DEFAULT '',
+message MEDIUMTEXT,
+created_at TIMESTAMP(6) NOT NULL,
+INDEX idx_space_logs_space (space_id, log_id),
+INDEX idx_space_logs_created (created_at)
+)`)
+	if err != nil {
+		return err
+	}
+
+	db.logger.Debug("ensuring llm usage table exists")
+	_, err = db.connection.Exec(`CREATE TABLE IF NOT EXISTS llm_usage (
+llm_usage_id CHAR(36) PRIMARY KEY,
+node_id CHAR(36) NOT NULL DEFAULT '',
+user_id CHAR(36) NOT NULL,
+model VARCHAR(255) NOT NULL DEFAULT '',
+day TIMESTAMP(6) NOT NULL,
+requests BIGINT UNSIGNED NOT NULL DEFAULT 0,
+prompt_tokens BIGINT UNSIGNED NOT NULL DEFAULT 0,
+completion_tokens BIGINT UNSIGNED NOT NULL DEFAULT 0,
+total_tokens BIGINT UNSIGNED NOT NULL DEFAULT 0,
+updated_at BIGINT UNSIGNED DEFAULT 0,
+INDEX idx_llm_usage_day (day),
+INDEX idx_llm_usage_user_day (user_id, day)
+)`)
+	if err != nil {
+		return err
+	}
+
 	db.logger.Debug("ensuring templates table exists")
 	_, err = db.connection.Exec(`CREATE TABLE IF NOT EXISTS templates (
 template_id CHAR(36) PRIMARY KEY,
@@ -177,6 +233,9 @@ with_vscode_tunnel TINYINT(1) NOT NULL DEFAULT 0,
 with_code_server TINYINT(1) NOT NULL DEFAULT 0,
 with_ssh TINYINT(1) NOT NULL DEFAULT 0,
 with_run_command TINYINT(1) NOT NULL DEFAULT 0,
+disable_ssh_local_forward TINYINT(1) NOT NULL DEFAULT 0,
+with_ssh_reverse_forward TINYINT(1) NOT NULL DEFAULT 0,
+with_terminal_recording TINYINT(1) NOT NULL DEFAULT 0,
 allow_node_migration TINYINT(1) NOT NULL DEFAULT 0,
 startup_script_id CHAR(36) DEFAULT '',
 shutdown_script_id CHAR(36) DEFAULT '',
@@ -200,6 +259,7 @@ health_check_max_failures INT UNSIGNED NOT NULL DEFAULT 3,
 health_check_auto_restart TINYINT(1) NOT NULL DEFAULT 0,
     disable_user_activity TINYINT(1) NOT NULL DEFAULT 0,
     ports JSON NOT NULL DEFAULT '[]',
+    idle_policy JSON DEFAULT NULL,
     created_user_id CHAR(36),
 created_at TIMESTAMP(6),
 updated_user_id CHAR(36),
@@ -218,6 +278,9 @@ max_spaces INT UNSIGNED NOT NULL DEFAULT 0,
 compute_units INT UNSIGNED NOT NULL DEFAULT 0,
 storage_units INT UNSIGNED NOT NULL DEFAULT 0,
 max_tunnels INT UNSIGNED NOT NULL DEFAULT 0,
+daily_token_budget BIGINT UNSIGNED NOT NULL DEFAULT 0,
+monthly_token_budget BIGINT UNSIGNED NOT NULL DEFAULT 0,
+allowed_models JSON DEFAULT NULL,
 is_deleted TINYINT(1) NOT NULL DEFAULT 0,
 created_user_id CHAR(36),
 created_at TIMESTAMP(6),
@@ -566,6 +629,23 @@ INDEX namespace_user (namespace, user_id)
 			if err != nil {
 				goto again
 			}
+
+			err = db.cleanupExpiredLLMUsage()
+			if err != nil {
+				goto again
+			}
+
+			_, err = db.connection.Exec("DELETE FROM terminal_recordings WHERE expires_at < ?", now)
+			if err != nil {
+				goto again
+			}
+
+			if cfg.SpaceLogs.Retention > 0 {
+				_, err = db.connection.Exec("DELETE FROM space_logs WHERE created_at < ?", now.Add(-time.Duration(24*cfg.SpaceLogs.Retention)*time.Hour))
+				if err != nil {
+					goto again
+				}
+			}
 		}
 	}()
 
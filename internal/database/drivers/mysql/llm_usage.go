@@ -0,0 +1,67 @@
+package driver_mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (db *MySQLDriver) SaveLLMUsage(usage *model.LLMUsage) error {
+	_, err := db.connection.Exec(`INSERT INTO llm_usage (
+llm_usage_id,
+node_id,
+user_id,
+model,
+day,
+requests,
+prompt_tokens,
+completion_tokens,
+total_tokens,
+updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+requests = VALUES(requests),
+prompt_tokens = VALUES(prompt_tokens),
+completion_tokens = VALUES(completion_tokens),
+total_tokens = VALUES(total_tokens),
+updated_at = VALUES(updated_at)`,
+		usage.Id,
+		usage.NodeId,
+		usage.UserId,
+		usage.Model,
+		usage.Day.UTC(),
+		usage.Requests,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.TotalTokens,
+		usage.UpdatedAt,
+	)
+	return err
+}
+
+func (db *MySQLDriver) GetLLMUsage(id string) (*model.LLMUsage, error) {
+	var usage []*model.LLMUsage
+	err := db.read("llm_usage", &usage, nil, "llm_usage_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(usage) == 0 {
+		return nil, fmt.Errorf("llm usage not found")
+	}
+	return usage[0], nil
+}
+
+func (db *MySQLDriver) GetLLMUsageRange(from time.Time, to time.Time) ([]*model.LLMUsage, error) {
+	var usage []*model.LLMUsage
+	err := db.read("llm_usage", &usage, nil, "day >= ? AND day <= ? ORDER BY day ASC", model.LLMUsageDay(from), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (db *MySQLDriver) cleanupExpiredLLMUsage() error {
+	_, err := db.connection.Exec("DELETE FROM llm_usage WHERE day < ?", time.Now().UTC().Add(-model.LLMUsageRetention))
+	return err
+}
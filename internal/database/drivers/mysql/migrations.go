@@ -124,6 +124,32 @@ var migrations = []string{
 	`ALTER TABLE mcp_servers ADD COLUMN IF NOT EXISTS env JSON NOT NULL DEFAULT '[]'`,
 	// 59: add generic preferences JSON column to users (UI prefs, e.g. pinned nav items)
 	`ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences JSON DEFAULT NULL`,
+	// 60: add idle auto-stop policy to templates
+	`ALTER TABLE templates ADD COLUMN IF NOT EXISTS idle_policy JSON DEFAULT NULL`,
+	// 61: add autoscaling policy to pools
+	`ALTER TABLE pools ADD COLUMN IF NOT EXISTS autoscale JSON DEFAULT NULL`,
+	// 62: add last autoscale time to pools
+	`ALTER TABLE pools ADD COLUMN IF NOT EXISTS last_scaled_at TIMESTAMP(6) NULL DEFAULT NULL`,
+	// 63: add space restriction to tokens (NULL = any space)
+	`ALTER TABLE tokens ADD COLUMN IF NOT EXISTS spaces JSON DEFAULT NULL`,
+	// 64: add stack restriction to tokens (NULL = any stack)
+	`ALTER TABLE tokens ADD COLUMN IF NOT EXISTS stacks JSON DEFAULT NULL`,
+	// 65: add source address restriction to tokens
+	`ALTER TABLE tokens ADD COLUMN IF NOT EXISTS source_cidr VARCHAR(64) NOT NULL DEFAULT ''`,
+	// 66: add SSH local forwarding switch to templates
+	`ALTER TABLE templates ADD COLUMN IF NOT EXISTS disable_ssh_local_forward TINYINT(1) NOT NULL DEFAULT 0`,
+	// 67: add SSH reverse forwarding switch to templates
+	`ALTER TABLE templates ADD COLUMN IF NOT EXISTS with_ssh_reverse_forward TINYINT(1) NOT NULL DEFAULT 0`,
+	// 68: add terminal session recording switch to templates
+	`ALTER TABLE templates ADD COLUMN IF NOT EXISTS with_terminal_recording TINYINT(1) NOT NULL DEFAULT 0`,
+	// 69: add application metrics to space usage samples
+	`ALTER TABLE space_usage ADD COLUMN IF NOT EXISTS custom_metrics JSON DEFAULT NULL`,
+	// 70: add daily AI token budget to groups
+	`ALTER TABLE groups ADD COLUMN IF NOT EXISTS daily_token_budget BIGINT UNSIGNED NOT NULL DEFAULT 0`,
+	// 71: add monthly AI token budget to groups
+	`ALTER TABLE groups ADD COLUMN IF NOT EXISTS monthly_token_budget BIGINT UNSIGNED NOT NULL DEFAULT 0`,
+	// 72: add AI model allowlist to groups (NULL = all models)
+	`ALTER TABLE groups ADD COLUMN IF NOT EXISTS allowed_models JSON DEFAULT NULL`,
 }
 
 func (db *MySQLDriver) runMigrations() error {
@@ -0,0 +1,76 @@
+package driver_mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (db *MySQLDriver) SaveSpaceLogs(entries []*model.SpaceLogEntry) error {
+	cfg := config.GetServerConfig()
+	if cfg.SpaceLogs.Retention < 1 || len(entries) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(entries))
+	args := make([]interface{}, 0, len(entries)*6)
+	for i, entry := range entries {
+		placeholders[i] = "(?, ?, ?, ?, ?, ?)"
+		args = append(args, entry.Id, entry.SpaceId, entry.Level, entry.Source, entry.Message, entry.CreatedAt.UTC())
+	}
+
+	_, err := db.connection.Exec(
+		"INSERT IGNORE INTO space_logs (log_id, space_id, level, source, message, created_at) VALUES "+strings.Join(placeholders, ", "),
+		args...,
+	)
+	return err
+}
+
+func (db *MySQLDriver) GetSpaceLogs(spaceId string, filter *model.SpaceLogFilter, limit int) ([]*model.SpaceLogEntry, error) {
+	clauses := []string{"space_id = ?"}
+	args := []interface{}{spaceId}
+
+	if filter != nil {
+		if filter.Before != "" {
+			clauses = append(clauses, "log_id < ?")
+			args = append(args, filter.Before)
+		}
+		if filter.Level != "" {
+			levels := model.SpaceLogLevelsFrom(filter.Level)
+			clauses = append(clauses, "level IN (?"+strings.Repeat(", ?", len(levels)-1)+")")
+			for _, level := range levels {
+				args = append(args, level)
+			}
+		}
+		if filter.Source != "" {
+			clauses = append(clauses, "source = ?")
+			args = append(args, filter.Source)
+		}
+		if filter.From != nil {
+			clauses = append(clauses, "created_at >= ?")
+			args = append(args, filter.From.UTC())
+		}
+		if filter.To != nil {
+			clauses = append(clauses, "created_at <= ?")
+			args = append(args, filter.To.UTC())
+		}
+		if filter.Query != "" {
+			// LOCATE rather than LIKE so % and _ in the query aren't wildcards
+			clauses = append(clauses, "LOCATE(LOWER(?), LOWER(message)) > 0")
+			args = append(args, filter.Query)
+		}
+	}
+
+	where := strings.Join(clauses, " AND ") + " ORDER BY log_id DESC"
+	if limit > 0 {
+		where += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	var entries []*model.SpaceLogEntry
+	if err := db.read("space_logs", &entries, nil, where, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
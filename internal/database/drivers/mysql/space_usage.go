@@ -1,6 +1,7 @@
 package driver_mysql
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -29,9 +30,10 @@ activity_space_stops,
 activity_space_creates,
 activity_space_deletes,
 last_activity_at,
+custom_metrics,
 created_at,
 updated_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
 space_id = VALUES(space_id),
 user_id = VALUES(user_id),
@@ -52,9 +54,19 @@ activity_space_stops = VALUES(activity_space_stops),
 activity_space_creates = VALUES(activity_space_creates),
 activity_space_deletes = VALUES(activity_space_deletes),
 last_activity_at = VALUES(last_activity_at),
+custom_metrics = VALUES(custom_metrics),
 created_at = VALUES(created_at),
 updated_at = VALUES(updated_at)`
 
+	var customMetrics interface{}
+	if len(sample.CustomMetrics) > 0 {
+		data, err := json.Marshal(sample.CustomMetrics)
+		if err != nil {
+			return err
+		}
+		customMetrics = string(data)
+	}
+
 	_, err := db.connection.Exec(
 		query,
 		sample.Id,
@@ -77,6 +89,7 @@ updated_at = VALUES(updated_at)`
 		sample.ActivitySpaceCreates,
 		sample.ActivitySpaceDeletes,
 		nullableDatabaseTime(sample.LastActivityAt, "2006-01-02 15:04:05.000000"),
+		customMetrics,
 		sample.CreatedAt.UTC(),
 		sample.UpdatedAt,
 	)
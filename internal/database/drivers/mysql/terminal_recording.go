@@ -0,0 +1,47 @@
+package driver_mysql
+
+import (
+	"fmt"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (db *MySQLDriver) SaveTerminalRecording(recording *model.TerminalRecording) error {
+	var doUpdate bool
+	err := db.connection.QueryRow("SELECT EXISTS(SELECT 1 FROM terminal_recordings WHERE recording_id=?)", recording.Id).Scan(&doUpdate)
+	if err != nil {
+		return err
+	}
+
+	if doUpdate {
+		return db.update("terminal_recordings", recording, nil)
+	}
+	return db.create("terminal_recordings", recording)
+}
+
+func (db *MySQLDriver) GetTerminalRecording(id string) (*model.TerminalRecording, error) {
+	var recordings []*model.TerminalRecording
+	err := db.read("terminal_recordings", &recordings, nil, "recording_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("terminal recording not found")
+	}
+	return recordings[0], nil
+}
+
+func (db *MySQLDriver) GetTerminalRecordingsForSpace(spaceId string) ([]*model.TerminalRecording, error) {
+	var recordings []*model.TerminalRecording
+	err := db.read(
+		"terminal_recordings",
+		&recordings,
+		[]string{"Id", "SpaceId", "Username", "Source", "StartedAt", "Duration", "Size", "Truncated", "ExpiresAt"},
+		"space_id = ? ORDER BY started_at DESC",
+		spaceId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return recordings, nil
+}
@@ -19,3 +19,11 @@ func (db *RedisDbDriver) GetAuditLogs(filter *model.AuditLogFilter, offset int,
 func (db *RedisDbDriver) GetAuditLogsForExport(filter *model.AuditLogFilter) ([]*model.AuditLogEntry, error) {
 	return []*model.AuditLogEntry{}, nil
 }
+
+func (db *RedisDbDriver) GetAuditLogsAfter(after *model.AuditLogEntry, limit int) ([]*model.AuditLogEntry, error) {
+	return []*model.AuditLogEntry{}, nil
+}
+
+func (db *RedisDbDriver) HasAuditLogEntry(entry *model.AuditLogEntry) (bool, error) {
+	return false, nil
+}
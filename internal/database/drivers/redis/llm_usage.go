@@ -0,0 +1,69 @@
+package driver_redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (db *RedisDbDriver) SaveLLMUsage(usage *model.LLMUsage) error {
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(usage.Day.Add(model.LLMUsageRetention))
+	if ttl <= 0 {
+		return nil
+	}
+
+	return db.connection.Set(context.Background(), fmt.Sprintf("%sLLMUsage:%s", db.prefix, usage.Id), data, ttl).Err()
+}
+
+func (db *RedisDbDriver) GetLLMUsage(id string) (*model.LLMUsage, error) {
+	v, err := db.connection.Get(context.Background(), fmt.Sprintf("%sLLMUsage:%s", db.prefix, id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var usage model.LLMUsage
+	if err := json.Unmarshal([]byte(v), &usage); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+func (db *RedisDbDriver) GetLLMUsageRange(from time.Time, to time.Time) ([]*model.LLMUsage, error) {
+	var usage []*model.LLMUsage
+	from = model.LLMUsageDay(from)
+	iter := db.connection.Scan(context.Background(), 0, fmt.Sprintf("%sLLMUsage:*", db.prefix), 0).Iterator()
+	for iter.Next(context.Background()) {
+		v, err := db.connection.Get(context.Background(), iter.Val()).Result()
+		if err != nil {
+			continue
+		}
+
+		var obj model.LLMUsage
+		if err := json.Unmarshal([]byte(v), &obj); err != nil {
+			return nil, err
+		}
+		if obj.Day.Before(from) || obj.Day.After(to.UTC()) {
+			continue
+		}
+		usage = append(usage, &obj)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Day.Before(usage[j].Day)
+	})
+
+	return usage, nil
+}
@@ -0,0 +1,100 @@
+package driver_redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Space logs are held in a sorted set per space with every score 0, so
+// members sort by their leading log id and lex ranges select by time.
+const spaceLogIdLength = 36
+
+func (db *RedisDbDriver) SaveSpaceLogs(entries []*model.SpaceLogEntry) error {
+	cfg := config.GetServerConfig()
+	if cfg.SpaceLogs.Retention < 1 || len(entries) == 0 {
+		return nil
+	}
+
+	retention := time.Duration(cfg.SpaceLogs.Retention) * 24 * time.Hour
+	ctx := context.Background()
+
+	bySpace := make(map[string][]redis.Z)
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		bySpace[entry.SpaceId] = append(bySpace[entry.SpaceId], redis.Z{Member: entry.Id + string(data)})
+	}
+
+	cutoff := model.SpaceLogIdPrefix(time.Now().Add(-retention))
+	for spaceId, members := range bySpace {
+		key := fmt.Sprintf("%sSpaceLogs:%s", db.prefix, spaceId)
+
+		pipe := db.connection.TxPipeline()
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByLex(ctx, key, "-", "("+cutoff)
+		pipe.Expire(ctx, key, retention)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *RedisDbDriver) GetSpaceLogs(spaceId string, filter *model.SpaceLogFilter, limit int) ([]*model.SpaceLogEntry, error) {
+	var entries []*model.SpaceLogEntry
+
+	key := fmt.Sprintf("%sSpaceLogs:%s", db.prefix, spaceId)
+	max, min := "+", "-"
+	if filter != nil && filter.Before != "" {
+		max = "(" + filter.Before
+	}
+	if filter != nil && filter.From != nil {
+		min = "[" + model.SpaceLogIdPrefix(*filter.From)
+	}
+
+	const batchSize = 500
+	for offset := int64(0); ; offset += batchSize {
+		members, err := db.connection.ZRevRangeByLex(context.Background(), key, &redis.ZRangeBy{
+			Max:    max,
+			Min:    min,
+			Offset: offset,
+			Count:  batchSize,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			if len(member) <= spaceLogIdLength {
+				continue
+			}
+
+			var entry model.SpaceLogEntry
+			if err := json.Unmarshal([]byte(member[spaceLogIdLength:]), &entry); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal space log entry: %w", err)
+			}
+			if !entry.MatchesFilter(filter) {
+				continue
+			}
+
+			entries = append(entries, &entry)
+			if limit > 0 && len(entries) >= limit {
+				return entries, nil
+			}
+		}
+
+		if len(members) < batchSize {
+			return entries, nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package driver_redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func (db *RedisDbDriver) SaveTerminalRecording(recording *model.TerminalRecording) error {
+	data, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(recording.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := db.connection.Set(context.Background(), fmt.Sprintf("%sTerminalRecording:%s", db.prefix, recording.Id), data, ttl).Err(); err != nil {
+		return err
+	}
+	if err := db.connection.Set(context.Background(), fmt.Sprintf("%sTerminalRecordingData:%s", db.prefix, recording.Id), recording.Data, ttl).Err(); err != nil {
+		return err
+	}
+
+	return db.connection.Set(context.Background(), fmt.Sprintf("%sTerminalRecordingsBySpace:%s:%s", db.prefix, recording.SpaceId, recording.Id), recording.Id, ttl).Err()
+}
+
+func (db *RedisDbDriver) GetTerminalRecording(id string) (*model.TerminalRecording, error) {
+	recording, err := db.getTerminalRecording(id)
+	if err != nil {
+		return nil, err
+	}
+
+	recording.Data, err = db.connection.Get(context.Background(), fmt.Sprintf("%sTerminalRecordingData:%s", db.prefix, id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return recording, nil
+}
+
+func (db *RedisDbDriver) GetTerminalRecordingsForSpace(spaceId string) ([]*model.TerminalRecording, error) {
+	var recordings []*model.TerminalRecording
+
+	prefix := fmt.Sprintf("%sTerminalRecordingsBySpace:%s:", db.prefix, spaceId)
+	iter := db.connection.Scan(context.Background(), 0, prefix+"*", 0).Iterator()
+	for iter.Next(context.Background()) {
+		recording, err := db.getTerminalRecording(iter.Val()[len(prefix):])
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recording)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, nil
+}
+
+func (db *RedisDbDriver) getTerminalRecording(id string) (*model.TerminalRecording, error) {
+	v, err := db.connection.Get(context.Background(), fmt.Sprintf("%sTerminalRecording:%s", db.prefix, id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var recording model.TerminalRecording
+	if err := json.Unmarshal([]byte(v), &recording); err != nil {
+		return nil, err
+	}
+
+	return &recording, nil
+}
@@ -47,6 +47,10 @@ const (
 	AuditEventSpaceTransfer  = "Space Transfer"
 	AuditEventSpaceShare     = "Space Shared"
 	AuditEventSpaceStopShare = "Space Stop Share"
+	AuditEventSpaceRecording = "Space Terminal Recording"
+
+	AuditEventTerminalSessionDetach = "Terminal Session Detach"
+	AuditEventTerminalSessionClose  = "Terminal Session Close"
 
 	// Templates
 	AuditEventTemplateCreate = "Template Create"
@@ -107,6 +111,14 @@ const (
 	AuditEventEventSinkDeliveryFailed = "Event Sink Delivery Failed"
 	AuditEventEventSinkScriptFailed   = "Event Sink Script Failed"
 	AuditEventEventSinkDropped        = "Event Sink Dropped"
+
+	// Pools
+	AuditEventPoolScale = "Pool Scale"
+
+	// SSH
+	AuditEventSSHCertIssue      = "SSH Certificate Issue"
+	AuditEventSSHLocalForward   = "SSH Local Forward"
+	AuditEventSSHReverseForward = "SSH Reverse Forward"
 )
 
 type AuditLogFilter struct {
@@ -21,6 +21,13 @@ type Group struct {
 	CreatedAt     time.Time     `json:"created_at" db:"created_at" msgpack:"created_at"`
 	UpdatedUserId string        `json:"updated_user_id" db:"updated_user_id" msgpack:"updated_user_id"`
 	UpdatedAt     hlc.Timestamp `json:"updated_at" db:"updated_at" msgpack:"updated_at"`
+
+	// AI token budgets shared by the members of the group, 0 is unlimited
+	DailyTokenBudget   uint64 `json:"daily_token_budget" db:"daily_token_budget" msgpack:"daily_token_budget"`
+	MonthlyTokenBudget uint64 `json:"monthly_token_budget" db:"monthly_token_budget" msgpack:"monthly_token_budget"`
+
+	// AI models the members of the group may use, empty allows all models
+	AllowedModels []string `json:"allowed_models" db:"allowed_models,json" msgpack:"allowed_models"`
 }
 
 func NewGroup(name string, userId string, maxSpaces uint32, computeUnits uint32, storageUnits uint32, maxTunnels uint32) *Group {
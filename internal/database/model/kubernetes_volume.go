@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//
+// Define the data structures for Kubernetes persistent volume claims
+//
+
+type KubernetesVolume struct {
+	Name         string   `yaml:"name"`
+	Namespace    string   `yaml:"namespace,omitempty"`
+	StorageClass string   `yaml:"storage_class,omitempty"`
+	Size         string   `yaml:"size"`
+	AccessModes  []string `yaml:"access_modes,omitempty"`
+}
+
+type KubernetesVolumes struct {
+	Volumes []KubernetesVolume `yaml:"volumes"`
+}
+
+func LoadKubernetesVolumesFromYaml(yamlData string, t *Template, space *Space, user *User, variables map[string]interface{}) (*KubernetesVolumes, error) {
+	var err error
+	volumes := &KubernetesVolumes{}
+
+	if space != nil || user != nil {
+		yamlData, err = ResolveVariables(yamlData, t, space, user, variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = yaml.Unmarshal([]byte(yamlData), volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range volumes.Volumes {
+		volumes.Volumes[i].Name = strings.TrimSpace(volumes.Volumes[i].Name)
+		if volumes.Volumes[i].Name == "" {
+			return nil, fmt.Errorf("volume name must be set")
+		}
+		if strings.TrimSpace(volumes.Volumes[i].Size) == "" {
+			return nil, fmt.Errorf("volume %s must set a size", volumes.Volumes[i].Name)
+		}
+
+		// Default to a volume that can be mounted by a single node
+		if len(volumes.Volumes[i].AccessModes) == 0 {
+			volumes.Volumes[i].AccessModes = []string{"ReadWriteOnce"}
+		}
+	}
+
+	return volumes, nil
+}
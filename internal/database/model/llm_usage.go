@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paularlott/gossip/hlc"
+)
+
+const LLMUsageRetention = 400 * 24 * time.Hour
+
+var llmUsageNamespace = uuid.MustParse("6f1c2b7e-3d0a-4e8f-9b5c-2a7d4e1f8c90")
+
+// LLMUsage is the token usage of one user against one model for a day as
+// recorded by a single server node.
+//
+// Each node only ever writes its own rows so the rows of all nodes add up to
+// the cluster wide usage and replicas are merged by keeping the newest copy.
+type LLMUsage struct {
+	Id               string        `json:"llm_usage_id" db:"llm_usage_id,pk" msgpack:"llm_usage_id"`
+	NodeId           string        `json:"node_id" db:"node_id" msgpack:"node_id"`
+	UserId           string        `json:"user_id" db:"user_id" msgpack:"user_id"`
+	Model            string        `json:"model" db:"model" msgpack:"model"`
+	Day              time.Time     `json:"day" db:"day" msgpack:"day"`
+	Requests         uint64        `json:"requests" db:"requests" msgpack:"requests"`
+	PromptTokens     uint64        `json:"prompt_tokens" db:"prompt_tokens" msgpack:"prompt_tokens"`
+	CompletionTokens uint64        `json:"completion_tokens" db:"completion_tokens" msgpack:"completion_tokens"`
+	TotalTokens      uint64        `json:"total_tokens" db:"total_tokens" msgpack:"total_tokens"`
+	UpdatedAt        hlc.Timestamp `json:"updated_at" db:"updated_at" msgpack:"updated_at"`
+}
+
+func NewLLMUsage(nodeId, userId, modelName string, at time.Time) *LLMUsage {
+	day := LLMUsageDay(at)
+	return &LLMUsage{
+		Id:        LLMUsageId(nodeId, userId, modelName, day),
+		NodeId:    nodeId,
+		UserId:    userId,
+		Model:     modelName,
+		Day:       day,
+		UpdatedAt: hlc.Now(),
+	}
+}
+
+// LLMUsageId returns the id of the usage row for the node, user, model and
+// day, the same inputs always give the same id.
+func LLMUsageId(nodeId, userId, modelName string, at time.Time) string {
+	key := nodeId + "/" + userId + "/" + modelName + "/" + LLMUsageDay(at).Format("20060102")
+	return uuid.NewSHA1(llmUsageNamespace, []byte(key)).String()
+}
+
+// LLMUsageDay returns the start of the UTC day containing t.
+func LLMUsageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// LLMUsageMonth returns the start of the UTC month containing t.
+func LLMUsageMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (u *LLMUsage) Add(promptTokens, completionTokens, totalTokens uint64) {
+	if totalTokens < promptTokens+completionTokens {
+		totalTokens = promptTokens + completionTokens
+	}
+
+	u.Requests++
+	u.PromptTokens += promptTokens
+	u.CompletionTokens += completionTokens
+	u.TotalTokens += totalTokens
+	u.UpdatedAt = hlc.Now()
+}
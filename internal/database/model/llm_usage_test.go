@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLLMUsageId_sameDay(t *testing.T) {
+	morning := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+
+	if LLMUsageId("node", "user", "gpt", morning) != LLMUsageId("node", "user", "gpt", evening) {
+		t.Error("expected the same id for the same day")
+	}
+	if LLMUsageId("node", "user", "gpt", morning) == LLMUsageId("node", "user", "gpt", evening.Add(2*time.Hour)) {
+		t.Error("expected a different id for the next day")
+	}
+	if LLMUsageId("node", "user", "gpt", morning) == LLMUsageId("other", "user", "gpt", morning) {
+		t.Error("expected a different id for another node")
+	}
+}
+
+func TestLLMUsageMonth(t *testing.T) {
+	at := time.Date(2026, 3, 15, 12, 30, 0, 0, time.FixedZone("X", 3600))
+	want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := LLMUsageMonth(at); !got.Equal(want) {
+		t.Errorf("LLMUsageMonth() = %v, want %v", got, want)
+	}
+	if got := LLMUsageDay(at); !got.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("LLMUsageDay() = %v", got)
+	}
+}
+
+func TestLLMUsage_Add(t *testing.T) {
+	usage := NewLLMUsage("node", "user", "gpt", time.Now())
+	usage.Add(10, 5, 0)
+	usage.Add(20, 10, 40)
+
+	if usage.Requests != 2 || usage.PromptTokens != 30 || usage.CompletionTokens != 15 {
+		t.Errorf("unexpected totals %+v", usage)
+	}
+	if usage.TotalTokens != 55 {
+		t.Errorf("TotalTokens = %d, want 55", usage.TotalTokens)
+	}
+}
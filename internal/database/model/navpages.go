@@ -40,6 +40,7 @@ func VisibleNavPages(user *User, cfg *config.ServerConfig, auditAvailable bool)
 		{"/groups", "Groups", user.HasPermission(PermissionManageGroups) && !leaf},
 		{"/roles", "Roles", user.HasPermission(PermissionManageRoles) && !leaf},
 		{"/audit-logs", "Audit Logs", user.HasPermission(PermissionViewAuditLogs) && auditAvailable},
+		{"/llm-usage", "AI Usage", user.HasPermission(PermissionViewAIUsage) && cfg.Chat.Enabled && !leaf},
 		{"/cluster-info", "Cluster Info", user.HasPermission(PermissionClusterInfo) && cfg.Cluster.AdvertiseAddr != "" && !leaf},
 	}
 
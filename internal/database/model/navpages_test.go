@@ -66,6 +66,20 @@ func TestVisibleNavPages_AuditGatedByAvailability(t *testing.T) {
 	}
 }
 
+func TestVisibleNavPages_AIUsageGatedByChat(t *testing.T) {
+	u := allPermsUser(t)
+
+	if has := contains(pageURLs(VisibleNavPages(u, &config.ServerConfig{}, true)), "/llm-usage"); has {
+		t.Fatal("llm-usage must be hidden when chat is disabled")
+	}
+
+	cfg := &config.ServerConfig{}
+	cfg.Chat.Enabled = true
+	if has := contains(pageURLs(VisibleNavPages(u, cfg, true)), "/llm-usage"); !has {
+		t.Fatal("llm-usage should be visible when chat is enabled")
+	}
+}
+
 func TestVisibleNavPages_NoPermissions(t *testing.T) {
 	// A user with no roles sees only the always-available pages.
 	u := &User{Id: "u2", Username: "nobody", Roles: nil}
@@ -14,6 +14,8 @@ type PoolDefinition struct {
 	TemplateId      string        `json:"template_id" db:"template_id" msgpack:"template_id"`
 	StartupScriptId string        `json:"startup_script_id" db:"startup_script_id" msgpack:"startup_script_id"`
 	DesiredCount    int           `json:"desired_count" db:"desired_count" msgpack:"desired_count"`
+	Autoscale       PoolAutoscale `json:"autoscale" db:"autoscale,json" msgpack:"autoscale"`
+	LastScaledAt    *time.Time    `json:"last_scaled_at,omitempty" db:"last_scaled_at" msgpack:"last_scaled_at"`
 	Active          bool          `json:"active" db:"active" msgpack:"active"`
 	Zone            string        `json:"zone" db:"zone" msgpack:"zone"`
 	IsDeleted       bool          `json:"is_deleted" db:"is_deleted" msgpack:"is_deleted"`
@@ -23,6 +25,40 @@ type PoolDefinition struct {
 	UpdatedAt       hlc.Timestamp `json:"updated_at" db:"updated_at" msgpack:"updated_at"`
 }
 
+// PoolAutoscale describes how the leader resizes an active pool from the
+// metrics its members report. The pool is kept between MinCount and MaxCount,
+// sized so the average CPU stays near TargetCPUPercent, each member serves
+// about TargetRPSPerMember requests per second and the average of the
+// application metric TargetMetric, reported by the members over OTLP, stays
+// near TargetMetricValue; a target of zero is ignored.
+// After scaling the pool is not scaled in the same direction again until the
+// cooldown, in seconds, has passed.
+type PoolAutoscale struct {
+	Enabled            bool    `json:"enabled" msgpack:"enabled"`
+	MinCount           int     `json:"min_count" msgpack:"min_count"`
+	MaxCount           int     `json:"max_count" msgpack:"max_count"`
+	TargetCPUPercent   float64 `json:"target_cpu_percent" msgpack:"target_cpu_percent"`
+	TargetRPSPerMember float64 `json:"target_rps_per_member" msgpack:"target_rps_per_member"`
+	TargetMetric       string  `json:"target_metric,omitempty" msgpack:"target_metric,omitempty"`
+	TargetMetricValue  float64 `json:"target_metric_value,omitempty" msgpack:"target_metric_value,omitempty"`
+	ScaleUpCooldown    uint32  `json:"scale_up_cooldown" msgpack:"scale_up_cooldown"`
+	ScaleDownCooldown  uint32  `json:"scale_down_cooldown" msgpack:"scale_down_cooldown"`
+}
+
+// Clamp returns count limited to the autoscale bounds.
+func (a *PoolAutoscale) Clamp(count int) int {
+	return max(a.MinCount, min(a.MaxCount, count))
+}
+
+// Cooldown returns how long to wait after scaling before the pool may be scaled
+// in the same direction again.
+func (a *PoolAutoscale) Cooldown(up bool) time.Duration {
+	if up {
+		return time.Duration(a.ScaleUpCooldown) * time.Second
+	}
+	return time.Duration(a.ScaleDownCooldown) * time.Second
+}
+
 func NewPoolDefinition(name, templateId, startupScriptId string, desiredCount int, userId string) *PoolDefinition {
 	id, err := uuid.NewV7()
 	if err != nil {
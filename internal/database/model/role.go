@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"sync"
 	"time"
 
@@ -53,6 +54,10 @@ const (
 	PermissionManageGlobalSlashCommands        // Can Manage Global Slash Commands
 	PermissionManageOwnSlashCommands           // Can Manage Own Slash Commands
 	PermissionManageMCPServers                 // Can Manage MCP Servers
+	PermissionSSHLocalForward                  // Can forward ports from a space over SSH (ssh -L)
+	PermissionSSHReverseForward                // Can forward ports into a space over SSH (ssh -R)
+	PermissionViewAIUsage                      // Can view the AI token usage report
+	PermissionWriteSharedTerminals             // Can type in shared terminal sessions of spaces shared with the user
 )
 
 type PermissionName struct {
@@ -95,6 +100,7 @@ var PermissionNames = []PermissionName{
 	{PermissionManageOwnSlashCommands, "Slash Commands", "Manage Own Slash Commands", "Create and edit your own slash commands."},
 
 	{PermissionManageMCPServers, "AI Tools", "Manage MCP Servers", "Register and configure MCP servers."},
+	{PermissionViewAIUsage, "AI Tools", "View AI Usage", "View AI token usage by user, group and model."},
 
 	{PermissionManageStackDefinitions, "Stacks", "Manage Global Stack Definitions", "Create, edit, and delete global (system) stack definitions."},
 	{PermissionManageOwnStackDefinitions, "Stacks", "Manage Own Stack Definitions", "Create, edit, and delete personal stack definitions."},
@@ -113,9 +119,12 @@ var PermissionNames = []PermissionName{
 	{PermissionUseCodeServer, "Space Operations", "Use Code Server", "Open code-server in a space."},
 	{PermissionUseLogs, "Space Operations", "View Logs", "View the log window for a space."},
 	{PermissionUseSSH, "Space Operations", "Use SSH", "Connect to spaces over SSH."},
+	{PermissionSSHLocalForward, "Space Operations", "SSH Local Port Forwarding", "Reach ports in or from a space over SSH (ssh -L), needed by remote editors."},
+	{PermissionSSHReverseForward, "Space Operations", "SSH Reverse Port Forwarding", "Expose local services to a space over SSH (ssh -R)."},
 	{PermissionUseVNC, "Space Operations", "Use VNC", "Use the VNC graphical desktop in a space."},
 	{PermissionUseVSCodeTunnel, "Space Operations", "Use VSCode Tunnel", "Connect to a space via a VS Code tunnel."},
 	{PermissionUseWebTerminal, "Space Operations", "Use Web Terminal", "Use the web terminal in a space."},
+	{PermissionWriteSharedTerminals, "Space Operations", "Type in Shared Terminals", "Type in the shared terminal sessions of spaces shared with you, without it they are read only."},
 	{PermissionRunCommands, "Space Operations", "Run Commands", "Execute commands inside a space."},
 	{PermissionCopyFiles, "Space Operations", "Copy Files", "Copy files to and from a space."},
 }
@@ -171,6 +180,8 @@ func SetRoleCache(roles []*Role) {
 			PermissionUseVNC,
 			PermissionUseWebTerminal,
 			PermissionUseSSH,
+			PermissionSSHLocalForward,
+			PermissionSSHReverseForward,
 			PermissionUseCodeServer,
 			PermissionUseVSCodeTunnel,
 			PermissionUseLogs,
@@ -194,6 +205,8 @@ func SetRoleCache(roles []*Role) {
 			PermissionUsePools,
 			PermissionManageEvents,
 			PermissionManageGlobalEvents,
+			PermissionViewAIUsage,
+			PermissionWriteSharedTerminals,
 		},
 		CreatedAt: adminTime,
 		UpdatedAt: hlc.Timestamp(0),
@@ -252,6 +265,30 @@ func NewRole(name string, permissions []uint16, userId string) *Role {
 	return role
 }
 
+// GrantSSHLocalForward gives a role that can use SSH the local port forwarding
+// permission, roles created before the permission existed could always forward
+// ports. Returns true if the role was changed.
+func (role *Role) GrantSSHLocalForward() bool {
+	if !slices.Contains(role.Permissions, PermissionUseSSH) || slices.Contains(role.Permissions, PermissionSSHLocalForward) {
+		return false
+	}
+
+	role.Permissions = append(role.Permissions, PermissionSSHLocalForward)
+	return true
+}
+
+// SSHForwardingChanged reports whether the port forwarding permissions differ
+// between two versions of a role, the SSH keys in the spaces of its users then
+// need updating.
+func SSHForwardingChanged(before, after *Role) bool {
+	for _, permission := range []uint16{PermissionSSHLocalForward, PermissionSSHReverseForward} {
+		if slices.Contains(before.Permissions, permission) != slices.Contains(after.Permissions, permission) {
+			return true
+		}
+	}
+	return false
+}
+
 func RoleExists(roleId string) bool {
 	_, ok := roleCache[roleId]
 	return ok
@@ -54,3 +54,40 @@ func TestRoleCache(t *testing.T) {
 		t.Error("Role3 should exist after save")
 	}
 }
+
+func TestGrantSSHLocalForward(t *testing.T) {
+	role := NewRole("ssh", []uint16{PermissionUseSSH}, "user-1")
+	if !role.GrantSSHLocalForward() {
+		t.Error("Expected the SSH role to be granted local forwarding")
+	}
+	if role.GrantSSHLocalForward() {
+		t.Error("Expected no change once local forwarding is granted")
+	}
+	if len(role.Permissions) != 2 {
+		t.Errorf("Expected 2 permissions, got %d", len(role.Permissions))
+	}
+
+	role = NewRole("spaces", []uint16{PermissionUseSpaces}, "user-1")
+	if role.GrantSSHLocalForward() {
+		t.Error("Expected roles without SSH to be left alone")
+	}
+}
+
+func TestSSHForwardingChanged(t *testing.T) {
+	before := NewRole("ssh", []uint16{PermissionUseSSH, PermissionSSHLocalForward}, "user-1")
+
+	after := NewRole("ssh", []uint16{PermissionSSHLocalForward, PermissionUseSSH, PermissionUseSpaces}, "user-1")
+	if SSHForwardingChanged(before, after) {
+		t.Error("Expected no change when the forwarding permissions are the same")
+	}
+
+	after = NewRole("ssh", []uint16{PermissionUseSSH}, "user-1")
+	if !SSHForwardingChanged(before, after) {
+		t.Error("Expected a change when local forwarding is removed")
+	}
+
+	after = NewRole("ssh", []uint16{PermissionUseSSH, PermissionSSHLocalForward, PermissionSSHReverseForward}, "user-1")
+	if !SSHForwardingChanged(before, after) {
+		t.Error("Expected a change when reverse forwarding is added")
+	}
+}
@@ -121,7 +121,7 @@ type Space struct {
 	Shell            string             `json:"shell" db:"shell" msgpack:"shell"`
 	StartupScriptId  string             `json:"startup_script_id" db:"startup_script_id" msgpack:"startup_script_id"`
 	TemplateHash     string             `json:"template_hash" db:"template_hash" msgpack:"template_hash"`
-	NomadNamespace   string             `json:"nomad_namespace" db:"nomad_namespace" msgpack:"nomad_namespace"`
+	NomadNamespace   string             `json:"nomad_namespace" db:"nomad_namespace" msgpack:"nomad_namespace"` // Namespace of the Nomad job or Kubernetes workload
 	ContainerId      string             `json:"container_id" db:"container_id" msgpack:"container_id"`
 	IconURL          string             `json:"icon_url" db:"icon_url" msgpack:"icon_url"`
 	VolumeData       VolumeDataMap      `json:"volume_data" db:"volume_data" msgpack:"volume_data"`
@@ -259,6 +259,16 @@ func (s *Space) SharedUserIds() []string {
 	return userIds
 }
 
+// SharedTerminalReadOnly reports whether the user attaches to a shared
+// terminal session of the space read only. The owner and space managers pick
+// the mode, other users get the mode their role allows.
+func (s *Space) SharedTerminalReadOnly(user *User, requested bool) bool {
+	if s.UserId == user.Id || user.HasPermission(PermissionManageSpaces) {
+		return requested
+	}
+	return !user.HasPermission(PermissionWriteSharedTerminals)
+}
+
 func (s *Space) IsSharedWith(userId string) bool {
 	if userId == "" {
 		return false
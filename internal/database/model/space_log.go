@@ -0,0 +1,146 @@
+package model
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SpaceLogLevelDebug = "debug"
+	SpaceLogLevelInfo  = "info"
+	SpaceLogLevelError = "error"
+)
+
+// SpaceLogEntry is one log line collected from a space by its agent.
+//
+// Ids are UUIDv7 carrying the time of the log line, so ordering by id orders
+// the log by time and the id of the oldest entry returned is the cursor used
+// to page further back.
+type SpaceLogEntry struct {
+	Id        string    `json:"log_id" db:"log_id,pk"`
+	SpaceId   string    `json:"space_id" db:"space_id"`
+	Level     string    `json:"level" db:"level"`
+	Source    string    `json:"source" db:"source"`
+	Message   string    `json:"message" db:"message"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SpaceLogFilter selects space log entries, empty fields match everything.
+type SpaceLogFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Level  string // minimum severity
+	Source string
+	Query  string // case insensitive text to find in the message
+	Before string // only entries older than this log id
+}
+
+var spaceLogNamespace = uuid.MustParse("3b8e51d2-6a4f-4c1e-9d27-8f0c5e6a1b93")
+
+// NewSpaceLogEntry creates the entry for a log line, the id is derived from
+// the line so every server receiving it from the agent stores it once.
+func NewSpaceLogEntry(spaceId, level, source, message string, at time.Time, seq uint64) *SpaceLogEntry {
+	id := uuid.NewSHA1(spaceLogNamespace, []byte(fmt.Sprintf("%s\x00%d\x00%d\x00%s\x00%s\x00%s", spaceId, at.UnixNano(), seq, level, source, message)))
+
+	// Make it a UUIDv7 carrying the time of the log line
+	binary.BigEndian.PutUint16(id[0:2], uint16(uint64(at.UnixMilli())>>32))
+	binary.BigEndian.PutUint32(id[2:6], uint32(at.UnixMilli()))
+	id[6] = (id[6] & 0x0f) | 0x70
+
+	return &SpaceLogEntry{
+		Id:        id.String(),
+		SpaceId:   spaceId,
+		Level:     level,
+		Source:    source,
+		Message:   message,
+		CreatedAt: at.UTC(),
+	}
+}
+
+// SpaceLogIdPrefix returns the leading characters shared by the ids of log
+// entries written at t, ids starting with a smaller prefix are older.
+func SpaceLogIdPrefix(t time.Time) string {
+	ms := uint64(t.UnixMilli())
+	return fmt.Sprintf("%08x-%04x", uint32(ms>>16), uint16(ms))
+}
+
+// SpaceLogLevelRank orders the levels by severity, unknown levels rank as
+// debug.
+func SpaceLogLevelRank(level string) int {
+	switch strings.ToLower(level) {
+	case SpaceLogLevelError:
+		return 2
+	case SpaceLogLevelInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SpaceLogLevelsFrom returns the levels at or above the given severity.
+func SpaceLogLevelsFrom(level string) []string {
+	levels := []string{SpaceLogLevelDebug, SpaceLogLevelInfo, SpaceLogLevelError}
+	return levels[SpaceLogLevelRank(level):]
+}
+
+func (e *SpaceLogEntry) MatchesFilter(filter *SpaceLogFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Before != "" && e.Id >= filter.Before {
+		return false
+	}
+	if filter.Level != "" && SpaceLogLevelRank(e.Level) < SpaceLogLevelRank(filter.Level) {
+		return false
+	}
+	if filter.Source != "" && !strings.EqualFold(e.Source, filter.Source) {
+		return false
+	}
+	if filter.From != nil && e.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && e.CreatedAt.After(*filter.To) {
+		return false
+	}
+	if filter.Query != "" && !strings.Contains(strings.ToLower(e.Message), strings.ToLower(filter.Query)) {
+		return false
+	}
+	return true
+}
+
+// SpaceLogFilterFromQuery builds a filter from the query parameters of a log
+// request: from / to (RFC3339), level, source, q and before.
+func SpaceLogFilterFromQuery(query url.Values) (*SpaceLogFilter, error) {
+	filter := &SpaceLogFilter{
+		Level:  strings.ToLower(query.Get("level")),
+		Source: query.Get("source"),
+		Query:  query.Get("q"),
+		Before: query.Get("before"),
+	}
+
+	switch filter.Level {
+	case "", SpaceLogLevelDebug, SpaceLogLevelInfo, SpaceLogLevelError:
+	default:
+		return nil, fmt.Errorf("invalid level %q", filter.Level)
+	}
+
+	for _, p := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := query.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s time: %w", p.name, err)
+			}
+			*p.target = &t
+		}
+	}
+
+	return filter, nil
+}
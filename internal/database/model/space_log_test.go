@@ -0,0 +1,88 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSpaceLogEntry_idOrder(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	older := NewSpaceLogEntry("space", SpaceLogLevelInfo, "", "a", base, 1)
+	newer := NewSpaceLogEntry("space", SpaceLogLevelInfo, "", "b", base.Add(time.Millisecond), 2)
+
+	if older.Id >= newer.Id {
+		t.Errorf("expected ids in time order: %s >= %s", older.Id, newer.Id)
+	}
+	if !strings.HasPrefix(older.Id, SpaceLogIdPrefix(base)) {
+		t.Errorf("id %s doesn't start with the prefix for its time %s", older.Id, SpaceLogIdPrefix(base))
+	}
+	if SpaceLogIdPrefix(base.Add(-time.Second)) >= older.Id {
+		t.Error("expected the prefix of an earlier time to sort before the id")
+	}
+}
+
+func TestNewSpaceLogEntry_sameLineSameId(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := NewSpaceLogEntry("space", SpaceLogLevelInfo, "nginx", "GET / 200", at, 7)
+
+	if again := NewSpaceLogEntry("space", SpaceLogLevelInfo, "nginx", "GET / 200", at, 7); again.Id != entry.Id {
+		t.Errorf("expected the same line to get the same id, got %s and %s", entry.Id, again.Id)
+	}
+	if next := NewSpaceLogEntry("space", SpaceLogLevelInfo, "nginx", "GET / 200", at, 8); next.Id == entry.Id {
+		t.Error("expected the next line of the agent to get a new id")
+	}
+	if other := NewSpaceLogEntry("other", SpaceLogLevelInfo, "nginx", "GET / 200", at, 7); other.Id == entry.Id {
+		t.Error("expected a line of another space to get a new id")
+	}
+	if entry.Id[14] != '7' {
+		t.Errorf("expected a version 7 id, got %s", entry.Id)
+	}
+}
+
+func TestSpaceLogEntry_MatchesFilter(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := NewSpaceLogEntry("space", SpaceLogLevelInfo, "nginx", "GET /index.html 200", at, 1)
+	before, after := at.Add(-time.Minute), at.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		filter *SpaceLogFilter
+		want   bool
+	}{
+		{"nil", nil, true},
+		{"empty", &SpaceLogFilter{}, true},
+		{"level below", &SpaceLogFilter{Level: SpaceLogLevelDebug}, true},
+		{"level above", &SpaceLogFilter{Level: SpaceLogLevelError}, false},
+		{"source", &SpaceLogFilter{Source: "NGINX"}, true},
+		{"other source", &SpaceLogFilter{Source: "sshd"}, false},
+		{"query", &SpaceLogFilter{Query: "index"}, true},
+		{"missing query", &SpaceLogFilter{Query: "404"}, false},
+		{"in range", &SpaceLogFilter{From: &before, To: &after}, true},
+		{"too old", &SpaceLogFilter{From: &after}, false},
+		{"cursor", &SpaceLogFilter{Before: entry.Id}, false},
+	}
+	for _, tt := range tests {
+		if got := entry.MatchesFilter(tt.filter); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpaceLogFilterFromQuery(t *testing.T) {
+	filter, err := SpaceLogFilterFromQuery(url.Values{"level": {"ERROR"}, "from": {"2026-03-01T12:00:00Z"}, "q": {"fail"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Level != SpaceLogLevelError || filter.Query != "fail" || filter.From == nil || filter.To != nil {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+
+	if _, err := SpaceLogFilterFromQuery(url.Values{"level": {"warn"}}); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := SpaceLogFilterFromQuery(url.Values{"to": {"yesterday"}}); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
@@ -186,3 +186,36 @@ func TestNormalizeDependsOn(t *testing.T) {
 		t.Fatalf("Unexpected dependencies: %#v", space.DependsOn)
 	}
 }
+
+func TestSharedTerminalReadOnly(t *testing.T) {
+	SetRoleCache([]*Role{
+		{Id: "typist", Permissions: []uint16{PermissionWriteSharedTerminals}},
+	})
+
+	space := &Space{UserId: "owner", Shares: []string{"viewer", "typist"}}
+	owner := &User{Id: "owner"}
+	admin := &User{Id: "admin", Roles: []string{RoleAdminUUID}}
+	viewer := &User{Id: "viewer"}
+	typist := &User{Id: "typist", Roles: []string{"typist"}}
+
+	tests := []struct {
+		name      string
+		user      *User
+		requested bool
+		expected  bool
+	}{
+		{"owner read write", owner, false, false},
+		{"owner watching", owner, true, true},
+		{"space manager watching", admin, true, true},
+		{"shared user asking for read write", viewer, false, true},
+		{"shared user allowed to type", typist, false, false},
+		{"shared user allowed to type ignores the request", typist, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := space.SharedTerminalReadOnly(tt.user, tt.requested); got != tt.expected {
+				t.Errorf("SharedTerminalReadOnly() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -9,18 +9,20 @@ import (
 
 	"github.com/paularlott/gossip/hlc"
 	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/util"
 
 	"github.com/google/uuid"
 	"github.com/paularlott/knot/internal/log"
 )
 
 const (
-	PlatformManual    = "manual"
-	PlatformDocker    = "docker"
-	PlatformPodman    = "podman"
-	PlatformNomad     = "nomad"
-	PlatformApple     = "apple"
-	PlatformContainer = "container"
+	PlatformManual     = "manual"
+	PlatformDocker     = "docker"
+	PlatformPodman     = "podman"
+	PlatformNomad      = "nomad"
+	PlatformApple      = "apple"
+	PlatformContainer  = "container"
+	PlatformKubernetes = "kubernetes"
 
 	LeafNodeZone = "<leaf-node>"
 
@@ -49,6 +51,9 @@ type Template struct {
 	WithCodeServer           bool                   `json:"with_code_server" db:"with_code_server"`
 	WithSSH                  bool                   `json:"with_ssh" db:"with_ssh"`
 	WithRunCommand           bool                   `json:"with_run_command" db:"with_run_command"`
+	DisableSSHLocalForward   bool                   `json:"disable_ssh_local_forward" db:"disable_ssh_local_forward"`
+	WithSSHReverseForward    bool                   `json:"with_ssh_reverse_forward" db:"with_ssh_reverse_forward"`
+	WithTerminalRecording    bool                   `json:"with_terminal_recording" db:"with_terminal_recording"`
 	AllowNodeMigration       bool                   `json:"allow_node_migration" db:"allow_node_migration"`
 	StartupScriptId          string                 `json:"startup_script_id" db:"startup_script_id"`
 	ShutdownScriptId         string                 `json:"shutdown_script_id" db:"shutdown_script_id"`
@@ -72,6 +77,7 @@ type Template struct {
 	HealthCheckAutoRestart   bool                   `json:"health_check_auto_restart" db:"health_check_auto_restart"`
 	DisableUserActivity      bool                   `json:"disable_user_activity" db:"disable_user_activity"`
 	Ports                    []TemplatePort         `json:"ports" db:"ports,json"`
+	IdlePolicy               TemplateIdlePolicy     `json:"idle_policy" db:"idle_policy,json"`
 	CreatedUserId            string                 `json:"created_user_id" db:"created_user_id"`
 	CreatedAt                time.Time              `json:"created_at" db:"created_at"`
 	UpdatedUserId            string                 `json:"updated_user_id" db:"updated_user_id"`
@@ -89,6 +95,41 @@ type TemplateCustomField struct {
 	Description string `json:"description"`
 }
 
+// TemplateIdlePolicy describes when a running space is considered idle and
+// should be stopped by the server. A space is busy while it has interactive
+// sessions (terminal, SSH, code-server), file activity or CPU usage at or above
+// the threshold; once it has been idle for Timeout minutes it is stopped.
+type TemplateIdlePolicy struct {
+	Enabled      bool    `json:"enabled"`
+	Timeout      uint32  `json:"timeout"`
+	CPUThreshold float64 `json:"cpu_threshold"`
+	WarnBefore   uint32  `json:"warn_before"`
+}
+
+// TimeoutDuration returns how long a space may be idle before it is stopped,
+// zero if the policy is disabled.
+func (p *TemplateIdlePolicy) TimeoutDuration() time.Duration {
+	if !p.Enabled || p.Timeout == 0 {
+		return 0
+	}
+	return time.Duration(p.Timeout) * time.Minute
+}
+
+// WarnDuration returns how long a space may be idle before the warning event
+// is raised, zero if no warning should be given.
+func (p *TemplateIdlePolicy) WarnDuration() time.Duration {
+	timeout := p.TimeoutDuration()
+	if timeout == 0 || p.WarnBefore == 0 {
+		return 0
+	}
+
+	warnBefore := time.Duration(p.WarnBefore) * time.Minute
+	if warnBefore >= timeout {
+		return 0
+	}
+	return timeout - warnBefore
+}
+
 type TemplatePort struct {
 	Name     string `json:"name"`
 	Port     uint16 `json:"port"`
@@ -175,6 +216,35 @@ func (template *Template) GetVolumes(space *Space, user *User, variables map[str
 	return LoadVolumesFromYaml(template.Volumes, template, space, user, variables)
 }
 
+// SSHForwarding reports whether user may forward ports from spaces of the
+// template, locally and in reverse. A nil user checks only the template.
+func (template *Template) SSHForwarding(user *User) (local, reverse bool) {
+	local = !template.DisableSSHLocalForward && (user == nil || user.HasPermission(PermissionSSHLocalForward))
+	reverse = template.WithSSHReverseForward && (user == nil || user.HasPermission(PermissionSSHReverseForward))
+	return local, reverse
+}
+
+// SSHKeyOptions returns the authorized key options for the keys of user in
+// spaces of the template.
+func (template *Template) SSHKeyOptions(user *User) string {
+	return util.SSHForwardingOptions(template.SSHForwarding(user))
+}
+
+// SSHAuthorizedKeys returns the authorized key lines and GitHub usernames for
+// user's keys, restricted by the template, with the options for the keys of
+// each GitHub username.
+func (template *Template) SSHAuthorizedKeys(user *User) (keys []string, githubUsernames []string, githubKeyOptions []string) {
+	options := template.SSHKeyOptions(user)
+	for _, key := range util.SplitSSHPublicKeys(user.SSHPublicKey) {
+		keys = append(keys, util.AddSSHKeyOptions(key, options))
+	}
+	if user.GitHubUsername != "" {
+		githubUsernames = append(githubUsernames, user.GitHubUsername)
+		githubKeyOptions = append(githubKeyOptions, options)
+	}
+	return keys, githubUsernames, githubKeyOptions
+}
+
 func (template *Template) UpdateHash() {
 	hash := md5.Sum([]byte(template.Job + template.Volumes + template.Platform + fmt.Sprintf("%t%t%t%t%t%t%v", template.WithTerminal, template.WithVSCodeTunnel, template.WithCodeServer, template.WithSSH, template.WithRunCommand, template.AllowNodeMigration, template.CustomFields)))
 	template.Hash = hex.EncodeToString(hash[:])
@@ -221,3 +221,31 @@ func TestIsValidForZone(t *testing.T) {
 		})
 	}
 }
+
+func TestSSHKeyOptions(t *testing.T) {
+	SetRoleCache([]*Role{})
+
+	admin := &User{Roles: []string{RoleAdminUUID}}
+	user := &User{}
+
+	tests := []struct {
+		name     string
+		template *Template
+		user     *User
+		expected string
+	}{
+		{"default template", &Template{}, admin, "no-remote-forwarding"},
+		{"reverse forwarding enabled", &Template{WithSSHReverseForward: true}, admin, ""},
+		{"local forwarding disabled", &Template{DisableSSHLocalForward: true, WithSSHReverseForward: true}, admin, "no-local-forwarding"},
+		{"user without permissions", &Template{WithSSHReverseForward: true}, user, "no-port-forwarding"},
+		{"template only", &Template{WithSSHReverseForward: true}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.template.SSHKeyOptions(tt.user); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
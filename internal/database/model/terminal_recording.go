@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paularlott/knot/internal/log"
+)
+
+const (
+	TerminalRecordingSourceWeb = "terminal"
+	TerminalRecordingSourceSSH = "ssh"
+)
+
+// TerminalRecording is an asciicast v2 recording of an interactive session in
+// a space, Data holds the gzip compressed cast and isn't loaded in listings.
+type TerminalRecording struct {
+	Id        string    `json:"recording_id" db:"recording_id,pk"`
+	SpaceId   string    `json:"space_id" db:"space_id"`
+	Username  string    `json:"username" db:"username"`
+	Source    string    `json:"source" db:"source"`
+	StartedAt time.Time `json:"started_at" db:"started_at"`
+	Duration  float64   `json:"duration" db:"duration"` // seconds
+	Size      int       `json:"size" db:"size"`         // uncompressed bytes
+	Truncated bool      `json:"truncated" db:"truncated"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	Data      []byte    `json:"-" db:"data"`
+}
+
+func NewTerminalRecording(spaceId, username, source string, startedAt time.Time, retentionDays int) *TerminalRecording {
+	id, err := uuid.NewV7()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	return &TerminalRecording{
+		Id:        id.String(),
+		SpaceId:   spaceId,
+		Username:  username,
+		Source:    source,
+		StartedAt: startedAt.UTC(),
+		ExpiresAt: time.Now().UTC().Add(time.Duration(retentionDays) * 24 * time.Hour),
+	}
+}
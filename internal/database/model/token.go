@@ -1,6 +1,8 @@
 package model
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/paularlott/gossip/hlc"
@@ -11,18 +13,73 @@ import (
 
 const (
 	MaxTokenAge = 14 * 24 * time.Hour // 2 weeks
+)
 
-	// ScopeMethods allows a token to reach /api/methods* endpoints.
-	// Scopes are narrowing: an empty/nil Scopes slice means unrestricted
-	// (the token inherits the user's full authenticated surface, matching
-	// pre-scopes behaviour). A non-empty slice restricts the token to only
-	// the endpoint groups named by the listed scopes.
-	ScopeMethods = "methods"
-	ScopeMCP     = "mcp"
+// Token scopes. Scopes are narrowing: an empty/nil Scopes slice means
+// unrestricted (the token inherits the user's full authenticated surface,
+// matching pre-scopes behaviour). A non-empty slice restricts the token to
+// only the endpoint groups named by the listed scopes. A :write scope also
+// grants the matching :read scope.
+const (
+	ScopeMethods        = "methods"
+	ScopeMCP            = "mcp"
+	ScopeSpacesRead     = "spaces:read"
+	ScopeSpacesWrite    = "spaces:write"
+	ScopeSpacesExec     = "spaces:exec"
+	ScopeTemplatesRead  = "templates:read"
+	ScopeTemplatesWrite = "templates:write"
+	ScopeVolumesRead    = "volumes:read"
+	ScopeVolumesWrite   = "volumes:write"
+	ScopePoolsRead      = "pools:read"
+	ScopePoolsWrite     = "pools:write"
+	ScopeScriptsRead    = "scripts:read"
+	ScopeScriptsWrite   = "scripts:write"
+	ScopeSkillsRead     = "skills:read"
+	ScopeSkillsWrite    = "skills:write"
+	ScopeEventsRead     = "events:read"
+	ScopeEventsWrite    = "events:write"
+	ScopeEventsEmit     = "events:emit"
+	ScopeTunnels        = "tunnels"
+	ScopeAssistant      = "assistant"
+	ScopeUsersRead      = "users:read"
+	ScopeUsersWrite     = "users:write"
+	ScopeTokens         = "tokens"
+	ScopeAuditRead      = "audit:read"
+	ScopeClusterRead    = "cluster:read"
+	ScopeLeaf           = "leaf"
+	ScopeServerRead     = "server:read"
 )
 
 // KnownTokenScopes is the authoritative list of valid scope strings.
-var KnownTokenScopes = []string{ScopeMethods, ScopeMCP}
+var KnownTokenScopes = []string{
+	ScopeMethods,
+	ScopeMCP,
+	ScopeSpacesRead,
+	ScopeSpacesWrite,
+	ScopeSpacesExec,
+	ScopeTemplatesRead,
+	ScopeTemplatesWrite,
+	ScopeVolumesRead,
+	ScopeVolumesWrite,
+	ScopePoolsRead,
+	ScopePoolsWrite,
+	ScopeScriptsRead,
+	ScopeScriptsWrite,
+	ScopeSkillsRead,
+	ScopeSkillsWrite,
+	ScopeEventsRead,
+	ScopeEventsWrite,
+	ScopeEventsEmit,
+	ScopeTunnels,
+	ScopeAssistant,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeTokens,
+	ScopeAuditRead,
+	ScopeClusterRead,
+	ScopeLeaf,
+	ScopeServerRead,
+}
 
 // IsKnownTokenScope reports whether s is a valid scope string.
 func IsKnownTokenScope(s string) bool {
@@ -46,6 +103,12 @@ type Token struct {
 	// nil/empty = unrestricted (backward compatible with pre-scopes tokens).
 	// Non-empty = token may only reach endpoints covered by the listed scopes.
 	Scopes []string `json:"scopes,omitempty" db:"scopes,json"`
+	// Spaces and Stacks restrict the token to the named spaces and the
+	// spaces of the named stacks. Both empty = any space the user can reach.
+	Spaces []string `json:"spaces,omitempty" db:"spaces,json"`
+	Stacks []string `json:"stacks,omitempty" db:"stacks,json"`
+	// SourceCIDR restricts the addresses the token can be used from.
+	SourceCIDR string `json:"source_cidr,omitempty" db:"source_cidr"`
 }
 
 func NewToken(name string, userId string) *Token {
@@ -68,3 +131,56 @@ func NewToken(name string, userId string) *Token {
 
 	return token
 }
+
+// IsRestricted reports whether the token is limited in any way, unrestricted
+// tokens reach everything their user can.
+func (token *Token) IsRestricted() bool {
+	return len(token.Scopes) > 0 || token.HasSpaceRestriction() || token.SourceCIDR != ""
+}
+
+// HasSpaceRestriction reports whether the token is limited to named spaces
+// or stacks.
+func (token *Token) HasSpaceRestriction() bool {
+	return len(token.Spaces) > 0 || len(token.Stacks) > 0
+}
+
+// AllowsSpace reports whether the token's space restriction covers space.
+func (token *Token) AllowsSpace(space *Space) bool {
+	if !token.HasSpaceRestriction() {
+		return true
+	}
+	return slices.Contains(token.Spaces, space.Name) || (space.Stack != "" && slices.Contains(token.Stacks, space.Stack))
+}
+
+// Covers reports whether other grants no more access than token, so a
+// token can only be used to create or change tokens narrower than itself.
+func (token *Token) Covers(other *Token) bool {
+	if len(token.Scopes) > 0 {
+		if len(other.Scopes) == 0 {
+			return false
+		}
+		for _, scope := range other.Scopes {
+			if !slices.Contains(token.Scopes, scope) && !(strings.HasSuffix(scope, ":read") && slices.Contains(token.Scopes, strings.TrimSuffix(scope, ":read")+":write")) {
+				return false
+			}
+		}
+	}
+
+	if token.HasSpaceRestriction() {
+		if !other.HasSpaceRestriction() {
+			return false
+		}
+		for _, name := range other.Spaces {
+			if !slices.Contains(token.Spaces, name) {
+				return false
+			}
+		}
+		for _, name := range other.Stacks {
+			if !slices.Contains(token.Stacks, name) {
+				return false
+			}
+		}
+	}
+
+	return token.SourceCIDR == "" || token.SourceCIDR == other.SourceCIDR
+}
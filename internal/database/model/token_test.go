@@ -29,3 +29,53 @@ func TestNewToken(t *testing.T) {
 		t.Error("Token expiry should be approximately MaxTokenAge from now")
 	}
 }
+
+func TestTokenAllowsSpace(t *testing.T) {
+	token := &Token{Spaces: []string{"dev"}, Stacks: []string{"web"}}
+
+	tests := []struct {
+		space    *Space
+		expected bool
+	}{
+		{&Space{Name: "dev"}, true},
+		{&Space{Name: "frontend", Stack: "web"}, true},
+		{&Space{Name: "frontend", Stack: "api"}, false},
+		{&Space{Name: "prod"}, false},
+	}
+	for _, tt := range tests {
+		if got := token.AllowsSpace(tt.space); got != tt.expected {
+			t.Errorf("AllowsSpace(%s/%s) = %v, expected %v", tt.space.Stack, tt.space.Name, got, tt.expected)
+		}
+	}
+
+	if !(&Token{}).AllowsSpace(&Space{Name: "prod"}) {
+		t.Error("Unrestricted token should allow any space")
+	}
+}
+
+func TestTokenCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    *Token
+		other    *Token
+		expected bool
+	}{
+		{"unrestricted covers anything", &Token{}, &Token{}, true},
+		{"scoped does not cover unscoped", &Token{Scopes: []string{ScopeSpacesRead}}, &Token{}, false},
+		{"scoped covers subset", &Token{Scopes: []string{ScopeSpacesWrite, ScopeTokens}}, &Token{Scopes: []string{ScopeSpacesWrite}}, true},
+		{"write covers read", &Token{Scopes: []string{ScopeSpacesWrite}}, &Token{Scopes: []string{ScopeSpacesRead}}, true},
+		{"read does not cover write", &Token{Scopes: []string{ScopeSpacesRead}}, &Token{Scopes: []string{ScopeSpacesWrite}}, false},
+		{"space restricted does not cover unrestricted", &Token{Spaces: []string{"dev"}}, &Token{}, false},
+		{"space restricted covers subset", &Token{Spaces: []string{"dev", "test"}}, &Token{Spaces: []string{"dev"}}, true},
+		{"space restricted does not cover other stack", &Token{Spaces: []string{"dev"}}, &Token{Stacks: []string{"web"}}, false},
+		{"source restricted requires same source", &Token{SourceCIDR: "10.0.0.0/8"}, &Token{SourceCIDR: "10.0.0.0/8"}, true},
+		{"source restricted does not cover any source", &Token{SourceCIDR: "10.0.0.0/8"}, &Token{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Covers(tt.other); got != tt.expected {
+				t.Errorf("Covers() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
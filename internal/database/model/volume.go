@@ -50,3 +50,7 @@ func NewVolume(name string, definition string, userId string, platform string) *
 func (volume *Volume) GetVolume(variables map[string]interface{}) (*CSIVolumes, error) {
 	return LoadVolumesFromYaml(volume.Definition, nil, nil, nil, variables)
 }
+
+func (volume *Volume) GetKubernetesVolume(variables map[string]interface{}) (*KubernetesVolumes, error) {
+	return LoadKubernetesVolumesFromYaml(volume.Definition, nil, nil, nil, variables)
+}
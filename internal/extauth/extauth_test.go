@@ -0,0 +1,64 @@
+package extauth
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestApply(t *testing.T) {
+	mappings := []Mapping{
+		{Source: "developers", Groups: []string{"Developers"}},
+		{Source: "admins", Roles: []string{"Admin"}, Groups: []string{"g-ops"}},
+		{Source: "missing", Groups: []string{"No Such Group"}},
+	}
+	groups := []*model.Group{
+		{Id: "g-dev", Name: "Developers"},
+		{Id: "g-ops", Name: "Operations"},
+		{Id: "g-manual", Name: "Manual"},
+	}
+	roles := []*model.Role{
+		{Id: "r-admin", Name: "Admin"},
+		{Id: "r-user", Name: "User"},
+	}
+
+	user := &model.User{Groups: []string{"g-manual", "g-ops"}, Roles: []string{"r-user", "r-admin"}}
+	changes := Apply("test", user, []string{"developers"}, mappings, groups, roles)
+
+	if !slices.Equal(user.Groups, []string{"g-manual", "g-dev"}) {
+		t.Errorf("unexpected groups %v", user.Groups)
+	}
+	if !slices.Equal(user.Roles, []string{"r-user"}) {
+		t.Errorf("unexpected roles %v", user.Roles)
+	}
+	if !slices.Equal(changes.GroupsAdded, []string{"Developers"}) || !slices.Equal(changes.GroupsRemoved, []string{"Operations"}) {
+		t.Errorf("unexpected group changes %+v", changes)
+	}
+	if len(changes.RolesAdded) != 0 || !slices.Equal(changes.RolesRemoved, []string{"Admin"}) {
+		t.Errorf("unexpected role changes %+v", changes)
+	}
+
+	// Nothing changes the second time round
+	changes = Apply("test", user, []string{"developers"}, mappings, groups, roles)
+	if !changes.Empty() {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestSanitizeUsername(t *testing.T) {
+	tests := map[string]string{
+		"alice":             "alice",
+		"Alice Smith":       "Alice-Smith",
+		"alice@example.com": "alice-example.com",
+		"42bob":             "bob",
+		"a":                 "",
+		"--":                "",
+		"j..doe__x":         "j.doe-x",
+	}
+	for input, expected := range tests {
+		if got := SanitizeUsername(input); got != expected {
+			t.Errorf("SanitizeUsername(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
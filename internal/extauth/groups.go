@@ -0,0 +1,128 @@
+// Package extauth holds what the OIDC and LDAP logins share, creating users
+// for external identities and keeping their groups and roles in line with the
+// directory.
+package extauth
+
+import (
+	"slices"
+
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+)
+
+// Mapping grants knot groups and roles, by name or ID, to members of a
+// directory group.
+type Mapping struct {
+	Source string
+	Groups []string
+	Roles  []string
+}
+
+// Changes lists the names of the groups and roles Apply added or removed.
+type Changes struct {
+	GroupsAdded   []string
+	GroupsRemoved []string
+	RolesAdded    []string
+	RolesRemoved  []string
+}
+
+func (c *Changes) Empty() bool {
+	return len(c.GroupsAdded) == 0 && len(c.GroupsRemoved) == 0 && len(c.RolesAdded) == 0 && len(c.RolesRemoved) == 0
+}
+
+// Apply adds the groups and roles mapped from memberOf and removes any other
+// group or role named in the mappings, leaving the rest of the user's groups
+// and roles untouched. Sources are matched exactly, callers normalise both
+// sides if their directory is case insensitive.
+func Apply(provider string, user *model.User, memberOf []string, mappings []Mapping, groups []*model.Group, roles []*model.Role) Changes {
+	groupIds, groupNames := make(map[string]string), make(map[string]string)
+	for _, group := range groups {
+		if !group.IsDeleted {
+			groupIds[group.Id] = group.Id
+			groupIds[group.Name] = group.Id
+			groupNames[group.Id] = group.Name
+		}
+	}
+	roleIds, roleNames := make(map[string]string), make(map[string]string)
+	for _, role := range roles {
+		if !role.IsDeleted {
+			roleIds[role.Id] = role.Id
+			roleIds[role.Name] = role.Id
+			roleNames[role.Id] = role.Name
+		}
+	}
+
+	managedGroups, managedRoles := map[string]bool{}, map[string]bool{}
+	var grantedGroups, grantedRoles []string
+	for _, mapping := range mappings {
+		member := slices.Contains(memberOf, mapping.Source)
+		for _, ref := range mapping.Groups {
+			id, ok := groupIds[ref]
+			if !ok {
+				log.Warn("extauth: mapped group not found", "provider", provider, "group", ref)
+				continue
+			}
+			managedGroups[id] = true
+			if member {
+				grantedGroups = append(grantedGroups, id)
+			}
+		}
+		for _, ref := range mapping.Roles {
+			id, ok := roleIds[ref]
+			if !ok {
+				log.Warn("extauth: mapped role not found", "provider", provider, "role", ref)
+				continue
+			}
+			managedRoles[id] = true
+			if member {
+				grantedRoles = append(grantedRoles, id)
+			}
+		}
+	}
+
+	changes := Changes{}
+	previousGroups, previousRoles := user.Groups, user.Roles
+	user.Groups = applyGranted(previousGroups, managedGroups, grantedGroups)
+	user.Roles = applyGranted(previousRoles, managedRoles, grantedRoles)
+	changes.GroupsAdded, changes.GroupsRemoved = diff(previousGroups, user.Groups, groupNames)
+	changes.RolesAdded, changes.RolesRemoved = diff(previousRoles, user.Roles, roleNames)
+
+	return changes
+}
+
+func applyGranted(current []string, managed map[string]bool, granted []string) []string {
+	result := make([]string, 0, len(current)+len(granted))
+	for _, id := range current {
+		if (!managed[id] || slices.Contains(granted, id)) && !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	for _, id := range granted {
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// diff returns the names of the IDs added and removed between before and
+// after, falling back to the ID for anything without a name.
+func diff(before, after []string, names map[string]string) (added, removed []string) {
+	name := func(id string) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return id
+	}
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			added = append(added, name(id))
+		}
+	}
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			removed = append(removed, name(id))
+		}
+	}
+	return added, removed
+}
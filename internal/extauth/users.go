@@ -0,0 +1,76 @@
+package extauth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9\-\.]+`)
+
+// NewUsername picks a free knot username for a new user, from the external
+// username if it can be made valid or else from the email's local part.
+func NewUsername(db database.DbDriver, externalUsername, email string) (string, error) {
+	base := SanitizeUsername(externalUsername)
+	if base == "" {
+		local, _, _ := strings.Cut(email, "@")
+		base = SanitizeUsername(local)
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		if existing, err := db.GetUserByUsername(username); err != nil || existing == nil {
+			return username, nil
+		}
+		if i > 100 {
+			return "", fmt.Errorf("unable to find a free username for %s", base)
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		username = strings.TrimRight(base[:min(len(base), 64-len(suffix))], "-.") + suffix
+	}
+}
+
+// SanitizeUsername turns an external username into a valid knot username, or "".
+func SanitizeUsername(name string) string {
+	name = usernameInvalidChars.ReplaceAllString(name, "-")
+	for strings.Contains(name, "--") || strings.Contains(name, "..") {
+		name = strings.ReplaceAll(strings.ReplaceAll(name, "--", "-"), "..", ".")
+	}
+	name = strings.TrimLeft(name, "0123456789-.")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	name = strings.TrimRight(name, "-.")
+	if !validate.Username(name) {
+		return ""
+	}
+	return name
+}
+
+// DeactivateUser disables a user removed by their directory or identity
+// provider, ending their sessions and stopping their spaces.
+func DeactivateUser(user *model.User) error {
+	user.Active = false
+	user.UpdatedAt = hlc.Now()
+	if err := database.GetInstance().SaveUser(user, []string{"Active", "UpdatedAt"}); err != nil {
+		return err
+	}
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	userService := service.GetUserService()
+	userService.RemoveUsersSessions(user)
+	go userService.UpdateUserSpaces(user)
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+// ProviderId keys LDAP users in model.User.ExternalAuthProviders, the
+// provider UID is the user's DN.
+const ProviderId = "ldap"
+
+const (
+	dialTimeout    = 10 * time.Second
+	requestTimeout = 30 * time.Second
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid LDAP credentials")
+	ErrUserNotFound       = errors.New("user not found in the directory")
+	ErrAmbiguousUser      = errors.New("user filter matched more than one directory entry")
+)
+
+// Entry is a user as read from the directory.
+type Entry struct {
+	DN       string
+	Email    string
+	Username string
+	Groups   []string // DNs of the groups the user is a member of
+}
+
+// Enabled returns true if LDAP logins are configured.
+func Enabled() bool {
+	cfg := config.GetServerConfig()
+	return cfg != nil && cfg.LDAP.Enabled && cfg.LDAP.URL != ""
+}
+
+// Authenticate finds the user with the given email and checks their password
+// with a simple bind as the user.
+func Authenticate(email, password string) (*Entry, error) {
+	// An empty password is an unauthenticated bind which most servers accept
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	cfg := &config.GetServerConfig().LDAP
+	conn, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := search(conn, cfg, cfg.BaseDN, goldap.ScopeWholeSubtree, userFilter(cfg, goldap.EscapeFilter(email)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// connect dials the server and binds as the search account, or anonymously
+// when no bind DN is configured.
+func connect(cfg *config.LDAPConfig) (*goldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.SkipVerify}
+	if u, err := url.Parse(cfg.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := goldap.DialURL(cfg.URL, goldap.DialWithDialer(&net.Dialer{Timeout: dialTimeout}), goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("LDAP connect: %w", err)
+	}
+	conn.SetTimeout(requestTimeout)
+
+	if _, isTLS := conn.TLSConnectionState(); cfg.StartTLS && !isTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("LDAP StartTLS: %w", err)
+		}
+	}
+
+	if cfg.BindDN != "" {
+		err = conn.Bind(cfg.BindDN, cfg.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("LDAP service bind: %w", err)
+	}
+
+	return conn, nil
+}
+
+// search returns the single user entry matching filter under baseDN.
+func search(conn *goldap.Conn, cfg *config.LDAPConfig, baseDN string, scope int, filter string) (*Entry, error) {
+	request := goldap.NewSearchRequest(
+		baseDN, scope, goldap.NeverDerefAliases, 2, int(requestTimeout.Seconds()), false,
+		filter,
+		[]string{cfg.EmailAttribute, cfg.UsernameAttribute, cfg.GroupAttribute},
+		nil,
+	)
+
+	result, err := conn.Search(request)
+	switch {
+	case goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject):
+		return nil, ErrUserNotFound
+	case goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded):
+		return nil, ErrAmbiguousUser
+	case err != nil:
+		return nil, fmt.Errorf("LDAP search: %w", err)
+	case len(result.Entries) == 0:
+		return nil, ErrUserNotFound
+	case len(result.Entries) > 1:
+		return nil, ErrAmbiguousUser
+	}
+
+	entry := result.Entries[0]
+	return &Entry{
+		DN:       entry.DN,
+		Email:    entry.GetAttributeValue(cfg.EmailAttribute),
+		Username: entry.GetAttributeValue(cfg.UsernameAttribute),
+		Groups:   entry.GetAttributeValues(cfg.GroupAttribute),
+	}, nil
+}
+
+// lookup re-reads a known user by DN, they are only found while they still
+// match the user filter so accounts disabled by the filter drop out.
+func lookup(conn *goldap.Conn, cfg *config.LDAPConfig, dn string) (*Entry, error) {
+	return search(conn, cfg, dn, goldap.ScopeBaseObject, userFilter(cfg, "*"))
+}
+
+// userFilter fills the escaped email, or a wildcard, into the user filter.
+func userFilter(cfg *config.LDAPConfig, email string) string {
+	return strings.ReplaceAll(cfg.UserFilter, "{email}", email)
+}
+
+// memberships returns the lower cased DN and CN of each group so mappings can
+// name a group either way.
+func memberships(groupDNs []string) []string {
+	names := make([]string, 0, len(groupDNs)*2)
+	for _, groupDN := range groupDNs {
+		names = append(names, normalizeGroup(groupDN))
+		if dn, err := goldap.ParseDN(groupDN); err == nil && len(dn.RDNs) > 0 {
+			for _, attr := range dn.RDNs[0].Attributes {
+				if strings.EqualFold(attr.Type, "cn") {
+					names = append(names, strings.ToLower(attr.Value))
+				}
+			}
+		}
+	}
+	return names
+}
+
+// normalizeGroup lower cases a group name or DN, removing any spacing
+// differences in a DN.
+func normalizeGroup(name string) string {
+	if strings.Contains(name, "=") {
+		if dn, err := goldap.ParseDN(name); err == nil {
+			return strings.ToLower(dn.String())
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
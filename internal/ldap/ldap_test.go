@@ -0,0 +1,71 @@
+package ldap
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+func TestUserFilter(t *testing.T) {
+	cfg := &config.LDAPConfig{UserFilter: "(&(objectClass=person)(mail={email}))"}
+
+	// Logins are escaped so an email can't widen the filter
+	if got := userFilter(cfg, goldap.EscapeFilter("*)(uid=*")); got != `(&(objectClass=person)(mail=\2a\29\28uid=\2a))` {
+		t.Errorf("unexpected login filter %q", got)
+	}
+	if got := userFilter(cfg, "*"); got != "(&(objectClass=person)(mail=*))" {
+		t.Errorf("unexpected sync filter %q", got)
+	}
+}
+
+func TestMemberships(t *testing.T) {
+	got := memberships([]string{
+		"CN=Developers, OU=Groups, DC=example, DC=com",
+		"cn=knot-admins,ou=groups,dc=example,dc=com",
+	})
+	expected := []string{
+		"cn=developers,ou=groups,dc=example,dc=com",
+		"developers",
+		"cn=knot-admins,ou=groups,dc=example,dc=com",
+		"knot-admins",
+	}
+	if !slices.Equal(got, expected) {
+		t.Errorf("memberships = %v, expected %v", got, expected)
+	}
+
+	if normalizeGroup("cn=Developers,ou=Groups,dc=example,dc=com") != got[0] {
+		t.Error("expected a mapped DN to normalize like a membership")
+	}
+	if normalizeGroup(" Developers ") != "developers" {
+		t.Error("expected a mapped CN to be lower cased")
+	}
+}
+
+func TestCanLinkByEmail(t *testing.T) {
+	entry := &Entry{DN: "uid=bob,ou=people,dc=example,dc=com"}
+	local := &model.User{}
+	linked := &model.User{ExternalAuthProviders: map[string]model.ExternalProvider{ProviderId: {ProviderUID: entry.DN}}}
+	other := &model.User{ExternalAuthProviders: map[string]model.ExternalProvider{ProviderId: {ProviderUID: "uid=alice,ou=people,dc=example,dc=com"}}}
+
+	tests := []struct {
+		name        string
+		user        *model.User
+		linkByEmail bool
+		want        error
+	}{
+		{"linking disabled", local, false, ErrNotLinked},
+		{"local user", local, true, nil},
+		{"same entry", linked, true, nil},
+		{"other entry", other, true, ErrAlreadyLinked},
+	}
+	for _, tt := range tests {
+		if err := canLinkByEmail(tt.user, entry, tt.linkByEmail); !errors.Is(err, tt.want) {
+			t.Errorf("%s: canLinkByEmail() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/extauth"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/audit"
+)
+
+// StartSync runs SyncOnce on the cluster leader every sync interval.
+func StartSync() {
+	interval := config.GetServerConfig().LDAP.SyncInterval
+	if !Enabled() || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if transport := service.GetTransport(); transport != nil && !transport.IsLeader() {
+				continue
+			}
+			if err := SyncOnce(); err != nil {
+				log.Error("ldap: directory sync failed", "error", err)
+			}
+		}
+	}()
+}
+
+// SyncOnce re-reads every LDAP user from the directory, applying the group
+// mappings and deactivating users no longer in the directory. Users already
+// deactivated are left for an administrator to reactivate.
+func SyncOnce() error {
+	cfg := &config.GetServerConfig().LDAP
+	db := database.GetInstance()
+
+	users, err := db.GetUsers()
+	if err != nil {
+		return err
+	}
+	groups, err := db.GetGroups()
+	if err != nil {
+		return err
+	}
+	roles := model.GetRolesFromCache()
+
+	conn, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var linked, missing []*model.User
+	for _, user := range users {
+		if user.IsDeleted || !user.Active || !IsDirectoryUser(user) {
+			continue
+		}
+		linked = append(linked, user)
+
+		entry, err := lookup(conn, cfg, user.ExternalAuthProviders[ProviderId].ProviderUID)
+		if errors.Is(err, ErrUserNotFound) {
+			missing = append(missing, user)
+			continue
+		}
+		if err != nil {
+			// Stop rather than risk acting on a partial view of the directory
+			return err
+		}
+
+		changes := syncAccess(user, entry, groups, roles)
+		if changes.Empty() {
+			continue
+		}
+
+		user.UpdatedAt = hlc.Now()
+		if err := db.SaveUser(user, []string{"Groups", "Roles", "UpdatedAt"}); err != nil {
+			log.Error("ldap: unable to save user", "user", user.Username, "error", err)
+			continue
+		}
+		service.GetTransport().GossipUser(user)
+		sse.PublishUsersChanged(user.Id)
+		go service.GetUserService().UpdateUserSpaces(user)
+
+		auditChanges(user, "Directory sync updated", changes)
+	}
+
+	// A directory that suddenly has none of our users is more likely a
+	// misconfigured filter than everyone leaving
+	if len(missing) > 1 && len(missing) == len(linked) {
+		return fmt.Errorf("none of the %d LDAP users were found, not deactivating them", len(linked))
+	}
+	for _, user := range missing {
+		deactivate(user)
+	}
+
+	return nil
+}
+
+// deactivate disables a user removed from the directory and audits it.
+func deactivate(user *model.User) {
+	if err := extauth.DeactivateUser(user); err != nil {
+		log.Error("ldap: unable to deactivate user", "user", user.Username, "error", err)
+		return
+	}
+
+	log.Info("ldap: deactivated user removed from the directory", "user", user.Username)
+
+	audit.Log(
+		ProviderId,
+		model.AuditActorTypeSystem,
+		model.AuditEventUserUpdate,
+		fmt.Sprintf("Directory sync deactivated user %s (%s)", user.Username, user.Email),
+		&map[string]interface{}{
+			"user_id":    user.Id,
+			"user_name":  user.Username,
+			"user_email": user.Email,
+			"active":     false,
+		},
+	)
+}
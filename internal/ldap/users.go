@@ -0,0 +1,172 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/extauth"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/audit"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+var (
+	ErrNoAccount     = errors.New("no knot account exists for this directory user")
+	ErrNotLinked     = errors.New("the knot account with this email is not linked to the directory")
+	ErrAlreadyLinked = errors.New("the knot account with this email is linked to another directory user")
+	ErrUserInactive  = errors.New("user is not active")
+)
+
+// IsDirectoryUser returns true if the user signs in through LDAP.
+func IsDirectoryUser(user *model.User) bool {
+	_, ok := user.ExternalAuthProviders[ProviderId]
+	return ok
+}
+
+// LoginUser finds the knot user for an authenticated directory entry, linking
+// the user with the same email if link_by_email is set or creating one if
+// signup is allowed, then applies the group mappings. The returned bool is
+// true if the user was created.
+func LoginUser(entry *Entry) (*model.User, bool, error) {
+	db := database.GetInstance()
+	cfg := config.GetServerConfig().LDAP
+
+	user, err := db.GetUserByProviderUID(ProviderId, entry.DN)
+	if err != nil || user == nil || user.IsDeleted {
+		user = nil
+
+		existing, err := db.GetUserByEmail(entry.Email)
+		if err == nil && existing != nil && !existing.IsDeleted {
+			if err := canLinkByEmail(existing, entry, cfg.LinkByEmail); err != nil {
+				log.Warn("ldap: not linking user", "dn", entry.DN, "user_id", existing.Id, "error", err)
+				return nil, false, err
+			}
+			user = existing
+		}
+	}
+
+	created := false
+	if user == nil {
+		if !cfg.AllowSignup {
+			return nil, false, ErrNoAccount
+		}
+		user, err = newUser(db, entry)
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+	}
+
+	if !user.Active {
+		return nil, false, ErrUserInactive
+	}
+
+	if user.ExternalAuthProviders == nil {
+		user.ExternalAuthProviders = make(map[string]model.ExternalProvider)
+	}
+	externalProvider := user.ExternalAuthProviders[ProviderId]
+	externalProvider.ProviderUID = entry.DN
+	externalProvider.Username = entry.displayUsername()
+	user.ExternalAuthProviders[ProviderId] = externalProvider
+
+	groups, err := db.GetGroups()
+	if err != nil {
+		return nil, false, err
+	}
+	changes := syncAccess(user, entry, groups, model.GetRolesFromCache())
+
+	user.UpdatedAt = hlc.Now()
+
+	var saveFields []string
+	if !created {
+		saveFields = []string{"ExternalAuthProviders", "Groups", "Roles", "UpdatedAt"}
+	}
+	if err := db.SaveUser(user, saveFields); err != nil {
+		return nil, false, err
+	}
+
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	if !created && !changes.Empty() {
+		auditChanges(user, "Directory login updated", changes)
+		go service.GetUserService().UpdateUserSpaces(user)
+	}
+
+	return user, created, nil
+}
+
+// canLinkByEmail checks a directory entry may be linked to the existing user
+// with the same email, which must be enabled and never moves a user already
+// linked to another directory entry.
+func canLinkByEmail(user *model.User, entry *Entry, linkByEmail bool) error {
+	if !linkByEmail {
+		return ErrNotLinked
+	}
+	if linked, ok := user.ExternalAuthProviders[ProviderId]; ok && linked.ProviderUID != "" && linked.ProviderUID != entry.DN {
+		return ErrAlreadyLinked
+	}
+	return nil
+}
+
+// newUser creates the user for a first login, without a local password.
+func newUser(db database.DbDriver, entry *Entry) (*model.User, error) {
+	if !validate.Email(entry.Email) {
+		return nil, fmt.Errorf("directory entry %s has no valid email address", entry.DN)
+	}
+
+	username, err := extauth.NewUsername(db, entry.Username, entry.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := model.NewUser(username, entry.Email, "", []string{}, []string{}, "", "bash", "UTC", 0, "", 0, 0, 0)
+	user.Password = ""
+
+	log.Info("ldap: creating user", "dn", entry.DN, "username", username, "email", entry.Email)
+	return user, nil
+}
+
+// syncAccess applies the configured group mappings for the entry's groups,
+// matching group DNs and CNs without regard to case.
+func syncAccess(user *model.User, entry *Entry, groups []*model.Group, roles []*model.Role) extauth.Changes {
+	cfg := config.GetServerConfig().LDAP
+
+	mappings := make([]extauth.Mapping, 0, len(cfg.GroupMappings))
+	for _, mapping := range cfg.GroupMappings {
+		mappings = append(mappings, extauth.Mapping{Source: normalizeGroup(mapping.Group), Groups: mapping.Groups, Roles: mapping.Roles})
+	}
+	return extauth.Apply(ProviderId, user, memberships(entry.Groups), mappings, groups, roles)
+}
+
+// auditChanges records the groups and roles the directory added or removed.
+func auditChanges(user *model.User, action string, changes extauth.Changes) {
+	audit.Log(
+		ProviderId,
+		model.AuditActorTypeSystem,
+		model.AuditEventUserUpdate,
+		fmt.Sprintf("%s user %s (%s)", action, user.Username, user.Email),
+		&map[string]interface{}{
+			"user_id":        user.Id,
+			"user_name":      user.Username,
+			"user_email":     user.Email,
+			"groups_added":   changes.GroupsAdded,
+			"groups_removed": changes.GroupsRemoved,
+			"roles_added":    changes.RolesAdded,
+			"roles_removed":  changes.RolesRemoved,
+		},
+	)
+}
+
+func (entry *Entry) displayUsername() string {
+	if entry.Username != "" {
+		return entry.Username
+	}
+	return entry.Email
+}
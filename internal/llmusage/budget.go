@@ -0,0 +1,171 @@
+package llmusage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+// BudgetExceededError is returned when a group the user belongs to has used
+// its token budget for the day or month.
+type BudgetExceededError struct {
+	Group  string
+	Period string
+	Budget uint64
+	Used   uint64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("the %s AI token budget of %d tokens for group %s has been used (%d tokens)", e.Period, e.Budget, e.Group, e.Used)
+}
+
+// budgetCacheTTL is how long the group token totals are reused before they are
+// reloaded, usage recorded on this node is added to them as it happens.
+const budgetCacheTTL = 30 * time.Second
+
+// groupTotals are the tokens used by the members of a group today and this month.
+type groupTotals struct {
+	daily   uint64
+	monthly uint64
+}
+
+var (
+	budgetMu         sync.Mutex
+	budgetLoadedAt   time.Time
+	budgetTotals     map[string]*groupTotals
+	budgetUserGroups map[string][]string
+)
+
+// CheckBudget returns a BudgetExceededError if any group of the user has used
+// its daily or monthly token budget. Budgets are shared by all members of a
+// group, users outside of any group with a budget are never limited.
+func CheckBudget(user *model.User) error {
+	if user == nil || len(user.Groups) == 0 {
+		return nil
+	}
+
+	db := database.GetInstance()
+
+	var groups []*model.Group
+	for _, groupId := range user.Groups {
+		group, err := db.GetGroup(groupId)
+		if err != nil || group == nil || group.IsDeleted {
+			continue
+		}
+		if group.DailyTokenBudget > 0 || group.MonthlyTokenBudget > 0 {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+
+	now := time.Now().UTC()
+	if budgetTotals == nil || now.Sub(budgetLoadedAt) > budgetCacheTTL || !model.LLMUsageDay(now).Equal(model.LLMUsageDay(budgetLoadedAt)) {
+		usage, err := db.GetLLMUsageRange(model.LLMUsageMonth(now), now)
+		if err != nil {
+			return err
+		}
+
+		users, err := db.GetUsers()
+		if err != nil {
+			return err
+		}
+
+		budgetUserGroups = make(map[string][]string, len(users))
+		for _, u := range users {
+			if !u.IsDeleted {
+				budgetUserGroups[u.Id] = u.Groups
+			}
+		}
+		budgetTotals = sumGroupUsage(GroupMembers(users), usage, now)
+		budgetLoadedAt = now
+	}
+
+	return checkGroupBudgets(groups, budgetTotals)
+}
+
+// addBudgetUsage adds tokens recorded on this node to the cached totals of the
+// user's groups so budgets apply before the totals are next reloaded.
+func addBudgetUsage(userId string, tokens uint64) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+
+	if budgetTotals == nil {
+		return
+	}
+
+	for _, groupId := range budgetUserGroups[userId] {
+		totals := budgetTotals[groupId]
+		if totals == nil {
+			totals = &groupTotals{}
+			budgetTotals[groupId] = totals
+		}
+		totals.daily += tokens
+		totals.monthly += tokens
+	}
+}
+
+// GroupMembers maps each group id to the ids of the users in the group.
+func GroupMembers(users []*model.User) map[string]map[string]bool {
+	members := make(map[string]map[string]bool)
+	for _, user := range users {
+		if user.IsDeleted {
+			continue
+		}
+		for _, groupId := range user.Groups {
+			if members[groupId] == nil {
+				members[groupId] = make(map[string]bool)
+			}
+			members[groupId][user.Id] = true
+		}
+	}
+	return members
+}
+
+// sumGroupUsage totals the tokens used today and this month by the members of
+// each group.
+func sumGroupUsage(members map[string]map[string]bool, usage []*model.LLMUsage, now time.Time) map[string]*groupTotals {
+	today := model.LLMUsageDay(now)
+	month := model.LLMUsageMonth(now)
+
+	totals := make(map[string]*groupTotals, len(members))
+	for groupId, users := range members {
+		group := &groupTotals{}
+		for _, row := range usage {
+			if !users[row.UserId] || row.Day.Before(month) {
+				continue
+			}
+			group.monthly += row.TotalTokens
+			if row.Day.Equal(today) {
+				group.daily += row.TotalTokens
+			}
+		}
+		totals[groupId] = group
+	}
+	return totals
+}
+
+func checkGroupBudgets(groups []*model.Group, totals map[string]*groupTotals) error {
+	for _, group := range groups {
+		used := totals[group.Id]
+		if used == nil {
+			continue
+		}
+
+		if group.DailyTokenBudget > 0 && used.daily >= group.DailyTokenBudget {
+			return &BudgetExceededError{Group: group.Name, Period: "daily", Budget: group.DailyTokenBudget, Used: used.daily}
+		}
+		if group.MonthlyTokenBudget > 0 && used.monthly >= group.MonthlyTokenBudget {
+			return &BudgetExceededError{Group: group.Name, Period: "monthly", Budget: group.MonthlyTokenBudget, Used: used.monthly}
+		}
+	}
+
+	return nil
+}
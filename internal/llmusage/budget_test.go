@@ -0,0 +1,81 @@
+package llmusage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func usageRow(userId, modelName string, day time.Time, total uint64) *model.LLMUsage {
+	row := model.NewLLMUsage("node", userId, modelName, day)
+	row.Add(total, 0, total)
+	return row
+}
+
+func TestCheckGroupBudgets(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	members := GroupMembers([]*model.User{
+		{Id: "u1", Groups: []string{"g1"}},
+		{Id: "u2", Groups: []string{"g1"}},
+		{Id: "u3"},
+	})
+	usage := []*model.LLMUsage{
+		usageRow("u1", "gpt", now, 60),
+		usageRow("u2", "gpt", now, 50),
+		usageRow("u1", "gpt", now.AddDate(0, 0, -3), 500),
+		usageRow("u1", "gpt", now.AddDate(0, -1, 0), 10000),
+		usageRow("u3", "gpt", now, 10000),
+	}
+
+	tests := []struct {
+		name   string
+		group  *model.Group
+		period string
+	}{
+		{"no budget", &model.Group{Id: "g1"}, ""},
+		{"daily under", &model.Group{Id: "g1", DailyTokenBudget: 200}, ""},
+		{"daily used", &model.Group{Id: "g1", DailyTokenBudget: 110}, "daily"},
+		{"monthly under", &model.Group{Id: "g1", MonthlyTokenBudget: 1000}, ""},
+		{"monthly used", &model.Group{Id: "g1", MonthlyTokenBudget: 600}, "monthly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGroupBudgets([]*model.Group{tt.group}, sumGroupUsage(members, usage, now))
+
+			var budgetErr *BudgetExceededError
+			if tt.period == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("expected a budget error, got %v", err)
+			}
+			if budgetErr.Period != tt.period {
+				t.Errorf("Period = %s, want %s", budgetErr.Period, tt.period)
+			}
+		})
+	}
+}
+
+func TestAddBudgetUsage(t *testing.T) {
+	budgetTotals = map[string]*groupTotals{"g1": {daily: 10, monthly: 100}}
+	budgetUserGroups = map[string][]string{"u1": {"g1", "g2"}}
+	defer func() {
+		budgetTotals = nil
+		budgetUserGroups = nil
+	}()
+
+	addBudgetUsage("u1", 5)
+	addBudgetUsage("u2", 50)
+
+	if got := budgetTotals["g1"]; got.daily != 15 || got.monthly != 105 {
+		t.Errorf("g1 totals = %+v, want daily 15 monthly 105", *got)
+	}
+	if got := budgetTotals["g2"]; got == nil || got.daily != 5 || got.monthly != 5 {
+		t.Errorf("g2 totals = %+v, want daily 5 monthly 5", got)
+	}
+}
@@ -0,0 +1,102 @@
+package llmusage
+
+import (
+	"context"
+
+	"github.com/paularlott/knot/internal/database/model"
+
+	ai "github.com/paularlott/mcp/ai"
+	mcpopenai "github.com/paularlott/mcp/ai/openai"
+)
+
+// meteredClient wraps an AI client so every chat completion made on behalf of
+// a user is checked against the budgets of their groups and the tokens used
+// are recorded. Requests without a user in the context are passed through.
+type meteredClient struct {
+	ai.Client
+}
+
+func NewMeteredClient(client ai.Client) ai.Client {
+	return &meteredClient{Client: client}
+}
+
+func (c *meteredClient) ChatCompletion(ctx context.Context, req ai.ChatCompletionRequest) (*ai.ChatCompletionResponse, error) {
+	user, _ := ctx.Value("user").(*model.User)
+	if err := CheckBudget(user); err != nil {
+		return nil, err
+	}
+
+	response, err := c.Client.ChatCompletion(ctx, req)
+	if err == nil && user != nil {
+		RecordResponse(user.Id, req.Model, response.Model, response.Usage)
+	}
+	return response, err
+}
+
+func (c *meteredClient) StreamChatCompletion(ctx context.Context, req ai.ChatCompletionRequest) *ai.ChatStream {
+	user, _ := ctx.Value("user").(*model.User)
+	if user == nil {
+		return c.Client.StreamChatCompletion(ctx, req)
+	}
+
+	responseChan := make(chan ai.ChatCompletionResponse, 50)
+	errorChan := make(chan error, 1)
+	stream := mcpopenai.NewChatStream(ctx, responseChan, errorChan)
+
+	if err := CheckBudget(user); err != nil {
+		errorChan <- err
+		close(errorChan)
+		close(responseChan)
+		stream.SetRetryMetadata(nil)
+		return stream
+	}
+
+	upstream := c.Client.StreamChatCompletion(ctx, req)
+	go func() {
+		defer close(responseChan)
+		defer close(errorChan)
+
+		// The final chunk carries the usage of the whole completion, including
+		// any tool call rounds, estimated if the provider doesn't report it
+		modelName := ""
+		var usage *ai.Usage
+		for upstream.Next() {
+			response := upstream.Current()
+			if response.Model != "" {
+				modelName = response.Model
+			}
+			if response.Usage != nil {
+				usage = response.Usage
+			}
+
+			select {
+			case responseChan <- response:
+			case <-ctx.Done():
+			}
+		}
+
+		RecordResponse(user.Id, req.Model, modelName, usage)
+
+		if err := upstream.Err(); err != nil {
+			errorChan <- err
+		}
+		stream.SetRetryMetadata(upstream.Retry())
+	}()
+
+	return stream
+}
+
+// RecordResponse records the usage reported by the provider against the
+// model that answered, falling back to the requested model.
+func RecordResponse(userId, requestedModel, responseModel string, usage *ai.Usage) {
+	if usage == nil || usage.TotalTokens+usage.PromptTokens+usage.CompletionTokens == 0 {
+		return
+	}
+
+	modelName := responseModel
+	if modelName == "" {
+		modelName = requestedModel
+	}
+
+	Record(userId, modelName, uint64(usage.PromptTokens), uint64(usage.CompletionTokens), uint64(usage.TotalTokens))
+}
@@ -0,0 +1,53 @@
+package llmusage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/service"
+)
+
+var (
+	recordMu sync.Mutex
+	nodeId   string
+)
+
+// Record adds the tokens of one completion to the usage of the user and model
+// for today on this node and shares the updated row with the cluster.
+func Record(userId, modelName string, promptTokens, completionTokens, totalTokens uint64) {
+	if userId == "" {
+		return
+	}
+
+	db := database.GetInstance()
+
+	recordMu.Lock()
+	if nodeId == "" {
+		if cfg, err := db.GetCfgValue("node_id"); err == nil && cfg != nil {
+			nodeId = cfg.Value
+		}
+	}
+
+	now := time.Now().UTC()
+	usage, err := db.GetLLMUsage(model.LLMUsageId(nodeId, userId, modelName, now))
+	if err != nil || usage == nil {
+		usage = model.NewLLMUsage(nodeId, userId, modelName, now)
+	}
+	usage.Add(promptTokens, completionTokens, totalTokens)
+	err = db.SaveLLMUsage(usage)
+	recordMu.Unlock()
+
+	if err != nil {
+		log.WithError(err).Error("failed to save llm usage", "user_id", userId, "model", modelName)
+		return
+	}
+
+	addBudgetUsage(userId, totalTokens)
+
+	if transport := service.GetTransport(); transport != nil {
+		transport.GossipLLMUsage(usage)
+	}
+}
@@ -0,0 +1,118 @@
+package llmusage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+// BuildReport totals the usage rows for the days from..to by user and model,
+// group and model. The rows must also cover the current month so the group
+// usage can be compared with the budgets.
+func BuildReport(usage []*model.LLMUsage, users []*model.User, groups []*model.Group, from, to, now time.Time) *apiclient.LLMUsageReport {
+	from = model.LLMUsageDay(from)
+	today := model.LLMUsageDay(now)
+	month := model.LLMUsageMonth(now)
+
+	report := &apiclient.LLMUsageReport{
+		From:   from,
+		To:     to.UTC(),
+		Users:  []apiclient.LLMUsageUserInfo{},
+		Groups: []apiclient.LLMUsageGroupInfo{},
+		Models: []apiclient.LLMUsageModelInfo{},
+	}
+
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.Id] = user.Username
+	}
+	members := GroupMembers(users)
+
+	type userModel struct{ userId, model string }
+	byUserModel := make(map[userModel]*apiclient.LLMUsageUserInfo)
+	byModel := make(map[string]*apiclient.LLMUsageModelInfo)
+	byGroup := make(map[string]*apiclient.LLMUsageGroupInfo)
+
+	for _, group := range groups {
+		if group.IsDeleted {
+			continue
+		}
+		byGroup[group.Id] = &apiclient.LLMUsageGroupInfo{
+			GroupId:            group.Id,
+			Name:               group.Name,
+			DailyTokenBudget:   group.DailyTokenBudget,
+			MonthlyTokenBudget: group.MonthlyTokenBudget,
+		}
+	}
+
+	for _, row := range usage {
+		inRange := !row.Day.Before(from) && !row.Day.After(to)
+
+		for groupId, info := range byGroup {
+			if !members[groupId][row.UserId] {
+				continue
+			}
+			if inRange {
+				addTotals(&info.LLMUsageTotals, row)
+			}
+			if !row.Day.Before(month) {
+				info.UsedThisMonth += row.TotalTokens
+				if row.Day.Equal(today) {
+					info.UsedToday += row.TotalTokens
+				}
+			}
+		}
+
+		if !inRange {
+			continue
+		}
+
+		addTotals(&report.Total, row)
+
+		key := userModel{row.UserId, row.Model}
+		if byUserModel[key] == nil {
+			byUserModel[key] = &apiclient.LLMUsageUserInfo{UserId: row.UserId, Username: usernames[row.UserId], Model: row.Model}
+		}
+		addTotals(&byUserModel[key].LLMUsageTotals, row)
+
+		if byModel[row.Model] == nil {
+			byModel[row.Model] = &apiclient.LLMUsageModelInfo{Model: row.Model}
+		}
+		addTotals(&byModel[row.Model].LLMUsageTotals, row)
+	}
+
+	for _, info := range byUserModel {
+		report.Users = append(report.Users, *info)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		if report.Users[i].Username != report.Users[j].Username {
+			return report.Users[i].Username < report.Users[j].Username
+		}
+		return report.Users[i].Model < report.Users[j].Model
+	})
+
+	for _, info := range byGroup {
+		report.Groups = append(report.Groups, *info)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Name < report.Groups[j].Name
+	})
+
+	for _, info := range byModel {
+		report.Models = append(report.Models, *info)
+	}
+	sort.Slice(report.Models, func(i, j int) bool {
+		return report.Models[i].Model < report.Models[j].Model
+	})
+
+	return report
+}
+
+func addTotals(totals *apiclient.LLMUsageTotals, row *model.LLMUsage) {
+	totals.Requests += row.Requests
+	totals.PromptTokens += row.PromptTokens
+	totals.CompletionTokens += row.CompletionTokens
+	totals.TotalTokens += row.TotalTokens
+}
@@ -0,0 +1,44 @@
+package llmusage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	users := []*model.User{
+		{Id: "u1", Username: "alice", Groups: []string{"g1"}},
+		{Id: "u2", Username: "bob"},
+	}
+	groups := []*model.Group{
+		{Id: "g1", Name: "dev", DailyTokenBudget: 1000},
+	}
+	usage := []*model.LLMUsage{
+		usageRow("u1", "gpt", now, 10),
+		usageRow("u1", "claude", now.AddDate(0, 0, -1), 20),
+		usageRow("u2", "gpt", now, 30),
+		usageRow("u1", "gpt", now.AddDate(0, 0, -10), 40),
+	}
+
+	report := BuildReport(usage, users, groups, now.AddDate(0, 0, -2), now, now)
+
+	if report.Total.TotalTokens != 60 || report.Total.Requests != 3 {
+		t.Errorf("unexpected total %+v", report.Total)
+	}
+	if len(report.Users) != 3 || report.Users[0].Username != "alice" || report.Users[0].Model != "claude" {
+		t.Errorf("unexpected users %+v", report.Users)
+	}
+	if len(report.Models) != 2 || report.Models[1].Model != "gpt" || report.Models[1].TotalTokens != 40 {
+		t.Errorf("unexpected models %+v", report.Models)
+	}
+	if len(report.Groups) != 1 {
+		t.Fatalf("unexpected groups %+v", report.Groups)
+	}
+	group := report.Groups[0]
+	if group.TotalTokens != 30 || group.UsedToday != 10 || group.UsedThisMonth != 70 || group.DailyTokenBudget != 1000 {
+		t.Errorf("unexpected group %+v", group)
+	}
+}
@@ -2,7 +2,8 @@
 // per-user, MCP-backed chat architecture.
 //
 // Knot uses lmchatkit.StandardHost (same as llmrouter) with the OpenAI base URL
-// pointing at knot's configured LLM endpoint. This is required because the
+// pointing at the chat router's loopback proxy, which forwards the raw request
+// to the provider of the requested model. This is required because the
 // MCP AI client's StreamChatCompletion SUPPRESSES tool-call delta chunks when
 // MCP servers are present — lmchatkit's manual approval flow needs those chunks
 // to reach the frontend. StandardHost uses TranslateOpenAIStream on the raw
@@ -15,19 +16,28 @@
 package lmchatkit
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
 
+	"github.com/paularlott/knot/internal/chat"
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/llmusage"
 	internalmcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/util/rest"
 
 	"github.com/paularlott/lmchatkit"
 	mcplib "github.com/paularlott/mcp"
 )
 
+// maxPeekBodySize limits how much of a request body is read to find the model.
+const maxPeekBodySize = 32 * 1024 * 1024
+
 // ScriptToolsProvider returns a per-user MCP tool provider (script tools +
 // method tools), matching the MCPServerContext middleware logic.
 type ScriptToolsProvider func(ctx context.Context, user *model.User) mcplib.ToolProvider
@@ -35,22 +45,34 @@ type ScriptToolsProvider func(ctx context.Context, user *model.User) mcplib.Tool
 // NewHost builds a lmchatkit.StandardHost configured for knot's LLM endpoint,
 // MCP server, and single persona. The per-user tool provider is injected by
 // AuthMiddleware (callers must wrap lmchatkit's routes with it).
-func NewHost(cfg config.ChatConfig, mcpServer *mcplib.Server, scriptToolsProvider ScriptToolsProvider) *lmchatkit.StandardHost {
-	// StandardHost.Complete appends "/v1/chat/completions" itself, so strip
-	// a trailing /v1 from the configured BaseURL to avoid a doubled path
-	// (e.g. http://host:1234/v1 → http://host:1234 + /v1/chat/completions).
-	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
-	baseURL = strings.TrimSuffix(baseURL, "/v1")
+//
+// StandardHost talks to the router's loopback proxy rather than a provider
+// so chat UI requests follow the model aliases and fallbacks.
+func NewHost(cfg config.ChatConfig, router *chat.Router, mcpServer *mcplib.Server, scriptToolsProvider ScriptToolsProvider) (*lmchatkit.StandardHost, error) {
+	// StandardHost.Complete appends "/v1/chat/completions" itself, the proxy
+	// base URL has no /v1 suffix.
+	baseURL, token, err := router.StartProxy()
+	if err != nil {
+		return nil, err
+	}
 
 	return &lmchatkit.StandardHost{
 		ModelsFunc: func(ctx context.Context) ([]lmchatkit.Model, error) {
-			if cfg.Model == "" {
-				return nil, nil
+			user := userFromCtx(ctx)
+
+			var models []lmchatkit.Model
+			for _, alias := range router.Aliases() {
+				if chat.CheckModelAllowed(user, alias) == nil {
+					models = append(models, lmchatkit.Model{ID: alias})
+				}
 			}
-			return []lmchatkit.Model{{ID: cfg.Model}}, nil
+			if len(router.Aliases()) == 0 && cfg.Model != "" && chat.CheckModelAllowed(user, cfg.Model) == nil {
+				models = append(models, lmchatkit.Model{ID: cfg.Model})
+			}
+			return models, nil
 		},
 		OpenAIBaseURL: baseURL,
-		OpenAIToken:   cfg.APIKey,
+		OpenAIToken:   token,
 		MCPServer: func(ctx context.Context) *mcplib.Server {
 			return mcpServer
 		},
@@ -61,21 +83,45 @@ func NewHost(cfg config.ChatConfig, mcpServer *mcplib.Server, scriptToolsProvide
 			}
 			return current + internalmcp.BuildSkillsPrompt(user)
 		},
-	}
+	}, nil
 }
 
 // AuthMiddleware returns the middleware that wraps every lmchatkit HTTP handler.
 // It authenticates the user (delegating to knot's ApiAuth + permission check)
 // and injects the per-user MCP tool provider into the request context so that
 // StandardHost.ListTools / CallTool resolve the user's script and method tools.
-func AuthMiddleware(apiAuthMiddleware func(http.Handler) http.Handler, mcpServer *mcplib.Server, scriptToolsProvider ScriptToolsProvider) func(http.Handler) http.Handler {
+//
+// Requests that may reach the LLM are refused once a group of the user has
+// used its AI token budget or when their model, the default model if they
+// don't name one, isn't allowed by the user's groups. Conversation history is
+// always available. Requests to the router's proxy are made on behalf of the
+// user, so the proxy checks the model each completion resolves to and records
+// the tokens used.
+func AuthMiddleware(router *chat.Router, apiAuthMiddleware func(http.Handler) http.Handler, mcpServer *mcplib.Server, scriptToolsProvider ScriptToolsProvider) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		// First authenticate (sets user in context), then inject MCP tools.
 		withTools := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
+			if r.Method == http.MethodPost && !strings.Contains(r.URL.Path, "/conversations") {
+				if err := llmusage.CheckBudget(userFromCtx(ctx)); err != nil {
+					rest.WriteResponse(http.StatusTooManyRequests, w, r, map[string]string{"error": err.Error()})
+					return
+				}
+				if modelName, ok := peekModel(r); ok {
+					if modelName == "" {
+						modelName = router.DefaultModel()
+					}
+					if err := chat.CheckModelAllowed(userFromCtx(ctx), modelName); err != nil {
+						rest.WriteResponse(http.StatusForbidden, w, r, map[string]string{"error": err.Error()})
+						return
+					}
+				}
+			}
+
 			ctx = context.WithValue(ctx, "mcp", mcpServer)
-			if scriptToolsProvider != nil {
-				if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+			if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+				ctx = router.WithProxyUser(ctx, user)
+				if scriptToolsProvider != nil {
 					if provider := scriptToolsProvider(ctx, user); provider != nil {
 						ctx = mcplib.WithToolProviders(ctx, provider)
 					}
@@ -87,6 +133,28 @@ func AuthMiddleware(apiAuthMiddleware func(http.Handler) http.Handler, mcpServer
 	}
 }
 
+// peekModel returns the model named by a JSON request body, leaving the body
+// in place for the next handler. Returns false if the body isn't JSON.
+func peekModel(r *http.Request) (string, bool) {
+	if r.Body == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return "", false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBodySize))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return "", false
+	}
+
+	var request struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(body, &request) != nil {
+		return "", false
+	}
+	return request.Model, true
+}
+
 // PersonaSource returns a lmchatkit.PersonaSource backed by knot's single
 // system-defined persona (loaded from the configured system prompt file).
 func PersonaSource() lmchatkit.PersonaSource {
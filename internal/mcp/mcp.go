@@ -27,7 +27,8 @@ func InitializeMCPServer(routes *http.ServeMux, enableWebEndpoint bool, mcpConfi
 	server.SetInstructions(`These tools manage spaces, templates, and other resources.
 
 All tools are directly callable on the /mcp endpoint.
-Use tool_search to discover tools by keyword or description.`)
+Use tool_search to discover tools by keyword or description.
+Skills and slash commands are available as prompts, space files and logs as resources.`)
 
 	if enableWebEndpoint {
 		// Create unified handler for /mcp endpoint
@@ -49,8 +50,18 @@ Use tool_search to discover tools by keyword or description.`)
 			// Attach providers and apply show-all mode (X-MCP-Show-All / ?show_all) in one step
 			ctx := mcp.WithShowAllFromRequest(r.Context(), r, providers...)
 
+			// Skills and slash commands are offered as prompts, space files and logs as resources
+			if user != nil {
+				ctx = mcp.WithPromptProviders(ctx, NewPromptProvider(user))
+				ctx = mcp.WithResourceProviders(ctx, NewResourceProvider(user))
+			}
+
+			// Relay progress from streaming methods when the client asks for it
+			w, r, finish := withProgress(w, r.WithContext(ctx))
+			defer finish()
+
 			// Handle the MCP request
-			server.HandleRequest(w, r.WithContext(ctx))
+			server.HandleRequest(w, r)
 		})
 
 		// Apply authentication middleware - unified endpoint
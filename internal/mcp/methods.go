@@ -10,7 +10,10 @@ import (
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/methods"
+	"github.com/paularlott/knot/internal/tracing"
 	mcplib "github.com/paularlott/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type methodToolsProvider struct {
@@ -68,11 +71,25 @@ func (p *methodToolsProvider) ExecuteTool(ctx context.Context, name string, para
 		if err != nil {
 			return nil, err
 		}
-		response, err := session.SendCallMethod(&msg.CallMethodRequest{
-			Method: localName,
-			Params: raw,
-			ID:     1,
-		}, entry.Server.Timeout)
+		ctx, span := tracing.StartKind(ctx, "agent.call_method", trace.SpanKindClient,
+			attribute.String("knot.method", name),
+			attribute.String("knot.space_id", entry.SpaceID),
+		)
+		defer span.End()
+
+		callReq := &msg.CallMethodRequest{
+			Method:       localName,
+			Params:       raw,
+			ID:           1,
+			TraceContext: tracing.Carrier(ctx),
+		}
+
+		var response *msg.CallMethodResponse
+		if onProgress := progressFromContext(ctx); entry.Streaming && onProgress != nil {
+			response, err = session.SendCallMethodStream(callReq, entry.Server.Timeout, onProgress)
+		} else {
+			response, err = session.SendCallMethod(callReq, entry.Server.Timeout)
+		}
 		if err != nil {
 			return nil, err
 		}
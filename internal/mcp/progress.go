@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/paularlott/knot/internal/methods"
+)
+
+type progressKey struct{}
+
+// progressFromContext returns the function sending progress notifications to
+// the MCP client, nil if the client did not ask for progress.
+func progressFromContext(ctx context.Context) func(*methods.Progress) {
+	fn, _ := ctx.Value(progressKey{}).(func(*methods.Progress))
+	return fn
+}
+
+// withProgress lets a tools/call carrying _meta.progressToken receive progress
+// notifications. When the client accepts server-sent events the reply becomes
+// an event stream, notifications are sent as the tool reports progress and the
+// response written by the MCP server is sent as the last event. finish must be
+// called once the MCP server has handled the request.
+func withProgress(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if r.Method != http.MethodPost || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return w, r, func() {}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return w, r, func() {}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var request struct {
+		Method string `json:"method"`
+		Params struct {
+			Meta struct {
+				ProgressToken any `json:"progressToken"`
+			} `json:"_meta"`
+		} `json:"params"`
+	}
+	if json.Unmarshal(body, &request) != nil || request.Method != "tools/call" || request.Params.Meta.ProgressToken == nil {
+		return w, r, func() {}
+	}
+
+	pw := &progressWriter{
+		w:      w,
+		header: w.Header().Clone(),
+		token:  request.Params.Meta.ProgressToken,
+	}
+	ctx := context.WithValue(r.Context(), progressKey{}, pw.send)
+	return pw, r.WithContext(ctx), pw.finish
+}
+
+// progressWriter holds back the MCP server's response so progress events can
+// be sent ahead of it.
+type progressWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+	token  any
+
+	mu       sync.Mutex
+	started  bool
+	finished bool
+}
+
+func (pw *progressWriter) Header() http.Header {
+	return pw.header
+}
+
+func (pw *progressWriter) WriteHeader(status int) {
+	if pw.status == 0 {
+		pw.status = status
+	}
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	if pw.status == 0 {
+		pw.status = http.StatusOK
+	}
+	return pw.body.Write(p)
+}
+
+// send writes a progress notification, the first one switches the reply to an
+// event stream. Partial output is sent as the message as MCP progress has no
+// field for it.
+func (pw *progressWriter) send(progress *methods.Progress) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.finished {
+		return
+	}
+
+	message := progress.Message
+	if data, ok := progress.Data.(string); ok && message == "" {
+		message = data
+	}
+
+	if !pw.started {
+		pw.started = true
+		for key, values := range pw.header {
+			pw.w.Header()[key] = values
+		}
+		pw.w.Header().Set("Content-Type", "text/event-stream")
+		pw.w.Header().Set("Cache-Control", "no-cache")
+		pw.w.Header().Set("X-Accel-Buffering", "no")
+		pw.w.WriteHeader(http.StatusOK)
+	}
+
+	pw.writeEvent(methods.ProgressNotification(&methods.Progress{
+		ProgressToken: pw.token,
+		Progress:      progress.Progress,
+		Total:         progress.Total,
+		Message:       message,
+	}))
+}
+
+func (pw *progressWriter) writeEvent(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(pw.w, "event: message\ndata: %s\n\n", data)
+	if flusher, ok := pw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish sends the held back response, as the last event if progress was
+// sent or as a plain reply if not.
+func (pw *progressWriter) finish() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	pw.finished = true
+
+	if pw.started {
+		pw.writeEvent(json.RawMessage(bytes.TrimSpace(pw.body.Bytes())))
+		return
+	}
+
+	for key, values := range pw.header {
+		pw.w.Header()[key] = values
+	}
+	if pw.status == 0 {
+		pw.status = http.StatusOK
+	}
+	pw.w.WriteHeader(pw.status)
+	_, _ = pw.w.Write(pw.body.Bytes())
+}
@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/internal/methods"
+)
+
+func newToolsCall(body string, accept string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", accept)
+	return r
+}
+
+func TestWithProgressStreamsNotifications(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := newToolsCall(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"export","_meta":{"progressToken":"abc"}}}`, "application/json, text/event-stream")
+
+	w, r, finish := withProgress(rec, r)
+	onProgress := progressFromContext(r.Context())
+	if onProgress == nil {
+		t.Fatal("expected a progress function in the context")
+	}
+
+	onProgress(&methods.Progress{Progress: 1, Total: 2, Data: "half way"})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":5,"result":{}}` + "\n"))
+	finish()
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %q", len(events), rec.Body.String())
+	}
+	if !strings.Contains(events[0], `"method":"notifications/progress"`) || !strings.Contains(events[0], `"progressToken":"abc"`) || !strings.Contains(events[0], `"message":"half way"`) {
+		t.Errorf("unexpected progress event %q", events[0])
+	}
+	if events[1] != `event: message`+"\n"+`data: {"jsonrpc":"2.0","id":5,"result":{}}` {
+		t.Errorf("unexpected response event %q", events[1])
+	}
+}
+
+func TestWithProgressPlainReply(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := newToolsCall(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"export","_meta":{"progressToken":1}}}`, "application/json, text/event-stream")
+
+	w, _, finish := withProgress(rec, r)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":5,"result":{}}`))
+	finish()
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected the response to be passed through, got %q", ct)
+	}
+	if rec.Body.String() != `{"jsonrpc":"2.0","id":5,"result":{}}` {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestWithProgressIgnoresOtherRequests(t *testing.T) {
+	for _, r := range []*http.Request{
+		newToolsCall(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"export"}}`, "application/json, text/event-stream"),
+		newToolsCall(`{"jsonrpc":"2.0","id":5,"method":"tools/list","params":{"_meta":{"progressToken":1}}}`, "application/json, text/event-stream"),
+		newToolsCall(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"_meta":{"progressToken":1}}}`, "application/json"),
+	} {
+		rec := httptest.NewRecorder()
+		w, r, _ := withProgress(rec, r)
+		if w != rec || progressFromContext(r.Context()) != nil {
+			t.Errorf("expected request to be left alone")
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/service"
+	mcplib "github.com/paularlott/mcp"
+)
+
+const (
+	skillPromptPrefix   = "skill-"
+	commandArgumentName = "arguments"
+)
+
+// promptProvider exposes the slash commands and skills the user can see as
+// MCP prompts. Commands keep their name and take a single free text argument
+// that replaces $ARGUMENTS in the body, skills are prefixed with "skill-".
+type promptProvider struct {
+	user *model.User
+}
+
+func NewPromptProvider(user *model.User) *promptProvider {
+	return &promptProvider{user: user}
+}
+
+func (p *promptProvider) GetPrompts(ctx context.Context) ([]mcplib.MCPPrompt, error) {
+	prompts := []mcplib.MCPPrompt{}
+	for _, command := range GetAccessibleCommands(p.user) {
+		description := command.ArgumentHint
+		if description == "" {
+			description = "Input for the command"
+		}
+		prompts = append(prompts, mcplib.MCPPrompt{
+			Name:        command.Name,
+			Description: command.Description,
+			Arguments: []mcplib.MCPPromptArgument{
+				{Name: commandArgumentName, Description: description},
+			},
+		})
+	}
+	for _, skill := range GetAccessibleSkills(p.user) {
+		prompts = append(prompts, mcplib.MCPPrompt{
+			Name:        skillPromptPrefix + skill.Name,
+			Description: skill.Description,
+		})
+	}
+	return prompts, nil
+}
+
+func (p *promptProvider) GetPrompt(ctx context.Context, name string, args map[string]string) (*mcplib.PromptResponse, error) {
+	if skillName, ok := strings.CutPrefix(name, skillPromptPrefix); ok {
+		for _, skill := range GetAccessibleSkills(p.user) {
+			if skill.Name == skillName {
+				response := mcplib.NewPromptResponseText(skill.Content)
+				response.Description = skill.Description
+				return response, nil
+			}
+		}
+	}
+
+	for _, command := range GetAccessibleCommands(p.user) {
+		if command.Name == name {
+			response := mcplib.NewPromptResponseText(renderCommandBody(command.Body, args[commandArgumentName]))
+			response.Description = command.Description
+			return response, nil
+		}
+	}
+
+	return nil, mcplib.ErrUnknownPrompt
+}
+
+// renderCommandBody substitutes the arguments into a command body, arguments
+// given to a body without a $ARGUMENTS placeholder are appended.
+func renderCommandBody(body, arguments string) string {
+	if strings.Contains(body, "$ARGUMENTS") {
+		return strings.ReplaceAll(body, "$ARGUMENTS", arguments)
+	}
+	if arguments == "" {
+		return body
+	}
+	return strings.TrimRight(body, "\n") + "\n\nARGUMENTS: " + arguments
+}
+
+// GetAccessibleCommands returns the active, zone-valid, ACL-filtered slash
+// commands for the user (global + own), with user commands overriding globals
+// of the same name. Returns nil if the user is nil or the database is
+// unreachable.
+func GetAccessibleCommands(user *model.User) []*model.Command {
+	if user == nil {
+		return nil
+	}
+
+	db := database.GetInstance()
+	if db == nil {
+		return nil
+	}
+
+	commands, err := db.GetCommands()
+	if err != nil {
+		return nil
+	}
+
+	currentZone := config.GetServerConfig().Zone
+
+	byName := make(map[string]*model.Command)
+	for _, command := range commands {
+		if !command.Active || command.IsDeleted {
+			continue
+		}
+		if !command.IsValidForZone(currentZone) {
+			continue
+		}
+		if !service.CanUserAccessCommand(user, command) {
+			continue
+		}
+		if existing, ok := byName[command.Name]; ok {
+			if command.IsUserCommand() && !existing.IsUserCommand() {
+				byName[command.Name] = command
+			}
+		} else {
+			byName[command.Name] = command
+		}
+	}
+
+	if len(byName) == 0 {
+		return nil
+	}
+
+	result := make([]*model.Command, 0, len(byName))
+	for _, c := range byName {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
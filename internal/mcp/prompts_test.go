@@ -0,0 +1,22 @@
+package mcp
+
+import "testing"
+
+func TestRenderCommandBody(t *testing.T) {
+	tests := []struct {
+		body      string
+		arguments string
+		want      string
+	}{
+		{"Review $ARGUMENTS carefully", "main.go", "Review main.go carefully"},
+		{"Fix $ARGUMENTS then test $ARGUMENTS", "x", "Fix x then test x"},
+		{"Summarise the repo\n", "", "Summarise the repo\n"},
+		{"Summarise the repo\n", "briefly", "Summarise the repo\n\nARGUMENTS: briefly"},
+	}
+
+	for _, tt := range tests {
+		if got := renderCommandBody(tt.body, tt.arguments); got != tt.want {
+			t.Errorf("renderCommandBody(%q, %q) = %q, want %q", tt.body, tt.arguments, got, tt.want)
+		}
+	}
+}
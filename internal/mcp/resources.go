@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/paularlott/knot/internal/agentapi/agent_server"
+	"github.com/paularlott/knot/internal/agentapi/msg"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/validate"
+	mcplib "github.com/paularlott/mcp"
+)
+
+const (
+	spaceResourcePrefix   = "knot://space/"
+	spaceFileTemplate     = "knot://space/{name}/file/{path}"
+	spaceLogsTemplate     = "knot://space/{name}/logs"
+	spaceLogResourceLines = 500
+)
+
+// resourceProvider exposes read only access to the files and logs of the
+// spaces the user can reach as MCP resources. File paths are always taken
+// from the root of the space filesystem.
+type resourceProvider struct {
+	user *model.User
+}
+
+func NewResourceProvider(user *model.User) *resourceProvider {
+	return &resourceProvider{user: user}
+}
+
+func (p *resourceProvider) GetResources(ctx context.Context) (*mcplib.ProvidedResources, error) {
+	resources := &mcplib.ProvidedResources{
+		Resources: []mcplib.MCPResource{},
+		Templates: []mcplib.MCPResourceTemplate{},
+	}
+
+	if p.user.HasPermission(model.PermissionCopyFiles) {
+		resources.Templates = append(resources.Templates, mcplib.MCPResourceTemplate{
+			URITemplate: spaceFileTemplate,
+			Name:        "Space file",
+			Description: "Read a file from a running space by its absolute path, e.g. knot://space/dev/file/home/user/README.md",
+		})
+	}
+
+	if p.user.HasPermission(model.PermissionUseLogs) {
+		resources.Templates = append(resources.Templates, mcplib.MCPResourceTemplate{
+			URITemplate: spaceLogsTemplate,
+			Name:        "Space logs",
+			Description: fmt.Sprintf("The latest %d log lines of a space", spaceLogResourceLines),
+			MimeType:    "text/plain",
+		})
+
+		spaces, err := database.GetInstance().GetSpacesForUser(p.user.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, space := range spaces {
+			if space.IsDeleted || space.IsDeleting {
+				continue
+			}
+			resources.Resources = append(resources.Resources, mcplib.MCPResource{
+				URI:         spaceResourcePrefix + url.PathEscape(space.Name) + "/logs",
+				Name:        space.Name + " logs",
+				Description: fmt.Sprintf("The latest %d log lines of space %s", spaceLogResourceLines, space.Name),
+				MimeType:    "text/plain",
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func (p *resourceProvider) ReadResource(ctx context.Context, uri string) (*mcplib.ResourceResponse, error) {
+	spaceName, filePath, isLogs, ok := parseSpaceResourceURI(uri)
+	if !ok {
+		return nil, mcplib.ErrUnknownResource
+	}
+
+	if isLogs {
+		return p.readSpaceLogs(uri, spaceName)
+	}
+	return p.readSpaceFile(uri, spaceName, filePath)
+}
+
+func (p *resourceProvider) readSpaceFile(uri, spaceName, filePath string) (*mcplib.ResourceResponse, error) {
+	if !p.user.HasPermission(model.PermissionCopyFiles) {
+		return nil, fmt.Errorf("no permission to read files")
+	}
+
+	space, err := p.getSpace(spaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := database.GetInstance().GetTemplate(space.TemplateId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template")
+	}
+	if !template.WithRunCommand {
+		return nil, fmt.Errorf("file operations are not allowed in this space")
+	}
+	if !space.IsDeployed {
+		return nil, fmt.Errorf("space is not running")
+	}
+
+	session := agent_server.GetSession(space.Id)
+	if session == nil {
+		return nil, fmt.Errorf("agent session not found for space")
+	}
+
+	responseChannel, err := session.SendCopyFile(&msg.CopyFileMessage{
+		SourcePath: filePath,
+		Direction:  "from_space",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send read file command to agent: %v", err)
+	}
+
+	response := <-responseChannel
+	if response == nil {
+		return nil, fmt.Errorf("no response from agent")
+	}
+	if !response.Success {
+		return nil, fmt.Errorf("failed to read file: %s", response.Error)
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(filePath))
+	if utf8.Valid(response.Content) {
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+		return mcplib.NewResourceResponseText(uri, string(response.Content), mimeType), nil
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return mcplib.NewResourceResponseBlob(uri, response.Content, mimeType), nil
+}
+
+func (p *resourceProvider) readSpaceLogs(uri, spaceName string) (*mcplib.ResourceResponse, error) {
+	if !p.user.HasPermission(model.PermissionUseLogs) {
+		return nil, fmt.Errorf("no permission to view logs")
+	}
+
+	space, err := p.getSpace(spaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := agent_server.QuerySpaceLogs(space.Id, nil, spaceLogResourceLines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %v", err)
+	}
+
+	// Entries are newest first, the resource reads oldest first
+	var sb strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		sb.WriteString(entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+		sb.WriteString(" " + strings.ToUpper(entry.Level))
+		if entry.Source != "" {
+			sb.WriteString(" [" + entry.Source + "]")
+		}
+		sb.WriteString(" " + strings.TrimRight(entry.Message, "\n") + "\n")
+	}
+
+	return mcplib.NewResourceResponseText(uri, sb.String(), "text/plain"), nil
+}
+
+// getSpace resolves a space by id or name, the user must own the space, have
+// it shared with them or be able to manage spaces.
+func (p *resourceProvider) getSpace(nameOrId string) (*model.Space, error) {
+	db := database.GetInstance()
+
+	var space *model.Space
+	var err error
+	if validate.UUID(nameOrId) {
+		space, err = db.GetSpace(nameOrId)
+	} else {
+		space, err = db.GetSpaceByName(p.user.Id, nameOrId)
+	}
+	if err != nil || space == nil || space.IsDeleted {
+		return nil, fmt.Errorf("space not found")
+	}
+
+	if space.UserId != p.user.Id && !space.IsSharedWith(p.user.Id) && !p.user.HasPermission(model.PermissionManageSpaces) {
+		return nil, fmt.Errorf("no permission to access this space")
+	}
+
+	return space, nil
+}
+
+// parseSpaceResourceURI splits a knot://space/{name}/file/{path} or
+// knot://space/{name}/logs URI into its parts. Names end at the first / so the
+// file path may contain further slashes, both are URL unescaped and the file
+// path is returned as an absolute path.
+func parseSpaceResourceURI(uri string) (spaceName, filePath string, isLogs, ok bool) {
+	rest, found := strings.CutPrefix(uri, spaceResourcePrefix)
+	if !found {
+		return "", "", false, false
+	}
+
+	name, rest, found := strings.Cut(rest, "/")
+	if !found || name == "" {
+		return "", "", false, false
+	}
+	spaceName, err := url.PathUnescape(name)
+	if err != nil {
+		return "", "", false, false
+	}
+
+	if rest == "logs" {
+		return spaceName, "", true, true
+	}
+
+	rest, found = strings.CutPrefix(rest, "file/")
+	if !found || rest == "" {
+		return "", "", false, false
+	}
+	filePath, err = url.PathUnescape(rest)
+	if err != nil {
+		return "", "", false, false
+	}
+
+	return spaceName, "/" + strings.TrimLeft(filePath, "/"), false, true
+}
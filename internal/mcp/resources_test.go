@@ -0,0 +1,32 @@
+package mcp
+
+import "testing"
+
+func TestParseSpaceResourceURI(t *testing.T) {
+	tests := []struct {
+		uri       string
+		spaceName string
+		filePath  string
+		isLogs    bool
+		ok        bool
+	}{
+		{"knot://space/dev/logs", "dev", "", true, true},
+		{"knot://space/dev/file/home/user/README.md", "dev", "/home/user/README.md", false, true},
+		{"knot://space/dev/file//etc/hosts", "dev", "/etc/hosts", false, true},
+		{"knot://space/dev/file/src/file/main.go", "dev", "/src/file/main.go", false, true},
+		{"knot://space/my%20space/file/notes%20today.txt", "my space", "/notes today.txt", false, true},
+		{"knot://space/dev/file/", "", "", false, false},
+		{"knot://space/dev", "", "", false, false},
+		{"knot://space//logs", "", "", false, false},
+		{"knot://space/dev/other", "", "", false, false},
+		{"file:///etc/hosts", "", "", false, false},
+	}
+
+	for _, tt := range tests {
+		spaceName, filePath, isLogs, ok := parseSpaceResourceURI(tt.uri)
+		if spaceName != tt.spaceName || filePath != tt.filePath || isLogs != tt.isLogs || ok != tt.ok {
+			t.Errorf("parseSpaceResourceURI(%q) = %q, %q, %v, %v; want %q, %q, %v, %v",
+				tt.uri, spaceName, filePath, isLogs, ok, tt.spaceName, tt.filePath, tt.isLogs, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+import knot.pool
+import scriptling.mcp.tool as tool
+
+name = tool.get_string("name")
+enabled = tool.get_bool("enabled", True)
+
+# Settings left out keep their current value
+settings = {}
+for key in ["min_count", "max_count", "target_cpu_percent", "target_rps_per_member", "scale_up_cooldown", "scale_down_cooldown"]:
+    value = tool.get_int(key, -1)
+    if value >= 0:
+        settings[key] = value
+
+target_metric = tool.get_string("target_metric", "")
+if target_metric:
+    settings["target_metric"] = target_metric
+    settings["target_metric_value"] = tool.get_int("target_metric_value", 0)
+
+try:
+    autoscale = knot.pool.set_autoscale(name, enabled=enabled, **settings)
+    if autoscale["enabled"]:
+        tool.return_string(f"Pool '{name}' autoscales between {autoscale['min_count']} and {autoscale['max_count']} spaces")
+    else:
+        tool.return_string(f"Autoscaling disabled for pool '{name}'")
+except Exception as e:
+    tool.return_error(str(e))
@@ -194,6 +194,32 @@ func (r *Registry) Count() int {
 	return total
 }
 
+// MethodStats summarises the registrations of one method name.
+type MethodStats struct {
+	Name          string
+	Registrations int
+	InFlight      int
+}
+
+// Stats returns the registration and in-flight call counts of every method
+// name, sorted by name.
+func (r *Registry) Stats() []MethodStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stats := make([]MethodStats, 0, len(r.entries))
+	for name, entries := range r.entries {
+		stat := MethodStats{Name: name, Registrations: len(entries)}
+		for _, entry := range entries {
+			stat.InFlight += entry.inFlight
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
 func (r *Registry) List(user *model.User) []MethodInfo {
 	if user == nil {
 		return nil
@@ -430,6 +456,7 @@ func (e *Entry) info() MethodInfo {
 		Scope:        e.Scope,
 		Groups:       e.Groups,
 		MCPTool:      e.MCPTool,
+		Streaming:    e.Streaming,
 		Events:       e.Events,
 		EventSinks:   e.EventSinks,
 		ParamsSchema: e.ParamsSchema,
@@ -448,6 +475,7 @@ func sameVisibleRegistration(a, b *Entry) bool {
 		a.Description == b.Description &&
 		a.Scope == b.Scope &&
 		a.MCPTool == b.MCPTool &&
+		a.Streaming == b.Streaming &&
 		reflect.DeepEqual(a.Keywords, b.Keywords) &&
 		reflect.DeepEqual(a.Groups, b.Groups) &&
 		reflect.DeepEqual(a.ParamsSchema, b.ParamsSchema) &&
@@ -6,7 +6,15 @@ const (
 	ScopePrivate = "private"
 	ScopeShared  = "shared"
 
+	// ServerTypeStdio is a process spawned by the agent, JSON-RPC is carried
+	// over its stdin/stdout.
 	ServerTypeStdio = "stdio"
+	// ServerTypeHTTP is an existing JSON-RPC over HTTP endpoint, calls are
+	// POSTed to the URL.
+	ServerTypeHTTP = "http"
+	// ServerTypeUnix is an existing JSON-RPC over HTTP endpoint listening on a
+	// Unix socket, calls are POSTed to the path.
+	ServerTypeUnix = "unix"
 
 	// ModeConcurrent allows multiple JSON-RPC requests to be in flight on the
 	// method server at the same time. Responses are correlated by id. This is
@@ -15,6 +23,10 @@ const (
 	// ModeSerial lets only one JSON-RPC request be in flight on the method
 	// server at a time. Useful for method servers that are not re-entrant.
 	ModeSerial = "serial"
+
+	// ProgressMethod is the notification a streaming method sends while it
+	// runs, it matches the MCP progress notification.
+	ProgressMethod = "notifications/progress"
 )
 
 type ServerConfig struct {
@@ -23,6 +35,26 @@ type ServerConfig struct {
 	Args    []string `json:"args,omitempty" toml:"args" msgpack:"args,omitempty"`
 	Timeout int      `json:"timeout,omitempty" toml:"timeout" msgpack:"timeout,omitempty"`
 	Mode    string   `json:"mode,omitempty" toml:"mode" msgpack:"mode,omitempty"`
+
+	// URL is the endpoint of an http server.
+	URL string `json:"url,omitempty" toml:"url" msgpack:"url,omitempty"`
+	// Socket is the path of the Unix socket of a unix server, Path is the
+	// HTTP path on the socket and defaults to /.
+	Socket string `json:"socket,omitempty" toml:"socket" msgpack:"socket,omitempty"`
+	Path   string `json:"path,omitempty" toml:"path" msgpack:"path,omitempty"`
+	// Headers are sent with every request to an http or unix server.
+	Headers map[string]string `json:"headers,omitempty" toml:"headers" msgpack:"headers,omitempty"`
+	// HealthPath is probed with a GET by the agent for http and unix servers,
+	// when empty the probe only checks a connection can be made.
+	HealthPath string `json:"health_path,omitempty" toml:"health_path" msgpack:"health_path,omitempty"`
+	// HealthInterval is the number of seconds between health probes.
+	HealthInterval int `json:"health_interval,omitempty" toml:"health_interval" msgpack:"health_interval,omitempty"`
+}
+
+// IsRemote reports whether the server is an existing listener rather than a
+// process spawned by the agent.
+func (s *ServerConfig) IsRemote() bool {
+	return s.Type == ServerTypeHTTP || s.Type == ServerTypeUnix
 }
 
 type MethodDefinition struct {
@@ -33,6 +65,7 @@ type MethodDefinition struct {
 	Scope        string         `json:"scope,omitempty" toml:"scope" msgpack:"scope,omitempty"`
 	Groups       []string       `json:"groups,omitempty" toml:"groups" msgpack:"groups,omitempty"`
 	MCPTool      bool           `json:"mcp_tool" toml:"mcp_tool" msgpack:"mcp_tool"`
+	Streaming    bool           `json:"streaming,omitempty" toml:"streaming" msgpack:"streaming,omitempty"`
 	Events       []string       `json:"events,omitempty" toml:"events,omitempty" msgpack:"events,omitempty"`
 	EventSinks   []string       `json:"event_sinks,omitempty" toml:"event_sinks,omitempty" msgpack:"event_sinks,omitempty"`
 	ParamsSchema map[string]any `json:"params_schema,omitempty" toml:"params_schema" msgpack:"params_schema,omitempty"`
@@ -52,6 +85,7 @@ type MethodInfo struct {
 	Scope         string         `json:"scope" msgpack:"scope"`
 	Groups        []string       `json:"groups,omitempty" msgpack:"groups,omitempty"`
 	MCPTool       bool           `json:"mcp_tool" msgpack:"mcp_tool"`
+	Streaming     bool           `json:"streaming,omitempty" msgpack:"streaming,omitempty"`
 	Events        []string       `json:"events,omitempty" msgpack:"events,omitempty"`
 	EventSinks    []string       `json:"event_sinks,omitempty" msgpack:"event_sinks,omitempty"`
 	ParamsSchema  map[string]any `json:"params_schema,omitempty" msgpack:"params_schema,omitempty"`
@@ -79,3 +113,30 @@ type JSONRPCError struct {
 	Code    int    `json:"code" msgpack:"code"`
 	Message string `json:"message" msgpack:"message"`
 }
+
+// JSONRPCNotification is a JSON-RPC request without an id.
+type JSONRPCNotification struct {
+	JSONRPC string `json:"jsonrpc" msgpack:"jsonrpc"`
+	Method  string `json:"method" msgpack:"method"`
+	Params  any    `json:"params,omitempty" msgpack:"params,omitempty"`
+}
+
+// Progress is the params of a progress notification. A streaming method is
+// called with _meta.progressToken in its params and sends the token back with
+// each notification. Data carries partial output.
+type Progress struct {
+	ProgressToken any     `json:"progressToken,omitempty" msgpack:"progress_token,omitempty"`
+	Progress      float64 `json:"progress" msgpack:"progress"`
+	Total         float64 `json:"total,omitempty" msgpack:"total,omitempty"`
+	Message       string  `json:"message,omitempty" msgpack:"message,omitempty"`
+	Data          any     `json:"data,omitempty" msgpack:"data,omitempty"`
+}
+
+// ProgressNotification wraps the progress in a JSON-RPC notification.
+func ProgressNotification(progress *Progress) *JSONRPCNotification {
+	return &JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  ProgressMethod,
+		Params:  progress,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -65,11 +66,8 @@ func NormalizeAndValidate(reg *Registration, spaceName string) error {
 	if reg.Server.Type == "" {
 		reg.Server.Type = ServerTypeStdio
 	}
-	if reg.Server.Type != ServerTypeStdio {
-		return fmt.Errorf("unsupported server type %q", reg.Server.Type)
-	}
-	if strings.TrimSpace(reg.Server.Command) == "" {
-		return fmt.Errorf("server command is required")
+	if err := validateServerTarget(&reg.Server); err != nil {
+		return err
 	}
 	if reg.Server.Timeout == 0 {
 		reg.Server.Timeout = 30
@@ -138,6 +136,9 @@ func NormalizeAndValidate(reg *Registration, spaceName string) error {
 				return err
 			}
 		}
+		if method.Streaming && reg.Server.IsRemote() {
+			return fmt.Errorf("method %q is streaming, streaming methods require a stdio server", method.Name)
+		}
 		if method.MCPTool {
 			toolName := MCPToolName(method.Name)
 			if existing, ok := seenMCPTools[toolName]; ok {
@@ -150,6 +151,57 @@ func NormalizeAndValidate(reg *Registration, spaceName string) error {
 	return nil
 }
 
+// validateServerTarget checks the fields needed to reach the method server for
+// its type and applies the defaults.
+func validateServerTarget(server *ServerConfig) error {
+	switch server.Type {
+	case ServerTypeStdio:
+		if strings.TrimSpace(server.Command) == "" {
+			return fmt.Errorf("server command is required")
+		}
+		return nil
+
+	case ServerTypeHTTP:
+		server.URL = strings.TrimSpace(server.URL)
+		if server.URL == "" {
+			return fmt.Errorf("server url is required")
+		}
+		u, err := url.Parse(server.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("server url must be an http or https URL")
+		}
+
+	case ServerTypeUnix:
+		server.Socket = strings.TrimSpace(server.Socket)
+		if server.Socket == "" {
+			return fmt.Errorf("server socket is required")
+		}
+		server.Path = strings.TrimSpace(server.Path)
+		if server.Path == "" {
+			server.Path = "/"
+		}
+		if !strings.HasPrefix(server.Path, "/") {
+			return fmt.Errorf("server path must start with /")
+		}
+
+	default:
+		return fmt.Errorf("unsupported server type %q", server.Type)
+	}
+
+	server.HealthPath = strings.TrimSpace(server.HealthPath)
+	if server.HealthPath != "" && !strings.HasPrefix(server.HealthPath, "/") {
+		return fmt.Errorf("server health_path must start with /")
+	}
+	if server.HealthInterval == 0 {
+		server.HealthInterval = 10
+	}
+	if server.HealthInterval < 0 {
+		return fmt.Errorf("server health_interval must be positive")
+	}
+
+	return nil
+}
+
 func validateSchema(methodName string, field string, schema map[string]any) error {
 	if len(schema) == 0 {
 		return nil
@@ -129,3 +129,93 @@ description = "Search things"
 		t.Fatalf("expected unknown mode error")
 	}
 }
+
+func TestLoadTOMLRemoteServers(t *testing.T) {
+	reg, err := LoadTOML([]byte(`
+[server]
+type = "unix"
+socket = "/run/notes.sock"
+mode = "serial"
+
+[[methods]]
+name = "notes.search"
+description = "Search notes"
+`), "notes")
+	if err != nil {
+		t.Fatalf("LoadTOML() error = %v", err)
+	}
+	if reg.Server.Path != "/" {
+		t.Fatalf("expected default path /, got %q", reg.Server.Path)
+	}
+	if reg.Server.HealthInterval != 10 {
+		t.Fatalf("expected default health interval 10, got %d", reg.Server.HealthInterval)
+	}
+	if !reg.Server.IsRemote() {
+		t.Fatalf("expected unix server to be remote")
+	}
+
+	reg, err = LoadTOML([]byte(`
+[server]
+type = "http"
+url = "http://127.0.0.1:8080/rpc"
+health_path = "/healthz"
+
+[server.headers]
+Authorization = "Bearer secret"
+
+[[methods]]
+name = "notes.search"
+description = "Search notes"
+`), "notes")
+	if err != nil {
+		t.Fatalf("LoadTOML() error = %v", err)
+	}
+	if reg.Server.Headers["Authorization"] != "Bearer secret" {
+		t.Fatalf("expected headers to be loaded, got %v", reg.Server.Headers)
+	}
+
+	for _, server := range []string{
+		"type = \"http\"",
+		"type = \"http\"\nurl = \"ftp://example.com\"",
+		"type = \"unix\"",
+		"type = \"unix\"\nsocket = \"/run/x.sock\"\npath = \"rpc\"",
+		"type = \"http\"\nurl = \"http://localhost\"\nhealth_path = \"healthz\"",
+	} {
+		_, err := LoadTOML([]byte("[server]\n"+server+"\n\n[[methods]]\nname = \"notes.search\"\ndescription = \"Search\"\n"), "notes")
+		if err == nil {
+			t.Errorf("expected error for server config %q", server)
+		}
+	}
+}
+
+func TestLoadTOMLStreaming(t *testing.T) {
+	reg, err := LoadTOML([]byte(`
+[server]
+command = "./bin/method-server"
+
+[[methods]]
+name = "notes.export"
+description = "Export notes"
+streaming = true
+`), "notes")
+	if err != nil {
+		t.Fatalf("LoadTOML() error = %v", err)
+	}
+	if !reg.Methods[0].Streaming {
+		t.Fatalf("expected method to be streaming")
+	}
+
+	_, err = LoadTOML([]byte(`
+[server]
+type = "http"
+url = "http://127.0.0.1:8080/rpc"
+
+[[methods]]
+name = "notes.export"
+description = "Export notes"
+streaming = true
+`), "notes")
+	if err == nil {
+		t.Fatalf("expected streaming to be rejected for an http server")
+	}
+}
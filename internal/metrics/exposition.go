@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	typeGauge   = "gauge"
+	typeCounter = "counter"
+)
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+type family struct {
+	name       string
+	help       string
+	metricType string
+	samples    []sample
+}
+
+// Writer collects metric samples for a single scrape and writes them in the
+// Prometheus text exposition format. Samples are grouped by metric name in the
+// order each name was first seen.
+type Writer struct {
+	families []*family
+	index    map[string]*family
+}
+
+func NewWriter() *Writer {
+	return &Writer{
+		index: make(map[string]*family),
+	}
+}
+
+// Gauge adds a gauge sample, labels are given as name, value pairs.
+func (w *Writer) Gauge(name, help string, value float64, labels ...string) {
+	w.add(name, help, typeGauge, value, labels)
+}
+
+// Counter adds a counter sample, labels are given as name, value pairs.
+func (w *Writer) Counter(name, help string, value float64, labels ...string) {
+	w.add(name, help, typeCounter, value, labels)
+}
+
+func (w *Writer) add(name, help, metricType string, value float64, labels []string) {
+	f, ok := w.index[name]
+	if !ok {
+		f = &family{name: name, help: help, metricType: metricType}
+		w.families = append(w.families, f)
+		w.index[name] = f
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// WriteTo writes the collected samples to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(out)}
+	for _, f := range w.families {
+		cw.writeString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		cw.writeString("# TYPE " + f.name + " " + f.metricType + "\n")
+		for _, s := range f.samples {
+			cw.writeString(f.name)
+			if len(s.labels) > 1 {
+				cw.writeString("{")
+				for i := 0; i+1 < len(s.labels); i += 2 {
+					if i > 0 {
+						cw.writeString(",")
+					}
+					cw.writeString(s.labels[i] + `="` + escapeLabelValue(s.labels[i+1]) + `"`)
+				}
+				cw.writeString("}")
+			}
+			cw.writeString(" " + formatValue(s.value) + "\n")
+		}
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// Serve writes the collected samples as the response to a scrape.
+func (w *Writer) Serve(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", ContentType)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = w.WriteTo(rw)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) writeString(s string) {
+	if c.err != nil {
+		return
+	}
+	n, err := c.w.WriteString(s)
+	c.n += int64(n)
+	c.err = err
+}
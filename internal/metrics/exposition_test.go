@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriterGroupsSamplesByName(t *testing.T) {
+	w := NewWriter()
+	w.Gauge("knot_spaces", "Spaces by state.", 2, "state", "running")
+	w.Counter("knot_deliveries_total", "Deliveries.", 5)
+	w.Gauge("knot_spaces", "Spaces by state.", 1, "state", "stopped")
+
+	var out strings.Builder
+	if _, err := w.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	want := `# HELP knot_spaces Spaces by state.
+# TYPE knot_spaces gauge
+knot_spaces{state="running"} 2
+knot_spaces{state="stopped"} 1
+# HELP knot_deliveries_total Deliveries.
+# TYPE knot_deliveries_total counter
+knot_deliveries_total 5
+`
+	if out.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriterEscapesLabelsAndValues(t *testing.T) {
+	w := NewWriter()
+	w.Gauge("knot_test", "Line one\nline two", 0.5, "name", `a "quoted" \ name`+"\n", "zone", "eu")
+	w.Gauge("knot_test", "", math.Inf(1))
+	w.Gauge("knot_test", "", math.NaN())
+
+	var out strings.Builder
+	if _, err := w.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d: %q", len(lines), out.String())
+	}
+	if lines[0] != `# HELP knot_test Line one\nline two` {
+		t.Errorf("unexpected help line %q", lines[0])
+	}
+	if lines[2] != `knot_test{name="a \"quoted\" \\ name\n",zone="eu"} 0.5` {
+		t.Errorf("unexpected sample line %q", lines[2])
+	}
+	if lines[3] != "knot_test +Inf" || lines[4] != "knot_test NaN" {
+		t.Errorf("unexpected special values %q %q", lines[3], lines[4])
+	}
+}
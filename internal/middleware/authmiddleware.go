@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/paularlott/gossip/hlc"
@@ -243,14 +242,11 @@ func ApiAuth(next http.HandlerFunc) http.HandlerFunc {
 			ctx = context.WithValue(ctx, "api_version", apiVersion)
 		}
 
-		// Enforce token scopes. A token with a non-empty Scopes slice may
-		// only reach endpoints covered by one of its scopes. Session cookies,
-		// agent tokens, and unscoped API tokens bypass this — only scoped
-		// user-issued API tokens are restricted.
-		if token, _ := ctx.Value("access_token").(*model.Token); token != nil && len(token.Scopes) > 0 {
-			if !tokenScopeAllows(token.Scopes, r.URL.Path) {
+		// Enforce the restrictions of scoped API tokens, see tokenscopes.go
+		if user, _ := ctx.Value("user").(*model.User); user != nil {
+			if reason := checkTokenRestrictions(r.WithContext(ctx), user); reason != "" {
 				rest.WriteResponse(http.StatusForbidden, w, r.WithContext(ctx), ErrorResponse{
-					Error: "token scopes do not permit this endpoint",
+					Error: reason,
 				})
 				return
 			}
@@ -320,6 +316,10 @@ func ApiPermissionUseTunnels(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionUseTunnels, "No permission to use tunnels")
 }
 
+func ApiPermissionUseLogs(next http.HandlerFunc) http.HandlerFunc {
+	return checkPermission(next, model.PermissionUseLogs, "No permission to view logs")
+}
+
 func ApiPermissionViewAuditLogs(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionViewAuditLogs, "No permission to view audit logs")
 }
@@ -428,6 +428,10 @@ func ApiPermissionUseWebAssistant(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionUseWebAssistant, "No permission to use web assistant")
 }
 
+func ApiPermissionViewAIUsage(next http.HandlerFunc) http.HandlerFunc {
+	return checkPermission(next, model.PermissionViewAIUsage, "No permission to view AI usage")
+}
+
 func ApiPermissionManageScripts(next http.HandlerFunc) http.HandlerFunc {
 	cfg := config.GetServerConfig()
 	if cfg.LeafNode {
@@ -454,21 +458,17 @@ func ApiPermissionManageMCPServers(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionManageMCPServers, "No permission to manage MCP servers")
 }
 
-// TokenScopesRequired wraps a handler with token-scope enforcement. If the
-// request was authenticated via a scoped API token (i.e. the token in context
-// has a non-empty Scopes slice), the request path must be covered by at least
-// one of the token's scopes. Tokens with no scopes (nil/empty — the default
-// for all pre-scopes tokens) are unrestricted. Session cookies and agent
-// tokens bypass this check entirely.
+// TokenScopesRequired wraps a handler with token restriction enforcement for
+// routes authenticated outside ApiAuth. Requests authenticated via a scoped
+// API token must be covered by one of the token's scopes and satisfy its
+// space and source address restrictions. Tokens with no restrictions (the
+// default for all pre-scopes tokens) are unaffected. Session cookies and
+// agent tokens bypass this check entirely.
 func TokenScopesRequired(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if token, _ := r.Context().Value("access_token").(*model.Token); token != nil && len(token.Scopes) > 0 {
-			if !tokenScopeAllows(token.Scopes, r.URL.Path) {
-				rest.WriteResponse(http.StatusForbidden, w, r, struct {
-					Error string `json:"error"`
-				}{
-					Error: "token scopes do not permit this endpoint",
-				})
+		if user, _ := r.Context().Value("user").(*model.User); user != nil {
+			if reason := checkTokenRestrictions(r, user); reason != "" {
+				rest.WriteResponse(http.StatusForbidden, w, r, ErrorResponse{Error: reason})
 				return
 			}
 		}
@@ -476,24 +476,6 @@ func TokenScopesRequired(next http.HandlerFunc) http.HandlerFunc {
 	})
 }
 
-// tokenScopeAllowedPaths maps each known scope to the URL prefix(es) it
-// covers. Add entries here as new scopes are introduced.
-var tokenScopeAllowedPaths = map[string][]string{
-	model.ScopeMethods: {"/api/methods"},
-	model.ScopeMCP:     {"/mcp"},
-}
-
-func tokenScopeAllows(scopes []string, path string) bool {
-	for _, scope := range scopes {
-		for _, prefix := range tokenScopeAllowedPaths[scope] {
-			if strings.HasPrefix(path, prefix) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 // OptionalWebAuth injects the authenticated user into the context if a valid
 // session cookie is present, but never redirects — unauthenticated requests
 // pass through with no user in context.
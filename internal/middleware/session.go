@@ -27,6 +27,22 @@ func GetSessionFromCookie(r *http.Request) (*model.Session, error) {
 	return nil, nil
 }
 
+// SetSessionCookie signs the browser in to the session, replacing any stale
+// session cookie.
+func SetSessionCookie(w http.ResponseWriter, session *model.Session) {
+	cfg := config.GetServerConfig()
+	DeleteSessionCookie(w)
+	http.SetCookie(w, &http.Cookie{
+		Name:     model.WebSessionCookie,
+		Value:    session.Id,
+		Path:     "/",
+		Domain:   cfg.SessionCookieDomain(),
+		HttpOnly: true,
+		Secure:   cfg.TLS.UseTLS,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 func DeleteSessionCookie(w http.ResponseWriter) {
 	cfg := config.GetServerConfig()
 	// Clear both cookie scopes so a stale cookie from before a domain change
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+// tokenScopeRule names the scopes covering the paths matched by pattern.
+// Patterns are compared segment by segment with path.Match and match any
+// path they are a prefix of, the first matching rule applies.
+type tokenScopeRule struct {
+	pattern string
+	read    string // scope for GET and HEAD requests
+	write   string // scope for all other methods
+}
+
+// tokenScopeRules maps every token authenticated route to its scope, more
+// specific patterns come first. Paths without a rule can't be reached by a
+// scoped token.
+var tokenScopeRules = []tokenScopeRule{
+	{"/api/methods", model.ScopeMethods, model.ScopeMethods},
+	{"/mcp", model.ScopeMCP, model.ScopeMCP},
+
+	// Spaces
+	{"/api/spaces/*/emit-event", model.ScopeEventsEmit, model.ScopeEventsEmit},
+	{"/api/spaces/*/run-command", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/api/spaces/*/files", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/api/spaces/*/execute-script*", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/api/spaces/*/ssh-cert", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/api/spaces/*/recordings", model.ScopeAuditRead, model.ScopeAuditRead},
+	{"/api/spaces/*/terminal-sessions", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/api/spaces", model.ScopeSpacesRead, model.ScopeSpacesWrite},
+	{"/api/stacks", model.ScopeSpacesRead, model.ScopeSpacesWrite},
+	{"/api/ssh/ca", model.ScopeSpacesRead, model.ScopeSpacesRead},
+	{"/logs", model.ScopeSpacesRead, model.ScopeSpacesRead},
+	{"/space-io/*/tunnel", model.ScopeTunnels, model.ScopeTunnels},
+	{"/space-io", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/proxy/spaces", model.ScopeSpacesExec, model.ScopeSpacesExec},
+	{"/tunnel/spaces", model.ScopeSpacesExec, model.ScopeSpacesExec},
+
+	// Templates and the resources spaces are built from
+	{"/api/templates", model.ScopeTemplatesRead, model.ScopeTemplatesWrite},
+	{"/api/templatevars", model.ScopeTemplatesRead, model.ScopeTemplatesWrite},
+	{"/api/stack-definitions", model.ScopeTemplatesRead, model.ScopeTemplatesWrite},
+	{"/api/base-images", model.ScopeTemplatesRead, model.ScopeTemplatesWrite},
+	{"/api/capabilities", model.ScopeTemplatesRead, model.ScopeTemplatesRead},
+	{"/api/spec", model.ScopeTemplatesWrite, model.ScopeTemplatesWrite},
+	{"/api/volumes", model.ScopeVolumesRead, model.ScopeVolumesWrite},
+	{"/api/pools", model.ScopePoolsRead, model.ScopePoolsWrite},
+
+	// Scripts and the assistant
+	{"/api/scripts", model.ScopeScriptsRead, model.ScopeScriptsWrite},
+	{"/api/skill", model.ScopeSkillsRead, model.ScopeSkillsWrite},
+	{"/api/command", model.ScopeSkillsRead, model.ScopeSkillsWrite},
+	{"/api/mcp-servers", model.ScopeSkillsRead, model.ScopeSkillsWrite},
+	{"/v1", model.ScopeAssistant, model.ScopeAssistant},
+
+	// Events and tunnels
+	{"/api/events/emit", model.ScopeEventsEmit, model.ScopeEventsEmit},
+	{"/api/event-sinks", model.ScopeEventsRead, model.ScopeEventsWrite},
+	{"/api/tunnels", model.ScopeTunnels, model.ScopeTunnels},
+	{"/tunnel/server", model.ScopeTunnels, model.ScopeTunnels},
+
+	// Users and access
+	{"/api/users", model.ScopeUsersRead, model.ScopeUsersWrite},
+	{"/api/groups", model.ScopeUsersRead, model.ScopeUsersWrite},
+	{"/api/roles", model.ScopeUsersRead, model.ScopeUsersWrite},
+	{"/api/permissions", model.ScopeUsersRead, model.ScopeUsersRead},
+	{"/scim/v2", model.ScopeUsersRead, model.ScopeUsersWrite},
+	{"/api/tokens", model.ScopeTokens, model.ScopeTokens},
+	{"/api/sessions", model.ScopeTokens, model.ScopeTokens},
+
+	// Server
+	{"/api/audit-logs", model.ScopeAuditRead, model.ScopeAuditRead},
+	{"/api/llm-usage", model.ScopeAuditRead, model.ScopeAuditRead},
+	{"/api/cluster-info", model.ScopeClusterRead, model.ScopeClusterRead},
+	{"/cluster/leaf", model.ScopeLeaf, model.ScopeLeaf},
+	{"/api/server-info", model.ScopeServerRead, model.ScopeServerRead},
+	{"/api/search", model.ScopeServerRead, model.ScopeServerRead},
+	{"/api/icons", model.ScopeServerRead, model.ScopeServerRead},
+}
+
+// tokenAlwaysAllowed can be reached by any scoped token so clients can check
+// the server and who they are.
+var tokenAlwaysAllowed = []string{"GET /api/ping", "GET /api/users/whoami"}
+
+// tokenScopeFor returns the scope covering a request, or "" if there is none.
+func tokenScopeFor(method, urlPath string) string {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+
+rules:
+	for _, rule := range tokenScopeRules {
+		patterns := strings.Split(strings.Trim(rule.pattern, "/"), "/")
+		if len(patterns) > len(segments) {
+			continue
+		}
+		for i, pattern := range patterns {
+			if ok, _ := path.Match(pattern, segments[i]); !ok {
+				continue rules
+			}
+		}
+
+		if method == http.MethodGet || method == http.MethodHead {
+			return rule.read
+		}
+		return rule.write
+	}
+
+	return ""
+}
+
+// hasTokenScope reports whether scopes include required, a :write scope also
+// grants the matching :read scope.
+func hasTokenScope(scopes []string, required string) bool {
+	if slices.Contains(scopes, required) {
+		return true
+	}
+	if resource, ok := strings.CutSuffix(required, ":read"); ok {
+		return slices.Contains(scopes, resource+":write")
+	}
+	return false
+}
+
+func tokenScopeAllows(scopes []string, method, urlPath string) bool {
+	if slices.Contains(tokenAlwaysAllowed, method+" "+urlPath) {
+		return true
+	}
+	required := tokenScopeFor(method, urlPath)
+	return required != "" && hasTokenScope(scopes, required)
+}
+
+// checkTokenRestrictions applies the scopes, space restriction and source
+// address of the API token a request was authenticated with, returning the
+// reason it is refused or "" if it may continue. Session cookies, agent
+// tokens and unrestricted API tokens aren't affected.
+func checkTokenRestrictions(r *http.Request, user *model.User) string {
+	token, _ := r.Context().Value("access_token").(*model.Token)
+	if token == nil || !token.IsRestricted() {
+		return ""
+	}
+
+	if token.SourceCIDR != "" {
+		prefix, err := netip.ParsePrefix(token.SourceCIDR)
+		ip, ok := ClientIP(r)
+		if err != nil || !ok || !prefix.Contains(ip) {
+			return "token is not permitted from this address"
+		}
+	}
+
+	if len(token.Scopes) > 0 && !tokenScopeAllows(token.Scopes, r.Method, r.URL.Path) {
+		return "token scopes do not permit this endpoint"
+	}
+
+	if token.HasSpaceRestriction() {
+		if stackName := r.PathValue("stack_name"); stackName != "" {
+			if !slices.Contains(token.Stacks, stackName) {
+				return "token is not permitted to access this stack"
+			}
+		} else if ref := cmp.Or(r.PathValue("space_id"), r.PathValue("space_name")); ref != "" {
+			if !tokenAllowsSpaceRef(token, user, ref) {
+				return "token is not permitted to access this space"
+			}
+		} else {
+			// Changes that don't name a space could reach any of them, as can
+			// the tools behind MCP, methods and the assistant
+			switch tokenScopeFor(r.Method, r.URL.Path) {
+			case model.ScopeSpacesWrite, model.ScopeSpacesExec, model.ScopeEventsEmit,
+				model.ScopeMCP, model.ScopeMethods, model.ScopeAssistant:
+				return "token is restricted to named spaces"
+			}
+		}
+	}
+
+	return ""
+}
+
+// tokenAllowsSpaceRef resolves a space given by ID or name and checks it
+// against the token's space restriction. A name that isn't a space, such as a
+// pool, must itself be listed.
+func tokenAllowsSpaceRef(token *model.Token, user *model.User, ref string) bool {
+	db := database.GetInstance()
+
+	var space *model.Space
+	var err error
+	if validate.UUID(ref) {
+		space, err = db.GetSpace(ref)
+	} else {
+		space, err = db.GetSpaceByName(user.Id, ref)
+	}
+	if err != nil || space == nil {
+		return !validate.UUID(ref) && slices.Contains(token.Spaces, ref)
+	}
+	return token.AllowsSpace(space)
+}
+
+// TokenAllowsSpace reports whether the request's API token, if any, may
+// access space, used to filter lists for tokens restricted to named spaces.
+func TokenAllowsSpace(r *http.Request, space *model.Space) bool {
+	token, _ := r.Context().Value("access_token").(*model.Token)
+	return token == nil || token.AllowsSpace(space)
+}
+
+// ClientIP returns the address of the client making the request. The
+// X-Forwarded-For header is only followed through trusted proxies, so the
+// result is the first untrusted address counting back from the connection.
+func ClientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+
+	var trusted []netip.Prefix
+	for _, cidr := range config.GetServerConfig().TrustedProxies {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			trusted = append(trusted, prefix)
+		} else if addr, err := netip.ParseAddr(cidr); err == nil {
+			trusted = append(trusted, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	isTrusted := func(ip netip.Addr) bool {
+		return slices.ContainsFunc(trusted, func(prefix netip.Prefix) bool { return prefix.Contains(ip) })
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && isTrusted(ip); i-- {
+		next, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		ip = next.Unmap()
+	}
+
+	return ip, true
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestTokenScopeFor(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/api/spaces", model.ScopeSpacesRead},
+		{"GET", "/api/spaces/abc", model.ScopeSpacesRead},
+		{"POST", "/api/spaces/abc/start", model.ScopeSpacesWrite},
+		{"POST", "/api/spaces/stacks/web/stop", model.ScopeSpacesWrite},
+		{"POST", "/api/spaces/abc/run-command", model.ScopeSpacesExec},
+		{"POST", "/api/spaces/abc/files/read", model.ScopeSpacesExec},
+		{"GET", "/api/spaces/abc/execute-script-stream", model.ScopeSpacesExec},
+		{"POST", "/api/spaces/abc/ssh-cert", model.ScopeSpacesExec},
+		{"GET", "/api/spaces/abc/recordings/def", model.ScopeAuditRead},
+		{"DELETE", "/api/spaces/abc/terminal-sessions/pair", model.ScopeSpacesExec},
+		{"GET", "/api/ssh/ca", model.ScopeSpacesRead},
+		{"POST", "/api/spaces/abc/emit-event", model.ScopeEventsEmit},
+		{"POST", "/api/events/emit", model.ScopeEventsEmit},
+		{"GET", "/space-io/abc/run", model.ScopeSpacesExec},
+		{"POST", "/space-io/abc/tunnel/start", model.ScopeTunnels},
+		{"GET", "/proxy/spaces/abc/terminal/bash", model.ScopeSpacesExec},
+		{"PUT", "/api/templates/abc", model.ScopeTemplatesWrite},
+		{"GET", "/api/templates", model.ScopeTemplatesRead},
+		{"GET", "/tunnel/server/web", model.ScopeTunnels},
+		{"POST", "/api/methods/call", model.ScopeMethods},
+		{"POST", "/mcp", model.ScopeMCP},
+		{"POST", "/v1/chat/completions", model.ScopeAssistant},
+		{"GET", "/api/spacesx", ""},
+		{"GET", "/api/unknown", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tokenScopeFor(tt.method, tt.path); got != tt.expected {
+			t.Errorf("tokenScopeFor(%s %s) = %q, expected %q", tt.method, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestTokenScopeAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		method   string
+		path     string
+		expected bool
+	}{
+		{"methods scope reaches methods", []string{model.ScopeMethods}, "POST", "/api/methods/call", true},
+		{"methods scope cannot reach spaces", []string{model.ScopeMethods}, "GET", "/api/spaces", false},
+		{"write implies read", []string{model.ScopeSpacesWrite}, "GET", "/api/spaces", true},
+		{"read does not imply write", []string{model.ScopeSpacesRead}, "DELETE", "/api/spaces/abc", false},
+		{"exec is not implied by write", []string{model.ScopeSpacesWrite}, "POST", "/api/spaces/abc/run-command", false},
+		{"ping always allowed", []string{model.ScopeMCP}, "GET", "/api/ping", true},
+		{"whoami always allowed", []string{model.ScopeMCP}, "GET", "/api/users/whoami", true},
+		{"whoami keys need users scope", []string{model.ScopeMCP}, "PUT", "/api/users/whoami/ssh-public-key", false},
+		{"unmapped path denied", []string{model.ScopeServerRead}, "GET", "/api/unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenScopeAllows(tt.scopes, tt.method, tt.path); got != tt.expected {
+				t.Errorf("tokenScopeAllows() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckTokenRestrictions(t *testing.T) {
+	user := &model.User{Id: "user-1"}
+
+	tests := []struct {
+		name       string
+		token      *model.Token
+		method     string
+		path       string
+		pathValues map[string]string
+		remoteAddr string
+		allowed    bool
+	}{
+		{"unrestricted token", &model.Token{}, "DELETE", "/api/spaces/abc", nil, "192.0.2.1:1234", true},
+		{"scope permits", &model.Token{Scopes: []string{model.ScopeTemplatesRead}}, "GET", "/api/templates", nil, "192.0.2.1:1234", true},
+		{"scope denies", &model.Token{Scopes: []string{model.ScopeTemplatesRead}}, "POST", "/api/templates", nil, "192.0.2.1:1234", false},
+		{"source inside CIDR", &model.Token{SourceCIDR: "192.0.2.0/24"}, "GET", "/api/templates", nil, "192.0.2.1:1234", true},
+		{"source outside CIDR", &model.Token{SourceCIDR: "192.0.2.0/24"}, "GET", "/api/templates", nil, "198.51.100.1:1234", false},
+		{"named stack", &model.Token{Stacks: []string{"web"}}, "POST", "/api/spaces/stacks/web/start", map[string]string{"stack_name": "web"}, "192.0.2.1:1234", true},
+		{"other stack", &model.Token{Stacks: []string{"web"}}, "POST", "/api/spaces/stacks/api/start", map[string]string{"stack_name": "api"}, "192.0.2.1:1234", false},
+		{"space restricted create", &model.Token{Spaces: []string{"dev"}}, "POST", "/api/spaces", nil, "192.0.2.1:1234", false},
+		{"space restricted list", &model.Token{Spaces: []string{"dev"}}, "GET", "/api/spaces", nil, "192.0.2.1:1234", true},
+		{"space restricted other resource", &model.Token{Spaces: []string{"dev"}}, "POST", "/api/templates", nil, "192.0.2.1:1234", true},
+		{"space restricted mcp", &model.Token{Spaces: []string{"dev"}}, "POST", "/mcp", nil, "192.0.2.1:1234", false},
+		{"stack restricted mcp", &model.Token{Stacks: []string{"web"}, Scopes: []string{model.ScopeMCP}}, "POST", "/mcp", nil, "192.0.2.1:1234", false},
+		{"space restricted methods", &model.Token{Spaces: []string{"dev"}}, "POST", "/api/methods", nil, "192.0.2.1:1234", false},
+		{"space restricted assistant", &model.Token{Spaces: []string{"dev"}}, "POST", "/v1/chat/completions", nil, "192.0.2.1:1234", false},
+		{"scoped mcp", &model.Token{Scopes: []string{model.ScopeMCP}}, "POST", "/mcp", nil, "192.0.2.1:1234", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.pathValues {
+				req.SetPathValue(key, value)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), "access_token", tt.token))
+
+			reason := checkTokenRestrictions(req, user)
+			if (reason == "") != tt.allowed {
+				t.Errorf("checkTokenRestrictions() = %q, expected allowed %v", reason, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	prev := config.GetServerConfig()
+	config.SetServerConfig(&config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+	defer config.SetServerConfig(prev)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{"direct", "198.51.100.7:1234", "", "198.51.100.7"},
+		{"untrusted proxy ignored", "198.51.100.7:1234", "203.0.113.9", "198.51.100.7"},
+		{"trusted proxy", "10.1.2.3:1234", "203.0.113.9", "203.0.113.9"},
+		{"spoofed entry skipped", "10.1.2.3:1234", "1.2.3.4, 203.0.113.9", "203.0.113.9"},
+		{"chain of trusted proxies", "10.1.2.3:1234", "203.0.113.9, 192.168.1.1", "203.0.113.9"},
+		{"trusted proxy without header", "10.1.2.3:1234", "", "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/ping", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			ip, ok := ClientIP(req)
+			if !ok || ip.String() != tt.expected {
+				t.Errorf("ClientIP() = %v, expected %s", ip, tt.expected)
+			}
+		})
+	}
+}
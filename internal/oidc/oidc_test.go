@@ -0,0 +1,328 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// mockIdP is a minimal OpenID Connect provider issuing RS256 ID tokens for
+// codes handed out by authorize.
+type mockIdP struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]mockGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		grant, ok := idp.codes[r.Form.Get("code")]
+		delete(idp.codes, r.Form.Get("code"))
+		idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := map[string]any{
+			"iss":   idp.URL,
+			"aud":   "knot",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": grant.nonce,
+		}
+		for k, v := range grant.claims {
+			claims[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, claims),
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+
+	return idp
+}
+
+func (idp *mockIdP) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: idp.key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(claims)
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signed.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// authorize plays the user signing in at the IdP, returning the code the
+// browser would bring back to the callback.
+func (idp *mockIdP) authorize(t *testing.T, authURL string, claims map[string]any) (string, url.Values) {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := "code-" + query.Get("state")
+	idp.codes[code] = mockGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	return code, query
+}
+
+func setupProvider(t *testing.T, providerCfg config.OIDCProviderConfig) *Provider {
+	t.Helper()
+
+	previous := config.GetServerConfig()
+	config.SetServerConfig(&config.ServerConfig{URL: "https://knot.example.com/", EncryptionKey: "0123456789abcdef0123456789abcdef"})
+	t.Cleanup(func() {
+		config.SetServerConfig(previous)
+		Init(nil)
+	})
+
+	Init([]config.OIDCProviderConfig{providerCfg})
+	provider := GetProvider(providerCfg.Id)
+	if provider == nil {
+		t.Fatal("provider not registered")
+	}
+	return provider
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := setupProvider(t, config.OIDCProviderConfig{Id: "corp", Issuer: idp.URL, ClientId: "knot", ClientSecret: "secret"})
+
+	loginState, err := NewLoginState("corp", "/spaces", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(t.Context(), provider.CallbackURL(), loginState.State, loginState.Nonce, loginState.Verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, query := idp.authorize(t, authURL, map[string]any{
+		"sub":                "user-1",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+		"groups":             []string{"developers", "admins"},
+	})
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Errorf("expected an S256 PKCE challenge, got %v", query)
+	}
+	if query.Get("redirect_uri") != "https://knot.example.com/auth/corp/callback" {
+		t.Errorf("unexpected redirect_uri %q", query.Get("redirect_uri"))
+	}
+	if query.Get("nonce") != loginState.Nonce || query.Get("state") != loginState.State {
+		t.Error("expected the login's state and nonce to be sent")
+	}
+
+	identity, err := provider.Exchange(t.Context(), provider.CallbackURL(), code, loginState.Verifier, loginState.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "user-1" || identity.Email != "alice@example.com" || !identity.EmailVerified || identity.Username != "alice" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+	if !slices.Equal(identity.Groups, []string{"developers", "admins"}) {
+		t.Errorf("unexpected groups %v", identity.Groups)
+	}
+
+	// A code is only redeemed with the verifier it was issued for
+	code, _ = idp.authorize(t, authURL, map[string]any{"sub": "user-1"})
+	if _, err := provider.Exchange(t.Context(), provider.CallbackURL(), code, "wrong-verifier-wrong-verifier-wrong-verifier", loginState.Nonce); err == nil {
+		t.Error("expected the exchange to fail with the wrong verifier")
+	}
+
+	// and the ID token must carry the login's nonce
+	code, _ = idp.authorize(t, authURL, map[string]any{"sub": "user-1"})
+	if _, err := provider.Exchange(t.Context(), provider.CallbackURL(), code, loginState.Verifier, "other-nonce"); !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("expected a nonce mismatch, got %v", err)
+	}
+}
+
+func TestLoginStateCookie(t *testing.T) {
+	setupProvider(t, config.OIDCProviderConfig{Id: "corp", Issuer: "https://idp.example.com", ClientId: "knot"})
+
+	loginState, err := NewLoginState("corp", "/spaces", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	if err := loginState.SetCookie(recorder); err != nil {
+		t.Fatal(err)
+	}
+	cookie := recorder.Result().Cookies()[0]
+
+	callback := func(value, providerId, state string) (*LoginState, error) {
+		r := httptest.NewRequest(http.MethodGet, "/auth/corp/callback", nil)
+		r.AddCookie(&http.Cookie{Name: cookie.Name, Value: value})
+		return ConsumeLoginState(httptest.NewRecorder(), r, providerId, state)
+	}
+
+	got, err := callback(cookie.Value, "corp", loginState.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Verifier != loginState.Verifier || got.LinkUserId != "user-1" || got.Redirect != "/spaces" {
+		t.Errorf("unexpected state %+v", got)
+	}
+
+	if _, err := callback(cookie.Value, "corp", "other-state"); err != ErrInvalidState {
+		t.Error("expected a mismatched state to be rejected")
+	}
+	if _, err := callback(cookie.Value, "other", loginState.State); err != ErrInvalidState {
+		t.Error("expected a different provider to be rejected")
+	}
+
+	forged := *loginState
+	forged.LinkUserId = "user-2"
+	payload, _ := json.Marshal(&forged)
+	_, signature, _ := strings.Cut(cookie.Value, ".")
+	if _, err := callback(base64.RawURLEncoding.EncodeToString(payload)+"."+signature, "corp", loginState.State); err != ErrInvalidState {
+		t.Error("expected a modified state to be rejected")
+	}
+}
+
+func TestAPILoginState(t *testing.T) {
+	setupProvider(t, config.OIDCProviderConfig{Id: "corp", Issuer: "https://idp.example.com", ClientId: "knot"})
+
+	loginState, err := NewAPILoginState("corp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	signed, err := loginState.SetAPICookie(recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := recorder.Result().Cookies()[0]
+	if cookie.Value != signed || cookie.Path != "/api/auth" {
+		t.Errorf("unexpected cookie %+v", cookie)
+	}
+
+	got, err := ConsumeAPILoginState(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/auth", nil), "corp", signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Nonce != loginState.Nonce {
+		t.Errorf("expected nonce %q, got %q", loginState.Nonce, got.Nonce)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/auth", nil)
+	r.AddCookie(cookie)
+	if _, err := ConsumeAPILoginState(httptest.NewRecorder(), r, "corp", ""); err != nil {
+		t.Errorf("expected the cookie to be used, got %v", err)
+	}
+
+	if _, err := ConsumeAPILoginState(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/auth", nil), "corp", ""); err != ErrInvalidState {
+		t.Error("expected a missing state to be rejected")
+	}
+	if _, err := ConsumeAPILoginState(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/auth", nil), "other", signed); err != ErrInvalidState {
+		t.Error("expected a different provider to be rejected")
+	}
+}
+
+func TestSyncAccess(t *testing.T) {
+	provider := &Provider{cfg: config.OIDCProviderConfig{
+		Id: "corp",
+		GroupMappings: []config.OIDCGroupMapping{
+			{Claim: "developers", Groups: []string{"Developers"}},
+			{Claim: "admins", Roles: []string{"Admin"}, Groups: []string{"g-ops"}},
+		},
+	}}
+	groups := []*model.Group{
+		{Id: "g-dev", Name: "Developers"},
+		{Id: "g-ops", Name: "Operations"},
+		{Id: "g-manual", Name: "Manual"},
+	}
+	roles := []*model.Role{
+		{Id: "r-admin", Name: "Admin"},
+		{Id: "r-user", Name: "User"},
+	}
+
+	user := &model.User{Groups: []string{"g-manual", "g-ops"}, Roles: []string{"r-user", "r-admin"}}
+	provider.syncAccess(user, []string{"developers"}, groups, roles)
+
+	if !slices.Equal(user.Groups, []string{"g-manual", "g-dev"}) {
+		t.Errorf("unexpected groups %v", user.Groups)
+	}
+	if !slices.Equal(user.Roles, []string{"r-user"}) {
+		t.Errorf("unexpected roles %v", user.Roles)
+	}
+
+	provider.syncAccess(user, []string{"admins"}, groups, roles)
+	if !slices.Equal(user.Groups, []string{"g-manual", "g-ops"}) {
+		t.Errorf("unexpected groups %v", user.Groups)
+	}
+	if !slices.Equal(user.Roles, []string{"r-user", "r-admin"}) {
+		t.Errorf("unexpected roles %v", user.Roles)
+	}
+}
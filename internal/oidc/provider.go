@@ -0,0 +1,252 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/paularlott/knot/internal/config"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const defaultGroupsClaim = "groups"
+
+var (
+	ErrUnknownProvider = errors.New("unknown OIDC provider")
+	ErrNonceMismatch   = errors.New("ID token nonce does not match the login")
+)
+
+// Identity is the user as described by the IdP's ID token and userinfo.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+	Groups        []string
+}
+
+// Provider is an OpenID Connect provider users can sign in with. Discovery is
+// done on first use so a provider that is down doesn't stop the server.
+type Provider struct {
+	cfg config.OIDCProviderConfig
+
+	mu       sync.Mutex
+	provider *gooidc.Provider
+	verifier *gooidc.IDTokenVerifier
+}
+
+var (
+	providersMutex sync.RWMutex
+	providers      []*Provider
+)
+
+// Init replaces the configured providers.
+func Init(cfgs []config.OIDCProviderConfig) {
+	list := make([]*Provider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		list = append(list, &Provider{cfg: cfg})
+	}
+
+	providersMutex.Lock()
+	providers = list
+	providersMutex.Unlock()
+}
+
+// GetProviders returns the configured providers in configuration order.
+func GetProviders() []*Provider {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	return slices.Clone(providers)
+}
+
+// GetProvider returns the provider with the given ID or nil.
+func GetProvider(id string) *Provider {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	for _, p := range providers {
+		if p.cfg.Id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (p *Provider) Id() string {
+	return p.cfg.Id
+}
+
+func (p *Provider) DisplayName() string {
+	if p.cfg.Name != "" {
+		return p.cfg.Name
+	}
+	return p.cfg.Id
+}
+
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+func (p *Provider) ClientId() string {
+	return p.cfg.ClientId
+}
+
+// CallbackURL is the redirect URI to register with the IdP for browser logins.
+func (p *Provider) CallbackURL() string {
+	return strings.TrimSuffix(config.GetServerConfig().URL, "/") + "/auth/" + p.cfg.Id + "/callback"
+}
+
+func (p *Provider) discover(ctx context.Context) (*gooidc.Provider, *gooidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := gooidc.NewProvider(ctx, p.cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("OIDC discovery for %s: %w", p.cfg.Id, err)
+		}
+		p.provider = provider
+		p.verifier = provider.Verifier(&gooidc.Config{ClientID: p.cfg.ClientId})
+	}
+
+	return p.provider, p.verifier, nil
+}
+
+func (p *Provider) oauth2Config(ctx context.Context, redirectURL string) (*oauth2.Config, *gooidc.Provider, *gooidc.IDTokenVerifier, error) {
+	provider, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	scopes := []string{gooidc.ScopeOpenID}
+	if len(p.cfg.Scopes) == 0 {
+		scopes = append(scopes, "profile", "email")
+	} else {
+		for _, scope := range p.cfg.Scopes {
+			if scope != gooidc.ScopeOpenID {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientId,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}, provider, verifier, nil
+}
+
+// AuthCodeURL returns the IdP URL to send the browser to, with the PKCE
+// challenge for verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, verifier string) (string, error) {
+	oauthConfig, _, _, err := p.oauth2Config(ctx, redirectURL)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), gooidc.Nonce(nonce)), nil
+}
+
+// Exchange redeems an authorization code and returns the verified identity,
+// the ID token must carry the nonce the login was started with.
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, verifier, nonce string) (*Identity, error) {
+	oauthConfig, provider, idVerifier, err := p.oauth2Config(ctx, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("ID token: %w", err)
+	}
+	if nonce == "" || idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	claims := map[string]any{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	// Fill in anything the ID token left out from the userinfo endpoint,
+	// never trusting it for a different subject
+	groupsClaim := p.groupsClaim()
+	if _, hasGroups := claims[groupsClaim]; !hasGroups || claims["email"] == nil {
+		if userInfo, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token)); err == nil && userInfo.Subject == idToken.Subject {
+			extra := map[string]any{}
+			if err := userInfo.Claims(&extra); err == nil {
+				for key, value := range extra {
+					if _, ok := claims[key]; !ok {
+						claims[key] = value
+					}
+				}
+			}
+		}
+	}
+
+	identity := &Identity{
+		Subject:       idToken.Subject,
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, "email_verified"),
+		Username:      claimString(claims, "preferred_username"),
+		Name:          claimString(claims, "name"),
+		Groups:        claimStrings(claims, groupsClaim),
+	}
+	return identity, nil
+}
+
+func (p *Provider) groupsClaim() string {
+	if p.cfg.GroupsClaim != "" {
+		return p.cfg.GroupsClaim
+	}
+	return defaultGroupsClaim
+}
+
+func claimString(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimBool accepts "true" as some IdPs send email_verified as a string.
+func claimBool(claims map[string]any, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// claimStrings accepts a list of strings or a single space or comma separated
+// string, as IdPs differ on how groups are sent.
+func claimStrings(claims map[string]any, name string) []string {
+	var values []string
+	switch value := claims[name].(type) {
+	case []any:
+		for _, v := range value {
+			if s, ok := v.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	case string:
+		values = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return values
+}
@@ -0,0 +1,206 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/util/crypt"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	stateCookieName = "__knot_oidc"
+	stateExpiry     = 10 * time.Minute
+)
+
+var ErrInvalidState = errors.New("invalid or expired OIDC login state")
+
+var (
+	fallbackKeyOnce sync.Once
+	fallbackKey     string
+)
+
+// LoginState is what the browser carries between starting a login and the
+// IdP's callback. It is kept in a signed cookie rather than in memory so the
+// callback can be handled by any server in the cluster.
+type LoginState struct {
+	Provider   string `json:"p"`
+	State      string `json:"s"`
+	Nonce      string `json:"n"`
+	Verifier   string `json:"v"`
+	Redirect   string `json:"r"`
+	LinkUserId string `json:"u,omitempty"` // set when a signed in user is linking the provider to their account
+	ExpiresAt  int64  `json:"e"`
+}
+
+// NewLoginState creates the state, nonce and PKCE verifier for a login.
+func NewLoginState(providerId, redirect, linkUserId string) (*LoginState, error) {
+	state, err := crypt.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypt.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginState{
+		Provider:   providerId,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   oauth2.GenerateVerifier(),
+		Redirect:   redirect,
+		LinkUserId: linkUserId,
+		ExpiresAt:  time.Now().Add(stateExpiry).Unix(),
+	}, nil
+}
+
+// NewAPILoginState creates the state and nonce for a login run by an API
+// client, the client keeps its own PKCE verifier.
+func NewAPILoginState(providerId string) (*LoginState, error) {
+	state, err := crypt.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypt.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginState{
+		Provider:  providerId,
+		State:     state,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(stateExpiry).Unix(),
+	}, nil
+}
+
+// SetCookie stores the state in the browser for the callback.
+func (s *LoginState) SetCookie(w http.ResponseWriter) error {
+	_, err := s.setCookie(w, "/auth/")
+	return err
+}
+
+// SetAPICookie stores the state for the API login and returns the signed
+// value, for API clients which don't keep cookies and send it in the request.
+func (s *LoginState) SetAPICookie(w http.ResponseWriter) (string, error) {
+	return s.setCookie(w, "/api/auth")
+}
+
+func (s *LoginState) setCookie(w http.ResponseWriter, path string) (string, error) {
+	cfg := config.GetServerConfig()
+
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	value := encoded + "." + sign(encoded)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    value,
+		Path:     path,
+		MaxAge:   int(stateExpiry.Seconds()),
+		HttpOnly: true,
+		Secure:   cfg.TLS.UseTLS,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return value, nil
+}
+
+// ConsumeLoginState reads and clears the state cookie, checking it belongs to
+// the provider and state the IdP returned.
+func ConsumeLoginState(w http.ResponseWriter, r *http.Request, providerId, state string) (*LoginState, error) {
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	clearCookie(w, "/auth/")
+
+	loginState, err := decodeLoginState(cookie.Value, providerId)
+	if err != nil {
+		return nil, err
+	}
+	if state == "" || !hmac.Equal([]byte(loginState.State), []byte(state)) {
+		return nil, ErrInvalidState
+	}
+
+	return loginState, nil
+}
+
+// ConsumeAPILoginState reads and clears the state of an API login, taking the
+// signed value from the request if given else from the cookie.
+func ConsumeAPILoginState(w http.ResponseWriter, r *http.Request, providerId, value string) (*LoginState, error) {
+	if value == "" {
+		cookie, err := r.Cookie(stateCookieName)
+		if err != nil {
+			return nil, ErrInvalidState
+		}
+		value = cookie.Value
+	}
+	clearCookie(w, "/api/auth")
+
+	return decodeLoginState(value, providerId)
+}
+
+func clearCookie(w http.ResponseWriter, path string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    "",
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   config.GetServerConfig().TLS.UseTLS,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// decodeLoginState checks the signature of a state and that it belongs to the
+// provider and hasn't expired.
+func decodeLoginState(value, providerId string) (*LoginState, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, ErrInvalidState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+
+	loginState := &LoginState{}
+	if err := json.Unmarshal(payload, loginState); err != nil {
+		return nil, ErrInvalidState
+	}
+	if loginState.Provider != providerId || loginState.Nonce == "" || time.Now().Unix() > loginState.ExpiresAt {
+		return nil, ErrInvalidState
+	}
+
+	return loginState, nil
+}
+
+// sign signs with the server's encryption key so any server in the cluster can
+// check the state. Without a key a random one is used, and logins must then
+// complete on the server they started on.
+func sign(value string) string {
+	key := config.GetServerConfig().EncryptionKey
+	if key == "" {
+		fallbackKeyOnce.Do(func() {
+			fallbackKey = crypt.CreateKey()
+		})
+		key = fallbackKey
+	}
+
+	mac := hmac.New(sha256.New, []byte("knot-oidc-state:"+key))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/extauth"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+var (
+	ErrNoAccount     = errors.New("no knot account is linked to this identity")
+	ErrAlreadyLinked = errors.New("this identity is linked to another user")
+	ErrUserInactive  = errors.New("user is not active")
+	ErrEmailInUse    = errors.New("a user with this email already exists")
+)
+
+// LoginUser finds the knot user for an identity, linking or creating it as
+// the provider allows, then brings the user's mapped groups and roles in line
+// with the IdP groups. When linkUserId is set the identity is linked to that
+// user instead of being looked up. The returned bool is true if the user was
+// created.
+func (p *Provider) LoginUser(identity *Identity, linkUserId string) (*model.User, bool, error) {
+	db := database.GetInstance()
+
+	user, err := db.GetUserByProviderUID(p.cfg.Id, identity.Subject)
+	if err != nil || user == nil || user.IsDeleted {
+		user = nil
+	}
+
+	created := false
+	switch {
+	case linkUserId != "":
+		if user != nil && user.Id != linkUserId {
+			return nil, false, ErrAlreadyLinked
+		}
+		user, err = db.GetUser(linkUserId)
+		if err != nil || user.IsDeleted {
+			return nil, false, ErrNoAccount
+		}
+
+	case user != nil:
+		// Already linked
+
+	case p.cfg.LinkByEmail && identity.EmailVerified && identity.Email != "":
+		user, err = db.GetUserByEmail(identity.Email)
+		if err == nil && user != nil && !user.IsDeleted {
+			break
+		}
+		user = nil
+		fallthrough
+
+	default:
+		if !p.cfg.AllowSignup {
+			return nil, false, ErrNoAccount
+		}
+		user, err = p.newUser(db, identity)
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+	}
+
+	if !user.Active {
+		return nil, false, ErrUserInactive
+	}
+
+	if user.ExternalAuthProviders == nil {
+		user.ExternalAuthProviders = make(map[string]model.ExternalProvider)
+	}
+	externalProvider := user.ExternalAuthProviders[p.cfg.Id]
+	externalProvider.ProviderUID = identity.Subject
+	externalProvider.Username = identity.displayUsername()
+	user.ExternalAuthProviders[p.cfg.Id] = externalProvider
+
+	groups, err := db.GetGroups()
+	if err != nil {
+		return nil, false, err
+	}
+	p.syncAccess(user, identity.Groups, groups, model.GetRolesFromCache())
+
+	now := time.Now().UTC()
+	user.LastLoginAt = &now
+	user.UpdatedAt = hlc.Now()
+
+	var saveFields []string
+	if !created {
+		saveFields = []string{"ExternalAuthProviders", "Groups", "Roles", "LastLoginAt", "UpdatedAt"}
+	}
+	if err := db.SaveUser(user, saveFields); err != nil {
+		return nil, false, err
+	}
+
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	return user, created, nil
+}
+
+// newUser creates the user for a first login, without a password so they can
+// only sign in through the provider until one is set.
+func (p *Provider) newUser(db database.DbDriver, identity *Identity) (*model.User, error) {
+	if !validate.Email(identity.Email) {
+		return nil, fmt.Errorf("%s did not supply a valid email address", p.DisplayName())
+	}
+	if existing, err := db.GetUserByEmail(identity.Email); err == nil && existing != nil && !existing.IsDeleted {
+		return nil, ErrEmailInUse
+	}
+
+	username, err := extauth.NewUsername(db, identity.Username, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := model.NewUser(username, identity.Email, "", []string{}, []string{}, "", "bash", "UTC", 0, "", 0, 0, 0)
+	user.Password = ""
+
+	log.Info("oidc: creating user", "provider", p.cfg.Id, "username", username, "email", identity.Email)
+	return user, nil
+}
+
+// syncAccess applies the provider's group mappings for the user's IdP groups.
+func (p *Provider) syncAccess(user *model.User, idpGroups []string, groups []*model.Group, roles []*model.Role) extauth.Changes {
+	mappings := make([]extauth.Mapping, 0, len(p.cfg.GroupMappings))
+	for _, mapping := range p.cfg.GroupMappings {
+		mappings = append(mappings, extauth.Mapping{Source: mapping.Claim, Groups: mapping.Groups, Roles: mapping.Roles})
+	}
+	return extauth.Apply(p.cfg.Id, user, idpGroups, mappings, groups, roles)
+}
+
+func (identity *Identity) displayUsername() string {
+	if identity.Username != "" {
+		return identity.Username
+	}
+	if identity.Email != "" {
+		return identity.Email
+	}
+	return identity.Subject
+}
@@ -3,9 +3,12 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/paularlott/knot/internal/chat"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/llmusage"
 	internalmcp "github.com/paularlott/knot/internal/mcp"
 	"github.com/paularlott/knot/internal/util/rest"
 	mcpopenai "github.com/paularlott/mcp/ai/openai"
@@ -57,9 +60,7 @@ func (s *Service) HandleChatCompletions(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Only set model if not provided by the caller
-	if req.Model == "" {
-		req.Model = s.model
-	}
+	req.Model = s.requestModel(req.Model)
 
 	// Inject system prompt only if no system message is present
 	user, _ := ctx.Value("user").(*model.User)
@@ -79,6 +80,22 @@ func (s *Service) handleNonStreamingChatCompletion(ctx context.Context, w http.R
 
 	response, err := s.client.ChatCompletion(ctx, req)
 	if err != nil {
+		var budgetErr *llmusage.BudgetExceededError
+		if errors.As(err, &budgetErr) {
+			rest.WriteResponse(http.StatusTooManyRequests, w, r, map[string]string{
+				"error": budgetErr.Error(),
+			})
+			return
+		}
+
+		var modelErr *chat.ModelNotAllowedError
+		if errors.As(err, &modelErr) {
+			rest.WriteResponse(http.StatusForbidden, w, r, map[string]string{
+				"error": modelErr.Error(),
+			})
+			return
+		}
+
 		log.WithError(err).Error("OpenAI: Chat completion failed")
 		rest.WriteResponse(http.StatusInternalServerError, w, r, map[string]string{
 			"error": "Chat completion failed",
@@ -92,6 +109,22 @@ func (s *Service) handleNonStreamingChatCompletion(ctx context.Context, w http.R
 // handleStreamingChatCompletion handles streaming chat completions
 func (s *Service) handleStreamingChatCompletion(ctx context.Context, w http.ResponseWriter, r *http.Request, req ChatCompletionRequest) {
 	// Context is already configured by MCPServerContext middleware with script tools provider
+
+	// Check the model and budget before the stream starts so the caller gets a status code
+	user, _ := ctx.Value("user").(*model.User)
+	if err := chat.CheckModelAllowed(user, s.requestModel(req.Model)); err != nil {
+		rest.WriteResponse(http.StatusForbidden, w, r, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := llmusage.CheckBudget(user); err != nil {
+		rest.WriteResponse(http.StatusTooManyRequests, w, r, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	streamWriter := rest.NewStreamWriter(w, r)
 	defer streamWriter.Close()
 
@@ -111,6 +144,15 @@ func (s *Service) handleStreamingChatCompletion(ctx context.Context, w http.Resp
 	streamWriter.WriteEnd()
 }
 
+// requestModel returns the model a request is sent to, the default model of
+// the router when the request doesn't name one.
+func (s *Service) requestModel(modelName string) string {
+	if modelName == "" {
+		return s.model
+	}
+	return modelName
+}
+
 // replaceSystemPrompt injects the system prompt if none is present and always
 // appends the skills prompt to the system message (whether injected or caller-supplied).
 func (s *Service) replaceSystemPrompt(messages []Message, skillsPrompt string) []Message {
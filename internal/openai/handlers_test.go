@@ -138,3 +138,14 @@ func TestHandlers_ChatCompletions_NonStreaming(t *testing.T) {
 		t.Errorf("Unexpected response content: %s", response.Choices[0].Message.GetContentAsString())
 	}
 }
+
+func TestService_requestModel(t *testing.T) {
+	service := NewService(nil, "", "fast")
+
+	if got := service.requestModel(""); got != "fast" {
+		t.Errorf("Expected the default model for an empty model, got %s", got)
+	}
+	if got := service.requestModel("smart"); got != "smart" {
+		t.Errorf("Expected the named model, got %s", got)
+	}
+}
@@ -139,6 +139,11 @@ func (p *ResponseWorkerPool) processResponse(response *model.Response) {
 		p.cancelMux.Unlock()
 	}()
 
+	// Run as the owner of the response so their usage is recorded
+	if user, err := db.GetUser(response.UserId); err == nil && user != nil {
+		reqCtx = context.WithValue(reqCtx, "user", user)
+	}
+
 	// Process the response
 	processor := &responseProcessor{client: p.client}
 	result, err := processor.Process(reqCtx, response)
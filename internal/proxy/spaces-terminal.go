@@ -34,6 +34,13 @@ func HandleSpacesTerminalProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A named session is shared by everyone attaching to it
+	session := r.URL.Query().Get("session")
+	if session != "" && !validate.Name(session) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	// Load the space
 	db := database.GetInstance()
 	space, err := db.GetSpace(spaceId)
@@ -88,9 +95,20 @@ func HandleSpacesTerminalProxy(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// The user is only remembered if the agent will send back a recording
+		var recordingId string
+		if agentSession.TerminalRecording {
+			recordingId = agentSession.AddTerminalUser(user.Username)
+		}
+
 		// Write the terminal request
 		err = msg.WriteMessage(stream, &msg.Terminal{
-			Shell: shell,
+			Shell:       shell,
+			Username:    user.Username,
+			UserId:      user.Id,
+			Session:     session,
+			ReadOnly:    session != "" && space.SharedTerminalReadOnly(user, r.URL.Query().Get("read_only") == "true"),
+			RecordingId: recordingId,
 		})
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,327 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var errInvalidFilter = errors.New("invalid filter")
+
+// filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2).
+type filter interface {
+	match(attributes) bool
+}
+
+// attributes holds a resource flattened to lower cased attribute paths, each
+// with all of its values so multi-valued attributes such as emails.value
+// match if any value does.
+type attributes map[string][]string
+
+type andFilter struct{ left, right filter }
+type orFilter struct{ left, right filter }
+type notFilter struct{ inner filter }
+
+type compareFilter struct {
+	path  string
+	op    string
+	value string
+}
+
+func (f *andFilter) match(attrs attributes) bool { return f.left.match(attrs) && f.right.match(attrs) }
+func (f *orFilter) match(attrs attributes) bool  { return f.left.match(attrs) || f.right.match(attrs) }
+func (f *notFilter) match(attrs attributes) bool { return !f.inner.match(attrs) }
+
+// match compares without regard to case as none of the attributes knot
+// serves are case exact.
+func (f *compareFilter) match(attrs attributes) bool {
+	values := attrs[f.path]
+	if f.op == "pr" {
+		return len(values) > 0
+	}
+
+	expected := strings.ToLower(f.value)
+	for _, value := range values {
+		value = strings.ToLower(value)
+		var ok bool
+		switch f.op {
+		case "eq":
+			ok = value == expected
+		case "ne":
+			ok = value != expected
+		case "co":
+			ok = strings.Contains(value, expected)
+		case "sw":
+			ok = strings.HasPrefix(value, expected)
+		case "ew":
+			ok = strings.HasSuffix(value, expected)
+		case "gt":
+			ok = value > expected
+		case "ge":
+			ok = value >= expected
+		case "lt":
+			ok = value < expected
+		case "le":
+			ok = value <= expected
+		}
+		if ok {
+			return true
+		}
+	}
+
+	// ne also matches a resource without the attribute
+	return f.op == "ne" && len(values) == 0
+}
+
+// parseFilter parses a filter, an attribute path in a value filter such as
+// members[value eq "id"] is relative to the enclosing attribute.
+func parseFilter(input string) (filter, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errInvalidFilter
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", errInvalidFilter, p.tokens[p.pos].text)
+	}
+	return f, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		case c == '"':
+			// Find the closing quote, skipping escaped characters
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, fmt.Errorf("%w: unterminated string", errInvalidFilter)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(input[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidFilter, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t()\"", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peekWord(word string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord && strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseFactor() (filter, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected end", errInvalidFilter)
+	}
+
+	negate := false
+	if p.peekWord("not") {
+		negate = true
+		p.pos++
+	}
+
+	var f filter
+	var err error
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenOpen {
+		p.pos++
+		if f, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenClose {
+			return nil, fmt.Errorf("%w: missing )", errInvalidFilter)
+		}
+		p.pos++
+	} else if negate {
+		return nil, fmt.Errorf("%w: not must be followed by (", errInvalidFilter)
+	} else if f, err = p.parseComparison(); err != nil {
+		return nil, err
+	}
+
+	if negate {
+		return &notFilter{f}, nil
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseComparison() (filter, error) {
+	if p.pos+1 >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord || p.tokens[p.pos+1].kind != tokenWord {
+		return nil, fmt.Errorf("%w: expected attribute and operator", errInvalidFilter)
+	}
+
+	path := normalizePath(p.tokens[p.pos].text)
+	if strings.ContainsAny(path, "[]") {
+		return nil, fmt.Errorf("%w: value filters are not supported in searches", errInvalidFilter)
+	}
+	op := strings.ToLower(p.tokens[p.pos+1].text)
+	p.pos += 2
+
+	switch op {
+	case "pr":
+		return &compareFilter{path: path, op: op}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("%w: unknown operator %q", errInvalidFilter, op)
+	}
+
+	if p.pos >= len(p.tokens) || (p.tokens[p.pos].kind != tokenString && p.tokens[p.pos].kind != tokenWord) {
+		return nil, fmt.Errorf("%w: expected a value", errInvalidFilter)
+	}
+	value := p.tokens[p.pos]
+	p.pos++
+
+	// Unquoted values are true, false, null or numbers
+	if value.kind == tokenWord {
+		if strings.EqualFold(value.text, "null") {
+			if op == "eq" {
+				return &notFilter{&compareFilter{path: path, op: "pr"}}, nil
+			}
+			if op == "ne" {
+				return &compareFilter{path: path, op: "pr"}, nil
+			}
+			return nil, fmt.Errorf("%w: null only compares with eq or ne", errInvalidFilter)
+		}
+		if !strings.EqualFold(value.text, "true") && !strings.EqualFold(value.text, "false") && strings.IndexFunc(value.text, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' && r != '-' }) >= 0 {
+			return nil, fmt.Errorf("%w: unexpected value %q", errInvalidFilter, value.text)
+		}
+	}
+
+	return &compareFilter{path: path, op: op, value: value.text}, nil
+}
+
+// normalizePath lower cases an attribute path and drops the core schema URN
+// clients may prefix it with.
+func normalizePath(path string) string {
+	path = strings.ToLower(path)
+	for _, schema := range []string{schemaUser, schemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(path, prefix) {
+			return path[len(prefix):]
+		}
+	}
+	return path
+}
+
+// flatten turns a resource decoded from JSON into the attributes filters are
+// matched against. A multi-valued complex attribute is also reachable by its
+// name alone through the values of its value sub-attribute, so members eq
+// "id" behaves as members.value eq "id".
+func flatten(resource map[string]any) attributes {
+	attrs := attributes{}
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, sub := range v {
+				walk(joinPath(prefix, key), sub)
+			}
+			if inner, ok := v["value"]; ok && prefix != "" {
+				walk(prefix, inner)
+			}
+		case []any:
+			for _, item := range v {
+				walk(prefix, item)
+			}
+		case string:
+			attrs[prefix] = append(attrs[prefix], v)
+		case bool:
+			attrs[prefix] = append(attrs[prefix], fmt.Sprint(v))
+		case float64:
+			attrs[prefix] = append(attrs[prefix], fmt.Sprint(v))
+		}
+	}
+	walk("", resource)
+	return attrs
+}
+
+func joinPath(prefix, key string) string {
+	key = strings.ToLower(key)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
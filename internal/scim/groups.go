@@ -0,0 +1,374 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/audit"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+// groupResource is a knot group as a SCIM Group, membership is held on the
+// members' model.User.Groups.
+type groupResource struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []multiValue `json:"members"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+func newGroupResource(group *model.Group, users []*model.User, withMembers bool) *groupResource {
+	resource := &groupResource{
+		Schemas:     []string{schemaGroup},
+		Id:          group.Id,
+		DisplayName: group.Name,
+		Meta: &meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: group.UpdatedAt.Time().UTC().Format(time.RFC3339),
+			Location:     location("Groups", group.Id),
+		},
+	}
+	if withMembers {
+		resource.Members = []multiValue{}
+		for _, user := range users {
+			if slices.Contains(user.Groups, group.Id) {
+				resource.Members = append(resource.Members, multiValue{Value: user.Id, Display: user.Username, Ref: location("Users", user.Id)})
+			}
+		}
+	}
+	return resource
+}
+
+func (resource *groupResource) memberIds() []string {
+	ids := make([]string, 0, len(resource.Members))
+	for _, member := range resource.Members {
+		if !slices.Contains(ids, member.Value) {
+			ids = append(ids, member.Value)
+		}
+	}
+	return ids
+}
+
+func getGroup(db database.DbDriver, id string) (*model.Group, error) {
+	if !validate.UUID(id) {
+		return nil, errNotFound
+	}
+	group, err := db.GetGroup(id)
+	if err != nil || group == nil || group.IsDeleted {
+		return nil, errNotFound
+	}
+	return group, nil
+}
+
+// membersExcluded returns true if the client asked for groups without their
+// members, which providers do to keep listings of large groups small.
+func membersExcluded(r *http.Request) bool {
+	for _, attr := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+func handleListGroups(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	f, err := listFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	groups, err := db.GetGroups()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	groups = slices.DeleteFunc(groups, func(group *model.Group) bool { return group.IsDeleted })
+	slices.SortFunc(groups, func(a, b *model.Group) int { return strings.Compare(a.Id, b.Id) })
+
+	// Filter with members so members eq "id" works even if they're excluded
+	resources := []any{}
+	withMembers := !membersExcluded(r)
+	for _, group := range groups {
+		resource := newGroupResource(group, users, true)
+		if f != nil && !f.match(flatten(toMap(resource))) {
+			continue
+		}
+		if !withMembers {
+			resource.Members = nil
+		}
+		resources = append(resources, resource)
+	}
+
+	writeList(w, r, resources)
+}
+
+func handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	group, err := getGroup(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newGroupResource(group, users, !membersExcluded(r)))
+}
+
+func handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+	actor := r.Context().Value("user").(*model.User)
+
+	resource := &groupResource{}
+	if err := decodeBody(w, r, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := validateGroup(db, "", resource.DisplayName); err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkMembers(users, resource.memberIds()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	group := model.NewGroup(resource.DisplayName, actor.Id, 0, 0, 0, 0)
+	if err := db.SaveGroup(group); err != nil {
+		writeError(w, err)
+		return
+	}
+	service.GetTransport().GossipGroup(group)
+	sse.PublishGroupsChanged(group.Id)
+
+	auditGroup(r, actor, model.AuditEventGroupCreate, fmt.Sprintf("Provisioned group %s", group.Name), group, nil, nil)
+
+	added, removed := setMembers(db, group, users, resource.memberIds())
+	if len(added) > 0 {
+		auditGroup(r, actor, model.AuditEventGroupUpdate, fmt.Sprintf("Updated members of group %s", group.Name), group, added, removed)
+	}
+
+	w.Header().Set("Location", location("Groups", group.Id))
+	writeJSON(w, http.StatusCreated, newGroupResource(group, users, true))
+}
+
+func handleReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	group, err := getGroup(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resource := &groupResource{}
+	if err := decodeBody(w, r, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updateGroup(w, r, db, group, resource)
+}
+
+func handlePatchGroup(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	group, err := getGroup(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	request := &patchRequest{}
+	if err := decodeBody(w, r, request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	patched := toMap(newGroupResource(group, users, true))
+	if err := applyPatch(patched, request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resource := &groupResource{}
+	if err := fromMap(patched, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updateGroup(w, r, db, group, resource)
+}
+
+// updateGroup applies a replaced or patched resource to the group, adding and
+// removing members to match.
+func updateGroup(w http.ResponseWriter, r *http.Request, db database.DbDriver, group *model.Group, resource *groupResource) {
+	actor := r.Context().Value("user").(*model.User)
+
+	if err := validateGroup(db, group.Id, resource.DisplayName); err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkMembers(users, resource.memberIds()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if group.Name != resource.DisplayName {
+		group.Name = resource.DisplayName
+		group.UpdatedUserId = actor.Id
+		group.UpdatedAt = hlc.Now()
+		if err := db.SaveGroup(group); err != nil {
+			writeError(w, err)
+			return
+		}
+		service.GetTransport().GossipGroup(group)
+		sse.PublishGroupsChanged(group.Id)
+		auditGroup(r, actor, model.AuditEventGroupUpdate, fmt.Sprintf("Updated group %s", group.Name), group, nil, nil)
+	}
+
+	added, removed := setMembers(db, group, users, resource.memberIds())
+	if len(added) > 0 || len(removed) > 0 {
+		auditGroup(r, actor, model.AuditEventGroupUpdate, fmt.Sprintf("Updated members of group %s", group.Name), group, added, removed)
+	}
+
+	writeJSON(w, http.StatusOK, newGroupResource(group, users, true))
+}
+
+func handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+	actor := r.Context().Value("user").(*model.User)
+
+	group, err := getGroup(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	group.IsDeleted = true
+	group.UpdatedAt = hlc.Now()
+	group.UpdatedUserId = actor.Id
+	if err := db.SaveGroup(group); err != nil {
+		writeError(w, err)
+		return
+	}
+	service.GetTransport().GossipGroup(group)
+	sse.PublishGroupsDeleted(group.Id)
+
+	auditGroup(r, actor, model.AuditEventGroupDelete, fmt.Sprintf("Deleted group %s", group.Name), group, nil, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateGroup checks the name is valid and not used by another group.
+func validateGroup(db database.DbDriver, groupId, name string) error {
+	if !validate.Required(name) || !validate.MaxLength(name, 64) {
+		return newError(http.StatusBadRequest, "invalidValue", "displayName is required and must be at most 64 characters")
+	}
+
+	groups, err := db.GetGroups()
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if !group.IsDeleted && group.Id != groupId && strings.EqualFold(group.Name, name) {
+			return newError(http.StatusConflict, "uniqueness", "A group with this displayName already exists")
+		}
+	}
+	return nil
+}
+
+func checkMembers(users []*model.User, memberIds []string) error {
+	for _, id := range memberIds {
+		if !slices.ContainsFunc(users, func(user *model.User) bool { return user.Id == id }) {
+			return newError(http.StatusBadRequest, "invalidValue", fmt.Sprintf("Member %s is not a user", id))
+		}
+	}
+	return nil
+}
+
+// setMembers updates the users so exactly memberIds are in the group,
+// returning the usernames added and removed.
+func setMembers(db database.DbDriver, group *model.Group, users []*model.User, memberIds []string) (added, removed []string) {
+	for _, user := range users {
+		member := slices.Contains(memberIds, user.Id)
+		if member == slices.Contains(user.Groups, group.Id) {
+			continue
+		}
+
+		if member {
+			user.Groups = append(user.Groups, group.Id)
+		} else {
+			user.Groups = slices.DeleteFunc(user.Groups, func(id string) bool { return id == group.Id })
+		}
+		user.UpdatedAt = hlc.Now()
+		if err := db.SaveUser(user, []string{"Groups", "UpdatedAt"}); err != nil {
+			log.Error("scim: unable to update group membership", "user", user.Username, "group", group.Name, "error", err)
+			continue
+		}
+		service.GetTransport().GossipUser(user)
+		sse.PublishUsersChanged(user.Id)
+		go service.GetUserService().UpdateUserSpaces(user)
+
+		if member {
+			added = append(added, user.Username)
+		} else {
+			removed = append(removed, user.Username)
+		}
+	}
+	return added, removed
+}
+
+func auditGroup(r *http.Request, actor *model.User, event, details string, group *model.Group, added, removed []string) {
+	properties := map[string]interface{}{
+		"agent":           r.UserAgent(),
+		"IP":              r.RemoteAddr,
+		"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+		"group_id":        group.Id,
+		"group_name":      group.Name,
+		"provisioning":    ProviderId,
+	}
+	if added != nil || removed != nil {
+		properties["members_added"] = added
+		properties["members_removed"] = removed
+	}
+	audit.LogWithRequest(r, actor.Username, model.AuditActorTypeUser, event, details, &properties)
+}
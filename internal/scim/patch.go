@@ -0,0 +1,243 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// patchPath is a parsed PATCH path, attr[valueFilter].subAttr.
+type patchPath struct {
+	attr        string
+	valueFilter filter
+	filterText  string
+	subAttr     string
+}
+
+// applyPatch applies the operations of a PATCH request to a resource in its
+// generic form. Attributes outside the core schema are ignored as knot has
+// nowhere to keep them.
+func applyPatch(resource map[string]any, request *patchRequest) error {
+	if !slices.Contains(request.Schemas, schemaPatchOp) {
+		return newError(http.StatusBadRequest, "invalidSyntax", "Request is not a PatchOp")
+	}
+
+	for _, operation := range request.Operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return newError(http.StatusBadRequest, "invalidSyntax", fmt.Sprintf("Unknown operation %q", operation.Op))
+		}
+
+		if operation.Path == "" {
+			if op == "remove" {
+				return newError(http.StatusBadRequest, "noTarget", "Remove requires a path")
+			}
+			values, ok := operation.Value.(map[string]any)
+			if !ok {
+				return newError(http.StatusBadRequest, "invalidValue", "Operation without a path requires an object value")
+			}
+			for key, value := range values {
+				if path, ok := parsePatchPath(key); ok {
+					if err := applyOperation(resource, op, path, value); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+
+		path, ok := parsePatchPath(operation.Path)
+		if !ok {
+			continue
+		}
+		if path.filterText != "" && path.valueFilter == nil {
+			return newError(http.StatusBadRequest, "invalidPath", fmt.Sprintf("Invalid path %q", operation.Path))
+		}
+		if err := applyOperation(resource, op, path, operation.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parsePatchPath splits a path, returning false for extension schema
+// attributes which are ignored.
+func parsePatchPath(raw string) (*patchPath, bool) {
+	raw = strings.TrimSpace(raw)
+	path := normalizePath(raw)
+	if strings.Contains(path, ":") {
+		return nil, false
+	}
+
+	result := &patchPath{}
+	if open := strings.Index(path, "["); open >= 0 {
+		close := strings.LastIndex(path, "]")
+		if close < open {
+			result.attr, result.filterText = path[:open], path[open:]
+			return result, true
+		}
+		result.attr = path[:open]
+		// Keep the value's case from the original path
+		result.filterText = raw[strings.Index(raw, "[")+1 : strings.LastIndex(raw, "]")]
+		result.valueFilter, _ = parseFilter(result.filterText)
+		result.subAttr = strings.TrimPrefix(path[close+1:], ".")
+		return result, true
+	}
+
+	result.attr, result.subAttr, _ = strings.Cut(path, ".")
+	return result, true
+}
+
+func applyOperation(resource map[string]any, op string, path *patchPath, value any) error {
+	key := findKey(resource, path.attr)
+
+	// Operations on the entries of a multi-valued attribute
+	if path.valueFilter != nil {
+		items, _ := resource[key].([]any)
+		matched := false
+		var kept []any
+		for _, item := range items {
+			entry, ok := item.(map[string]any)
+			if !ok || !path.valueFilter.match(flatten(entry)) {
+				kept = append(kept, item)
+				continue
+			}
+			matched = true
+
+			switch {
+			case op == "remove" && path.subAttr == "":
+				continue
+			case op == "remove":
+				delete(entry, findKey(entry, path.subAttr))
+			case path.subAttr != "":
+				entry[findKey(entry, path.subAttr)] = value
+			default:
+				if replacement, ok := value.(map[string]any); ok {
+					for k, v := range replacement {
+						entry[findKey(entry, k)] = v
+					}
+				}
+			}
+			kept = append(kept, entry)
+		}
+
+		// Adding to an entry that doesn't exist yet creates it from an
+		// equality filter, e.g. emails[type eq "work"].value
+		if !matched && op != "remove" {
+			compare, ok := path.valueFilter.(*compareFilter)
+			if !ok || compare.op != "eq" {
+				return newError(http.StatusBadRequest, "noTarget", "No values match the path filter")
+			}
+			entry := map[string]any{compare.path: compare.value}
+			if path.subAttr != "" {
+				entry[path.subAttr] = value
+			} else if replacement, ok := value.(map[string]any); ok {
+				for k, v := range replacement {
+					entry[k] = v
+				}
+			}
+			kept = append(kept, entry)
+		}
+
+		resource[key] = kept
+		return nil
+	}
+
+	// Operations on a sub-attribute of a complex attribute
+	if path.subAttr != "" {
+		parent, ok := resource[key].(map[string]any)
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			parent = map[string]any{}
+			resource[key] = parent
+		}
+		if op == "remove" {
+			delete(parent, findKey(parent, path.subAttr))
+		} else {
+			parent[findKey(parent, path.subAttr)] = value
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		// Some providers name the entries to remove in the value rather
+		// than with a filter
+		existing, isList := resource[key].([]any)
+		removals, hasRemovals := value.([]any)
+		if !isList || !hasRemovals {
+			delete(resource, key)
+			break
+		}
+		var kept []any
+		for _, item := range existing {
+			if !containsValue(removals, item) {
+				kept = append(kept, item)
+			}
+		}
+		resource[key] = kept
+	case "add":
+		// Adding to a multi-valued attribute appends, skipping duplicates
+		if existing, ok := resource[key].([]any); ok {
+			additions, ok := value.([]any)
+			if !ok {
+				additions = []any{value}
+			}
+			for _, addition := range additions {
+				if !containsValue(existing, addition) {
+					existing = append(existing, addition)
+				}
+			}
+			resource[key] = existing
+		} else {
+			resource[key] = value
+		}
+	default:
+		resource[key] = value
+	}
+	return nil
+}
+
+// findKey returns the key in m matching name without regard to case, or name
+// if there is none.
+func findKey(m map[string]any, name string) string {
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+// containsValue compares entries of a multi-valued attribute by their value
+// sub-attribute.
+func containsValue(items []any, item any) bool {
+	value := func(v any) any {
+		if m, ok := v.(map[string]any); ok {
+			return m["value"]
+		}
+		return v
+	}
+	for _, existing := range items {
+		if reflect.DeepEqual(value(existing), value(item)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+// Package scim serves the SCIM 2.0 (RFC 7643, RFC 7644) Users and Groups
+// endpoints identity providers use to provision knot users and groups.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/middleware"
+)
+
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+
+	contentType = "application/scim+json"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	maxBodySize     = 1 << 20
+)
+
+// ProviderId keys the identity provider's view of a user in
+// model.User.ExternalAuthProviders, holding its externalId and userName.
+const ProviderId = "scim"
+
+// Routes registers the SCIM endpoints, authenticated with an API token for a
+// user who can manage users, and groups for the group endpoints.
+func Routes(router *http.ServeMux) {
+	users := func(next http.HandlerFunc) http.HandlerFunc {
+		return middleware.ApiAuth(middleware.ApiPermissionManageUsers(next))
+	}
+	groups := func(next http.HandlerFunc) http.HandlerFunc {
+		return middleware.ApiAuth(middleware.ApiPermissionManageUsers(middleware.ApiPermissionManageGroups(next)))
+	}
+
+	router.HandleFunc("GET /scim/v2/ServiceProviderConfig", users(handleServiceProviderConfig))
+	router.HandleFunc("GET /scim/v2/ResourceTypes", users(handleResourceTypes))
+
+	router.HandleFunc("GET /scim/v2/Users", users(handleListUsers))
+	router.HandleFunc("POST /scim/v2/Users", users(handleCreateUser))
+	router.HandleFunc("GET /scim/v2/Users/{id}", users(handleGetUser))
+	router.HandleFunc("PUT /scim/v2/Users/{id}", users(handleReplaceUser))
+	router.HandleFunc("PATCH /scim/v2/Users/{id}", users(handlePatchUser))
+	router.HandleFunc("DELETE /scim/v2/Users/{id}", users(handleDeleteUser))
+
+	router.HandleFunc("GET /scim/v2/Groups", groups(handleListGroups))
+	router.HandleFunc("POST /scim/v2/Groups", groups(handleCreateGroup))
+	router.HandleFunc("GET /scim/v2/Groups/{id}", groups(handleGetGroup))
+	router.HandleFunc("PUT /scim/v2/Groups/{id}", groups(handleReplaceGroup))
+	router.HandleFunc("PATCH /scim/v2/Groups/{id}", groups(handlePatchGroup))
+	router.HandleFunc("DELETE /scim/v2/Groups/{id}", groups(handleDeleteGroup))
+}
+
+// scimError is returned to the client as a SCIM error response.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+func newError(status int, scimType, detail string) *scimError {
+	return &scimError{status: status, scimType: scimType, detail: detail}
+}
+
+var (
+	errNotFound        = newError(http.StatusNotFound, "", "Resource not found")
+	errDeprovisionSelf = newError(http.StatusBadRequest, "mutability", "Cannot deprovision self")
+)
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location"`
+}
+
+// multiValue is an entry of a multi-valued attribute such as emails or members.
+type multiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug("scim: unable to write response", "error", err)
+	}
+}
+
+// writeError writes err as a SCIM error, anything other than a *scimError is
+// an internal error.
+func writeError(w http.ResponseWriter, err error) {
+	var e *scimError
+	if !errors.As(err, &e) {
+		log.Error("scim: request failed", "error", err)
+		e = newError(http.StatusInternalServerError, "", "Internal server error")
+	}
+	writeJSON(w, e.status, errorResponse{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(e.status),
+		ScimType: e.scimType,
+		Detail:   e.detail,
+	})
+}
+
+// decodeBody reads a JSON request body, accepting application/scim+json as
+// well as application/json.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax", "Invalid JSON: "+err.Error())
+	}
+	return nil
+}
+
+// toMap converts a resource to the generic form filters and PATCH work on.
+func toMap(resource any) map[string]any {
+	data, _ := json.Marshal(resource)
+	m := map[string]any{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// fromMap converts a patched resource back to its struct.
+func fromMap(m map[string]any, resource any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, resource); err != nil {
+		return newError(http.StatusBadRequest, "invalidValue", err.Error())
+	}
+	return nil
+}
+
+// listFilter returns the request's filter, or nil for none.
+func listFilter(r *http.Request) (filter, error) {
+	query := strings.TrimSpace(r.URL.Query().Get("filter"))
+	if query == "" {
+		return nil, nil
+	}
+	f, err := parseFilter(query)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+	return f, nil
+}
+
+// writeList writes the page of resources selected by startIndex and count.
+func writeList(w http.ResponseWriter, r *http.Request, resources []any) {
+	query := r.URL.Query()
+
+	startIndex, err := strconv.Atoi(query.Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil {
+		count = defaultPageSize
+	}
+	count = max(0, min(count, maxPageSize))
+
+	page := []any{}
+	if startIndex <= len(resources) {
+		page = resources[startIndex-1 : min(len(resources), startIndex-1+count)]
+	}
+
+	writeJSON(w, http.StatusOK, listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	})
+}
+
+func location(resourceType, id string) string {
+	return strings.TrimSuffix(config.GetServerConfig().URL, "/") + "/scim/v2/" + resourceType + "/" + id
+}
+
+func handleServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxPageSize},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "API token",
+			"description": "A knot API token for a user with permission to manage users and groups",
+			"primary":     true,
+		}},
+		"meta": map[string]any{
+			"resourceType": "ServiceProviderConfig",
+			"location":     strings.TrimSuffix(config.GetServerConfig().URL, "/") + "/scim/v2/ServiceProviderConfig",
+		},
+	})
+}
+
+func handleResourceTypes(w http.ResponseWriter, r *http.Request) {
+	resourceTypes := []any{
+		map[string]any{
+			"schemas":  []string{schemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   schemaUser,
+			"meta":     meta{ResourceType: "ResourceType", Location: location("ResourceTypes", "User")},
+		},
+		map[string]any{
+			"schemas":  []string{schemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   schemaGroup,
+			"meta":     meta{ResourceType: "ResourceType", Location: location("ResourceTypes", "Group")},
+		},
+	}
+	writeList(w, r, resourceTypes)
+}
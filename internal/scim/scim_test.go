@@ -0,0 +1,223 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func userMap(t *testing.T) map[string]any {
+	t.Helper()
+
+	m := map[string]any{}
+	err := json.Unmarshal([]byte(`{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"id": "u-1",
+		"externalId": "00u1",
+		"userName": "Alice@Example.com",
+		"emails": [{"value": "alice@example.com", "type": "work", "primary": true}],
+		"active": true,
+		"groups": [{"value": "g-1", "display": "Developers"}]
+	}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFilter(t *testing.T) {
+	attrs := flatten(userMap(t))
+
+	tests := map[string]bool{
+		`userName eq "alice@example.com"`:                               true,
+		`USERNAME Eq "ALICE@EXAMPLE.COM"`:                               true,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "a"`:    true,
+		`userName eq "bob"`:                                             false,
+		`emails.value co "example"`:                                     true,
+		`emails eq "alice@example.com"`:                                 true,
+		`groups.display eq "Developers" and active eq true`:             true,
+		`active eq false or externalId eq "00u1"`:                       true,
+		`not (externalId pr)`:                                           false,
+		`displayName pr`:                                                false,
+		`displayName eq null`:                                           true,
+		`(userName ew ".com" and active eq true) and not (id eq "u-2")`: true,
+	}
+	for input, expected := range tests {
+		f, err := parseFilter(input)
+		if err != nil {
+			t.Errorf("parseFilter(%q): %v", input, err)
+			continue
+		}
+		if got := f.match(attrs); got != expected {
+			t.Errorf("%s: got %v, expected %v", input, got, expected)
+		}
+	}
+
+	for _, input := range []string{``, `userName`, `userName eq`, `userName xx "a"`, `(userName eq "a"`, `userName eq "a`, `not userName pr`, `emails[type eq "work"]`} {
+		if _, err := parseFilter(input); err == nil {
+			t.Errorf("parseFilter(%q): expected an error", input)
+		}
+	}
+}
+
+func patch(t *testing.T, resource map[string]any, body string) error {
+	t.Helper()
+
+	request := &patchRequest{}
+	if err := json.Unmarshal([]byte(body), request); err != nil {
+		t.Fatal(err)
+	}
+	return applyPatch(resource, request)
+}
+
+func TestPatchUser(t *testing.T) {
+	resource := userMap(t)
+
+	// Entra ID style, capitalised ops, value filters and string booleans
+	err := patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "alice@corp.example.com"},
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "Add", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "Eng"}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler converts string booleans before decoding
+	if resource["active"] != "False" {
+		t.Errorf("unexpected active %v", resource["active"])
+	}
+	resource["active"] = false
+
+	resourceOut := &userResource{}
+	if err := fromMap(resource, resourceOut); err != nil {
+		t.Fatal(err)
+	}
+	if resourceOut.primaryEmail() != "alice@corp.example.com" || len(resourceOut.Emails) != 1 {
+		t.Errorf("unexpected emails %+v", resourceOut.Emails)
+	}
+
+	// Okta style, a replace without a path
+	err = patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "value": {"userName": "alice", "active": true}}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fromMap(resource, resourceOut); err != nil {
+		t.Fatal(err)
+	}
+	if resourceOut.UserName != "alice" || resourceOut.Active == nil || !*resourceOut.Active {
+		t.Errorf("unexpected user %+v", resourceOut)
+	}
+
+	// An email type that doesn't exist yet is added
+	err = patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "add", "path": "emails[type eq \"home\"].value", "value": "alice@home.example.com"}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fromMap(resource, resourceOut); err != nil {
+		t.Fatal(err)
+	}
+	if len(resourceOut.Emails) != 2 || resourceOut.Emails[1].Type != "home" || resourceOut.primaryEmail() != "alice@corp.example.com" {
+		t.Errorf("unexpected emails %+v", resourceOut.Emails)
+	}
+
+	if err := patch(t, resource, `{"schemas": [], "Operations": []}`); err == nil {
+		t.Error("expected a request without the PatchOp schema to be rejected")
+	}
+	if err := patch(t, resource, `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "move", "path": "active"}]}`); err == nil {
+		t.Error("expected an unknown op to be rejected")
+	}
+}
+
+func TestPatchGroupMembers(t *testing.T) {
+	resource := map[string]any{}
+	json.Unmarshal([]byte(`{"displayName": "Developers", "members": [{"value": "u-1"}, {"value": "u-2"}]}`), &resource)
+
+	members := func() []string {
+		group := &groupResource{}
+		if err := fromMap(resource, group); err != nil {
+			t.Fatal(err)
+		}
+		return group.memberIds()
+	}
+
+	err := patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "u-3"}, {"value": "u-1"}]},
+			{"op": "remove", "path": "members[value eq \"u-2\"]"}
+		]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := members(); len(got) != 2 || got[0] != "u-1" || got[1] != "u-3" {
+		t.Errorf("unexpected members %v", got)
+	}
+
+	// Removal by value, as sent by some providers
+	err = patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "remove", "path": "members", "value": [{"value": "u-1"}]}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := members(); len(got) != 1 || got[0] != "u-3" {
+		t.Errorf("unexpected members %v", got)
+	}
+
+	err = patch(t, resource, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "remove", "path": "members"}, {"op": "replace", "path": "displayName", "value": "Platform"}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := members(); len(got) != 0 || resource["displayName"] != "Platform" {
+		t.Errorf("unexpected group %v", resource)
+	}
+}
+
+func TestWriteList(t *testing.T) {
+	resources := []any{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		query    string
+		expected []any
+		start    int
+	}{
+		{"", resources, 1},
+		{"?startIndex=2&count=2", []any{"b", "c"}, 2},
+		{"?startIndex=5&count=10", []any{"e"}, 5},
+		{"?startIndex=9", []any{}, 9},
+		{"?startIndex=0&count=0", []any{}, 1},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		writeList(recorder, httptest.NewRequest("GET", "/scim/v2/Users"+test.query, nil), resources)
+
+		response := listResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.TotalResults != 5 || response.StartIndex != test.start || response.ItemsPerPage != len(test.expected) {
+			t.Errorf("%s: unexpected response %+v", test.query, response)
+		}
+		for i := range test.expected {
+			if response.Resources[i] != test.expected[i] {
+				t.Errorf("%s: unexpected resources %v", test.query, response.Resources)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/extauth"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/audit"
+	"github.com/paularlott/knot/internal/util/validate"
+)
+
+// userResource is a knot user as a SCIM User. The identity provider's
+// userName is kept as given, as it is often an email address which isn't a
+// valid knot username, and the knot username is derived from it.
+type userResource struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id,omitempty"`
+	ExternalId  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []multiValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Groups      []multiValue `json:"groups,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+func newUserResource(user *model.User, groupNames map[string]string) *userResource {
+	active := user.Active
+	resource := &userResource{
+		Schemas:     []string{schemaUser},
+		Id:          user.Id,
+		UserName:    user.Username,
+		DisplayName: user.Username,
+		Emails:      []multiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Groups:      []multiValue{},
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: user.UpdatedAt.Time().UTC().Format(time.RFC3339),
+			Location:     location("Users", user.Id),
+		},
+	}
+	if provider, ok := user.ExternalAuthProviders[ProviderId]; ok {
+		resource.ExternalId = provider.ProviderUID
+		if provider.Username != "" {
+			resource.UserName = provider.Username
+		}
+	}
+	for _, groupId := range user.Groups {
+		if name, ok := groupNames[groupId]; ok {
+			resource.Groups = append(resource.Groups, multiValue{Value: groupId, Display: name, Ref: location("Groups", groupId)})
+		}
+	}
+	return resource
+}
+
+// primaryEmail returns the primary email, or the first if none is primary.
+func (resource *userResource) primaryEmail() string {
+	for _, email := range resource.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(resource.Emails) > 0 {
+		return resource.Emails[0].Value
+	}
+	return ""
+}
+
+// groupNames maps the IDs of the groups that exist to their names.
+func groupNames(db database.DbDriver) (map[string]string, error) {
+	groups, err := db.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(groups))
+	for _, group := range groups {
+		if !group.IsDeleted {
+			names[group.Id] = group.Name
+		}
+	}
+	return names, nil
+}
+
+// activeUsers returns the users that haven't been deleted, oldest first so
+// pages are stable.
+func activeUsers(db database.DbDriver) ([]*model.User, error) {
+	users, err := db.GetUsers()
+	if err != nil {
+		return nil, err
+	}
+	users = slices.DeleteFunc(users, func(user *model.User) bool { return user.IsDeleted })
+	slices.SortFunc(users, func(a, b *model.User) int { return strings.Compare(a.Id, b.Id) })
+	return users, nil
+}
+
+func getUser(db database.DbDriver, id string) (*model.User, error) {
+	if !validate.UUID(id) {
+		return nil, errNotFound
+	}
+	user, err := db.GetUser(id)
+	if err != nil || user == nil || user.IsDeleted {
+		return nil, errNotFound
+	}
+	return user, nil
+}
+
+func handleListUsers(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	f, err := listFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	users, err := activeUsers(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	names, err := groupNames(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resources := []any{}
+	for _, user := range users {
+		resource := newUserResource(user, names)
+		if f == nil || f.match(flatten(toMap(resource))) {
+			resources = append(resources, resource)
+		}
+	}
+
+	writeList(w, r, resources)
+}
+
+func handleGetUser(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	user, err := getUser(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	names, err := groupNames(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newUserResource(user, names))
+}
+
+// handleCreateUser provisions a user without a password, they sign in through
+// the identity provider.
+func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+	actor := r.Context().Value("user").(*model.User)
+
+	resource := &userResource{}
+	if err := decodeBody(w, r, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	email := resource.primaryEmail()
+	if err := validateUser(db, "", resource.UserName, email); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	username := resource.UserName
+	if !usernameAvailable(db, "", username) {
+		var err error
+		if username, err = extauth.NewUsername(db, resource.UserName, email); err != nil {
+			writeError(w, newError(http.StatusBadRequest, "invalidValue", err.Error()))
+			return
+		}
+	}
+
+	user := model.NewUser(username, email, "", []string{}, []string{}, "", "bash", "UTC", 0, "", 0, 0, 0)
+	user.Password = ""
+	user.ExternalAuthProviders = map[string]model.ExternalProvider{
+		ProviderId: {ProviderUID: resource.ExternalId, Username: resource.UserName},
+	}
+	if resource.Active != nil {
+		user.Active = *resource.Active
+	}
+
+	if err := db.SaveUser(user, nil); err != nil {
+		writeError(w, err)
+		return
+	}
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	auditUser(r, actor, model.AuditEventUserCreate, fmt.Sprintf("Provisioned user %s (%s)", user.Username, user.Email), user)
+
+	names, _ := groupNames(db)
+	w.Header().Set("Location", location("Users", user.Id))
+	writeJSON(w, http.StatusCreated, newUserResource(user, names))
+}
+
+func handleReplaceUser(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	user, err := getUser(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resource := &userResource{}
+	if err := decodeBody(w, r, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updateUser(w, r, db, user, resource)
+}
+
+func handlePatchUser(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+
+	user, err := getUser(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	request := &patchRequest{}
+	if err := decodeBody(w, r, request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	names, err := groupNames(db)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	patched := toMap(newUserResource(user, names))
+	if err := applyPatch(patched, request); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Some providers send booleans as strings
+	if key := findKey(patched, "active"); patched[key] != nil {
+		if value, ok := patched[key].(string); ok {
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, newError(http.StatusBadRequest, "invalidValue", "active must be a boolean"))
+				return
+			}
+			patched[key] = active
+		}
+	}
+
+	resource := &userResource{}
+	if err := fromMap(patched, resource); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	updateUser(w, r, db, user, resource)
+}
+
+// updateUser applies a replaced or patched resource to the user. A user made
+// inactive is deprovisioned, their sessions are ended and spaces stopped.
+func updateUser(w http.ResponseWriter, r *http.Request, db database.DbDriver, user *model.User, resource *userResource) {
+	actor := r.Context().Value("user").(*model.User)
+
+	email := resource.primaryEmail()
+	if err := validateUser(db, user.Id, resource.UserName, email); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The knot username follows the provider's when it is a valid username
+	if resource.UserName != user.Username && usernameAvailable(db, user.Id, resource.UserName) {
+		user.Username = resource.UserName
+	}
+	user.Email = email
+	if user.ExternalAuthProviders == nil {
+		user.ExternalAuthProviders = make(map[string]model.ExternalProvider)
+	}
+	provider := user.ExternalAuthProviders[ProviderId]
+	provider.ProviderUID = resource.ExternalId
+	provider.Username = resource.UserName
+	user.ExternalAuthProviders[ProviderId] = provider
+
+	deactivate := resource.Active != nil && !*resource.Active && user.Active
+	reactivate := resource.Active != nil && *resource.Active && !user.Active
+	if deactivate && user.Id == actor.Id {
+		writeError(w, errDeprovisionSelf)
+		return
+	}
+	if reactivate {
+		user.Active = true
+	}
+
+	user.UpdatedAt = hlc.Now()
+	if err := db.SaveUser(user, []string{"Username", "Email", "ExternalAuthProviders", "Active", "UpdatedAt"}); err != nil {
+		writeError(w, err)
+		return
+	}
+	service.GetTransport().GossipUser(user)
+	sse.PublishUsersChanged(user.Id)
+
+	switch {
+	case deactivate:
+		if err := extauth.DeactivateUser(user); err != nil {
+			writeError(w, err)
+			return
+		}
+		auditUser(r, actor, model.AuditEventUserUpdate, fmt.Sprintf("Deprovisioned user %s (%s)", user.Username, user.Email), user)
+	case reactivate:
+		go service.GetUserService().UpdateUserSpaces(user)
+		auditUser(r, actor, model.AuditEventUserUpdate, fmt.Sprintf("Reactivated user %s (%s)", user.Username, user.Email), user)
+	default:
+		auditUser(r, actor, model.AuditEventUserUpdate, fmt.Sprintf("Updated user %s (%s)", user.Username, user.Email), user)
+	}
+
+	names, _ := groupNames(db)
+	writeJSON(w, http.StatusOK, newUserResource(user, names))
+}
+
+// handleDeleteUser deprovisions the user, keeping the account and its spaces
+// so an administrator can transfer or remove them.
+func handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	db := database.GetInstance()
+	actor := r.Context().Value("user").(*model.User)
+
+	user, err := getUser(db, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// If trying to deprovision self then fail
+	if user.Id == actor.Id {
+		writeError(w, errDeprovisionSelf)
+		return
+	}
+
+	if user.Active {
+		if err := extauth.DeactivateUser(user); err != nil {
+			writeError(w, err)
+			return
+		}
+		auditUser(r, actor, model.AuditEventUserUpdate, fmt.Sprintf("Deprovisioned user %s (%s)", user.Username, user.Email), user)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateUser checks a user's userName and email are valid and not used by
+// another user.
+func validateUser(db database.DbDriver, userId, userName, email string) error {
+	if strings.TrimSpace(userName) == "" || !validate.MaxLength(userName, 255) {
+		return newError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if !validate.Email(email) {
+		return newError(http.StatusBadRequest, "invalidValue", "A valid email is required")
+	}
+
+	if existing, err := db.GetUserByEmail(email); err == nil && existing != nil && !existing.IsDeleted && existing.Id != userId {
+		return newError(http.StatusConflict, "uniqueness", "A user with this email already exists")
+	}
+
+	users, err := db.GetUsers()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.IsDeleted || user.Id == userId {
+			continue
+		}
+		if strings.EqualFold(user.ExternalAuthProviders[ProviderId].Username, userName) || strings.EqualFold(user.Username, userName) {
+			return newError(http.StatusConflict, "uniqueness", "A user with this userName already exists")
+		}
+	}
+
+	return nil
+}
+
+// usernameAvailable returns true if name is a valid knot username not used by
+// another user.
+func usernameAvailable(db database.DbDriver, userId, name string) bool {
+	if !validate.Username(name) {
+		return false
+	}
+	existing, err := db.GetUserByUsername(name)
+	return err != nil || existing == nil || existing.Id == userId
+}
+
+func auditUser(r *http.Request, actor *model.User, event, details string, user *model.User) {
+	audit.LogWithRequest(r,
+		actor.Username,
+		model.AuditActorTypeUser,
+		event,
+		details,
+		&map[string]interface{}{
+			"agent":           r.UserAgent(),
+			"IP":              r.RemoteAddr,
+			"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+			"user_id":         user.Id,
+			"user_name":       user.Username,
+			"user_email":      user.Email,
+			"provisioning":    ProviderId,
+		},
+	)
+}
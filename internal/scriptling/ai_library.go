@@ -31,7 +31,8 @@ func GetAILibrary(aiClient ai.Client) *object.Library {
 	}, fmt.Sprintf(`Client() - Get a pre-configured AI client instance.
 
 Returns a client connected to the AI provider with MCP tools available.
-Per-user tools are automatically discovered and executed.
+Per-user tools are automatically discovered and executed. Requests are routed
+by model alias to the configured providers, see client.models().
 
 Returns:
   Client: A pre-configured AI client instance.
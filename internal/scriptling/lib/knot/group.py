@@ -35,25 +35,31 @@ def get(group_id):
         "max_spaces": response.get("max_spaces", 0),
         "compute_units": response.get("compute_units", 0),
         "storage_units": response.get("storage_units", 0),
-        "max_tunnels": response.get("max_tunnels", 0)
+        "max_tunnels": response.get("max_tunnels", 0),
+        "daily_token_budget": response.get("daily_token_budget", 0),
+        "monthly_token_budget": response.get("monthly_token_budget", 0),
+        "allowed_models": response.get("allowed_models") or []
     }
 
 
-def create(name, max_spaces=0, compute_units=0, storage_units=0, max_tunnels=0):
+def create(name, max_spaces=0, compute_units=0, storage_units=0, max_tunnels=0, daily_token_budget=0, monthly_token_budget=0, allowed_models=None):
     """Create a new group."""
     body = {
         "name": name,
         "max_spaces": max_spaces,
         "compute_units": compute_units,
         "storage_units": storage_units,
-        "max_tunnels": max_tunnels
+        "max_tunnels": max_tunnels,
+        "daily_token_budget": daily_token_budget,
+        "monthly_token_budget": monthly_token_budget,
+        "allowed_models": allowed_models or []
     }
 
     response = api.post("/api/groups", body)
     return response.get("group_id")
 
 
-def update(group_id, name=None, max_spaces=None, compute_units=None, storage_units=None):
+def update(group_id, name=None, max_spaces=None, compute_units=None, storage_units=None, daily_token_budget=None, monthly_token_budget=None, allowed_models=None):
     """Update group properties."""
     current = api.get(f"/api/groups/{_enc(group_id)}")
 
@@ -62,7 +68,10 @@ def update(group_id, name=None, max_spaces=None, compute_units=None, storage_uni
         "max_spaces": max_spaces if max_spaces is not None else current.get("max_spaces", 0),
         "compute_units": compute_units if compute_units is not None else current.get("compute_units", 0),
         "storage_units": storage_units if storage_units is not None else current.get("storage_units", 0),
-        "max_tunnels": current.get("max_tunnels", 0)
+        "max_tunnels": current.get("max_tunnels", 0),
+        "daily_token_budget": daily_token_budget if daily_token_budget is not None else current.get("daily_token_budget", 0),
+        "monthly_token_budget": monthly_token_budget if monthly_token_budget is not None else current.get("monthly_token_budget", 0),
+        "allowed_models": allowed_models if allowed_models is not None else (current.get("allowed_models") or [])
     }
 
     api.put(f"/api/groups/{_enc(group_id)}", body)
@@ -31,6 +31,22 @@ def _parse_member(member):
     }
 
 
+def _parse_autoscale(autoscale):
+    """Parse a pool autoscale policy into a stable dict."""
+    autoscale = autoscale or {}
+    return {
+        "enabled": autoscale.get("enabled", False),
+        "min_count": autoscale.get("min_count", 0),
+        "max_count": autoscale.get("max_count", 0),
+        "target_cpu_percent": autoscale.get("target_cpu_percent", 0),
+        "target_rps_per_member": autoscale.get("target_rps_per_member", 0),
+        "target_metric": autoscale.get("target_metric", ""),
+        "target_metric_value": autoscale.get("target_metric_value", 0),
+        "scale_up_cooldown": autoscale.get("scale_up_cooldown", 0),
+        "scale_down_cooldown": autoscale.get("scale_down_cooldown", 0),
+    }
+
+
 def _parse_pool(pool):
     """Parse a pool response into a stable dict."""
     util = pool.get("utilization", {}) or {}
@@ -40,6 +56,8 @@ def _parse_pool(pool):
         "template_id": pool.get("template_id", ""),
         "startup_script_id": pool.get("startup_script_id", ""),
         "desired_count": pool.get("desired_count", 0),
+        "autoscale": _parse_autoscale(pool.get("autoscale")),
+        "last_scaled_at": pool.get("last_scaled_at", ""),
         "alive_members": pool.get("alive_members", 0),
         "active": pool.get("active", False),
         "utilization": {
@@ -123,6 +141,36 @@ def set_size(name, desired_count):
     return True
 
 
+def set_autoscale(name, enabled=True, min_count=None, max_count=None, target_cpu_percent=None,
+                  target_rps_per_member=None, scale_up_cooldown=None, scale_down_cooldown=None,
+                  target_metric=None, target_metric_value=None):
+    """Enable, update or disable autoscaling for a pool and return the new policy.
+
+    Settings left as None keep their current value. The leader resizes the pool
+    between min_count and max_count to hold the targets, a target of 0 is ignored.
+    target_metric names an application metric the spaces push over OTLP, the
+    pool is sized so its average stays near target_metric_value.
+    """
+    current = get(name)
+    autoscale = current.get("autoscale")
+    autoscale["enabled"] = enabled
+    for key, value in [
+        ("min_count", min_count),
+        ("max_count", max_count),
+        ("target_cpu_percent", target_cpu_percent),
+        ("target_rps_per_member", target_rps_per_member),
+        ("target_metric", target_metric),
+        ("target_metric_value", target_metric_value),
+        ("scale_up_cooldown", scale_up_cooldown),
+        ("scale_down_cooldown", scale_down_cooldown),
+    ]:
+        if value is not None:
+            autoscale[key] = value
+
+    api.put(f"/api/pools/{_enc(current.get('id'))}", {"autoscale": autoscale})
+    return autoscale
+
+
 def start(name):
     """Start a stopped pool: starts all member spaces and creates any missing ones."""
     api.post(f"/api/pools/{_enc(name)}/start")
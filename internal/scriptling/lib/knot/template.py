@@ -75,6 +75,8 @@ def create(name, job="", description="", platform="", volumes="", active=True,
            compute_units=0, storage_units=0, with_terminal=False,
            with_vscode_tunnel=False, with_code_server=False, with_ssh=False,
            with_run_command=False, allow_node_migration=False,
+           disable_ssh_local_forward=False, with_ssh_reverse_forward=False,
+           with_terminal_recording=False,
            schedule_enabled=False, icon_url="",
            groups=None, zones=None, paths=None, disable_user_activity=False,
            health_check_type="none", health_check_config="", health_check_skip_ssl_verify=False,
@@ -96,6 +98,9 @@ def create(name, job="", description="", platform="", volumes="", active=True,
         "with_code_server": with_code_server,
         "with_ssh": with_ssh,
         "with_run_command": with_run_command,
+        "disable_ssh_local_forward": disable_ssh_local_forward,
+        "with_ssh_reverse_forward": with_ssh_reverse_forward,
+        "with_terminal_recording": with_terminal_recording,
         "allow_node_migration": allow_node_migration,
         "schedule_enabled": schedule_enabled,
         "icon_url": icon_url,
@@ -122,6 +127,8 @@ def update(template_id, name=None, job=None, description=None, platform=None,
            volumes=None, active=None, compute_units=None, storage_units=None,
            with_terminal=None, with_vscode_tunnel=None, with_code_server=None,
            with_ssh=None, with_run_command=None, allow_node_migration=None,
+           disable_ssh_local_forward=None, with_ssh_reverse_forward=None,
+           with_terminal_recording=None,
            schedule_enabled=None,
            icon_url=None, groups=None, zones=None, paths=None, disable_user_activity=None,
            health_check_type=None, health_check_config=None, health_check_skip_ssl_verify=None,
@@ -146,6 +153,9 @@ def update(template_id, name=None, job=None, description=None, platform=None,
         "with_code_server": with_code_server if with_code_server is not None else current.get("with_code_server", False),
         "with_ssh": with_ssh if with_ssh is not None else current.get("with_ssh", False),
         "with_run_command": with_run_command if with_run_command is not None else current.get("with_run_command", False),
+        "disable_ssh_local_forward": disable_ssh_local_forward if disable_ssh_local_forward is not None else current.get("disable_ssh_local_forward", False),
+        "with_ssh_reverse_forward": with_ssh_reverse_forward if with_ssh_reverse_forward is not None else current.get("with_ssh_reverse_forward", False),
+        "with_terminal_recording": with_terminal_recording if with_terminal_recording is not None else current.get("with_terminal_recording", False),
         "allow_node_migration": allow_node_migration if allow_node_migration is not None else current.get("allow_node_migration", False),
         "schedule_enabled": schedule_enabled if schedule_enabled is not None else current.get("schedule_enabled", False),
         "icon_url": icon_url if icon_url is not None else current.get("icon_url", ""),
@@ -272,6 +282,9 @@ def _parse_template(response):
         "with_code_server": response.get("with_code_server", False),
         "with_ssh": response.get("with_ssh", False),
         "with_run_command": response.get("with_run_command", False),
+        "disable_ssh_local_forward": response.get("disable_ssh_local_forward", False),
+        "with_ssh_reverse_forward": response.get("with_ssh_reverse_forward", False),
+        "with_terminal_recording": response.get("with_terminal_recording", False),
         "allow_node_migration": response.get("allow_node_migration", False),
         "schedule_enabled": response.get("schedule_enabled", False),
         "auto_start": response.get("auto_start", False),
@@ -67,10 +67,11 @@ func GetMethodsLibrary() *object.Library {
 func buildServerClass() *object.Class {
 	cb := object.NewClassBuilder("Server")
 
-	// Constructor: Server(command, *, type="stdio", timeout=30, args=None, mode="concurrent")
-	// `type` defaults to "stdio" (the only currently supported transport) and
-	// is exposed as a kwarg so future transports can be opted into without
-	// forcing every caller to spell out the default.
+	// Constructor: Server(target, *, type="stdio", timeout=30, args=None, mode="concurrent",
+	//                    path="/", headers=None, health_path="", health_interval=10)
+	// `type` defaults to "stdio". The target is the command for stdio servers,
+	// the URL for http servers and the socket path for unix servers; path,
+	// headers and the health settings only apply to http and unix servers.
 	cb.Constructor(func(ctx context.Context, kwargs object.Kwargs, command string) (*serverState, error) {
 		state := &serverState{}
 		state.reg.Server.Type = methods.ServerTypeStdio
@@ -102,18 +103,59 @@ func buildServerClass() *object.Class {
 		if v := kwargs.Get("args"); v != nil {
 			state.reg.Server.Args = toStringSlice(v)
 		}
+		if v := kwargs.Get("path"); v != nil {
+			path, errObj := v.AsString()
+			if errObj != nil {
+				return nil, conversion.ToGoError(errObj)
+			}
+			state.reg.Server.Path = path
+		}
+		if v := kwargs.Get("health_path"); v != nil {
+			path, errObj := v.AsString()
+			if errObj != nil {
+				return nil, conversion.ToGoError(errObj)
+			}
+			state.reg.Server.HealthPath = path
+		}
+		if v := kwargs.Get("health_interval"); v != nil {
+			interval, errObj := v.AsInt()
+			if errObj != nil {
+				return nil, conversion.ToGoError(errObj)
+			}
+			state.reg.Server.HealthInterval = int(interval)
+		}
+		if v := kwargs.Get("headers"); v != nil {
+			headers, ok := conversion.ToGo(v).(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Server() headers must be a dict")
+			}
+			state.reg.Server.Headers = make(map[string]string, len(headers))
+			for key, value := range headers {
+				state.reg.Server.Headers[key] = fmt.Sprint(value)
+			}
+		}
 		for _, k := range kwargs.Keys() {
 			switch k {
-			case "type", "timeout", "mode", "args":
+			case "type", "timeout", "mode", "args", "path", "headers", "health_path", "health_interval":
 			default:
 				return nil, fmt.Errorf("Server() unknown kwarg %q", k)
 			}
 		}
+
+		// The target names the listener for http and unix servers
+		switch state.reg.Server.Type {
+		case methods.ServerTypeHTTP:
+			state.reg.Server.URL = command
+			state.reg.Server.Command = ""
+		case methods.ServerTypeUnix:
+			state.reg.Server.Socket = command
+			state.reg.Server.Command = ""
+		}
 		return state, nil
 	})
 
 	// server.method(*, name, description, local_name="", keywords=[], scope="private",
-	//                groups=[], mcp_tool=False, params=None, result=None)
+	//                groups=[], mcp_tool=False, streaming=False, params=None, result=None)
 	// name may be passed positionally or as a keyword argument.
 	cb.MethodWithHelp("method", func(self *serverState, ctx context.Context, kwargs object.Kwargs, args ...object.Object) object.Object {
 		var name string
@@ -172,6 +214,13 @@ func buildServerClass() *object.Class {
 			}
 			def.MCPTool = b
 		}
+		if v := kwargs.Get("streaming"); v != nil {
+			b, errObj := v.AsBool()
+			if errObj != nil {
+				return errors.ParameterError("streaming", errObj)
+			}
+			def.Streaming = b
+		}
 		if v := kwargs.Get("params"); v != nil {
 			def.ParamsSchema = toSchemaMap("params", v)
 			if def.ParamsSchema == nil {
@@ -192,7 +241,7 @@ func buildServerClass() *object.Class {
 		}
 		for _, k := range kwargs.Keys() {
 			switch k {
-			case "name", "local_name", "description", "scope", "keywords", "groups", "mcp_tool", "params", "result", "events", "event_sinks":
+			case "name", "local_name", "description", "scope", "keywords", "groups", "mcp_tool", "streaming", "params", "result", "events", "event_sinks":
 			default:
 				return &object.Error{Message: fmt.Sprintf("method() unknown kwarg %q", k)}
 			}
@@ -200,7 +249,7 @@ func buildServerClass() *object.Class {
 
 		self.reg.Methods = append(self.reg.Methods, def)
 		return object.NewBoolean(true)
-	}, `method(name, *, local_name="", description="", scope="private", keywords=[], groups=[], mcp_tool=False, params=None, result=None, events=[], event_sinks=[]) - Add a method definition`)
+	}, `method(name, *, local_name="", description="", scope="private", keywords=[], groups=[], mcp_tool=False, streaming=False, params=None, result=None, events=[], event_sinks=[]) - Add a method definition`)
 
 	// server.register() - validate and publish the collected registration.
 	cb.MethodWithHelp("register", func(self *serverState, ctx context.Context) object.Object {
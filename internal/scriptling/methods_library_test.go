@@ -132,6 +132,23 @@ server.register()
 	}
 }
 
+func TestServerUnixType(t *testing.T) {
+	reg, err := evalServer(t, `
+server = Server("/run/notes.sock", type="unix", path="/rpc", health_path="/healthz", headers={"X-Token": "abc"})
+server.method(name="ping", description="ping")
+server.register()
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.Server.Type != methods.ServerTypeUnix || reg.Server.Socket != "/run/notes.sock" || reg.Server.Command != "" {
+		t.Errorf("unexpected server %+v", reg.Server)
+	}
+	if reg.Server.Path != "/rpc" || reg.Server.HealthPath != "/healthz" || reg.Server.Headers["X-Token"] != "abc" {
+		t.Errorf("unexpected server options %+v", reg.Server)
+	}
+}
+
 func TestServerRejectsUnknownKwarg(t *testing.T) {
 	_, err := evalServer(t, `
 server = Server("./bin/x", unknown=1)
@@ -1,6 +1,10 @@
 package service
 
-import "github.com/paularlott/knot/internal/database/model"
+import (
+	"context"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
 
 type Container interface {
 	// Volumes
@@ -11,7 +15,7 @@ type Container interface {
 	// teardown synchronously and return any teardown error. The shutdown script
 	// is bounded by a timeout (helper.ShutdownScriptTimeout) so a hung agent
 	// script cannot block the caller indefinitely.
-	StartSpace(space *model.Space, template *model.Template, user *model.User) error
+	StartSpace(ctx context.Context, space *model.Space, template *model.Template, user *model.User) error
 	StopSpace(space *model.Space) error
 	RestartSpace(space *model.Space) error
 	DeleteSpace(space *model.Space)
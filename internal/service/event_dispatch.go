@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -94,9 +95,23 @@ type JSONRPCSubscription struct {
 	EventSinks []string
 }
 
+// EventSinkStats describes the delivery queue and delivery outcomes of a sink
+// on this server since it started.
+type EventSinkStats struct {
+	SinkId     string
+	SinkName   string
+	SinkType   string
+	QueueDepth int
+	Delivered  uint64
+	Retried    uint64
+	GivenUp    uint64
+	Dropped    uint64
+}
+
 type EventDispatcher struct {
 	mu                sync.RWMutex
 	inFlight          map[string]*InFlightEntry
+	sinkStats         map[string]*EventSinkStats
 	processed         map[string]time.Time
 	jsonrpcDelivered  map[string]time.Time
 	queues            map[string]*sinkQueue
@@ -116,6 +131,7 @@ func GetEventDispatcher() *EventDispatcher {
 	dispatcherOnce.Do(func() {
 		dispatcher = &EventDispatcher{
 			inFlight:          make(map[string]*InFlightEntry),
+			sinkStats:         make(map[string]*EventSinkStats),
 			processed:         make(map[string]time.Time),
 			jsonrpcDelivered:  make(map[string]time.Time),
 			queues:            make(map[string]*sinkQueue),
@@ -290,6 +306,7 @@ func (d *EventDispatcher) enqueue(sink *model.EventSink, envelope *EventEnvelope
 	q.mu.Lock()
 	if len(q.queue) >= SinkQueueSize {
 		q.mu.Unlock()
+		d.countDelivery(sink.Id, func(stats *EventSinkStats) { stats.Dropped++ })
 		log.Warn("event sink queue full, dropping event", "sink_id", sink.Id, "event_id", envelope.EventId)
 		logAudit(model.AuditEventEventSinkDropped,
 			fmt.Sprintf("Event sink %s queue full, dropped event %s", sink.Name, envelope.EventId),
@@ -319,6 +336,7 @@ func (d *EventDispatcher) deliver(sinkId string) {
 	for attempt := uint32(1); attempt <= RetryAttempts; attempt++ {
 		err := d.deliverOnce(task)
 		if err == nil {
+			d.countDelivery(sinkId, func(stats *EventSinkStats) { stats.Delivered++ })
 			d.markDone(task.envelope.EventId, sinkId)
 			return
 		}
@@ -330,6 +348,7 @@ func (d *EventDispatcher) deliver(sinkId string) {
 			"error", err)
 
 		if attempt < RetryAttempts {
+			d.countDelivery(sinkId, func(stats *EventSinkStats) { stats.Retried++ })
 			d.markRetry(task.envelope.EventId, sinkId, attempt, err.Error())
 			delay := RetryDelay1
 			if attempt == 2 {
@@ -339,9 +358,78 @@ func (d *EventDispatcher) deliver(sinkId string) {
 		}
 	}
 
+	d.countDelivery(sinkId, func(stats *EventSinkStats) { stats.GivenUp++ })
 	d.markGivenUp(task.envelope.EventId, sinkId, task)
 }
 
+func (d *EventDispatcher) countDelivery(sinkId string, fn func(stats *EventSinkStats)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats, ok := d.sinkStats[sinkId]
+	if !ok {
+		stats = &EventSinkStats{SinkId: sinkId}
+		d.sinkStats[sinkId] = stats
+	}
+	fn(stats)
+}
+
+// SinkStats returns the queue depth and delivery counts of every known sink,
+// sinks that have been deleted keep their counts until the server restarts.
+func (d *EventDispatcher) SinkStats() []EventSinkStats {
+	d.mu.RLock()
+	result := make(map[string]*EventSinkStats, len(d.sinkCache)+len(d.sinkStats))
+	for id, stats := range d.sinkStats {
+		copied := *stats
+		result[id] = &copied
+	}
+	for _, sink := range d.sinkCache {
+		stats, ok := result[sink.Id]
+		if !ok {
+			stats = &EventSinkStats{SinkId: sink.Id}
+			result[sink.Id] = stats
+		}
+		stats.SinkName = sink.Name
+		stats.SinkType = sink.SinkType
+	}
+	queues := make(map[string]*sinkQueue, len(d.queues))
+	for id, q := range d.queues {
+		queues[id] = q
+	}
+	d.mu.RUnlock()
+
+	for id, q := range queues {
+		q.mu.Lock()
+		depth := len(q.queue)
+		q.mu.Unlock()
+		stats, ok := result[id]
+		if !ok {
+			stats = &EventSinkStats{SinkId: id}
+			result[id] = stats
+		}
+		stats.QueueDepth = depth
+	}
+
+	list := make([]EventSinkStats, 0, len(result))
+	for _, stats := range result {
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].SinkId < list[j].SinkId
+	})
+	return list
+}
+
+// InFlightCounts returns the number of tracked deliveries in each status.
+func (d *EventDispatcher) InFlightCounts() map[string]int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, entry := range d.inFlight {
+		counts[entry.Status]++
+	}
+	return counts
+}
+
 func (d *EventDispatcher) deliverOnce(task *deliveryTask) error {
 	switch task.sink.SinkType {
 	case "webhook":
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"testing"
@@ -76,6 +77,7 @@ func (f *fakeTransport) GossipUser(*model.User)                         {}
 func (f *fakeTransport) GossipToken(*model.Token)                       {}
 func (f *fakeTransport) GossipVolume(*model.Volume)                     {}
 func (f *fakeTransport) GossipSpaceUsageSample(*model.SpaceUsageSample) {}
+func (f *fakeTransport) GossipLLMUsage(*model.LLMUsage)                 {}
 func (f *fakeTransport) GossipAuditLog(*model.AuditLogEntry)            {}
 func (f *fakeTransport) GossipSession(*model.Session)                   {}
 func (f *fakeTransport) GossipScript(*model.Script)                     {}
@@ -89,8 +91,8 @@ func (f *fakeTransport) GossipMCPServer(*model.MCPServer)               {}
 func (f *fakeTransport) GossipPoolDefinition(*model.PoolDefinition)     {}
 func (f *fakeTransport) GetAgentEndpoints() []string                    { return nil }
 func (f *fakeTransport) GetTunnelServers() []string                     { return nil }
-func (f *fakeTransport) LockResource(string) string                     { return "" }
-func (f *fakeTransport) UnlockResource(string, string)                  {}
+func (f *fakeTransport) LockResource(context.Context, string) string    { return "" }
+func (f *fakeTransport) UnlockResource(context.Context, string, string) {}
 func (f *fakeTransport) Nodes() []*gossip.Node                          { return nil }
 func (f *fakeTransport) GetNodeByIDString(string) *gossip.Node          { return nil }
 func (f *fakeTransport) EnqueueSpaceCleanup(*model.Space)               {}
@@ -101,6 +103,7 @@ func (f *fakeTransport) EnqueueSpaceCleanup(*model.Space)               {}
 func newTestDispatcher() *EventDispatcher {
 	return &EventDispatcher{
 		inFlight:          make(map[string]*InFlightEntry),
+		sinkStats:         make(map[string]*EventSinkStats),
 		processed:         make(map[string]time.Time),
 		jsonrpcDelivered:  make(map[string]time.Time),
 		queues:            make(map[string]*sinkQueue),
@@ -15,6 +15,7 @@ import (
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/tracing"
 	"github.com/paularlott/knot/internal/util/rest"
 )
 
@@ -91,6 +92,7 @@ func ForwardToNode(w http.ResponseWriter, r *http.Request, nodeId string) error
 	}
 	cfg := config.GetServerConfig()
 	req.Header.Set("X-Cluster-Key", cfg.Cluster.Key)
+	tracing.Inject(r.Context(), req.Header)
 	if user, ok := r.Context().Value("user").(*model.User); ok && user != nil {
 		req.Header.Set("X-Cluster-User-Id", user.Id)
 	}
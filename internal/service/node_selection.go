@@ -21,8 +21,8 @@ type nodeCandidate struct {
 // SelectNodeForSpace selects the best node for a space based on template requirements
 // Returns node ID or empty string for auto-selection, or error if no suitable node found
 func SelectNodeForSpace(template *model.Template, selectedNodeId string) (string, error) {
-	// Skip node selection for nomad and manual platforms
-	if template.Platform == model.PlatformNomad || template.Platform == model.PlatformManual {
+	// Skip node selection for cluster scheduled and manual platforms
+	if template.Platform == model.PlatformNomad || template.Platform == model.PlatformKubernetes || template.Platform == model.PlatformManual {
 		return "", nil
 	}
 
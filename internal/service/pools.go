@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -39,6 +40,7 @@ type PoolSessionState struct {
 	MethodRPS        float64
 	HTTPRPS          float64
 	TCPRPS           float64
+	CustomMetrics    map[string]float64
 }
 
 var (
@@ -138,35 +140,58 @@ func (s *PoolService) Info(pool *model.PoolDefinition, user *model.User) (apicli
 		TemplateId:      pool.TemplateId,
 		StartupScriptId: pool.StartupScriptId,
 		DesiredCount:    pool.DesiredCount,
+		Autoscale:       pool.Autoscale,
+		LastScaledAt:    pool.LastScaledAt,
 		Active:          pool.Active,
 		Members:         []apiclient.PoolMemberInfo{},
 	}
 
-	var cpuTotal, memTotal float64
-	var resourceCount int
 	for _, space := range spaces {
 		if space.PoolId != pool.Id || space.IsDeleted {
 			continue
 		}
-		member := s.memberInfo(space)
-		info.Members = append(info.Members, member)
-		if member.State == "alive" {
-			info.AliveMembers++
-			info.Utilization.MethodRPS += member.MethodRPS
-			info.Utilization.HTTPRPS += member.HTTPRPS
-			info.Utilization.TCPRPS += member.TCPRPS
-			info.Utilization.MethodInflight += member.MethodInflight
-			cpuTotal += member.CPUPercent
-			memTotal += member.MemoryPercent
-			resourceCount++
+		info.Members = append(info.Members, s.memberInfo(space))
+	}
+	info.Utilization, info.AliveMembers = poolUtilization(info.Members)
+	return info, nil
+}
+
+// poolUtilization totals the load reported by the alive members, returning it
+// with the number of alive members.
+func poolUtilization(members []apiclient.PoolMemberInfo) (apiclient.PoolUtilization, int) {
+	var utilization apiclient.PoolUtilization
+	var cpuTotal, memTotal float64
+	metricTotals := map[string]float64{}
+	metricCounts := map[string]int{}
+	alive := 0
+	for _, member := range members {
+		if member.State != "alive" {
+			continue
+		}
+		alive++
+		utilization.MethodRPS += member.MethodRPS
+		utilization.HTTPRPS += member.HTTPRPS
+		utilization.TCPRPS += member.TCPRPS
+		utilization.MethodInflight += member.MethodInflight
+		cpuTotal += member.CPUPercent
+		memTotal += member.MemoryPercent
+		for name, value := range member.CustomMetrics {
+			metricTotals[name] += value
+			metricCounts[name]++
 		}
 	}
-	info.Utilization.CombinedRPS = info.Utilization.MethodRPS + info.Utilization.HTTPRPS + info.Utilization.TCPRPS
-	if resourceCount > 0 {
-		info.Utilization.AvgCPUPercent = cpuTotal / float64(resourceCount)
-		info.Utilization.AvgMemoryPercent = memTotal / float64(resourceCount)
+	utilization.CombinedRPS = utilization.MethodRPS + utilization.HTTPRPS + utilization.TCPRPS
+	if alive > 0 {
+		utilization.AvgCPUPercent = cpuTotal / float64(alive)
+		utilization.AvgMemoryPercent = memTotal / float64(alive)
 	}
-	return info, nil
+	if len(metricTotals) > 0 {
+		utilization.AvgCustomMetrics = make(map[string]float64, len(metricTotals))
+		for name, total := range metricTotals {
+			utilization.AvgCustomMetrics[name] = total / float64(metricCounts[name])
+		}
+	}
+	return utilization, alive
 }
 
 func (s *PoolService) memberInfo(space *model.Space) apiclient.PoolMemberInfo {
@@ -187,6 +212,7 @@ func (s *PoolService) memberInfo(space *model.Space) apiclient.PoolMemberInfo {
 		member.CombinedRPS = member.MethodRPS + member.HTTPRPS + member.TCPRPS
 		member.MethodInflight = methods.DefaultRegistry().InFlightForSpace(space.Id)
 		member.CPUPercent = session.CPUPercent
+		member.CustomMetrics = session.CustomMetrics
 		if session.MemoryLimitBytes > 0 {
 			member.MemoryPercent = float64(session.MemoryUsedBytes) / float64(session.MemoryLimitBytes) * 100
 		}
@@ -218,6 +244,9 @@ func (s *PoolService) validate(pool *model.PoolDefinition) error {
 	if pool.DesiredCount < 1 {
 		return fmt.Errorf("desired_count must be at least 1")
 	}
+	if err := validateAutoscale(&pool.Autoscale); err != nil {
+		return err
+	}
 	db := database.GetInstance()
 	template, err := db.GetTemplate(pool.TemplateId)
 	if err != nil || template == nil || template.IsDeleted || !template.Active {
@@ -247,6 +276,9 @@ func (s *PoolService) Create(pool *model.PoolDefinition, user *model.User) error
 	pool.Zone = config.GetServerConfig().Zone
 
 	requested := pool.DesiredCount
+	if pool.Autoscale.Enabled {
+		requested = pool.Autoscale.Clamp(requested)
+	}
 	if requested < 1 {
 		requested = 1
 	}
@@ -384,6 +416,18 @@ func (s *PoolService) SetSize(pool *model.PoolDefinition, desiredCount int, user
 	return s.savePool(pool)
 }
 
+// SetAutoscale replaces the pool's autoscaling policy. The leader's sweep
+// applies it, moving the pool within the new bounds on its next pass.
+func (s *PoolService) SetAutoscale(pool *model.PoolDefinition, autoscale model.PoolAutoscale, user *model.User) error {
+	if err := validateAutoscale(&autoscale); err != nil {
+		return err
+	}
+	pool.Autoscale = autoscale
+	pool.UpdatedUserId = user.Id
+	pool.UpdatedAt = hlc.Now()
+	return s.savePool(pool)
+}
+
 func (s *PoolService) Delete(pool *model.PoolDefinition, user *model.User) error {
 	if pool.Active {
 		return fmt.Errorf("stop the pool before deleting it")
@@ -595,7 +639,7 @@ func (s *PoolService) reconcileStoppedPool(pool *model.PoolDefinition, members [
 //
 //  1. If any member is IsPending → skip, wait for the transition.
 //  2. If any member is IsDeleting → retry DeleteSpace, wait.
-//  3. No transitions in progress:
+//  3. No transitions in progress, autoscaling may adjust DesiredCount, then:
 //     - total > DesiredCount:
 //     Excess running → drain (stop new traffic), mark. Next sweep → StopSpace.
 //     Excess stopped → grace period (2 passes) → deletePoolSpace.
@@ -620,6 +664,8 @@ func (s *PoolService) reconcile(pool *model.PoolDefinition, members []*model.Spa
 		return
 	}
 
+	s.autoscale(pool, members)
+
 	if len(members) > pool.DesiredCount {
 		s.handleExcess(members, pool.DesiredCount)
 		return
@@ -841,7 +887,7 @@ func (s *PoolService) createPoolSpace(pool *model.PoolDefinition, user *model.Us
 	}
 
 	if pool.Active {
-		return GetContainerService().StartSpace(space, template, user)
+		return GetContainerService().StartSpace(context.Background(), space, template, user)
 	}
 	return nil
 }
@@ -856,7 +902,7 @@ func (s *PoolService) startPoolSpace(pool *model.PoolDefinition, space *model.Sp
 	if err != nil || template == nil {
 		return fmt.Errorf("template not found")
 	}
-	return GetContainerService().StartSpace(space, template, user)
+	return GetContainerService().StartSpace(context.Background(), space, template, user)
 }
 
 // deletePoolSpace initiates deletion of a STOPPED pool space via the normal
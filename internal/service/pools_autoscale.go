@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/paularlott/gossip/hlc"
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/sse"
+)
+
+// autoscaleTolerance is how far the load may drift from the target, as a
+// fraction of the target, before the pool is resized. It stops the pool
+// flapping when the load sits close to the target.
+const autoscaleTolerance = 0.1
+
+func validateAutoscale(autoscale *model.PoolAutoscale) error {
+	if !autoscale.Enabled {
+		return nil
+	}
+	if autoscale.MinCount < 1 {
+		return fmt.Errorf("autoscale min_count must be at least 1")
+	}
+	if autoscale.MaxCount < autoscale.MinCount {
+		return fmt.Errorf("autoscale max_count must be at least min_count")
+	}
+	if autoscale.TargetCPUPercent < 0 || autoscale.TargetCPUPercent > 100 {
+		return fmt.Errorf("autoscale target_cpu_percent must be between 0 and 100")
+	}
+	if autoscale.TargetRPSPerMember < 0 {
+		return fmt.Errorf("autoscale target_rps_per_member cannot be negative")
+	}
+	if autoscale.TargetMetricValue < 0 {
+		return fmt.Errorf("autoscale target_metric_value cannot be negative")
+	}
+	if (autoscale.TargetMetric == "") != (autoscale.TargetMetricValue == 0) {
+		return fmt.Errorf("autoscale target_metric and target_metric_value must be set together")
+	}
+	if autoscale.TargetCPUPercent == 0 && autoscale.TargetRPSPerMember == 0 && autoscale.TargetMetricValue == 0 {
+		return fmt.Errorf("autoscale requires target_cpu_percent, target_rps_per_member or target_metric")
+	}
+	return nil
+}
+
+// autoscaleTarget returns the member count the pool should have and the rule
+// that chose it: "bounds" when the current count is outside min/max, otherwise
+// "cpu", "rps" or "metric". Each rule sizes the pool so the load per alive member meets
+// its target and the largest answer wins. The pool only shrinks when every
+// member is alive, so members that are still coming up are not counted as
+// spare capacity.
+func autoscaleTarget(autoscale *model.PoolAutoscale, current, alive int, utilization apiclient.PoolUtilization) (int, string) {
+	if clamped := autoscale.Clamp(current); clamped != current {
+		return clamped, "bounds"
+	}
+	if alive == 0 {
+		return current, ""
+	}
+
+	target, reason := 0, ""
+	if autoscale.TargetCPUPercent > 0 {
+		if count := scaledCount(current, alive, utilization.AvgCPUPercent/autoscale.TargetCPUPercent); count > target {
+			target, reason = count, "cpu"
+		}
+	}
+	if autoscale.TargetRPSPerMember > 0 {
+		perMember := utilization.CombinedRPS / float64(alive)
+		if count := scaledCount(current, alive, perMember/autoscale.TargetRPSPerMember); count > target {
+			target, reason = count, "rps"
+		}
+	}
+	if autoscale.TargetMetricValue > 0 {
+		// Members that don't report the metric leave the pool at its size
+		if value, ok := utilization.AvgCustomMetrics[autoscale.TargetMetric]; ok {
+			if count := scaledCount(current, alive, value/autoscale.TargetMetricValue); count > target {
+				target, reason = count, "metric"
+			}
+		} else if current > target {
+			target = current
+		}
+	}
+
+	if target < current && alive < current {
+		return current, ""
+	}
+	target = autoscale.Clamp(target)
+	if target == current {
+		return current, ""
+	}
+	return target, reason
+}
+
+// scaledCount returns the member count needed for the load to meet the target
+// given the alive members run at ratio times the target.
+func scaledCount(current, alive int, ratio float64) int {
+	if math.Abs(ratio-1) <= autoscaleTolerance {
+		return current
+	}
+	return int(math.Ceil(float64(alive) * ratio))
+}
+
+// autoscale resizes an active pool from its members' metrics. Each change is
+// saved and gossiped like a manual resize, then recorded as a pool.scaled
+// system event and an audit log entry.
+func (s *PoolService) autoscale(pool *model.PoolDefinition, members []*model.Space) {
+	if !pool.Autoscale.Enabled {
+		return
+	}
+
+	infos := make([]apiclient.PoolMemberInfo, 0, len(members))
+	for _, space := range members {
+		infos = append(infos, s.memberInfo(space))
+	}
+	utilization, alive := poolUtilization(infos)
+
+	target, reason := autoscaleTarget(&pool.Autoscale, pool.DesiredCount, alive, utilization)
+	if target == pool.DesiredCount {
+		return
+	}
+
+	now := time.Now().UTC()
+	if reason != "bounds" && pool.LastScaledAt != nil && now.Sub(*pool.LastScaledAt) < pool.Autoscale.Cooldown(target > pool.DesiredCount) {
+		return
+	}
+
+	previous := pool.DesiredCount
+	pool.DesiredCount = target
+	pool.LastScaledAt = &now
+	pool.UpdatedAt = hlc.Now()
+	if err := s.savePool(pool); err != nil {
+		log.WithError(err).Error("failed to save autoscaled pool", "pool_id", pool.Id)
+		pool.DesiredCount = previous
+		return
+	}
+	sse.PublishPoolChanged(pool.Id)
+
+	log.Info("autoscaled pool", "pool_id", pool.Id, "from", previous, "to", target, "reason", reason)
+
+	payload := map[string]interface{}{
+		"pool_id":         pool.Id,
+		"pool_name":       pool.Name,
+		"previous":        previous,
+		"current":         target,
+		"reason":          reason,
+		"alive_members":   alive,
+		"avg_cpu_percent": utilization.AvgCPUPercent,
+		"combined_rps":    utilization.CombinedRPS,
+		"target_metric":   pool.Autoscale.TargetMetric,
+		"metric_value":    utilization.AvgCustomMetrics[pool.Autoscale.TargetMetric],
+		"min_count":       pool.Autoscale.MinCount,
+		"max_count":       pool.Autoscale.MaxCount,
+		"scaled_at":       now.Format(time.RFC3339Nano),
+	}
+	RaiseSystemEvent("pool.scaled", "", pool.CreatedUserId, payload)
+	logAudit(model.AuditEventPoolScale,
+		fmt.Sprintf("Pool %s scaled from %d to %d members (%s)", pool.Name, previous, target, reason),
+		payload)
+}
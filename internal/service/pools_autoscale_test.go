@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestAutoscaleTarget(t *testing.T) {
+	cpu := &model.PoolAutoscale{Enabled: true, MinCount: 2, MaxCount: 10, TargetCPUPercent: 50}
+	rps := &model.PoolAutoscale{Enabled: true, MinCount: 1, MaxCount: 5, TargetRPSPerMember: 100}
+	both := &model.PoolAutoscale{Enabled: true, MinCount: 1, MaxCount: 10, TargetCPUPercent: 50, TargetRPSPerMember: 100}
+	queue := &model.PoolAutoscale{Enabled: true, MinCount: 1, MaxCount: 10, TargetMetric: "queue_depth", TargetMetricValue: 20}
+	queueCPU := &model.PoolAutoscale{Enabled: true, MinCount: 1, MaxCount: 10, TargetCPUPercent: 50, TargetMetric: "queue_depth", TargetMetricValue: 20}
+
+	tests := []struct {
+		name        string
+		autoscale   *model.PoolAutoscale
+		current     int
+		alive       int
+		utilization apiclient.PoolUtilization
+		want        int
+		reason      string
+	}{
+		{"below min", cpu, 1, 1, apiclient.PoolUtilization{}, 2, "bounds"},
+		{"above max", rps, 8, 8, apiclient.PoolUtilization{}, 5, "bounds"},
+		{"no metrics", cpu, 3, 0, apiclient.PoolUtilization{}, 3, ""},
+		{"within tolerance", cpu, 4, 4, apiclient.PoolUtilization{AvgCPUPercent: 54}, 4, ""},
+		{"cpu scale up", cpu, 4, 4, apiclient.PoolUtilization{AvgCPUPercent: 80}, 7, "cpu"},
+		{"cpu scale up capped", cpu, 8, 8, apiclient.PoolUtilization{AvgCPUPercent: 100}, 10, "cpu"},
+		{"cpu scale down", cpu, 6, 6, apiclient.PoolUtilization{AvgCPUPercent: 20}, 3, "cpu"},
+		{"cpu scale down to min", cpu, 6, 6, apiclient.PoolUtilization{AvgCPUPercent: 1}, 2, "cpu"},
+		{"no scale down while members start", cpu, 6, 4, apiclient.PoolUtilization{AvgCPUPercent: 20}, 6, ""},
+		{"rps scale up", rps, 2, 2, apiclient.PoolUtilization{CombinedRPS: 450}, 5, "rps"},
+		{"rps scale down", rps, 4, 4, apiclient.PoolUtilization{CombinedRPS: 150}, 2, "rps"},
+		{"largest rule wins", both, 2, 2, apiclient.PoolUtilization{AvgCPUPercent: 10, CombinedRPS: 600}, 6, "rps"},
+		{"busy rule blocks scale down", both, 4, 4, apiclient.PoolUtilization{AvgCPUPercent: 50, CombinedRPS: 40}, 4, ""},
+		{"metric scale up", queue, 2, 2, apiclient.PoolUtilization{AvgCustomMetrics: map[string]float64{"queue_depth": 50}}, 5, "metric"},
+		{"metric scale down", queue, 4, 4, apiclient.PoolUtilization{AvgCustomMetrics: map[string]float64{"queue_depth": 5}}, 1, "metric"},
+		{"metric not reported", queue, 4, 4, apiclient.PoolUtilization{AvgCustomMetrics: map[string]float64{"other": 100}}, 4, ""},
+		{"missing metric blocks scale down", queueCPU, 4, 4, apiclient.PoolUtilization{AvgCPUPercent: 10}, 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := autoscaleTarget(tt.autoscale, tt.current, tt.alive, tt.utilization)
+			if got != tt.want || reason != tt.reason {
+				t.Errorf("got %d (%q), want %d (%q)", got, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestValidateAutoscale(t *testing.T) {
+	valid := []model.PoolAutoscale{
+		{},
+		{Enabled: true, MinCount: 1, MaxCount: 1, TargetCPUPercent: 60},
+		{Enabled: true, MinCount: 2, MaxCount: 8, TargetRPSPerMember: 50, ScaleUpCooldown: 60, ScaleDownCooldown: 300},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetMetric: "queue_depth", TargetMetricValue: 10},
+	}
+	for _, autoscale := range valid {
+		if err := validateAutoscale(&autoscale); err != nil {
+			t.Errorf("expected %+v to be valid: %v", autoscale, err)
+		}
+	}
+
+	invalid := []model.PoolAutoscale{
+		{Enabled: true, MinCount: 0, MaxCount: 4, TargetCPUPercent: 60},
+		{Enabled: true, MinCount: 4, MaxCount: 2, TargetCPUPercent: 60},
+		{Enabled: true, MinCount: 1, MaxCount: 4},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetCPUPercent: 150},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetRPSPerMember: -1},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetMetric: "queue_depth"},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetMetricValue: 10},
+		{Enabled: true, MinCount: 1, MaxCount: 4, TargetMetric: "queue_depth", TargetMetricValue: -5},
+	}
+	for _, autoscale := range invalid {
+		if err := validateAutoscale(&autoscale); err == nil {
+			t.Errorf("expected %+v to be rejected", autoscale)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"sync"
 	"testing"
 
@@ -21,7 +22,7 @@ type fakeContainer struct {
 
 func (c *fakeContainer) CreateVolume(*model.Volume) error { return nil }
 func (c *fakeContainer) DeleteVolume(*model.Volume) error { return nil }
-func (c *fakeContainer) StartSpace(space *model.Space, _ *model.Template, _ *model.User) error {
+func (c *fakeContainer) StartSpace(_ context.Context, space *model.Space, _ *model.Template, _ *model.User) error {
 	c.mu.Lock()
 	c.started = append(c.started, space.Id)
 	c.mu.Unlock()
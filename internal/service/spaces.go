@@ -21,7 +21,22 @@ type SpaceListOptions struct {
 	CheckZone      bool
 }
 
-var spaceService *SpaceService
+var (
+	spaceService            *SpaceService
+	spaceListChangedHandler func()
+)
+
+// SetSpaceListChangedHandler registers a function called when spaces are
+// created, renamed or deleted, e.g. to refresh the MCP resource list.
+func SetSpaceListChangedHandler(handler func()) {
+	spaceListChangedHandler = handler
+}
+
+func notifySpaceListChanged() {
+	if spaceListChangedHandler != nil {
+		spaceListChangedHandler()
+	}
+}
 
 func GetSpaceService() *SpaceService {
 	if spaceService == nil {
@@ -146,6 +161,7 @@ func (s *SpaceService) CreateSpace(space *model.Space, user *model.User) error {
 		transport.GossipSpace(space)
 	}
 	sse.PublishSpaceChanged(space.Id, space.UserId)
+	notifySpaceListChanged()
 
 	return nil
 }
@@ -201,6 +217,9 @@ func (s *SpaceService) UpdateSpace(space *model.Space, user *model.User) error {
 		transport.GossipSpace(space)
 	}
 	sse.PublishSpaceChanged(space.Id, space.UserId)
+	if space.Name != existing.Name {
+		notifySpaceListChanged()
+	}
 
 	return nil
 }
@@ -368,6 +387,7 @@ func (s *SpaceService) DeleteSpace(spaceId string, user *model.User) error {
 		transport.GossipSpace(space)
 	}
 	sse.PublishSpaceDeleted(space.Id, space.UserId)
+	notifySpaceListChanged()
 
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
 	"github.com/paularlott/knot/internal/sse"
 	"github.com/paularlott/knot/internal/util/validate"
 )
@@ -115,6 +116,11 @@ func (s *TemplateService) CreateTemplate(template *model.Template, user *model.U
 		}
 	}
 
+	// Validate idle policy
+	if err := s.validateIdlePolicy(&template.IdlePolicy); err != nil {
+		return err
+	}
+
 	// Validate groups exist
 	if err := s.validateGroups(template.Groups); err != nil {
 		return err
@@ -172,6 +178,11 @@ func (s *TemplateService) UpdateTemplate(template *model.Template, user *model.U
 		}
 	}
 
+	// Validate idle policy
+	if err := s.validateIdlePolicy(&template.IdlePolicy); err != nil {
+		return err
+	}
+
 	// Validate groups exist
 	if err := s.validateGroups(template.Groups); err != nil {
 		return err
@@ -192,6 +203,11 @@ func (s *TemplateService) UpdateTemplate(template *model.Template, user *model.U
 		agentHealthConfigUpdater(template)
 	}
 
+	// Push the keys again so the agents apply the new forwarding options
+	if existing.DisableSSHLocalForward != template.DisableSSHLocalForward || existing.WithSSHReverseForward != template.WithSSHReverseForward {
+		go updateTemplateSpaceSSHKeys(template)
+	}
+
 	// Gossip the template and notify SSE clients
 	GetTransport().GossipTemplate(template)
 	sse.PublishTemplatesChanged(template.Id)
@@ -199,6 +215,26 @@ func (s *TemplateService) UpdateTemplate(template *model.Template, user *model.U
 	return nil
 }
 
+func updateTemplateSpaceSSHKeys(template *model.Template) {
+	db := database.GetInstance()
+	spaces, err := db.GetSpacesByTemplateId(template.Id)
+	if err != nil {
+		log.WithError(err).Error("failed to get spaces for template", "template_id", template.Id)
+		return
+	}
+
+	for _, space := range spaces {
+		if space.IsDeleted {
+			continue
+		}
+		owner, err := db.GetUser(space.UserId)
+		if err != nil || owner == nil {
+			continue
+		}
+		GetUserService().UpdateSpaceSSHKeys(space, owner)
+	}
+}
+
 func templateHealthConfigChanged(a, b *model.Template) bool {
 	if a == nil || b == nil {
 		return false
@@ -289,7 +325,7 @@ func (s *TemplateService) validateTemplateInput(name, platform, job, volumes str
 		return fmt.Errorf("invalid template name given")
 	}
 
-	if !validate.OneOf(platform, []string{model.PlatformManual, model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer}) {
+	if !validate.OneOf(platform, []string{model.PlatformManual, model.PlatformDocker, model.PlatformPodman, model.PlatformNomad, model.PlatformApple, model.PlatformContainer, model.PlatformKubernetes}) {
 		return fmt.Errorf("invalid platform")
 	}
 
@@ -338,6 +374,27 @@ func (s *TemplateService) validateTemplateInput(name, platform, job, volumes str
 	return nil
 }
 
+// validateIdlePolicy validates the idle auto-stop settings of a template
+func (s *TemplateService) validateIdlePolicy(policy *model.TemplateIdlePolicy) error {
+	if !policy.Enabled {
+		return nil
+	}
+
+	if policy.Timeout == 0 {
+		return fmt.Errorf("idle timeout must be greater than 0 minutes")
+	}
+
+	if policy.CPUThreshold < 0 || policy.CPUThreshold > 100 {
+		return fmt.Errorf("idle CPU threshold must be between 0 and 100")
+	}
+
+	if policy.WarnBefore >= policy.Timeout {
+		return fmt.Errorf("idle warning must be less than the idle timeout")
+	}
+
+	return nil
+}
+
 // validateGroups validates that all provided group IDs exist
 func (s *TemplateService) validateGroups(groups []string) error {
 	db := database.GetInstance()
@@ -247,3 +247,29 @@ func TestValidateTemplateInputWithCustomFields(t *testing.T) {
 		t.Error("Invalid custom field name should error")
 	}
 }
+
+func TestValidateIdlePolicy(t *testing.T) {
+	service := GetTemplateService()
+
+	tests := []struct {
+		name    string
+		policy  model.TemplateIdlePolicy
+		wantErr bool
+	}{
+		{"disabled ignores values", model.TemplateIdlePolicy{Enabled: false, Timeout: 0, WarnBefore: 10}, false},
+		{"valid policy", model.TemplateIdlePolicy{Enabled: true, Timeout: 45, CPUThreshold: 5, WarnBefore: 10}, false},
+		{"zero timeout", model.TemplateIdlePolicy{Enabled: true, Timeout: 0}, true},
+		{"cpu threshold too high", model.TemplateIdlePolicy{Enabled: true, Timeout: 45, CPUThreshold: 101}, true},
+		{"negative cpu threshold", model.TemplateIdlePolicy{Enabled: true, Timeout: 45, CPUThreshold: -1}, true},
+		{"warning not before timeout", model.TemplateIdlePolicy{Enabled: true, Timeout: 45, WarnBefore: 45}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.validateIdlePolicy(&tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateIdlePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
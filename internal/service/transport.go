@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"sync"
 
 	"github.com/paularlott/gossip"
@@ -17,6 +18,7 @@ type Transport interface {
 	GossipToken(token *model.Token)
 	GossipVolume(volume *model.Volume)
 	GossipSpaceUsageSample(sample *model.SpaceUsageSample)
+	GossipLLMUsage(usage *model.LLMUsage)
 	GossipAuditLog(entry *model.AuditLogEntry)
 	GossipSession(session *model.Session)
 	GossipScript(script *model.Script)
@@ -36,8 +38,8 @@ type Transport interface {
 	GetTunnelServers() []string
 	IsLeader() bool
 
-	LockResource(resourceId string) string
-	UnlockResource(resourceId, unlockToken string)
+	LockResource(ctx context.Context, resourceId string) string
+	UnlockResource(ctx context.Context, resourceId, unlockToken string)
 
 	Nodes() []*gossip.Node
 	GetNodeByIDString(id string) *gossip.Node
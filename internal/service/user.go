@@ -15,6 +15,7 @@ type UserService interface {
 	UpdateUserSpaces(user *model.User)
 	UpdateSpacesSSHKey(user *model.User)
 	UpdateSpaceSSHKeys(space *model.Space, user *model.User)
+	UpdateRoleSSHKeys(roleId string)
 }
 
 var userService UserService
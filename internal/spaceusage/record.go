@@ -91,6 +91,7 @@ func buildSampleFromState(spaceId, userId, bucketKind string, now time.Time, sta
 	sample.MemoryLimitBytes = state.MemoryLimitBytes
 	sample.DiskUsedBytes = state.DiskUsedBytes
 	sample.DiskLimitBytes = state.DiskLimitBytes
+	sample.MergeCustomMetrics(state.CustomMetrics)
 	sample.UpdatedAt = hlc.Now()
 	return sample
 }
@@ -109,6 +110,7 @@ func rollupMinuteIntoDay(db database.DbDriver, spaceId, userId string, minuteBuc
 	daySample.MemoryLimitBytes = minuteSample.MemoryLimitBytes
 	daySample.DiskUsedBytes = minuteSample.DiskUsedBytes
 	daySample.DiskLimitBytes = minuteSample.DiskLimitBytes
+	daySample.MergeCustomMetrics(minuteSample.CustomMetrics)
 	daySample.UpdatedAt = hlc.Now()
 
 	return saveMergedSample(db, daySample)
@@ -137,6 +139,7 @@ func mergeLocalSpaceUsageSample(target, incoming *model.SpaceUsageSample) {
 	target.MemoryLimitBytes = maxUint64(target.MemoryLimitBytes, incoming.MemoryLimitBytes)
 	target.DiskUsedBytes = maxUint64(target.DiskUsedBytes, incoming.DiskUsedBytes)
 	target.DiskLimitBytes = maxUint64(target.DiskLimitBytes, incoming.DiskLimitBytes)
+	target.MergeCustomMetrics(incoming.CustomMetrics)
 }
 
 func maxFloat(left, right float64) float64 {
@@ -7,6 +7,7 @@ import (
 	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/container/apple"
 	"github.com/paularlott/knot/internal/container/docker"
+	"github.com/paularlott/knot/internal/container/kubernetes"
 	"github.com/paularlott/knot/internal/container/nomad"
 	"github.com/paularlott/knot/internal/container/podman"
 	"github.com/paularlott/knot/internal/container/runtime"
@@ -28,6 +29,12 @@ func NomadRuntimeKey(space *model.Space) string {
 	return NormalizeNomadNamespace(space.NomadNamespace) + "\x00" + space.ContainerId
 }
 
+// KubernetesRuntimeKey matches the keys returned by the kubernetes client,
+// pods are found by the space label rather than the workload name.
+func KubernetesRuntimeKey(space *model.Space) string {
+	return space.NomadNamespace + "\x00" + space.Id
+}
+
 func RuntimeRefRunning(space *model.Space, template *model.Template, refs map[string]bool) bool {
 	if template == nil || refs == nil {
 		return false
@@ -36,6 +43,8 @@ func RuntimeRefRunning(space *model.Space, template *model.Template, refs map[st
 	switch template.Platform {
 	case model.PlatformNomad:
 		return refs[NomadRuntimeKey(space)]
+	case model.PlatformKubernetes:
+		return refs[KubernetesRuntimeKey(space)]
 	default:
 		return refs[space.ContainerId]
 	}
@@ -80,6 +89,20 @@ func ListRunningRuntimeRefs(template *model.Template, spaces []*model.Space) (ma
 			return nil, err
 		}
 
+		namespaces := make([]string, 0, len(spaces))
+		for _, space := range spaces {
+			if space == nil {
+				continue
+			}
+			namespaces = append(namespaces, space.NomadNamespace)
+		}
+		return client.ListRunningSpaceRuntimeRefs(namespaces)
+	case model.PlatformKubernetes:
+		client, err := kubernetes.NewClient()
+		if err != nil {
+			return nil, err
+		}
+
 		namespaces := make([]string, 0, len(spaces))
 		for _, space := range spaces {
 			if space == nil {
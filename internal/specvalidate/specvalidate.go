@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/paularlott/knot/internal/container/kubernetes"
 	"github.com/paularlott/knot/internal/container/nomad"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/util"
@@ -38,6 +39,10 @@ func ValidateTemplateSpecWithNomadParser(platform, job, volumes string, parseNom
 		issues := validateNomadJob(job, parseNomadJob)
 		issues = append(issues, validateNomadVolumes("volumes", volumes, false)...)
 		return issues
+	case model.PlatformKubernetes:
+		issues := validateKubernetesManifest(job)
+		issues = append(issues, validateKubernetesVolumes("volumes", volumes, false)...)
+		return issues
 	default:
 		return []Issue{{Field: "platform", Message: "unsupported platform"}}
 	}
@@ -49,6 +54,8 @@ func ValidateVolumeSpec(platform, definition string) []Issue {
 		return validateLocalVolumeDefinitions("definition", definition, true)
 	case model.PlatformNomad:
 		return validateNomadVolumes("definition", definition, true)
+	case model.PlatformKubernetes:
+		return validateKubernetesVolumes("definition", definition, true)
 	default:
 		return []Issue{{Field: "platform", Message: "unsupported platform"}}
 	}
@@ -91,6 +98,28 @@ func validateNomadJob(job string, parseNomadJob NomadJobParser) []Issue {
 	return nil
 }
 
+func validateKubernetesManifest(job string) []Issue {
+	if strings.TrimSpace(job) == "" {
+		return []Issue{{Field: "job", Message: "manifest is required"}}
+	}
+
+	// Resolve template variables first as they can appear anywhere in the
+	// manifest, falling back to the raw manifest if resolution fails.
+	resolved := job
+	func() {
+		defer func() { recover() }()
+		if r, err := model.ResolveVariables(job, &model.Template{}, nil, nil, nil); err == nil {
+			resolved = r
+		}
+	}()
+
+	if err := kubernetes.ValidateManifest(resolved); err != nil {
+		return []Issue{{Field: "job", Line: lineFromError(err), Message: cleanYAMLError(err.Error())}}
+	}
+
+	return nil
+}
+
 // validateLocalContainerJob validates a local container (docker/podman/apple)
 // spec by walking the decoded YAML node tree so every issue can carry the line
 // of the offending content.
@@ -258,6 +287,53 @@ func validateNomadVolumes(field, volumes string, requireSingle bool) []Issue {
 	return issues
 }
 
+// kubernetesAccessModes are the access modes a persistent volume claim accepts.
+var kubernetesAccessModes = map[string]bool{
+	"ReadWriteOnce":    true,
+	"ReadOnlyMany":     true,
+	"ReadWriteMany":    true,
+	"ReadWriteOncePod": true,
+}
+
+func validateKubernetesVolumes(field, volumes string, requireSingle bool) []Issue {
+	if strings.TrimSpace(volumes) == "" {
+		if requireSingle {
+			return []Issue{{Field: field, Message: "volume definition is required"}}
+		}
+		return nil
+	}
+
+	var spec model.KubernetesVolumes
+	if err := decodeYAMLStrict(volumes, &spec); err != nil {
+		return []Issue{{Field: field, Line: lineFromError(err), Message: cleanYAMLError(err.Error())}}
+	}
+
+	if requireSingle && len(spec.Volumes) != 1 {
+		return []Issue{{Field: field, Message: "volume definition must contain exactly 1 volume"}}
+	}
+	if len(spec.Volumes) == 0 {
+		return []Issue{{Field: field, Message: "definition must contain at least 1 volume"}}
+	}
+
+	var issues []Issue
+	for _, volume := range spec.Volumes {
+		if strings.TrimSpace(volume.Name) == "" {
+			issues = append(issues, Issue{Field: field, Message: "volume name must be set"})
+			continue
+		}
+		if strings.TrimSpace(volume.Size) == "" {
+			issues = append(issues, Issue{Field: field, Message: fmt.Sprintf("volume %q must set a size", volume.Name)})
+		}
+		for _, mode := range volume.AccessModes {
+			if !kubernetesAccessModes[mode] {
+				issues = append(issues, Issue{Field: field, Message: fmt.Sprintf("volume %q has unsupported access mode %q", volume.Name, mode)})
+			}
+		}
+	}
+
+	return issues
+}
+
 func decodeYAMLStrict(data string, target interface{}) error {
 	decoder := yaml.NewDecoder(strings.NewReader(data))
 	decoder.KnownFields(true)
@@ -312,6 +312,52 @@ volumes:
 	}
 }
 
+func TestValidateTemplateSpecKubernetes(t *testing.T) {
+	issues := ValidateTemplateSpec(model.PlatformKubernetes, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: ${{ .space.name }}
+spec:
+  containers:
+    - name: space
+      image: ubuntu:24.04
+`, "")
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+
+	issues = ValidateTemplateSpec(model.PlatformKubernetes, `
+kind: Deployment
+metadata:
+  name: dev
+`, "")
+	if !containsIssue(issues, 0, "unsupported kind") {
+		t.Fatalf("expected unsupported kind issue, got %+v", issues)
+	}
+}
+
+func TestValidateVolumeSpecKubernetes(t *testing.T) {
+	issues := ValidateVolumeSpec(model.PlatformKubernetes, `
+volumes:
+  - name: data
+    size: 10Gi
+    access_modes: [ReadWriteMany]
+`)
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+
+	issues = ValidateVolumeSpec(model.PlatformKubernetes, `
+volumes:
+  - name: data
+    access_modes: [ReadWriteSometimes]
+`)
+	if !containsIssue(issues, 0, "size") || !containsIssue(issues, 0, "ReadWriteSometimes") {
+		t.Fatalf("expected size and access mode issues, got %+v", issues)
+	}
+}
+
 func TestValidateVolumeSpecAppleWithSize(t *testing.T) {
 	issues := ValidateVolumeSpec(model.PlatformApple, `
 volumes:
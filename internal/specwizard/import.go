@@ -0,0 +1,933 @@
+package specwizard
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+	"gopkg.in/yaml.v3"
+)
+
+// ---------------------------------------------------------------------------
+// devcontainer.json / docker-compose import
+// ---------------------------------------------------------------------------
+
+// Import formats accepted by Import. The native formats (container YAML,
+// Nomad HCL, Kubernetes manifests) go through Parse instead.
+const (
+	ImportFormatDevcontainer = "devcontainer"
+	ImportFormatCompose      = "compose"
+)
+
+// Defaults for names the source formats don't carry. Every space gets its own
+// container so a fixed name from the source would clash between spaces.
+const (
+	importDefaultName     = "${{ .user.username }}-${{ .space.name }}"
+	importDefaultHostname = "${{ .space.name }}"
+)
+
+// composeVariable matches compose/devcontainer ${VAR} style interpolation,
+// knot's own ${{ ... }} variables are left alone.
+var composeVariable = regexp.MustCompile(`\$\{[^{}]+\}`)
+
+// importer accumulates the spec, startup commands and the report while a
+// source document is converted.
+type importer struct {
+	spec     *apiclient.UnifiedSpec
+	report   *apiclient.SpecImportReport
+	commands []string
+	volumes  map[string]bool
+}
+
+func newImporter(format string) *importer {
+	return &importer{
+		spec: &apiclient.UnifiedSpec{
+			Name:     importDefaultName,
+			Hostname: importDefaultHostname,
+		},
+		report:  &apiclient.SpecImportReport{Format: format},
+		volumes: make(map[string]bool),
+	}
+}
+
+// Import converts a devcontainer.json or compose file into a UnifiedSpec.
+// Commands run after the container is created are collected into a startup
+// script, and everything that couldn't be mapped is listed in the report.
+// service selects the compose service to import and may be empty when the
+// file defines a single service.
+func Import(format, data, service string) (*apiclient.UnifiedSpec, *apiclient.SpecImportReport, error) {
+	var im *importer
+	var err error
+
+	switch format {
+	case ImportFormatDevcontainer:
+		im, err = importDevcontainer(data)
+	case ImportFormatCompose:
+		im, err = importCompose(data, service)
+	default:
+		return nil, nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	im.report.StartupScript = buildStartupScript(format, im.commands)
+	return im.spec, im.report, nil
+}
+
+func (im *importer) unmapped(field, format string, args ...interface{}) {
+	im.report.Unmapped = append(im.report.Unmapped, apiclient.SpecImportIssue{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkVariables reports source variables that knot won't interpolate.
+func (im *importer) checkVariables(field, value string) {
+	for _, v := range composeVariable.FindAllString(value, -1) {
+		if strings.HasPrefix(v, "${{") {
+			continue
+		}
+		im.unmapped(field, "variable %s is not interpolated by knot, replace it with a value or a ${{ .var.* }} variable", v)
+	}
+}
+
+func (im *importer) addEnv(field, key, value string) {
+	if key == "" {
+		return
+	}
+	im.checkVariables(field+"."+key, value)
+	for i, kv := range im.spec.Environment {
+		if kv.Key == key {
+			im.spec.Environment[i].Value = value
+			return
+		}
+	}
+	im.spec.Environment = append(im.spec.Environment, apiclient.KeyValue{Key: key, Value: value})
+}
+
+func (im *importer) addCapabilities(caps []string, drop bool) {
+	caps = NormaliseCapabilities(caps)
+	if drop {
+		im.spec.CapDrop = NormaliseCapabilities(append(im.spec.CapDrop, caps...))
+	} else {
+		im.spec.CapAdd = NormaliseCapabilities(append(im.spec.CapAdd, caps...))
+	}
+}
+
+// addMount adds a bind mount or named volume. Named volumes also get a Volume
+// Definition entry so knot creates them per space.
+func (im *importer) addMount(field, mountType, source, target string, readOnly bool) {
+	if target == "" {
+		im.unmapped(field, "mount has no target")
+		return
+	}
+
+	switch mountType {
+	case "bind":
+		if source == "" || strings.Contains(source, "${") || !strings.HasPrefix(source, "/") {
+			im.unmapped(field, "bind mount of %q can't be mapped, knot spaces don't have access to the local workspace", source)
+			return
+		}
+		im.spec.Storage = append(im.spec.Storage, apiclient.StorageEntry{
+			Kind:          "bind",
+			HostPath:      source,
+			ContainerPath: target,
+			ReadOnly:      readOnly,
+		})
+
+	case "volume", "":
+		if source == "" {
+			im.unmapped(field, "anonymous volume at %s is not supported, give the volume a name", target)
+			return
+		}
+		im.checkVariables(field, source)
+		im.spec.Storage = append(im.spec.Storage, apiclient.StorageEntry{
+			Kind:          "volume",
+			Name:          source,
+			ContainerPath: target,
+			ReadOnly:      readOnly,
+		})
+		im.volumes[source] = true
+
+	default:
+		im.unmapped(field, "mount type %q is not supported", mountType)
+	}
+}
+
+// addCommand adds a lifecycle command to the startup script. Commands are
+// strings run by the shell, exec form arrays, or objects of named commands
+// that run one after the other.
+func (im *importer) addCommand(field string, command interface{}) {
+	switch c := command.(type) {
+	case nil:
+	case string:
+		if strings.TrimSpace(c) != "" {
+			im.commands = append(im.commands, c)
+		}
+	case []interface{}:
+		args := make([]string, 0, len(c))
+		for _, arg := range c {
+			args = append(args, shellQuote(fmt.Sprint(arg)))
+		}
+		if len(args) > 0 {
+			im.commands = append(im.commands, strings.Join(args, " "))
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(c))
+		for name := range c {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			im.addCommand(field+"."+name, c[name])
+		}
+	default:
+		im.unmapped(field, "unsupported command format")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// devcontainer.json
+// ---------------------------------------------------------------------------
+
+// devcontainerFields are the devcontainer.json properties the importer maps,
+// anything else is reported.
+var devcontainerFields = map[string]bool{
+	"name":                 true,
+	"image":                true,
+	"build":                true,
+	"containerEnv":         true,
+	"remoteEnv":            true,
+	"forwardPorts":         true,
+	"portsAttributes":      true,
+	"appPort":              true,
+	"mounts":               true,
+	"capAdd":               true,
+	"privileged":           true,
+	"runArgs":              true,
+	"hostRequirements":     true,
+	"onCreateCommand":      true,
+	"updateContentCommand": true,
+	"postCreateCommand":    true,
+	"postStartCommand":     true,
+	"$schema":              true,
+}
+
+func importDevcontainer(data string) (*importer, error) {
+	var dc map[string]interface{}
+	if err := json.Unmarshal([]byte(stripJSONC(data)), &dc); err != nil {
+		return nil, fmt.Errorf("devcontainer.json parse failed: %w", err)
+	}
+
+	im := newImporter(ImportFormatDevcontainer)
+	im.report.Name, _ = dc["name"].(string)
+
+	if _, ok := dc["dockerComposeFile"]; ok {
+		im.unmapped("dockerComposeFile", "the container is defined by a compose file, import the compose file with the compose format instead")
+	}
+
+	im.spec.Image, _ = dc["image"].(string)
+	if build, ok := dc["build"].(map[string]interface{}); ok {
+		im.importBuild("build", build)
+	}
+
+	for _, field := range []string{"containerEnv", "remoteEnv"} {
+		env, _ := dc[field].(map[string]interface{})
+		for _, key := range sortedKeys(env) {
+			im.addEnv(field, key, fmt.Sprint(env[key]))
+		}
+	}
+
+	// Forwarded ports are reached through knot, they don't need publishing
+	attributes, _ := dc["portsAttributes"].(map[string]interface{})
+	forward, _ := dc["forwardPorts"].([]interface{})
+	for _, p := range forward {
+		port, err := strconv.Atoi(fmt.Sprint(p))
+		if err != nil || port < 1 || port > 65535 {
+			im.unmapped("forwardPorts", "port %v can't be mapped, only ports of the space container are supported", p)
+			continue
+		}
+		label := ""
+		if attr, ok := attributes[strconv.Itoa(port)].(map[string]interface{}); ok {
+			label, _ = attr["label"].(string)
+		}
+		if label == "" {
+			label = "port-" + strconv.Itoa(port)
+		}
+		im.report.Ports = append(im.report.Ports, model.TemplatePort{Name: label, Port: uint16(port), Protocol: "http"})
+	}
+
+	appPorts := dc["appPort"]
+	if _, ok := appPorts.([]interface{}); !ok && appPorts != nil {
+		appPorts = []interface{}{appPorts}
+	}
+	for _, p := range asSlice(appPorts) {
+		im.addPort("appPort", fmt.Sprint(p))
+	}
+
+	for _, m := range asSlice(dc["mounts"]) {
+		switch mount := m.(type) {
+		case string:
+			options := parseMountOptions(mount)
+			im.addMount("mounts", options["type"], firstOf(options, "source", "src"), firstOf(options, "target", "dst", "destination"), options["readonly"] == "true" || options["ro"] == "true")
+		case map[string]interface{}:
+			mountType, _ := mount["type"].(string)
+			source, _ := mount["source"].(string)
+			target, _ := mount["target"].(string)
+			im.addMount("mounts", mountType, source, target, false)
+		}
+	}
+
+	im.addCapabilities(asStrings(dc["capAdd"]), false)
+	if privileged, ok := dc["privileged"].(bool); ok {
+		im.spec.Privileged = privileged
+	}
+	im.importRunArgs(asStrings(dc["runArgs"]))
+
+	if req, ok := dc["hostRequirements"].(map[string]interface{}); ok {
+		if cpus, ok := req["cpus"]; ok {
+			im.spec.CPUs = fmt.Sprint(cpus)
+		}
+		if memory, ok := req["memory"].(string); ok {
+			im.spec.Memory = normaliseMemory(memory)
+		}
+		for _, key := range sortedKeys(req) {
+			if key != "cpus" && key != "memory" {
+				im.unmapped("hostRequirements."+key, "host requirement %q is not supported", key)
+			}
+		}
+	}
+
+	for _, field := range []string{"onCreateCommand", "updateContentCommand", "postCreateCommand", "postStartCommand"} {
+		im.addCommand(field, dc[field])
+	}
+
+	if features, ok := dc["features"].(map[string]interface{}); ok {
+		for _, feature := range sortedKeys(features) {
+			im.unmapped("features", "feature %s is not installed, add it to the image instead", feature)
+		}
+	}
+	for _, key := range sortedKeys(dc) {
+		if !devcontainerFields[key] && key != "features" && key != "dockerComposeFile" {
+			im.unmapped(key, "property is not supported")
+		}
+	}
+
+	if im.spec.Image == "" {
+		im.unmapped("image", "no image is set, knot doesn't build images so build and push the image then set it in the template")
+	}
+
+	return im, nil
+}
+
+// importBuild maps a build section, knot runs prebuilt images so only the
+// build args carry over, as environment variables.
+func (im *importer) importBuild(field string, build map[string]interface{}) {
+	dockerfile, _ := build["dockerfile"].(string)
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if im.spec.Image == "" {
+		im.unmapped(field, "the image is built from %s, knot doesn't build images so build and push it then set the image", dockerfile)
+	}
+
+	args := build["args"]
+	if list, ok := args.([]interface{}); ok {
+		// compose allows args as a KEY=value list
+		m := make(map[string]interface{})
+		for _, item := range list {
+			key, value, _ := strings.Cut(fmt.Sprint(item), "=")
+			m[key] = value
+		}
+		args = m
+	}
+	if m, ok := args.(map[string]interface{}); ok {
+		for _, key := range sortedKeys(m) {
+			im.addEnv(field+".args", key, fmt.Sprint(m[key]))
+		}
+		if len(m) > 0 {
+			im.unmapped(field+".args", "build args were added as environment variables")
+		}
+	}
+}
+
+// importRunArgs maps the docker run flags the spec can represent.
+func (im *importer) importRunArgs(args []string) {
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		takeValue := func() string {
+			if hasValue {
+				return value
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+
+		switch flag {
+		case "--privileged":
+			im.spec.Privileged = true
+		case "--cap-add":
+			im.addCapabilities([]string{takeValue()}, false)
+		case "--cap-drop":
+			im.addCapabilities([]string{takeValue()}, true)
+		case "-e", "--env":
+			key, value, _ := strings.Cut(takeValue(), "=")
+			im.addEnv("runArgs", key, value)
+		case "-p", "--publish":
+			im.addPort("runArgs", takeValue())
+		case "--device":
+			if hc := parseHostContainerStrings([]string{takeValue()}); len(hc) > 0 {
+				im.spec.Devices = append(im.spec.Devices, hc...)
+			}
+		case "--add-host":
+			if h := parseHostIPStrings([]string{takeValue()}); len(h) > 0 {
+				im.spec.ExtraHosts = append(im.spec.ExtraHosts, h...)
+			}
+		case "--dns":
+			im.spec.DNS = append(im.spec.DNS, takeValue())
+		case "--dns-search":
+			im.spec.DNSSearch = append(im.spec.DNSSearch, takeValue())
+		case "-h", "--hostname":
+			im.spec.Hostname = takeValue()
+		case "-m", "--memory":
+			im.spec.Memory = normaliseMemory(takeValue())
+		case "--cpus":
+			im.spec.CPUs = takeValue()
+		case "--network", "--net":
+			im.spec.Network = takeValue()
+		default:
+			im.unmapped("runArgs", "run argument %s is not supported", args[i])
+		}
+	}
+}
+
+func (im *importer) addPort(field, port string) {
+	pm, ok := parsePortMapping(port)
+	if !ok {
+		im.unmapped(field, "port %q can't be mapped", port)
+		return
+	}
+	im.spec.Ports = append(im.spec.Ports, pm)
+}
+
+// stripJSONC removes the comments and trailing commas that devcontainer.json
+// allows but encoding/json doesn't. Comments go first so a comment between a
+// trailing comma and the closing bracket doesn't hide the comma.
+func stripJSONC(data string) string {
+	return stripTrailingCommas(stripComments(data))
+}
+
+func stripComments(data string) string {
+	var out strings.Builder
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(data[i+2:], "*/")
+			if end == -1 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+func stripTrailingCommas(data string) string {
+	var out strings.Builder
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			out.WriteByte(c)
+		case ',':
+			// Drop the comma if the next token closes the object or array
+			j := i + 1
+			for j < len(data) && strings.ContainsRune(" \t\r\n", rune(data[j])) {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+// parseMountOptions parses the docker --mount string form,
+// e.g. source=cache,target=/cache,type=volume.
+func parseMountOptions(mount string) map[string]string {
+	options := make(map[string]string)
+	for _, part := range strings.Split(mount, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(part), "=")
+		if !hasValue {
+			value = "true"
+		}
+		options[strings.ToLower(key)] = value
+	}
+	return options
+}
+
+// ---------------------------------------------------------------------------
+// docker-compose
+// ---------------------------------------------------------------------------
+
+// composeServiceFields are the service keys the importer maps, anything else
+// is reported.
+var composeServiceFields = map[string]bool{
+	"image":          true,
+	"build":          true,
+	"container_name": true,
+	"hostname":       true,
+	"command":        true,
+	"environment":    true,
+	"ports":          true,
+	"volumes":        true,
+	"cap_add":        true,
+	"cap_drop":       true,
+	"privileged":     true,
+	"devices":        true,
+	"extra_hosts":    true,
+	"dns":            true,
+	"dns_search":     true,
+	"network_mode":   true,
+	"mem_limit":      true,
+	"cpus":           true,
+	"deploy":         true,
+	"post_start":     true,
+}
+
+func importCompose(data, service string) (*importer, error) {
+	var compose struct {
+		Services map[string]map[string]interface{} `yaml:"services"`
+		Volumes  map[string]interface{}            `yaml:"volumes"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &compose); err != nil {
+		return nil, fmt.Errorf("compose file parse failed: %w", err)
+	}
+	if len(compose.Services) == 0 {
+		return nil, fmt.Errorf("compose file has no services")
+	}
+
+	names := sortedKeys(compose.Services)
+	if service == "" {
+		if len(names) != 1 {
+			return nil, fmt.Errorf("compose file has several services, choose one of: %s", strings.Join(names, ", "))
+		}
+		service = names[0]
+	}
+	svc, ok := compose.Services[service]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found, choose one of: %s", service, strings.Join(names, ", "))
+	}
+
+	im := newImporter(ImportFormatCompose)
+	im.report.Name = service
+
+	for _, name := range names {
+		if name != service {
+			im.unmapped("services."+name, "only service %s is imported, create another template for this service", service)
+		}
+	}
+
+	im.spec.Image, _ = svc["image"].(string)
+	im.checkVariables("image", im.spec.Image)
+	switch build := svc["build"].(type) {
+	case string:
+		im.importBuild("build", map[string]interface{}{})
+	case map[string]interface{}:
+		im.importBuild("build", build)
+	}
+
+	if _, ok := svc["container_name"]; ok {
+		im.unmapped("container_name", "knot names the container after the space")
+	}
+	if hostname, ok := svc["hostname"].(string); ok {
+		im.spec.Hostname = hostname
+	}
+
+	switch command := svc["command"].(type) {
+	case string:
+		im.spec.Command = splitCommandLine(command)
+	case []interface{}:
+		im.spec.Command = asStrings(command)
+	}
+
+	switch env := svc["environment"].(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(env) {
+			value := ""
+			if env[key] != nil {
+				value = fmt.Sprint(env[key])
+			}
+			im.addEnv("environment", key, value)
+		}
+	case []interface{}:
+		for _, item := range asStrings(env) {
+			key, value, _ := strings.Cut(item, "=")
+			im.addEnv("environment", key, value)
+		}
+	}
+
+	for _, p := range asSlice(svc["ports"]) {
+		switch port := p.(type) {
+		case map[string]interface{}:
+			target, _ := strconv.Atoi(fmt.Sprint(port["target"]))
+			published, _ := strconv.Atoi(fmt.Sprint(port["published"]))
+			if published == 0 {
+				published = target
+			}
+			protocol, _ := port["protocol"].(string)
+			im.addPort("ports", fmt.Sprintf("%d:%d/%s", published, target, firstNonEmpty(protocol, "tcp")))
+		default:
+			im.addComposePort(fmt.Sprint(port))
+		}
+	}
+
+	for _, v := range asSlice(svc["volumes"]) {
+		switch volume := v.(type) {
+		case string:
+			im.addComposeVolume(volume)
+		case map[string]interface{}:
+			mountType, _ := volume["type"].(string)
+			source, _ := volume["source"].(string)
+			target, _ := volume["target"].(string)
+			readOnly, _ := volume["read_only"].(bool)
+			im.addMount("volumes", mountType, source, target, readOnly)
+		}
+	}
+	for _, name := range sortedKeys(compose.Volumes) {
+		if !im.volumes[name] {
+			im.unmapped("volumes."+name, "volume is not used by service %s", service)
+		}
+		if def, ok := compose.Volumes[name].(map[string]interface{}); ok && len(def) > 0 {
+			im.unmapped("volumes."+name, "volume options are not supported, the volume is created with the platform defaults")
+		}
+	}
+
+	im.addCapabilities(asStrings(svc["cap_add"]), false)
+	im.addCapabilities(asStrings(svc["cap_drop"]), true)
+	if privileged, ok := svc["privileged"].(bool); ok {
+		im.spec.Privileged = privileged
+	}
+	if devices := parseHostContainerStrings(asStrings(svc["devices"])); len(devices) > 0 {
+		im.spec.Devices = devices
+	}
+
+	switch hosts := svc["extra_hosts"].(type) {
+	case map[string]interface{}:
+		for _, host := range sortedKeys(hosts) {
+			im.spec.ExtraHosts = append(im.spec.ExtraHosts, apiclient.HostIP{Hostname: host, IP: fmt.Sprint(hosts[host])})
+		}
+	case []interface{}:
+		for _, entry := range asStrings(hosts) {
+			host, ip, _ := strings.Cut(strings.Replace(entry, "=", ":", 1), ":")
+			im.spec.ExtraHosts = append(im.spec.ExtraHosts, apiclient.HostIP{Hostname: host, IP: ip})
+		}
+	}
+
+	im.spec.DNS = asStrings(svc["dns"])
+	im.spec.DNSSearch = asStrings(svc["dns_search"])
+
+	if networkMode, ok := svc["network_mode"].(string); ok {
+		if networkMode == "host" || networkMode == "bridge" || networkMode == "none" {
+			im.spec.Network = networkMode
+		} else {
+			im.unmapped("network_mode", "network mode %q is not supported", networkMode)
+		}
+	}
+
+	if memory, ok := svc["mem_limit"]; ok {
+		im.spec.Memory = normaliseMemory(fmt.Sprint(memory))
+	}
+	if cpus, ok := svc["cpus"]; ok {
+		im.spec.CPUs = fmt.Sprint(cpus)
+	}
+	if deploy, ok := svc["deploy"].(map[string]interface{}); ok {
+		limits, _ := mapAt(deploy, "resources", "limits")
+		if memory, ok := limits["memory"]; ok {
+			im.spec.Memory = normaliseMemory(fmt.Sprint(memory))
+		}
+		if cpus, ok := limits["cpus"]; ok {
+			im.spec.CPUs = fmt.Sprint(cpus)
+		}
+		for _, key := range sortedKeys(deploy) {
+			if key != "resources" {
+				im.unmapped("deploy."+key, "deploy option is not supported")
+			}
+		}
+	}
+
+	for _, hook := range asSlice(svc["post_start"]) {
+		if h, ok := hook.(map[string]interface{}); ok {
+			im.addCommand("post_start", h["command"])
+		}
+	}
+
+	for _, key := range sortedKeys(svc) {
+		if !composeServiceFields[key] {
+			im.unmapped(key, "service option is not supported")
+		}
+	}
+
+	if im.spec.Image == "" && svc["build"] == nil {
+		im.unmapped("image", "no image is set")
+	}
+
+	return im, nil
+}
+
+// addComposePort maps the short port syntax, [ip:]host:container[/protocol].
+func (im *importer) addComposePort(port string) {
+	im.checkVariables("ports", port)
+	if strings.Contains(port, "-") {
+		im.unmapped("ports", "port range %q is not supported", port)
+		return
+	}
+
+	mapping, protocol, _ := strings.Cut(port, "/")
+	parts := strings.Split(mapping, ":")
+	if len(parts) == 3 {
+		// Drop the host IP, knot binds to all interfaces
+		im.unmapped("ports", "host IP of %q is ignored", port)
+		parts = parts[1:]
+	}
+	mapping = strings.Join(parts, ":")
+	if protocol != "" {
+		mapping += "/" + protocol
+	}
+	im.addPort("ports", mapping)
+}
+
+// addComposeVolume maps the short volume syntax, [source:]target[:mode].
+func (im *importer) addComposeVolume(volume string) {
+	parts := strings.Split(volume, ":")
+	if len(parts) == 1 {
+		im.addMount("volumes", "volume", "", parts[0], false)
+		return
+	}
+
+	source, target := parts[0], parts[1]
+	readOnly := len(parts) > 2 && strings.Contains(parts[2], "ro")
+
+	mountType := "volume"
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") || strings.HasPrefix(source, "$") {
+		mountType = "bind"
+	}
+	im.addMount("volumes", mountType, source, target, readOnly)
+}
+
+// ---------------------------------------------------------------------------
+// Startup script
+// ---------------------------------------------------------------------------
+
+// buildStartupScript renders the lifecycle commands as a scriptling startup
+// script, empty if there are none.
+func buildStartupScript(format string, commands []string) string {
+	if len(commands) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Startup script imported from %s.\n", format)
+	b.WriteString("# Startup scripts run every time the space starts, so commands must be safe to repeat.\n")
+	b.WriteString("import subprocess\n\n")
+	b.WriteString("commands = [\n")
+	for _, command := range commands {
+		fmt.Fprintf(&b, "    %s,\n", strconv.Quote(command))
+	}
+	b.WriteString("]\n\n")
+	b.WriteString("for command in commands:\n")
+	b.WriteString("    print(\"Running: \" + command)\n")
+	b.WriteString("    result = subprocess.run([\"sh\", \"-c\", command])\n")
+	b.WriteString("    if result.returncode != 0:\n")
+	b.WriteString("        raise Exception(\"command failed with exit code \" + str(result.returncode) + \": \" + command)\n")
+
+	return b.String()
+}
+
+// splitCommandLine splits a command string into arguments, honouring single
+// and double quotes and backslash escapes.
+func splitCommandLine(s string) []string {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				current.WriteByte(s[i])
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			current.WriteByte(s[i])
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
+// shellQuote quotes an argument for sh when it contains special characters.
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+,", r))
+	}) == -1 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// normaliseMemory converts docker style memory sizes (512m, 2gb) to the
+// 512M / 2G form used by the container specs.
+func normaliseMemory(memory string) string {
+	memory = strings.TrimSpace(memory)
+	lower := strings.ToLower(memory)
+	for _, suffix := range []string{"b", "ib"} {
+		if trimmed, ok := strings.CutSuffix(lower, suffix); ok && len(trimmed) > 0 && strings.ContainsAny(trimmed[len(trimmed)-1:], "kmgt") {
+			lower = trimmed
+			break
+		}
+	}
+	if lower != "" && strings.ContainsAny(lower[len(lower)-1:], "kmgt") {
+		return lower[:len(lower)-1] + strings.ToUpper(lower[len(lower)-1:])
+	}
+	return memory
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func asSlice(v interface{}) []interface{} {
+	if s, ok := v.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+// asStrings accepts a single string or a list.
+func asStrings(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	}
+	return nil
+}
+
+func mapAt(m map[string]interface{}, path ...string) (map[string]interface{}, bool) {
+	for _, key := range path {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		m = next
+	}
+	return m, true
+}
+
+func firstOf(m map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := m[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
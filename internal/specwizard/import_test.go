@@ -0,0 +1,234 @@
+package specwizard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+const testDevcontainer = `{
+	// Comments and trailing commas are allowed
+	"name": "go-dev",
+	"image": "mcr.microsoft.com/devcontainers/go:1.22",
+	"containerEnv": { "GOFLAGS": "-mod=mod", "TOKEN": "${localEnv:TOKEN}" },
+	"forwardPorts": [8080, "db:5432"],
+	"portsAttributes": { "8080": { "label": "web" } },
+	"mounts": [
+		"source=go-cache,target=/go/pkg,type=volume",
+		"source=${localWorkspaceFolder}/.cache,target=/cache,type=bind",
+	],
+	"capAdd": ["SYS_PTRACE"],
+	"runArgs": ["--cap-add=NET_ADMIN", "--memory", "4g", "--security-opt", "seccomp=unconfined"],
+	"hostRequirements": { "cpus": 2 },
+	"postCreateCommand": "go mod download",
+	"postStartCommand": { "tidy": ["go", "mod", "tidy"], "echo": "echo started" },
+	"features": { "ghcr.io/devcontainers/features/node:1": {} },
+	"customizations": { "vscode": {} },
+}`
+
+func TestImportDevcontainer(t *testing.T) {
+	spec, report, err := Import(ImportFormatDevcontainer, testDevcontainer, "")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	if spec.Image != "mcr.microsoft.com/devcontainers/go:1.22" || spec.Name != importDefaultName {
+		t.Errorf("unexpected image/name: %q %q", spec.Image, spec.Name)
+	}
+	if len(spec.Environment) != 2 || spec.Environment[0].Key != "GOFLAGS" {
+		t.Errorf("unexpected environment: %+v", spec.Environment)
+	}
+	if !reflect.DeepEqual(spec.CapAdd, []string{"CAP_SYS_PTRACE", "CAP_NET_ADMIN"}) {
+		t.Errorf("unexpected capabilities: %v", spec.CapAdd)
+	}
+	if spec.Memory != "4G" || spec.CPUs != "2" {
+		t.Errorf("unexpected resources: memory=%q cpus=%q", spec.Memory, spec.CPUs)
+	}
+	if len(spec.Storage) != 1 || spec.Storage[0].Kind != "volume" || spec.Storage[0].Name != "go-cache" || spec.Storage[0].ContainerPath != "/go/pkg" {
+		t.Errorf("unexpected storage: %+v", spec.Storage)
+	}
+
+	if report.Name != "go-dev" {
+		t.Errorf("unexpected name: %q", report.Name)
+	}
+	if !reflect.DeepEqual(report.Ports, []model.TemplatePort{{Name: "web", Port: 8080, Protocol: "http"}}) {
+		t.Errorf("unexpected ports: %+v", report.Ports)
+	}
+
+	// Object commands run in name order
+	for _, want := range []string{`"go mod download",`, `"echo started",`, `"go mod tidy",`, "import subprocess"} {
+		if !strings.Contains(report.StartupScript, want) {
+			t.Errorf("expected %q in startup script:\n%s", want, report.StartupScript)
+		}
+	}
+	if strings.Index(report.StartupScript, "echo started") > strings.Index(report.StartupScript, "go mod tidy") {
+		t.Errorf("commands out of order:\n%s", report.StartupScript)
+	}
+
+	fields := map[string]bool{}
+	for _, issue := range report.Unmapped {
+		fields[issue.Field] = true
+	}
+	for _, want := range []string{"containerEnv.TOKEN", "forwardPorts", "mounts", "runArgs", "features", "customizations"} {
+		if !fields[want] {
+			t.Errorf("expected %s to be reported, got %+v", want, report.Unmapped)
+		}
+	}
+}
+
+func TestImportDevcontainer_build(t *testing.T) {
+	data := `{"build": {"dockerfile": "Dockerfile", "args": {"VARIANT": "3.12"}}}`
+	spec, report, err := Import(ImportFormatDevcontainer, data, "")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(spec.Environment) != 1 || spec.Environment[0].Key != "VARIANT" {
+		t.Errorf("expected build args as environment, got %+v", spec.Environment)
+	}
+	if report.StartupScript != "" {
+		t.Errorf("expected no startup script, got:\n%s", report.StartupScript)
+	}
+	if len(report.Unmapped) == 0 || report.Unmapped[0].Field != "build" {
+		t.Errorf("expected the build to be reported, got %+v", report.Unmapped)
+	}
+}
+
+const testCompose = `services:
+  app:
+    image: node:20
+    container_name: app
+    command: npm run "dev server"
+    environment:
+      - NODE_ENV=development
+      - API_URL=${API_URL}
+    ports:
+      - "3000:3000"
+      - target: 9229
+        published: 9230
+    volumes:
+      - node_modules:/app/node_modules
+      - /srv/data:/data:ro
+      - ./src:/app/src
+    cap_add: [SYS_ADMIN]
+    extra_hosts:
+      - "db.local:10.0.0.5"
+    mem_limit: 2gb
+    deploy:
+      resources:
+        limits:
+          cpus: "1.5"
+    post_start:
+      - command: npm install
+    healthcheck:
+      test: ["CMD", "true"]
+  db:
+    image: postgres:16
+volumes:
+  node_modules:
+`
+
+func TestImportCompose(t *testing.T) {
+	spec, report, err := Import(ImportFormatCompose, testCompose, "app")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	if spec.Image != "node:20" || spec.Name != importDefaultName {
+		t.Errorf("unexpected image/name: %q %q", spec.Image, spec.Name)
+	}
+	if !reflect.DeepEqual(spec.Command, []string{"npm", "run", "dev server"}) {
+		t.Errorf("unexpected command: %q", spec.Command)
+	}
+	if len(spec.Environment) != 2 {
+		t.Errorf("unexpected environment: %+v", spec.Environment)
+	}
+	if len(spec.Ports) != 2 || spec.Ports[1].HostPort != 9230 || spec.Ports[1].ContainerPort != 9229 {
+		t.Errorf("unexpected ports: %+v", spec.Ports)
+	}
+	if len(spec.Storage) != 2 {
+		t.Fatalf("unexpected storage: %+v", spec.Storage)
+	}
+	if s := spec.Storage[0]; s.Kind != "volume" || s.Name != "node_modules" {
+		t.Errorf("unexpected volume: %+v", s)
+	}
+	if s := spec.Storage[1]; s.Kind != "bind" || s.HostPath != "/srv/data" || !s.ReadOnly {
+		t.Errorf("unexpected bind: %+v", s)
+	}
+	if len(spec.ExtraHosts) != 1 || spec.ExtraHosts[0].IP != "10.0.0.5" {
+		t.Errorf("unexpected extra hosts: %+v", spec.ExtraHosts)
+	}
+	if spec.Memory != "2G" || spec.CPUs != "1.5" {
+		t.Errorf("unexpected resources: memory=%q cpus=%q", spec.Memory, spec.CPUs)
+	}
+	if !strings.Contains(report.StartupScript, `"npm install",`) {
+		t.Errorf("expected post_start in startup script:\n%s", report.StartupScript)
+	}
+
+	fields := map[string]bool{}
+	for _, issue := range report.Unmapped {
+		fields[issue.Field] = true
+	}
+	for _, want := range []string{"services.db", "container_name", "environment.API_URL", "volumes", "healthcheck"} {
+		if !fields[want] {
+			t.Errorf("expected %s to be reported, got %+v", want, report.Unmapped)
+		}
+	}
+
+	// The result builds into a container spec
+	job, volumes, err := BuildContainerYAML(spec, "", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if !strings.Contains(job, "image: node:20") || !strings.Contains(volumes, "node_modules") {
+		t.Errorf("unexpected build output:\n%s\n%s", job, volumes)
+	}
+}
+
+func TestImportCompose_serviceSelection(t *testing.T) {
+	if _, _, err := Import(ImportFormatCompose, testCompose, ""); err == nil || !strings.Contains(err.Error(), "app, db") {
+		t.Errorf("expected an error listing the services, got %v", err)
+	}
+	if _, _, err := Import(ImportFormatCompose, testCompose, "web"); err == nil {
+		t.Error("expected an error for a missing service")
+	}
+
+	_, report, err := Import(ImportFormatCompose, "services:\n  only:\n    image: alpine\n", "")
+	if err != nil || report.Name != "only" {
+		t.Errorf("expected the single service to be chosen, got %v %+v", err, report)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"sleep infinity", []string{"sleep", "infinity"}},
+		{`sh -c "echo 'hi there'"`, []string{"sh", "-c", "echo 'hi there'"}},
+		{`a\ b ''`, []string{"a b", ""}},
+	}
+	for _, tt := range tests {
+		if got := splitCommandLine(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"{\"image\": \"x\", // note\n}", "{\"image\": \"x\" \n}"},
+		{`{"a": [1, /* c */ ]}`, `{"a": [1  ]}`},
+		{`{"a": [1, 2,], "b": {"c": 1,},}`, `{"a": [1, 2], "b": {"c": 1}}`},
+		{`{"url": "http://x/*y*/", "s": ",]"}`, `{"url": "http://x/*y*/", "s": ",]"}`},
+	}
+	for _, tt := range tests {
+		if got := stripJSONC(tt.in); got != tt.want {
+			t.Errorf("stripJSONC(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,713 @@
+package specwizard
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+	"gopkg.in/yaml.v3"
+)
+
+// ---------------------------------------------------------------------------
+// Kubernetes manifest YAML
+// ---------------------------------------------------------------------------
+
+// kubernetesSkeleton is the manifest the wizard starts from when the template
+// has no job yet.
+const kubernetesSkeleton = `apiVersion: v1
+kind: Pod
+metadata:
+  name: ${{ .user.username }}-${{ .space.name }}
+spec:
+  containers:
+    - name: space
+`
+
+// kubernetesDocument is one document of a multi-document manifest. separator
+// holds the "---" line that introduced it (if any) and body the original text,
+// so documents the wizard doesn't edit are written back byte-for-byte.
+type kubernetesDocument struct {
+	separator string
+	body      string
+	node      *yaml.Node
+}
+
+// kubernetesMount is a volumeMounts entry backed by a persistent volume claim.
+type kubernetesMount struct {
+	claim    string
+	path     string
+	readOnly bool
+}
+
+// ParseKubernetesYAML converts a Kubernetes space manifest into UnifiedSpec.
+// Only the workload (the single Pod or StatefulSet) is read; other documents
+// in the manifest are left to the raw editor. wizardable is false when the
+// manifest can't be decoded, uses anchors, or the workload runs more than one
+// container.
+func ParseKubernetesYAML(job, volumes string) (spec *apiclient.UnifiedSpec, wizardable bool, reason string) {
+	defs, reason := parseKubernetesVolumeDefinitions(volumes)
+	if reason != "" {
+		return nil, false, reason
+	}
+
+	if strings.TrimSpace(job) == "" {
+		return &apiclient.UnifiedSpec{Storage: kubernetesStorage(nil, defs)}, true, ""
+	}
+
+	_, workload, reason, err := inspectKubernetesYAML(job)
+	if err != nil {
+		return nil, false, fmt.Sprintf("Kubernetes manifest parse failed: %s", err.Error())
+	}
+	if reason != "" {
+		return nil, false, reason
+	}
+	if workload == nil {
+		// Comments only — treat as a fresh spec.
+		return &apiclient.UnifiedSpec{Storage: kubernetesStorage(nil, defs)}, true, ""
+	}
+
+	root := workload.node.Content[0]
+	podSpec := kubernetesPodSpec(root, false)
+	c := kubernetesContainer(podSpec)
+	if c == nil {
+		return nil, false, "Kubernetes workload must run exactly one container (not editable via wizard)"
+	}
+
+	spec = &apiclient.UnifiedSpec{
+		Name:     nodeScalar(nodeGet(root, "metadata"), "name"),
+		Image:    nodeScalar(c, "image"),
+		Hostname: nodeScalar(podSpec, "hostname"),
+		Command:  nodeStrings(nodeGet(c, "command")),
+	}
+
+	if env := nodeGet(c, "env"); env != nil && env.Kind == yaml.SequenceNode {
+		for _, item := range env.Content {
+			// Variables from secrets or config maps stay in the raw manifest
+			if nodeGet(item, "valueFrom") != nil {
+				continue
+			}
+			spec.Environment = append(spec.Environment, apiclient.KeyValue{
+				Key:   nodeScalar(item, "name"),
+				Value: nodeScalar(item, "value"),
+			})
+		}
+	}
+
+	if ports := nodeGet(c, "ports"); ports != nil && ports.Kind == yaml.SequenceNode {
+		for _, item := range ports.Content {
+			containerPort, _ := strconv.Atoi(nodeScalar(item, "containerPort"))
+			hostPort, _ := strconv.Atoi(nodeScalar(item, "hostPort"))
+			spec.Ports = append(spec.Ports, apiclient.PortMapping{
+				HostPort:      hostPort,
+				ContainerPort: containerPort,
+				Protocol:      strings.ToLower(nodeScalar(item, "protocol")),
+				Label:         nodeScalar(item, "name"),
+			})
+		}
+	}
+
+	if sc := nodeGet(c, "securityContext"); sc != nil {
+		spec.Privileged = nodeScalar(sc, "privileged") == "true"
+		caps := nodeGet(sc, "capabilities")
+		spec.CapAdd = NormaliseCapabilities(nodeStrings(nodeGet(caps, "add")))
+		spec.CapDrop = NormaliseCapabilities(nodeStrings(nodeGet(caps, "drop")))
+	}
+
+	if resources := nodeGet(c, "resources"); resources != nil {
+		spec.Memory = nodeScalar(nodeGet(resources, "requests"), "memory")
+		spec.CPUs = nodeScalar(nodeGet(resources, "requests"), "cpu")
+		spec.MemoryMax = nodeScalar(nodeGet(resources, "limits"), "memory")
+	}
+
+	if dnsConfig := nodeGet(podSpec, "dnsConfig"); dnsConfig != nil {
+		spec.DNS = nodeStrings(nodeGet(dnsConfig, "nameservers"))
+		spec.DNSSearch = nodeStrings(nodeGet(dnsConfig, "searches"))
+	}
+
+	if aliases := nodeGet(podSpec, "hostAliases"); aliases != nil && aliases.Kind == yaml.SequenceNode {
+		for _, alias := range aliases.Content {
+			ip := nodeScalar(alias, "ip")
+			for _, hostname := range nodeStrings(nodeGet(alias, "hostnames")) {
+				spec.ExtraHosts = append(spec.ExtraHosts, apiclient.HostIP{Hostname: hostname, IP: ip})
+			}
+		}
+	}
+
+	claims := kubernetesClaimVolumes(podSpec)
+	var mounts []kubernetesMount
+	if volumeMounts := nodeGet(c, "volumeMounts"); volumeMounts != nil && volumeMounts.Kind == yaml.SequenceNode {
+		for _, item := range volumeMounts.Content {
+			claim, ok := claims[nodeScalar(item, "name")]
+			if !ok {
+				continue
+			}
+			mounts = append(mounts, kubernetesMount{
+				claim:    claim,
+				path:     nodeScalar(item, "mountPath"),
+				readOnly: nodeScalar(item, "readOnly") == "true",
+			})
+		}
+	}
+	spec.Storage = kubernetesStorage(mounts, defs)
+
+	return spec, true, ""
+}
+
+// BuildKubernetesYAML patches the wizard's fields into the workload of the
+// original manifest, other documents are preserved as written. A fresh Pod
+// manifest is generated when there is no original.
+func BuildKubernetesYAML(spec *apiclient.UnifiedSpec, originalJob, originalVolumes string) (job, volumes string, err error) {
+	if spec == nil {
+		return "", "", fmt.Errorf("nil spec")
+	}
+
+	docs, workload, reason, inspectErr := inspectKubernetesYAML(originalJob)
+	switch {
+	case strings.TrimSpace(originalJob) == "" || inspectErr != nil:
+		// Nothing worth preserving — start from the skeleton.
+		docs, workload = nil, nil
+	case reason != "":
+		return "", "", fmt.Errorf("%s", reason)
+	case workload == nil && hasKubernetesObjects(docs):
+		return "", "", fmt.Errorf("Kubernetes manifest has no Pod or StatefulSet")
+	}
+
+	if workload == nil {
+		// Inject the default knot env vars so the agent can reach the server
+		fresh := *spec
+		fresh.Environment = append(defaultKnotEnv(), spec.Environment...)
+		spec = &fresh
+
+		docs, workload, _, err = inspectKubernetesYAML(kubernetesSkeleton)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := patchKubernetesWorkload(workload.node.Content[0], spec); err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(workload.node); err != nil {
+		return "", "", fmt.Errorf("encode Kubernetes manifest: %w", err)
+	}
+	enc.Close()
+	workload.body = buf.String()
+
+	var out strings.Builder
+	for _, doc := range docs {
+		out.WriteString(doc.separator)
+		out.WriteString(doc.body)
+	}
+
+	return out.String(), buildKubernetesVolumeDefinitions(spec.Storage, originalVolumes), nil
+}
+
+// inspectKubernetesYAML splits the manifest into its documents and finds the
+// workload. reason is set when the manifest can't be edited by the wizard.
+func inspectKubernetesYAML(job string) (docs []*kubernetesDocument, workload *kubernetesDocument, reason string, err error) {
+	doc := &kubernetesDocument{}
+	for _, line := range strings.SplitAfter(job, "\n") {
+		trimmed := strings.TrimRight(line, " \t\r\n")
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") {
+			docs = append(docs, doc)
+			doc = &kubernetesDocument{separator: line}
+			continue
+		}
+		doc.body += line
+	}
+	docs = append(docs, doc)
+
+	for _, doc := range docs {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(doc.body), &node); err != nil {
+			return nil, nil, "", err
+		}
+		if node.Kind != yaml.DocumentNode || len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		doc.node = &node
+
+		kind := nodeScalar(node.Content[0], "kind")
+		if kind != "Pod" && kind != "StatefulSet" {
+			continue
+		}
+		if workload != nil {
+			return nil, nil, "Kubernetes manifest holds more than one Pod or StatefulSet (not editable via wizard)", nil
+		}
+		if hasAnchorsOrAliases(node.Content[0]) {
+			return nil, nil, "Kubernetes manifest uses anchors or aliases (not editable via wizard)", nil
+		}
+		workload = doc
+	}
+
+	return docs, workload, "", nil
+}
+
+func hasKubernetesObjects(docs []*kubernetesDocument) bool {
+	for _, doc := range docs {
+		if doc.node != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func patchKubernetesWorkload(root *yaml.Node, spec *apiclient.UnifiedSpec) error {
+	if spec.Name != "" {
+		nodeSetScalar(nodeEnsureMap(root, "metadata"), "name", spec.Name)
+	}
+
+	podSpec := kubernetesPodSpec(root, true)
+	nodeSetScalar(podSpec, "hostname", spec.Hostname)
+
+	containers := nodeGet(podSpec, "containers")
+	if containers == nil || containers.Kind != yaml.SequenceNode {
+		containers = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		nodeSet(podSpec, "containers", containers)
+	}
+	if len(containers.Content) == 0 {
+		c := newMappingNode()
+		appendMappingEntry(c, "name", scalarStr("space"))
+		containers.Content = append(containers.Content, c)
+	}
+	if len(containers.Content) != 1 {
+		return fmt.Errorf("Kubernetes workload must run exactly one container")
+	}
+	c := containers.Content[0]
+
+	nodeSetScalar(c, "image", spec.Image)
+	nodeSetSequence(c, "command", spec.Command)
+
+	// Environment, keeping variables sourced from secrets or config maps
+	env := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, kv := range spec.Environment {
+		if kv.Key == "" {
+			continue
+		}
+		item := newMappingNode()
+		appendMappingEntry(item, "name", scalarStr(kv.Key))
+		appendMappingEntry(item, "value", scalarStr(kv.Value))
+		env.Content = append(env.Content, item)
+	}
+	if existing := nodeGet(c, "env"); existing != nil && existing.Kind == yaml.SequenceNode {
+		for _, item := range existing.Content {
+			if nodeGet(item, "valueFrom") != nil {
+				env.Content = append(env.Content, item)
+			}
+		}
+	}
+	nodeSetOrDelete(c, "env", env)
+
+	ports := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, p := range spec.Ports {
+		item := newMappingNode()
+		if p.Label != "" {
+			appendMappingEntry(item, "name", scalarStr(p.Label))
+		}
+		appendMappingEntry(item, "containerPort", scalarNumeric(strconv.Itoa(p.ContainerPort)))
+		if p.HostPort != 0 {
+			appendMappingEntry(item, "hostPort", scalarNumeric(strconv.Itoa(p.HostPort)))
+		}
+		if p.Protocol != "" && p.Protocol != "tcp" {
+			appendMappingEntry(item, "protocol", scalarStr(strings.ToUpper(p.Protocol)))
+		}
+		ports.Content = append(ports.Content, item)
+	}
+	nodeSetOrDelete(c, "ports", ports)
+
+	// Security context
+	sc := nodeEnsureMap(c, "securityContext")
+	if spec.Privileged {
+		nodeSet(sc, "privileged", scalarBool(true))
+	} else {
+		nodeDelete(sc, "privileged")
+	}
+	caps := nodeEnsureMap(sc, "capabilities")
+	nodeSetSequence(caps, "add", kubernetesCapabilities(spec.CapAdd))
+	nodeSetSequence(caps, "drop", kubernetesCapabilities(spec.CapDrop))
+	nodeDeleteIfEmpty(sc, "capabilities")
+	nodeDeleteIfEmpty(c, "securityContext")
+
+	// Resources
+	resources := nodeEnsureMap(c, "resources")
+	requests := nodeEnsureMap(resources, "requests")
+	nodeSetScalar(requests, "cpu", spec.CPUs)
+	nodeSetScalar(requests, "memory", spec.Memory)
+	nodeSetScalar(nodeEnsureMap(resources, "limits"), "memory", spec.MemoryMax)
+	nodeDeleteIfEmpty(resources, "requests")
+	nodeDeleteIfEmpty(resources, "limits")
+	nodeDeleteIfEmpty(c, "resources")
+
+	// DNS
+	dnsConfig := nodeEnsureMap(podSpec, "dnsConfig")
+	nodeSetSequence(dnsConfig, "nameservers", spec.DNS)
+	nodeSetSequence(dnsConfig, "searches", spec.DNSSearch)
+	nodeDeleteIfEmpty(podSpec, "dnsConfig")
+
+	// Host aliases, grouped by IP in the order first seen
+	aliases := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	byIP := map[string]*yaml.Node{}
+	for _, h := range spec.ExtraHosts {
+		if h.Hostname == "" || h.IP == "" {
+			continue
+		}
+		hostnames, ok := byIP[h.IP]
+		if !ok {
+			item := newMappingNode()
+			hostnames = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			appendMappingEntry(item, "ip", scalarStr(h.IP))
+			appendMappingEntry(item, "hostnames", hostnames)
+			aliases.Content = append(aliases.Content, item)
+			byIP[h.IP] = hostnames
+		}
+		hostnames.Content = append(hostnames.Content, scalarStr(h.Hostname))
+	}
+	nodeSetOrDelete(podSpec, "hostAliases", aliases)
+
+	patchKubernetesStorage(podSpec, c, spec.Storage)
+
+	return nil
+}
+
+// patchKubernetesStorage replaces the claim backed volumes and their mounts,
+// other volumes such as emptyDir or configMap are kept. Existing pod volume
+// names are reused so references elsewhere in the manifest stay valid.
+func patchKubernetesStorage(podSpec, c *yaml.Node, entries []apiclient.StorageEntry) {
+	claims := kubernetesClaimVolumes(podSpec)
+	volumeNames := make(map[string]string, len(claims))
+	for name, claim := range claims {
+		volumeNames[claim] = name
+	}
+
+	volumes := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if existing := nodeGet(podSpec, "volumes"); existing != nil && existing.Kind == yaml.SequenceNode {
+		for _, item := range existing.Content {
+			if nodeGet(item, "persistentVolumeClaim") == nil {
+				volumes.Content = append(volumes.Content, item)
+			}
+		}
+	}
+
+	mounts := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if existing := nodeGet(c, "volumeMounts"); existing != nil && existing.Kind == yaml.SequenceNode {
+		for _, item := range existing.Content {
+			if _, ok := claims[nodeScalar(item, "name")]; !ok {
+				mounts.Content = append(mounts.Content, item)
+			}
+		}
+	}
+
+	added := map[string]bool{}
+	for _, e := range entries {
+		claim := e.Name
+		if e.Kind == "bind" {
+			claim = e.HostPath
+		}
+		if (e.Kind != "volume" && e.Kind != "bind") || claim == "" || e.ContainerPath == "" {
+			continue
+		}
+
+		name, ok := volumeNames[claim]
+		if !ok {
+			name = fmt.Sprintf("volume-%d", len(volumeNames)+1)
+			volumeNames[claim] = name
+		}
+
+		if !added[claim] {
+			added[claim] = true
+			pvc := newMappingNode()
+			appendMappingEntry(pvc, "claimName", scalarStr(claim))
+			item := newMappingNode()
+			appendMappingEntry(item, "name", scalarStr(name))
+			appendMappingEntry(item, "persistentVolumeClaim", pvc)
+			volumes.Content = append(volumes.Content, item)
+		}
+
+		mount := newMappingNode()
+		appendMappingEntry(mount, "name", scalarStr(name))
+		appendMappingEntry(mount, "mountPath", scalarStr(e.ContainerPath))
+		if e.ReadOnly {
+			appendMappingEntry(mount, "readOnly", scalarBool(true))
+		}
+		mounts.Content = append(mounts.Content, mount)
+	}
+
+	nodeSetOrDelete(podSpec, "volumes", volumes)
+	nodeSetOrDelete(c, "volumeMounts", mounts)
+}
+
+// parseKubernetesVolumeDefinitions decodes the Volume Definition YAML.
+func parseKubernetesVolumeDefinitions(volumes string) ([]model.KubernetesVolume, string) {
+	if strings.TrimSpace(volumes) == "" {
+		return nil, ""
+	}
+
+	var defs model.KubernetesVolumes
+	if err := yaml.Unmarshal([]byte(volumes), &defs); err != nil {
+		return nil, fmt.Sprintf("volume definition parse failed: %s", err.Error())
+	}
+	return defs.Volumes, ""
+}
+
+// kubernetesStorage merges the claim mounts with the volume definitions into
+// the wizard's Storage rows. Mounts of claims not defined by the template
+// become "bind" entries referring to the existing claim.
+func kubernetesStorage(mounts []kubernetesMount, defs []model.KubernetesVolume) []apiclient.StorageEntry {
+	var entries []apiclient.StorageEntry
+
+	defined := map[string]bool{}
+	for _, def := range defs {
+		defined[def.Name] = true
+
+		entry := apiclient.StorageEntry{
+			Kind:      "volume",
+			Name:      def.Name,
+			Size:      def.Size,
+			Namespace: def.Namespace,
+		}
+		for _, mode := range def.AccessModes {
+			entry.AccessModes = append(entry.AccessModes, apiclient.VolumeCapability{AccessMode: mode})
+		}
+
+		mounted := false
+		for _, m := range mounts {
+			if m.claim != def.Name {
+				continue
+			}
+			e := entry
+			e.ContainerPath = m.path
+			e.ReadOnly = m.readOnly
+			entries = append(entries, e)
+			mounted = true
+		}
+		if !mounted {
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, m := range mounts {
+		if defined[m.claim] {
+			continue
+		}
+		entries = append(entries, apiclient.StorageEntry{
+			Kind:          "bind",
+			HostPath:      m.claim,
+			ContainerPath: m.path,
+			ReadOnly:      m.readOnly,
+		})
+	}
+
+	return entries
+}
+
+// buildKubernetesVolumeDefinitions renders the "volume" entries as the Volume
+// Definition YAML, keeping the storage class of volumes already defined.
+func buildKubernetesVolumeDefinitions(entries []apiclient.StorageEntry, originalVolumes string) string {
+	original, _ := parseKubernetesVolumeDefinitions(originalVolumes)
+	storageClasses := map[string]string{}
+	for _, def := range original {
+		storageClasses[def.Name] = def.StorageClass
+	}
+
+	var defs model.KubernetesVolumes
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.Kind != "volume" || e.Name == "" || seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+
+		def := model.KubernetesVolume{
+			Name:         e.Name,
+			Namespace:    e.Namespace,
+			StorageClass: storageClasses[e.Name],
+			Size:         e.Size,
+		}
+		for _, mode := range e.AccessModes {
+			if mode.AccessMode != "" {
+				def.AccessModes = append(def.AccessModes, mode.AccessMode)
+			}
+		}
+		defs.Volumes = append(defs.Volumes, def)
+	}
+
+	if len(defs.Volumes) == 0 {
+		return ""
+	}
+
+	out, err := yaml.Marshal(defs)
+	if err != nil {
+		return originalVolumes
+	}
+	return string(out)
+}
+
+// kubernetesPodSpec returns the pod spec of a Pod or StatefulSet, creating
+// the path to it when create is set.
+func kubernetesPodSpec(root *yaml.Node, create bool) *yaml.Node {
+	path := []string{"spec"}
+	if nodeScalar(root, "kind") == "StatefulSet" {
+		path = []string{"spec", "template", "spec"}
+	}
+
+	node := root
+	for _, key := range path {
+		if create {
+			node = nodeEnsureMap(node, key)
+		} else if node = nodeGet(node, key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// kubernetesContainer returns the only container of the pod, nil if there
+// isn't exactly one.
+func kubernetesContainer(podSpec *yaml.Node) *yaml.Node {
+	containers := nodeGet(podSpec, "containers")
+	if containers == nil || containers.Kind != yaml.SequenceNode || len(containers.Content) != 1 {
+		return nil
+	}
+	if containers.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return containers.Content[0]
+}
+
+// kubernetesClaimVolumes maps the pod volume names backed by a persistent
+// volume claim to the claim name.
+func kubernetesClaimVolumes(podSpec *yaml.Node) map[string]string {
+	claims := map[string]string{}
+	volumes := nodeGet(podSpec, "volumes")
+	if volumes == nil || volumes.Kind != yaml.SequenceNode {
+		return claims
+	}
+	for _, item := range volumes.Content {
+		if pvc := nodeGet(item, "persistentVolumeClaim"); pvc != nil {
+			claims[nodeScalar(item, "name")] = nodeScalar(pvc, "claimName")
+		}
+	}
+	return claims
+}
+
+// kubernetesCapabilities converts capabilities to the upper case form
+// without the CAP_ prefix that Kubernetes uses.
+func kubernetesCapabilities(in []string) []string {
+	var out []string
+	for _, c := range NormaliseCapabilities(in) {
+		out = append(out, strings.ToUpper(capabilityBareName(c)))
+	}
+	return out
+}
+
+// --- yaml.Node mapping helpers ---
+
+func newMappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// nodeGet returns the value for key in a mapping node, nil if missing.
+func nodeGet(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func nodeScalar(mapping *yaml.Node, key string) string {
+	if value := nodeGet(mapping, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+func nodeStrings(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var out []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			out = append(out, item.Value)
+		}
+	}
+	return out
+}
+
+// nodeSet replaces the value for key, appending the key if missing.
+func nodeSet(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			// Keep any comment attached to the old value
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	appendMappingEntry(mapping, key, value)
+}
+
+func nodeDelete(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			removeMappingEntry(mapping, i)
+			return
+		}
+	}
+}
+
+// nodeEnsureMap returns the mapping under key, creating it if missing.
+func nodeEnsureMap(mapping *yaml.Node, key string) *yaml.Node {
+	if value := nodeGet(mapping, key); value != nil && value.Kind == yaml.MappingNode {
+		return value
+	}
+	value := newMappingNode()
+	nodeSet(mapping, key, value)
+	return value
+}
+
+// nodeSetScalar sets a string value, removing the key when value is empty.
+func nodeSetScalar(mapping *yaml.Node, key, value string) {
+	if value == "" {
+		nodeDelete(mapping, key)
+		return
+	}
+	if existing := nodeGet(mapping, key); existing != nil && existing.Kind == yaml.ScalarNode {
+		existing.Value = value
+		existing.Tag = "!!str"
+		return
+	}
+	nodeSet(mapping, key, scalarStr(value))
+}
+
+func nodeSetSequence(mapping *yaml.Node, key string, items []string) {
+	nodeSetOrDelete(mapping, key, sequenceNode(items))
+}
+
+// nodeSetOrDelete sets a sequence value, removing the key when it's empty.
+func nodeSetOrDelete(mapping *yaml.Node, key string, value *yaml.Node) {
+	if len(value.Content) == 0 {
+		nodeDelete(mapping, key)
+		return
+	}
+	nodeSet(mapping, key, value)
+}
+
+// nodeDeleteIfEmpty removes key when it holds an empty mapping.
+func nodeDeleteIfEmpty(mapping *yaml.Node, key string) {
+	if value := nodeGet(mapping, key); value != nil && value.Kind == yaml.MappingNode && len(value.Content) == 0 {
+		nodeDelete(mapping, key)
+	}
+}
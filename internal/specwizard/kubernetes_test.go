@@ -0,0 +1,168 @@
+package specwizard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+const kubernetesTestManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: dev
+spec:
+  hostname: dev
+  containers:
+    - name: space
+      image: ubuntu:24.04 # pinned
+      command: ["/bin/sh", "-c"]
+      env:
+        - name: FOO
+          value: bar
+        - name: SECRET
+          valueFrom:
+            secretKeyRef:
+              name: creds
+              key: password
+      ports:
+        - name: web
+          containerPort: 8080
+      securityContext:
+        capabilities:
+          add: ["NET_ADMIN"]
+      resources:
+        requests:
+          cpu: 500m
+          memory: 1Gi
+      volumeMounts:
+        - name: home
+          mountPath: /home/user
+        - name: shared
+          mountPath: /shared
+          readOnly: true
+  volumes:
+    - name: home
+      persistentVolumeClaim:
+        claimName: dev-home
+    - name: shared
+      persistentVolumeClaim:
+        claimName: team-shared
+`
+
+const kubernetesTestVolumes = `volumes:
+  - name: dev-home
+    storage_class: fast
+    size: 10Gi
+`
+
+func TestParseKubernetesYAML(t *testing.T) {
+	spec, wizardable, reason := ParseKubernetesYAML(kubernetesTestManifest, kubernetesTestVolumes)
+	if !wizardable {
+		t.Fatalf("expected wizardable, got %q", reason)
+	}
+
+	if spec.Name != "dev" || spec.Image != "ubuntu:24.04" || spec.Hostname != "dev" {
+		t.Errorf("unexpected name/image/hostname: %q %q %q", spec.Name, spec.Image, spec.Hostname)
+	}
+	if len(spec.Environment) != 1 || spec.Environment[0].Key != "FOO" {
+		t.Errorf("expected only FOO in environment, got %+v", spec.Environment)
+	}
+	if len(spec.Ports) != 1 || spec.Ports[0].ContainerPort != 8080 || spec.Ports[0].Label != "web" {
+		t.Errorf("unexpected ports: %+v", spec.Ports)
+	}
+	if len(spec.CapAdd) != 1 {
+		t.Errorf("unexpected cap_add: %+v", spec.CapAdd)
+	}
+	if spec.CPUs != "500m" || spec.Memory != "1Gi" {
+		t.Errorf("unexpected resources: cpu=%q memory=%q", spec.CPUs, spec.Memory)
+	}
+
+	if len(spec.Storage) != 2 {
+		t.Fatalf("expected 2 storage entries, got %+v", spec.Storage)
+	}
+	if s := spec.Storage[0]; s.Kind != "volume" || s.Name != "dev-home" || s.Size != "10Gi" || s.ContainerPath != "/home/user" {
+		t.Errorf("unexpected volume entry: %+v", s)
+	}
+	if s := spec.Storage[1]; s.Kind != "bind" || s.HostPath != "team-shared" || !s.ReadOnly {
+		t.Errorf("unexpected claim entry: %+v", s)
+	}
+}
+
+func TestParseKubernetesYAML_multipleContainers(t *testing.T) {
+	job := "kind: Pod\nmetadata:\n  name: dev\nspec:\n  containers:\n    - name: a\n    - name: b\n"
+	if _, wizardable, _ := ParseKubernetesYAML(job, ""); wizardable {
+		t.Error("expected a multi container pod to be refused")
+	}
+}
+
+func TestKubernetesYAMLRoundTrip(t *testing.T) {
+	spec, _, _ := ParseKubernetesYAML(kubernetesTestManifest, kubernetesTestVolumes)
+	spec.Image = "debian:12"
+	spec.Environment = append(spec.Environment, apiclient.KeyValue{Key: "NEW", Value: "1"})
+	spec.Storage[0].Size = "20Gi"
+
+	job, volumes, err := BuildKubernetesYAML(spec, kubernetesTestManifest, kubernetesTestVolumes)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	// The config map document is untouched
+	if !strings.HasPrefix(job, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  key: value\n---\n") {
+		t.Errorf("supporting document was not preserved:\n%s", job)
+	}
+	for _, want := range []string{"image: debian:12 # pinned", "name: NEW", "secretKeyRef", "claimName: dev-home", "claimName: team-shared"} {
+		if !strings.Contains(job, want) {
+			t.Errorf("expected %q in manifest:\n%s", want, job)
+		}
+	}
+
+	defs, err := model.LoadKubernetesVolumesFromYaml(volumes, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("volume definitions failed to parse: %v\n%s", err, volumes)
+	}
+	if len(defs.Volumes) != 1 || defs.Volumes[0].Size != "20Gi" || defs.Volumes[0].StorageClass != "fast" {
+		t.Errorf("unexpected volume definitions: %+v", defs.Volumes)
+	}
+
+	again, wizardable, reason := ParseKubernetesYAML(job, volumes)
+	if !wizardable {
+		t.Fatalf("rebuilt manifest not wizardable: %s", reason)
+	}
+	if again.Image != "debian:12" || len(again.Environment) != 2 || len(again.Storage) != 2 {
+		t.Errorf("round trip lost fields: %+v", again)
+	}
+}
+
+func TestBuildKubernetesYAML_fresh(t *testing.T) {
+	job, _, err := BuildKubernetesYAML(&apiclient.UnifiedSpec{Image: "ubuntu:24.04"}, "", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	for _, want := range []string{"kind: Pod", "image: ubuntu:24.04", "KNOT_SERVER"} {
+		if !strings.Contains(job, want) {
+			t.Errorf("expected %q in manifest:\n%s", want, job)
+		}
+	}
+}
+
+func TestCheckKubernetesRepresentable(t *testing.T) {
+	if fully, reason := CheckFullyRepresentable(model.PlatformKubernetes, kubernetesTestManifest, "", nil); fully {
+		t.Error("expected the config map and valueFrom to be reported")
+	} else if !strings.Contains(reason, "ConfigMap") || !strings.Contains(reason, "valueFrom") {
+		t.Errorf("unexpected reason: %s", reason)
+	}
+
+	job := "kind: Pod\nmetadata:\n  name: dev\nspec:\n  containers:\n    - name: space\n      image: ubuntu\n"
+	if fully, reason := CheckFullyRepresentable(model.PlatformKubernetes, job, "", nil); !fully {
+		t.Errorf("expected simple pod to be representable: %s", reason)
+	}
+}
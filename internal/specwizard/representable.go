@@ -1,6 +1,7 @@
 package specwizard
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/paularlott/knot/apiclient"
@@ -23,6 +24,8 @@ func CheckFullyRepresentable(platform, job, volumes string, spec *apiclient.Unif
 		return checkContainerRepresentable(job)
 	case platform == "nomad":
 		return checkNomadRepresentable(job)
+	case platform == "kubernetes":
+		return checkKubernetesRepresentable(job)
 	}
 	return true, ""
 }
@@ -201,3 +204,72 @@ func firstWord(s string) string {
 	}
 	return s
 }
+
+// kubernetesContainerFields and kubernetesPodFields are the keys the wizard
+// controls, anything else means the manifest has content the wizard can't show.
+var kubernetesContainerFields = map[string]bool{
+	"name":            true,
+	"image":           true,
+	"command":         true,
+	"env":             true,
+	"ports":           true,
+	"securityContext": true,
+	"resources":       true,
+	"volumeMounts":    true,
+}
+
+var kubernetesPodFields = map[string]bool{
+	"containers":  true,
+	"hostname":    true,
+	"dnsConfig":   true,
+	"hostAliases": true,
+	"volumes":     true,
+}
+
+func checkKubernetesRepresentable(job string) (bool, string) {
+	docs, workload, reason, err := inspectKubernetesYAML(job)
+	if err != nil || reason != "" || workload == nil {
+		return true, "" // handled by the wizardable check
+	}
+
+	var unknown []string
+	for _, doc := range docs {
+		if doc != workload && doc.node != nil {
+			unknown = append(unknown, fmt.Sprintf("%s %q", nodeScalar(doc.node.Content[0], "kind"), nodeScalar(nodeGet(doc.node.Content[0], "metadata"), "name")))
+		}
+	}
+
+	podSpec := kubernetesPodSpec(workload.node.Content[0], false)
+	for i := 0; podSpec != nil && i+1 < len(podSpec.Content); i += 2 {
+		if !kubernetesPodFields[podSpec.Content[i].Value] {
+			unknown = append(unknown, podSpec.Content[i].Value)
+		}
+	}
+
+	c := kubernetesContainer(podSpec)
+	for i := 0; c != nil && i+1 < len(c.Content); i += 2 {
+		if !kubernetesContainerFields[c.Content[i].Value] {
+			unknown = append(unknown, c.Content[i].Value)
+		}
+	}
+	if env := nodeGet(c, "env"); env != nil && env.Kind == yaml.SequenceNode {
+		for _, item := range env.Content {
+			if nodeGet(item, "valueFrom") != nil {
+				unknown = append(unknown, "env valueFrom")
+				break
+			}
+		}
+	}
+	if volumes := nodeGet(podSpec, "volumes"); volumes != nil && volumes.Kind == yaml.SequenceNode {
+		for _, item := range volumes.Content {
+			if nodeGet(item, "persistentVolumeClaim") == nil {
+				unknown = append(unknown, fmt.Sprintf("volume %q", nodeScalar(item, "name")))
+			}
+		}
+	}
+
+	if len(unknown) == 0 {
+		return true, ""
+	}
+	return false, "spec contains fields outside the wizard: " + strings.Join(unknown, ", ")
+}
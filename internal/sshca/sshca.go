@@ -0,0 +1,206 @@
+// Package sshca lets the server act as an SSH certificate authority, issuing
+// short lived user certificates bound to a knot user and space, and host
+// certificates for the spaces' SSH host keys.
+package sshca
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/util"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// clockSkew backdates certificates so they're accepted by spaces whose clock
+// runs a little behind the server.
+const clockSkew = 5 * time.Minute
+
+var (
+	ErrDisabled = errors.New("SSH certificate authority is not enabled")
+
+	mutex  sync.RWMutex
+	signer gossh.Signer
+	maxTTL time.Duration
+)
+
+// Init loads the CA key if the certificate authority is enabled. Without a key
+// file the key is derived from the encryption key so all servers in a cluster
+// share the same CA.
+func Init(cfg *config.ServerConfig) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	signer = nil
+	if !cfg.SSHCA.Enabled {
+		return nil
+	}
+
+	maxTTL = time.Duration(cfg.SSHCA.CertTTL) * time.Minute
+	if maxTTL <= 0 {
+		maxTTL = time.Hour
+	}
+
+	var err error
+	if cfg.SSHCA.KeyFile != "" {
+		var pemBytes []byte
+		if pemBytes, err = os.ReadFile(cfg.SSHCA.KeyFile); err != nil {
+			return fmt.Errorf("reading SSH CA key: %w", err)
+		}
+		if signer, err = gossh.ParsePrivateKey(pemBytes); err != nil {
+			return fmt.Errorf("parsing SSH CA key: %w", err)
+		}
+	} else {
+		if cfg.EncryptionKey == "" {
+			return fmt.Errorf("SSH CA requires a key file or an encryption key")
+		}
+		if signer, err = deriveSigner(cfg.EncryptionKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deriveSigner(encryptionKey string) (gossh.Signer, error) {
+	seed := sha256.Sum256([]byte("knot ssh ca\x00" + encryptionKey))
+	return gossh.NewSignerFromKey(ed25519.NewKeyFromSeed(seed[:]))
+}
+
+// Enabled returns true if the server is issuing certificates.
+func Enabled() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return signer != nil
+}
+
+// MaxTTL returns the longest lifetime of a user certificate.
+func MaxTTL() time.Duration {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return maxTTL
+}
+
+// PublicKey returns the CA public key in authorized_keys format, or "" if the
+// CA isn't enabled.
+func PublicKey() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if signer == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+// AuthorizedKey returns the authorized_keys line trusting user certificates
+// issued for the space, or "" if the CA isn't enabled.
+func AuthorizedKey(spaceId string) string {
+	publicKey := PublicKey()
+	if publicKey == "" {
+		return ""
+	}
+	return fmt.Sprintf(`cert-authority,principals="%s" %s knot-ca`, spaceId, publicKey)
+}
+
+// SignUserCert issues a certificate for publicKey allowing user to log in to
+// space, ttl is capped to the configured maximum.
+func SignUserCert(publicKey gossh.PublicKey, user *model.User, space *model.Space, ttl time.Duration) (*gossh.Certificate, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if signer == nil {
+		return nil, ErrDisabled
+	}
+	if _, ok := publicKey.(*gossh.Certificate); ok {
+		return nil, fmt.Errorf("public key is already a certificate")
+	}
+	if ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	now := time.Now()
+	cert := &gossh.Certificate{
+		Key:             publicKey,
+		Serial:          serial(),
+		CertType:        gossh.UserCert,
+		KeyId:           fmt.Sprintf("knot:%s:%s", user.Username, space.Name),
+		ValidPrincipals: []string{space.Id},
+		ValidAfter:      uint64(now.Add(-clockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(ttl).Unix()),
+		Permissions: gossh.Permissions{
+			Extensions: map[string]string{
+				"permit-agent-forwarding": "",
+				"permit-pty":              "",
+				"permit-user-rc":          "",
+				"permit-X11-forwarding":   "",
+			},
+		},
+	}
+
+	// OpenSSH only has the one extension for both directions
+	local := user.HasPermission(model.PermissionSSHLocalForward)
+	reverse := user.HasPermission(model.PermissionSSHReverseForward)
+	if local {
+		cert.Permissions.Extensions[util.SSHExtensionLocalForwarding] = ""
+	}
+	if reverse {
+		cert.Permissions.Extensions[util.SSHExtensionRemoteForwarding] = ""
+	}
+	if local && reverse {
+		cert.Permissions.Extensions[util.SSHExtensionPortForwarding] = ""
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// SignHostCert issues a host certificate for the space's SSH host key, valid
+// for the space ID which clients use as the host key alias. Returns "" if the
+// CA isn't enabled or the space has no host key.
+func SignHostCert(space *model.Space) (string, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if signer == nil || space.SSHHostSigner == "" {
+		return "", nil
+	}
+
+	hostSigner, err := gossh.ParsePrivateKey([]byte(space.SSHHostSigner))
+	if err != nil {
+		return "", fmt.Errorf("parsing space host key: %w", err)
+	}
+
+	cert := &gossh.Certificate{
+		Key:             hostSigner.PublicKey(),
+		Serial:          serial(),
+		CertType:        gossh.HostCert,
+		KeyId:           "knot:" + space.Id,
+		ValidPrincipals: []string{space.Id},
+		ValidAfter:      0,
+		ValidBefore:     gossh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(cert))), nil
+}
+
+func serial() uint64 {
+	var b [8]byte
+	rand.Read(b[:])
+	return binary.BigEndian.Uint64(b[:])
+}
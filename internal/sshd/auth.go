@@ -1,12 +1,14 @@
 package sshd
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/paularlott/knot/internal/util"
 
 	"github.com/gliderlabs/ssh"
 	"github.com/paularlott/knot/internal/log"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 var (
@@ -51,9 +53,21 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 	defer authorizedKeysMutex.RUnlock()
 
 	for _, authorizedKey := range authorizedKeys {
-		parsedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
-		if err == nil && ssh.KeysEqual(key, parsedKey) {
+		parsedKey, _, options, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+		if err != nil {
+			continue
+		}
+
+		// Keys marked cert-authority only vouch for certificates, as with OpenSSH
+		if principals, ok := certAuthorityPrincipals(options); ok {
+			if cert, isCert := key.(*gossh.Certificate); isCert && checkUserCert(cert, parsedKey, principals) {
+				log.Debug("certificate signed by authorized CA", "key_id", cert.KeyId)
+				ctx.SetValue(forwardingContextKey{}, newForwarding(key, options))
+				return true
+			}
+		} else if ssh.KeysEqual(key, parsedKey) {
 			log.Debug("key found in authorized keys")
+			ctx.SetValue(forwardingContextKey{}, newForwarding(key, options))
 			return true
 		}
 	}
@@ -62,3 +76,44 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 
 	return false
 }
+
+// certAuthorityPrincipals returns the principals allowed by a cert-authority
+// authorized key, ok is false if the key isn't a certificate authority.
+func certAuthorityPrincipals(options []string) (principals []string, ok bool) {
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			ok = true
+		case "principals":
+			for _, principal := range strings.Split(strings.Trim(value, `"`), ",") {
+				if principal = strings.TrimSpace(principal); principal != "" {
+					principals = append(principals, principal)
+				}
+			}
+		}
+	}
+	return principals, ok
+}
+
+// checkUserCert verifies a user certificate was signed by ca, is in its
+// validity period and names one of principals, any principal is accepted if
+// none are given.
+func checkUserCert(cert *gossh.Certificate, ca ssh.PublicKey, principals []string) bool {
+	if cert.CertType != gossh.UserCert || !ssh.KeysEqual(cert.SignatureKey, ca) {
+		return false
+	}
+
+	checker := &gossh.CertChecker{}
+	if len(principals) == 0 {
+		principals = cert.ValidPrincipals
+	}
+	for _, principal := range principals {
+		err := checker.CheckCert(principal, cert)
+		if err == nil {
+			return true
+		}
+		log.Debug("certificate rejected", "key_id", cert.KeyId, "error", err)
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package sshd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func newUserCert(t *testing.T, ca gossh.Signer, key gossh.PublicKey, principal string, validBefore time.Time) *gossh.Certificate {
+	t.Helper()
+	cert := &gossh.Certificate{
+		Key:             key,
+		CertType:        gossh.UserCert,
+		ValidPrincipals: []string{principal},
+		ValidAfter:      uint64(time.Now().Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPublicKeyHandlerCertAuthority(t *testing.T) {
+	ca := newSigner(t)
+	user := newSigner(t).PublicKey()
+	caKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(ca.PublicKey())))
+
+	if err := UpdateAuthorizedKeys([]string{`cert-authority,principals="space-1" ` + caKey + " knot-ca"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer UpdateAuthorizedKeys(nil, nil)
+
+	valid := time.Now().Add(time.Hour)
+	tests := []struct {
+		name     string
+		key      gossh.PublicKey
+		expected bool
+	}{
+		{"certificate for the space", newUserCert(t, ca, user, "space-1", valid), true},
+		{"certificate for another space", newUserCert(t, ca, user, "space-2", valid), false},
+		{"expired certificate", newUserCert(t, ca, user, "space-1", time.Now().Add(-time.Second)), false},
+		{"certificate from another CA", newUserCert(t, newSigner(t), user, "space-1", valid), false},
+		{"plain user key", user, false},
+		{"the CA key itself", ca.PublicKey(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publicKeyHandler(newTestContext(), tt.key); got != tt.expected {
+				t.Errorf("publicKeyHandler() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPublicKeyHandlerAuthorizedKey(t *testing.T) {
+	user := newSigner(t).PublicKey()
+
+	if err := UpdateAuthorizedKeys([]string{string(gossh.MarshalAuthorizedKey(user))}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer UpdateAuthorizedKeys(nil, nil)
+
+	if !publicKeyHandler(newTestContext(), user) {
+		t.Error("Authorized key should be accepted")
+	}
+	if publicKeyHandler(newTestContext(), newSigner(t).PublicKey()) {
+		t.Error("Unknown key should be rejected")
+	}
+}
@@ -0,0 +1,138 @@
+package sshd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/paularlott/knot/internal/util"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/paularlott/knot/internal/log"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+type forwardingContextKey struct{}
+
+// ForwardEvent describes a port forward opened over SSH.
+type ForwardEvent struct {
+	Reverse     bool
+	Host        string
+	Port        uint32
+	KeyId       string // certificate key ID, empty for plain keys
+	Fingerprint string
+	RemoteAddr  string
+}
+
+// forwarding is what a connection may forward, decided by the key it
+// authenticated with.
+type forwarding struct {
+	local       bool
+	reverse     bool
+	keyId       string
+	fingerprint string
+
+	mutex sync.Mutex
+	seen  map[string]bool
+}
+
+var (
+	forwardHookMutex = sync.RWMutex{}
+	forwardHook      func(ForwardEvent)
+)
+
+// SetForwardHook sets the function called as port forwards are opened, local
+// forwards are reported once per connection and destination.
+func SetForwardHook(hook func(ForwardEvent)) {
+	forwardHookMutex.Lock()
+	defer forwardHookMutex.Unlock()
+	forwardHook = hook
+}
+
+// newForwarding works out the forwarding allowed by an authorized key's
+// options and, for certificates, the extensions the CA granted.
+func newForwarding(key ssh.PublicKey, options []string) *forwarding {
+	fwd := &forwarding{
+		local:       true,
+		reverse:     true,
+		fingerprint: gossh.FingerprintSHA256(key),
+	}
+
+	for _, option := range options {
+		switch strings.ToLower(option) {
+		case util.SSHOptionNoPortForwarding, "restrict":
+			fwd.local, fwd.reverse = false, false
+		case util.SSHOptionNoLocalForwarding:
+			fwd.local = false
+		case util.SSHOptionNoRemoteForwarding:
+			fwd.reverse = false
+		}
+	}
+
+	if cert, ok := key.(*gossh.Certificate); ok {
+		_, local := cert.Extensions[util.SSHExtensionLocalForwarding]
+		_, reverse := cert.Extensions[util.SSHExtensionRemoteForwarding]
+		fwd.local = fwd.local && local
+		fwd.reverse = fwd.reverse && reverse
+		fwd.keyId = cert.KeyId
+		fwd.fingerprint = gossh.FingerprintSHA256(cert.Key)
+	}
+
+	return fwd
+}
+
+func getForwarding(ctx ssh.Context) *forwarding {
+	fwd, _ := ctx.Value(forwardingContextKey{}).(*forwarding)
+	return fwd
+}
+
+func localPortForwardingCallback(ctx ssh.Context, host string, port uint32) bool {
+	fwd := getForwarding(ctx)
+	if fwd == nil || !fwd.local {
+		log.Debug("local port forward denied", "host", host, "port", port)
+		return false
+	}
+
+	// Clients open a channel per connection, only report new destinations
+	destination := fmt.Sprintf("%s:%d", host, port)
+	fwd.mutex.Lock()
+	if fwd.seen == nil {
+		fwd.seen = map[string]bool{}
+	}
+	seen := fwd.seen[destination]
+	fwd.seen[destination] = true
+	fwd.mutex.Unlock()
+
+	if !seen {
+		reportForward(ctx, fwd, false, host, port)
+	}
+	return true
+}
+
+func reversePortForwardingCallback(ctx ssh.Context, host string, port uint32) bool {
+	fwd := getForwarding(ctx)
+	if fwd == nil || !fwd.reverse {
+		log.Debug("reverse port forward denied", "host", host, "port", port)
+		return false
+	}
+
+	reportForward(ctx, fwd, true, host, port)
+	return true
+}
+
+func reportForward(ctx ssh.Context, fwd *forwarding, reverse bool, host string, port uint32) {
+	forwardHookMutex.RLock()
+	hook := forwardHook
+	forwardHookMutex.RUnlock()
+
+	if hook != nil {
+		hook(ForwardEvent{
+			Reverse:     reverse,
+			Host:        host,
+			Port:        port,
+			KeyId:       fwd.keyId,
+			Fingerprint: fwd.fingerprint,
+			RemoteAddr:  ctx.RemoteAddr().String(),
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package sshd
+
+import (
+	"crypto/rand"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// testContext holds the values set during authentication, the rest of the
+// context isn't used by the handlers under test.
+type testContext struct {
+	ssh.Context
+	values map[interface{}]interface{}
+}
+
+func newTestContext() *testContext {
+	return &testContext{values: map[interface{}]interface{}{}}
+}
+
+func (ctx *testContext) SetValue(key, value interface{})   { ctx.values[key] = value }
+func (ctx *testContext) Value(key interface{}) interface{} { return ctx.values[key] }
+func (ctx *testContext) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000}
+}
+
+func TestForwardingOptions(t *testing.T) {
+	user := newSigner(t).PublicKey()
+	userKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(user)))
+
+	tests := []struct {
+		options string
+		local   bool
+		reverse bool
+	}{
+		{"", true, true},
+		{"no-port-forwarding ", false, false},
+		{"no-local-forwarding ", false, true},
+		{"no-remote-forwarding ", true, false},
+		{"restrict ", false, false},
+	}
+	for _, tt := range tests {
+		if err := UpdateAuthorizedKeys([]string{tt.options + userKey}, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := newTestContext()
+		if !publicKeyHandler(ctx, user) {
+			t.Fatalf("%q: key should be accepted", tt.options)
+		}
+		if got := localPortForwardingCallback(ctx, "localhost", 80); got != tt.local {
+			t.Errorf("%q: local forwarding = %v, expected %v", tt.options, got, tt.local)
+		}
+		if got := reversePortForwardingCallback(ctx, "localhost", 8080); got != tt.reverse {
+			t.Errorf("%q: reverse forwarding = %v, expected %v", tt.options, got, tt.reverse)
+		}
+	}
+	UpdateAuthorizedKeys(nil, nil)
+
+	if localPortForwardingCallback(newTestContext(), "localhost", 80) {
+		t.Error("Forwarding should be denied without an authenticated key")
+	}
+}
+
+func TestForwardingCertificate(t *testing.T) {
+	ca := newSigner(t)
+	user := newSigner(t).PublicKey()
+	caKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(ca.PublicKey())))
+
+	if err := UpdateAuthorizedKeys([]string{`no-remote-forwarding,cert-authority,principals="space-1" ` + caKey}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer UpdateAuthorizedKeys(nil, nil)
+
+	var events []ForwardEvent
+	SetForwardHook(func(event ForwardEvent) { events = append(events, event) })
+	defer SetForwardHook(nil)
+
+	cert := &gossh.Certificate{
+		Key:             user,
+		KeyId:           "knot:alice:dev",
+		CertType:        gossh.UserCert,
+		ValidPrincipals: []string{"space-1"},
+		ValidAfter:      uint64(time.Now().Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+		Permissions: gossh.Permissions{
+			Extensions: map[string]string{
+				"permit-local-forwarding@getknot.dev":  "",
+				"permit-remote-forwarding@getknot.dev": "",
+			},
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newTestContext()
+	if !publicKeyHandler(ctx, cert) {
+		t.Fatal("certificate should be accepted")
+	}
+
+	// The CA line denies reverse forwarding whatever the certificate allows
+	if !localPortForwardingCallback(ctx, "localhost", 80) || !localPortForwardingCallback(ctx, "localhost", 80) {
+		t.Error("Local forwarding should be allowed")
+	}
+	if reversePortForwardingCallback(ctx, "localhost", 8080) {
+		t.Error("Reverse forwarding should be denied")
+	}
+
+	if len(events) != 1 || events[0].Reverse || events[0].KeyId != "knot:alice:dev" || events[0].Port != 80 {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if events[0].Fingerprint != gossh.FingerprintSHA256(user) {
+		t.Errorf("Fingerprint should be of the certified key")
+	}
+}
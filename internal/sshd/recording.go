@@ -0,0 +1,90 @@
+package sshd
+
+import (
+	"io"
+	"sync"
+
+	"github.com/paularlott/knot/internal/asciicast"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/paularlott/knot/internal/log"
+)
+
+// SessionRecording is a finished recording of a PTY session and the key the
+// session authenticated with.
+type SessionRecording struct {
+	*asciicast.Recording
+	KeyId       string
+	Fingerprint string
+}
+
+var (
+	recordingMutex   = sync.RWMutex{}
+	recordingMaxSize int
+	recordingHook    func(*SessionRecording)
+)
+
+// SetRecording enables recording of PTY sessions, each finished recording is
+// passed to hook. A nil hook disables recording.
+func SetRecording(maxSize int, hook func(*SessionRecording)) {
+	recordingMutex.Lock()
+	defer recordingMutex.Unlock()
+	recordingMaxSize = maxSize
+	recordingHook = hook
+}
+
+// sessionRecorder records a PTY session, its methods do nothing on a nil
+// recorder so sessions needn't check if recording is enabled.
+type sessionRecorder struct {
+	recorder *asciicast.Recorder
+	ctx      ssh.Context
+	hook     func(*SessionRecording)
+}
+
+func newSessionRecorder(s ssh.Session, ptyReq ssh.Pty) *sessionRecorder {
+	recordingMutex.RLock()
+	defer recordingMutex.RUnlock()
+
+	if recordingHook == nil {
+		return nil
+	}
+
+	return &sessionRecorder{
+		recorder: asciicast.NewRecorder(ptyReq.Window.Width, ptyReq.Window.Height, s.RawCommand(), recordingMaxSize),
+		ctx:      s.Context(),
+		hook:     recordingHook,
+	}
+}
+
+// output returns the writer to copy the session's output through.
+func (sr *sessionRecorder) output(w io.Writer) io.Writer {
+	if sr == nil {
+		return w
+	}
+	return io.MultiWriter(w, sr.recorder)
+}
+
+func (sr *sessionRecorder) resize(win ssh.Window) {
+	if sr != nil {
+		sr.recorder.Resize(win.Width, win.Height)
+	}
+}
+
+func (sr *sessionRecorder) finish() {
+	if sr == nil {
+		return
+	}
+
+	recording, err := sr.recorder.Finish()
+	if err != nil {
+		log.WithError(err).Error("failed to finish session recording")
+		return
+	}
+
+	sessionRecording := &SessionRecording{Recording: recording}
+	if fwd := getForwarding(sr.ctx); fwd != nil {
+		sessionRecording.KeyId = fwd.keyId
+		sessionRecording.Fingerprint = fwd.fingerprint
+	}
+	sr.hook(sessionRecording)
+}
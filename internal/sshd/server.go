@@ -49,7 +49,7 @@ func prepareCommand(s ssh.Session, command string, extraEnv ...string) (*exec.Cm
 	return cmd, home, nil
 }
 
-func ListenAndServe(port int, privateKeyPEM string) {
+func ListenAndServe(port int, privateKeyPEM string, hostCertificate string) {
 	logger := log.WithGroup("sshd")
 	logger.Info("starting on port", "port", port)
 
@@ -68,7 +68,18 @@ func ListenAndServe(port int, privateKeyPEM string) {
 	if err != nil {
 		logger.Fatal("failed to parse SSH private key:", "err", err)
 	}
+	hostSigners := []ssh.Signer{signer}
 
+	// Offer the host certificate first so clients trusting the CA can verify it
+	if hostCertificate != "" {
+		if certSigner, err := newCertSigner(signer, hostCertificate); err != nil {
+			logger.WithError(err).Warn("ignoring SSH host certificate")
+		} else {
+			hostSigners = []ssh.Signer{certSigner, signer}
+		}
+	}
+
+	forwardHandler := &ssh.ForwardedTCPHandler{}
 	ssh_server := ssh.Server{
 		Version: "knot " + build.Version,
 		//Banner:  "Welcome to knot " + build.Version + "\r\n\r\n",
@@ -76,16 +87,19 @@ func ListenAndServe(port int, privateKeyPEM string) {
 		SubsystemHandlers: map[string]ssh.SubsystemHandler{
 			"sftp": SftpHandler,
 		},
-		HostSigners:      []ssh.Signer{signer},
+		HostSigners:      hostSigners,
 		PublicKeyHandler: publicKeyHandler,
 		Handler:          defaultHandler,
 		ChannelHandlers: map[string]ssh.ChannelHandler{
 			"session":      ssh.DefaultSessionHandler,
 			"direct-tcpip": ssh.DirectTCPIPHandler,
 		},
-		LocalPortForwardingCallback: ssh.LocalPortForwardingCallback(func(ctx ssh.Context, dhost string, dport uint32) bool {
-			return true
-		}),
+		RequestHandlers: map[string]ssh.RequestHandler{
+			"tcpip-forward":        forwardHandler.HandleSSHRequest,
+			"cancel-tcpip-forward": forwardHandler.HandleSSHRequest,
+		},
+		LocalPortForwardingCallback:   localPortForwardingCallback,
+		ReversePortForwardingCallback: reversePortForwardingCallback,
 	}
 
 	go func() {
@@ -93,6 +107,19 @@ func ListenAndServe(port int, privateKeyPEM string) {
 	}()
 }
 
+// newCertSigner pairs the host key with its certificate.
+func newCertSigner(signer gossh.Signer, hostCertificate string) (gossh.Signer, error) {
+	key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(hostCertificate))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*gossh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("not a certificate")
+	}
+	return gossh.NewCertSigner(cert, signer)
+}
+
 // executeNonPtyCommand executes a command in non-PTY mode with proper I/O handling
 func executeNonPtyCommand(s ssh.Session, command string) {
 	logger := log.WithGroup("sshd")
@@ -194,15 +221,19 @@ func defaultHandler(s ssh.Session) {
 				return
 			}
 
+			recorder := newSessionRecorder(s, ptyReq)
+			defer recorder.finish()
+
 			go func() {
 				for win := range winCh {
 					setWinsize(tty, win.Width, win.Height)
+					recorder.resize(win)
 				}
 			}()
 			go func() {
 				io.Copy(tty, s)
 			}()
-			io.Copy(s, tty)
+			io.Copy(recorder.output(s), tty)
 			err = cmd.Wait()
 			if err != nil {
 				if exitErr, ok := err.(*exec.ExitError); ok {
@@ -245,15 +276,19 @@ func defaultHandler(s ssh.Session) {
 			return
 		}
 
+		recorder := newSessionRecorder(s, ptyReq)
+		defer recorder.finish()
+
 		go func() {
 			for win := range winCh {
 				setWinsize(tty, win.Width, win.Height)
+				recorder.resize(win)
 			}
 		}()
 		go func() {
 			io.Copy(tty, s) // stdin
 		}()
-		io.Copy(s, tty) // stdout
+		io.Copy(recorder.output(s), tty) // stdout
 		err = cmd.Wait()
 		if err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func installRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestCarrierRoundTrip(t *testing.T) {
+	installRecorder(t)
+
+	if carrier := Carrier(context.Background()); carrier != nil {
+		t.Fatalf("expected no carrier outside a trace, got %v", carrier)
+	}
+
+	ctx, span := Start(context.Background(), "parent")
+	defer span.End()
+
+	carrier := Carrier(ctx)
+	if carrier["traceparent"] == "" {
+		t.Fatalf("expected a traceparent, got %v", carrier)
+	}
+
+	remote := trace.SpanContextFromContext(FromCarrier(context.Background(), carrier))
+	if remote.TraceID() != span.SpanContext().TraceID() || remote.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("extracted span context %v does not match %v", remote, span.SpanContext())
+	}
+	if !remote.IsRemote() {
+		t.Error("expected the extracted span context to be remote")
+	}
+}
+
+func TestMiddlewareContinuesTraceAndNamesRoute(t *testing.T) {
+	recorder := installRecorder(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/spaces/{space_id}", func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("expected the handler to run inside a span")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, parent := Start(context.Background(), "client")
+	req := httptest.NewRequest(http.MethodGet, "/api/spaces/abc", nil)
+	Inject(ctx, req.Header)
+	parent.End()
+
+	Middleware(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	server := spans[1]
+	if server.Name() != "GET /api/spaces/{space_id}" {
+		t.Errorf("unexpected span name %q", server.Name())
+	}
+	if server.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected the server span to continue the caller's trace")
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected span kind %v", server.SpanKind())
+	}
+	if server.Status().Code.String() != "Error" {
+		t.Errorf("expected a 5xx response to mark the span as failed, got %v", server.Status().Code)
+	}
+}
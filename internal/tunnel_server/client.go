@@ -36,19 +36,21 @@ type TunnelClient struct {
 	tlsName                string
 	localPortSkipTLSVerify bool
 	tunnelURL              string
+	policy                 *TunnelPolicy
 	ctx                    context.Context
 	cancel                 context.CancelFunc
 }
 
 type TunnelOpts struct {
-	Type          TunnelType // Type of tunnel
-	Protocol      string     // http, https or tcp
-	LocalPort     uint16     // The local port to forward to
-	TunnelName    string     // The name of the tunnel for web tunnels
-	SpaceName     string     // The name of the space for space tunnels
-	SpacePort     uint16     // The port within the space being forwarded
-	TlsName       string     // The name to present to TLS ports
-	TlsSkipVerify bool       // Don't verify TLS of the local port
+	Type          TunnelType    // Type of tunnel
+	Protocol      string        // http, https or tcp
+	LocalPort     uint16        // The local port to forward to
+	TunnelName    string        // The name of the tunnel for web tunnels
+	SpaceName     string        // The name of the space for space tunnels
+	SpacePort     uint16        // The port within the space being forwarded
+	TlsName       string        // The name to present to TLS ports
+	TlsSkipVerify bool          // Don't verify TLS of the local port
+	Policy        *TunnelPolicy // Access policy for web tunnels, nil for open
 }
 
 func NewTunnelClient(wsServerUrl, serverUrl, token string, skipTLSVerify bool, opts *TunnelOpts) *TunnelClient {
@@ -68,6 +70,7 @@ func NewTunnelClient(wsServerUrl, serverUrl, token string, skipTLSVerify bool, o
 		spacePort:              opts.SpacePort,
 		tlsName:                opts.TlsName,
 		localPortSkipTLSVerify: opts.TlsSkipVerify,
+		policy:                 opts.Policy,
 		ctx:                    ctx,
 		cancel:                 cancel,
 	}
@@ -97,6 +100,21 @@ func (c *TunnelClient) ConnectAndServe() error {
 		fmt.Printf("Tunnel URL: %s\n", tunnelUrl)
 		fmt.Printf("Forwarding to: %s://localhost:%d\n", c.protocol, c.localPort)
 
+		// Shut the tunnel down when the policy expires
+		if c.policy != nil && c.policy.ExpiresAt != nil {
+			fmt.Printf("Expires at: %s\n", c.policy.ExpiresAt.Local().Format(time.RFC1123))
+			go func() {
+				timer := time.NewTimer(time.Until(*c.policy.ExpiresAt))
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+					log.Info("Tunnel expired")
+					c.Shutdown()
+				case <-c.ctx.Done():
+				}
+			}()
+		}
+
 		// Add the tunnel servers to the list
 		c.serverListMutex.Lock()
 		for _, server := range tunnelServerInfo.TunnelServers {
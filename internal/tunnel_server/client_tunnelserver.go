@@ -97,6 +97,13 @@ func (ts *tunnelServer) ConnectAndServe() {
 
 			// Open the websocket
 			header := http.Header{"Authorization": []string{fmt.Sprintf("Bearer %s", ts.client.token)}}
+			if ts.client.tunnelType == WebTunnel && !ts.client.policy.IsOpen() {
+				policy, err := encodePolicy(ts.client.policy)
+				if err != nil {
+					log.Fatal("Failed to encode tunnel policy", "error", err)
+				}
+				header.Set(policyHeader, policy)
+			}
 			dialer := websocket.DefaultDialer
 			dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: ts.client.skipTLSVerify}
 			dialer.HandshakeTimeout = 5 * time.Second
@@ -115,6 +122,8 @@ func (ts *tunnelServer) ConnectAndServe() {
 						log.Fatal("Tunnels are not available on your account")
 					} else if response.StatusCode == http.StatusServiceUnavailable {
 						log.Fatal("Tunnel limit reached")
+					} else if response.StatusCode == http.StatusBadRequest && ts.client.tunnelType == WebTunnel {
+						log.Fatal("Tunnel rejected by server, check the name and access policy")
 					}
 				}
 
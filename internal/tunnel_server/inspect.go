@@ -0,0 +1,326 @@
+package tunnel_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/paularlott/knot/apiclient"
+)
+
+const (
+	maxCapturedRequests = 50        // Requests kept per tunnel
+	maxCapturedBody     = 64 * 1024 // Bytes of each body kept
+	replayTimeout       = 30 * time.Second
+)
+
+var (
+	ErrTunnelNotFound    = errors.New("tunnel not found")
+	ErrTunnelNotOnServer = errors.New("tunnel is not connected to this server, requests are only captured by the servers carrying the tunnel")
+	ErrRequestNotFound   = errors.New("request not found")
+	ErrBodyTruncated     = errors.New("request body was truncated, it can't be replayed")
+)
+
+type capturedRequest struct {
+	id                    int64
+	replayOf              int64
+	time                  time.Time
+	remoteAddr            string
+	method                string
+	url                   string
+	host                  string
+	requestHeaders        http.Header
+	requestBody           []byte
+	requestBodyTruncated  bool
+	status                int
+	responseHeaders       http.Header
+	responseBody          []byte
+	responseBodyTruncated bool
+	duration              time.Duration
+	err                   string
+}
+
+// requestLog is a ring buffer of the recent requests through a tunnel.
+//
+// Each tunnel server keeps its own log for the traffic it handled, so the
+// inspect API only sees the requests that arrived at the server it is called
+// on and returns ErrTunnelNotOnServer when the tunnel isn't connected there.
+type requestLog struct {
+	mutex    sync.Mutex
+	nextId   int64
+	requests []*capturedRequest
+}
+
+func (l *requestLog) add(c *capturedRequest) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.nextId++
+	c.id = l.nextId
+
+	if len(l.requests) >= maxCapturedRequests {
+		copy(l.requests, l.requests[1:])
+		l.requests = l.requests[:len(l.requests)-1]
+	}
+	l.requests = append(l.requests, c)
+}
+
+func (l *requestLog) get(id int64) *capturedRequest {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, c := range l.requests {
+		if c.id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (l *requestLog) list() []apiclient.TunnelRequest {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	list := make([]apiclient.TunnelRequest, 0, len(l.requests))
+	for i := len(l.requests) - 1; i >= 0; i-- {
+		list = append(list, l.requests[i].toApi())
+	}
+	return list
+}
+
+func (l *requestLog) clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.requests = nil
+}
+
+func (c *capturedRequest) toApi() apiclient.TunnelRequest {
+	return apiclient.TunnelRequest{
+		Id:                    c.id,
+		ReplayOf:              c.replayOf,
+		Time:                  c.time,
+		RemoteAddr:            c.remoteAddr,
+		Method:                c.method,
+		URL:                   c.url,
+		RequestHeaders:        c.requestHeaders,
+		RequestBody:           bodyString(c.requestBody),
+		RequestBodyTruncated:  c.requestBodyTruncated,
+		Status:                c.status,
+		ResponseHeaders:       c.responseHeaders,
+		ResponseBody:          bodyString(c.responseBody),
+		ResponseBodyTruncated: c.responseBodyTruncated,
+		DurationMs:            c.duration.Milliseconds(),
+		Error:                 c.err,
+	}
+}
+
+// bodyString returns the body as text, binary bodies are summarised.
+func bodyString(body []byte) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	return fmt.Sprintf("[%d bytes of binary data]", len(body))
+}
+
+// captureRequest records the start of a request, the body is read up to the
+// capture limit and then put back so the full body is still proxied.
+func captureRequest(r *http.Request) *capturedRequest {
+	c := &capturedRequest{
+		time:           time.Now().UTC(),
+		remoteAddr:     r.RemoteAddr,
+		method:         r.Method,
+		url:            r.URL.RequestURI(),
+		host:           r.Host,
+		requestHeaders: r.Header.Clone(),
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, _ := io.ReadAll(io.LimitReader(r.Body, maxCapturedBody+1))
+		if len(body) > maxCapturedBody {
+			c.requestBody = body[:maxCapturedBody]
+			c.requestBodyTruncated = true
+		} else {
+			c.requestBody = body
+		}
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	}
+
+	return c
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter records the response as it is written to the client.
+type captureWriter struct {
+	http.ResponseWriter
+	capture *capturedRequest
+	body    bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.capture.status == 0 {
+		w.capture.status = status
+		w.capture.responseHeaders = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.capture.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if room := maxCapturedBody - w.body.Len(); room > 0 {
+		w.body.Write(p[:min(room, len(p))])
+		if len(p) > room {
+			w.capture.responseBodyTruncated = true
+		}
+	} else if len(p) > 0 {
+		w.capture.responseBodyTruncated = true
+	}
+
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap allows http.ResponseController to reach the flusher and hijacker of
+// the underlying writer, needed for streaming responses and websockets.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *captureWriter) finish(start time.Time) {
+	w.capture.duration = time.Since(start)
+	w.capture.responseBody = w.body.Bytes()
+}
+
+// GetTunnelRequests returns the captured requests for one of the user's
+// tunnels, newest first.
+func GetTunnelRequests(userId, webName string) ([]apiclient.TunnelRequest, error) {
+	session, err := getUserTunnel(userId, webName)
+	if err != nil {
+		return nil, err
+	}
+	return session.requests.list(), nil
+}
+
+// ClearTunnelRequests discards the captured requests for one of the user's
+// tunnels.
+func ClearTunnelRequests(userId, webName string) error {
+	session, err := getUserTunnel(userId, webName)
+	if err != nil {
+		return err
+	}
+	session.requests.clear()
+	return nil
+}
+
+// ReplayTunnelRequest sends a captured request through the tunnel to the
+// client's local port again, the result is captured as a new request.
+func ReplayTunnelRequest(ctx context.Context, userId, webName string, requestId int64) (*apiclient.TunnelRequest, error) {
+	session, err := getUserTunnel(userId, webName)
+	if err != nil {
+		return nil, err
+	}
+
+	original := session.requests.get(requestId)
+	if original == nil {
+		return nil, ErrRequestNotFound
+	}
+	if original.requestBodyTruncated {
+		return nil, ErrBodyTruncated
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, original.method, "http://127.0.0.1"+original.url, bytes.NewReader(original.requestBody))
+	if err != nil {
+		return nil, err
+	}
+	request.Header = original.requestHeaders.Clone()
+	request.Host = original.host
+
+	capture := &capturedRequest{
+		replayOf:       original.id,
+		time:           time.Now().UTC(),
+		remoteAddr:     "replay",
+		method:         original.method,
+		url:            original.url,
+		host:           original.host,
+		requestHeaders: original.requestHeaders.Clone(),
+		requestBody:    original.requestBody,
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				stream, err := session.muxSession.Open()
+				if err != nil {
+					return nil, err
+				}
+
+				// Write a byte with a value of 1 so the client knows this is a new connection
+				if _, err := stream.Write([]byte{1}); err != nil {
+					stream.Close()
+					return nil, err
+				}
+				return stream, nil
+			},
+		},
+		// Replay exactly what was sent, the caller can follow redirects themselves
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		capture.duration = time.Since(start)
+		capture.err = err.Error()
+	} else {
+		defer response.Body.Close()
+
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxCapturedBody+1))
+		capture.duration = time.Since(start)
+		capture.status = response.StatusCode
+		capture.responseHeaders = response.Header.Clone()
+		if len(body) > maxCapturedBody {
+			capture.responseBody = body[:maxCapturedBody]
+			capture.responseBodyTruncated = true
+		} else {
+			capture.responseBody = body
+		}
+	}
+
+	session.requests.add(capture)
+	result := capture.toApi()
+	return &result, nil
+}
+
+func getUserTunnel(userId, webName string) (*tunnelSession, error) {
+	tunnelMutex.RLock()
+	session, ok := tunnels[strings.ToLower(webName)]
+	tunnelMutex.RUnlock()
+
+	if !ok {
+		return nil, ErrTunnelNotOnServer
+	}
+	if session.tunnelType != WebTunnel || session.user.Id != userId {
+		return nil, ErrTunnelNotFound
+	}
+	return session, nil
+}
@@ -0,0 +1,88 @@
+package tunnel_server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paularlott/knot/internal/database/model"
+)
+
+func TestRequestLogRing(t *testing.T) {
+	var log requestLog
+	for i := 0; i < maxCapturedRequests+5; i++ {
+		log.add(&capturedRequest{method: "GET"})
+	}
+
+	list := log.list()
+	if len(list) != maxCapturedRequests {
+		t.Fatalf("Expected %d requests, got %d", maxCapturedRequests, len(list))
+	}
+	if list[0].Id != maxCapturedRequests+5 || list[len(list)-1].Id != 6 {
+		t.Errorf("Expected newest first from %d to 6, got %d to %d", maxCapturedRequests+5, list[0].Id, list[len(list)-1].Id)
+	}
+	if log.get(1) != nil {
+		t.Error("Expected the oldest request to have been dropped")
+	}
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	body := strings.Repeat("a", maxCapturedBody+10)
+	r := httptest.NewRequest("POST", "/hook?x=1", strings.NewReader(body))
+
+	capture := captureRequest(r)
+	if !capture.requestBodyTruncated || len(capture.requestBody) != maxCapturedBody {
+		t.Errorf("Expected a truncated capture of %d bytes, got %d", maxCapturedBody, len(capture.requestBody))
+	}
+	if capture.url != "/hook?x=1" {
+		t.Errorf("Expected url /hook?x=1, got %s", capture.url)
+	}
+
+	// The full body must still be proxied
+	proxied, _ := io.ReadAll(r.Body)
+	if string(proxied) != body {
+		t.Errorf("Expected the full body of %d bytes, got %d", len(body), len(proxied))
+	}
+}
+
+func TestCaptureWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	capture := &capturedRequest{}
+	w := &captureWriter{ResponseWriter: recorder, capture: capture}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("hello"))
+	w.finish(capture.time)
+
+	if capture.status != http.StatusCreated || capture.responseHeaders.Get("Content-Type") != "text/plain" {
+		t.Errorf("Unexpected capture status %d headers %v", capture.status, capture.responseHeaders)
+	}
+	if string(capture.responseBody) != "hello" || recorder.Body.String() != "hello" {
+		t.Errorf("Expected body hello, got %q and %q", capture.responseBody, recorder.Body.String())
+	}
+}
+
+func TestGetUserTunnel(t *testing.T) {
+	tunnelMutex.Lock()
+	tunnels["bob--web"] = &tunnelSession{tunnelType: WebTunnel, user: &model.User{Id: "u1"}}
+	tunnelMutex.Unlock()
+	defer func() {
+		tunnelMutex.Lock()
+		delete(tunnels, "bob--web")
+		tunnelMutex.Unlock()
+	}()
+
+	if _, err := getUserTunnel("u1", "Bob--Web"); err != nil {
+		t.Fatalf("Expected the tunnel, got %v", err)
+	}
+	if _, err := getUserTunnel("u2", "bob--web"); !errors.Is(err, ErrTunnelNotFound) {
+		t.Errorf("Expected ErrTunnelNotFound for another user, got %v", err)
+	}
+	if _, err := getUserTunnel("u1", "bob--other"); !errors.Is(err, ErrTunnelNotOnServer) {
+		t.Errorf("Expected ErrTunnelNotOnServer, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package tunnel_server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/apiclient"
+	"github.com/paularlott/knot/internal/middleware"
+)
+
+const (
+	// Header used by the tunnel client to send the access policy to the server
+	policyHeader = "X-Knot-Tunnel-Policy"
+
+	// Path on the tunnel host that completes a knot login hand off
+	authCallbackPath = "/.knot/tunnel-auth"
+
+	// Cookie holding the signed login on the tunnel host
+	authCookieName = "__KNOT_TUNNEL"
+
+	authHandoffLifetime = 1 * time.Minute
+	authCookieLifetime  = 12 * time.Hour
+)
+
+// TunnelPolicy controls who can reach a web tunnel. When more than one of
+// RequireLogin, basic auth and BearerToken is set a request passing any of them
+// is allowed, the IP allowlist and expiry always apply.
+type TunnelPolicy struct {
+	RequireLogin      bool       `json:"require_login,omitempty" msgpack:"require_login,omitempty"`
+	BasicAuthUser     string     `json:"basic_auth_user,omitempty" msgpack:"basic_auth_user,omitempty"`
+	BasicAuthPassword string     `json:"basic_auth_password,omitempty" msgpack:"basic_auth_password,omitempty"`
+	BearerToken       string     `json:"bearer_token,omitempty" msgpack:"bearer_token,omitempty"`
+	AllowedIPs        []string   `json:"allowed_ips,omitempty" msgpack:"allowed_ips,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty" msgpack:"expires_at,omitempty"`
+
+	prefixes []netip.Prefix
+}
+
+// Validate checks the policy and prepares the IP allowlist.
+func (p *TunnelPolicy) Validate() error {
+	if (p.BasicAuthUser == "") != (p.BasicAuthPassword == "") {
+		return fmt.Errorf("basic auth requires both a username and password")
+	}
+
+	if strings.Contains(p.BasicAuthUser, ":") {
+		return fmt.Errorf("basic auth username must not contain ':'")
+	}
+
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expiry time must be in the future")
+	}
+
+	p.prefixes = make([]netip.Prefix, 0, len(p.AllowedIPs))
+	for _, entry := range p.AllowedIPs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return fmt.Errorf("invalid IP address %s", entry)
+			}
+			p.prefixes = append(p.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR %s", entry)
+		}
+		p.prefixes = append(p.prefixes, prefix.Masked())
+	}
+
+	return nil
+}
+
+// IsOpen reports whether the policy places no restrictions on the tunnel.
+func (p *TunnelPolicy) IsOpen() bool {
+	return p == nil || (!p.hasCredentials() && len(p.AllowedIPs) == 0 && p.ExpiresAt == nil)
+}
+
+func (p *TunnelPolicy) hasCredentials() bool {
+	return p.RequireLogin || p.BasicAuthUser != "" || p.BearerToken != ""
+}
+
+// Access returns the policy summary reported by the API.
+func (p *TunnelPolicy) Access() apiclient.TunnelAccess {
+	if p == nil {
+		return apiclient.TunnelAccess{AllowedIPs: []string{}}
+	}
+
+	allowed := make([]string, len(p.prefixes))
+	for i, prefix := range p.prefixes {
+		allowed[i] = prefix.String()
+	}
+
+	return apiclient.TunnelAccess{
+		RequireLogin: p.RequireLogin,
+		BasicAuth:    p.BasicAuthUser != "",
+		BearerToken:  p.BearerToken != "",
+		AllowedIPs:   allowed,
+		ExpiresAt:    p.ExpiresAt,
+	}
+}
+
+// Spec returns the policy in the form sent to the API, nil if it is open.
+func (p *TunnelPolicy) Spec() *apiclient.SpaceTunnelPolicy {
+	if p.IsOpen() {
+		return nil
+	}
+
+	return &apiclient.SpaceTunnelPolicy{
+		RequireLogin:      p.RequireLogin,
+		BasicAuthUser:     p.BasicAuthUser,
+		BasicAuthPassword: p.BasicAuthPassword,
+		BearerToken:       p.BearerToken,
+		AllowedIPs:        p.AllowedIPs,
+		ExpiresAt:         p.ExpiresAt,
+	}
+}
+
+// PolicyFromSpec validates a policy sent to the API, nil is returned if it
+// places no restrictions on the tunnel.
+func PolicyFromSpec(spec *apiclient.SpaceTunnelPolicy) (*TunnelPolicy, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	policy := &TunnelPolicy{
+		RequireLogin:      spec.RequireLogin,
+		BasicAuthUser:     spec.BasicAuthUser,
+		BasicAuthPassword: spec.BasicAuthPassword,
+		BearerToken:       spec.BearerToken,
+		AllowedIPs:        spec.AllowedIPs,
+		ExpiresAt:         spec.ExpiresAt,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if policy.IsOpen() {
+		return nil, nil
+	}
+
+	return policy, nil
+}
+
+// encodePolicy encodes the policy for the policy header.
+func encodePolicy(p *TunnelPolicy) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePolicy reads the policy sent by the tunnel client, nil is returned if
+// the client did not send one.
+func decodePolicy(r *http.Request) (*TunnelPolicy, error) {
+	value := r.Header.Get(policyHeader)
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tunnel policy: %w", err)
+	}
+
+	policy := &TunnelPolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid tunnel policy: %w", err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if policy.IsOpen() {
+		return nil, nil
+	}
+
+	return policy, nil
+}
+
+// ipAllowed checks the client address against the allowlist.
+func (p *TunnelPolicy) ipAllowed(r *http.Request) bool {
+	if len(p.prefixes) == 0 {
+		return true
+	}
+
+	addr, ok := middleware.ClientIP(r)
+	if !ok {
+		return false
+	}
+
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkBasicAuth reports whether the request carries the policy's basic auth
+// credentials.
+func (p *TunnelPolicy) checkBasicAuth(r *http.Request) bool {
+	if p.BasicAuthUser == "" {
+		return false
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(p.BasicAuthUser))
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(p.BasicAuthPassword))
+	return userMatch&passwordMatch == 1
+}
+
+// checkBearerToken reports whether the request carries the policy's token.
+func (p *TunnelPolicy) checkBearerToken(r *http.Request) bool {
+	if p.BearerToken == "" {
+		return false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(p.BearerToken)) == 1
+}
+
+// signAuth creates a token binding a user to a tunnel until expires, used both
+// for the login hand off and for the cookie on the tunnel host.
+func signAuth(key, webName, userId string, expires time.Time) string {
+	payload := webName + "|" + userId + "|" + strconv.FormatInt(expires.Unix(), 10)
+
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// verifyAuth checks a token created by signAuth and returns the user ID.
+func verifyAuth(key, webName, token string) (string, bool) {
+	payloadEncoded, sigEncoded, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payloadEncoded)
+	if err != nil {
+		return "", false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigEncoded)
+	if err != nil {
+		return "", false
+	}
+
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write(payload)
+	if !hmac.Equal(sig, h.Sum(nil)) {
+		return "", false
+	}
+
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 3 || parts[0] != webName || parts[1] == "" {
+		return "", false
+	}
+
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", false
+	}
+
+	return parts[1], true
+}
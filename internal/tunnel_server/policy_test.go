@@ -0,0 +1,160 @@
+package tunnel_server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+)
+
+func TestTunnelPolicyValidate(t *testing.T) {
+	policy := &TunnelPolicy{AllowedIPs: []string{"203.0.113.7", "10.0.0.0/8", "2001:db8::/32"}}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	access := policy.Access()
+	want := []string{"203.0.113.7/32", "10.0.0.0/8", "2001:db8::/32"}
+	if len(access.AllowedIPs) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, access.AllowedIPs)
+	}
+	for i := range want {
+		if access.AllowedIPs[i] != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], access.AllowedIPs[i])
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	invalid := []*TunnelPolicy{
+		{AllowedIPs: []string{"not-an-ip"}},
+		{AllowedIPs: []string{"10.0.0.0/33"}},
+		{BasicAuthUser: "user"},
+		{ExpiresAt: &past},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", p)
+		}
+	}
+}
+
+func TestTunnelPolicyIPAllowed(t *testing.T) {
+	prev := config.GetServerConfig()
+	config.SetServerConfig(&config.ServerConfig{TrustedProxies: []string{"127.0.0.1"}})
+	defer config.SetServerConfig(prev)
+
+	policy := &TunnelPolicy{AllowedIPs: []string{"203.0.113.0/24"}}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	tests := []struct {
+		remote    string
+		forwarded string
+		allowed   bool
+	}{
+		{"203.0.113.9:1234", "", true},
+		{"198.51.100.1:1234", "", false},
+		{"127.0.0.1:1234", "203.0.113.9", true},
+		{"127.0.0.1:1234", "198.51.100.1", false},
+		// Only the entry added by the trusted proxy counts
+		{"127.0.0.1:1234", "203.0.113.9, 198.51.100.1", false},
+		// Forwarded headers from untrusted addresses are ignored
+		{"198.51.100.1:1234", "203.0.113.9", false},
+		{"10.0.0.1:1234", "203.0.113.9", false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := policy.ipAllowed(r); got != tt.allowed {
+			t.Errorf("remote %s forwarded %q: expected %v, got %v", tt.remote, tt.forwarded, tt.allowed, got)
+		}
+	}
+}
+
+func TestTunnelPolicyCredentials(t *testing.T) {
+	policy := &TunnelPolicy{BasicAuthUser: "user", BasicAuthPassword: "secret", BearerToken: "token"}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("user", "secret")
+	if !policy.checkBasicAuth(r) {
+		t.Error("Expected basic auth to pass")
+	}
+
+	r.SetBasicAuth("user", "wrong")
+	if policy.checkBasicAuth(r) {
+		t.Error("Expected basic auth with the wrong password to fail")
+	}
+
+	r.Header.Set("Authorization", "Bearer token")
+	if !policy.checkBearerToken(r) {
+		t.Error("Expected bearer token to pass")
+	}
+
+	r.Header.Set("Authorization", "Bearer other")
+	if policy.checkBearerToken(r) {
+		t.Error("Expected the wrong bearer token to fail")
+	}
+}
+
+func TestSignAuth(t *testing.T) {
+	token := signAuth("key", "alice--app", "user-1", time.Now().Add(time.Minute))
+
+	if userId, ok := verifyAuth("key", "alice--app", token); !ok || userId != "user-1" {
+		t.Errorf("Expected token to verify for user-1, got %q %v", userId, ok)
+	}
+	if _, ok := verifyAuth("key", "bob--app", token); ok {
+		t.Error("Expected token to be rejected for another tunnel")
+	}
+	if _, ok := verifyAuth("other", "alice--app", token); ok {
+		t.Error("Expected token to be rejected with another key")
+	}
+
+	expired := signAuth("key", "alice--app", "user-1", time.Now().Add(-time.Minute))
+	if _, ok := verifyAuth("key", "alice--app", expired); ok {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestTunnelRedirect(t *testing.T) {
+	tests := map[string]string{
+		"/app?tab=1":          "/app?tab=1",
+		"":                    "/",
+		"//evil.com":          "/",
+		"/\\evil.com":         "/",
+		"/app\\..\\x":         "/",
+		"https://evil.com/":   "/",
+		"///evil.com":         "/",
+		"/\t/evil.com":        "/",
+		"javascript:alert(1)": "/",
+	}
+	for in, want := range tests {
+		if got := tunnelRedirect(in); got != want {
+			t.Errorf("tunnelRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPolicyFromSpec(t *testing.T) {
+	if policy, err := PolicyFromSpec(nil); policy != nil || err != nil {
+		t.Errorf("expected no policy for a nil spec, got %+v %v", policy, err)
+	}
+	if policy, err := PolicyFromSpec((&TunnelPolicy{}).Spec()); policy != nil || err != nil {
+		t.Errorf("expected no policy for an open spec, got %+v %v", policy, err)
+	}
+
+	sent := &TunnelPolicy{BearerToken: "token", AllowedIPs: []string{"10.0.0.0/8"}}
+	policy, err := PolicyFromSpec(sent.Spec())
+	if err != nil || policy == nil || policy.BearerToken != "token" || len(policy.prefixes) != 1 {
+		t.Errorf("unexpected policy %+v %v", policy, err)
+	}
+
+	if _, err := PolicyFromSpec((&TunnelPolicy{BasicAuthUser: "user"}).Spec()); err == nil {
+		t.Error("expected an invalid spec to be rejected")
+	}
+}
@@ -0,0 +1,159 @@
+package tunnel_server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/log"
+)
+
+// authorizeRequest applies the tunnel's access policy, if the request is
+// refused the response has been written and false is returned.
+func authorizeRequest(w http.ResponseWriter, r *http.Request, webName string, session *tunnelSession) bool {
+	policy := session.policy
+	if policy == nil {
+		return true
+	}
+
+	if policy.ExpiresAt != nil && time.Now().After(*policy.ExpiresAt) {
+		w.WriteHeader(http.StatusGone)
+		return false
+	}
+
+	if !policy.ipAllowed(r) {
+		log.WithGroup("tunnel").Debug("address not allowed", "webName", webName, "remote", r.RemoteAddr)
+		w.WriteHeader(http.StatusForbidden)
+		return false
+	}
+
+	if !policy.hasCredentials() {
+		return true
+	}
+
+	cfg := config.GetServerConfig()
+
+	// Complete the knot login hand off
+	if policy.RequireLogin && r.URL.Path == authCallbackPath {
+		completeLogin(w, r, webName, cfg)
+		return false
+	}
+
+	authorized := policy.checkBearerToken(r) || policy.checkBasicAuth(r)
+	if authorized {
+		r.Header.Del("Authorization")
+	} else if policy.RequireLogin {
+		authorized = hasLoginCookie(r, webName, cfg)
+	}
+
+	// Don't pass the tunnel login on to the service
+	if policy.RequireLogin {
+		removeCookie(r, authCookieName)
+	}
+
+	if authorized {
+		return true
+	}
+
+	// Browsers are sent to knot to log in, everything else is refused
+	if policy.RequireLogin && r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		redirect := "https://" + r.Host + r.URL.RequestURI()
+		http.Redirect(w, r, strings.TrimSuffix(cfg.URL, "/")+"/tunnel-auth?tunnel="+url.QueryEscape(webName)+"&redirect="+url.QueryEscape(redirect), http.StatusSeeOther)
+		return false
+	}
+
+	if policy.BasicAuthUser != "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="knot tunnel", charset="UTF-8"`)
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	return false
+}
+
+// HandleTunnelAuth runs on the knot server behind the web login, it hands the
+// logged in user to the tunnel host with a short lived signed token. The token
+// is bound to the tunnel name so it can't be replayed against another tunnel.
+func HandleTunnelAuth(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*model.User)
+	cfg := config.GetServerConfig()
+
+	webName := strings.ToLower(r.URL.Query().Get("tunnel"))
+	redirect, err := url.Parse(r.URL.Query().Get("redirect"))
+	if webName == "" || err != nil || redirect.Scheme != "https" || !strings.EqualFold(redirect.Host, webName+cfg.TunnelDomain) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token := signAuth(cfg.EncryptionKey, webName, user.Id, time.Now().Add(authHandoffLifetime))
+	target := url.URL{
+		Scheme:   "https",
+		Host:     redirect.Host,
+		Path:     authCallbackPath,
+		RawQuery: url.Values{"token": {token}, "redirect": {redirect.RequestURI()}}.Encode(),
+	}
+
+	http.Redirect(w, r, target.String(), http.StatusSeeOther)
+}
+
+// completeLogin swaps the hand off token for a cookie on the tunnel host.
+func completeLogin(w http.ResponseWriter, r *http.Request, webName string, cfg *config.ServerConfig) {
+	userId, ok := verifyAuth(cfg.EncryptionKey, webName, r.URL.Query().Get("token"))
+	if !ok || !userActive(userId) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	expires := time.Now().Add(authCookieLifetime)
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookieName,
+		Value:    signAuth(cfg.EncryptionKey, webName, userId, expires),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, tunnelRedirect(r.URL.Query().Get("redirect")), http.StatusSeeOther)
+}
+
+// tunnelRedirect keeps the redirect after a login within the tunnel. Paths a
+// browser would treat as another host, such as //host or /\host, go to the
+// root of the tunnel.
+func tunnelRedirect(redirect string) string {
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme != "" || u.Host != "" ||
+		!strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		return "/"
+	}
+	return redirect
+}
+
+func hasLoginCookie(r *http.Request, webName string, cfg *config.ServerConfig) bool {
+	cookie, err := r.Cookie(authCookieName)
+	if err != nil {
+		return false
+	}
+
+	userId, ok := verifyAuth(cfg.EncryptionKey, webName, cookie.Value)
+	return ok && userActive(userId)
+}
+
+func userActive(userId string) bool {
+	user, err := database.GetInstance().GetUser(userId)
+	return err == nil && user.Active && !user.IsDeleted
+}
+
+// removeCookie drops a cookie from the request before it is proxied.
+func removeCookie(r *http.Request, name string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			r.AddCookie(cookie)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/paularlott/knot/apiclient"
 	agentlogger "github.com/paularlott/knot/internal/agentapi/logger"
+	"github.com/paularlott/knot/internal/config"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/sse"
@@ -26,6 +28,9 @@ type tunnelSession struct {
 	tunnelName string
 	muxSession *yamux.Session
 	ws         *websocket.Conn
+	policy     *TunnelPolicy
+	expiry     *time.Timer
+	requests   requestLog
 }
 
 var (
@@ -84,6 +89,18 @@ func HandleTunnel(w http.ResponseWriter, r *http.Request) {
 	tunnelName = strings.ToLower(tunnelName)
 	webName := strings.ToLower(fmt.Sprintf("%s--%s", user.Username, tunnelName))
 
+	policy, err := decodePolicy(r)
+	if err != nil {
+		logger.WithError(err).Error("invalid tunnel policy", "webName", webName)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if policy != nil && policy.RequireLogin && config.GetServerConfig().EncryptionKey == "" {
+		logger.Error("tunnel login requires an encryption key", "webName", webName)
+		http.Error(w, "server is not configured for tunnel login", http.StatusBadRequest)
+		return
+	}
+
 	logger.Info("new tunnel", "webName", webName)
 
 	// Upgrade to a websocket
@@ -100,6 +117,7 @@ func HandleTunnel(w http.ResponseWriter, r *http.Request) {
 		user:       user,
 		tunnelName: tunnelName,
 		ws:         ws,
+		policy:     policy,
 	}
 
 	localConn := wsconn.New(ws)
@@ -130,9 +148,14 @@ func HandleTunnel(w http.ResponseWriter, r *http.Request) {
 
 		session.muxSession.Close()
 		session.ws.Close()
+		if session.expiry != nil {
+			session.expiry.Stop()
+		}
 
 		tunnelMutex.Lock()
-		delete(tunnels, webName)
+		if tunnels[webName] == session {
+			delete(tunnels, webName)
+		}
 		tunnelMutex.Unlock()
 		logger.Info("closed", "webName", webName)
 
@@ -145,6 +168,14 @@ func HandleTunnel(w http.ResponseWriter, r *http.Request) {
 	tunnels[webName] = session
 	tunnelMutex.Unlock()
 
+	// Close the tunnel once the policy expires
+	if policy != nil && policy.ExpiresAt != nil {
+		session.expiry = time.AfterFunc(time.Until(*policy.ExpiresAt), func() {
+			logger.Info("tunnel expired", "webName", webName)
+			closeTunnel(webName, session)
+		})
+	}
+
 	// Notify clients (the owning user's browser) that the tunnel list changed.
 	sse.PublishTunnelsChanged(user.Id)
 }
@@ -164,15 +195,36 @@ func CountUserTunnels(userId string) uint32 {
 	return count
 }
 
-func GetTunnelsForUser(userId string) []string {
-	var userTunnels []string
+// CountTunnels returns the number of tunnels open on this server by type, web
+// or port.
+func CountTunnels() map[string]int {
+	counts := map[string]int{"web": 0, "port": 0}
+
+	tunnelMutex.RLock()
+	defer tunnelMutex.RUnlock()
+
+	for _, t := range tunnels {
+		if t.tunnelType == PortTunnel {
+			counts["port"]++
+		} else {
+			counts["web"]++
+		}
+	}
+
+	return counts
+}
+
+// GetTunnelsForUser returns the access policy of each of the user's web
+// tunnels keyed by tunnel name.
+func GetTunnelsForUser(userId string) map[string]apiclient.TunnelAccess {
+	userTunnels := make(map[string]apiclient.TunnelAccess)
 
 	tunnelMutex.RLock()
 	defer tunnelMutex.RUnlock()
 
 	for _, t := range tunnels {
 		if t.user.Id == userId {
-			userTunnels = append(userTunnels, t.tunnelName)
+			userTunnels[t.tunnelName] = t.policy.Access()
 		}
 	}
 
@@ -188,32 +240,49 @@ func DeleteTunnel(userId, tunnelName string) error {
 	}
 	tunnelName = parts[1]
 
-	tunnelMutex.Lock()
-	defer tunnelMutex.Unlock()
-
+	tunnelMutex.RLock()
 	for key, t := range tunnels {
 		if t.user.Id == userId && t.tunnelName == tunnelName {
-			// Open a new stream to the tunnel client
-			stream, err := t.muxSession.Open()
-			if err == nil {
-				defer stream.Close()
+			tunnelMutex.RUnlock()
+			closeTunnel(key, t)
+			return nil
+		}
+	}
+	tunnelMutex.RUnlock()
 
-				// Write a byte with a value of 0 so the client knows to close the stream
-				stream.Write([]byte{0})
+	return fmt.Errorf("tunnel not found")
+}
 
-				// Wait for the client to close the stream
-				time.Sleep(1 * time.Second)
-			}
+// closeTunnel removes the tunnel if it is still registered under key then
+// tells the client it is closing. The caller must not hold tunnelMutex as
+// waiting for the client is done without it.
+func closeTunnel(key string, t *tunnelSession) {
+	tunnelMutex.Lock()
+	if tunnels[key] != t {
+		tunnelMutex.Unlock()
+		return
+	}
+	delete(tunnels, key)
+	tunnelMutex.Unlock()
 
-			t.muxSession.Close()
-			t.ws.Close()
-			delete(tunnels, key)
+	// Open a new stream to the tunnel client
+	stream, err := t.muxSession.Open()
+	if err == nil {
+		defer stream.Close()
 
-			// Notify clients the tunnel was removed.
-			sse.PublishTunnelsDeleted(key, userId)
-			return nil
-		}
+		// Write a byte with a value of 0 so the client knows to close the stream
+		stream.Write([]byte{0})
+
+		// Wait for the client to close the stream
+		time.Sleep(1 * time.Second)
 	}
 
-	return fmt.Errorf("tunnel not found")
+	if t.expiry != nil {
+		t.expiry.Stop()
+	}
+	t.muxSession.Close()
+	t.ws.Close()
+
+	// Notify clients the tunnel was removed.
+	sse.PublishTunnelsDeleted(key, t.user.Id)
 }
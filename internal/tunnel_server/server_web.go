@@ -34,6 +34,10 @@ func HandleWebTunnel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !authorizeRequest(w, r, domainParts[0], session) {
+		return
+	}
+
 	// Open a new stream to the tunnel client
 	stream, err := session.muxSession.Open()
 	if err != nil {
@@ -55,8 +59,16 @@ func HandleWebTunnel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Capture the request and response for inspection
+	capture := captureRequest(r)
+	writer := &captureWriter{ResponseWriter: w, capture: capture}
+	start := time.Now()
+
 	httpProxy := reverseProxy(targetURL, stream, nil, r.Host)
-	httpProxy.ServeHTTP(w, r)
+	httpProxy.ServeHTTP(writer, r)
+
+	writer.finish(start)
+	session.requests.add(capture)
 }
 
 // Start a web server to listen for connections to tunnels, the left most part of the domain is the <username>--<tunnel name>
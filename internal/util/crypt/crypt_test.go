@@ -1,7 +1,10 @@
 package crypt
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/binary"
+	"io"
 	"testing"
 )
 
@@ -132,3 +135,63 @@ func TestDecryptB64ShortInput(t *testing.T) {
 		t.Errorf("Expected empty string for short input, got %q", result)
 	}
 }
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := CreateKey()
+
+	for _, size := range []int{0, 10, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 17} {
+		plaintext := bytes.Repeat([]byte("knot"), size/4+1)[:size]
+
+		var encrypted bytes.Buffer
+		writer, err := NewStreamWriter(key, &encrypted)
+		if err != nil {
+			t.Fatalf("NewStreamWriter failed: %v", err)
+		}
+		// Write in odd sized pieces to exercise chunk boundaries
+		for offset := 0; offset < len(plaintext); offset += 1000 {
+			end := min(offset+1000, len(plaintext))
+			if _, err := writer.Write(plaintext[offset:end]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		reader, err := NewStreamReader(key, &encrypted)
+		if err != nil {
+			t.Fatalf("NewStreamReader failed: %v", err)
+		}
+		decrypted, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("size %d: ReadAll failed: %v", size, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("size %d: decrypted stream doesn't match original", size)
+		}
+	}
+}
+
+func TestStreamDetectsTruncationAndWrongKey(t *testing.T) {
+	key := CreateKey()
+	plaintext := bytes.Repeat([]byte("x"), 2*streamChunkSize+5)
+
+	var encrypted bytes.Buffer
+	writer, _ := NewStreamWriter(key, &encrypted)
+	writer.Write(plaintext)
+	writer.Close()
+
+	// Drop the final chunk
+	data := encrypted.Bytes()
+	firstFrame := 4 + int(binary.BigEndian.Uint32(data[:4]))
+	secondFrame := firstFrame + 4 + int(binary.BigEndian.Uint32(data[firstFrame:firstFrame+4]))
+	reader, _ := NewStreamReader(key, bytes.NewReader(data[:secondFrame]))
+	if _, err := io.ReadAll(reader); err != ErrStreamTruncated {
+		t.Errorf("Expected truncation error, got %v", err)
+	}
+
+	reader, _ = NewStreamReader(CreateKey(), bytes.NewReader(data))
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Error("Expected error decrypting with the wrong key")
+	}
+}
@@ -0,0 +1,194 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	streamChunkSize    = 64 * 1024
+	streamMaxFrameSize = streamChunkSize + 1024
+)
+
+var ErrStreamTruncated = errors.New("encrypted stream is truncated")
+
+// StreamWriter encrypts data with AES-GCM as it is written. Data is sealed in
+// length prefixed chunks so large payloads never have to be held in memory;
+// each chunk is bound to its position in the stream and the last chunk is
+// flagged so reordering or truncation is detected on read.
+type StreamWriter struct {
+	w       io.Writer
+	gcm     cipher.AEAD
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+// NewStreamWriter returns a writer that encrypts to w using the 32 byte key.
+// Close must be called to write the final chunk.
+func NewStreamWriter(key string, w io.Writer) (*StreamWriter, error) {
+	gcm, err := newStreamGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamWriter{
+		w:   w,
+		gcm: gcm,
+		buf: make([]byte, 0, streamChunkSize),
+	}, nil
+}
+
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+
+		// Only seal a full buffer once more data arrives so the final chunk is
+		// always written by Close with the final flag set
+		if len(s.buf) == cap(s.buf) && len(p) > 0 {
+			if err := s.seal(false); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close seals the remaining data as the final chunk, it does not close the
+// underlying writer.
+func (s *StreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.seal(true)
+}
+
+func (s *StreamWriter) seal(final bool) error {
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	frame := s.gcm.Seal(nonce, nonce, s.buf, streamChunkAAD(s.counter, final))
+	s.counter++
+	s.buf = s.buf[:0]
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(frame)))
+	if _, err := s.w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := s.w.Write(frame)
+	return err
+}
+
+// StreamReader decrypts a stream written by StreamWriter.
+type StreamReader struct {
+	r       io.Reader
+	gcm     cipher.AEAD
+	buf     []byte
+	counter uint64
+	done    bool
+}
+
+// NewStreamReader returns a reader that decrypts r using the 32 byte key.
+func NewStreamReader(key string, r io.Reader) (*StreamReader, error) {
+	gcm, err := newStreamGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamReader{
+		r:   r,
+		gcm: gcm,
+	}, nil
+}
+
+func (s *StreamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+func (s *StreamReader) open() error {
+	var size [4]byte
+	if _, err := io.ReadFull(s.r, size[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamTruncated
+		}
+		return err
+	}
+
+	frameSize := binary.BigEndian.Uint32(size[:])
+	nonceSize := s.gcm.NonceSize()
+	if frameSize < uint32(nonceSize) || frameSize > streamMaxFrameSize {
+		return fmt.Errorf("invalid encrypted chunk size %d", frameSize)
+	}
+
+	frame := make([]byte, frameSize)
+	if _, err := io.ReadFull(s.r, frame); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrStreamTruncated
+		}
+		return err
+	}
+
+	nonce, ciphertext := frame[:nonceSize], frame[nonceSize:]
+
+	// The final flag is authenticated so try the intermediate chunk first and
+	// fall back to the final chunk
+	plaintext, err := s.gcm.Open(nil, nonce, ciphertext, streamChunkAAD(s.counter, false))
+	if err != nil {
+		plaintext, err = s.gcm.Open(nil, nonce, ciphertext, streamChunkAAD(s.counter, true))
+		if err != nil {
+			return errors.New("failed to decrypt stream, wrong key or corrupt data")
+		}
+		s.done = true
+	}
+
+	s.counter++
+	s.buf = plaintext
+	return nil
+}
+
+func newStreamGCM(key string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func streamChunkAAD(counter uint64, final bool) []byte {
+	aad := make([]byte, 9)
+	binary.BigEndian.PutUint64(aad, counter)
+	if final {
+		aad[8] = 1
+	}
+	return aad
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/paularlott/knot/build"
+	"github.com/paularlott/knot/internal/tracing"
 
 	"github.com/shamaton/msgpack/v3"
 	"golang.org/x/net/http2"
@@ -261,6 +262,8 @@ func (c *HTTPClient) setHeaders(req *http.Request) {
 	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
+
+	tracing.Inject(req.Context(), req.Header)
 }
 
 func (c *HTTPClient) Get(ctx context.Context, path string, response interface{}) (int, error) {
@@ -415,6 +418,7 @@ func (c *HTTPClient) SendDataWithContentTypeAndAccept(ctx context.Context, metho
 	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -484,6 +488,7 @@ func (c *HTTPClient) GetJSON(ctx context.Context, path string, response interfac
 	for key, value := range c.headers {
 		req.Header.Set(key, value)
 	}
+	tracing.Inject(ctx, req.Header)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -520,15 +525,8 @@ func (c *HTTPClient) streamDataCore(
 	fn StreamResponseFunc,
 	createChunk func() interface{}, // Factory function to create typed chunks
 ) error {
-	// Marshal request based on content type
-	var data []byte
-	var err error
-
-	if c.contentType == ContentTypeMsgPack {
-		data, err = msgpack.Marshal(request)
-	} else {
-		data, err = json.Marshal(request)
-	}
+	// SSE streams always use JSON
+	data, err := json.Marshal(request)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -548,6 +546,8 @@ func (c *HTTPClient) streamDataCore(
 
 	// Set headers
 	c.setHeaders(req)
+	req.Header.Set("Content-Type", ContentTypeJSON)
+	req.Header.Set("Accept", "text/event-stream")
 
 	// For streaming requests, we need to temporarily disable the client timeout
 	// and rely on the context for cancellation instead
@@ -2,7 +2,10 @@ package util
 
 import (
 	"bufio"
+	"slices"
 	"strings"
+
+	"golang.org/x/crypto/ssh"
 )
 
 func SplitSSHPublicKeys(keys string) []string {
@@ -18,3 +21,85 @@ func SplitSSHPublicKeys(keys string) []string {
 
 	return sshKeys
 }
+
+// Authorized key options and certificate extensions controlling port
+// forwarding in each direction. Only knot's SSH server understands the
+// single direction options, OpenSSH is given no-port-forwarding in their place.
+const (
+	SSHOptionNoPortForwarding    = "no-port-forwarding"
+	SSHOptionNoLocalForwarding   = "no-local-forwarding"
+	SSHOptionNoRemoteForwarding  = "no-remote-forwarding"
+	SSHExtensionPortForwarding   = "permit-port-forwarding"
+	SSHExtensionLocalForwarding  = "permit-local-forwarding@getknot.dev"
+	SSHExtensionRemoteForwarding = "permit-remote-forwarding@getknot.dev"
+)
+
+// SSHForwardingOptions returns the authorized key options for a key allowed
+// to forward ports in the given directions.
+func SSHForwardingOptions(local, remote bool) string {
+	switch {
+	case !local && !remote:
+		return SSHOptionNoPortForwarding
+	case !local:
+		return SSHOptionNoLocalForwarding
+	case !remote:
+		return SSHOptionNoRemoteForwarding
+	}
+	return ""
+}
+
+// AddSSHKeyOptions prefixes an authorized key line with comma separated
+// options, merging them with any options the line already has.
+func AddSSHKeyOptions(key, options string) string {
+	if options == "" {
+		return key
+	}
+	if _, _, existing, _, err := ssh.ParseAuthorizedKey([]byte(key)); err == nil && len(existing) > 0 {
+		return options + "," + key
+	}
+	return options + " " + key
+}
+
+// OpenSSHAuthorizedKey rewrites the single direction forwarding options of an
+// authorized key line for OpenSSH.
+func OpenSSHAuthorizedKey(key string) string {
+	publicKey, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil || (!slices.Contains(options, SSHOptionNoLocalForwarding) && !slices.Contains(options, SSHOptionNoRemoteForwarding)) {
+		return key
+	}
+
+	kept := slices.DeleteFunc(options, func(option string) bool {
+		return option == SSHOptionNoLocalForwarding || option == SSHOptionNoRemoteForwarding || option == SSHOptionNoPortForwarding
+	})
+	kept = append(kept, SSHOptionNoPortForwarding)
+
+	line := strings.Join(kept, ",") + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// GitHubKeyEntries prefixes each GitHub username with the options for the
+// user's keys, like an authorized key. The options are sent to the agent
+// apart from the usernames so older agents still see plain usernames.
+func GitHubKeyEntries(usernames, options []string) []string {
+	entries := make([]string, 0, len(usernames))
+	for i, username := range usernames {
+		if i < len(options) && options[i] != "" {
+			username = options[i] + " " + username
+		}
+		entries = append(entries, username)
+	}
+	return entries
+}
+
+// SplitGitHubUsername splits an entry made by GitHubKeyEntries into its
+// options and GitHub username.
+func SplitGitHubUsername(entry string) (options, username string) {
+	entry = strings.TrimSpace(entry)
+	if i := strings.LastIndexByte(entry, ' '); i >= 0 {
+		return strings.TrimSpace(entry[:i]), entry[i+1:]
+	}
+	return "", entry
+}
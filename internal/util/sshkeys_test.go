@@ -18,3 +18,59 @@ func TestSplitSSHPublicKeys(t *testing.T) {
 		t.Fatalf("SplitSSHPublicKeys() = %#v, want %#v", got, want)
 	}
 }
+
+const testSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGJmYzFhNjQ0ZmEzZmI0ZGM2ZWY1NTQ2ZmM3NjRhMDk0 user@example.com"
+
+func TestAddSSHKeyOptions(t *testing.T) {
+	tests := []struct {
+		key, options, want string
+	}{
+		{testSSHKey, "", testSSHKey},
+		{testSSHKey, "no-local-forwarding", "no-local-forwarding " + testSSHKey},
+		{"cert-authority " + testSSHKey, "no-port-forwarding", "no-port-forwarding,cert-authority " + testSSHKey},
+	}
+	for _, tt := range tests {
+		if got := AddSSHKeyOptions(tt.key, tt.options); got != tt.want {
+			t.Errorf("AddSSHKeyOptions(%q, %q) = %q, want %q", tt.key, tt.options, got, tt.want)
+		}
+	}
+}
+
+func TestOpenSSHAuthorizedKey(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{testSSHKey, testSSHKey},
+		{"no-port-forwarding " + testSSHKey, "no-port-forwarding " + testSSHKey},
+		{"no-local-forwarding " + testSSHKey, "no-port-forwarding " + testSSHKey},
+		{"no-remote-forwarding,cert-authority " + testSSHKey, "cert-authority,no-port-forwarding " + testSSHKey},
+	}
+	for _, tt := range tests {
+		if got := OpenSSHAuthorizedKey(tt.key); got != tt.want {
+			t.Errorf("OpenSSHAuthorizedKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubKeyEntries(t *testing.T) {
+	got := GitHubKeyEntries([]string{"octocat", "hubot", "monalisa"}, []string{"no-port-forwarding", ""})
+	want := []string{"no-port-forwarding octocat", "hubot", "monalisa"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitHubKeyEntries() = %v, want %v", got, want)
+	}
+
+	for i, entry := range got {
+		if _, username := SplitGitHubUsername(entry); username != []string{"octocat", "hubot", "monalisa"}[i] {
+			t.Errorf("SplitGitHubUsername(%q) username = %q", entry, username)
+		}
+	}
+}
+
+func TestSplitGitHubUsername(t *testing.T) {
+	if options, username := SplitGitHubUsername("octocat"); options != "" || username != "octocat" {
+		t.Errorf("SplitGitHubUsername() = %q, %q", options, username)
+	}
+	if options, username := SplitGitHubUsername("no-port-forwarding octocat"); options != "no-port-forwarding" || username != "octocat" {
+		t.Errorf("SplitGitHubUsername() = %q, %q", options, username)
+	}
+}
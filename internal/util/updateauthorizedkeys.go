@@ -20,18 +20,20 @@ func UpdateAuthorizedKeys(keys []string, githubUsernames []string) error {
 	// Merge the keys into a single string
 	for _, key := range keys {
 		for _, sshKey := range SplitSSHPublicKeys(key) {
-			combinedKeys += sshKey + "\n"
+			combinedKeys += OpenSSHAuthorizedKey(sshKey) + "\n"
 		}
 	}
 
 	// If the github username is not empty, then download the keys from github
 	log.Debug("Downloading keys from GitHub")
 	for _, githubUsername := range githubUsernames {
-		githubKeys, err := GetGitHubKeys(githubUsername)
+		githubKeys, err := GetGitHubKeysArray(githubUsername)
 		if err != nil {
 			return err
 		}
-		combinedKeys += githubKeys + "\n"
+		for _, githubKey := range githubKeys {
+			combinedKeys += OpenSSHAuthorizedKey(githubKey) + "\n"
+		}
 	}
 
 	home, err := os.UserHomeDir()
@@ -115,9 +117,12 @@ func GetGitHubKeys(username string) (string, error) {
 	return string(body), nil
 }
 
+// GetGitHubKeysArray downloads the keys of a GitHub user, any options before
+// the username are added to each key.
 func GetGitHubKeysArray(username string) ([]string, error) {
 	keys := []string{}
 
+	options, username := SplitGitHubUsername(username)
 	ghKeys, err := GetGitHubKeys(username)
 	if err != nil {
 		return keys, err
@@ -125,7 +130,9 @@ func GetGitHubKeysArray(username string) ([]string, error) {
 
 	scanner := bufio.NewScanner(strings.NewReader(ghKeys))
 	for scanner.Scan() {
-		keys = append(keys, scanner.Text())
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			keys = append(keys, AddSSHKeyOptions(key, options))
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
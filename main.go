@@ -137,6 +137,7 @@ It offers both a user-friendly web interface and a command line interface to str
 			command_spaces.SpacesCmd,
 			command_stack.StackCmd,
 			command_ssh_config.SshConfigCmd,
+			command_ssh_config.SshCertCmd,
 			command_templates.TemplatesCmd,
 			commands_admin.AdminCmd,
 			command_tunnel.DesktopTunnelCmd,
@@ -3,6 +3,7 @@ package web
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/paularlott/gossip/hlc"
@@ -11,6 +12,7 @@ import (
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/middleware"
+	"github.com/paularlott/knot/internal/oidc"
 	"github.com/paularlott/knot/internal/service"
 	"github.com/paularlott/knot/internal/sse"
 
@@ -38,30 +40,23 @@ func HandleLoginPage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Parse the URL to redirect to to get just the path
-		var redirect string
-		redirectParam := r.URL.Query().Get("redirect")
-		u, _ := url.Parse(redirectParam)
-		if u.Path == "" || u.Path == "/logout" {
-			redirect = "/spaces"
-		} else if u.Path[0:1] != "/" {
-			redirect = "/" + u.Path
-		} else {
-			// Preserve both path and query parameters for OAuth redirects
-			if u.RawQuery != "" {
-				redirect = u.Path + "?" + u.RawQuery
-			} else {
-				redirect = u.Path
-			}
+		authProviders := []map[string]string{}
+		for _, provider := range oidc.GetProviders() {
+			authProviders = append(authProviders, map[string]string{
+				"ID":          provider.Id(),
+				"DisplayName": provider.DisplayName(),
+			})
 		}
 
 		data := map[string]interface{}{
-			"redirect":            redirect,
+			"redirect":            loginRedirect(r.URL.Query().Get("redirect")),
 			"version":             build.Version,
 			"totpEnabled":         cfg.TOTP.Enabled,
 			"logoURL":             cfg.UI.LogoURL,
 			"logoInvert":          cfg.UI.LogoInvert,
 			"passwordAuthEnabled": true,
+			"authProviders":       authProviders,
+			"loginError":          oidcErrorMessages[r.URL.Query().Get("error")],
 		}
 
 		err = tmpl.Execute(w, data)
@@ -71,6 +66,29 @@ func HandleLoginPage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// loginRedirect reduces the redirect parameter to a local path, keeping the
+// query for OAuth redirects. Paths a browser would treat as another host, such
+// as //host or /\host, fall back to the spaces page.
+func loginRedirect(redirectParam string) string {
+	u, err := url.Parse(redirectParam)
+	if err != nil || u.Path == "" || u.Path == "/logout" {
+		return "/spaces"
+	}
+
+	redirect := u.Path
+	if redirect[0:1] != "/" {
+		redirect = "/" + redirect
+	}
+	if strings.HasPrefix(redirect, "//") || strings.Contains(redirect, "\\") {
+		return "/spaces"
+	}
+
+	if u.RawQuery != "" {
+		return redirect + "?" + u.RawQuery
+	}
+	return redirect
+}
+
 func HandleLogoutPage(w http.ResponseWriter, r *http.Request) {
 	session := r.Context().Value("session").(*model.Session)
 	if session != nil {
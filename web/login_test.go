@@ -0,0 +1,30 @@
+package web
+
+import "testing"
+
+func TestLoginRedirect(t *testing.T) {
+	tests := []struct {
+		redirect string
+		want     string
+	}{
+		{"", "/spaces"},
+		{"/logout", "/spaces"},
+		{"/templates", "/templates"},
+		{"templates", "/templates"},
+		{"/oauth/authorize?client_id=abc", "/oauth/authorize?client_id=abc"},
+		{"https://evil.example/spaces", "/spaces"},
+		{"//evil.example", "/spaces"},
+		{"//evil.example/path", "/path"},
+		{"///evil.example", "/spaces"},
+		{"/%2Fevil.example", "/spaces"},
+		{"/\\evil.example", "/spaces"},
+		{"\\\\evil.example", "/spaces"},
+		{"/spaces\\..\\evil", "/spaces"},
+	}
+
+	for _, tt := range tests {
+		if got := loginRedirect(tt.redirect); got != tt.want {
+			t.Errorf("loginRedirect(%q) = %q, want %q", tt.redirect, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 	_ "time/tzdata"
 
+	"github.com/paularlott/knot/build"
 	"github.com/paularlott/knot/internal/agentapi/agent_server"
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/config"
@@ -63,9 +64,12 @@ func HandleLogsPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := map[string]interface{}{
-		"shell":    "",
+		"version":  build.Version,
 		"renderer": renderer,
 		"spaceId":  spaceId,
+		"level":    r.URL.Query().Get("level"),
+		"source":   r.URL.Query().Get("source"),
+		"q":        r.URL.Query().Get("q"),
 	}
 
 	err = tmpl.Execute(w, data)
@@ -106,6 +110,15 @@ func HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Filter the history and live lines, the cursor option appends the id of
+	// the oldest line to the end of history marker for paging further back
+	filter, err := model.SpaceLogFilterFromQuery(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	withCursor := r.URL.Query().Get("cursor") == "true"
+
 	ws := util.UpgradeToWS(w, r)
 	if ws == nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -113,49 +126,69 @@ func HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 	}
 	defer ws.Close()
 
-	// Get the agent session
+	// Get the agent session, without one only stored history can be shown
 	agentSession := agent_server.GetSession(spaceId)
-	if agentSession == nil {
+	if agentSession == nil && config.GetServerConfig().SpaceLogs.Retention < 1 {
 		w.WriteHeader(http.StatusNotFound)
 		ws.Close()
 		return
 	}
 
 	// Register a notification channel with the session
-	listenerId, channel := agentSession.RegisterLogListener()
-	if channel == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		ws.Close()
-		return
+	var listenerId string
+	var channel chan *msg.LogMessage
+	if agentSession != nil {
+		listenerId, channel = agentSession.RegisterLogListener()
+		if channel == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			ws.Close()
+			return
+		}
 	}
 
 	// Monitor for the websocket closing
+	closed := make(chan struct{})
 	go func() {
+		defer close(closed)
 		for {
 			_, _, err := ws.ReadMessage()
 			if err != nil {
 				logger.WithError(err).Debug("websocket closed")
-				agentSession.UnregisterLogListener(listenerId)
+				if agentSession != nil {
+					agentSession.UnregisterLogListener(listenerId)
+				}
 				return
 			}
 		}
 	}()
 
-	// Write the log history to the websocket
-	agentSession.LogHistoryMutex.RLock()
-	for _, logMessage := range agentSession.LogHistory {
-		if err := writeLogMessage(ws, logMessage, location); err != nil {
+	// Write the log history to the websocket, oldest first
+	history, err := agent_server.QuerySpaceLogs(spaceId, filter, agent_server.AGENT_SESSION_LOG_HISTORY)
+	if err != nil {
+		logger.WithError(err).Error("error loading log history")
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if err := writeLogMessage(ws, agent_server.LogMessageFromEntry(history[i]), location); err != nil {
 			logger.WithError(err).Error("error writing message")
-			agentSession.LogHistoryMutex.RUnlock()
 			return
 		}
 	}
-	agentSession.LogHistoryMutex.RUnlock()
 
 	// Send a marker to indicate the end of the history
-	ws.WriteMessage(websocket.TextMessage, []byte{0})
+	marker := []byte{0}
+	if withCursor && len(history) == agent_server.AGENT_SESSION_LOG_HISTORY {
+		marker = append(marker, history[len(history)-1].Id...)
+	}
+	ws.WriteMessage(websocket.TextMessage, marker)
 
-	// Simulate streaming logs
+	// Without a running agent there's nothing to follow
+	if agentSession == nil {
+		<-closed
+		return
+	}
+
+	// Live lines are newer than any time range or cursor so only match content
+	liveFilter := &model.SpaceLogFilter{Level: filter.Level, Source: filter.Source, Query: filter.Query}
 	for {
 		// Wait for a log message
 		logMessage, ok := <-channel
@@ -163,6 +196,16 @@ func HandleLogsStream(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		entry := model.SpaceLogEntry{
+			Level:     agent_server.SpaceLogLevel(logMessage.Level),
+			Source:    logMessage.Service,
+			Message:   logMessage.Message,
+			CreatedAt: logMessage.Date,
+		}
+		if !entry.MatchesFilter(liveFilter) {
+			continue
+		}
+
 		// Write the log message to the websocket
 		if err := writeLogMessage(ws, logMessage, location); err != nil {
 			logger.WithError(err).Error("error writing message")
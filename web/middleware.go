@@ -62,6 +62,10 @@ func checkPermissionViewAuditLogs(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionViewAuditLogs)
 }
 
+func checkPermissionViewAIUsage(next http.HandlerFunc) http.HandlerFunc {
+	return checkPermission(next, model.PermissionViewAIUsage)
+}
+
 func checkPermissionManageUsers(next http.HandlerFunc) http.HandlerFunc {
 	return checkPermission(next, model.PermissionManageUsers)
 }
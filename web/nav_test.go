@@ -45,6 +45,29 @@ func TestBuildNav_LeafNode_PromotesTemplatesAndVariables(t *testing.T) {
 	assertEqual(t, wantMore, urls(more), "leaf-node more section")
 }
 
+func TestBuildNav_AIUsageRequiresChat(t *testing.T) {
+	u := adminUser(t)
+	cfg := &config.ServerConfig{}
+	cfg.Chat.Enabled = true
+
+	_, more := buildNav(u, cfg, true)
+
+	wantMore := []string{
+		"/stacks", "/variables", "/templates", "/scripts", "/events",
+		"/skills", "/commands", "/mcp-servers", "/users", "/groups",
+		"/roles", "/audit-logs", "/llm-usage",
+	}
+	assertEqual(t, wantMore, urls(more), "more section with chat enabled")
+
+	cfg.LeafNode = true
+	_, more = buildNav(u, cfg, true)
+	for _, it := range more {
+		if it.URL == "/llm-usage" {
+			t.Fatalf("AI usage must be hidden on a leaf node")
+		}
+	}
+}
+
 func TestResolveNav_ModeA_NoPins(t *testing.T) {
 	u := adminUser(t)
 	u.SetNavStarred(nil)
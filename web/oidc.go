@@ -0,0 +1,198 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/paularlott/knot/internal/database"
+	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/middleware"
+	"github.com/paularlott/knot/internal/oidc"
+	"github.com/paularlott/knot/internal/service"
+	"github.com/paularlott/knot/internal/sse"
+	"github.com/paularlott/knot/internal/util/audit"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+// HandleOIDCStart sends the browser to the provider to sign in, or with
+// link=true to link the provider to the signed in user.
+func HandleOIDCStart(w http.ResponseWriter, r *http.Request) {
+	provider := oidc.GetProvider(r.PathValue("provider"))
+	if provider == nil {
+		showPageNotFound(w, r)
+		return
+	}
+
+	linkUserId := ""
+	redirect := loginRedirect(r.URL.Query().Get("redirect"))
+	if r.URL.Query().Get("link") == "true" {
+		session, _ := middleware.GetSessionFromCookie(r)
+		if session == nil {
+			http.Redirect(w, r, "/login?redirect="+url.QueryEscape(r.URL.String()), http.StatusSeeOther)
+			return
+		}
+		linkUserId = session.UserId
+	}
+
+	loginState, err := oidc.NewLoginState(provider.Id(), redirect, linkUserId)
+	if err != nil {
+		log.Error("oidc: unable to create login state", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), provider.CallbackURL(), loginState.State, loginState.Nonce, loginState.Verifier)
+	if err != nil {
+		log.Error("oidc: unable to start login", "provider", provider.Id(), "error", err)
+		loginFailed(w, r, linkUserId != "", "unavailable")
+		return
+	}
+
+	if err := loginState.SetCookie(w); err != nil {
+		log.Error("oidc: unable to save login state", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusSeeOther)
+}
+
+// HandleOIDCCallback completes a login or link when the provider redirects
+// back with an authorization code.
+func HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider := oidc.GetProvider(r.PathValue("provider"))
+	if provider == nil {
+		showPageNotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	loginState, err := oidc.ConsumeLoginState(w, r, provider.Id(), query.Get("state"))
+	if err != nil {
+		log.Warn("oidc: rejected callback", "provider", provider.Id(), "error", err)
+		loginFailed(w, r, false, "expired")
+		return
+	}
+	linking := loginState.LinkUserId != ""
+
+	if idpError := query.Get("error"); idpError != "" {
+		log.Warn("oidc: provider returned an error", "provider", provider.Id(), "error", idpError, "description", query.Get("error_description"))
+		loginFailed(w, r, linking, "refused")
+		return
+	}
+
+	auditFailed := func(actor string, err error) {
+		audit.LogWithRequest(r,
+			actor,
+			model.AuditActorTypeUser,
+			model.AuditEventAuthFailed,
+			"",
+			&map[string]interface{}{
+				"agent":           r.UserAgent(),
+				"IP":              r.RemoteAddr,
+				"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+				"provider":        provider.Id(),
+				"error":           err.Error(),
+			},
+		)
+	}
+
+	identity, err := provider.Exchange(r.Context(), provider.CallbackURL(), query.Get("code"), loginState.Verifier, loginState.Nonce)
+	if err != nil {
+		log.Warn("oidc: login failed", "provider", provider.Id(), "error", err)
+		auditFailed(provider.Id(), err)
+		loginFailed(w, r, linking, "failed")
+		return
+	}
+
+	user, created, err := provider.LoginUser(identity, loginState.LinkUserId)
+	if err != nil {
+		auditFailed(identity.Email, err)
+		code := "failed"
+		switch {
+		case errors.Is(err, oidc.ErrNoAccount):
+			code = "no_account"
+		case errors.Is(err, oidc.ErrAlreadyLinked):
+			code = "linked"
+		case errors.Is(err, oidc.ErrUserInactive):
+			code = "inactive"
+		case errors.Is(err, oidc.ErrEmailInUse):
+			code = "email_in_use"
+		}
+		loginFailed(w, r, linking, code)
+		return
+	}
+	if created {
+		audit.LogWithRequest(r,
+			provider.Id(),
+			model.AuditActorTypeSystem,
+			model.AuditEventUserCreate,
+			fmt.Sprintf("Created user %s (%s) on first login", user.Username, user.Email),
+			&map[string]interface{}{
+				"agent":           r.UserAgent(),
+				"IP":              r.RemoteAddr,
+				"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+				"user_id":         user.Id,
+				"user_name":       user.Username,
+				"user_email":      user.Email,
+			},
+		)
+	}
+
+	// Linking keeps the user's existing session
+	if linking {
+		http.Redirect(w, r, loginState.Redirect, http.StatusSeeOther)
+		return
+	}
+
+	session := model.NewSession(r, user.Id)
+	if err := database.GetSessionStorage().SaveSession(session); err != nil {
+		log.Error("oidc: unable to save session", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	service.GetTransport().GossipSession(session)
+	sse.PublishSessionsChanged("")
+	middleware.SetSessionCookie(w, session)
+
+	audit.LogWithRequest(r,
+		user.Email,
+		model.AuditActorTypeUser,
+		model.AuditEventAuthOk,
+		"",
+		&map[string]interface{}{
+			"agent":           r.UserAgent(),
+			"IP":              r.RemoteAddr,
+			"X-Forwarded-For": r.Header.Get("X-Forwarded-For"),
+			"provider":        provider.Id(),
+		},
+	)
+
+	http.Redirect(w, r, loginState.Redirect, http.StatusSeeOther)
+}
+
+// oidcErrorMessages are shown on the login and profile pages for the error
+// codes a failed provider login redirects with.
+var oidcErrorMessages = map[string]string{
+	"unavailable":  "The sign in provider is unavailable, please try again later.",
+	"expired":      "Your sign in expired, please try again.",
+	"refused":      "Sign in was cancelled or refused by the provider.",
+	"failed":       "Your sign in could not be verified.",
+	"no_account":   "There is no account for your sign in, contact your administrator.",
+	"linked":       "That account is already linked to another user.",
+	"inactive":     "Your account is disabled.",
+	"email_in_use": "An account already exists with your email address, sign in with your password and link the provider from your profile.",
+}
+
+// loginFailed returns the browser to the login page, or the profile page when
+// linking, with an error code to show.
+func loginFailed(w http.ResponseWriter, r *http.Request, linking bool, code string) {
+	page := "/login"
+	if linking {
+		page = "/profile"
+	}
+	http.Redirect(w, r, page+"?error="+code, http.StatusSeeOther)
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/paularlott/knot/build"
+	"github.com/paularlott/knot/internal/config"
+	"github.com/paularlott/knot/internal/util/validate"
+
+	"github.com/paularlott/knot/internal/log"
+)
+
+func HandleRecordingPage(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space_id")
+	recordingId := r.PathValue("recording_id")
+	if !validate.UUID(spaceId) || !validate.UUID(recordingId) {
+		showPageNotFound(w, r)
+		return
+	}
+
+	tmpl, err := newTemplate("recording.tmpl")
+	if err != nil {
+		log.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	renderer := "canvas"
+	if config.GetServerConfig().TerminalWebGL {
+		renderer = "webgl"
+	}
+
+	data := map[string]interface{}{
+		"renderer":    renderer,
+		"spaceId":     spaceId,
+		"recordingId": recordingId,
+		"version":     build.Version,
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		log.Error(err.Error())
+	}
+}
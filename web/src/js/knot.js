@@ -37,6 +37,7 @@ import './pages/spaceUsageComponent.js';
 import './pages/usageComponent.js';
 import './pages/tunnelsListComponent.js';
 import './pages/auditLogComponent.js';
+import './pages/llmUsageComponent.js';
 import './pages/clusterInfoComponent.js';
 import './pages/scriptListComponent.js';
 import './pages/scriptForm.js';
@@ -52,6 +53,7 @@ import './pages/stackDefinitionBuilder.js';
 import './pages/stackListComponent.js';
 
 import './terminal.js';
+import './recording.js';
 import './movable-modal.js';
 import './nav-starred.js';
 import './search-palette.js';
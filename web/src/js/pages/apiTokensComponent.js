@@ -9,10 +9,11 @@ window.apiTokensComponent = function () {
     }
   });
 
-  // Available scopes. Currently just "methods". Each entry has:
+  // Available scopes, matching model.KnownTokenScopes. Each entry has:
   //   value: the scope string stored in the DB
   //   label: human-readable label for the checkbox
   //   description: short help text
+  // A write scope also grants the matching read scope.
   const availableScopes = [
     {
       value: "methods",
@@ -24,8 +25,141 @@ window.apiTokensComponent = function () {
       label: "MCP",
       description: "Use the MCP server endpoint (/mcp)",
     },
+    {
+      value: "spaces:read",
+      label: "Spaces (read)",
+      description: "List spaces and stacks, view space details and logs",
+    },
+    {
+      value: "spaces:write",
+      label: "Spaces (write)",
+      description: "Create, update, delete, start and stop spaces and stacks",
+    },
+    {
+      value: "spaces:exec",
+      label: "Spaces (exec)",
+      description: "Run commands, copy files, forward ports and open terminals in spaces",
+    },
+    {
+      value: "templates:read",
+      label: "Templates (read)",
+      description: "View templates, variables, stack definitions and base images",
+    },
+    {
+      value: "templates:write",
+      label: "Templates (write)",
+      description: "Create, update and delete templates and their resources",
+    },
+    {
+      value: "volumes:read",
+      label: "Volumes (read)",
+      description: "View volumes",
+    },
+    {
+      value: "volumes:write",
+      label: "Volumes (write)",
+      description: "Create, update, delete, start and stop volumes",
+    },
+    {
+      value: "pools:read",
+      label: "Pools (read)",
+      description: "View pools",
+    },
+    {
+      value: "pools:write",
+      label: "Pools (write)",
+      description: "Create, update, resize, start and stop pools",
+    },
+    {
+      value: "scripts:read",
+      label: "Scripts (read)",
+      description: "View scripts",
+    },
+    {
+      value: "scripts:write",
+      label: "Scripts (write)",
+      description: "Create, update and delete scripts",
+    },
+    {
+      value: "skills:read",
+      label: "Skills (read)",
+      description: "View skills, slash commands and MCP servers",
+    },
+    {
+      value: "skills:write",
+      label: "Skills (write)",
+      description: "Manage skills, slash commands and MCP servers",
+    },
+    {
+      value: "events:read",
+      label: "Event Sinks (read)",
+      description: "View event sinks",
+    },
+    {
+      value: "events:write",
+      label: "Event Sinks (write)",
+      description: "Manage event sinks",
+    },
+    {
+      value: "events:emit",
+      label: "Emit Events",
+      description: "Emit events from spaces and users",
+    },
+    {
+      value: "tunnels",
+      label: "Tunnels",
+      description: "Create and manage tunnels",
+    },
+    {
+      value: "assistant",
+      label: "Assistant",
+      description: "Use the OpenAI compatible assistant endpoints (/v1)",
+    },
+    {
+      value: "users:read",
+      label: "Users (read)",
+      description: "View users, groups and roles",
+    },
+    {
+      value: "users:write",
+      label: "Users (write)",
+      description: "Manage users, groups and roles",
+    },
+    {
+      value: "tokens",
+      label: "Tokens",
+      description: "Manage API tokens and sessions",
+    },
+    {
+      value: "audit:read",
+      label: "Audit Logs",
+      description: "View and export audit logs",
+    },
+    {
+      value: "cluster:read",
+      label: "Cluster",
+      description: "View cluster information",
+    },
+    {
+      value: "leaf",
+      label: "Leaf Node",
+      description: "Connect a leaf node",
+    },
+    {
+      value: "server:read",
+      label: "Server",
+      description: "View server information, search and icons",
+    },
   ];
 
+  // Helper: split a comma separated restriction list.
+  function splitList(value) {
+    return value
+      .split(",")
+      .map((v) => v.trim())
+      .filter((v) => v.length > 0);
+  }
+
   // Helper: build a scope-checkbox state object from the available scopes,
   // defaulting to all checked (scoped mode assumes you want every scope
   // unless you explicitly untick one). Called for form initialization.
@@ -57,6 +191,9 @@ window.apiTokensComponent = function () {
       name: "",
       fullAccess: true,
       scopes: defaultScopeState(),
+      spaces: "",
+      stacks: "",
+      sourceCidr: "",
     },
     nameValid: true,
 
@@ -67,6 +204,9 @@ window.apiTokensComponent = function () {
       name: "",
       fullAccess: false,
       scopes: defaultScopeState(),
+      spaces: "",
+      stacks: "",
+      sourceCidr: "",
     },
     editNameValid: true,
 
@@ -88,6 +228,9 @@ window.apiTokensComponent = function () {
           this.createForm.name = "";
           this.createForm.fullAccess = true;
           this.createForm.scopes = defaultScopeState();
+          this.createForm.spaces = "";
+          this.createForm.stacks = "";
+          this.createForm.sourceCidr = "";
           this.nameValid = true;
         }
       });
@@ -98,6 +241,9 @@ window.apiTokensComponent = function () {
           this.editForm.name = "";
           this.editForm.fullAccess = false;
           this.editForm.scopes = defaultScopeState();
+          this.editForm.spaces = "";
+          this.editForm.stacks = "";
+          this.editForm.sourceCidr = "";
           this.editNameValid = true;
         }
       });
@@ -225,6 +371,9 @@ window.apiTokensComponent = function () {
         scopes: this.createForm.fullAccess
           ? null
           : this.selectedScopes(this.createForm.scopes),
+        spaces: splitList(this.createForm.spaces),
+        stacks: splitList(this.createForm.stacks),
+        source_cidr: this.createForm.sourceCidr.trim(),
       };
 
       await fetch("/api/tokens", {
@@ -274,6 +423,9 @@ window.apiTokensComponent = function () {
           this.editForm.scopes[s] = true;
         });
       }
+      this.editForm.spaces = (token.spaces || []).join(", ");
+      this.editForm.stacks = (token.stacks || []).join(", ");
+      this.editForm.sourceCidr = token.source_cidr || "";
       this.editModal.show = true;
     },
 
@@ -304,6 +456,9 @@ window.apiTokensComponent = function () {
       const data = {
         name: this.editForm.name,
         scopes: scopes,
+        spaces: splitList(this.editForm.spaces),
+        stacks: splitList(this.editForm.stacks),
+        source_cidr: this.editForm.sourceCidr.trim(),
       };
 
       await fetch(`/api/tokens/${this.editForm.tokenId}`, {
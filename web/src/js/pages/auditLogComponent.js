@@ -1,3 +1,5 @@
+import { popup } from "../popup.js";
+
 window.auditLogComponent = function() {
   return {
     loading: true,
@@ -55,6 +57,10 @@ window.auditLogComponent = function() {
       });
     },
 
+    playRecording(spaceId, recordingId) {
+      popup.openRecording(spaceId, recordingId);
+    },
+
     downloadExport() {
       const params = new URLSearchParams({ format: this.exportModal.format });
       if (this.exportModal.from) {
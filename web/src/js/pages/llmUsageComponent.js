@@ -0,0 +1,69 @@
+window.llmUsageComponent = function() {
+  const today = new Date();
+  const pad = (n) => String(n).padStart(2, '0');
+  const dateString = (d) => `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())}`;
+
+  return {
+    loading: true,
+    from: dateString(new Date(today.getFullYear(), today.getMonth(), 1)),
+    to: dateString(today),
+    report: {
+      total: {},
+      users: [],
+      groups: [],
+      models: [],
+    },
+
+    async init() {
+      await this.getUsage();
+    },
+
+    async getUsage() {
+      if (!this.from || !this.to) {
+        return;
+      }
+
+      const from = new Date(`${this.from}T00:00:00Z`).toISOString();
+      const to = new Date(`${this.to}T23:59:59Z`).toISOString();
+
+      await fetch(`/api/llm-usage?from=${encodeURIComponent(from)}&to=${encodeURIComponent(to)}`, {
+        headers: {
+          'Content-Type': 'application/json'
+        }
+      }).then((response) => {
+        if (response.status === 200) {
+          response.json().then((report) => {
+            report.users = report.users || [];
+            report.groups = report.groups || [];
+            report.models = report.models || [];
+            this.report = report;
+            this.loading = false;
+          });
+        } else if (response.status === 401) {
+          window.location.href = '/logout';
+        } else {
+          response.json().then((d) => {
+            this.$dispatch('show-alert', { msg: `Failed to load the AI usage, ${d.error}`, type: 'error' });
+          });
+        }
+      }).catch(() => {
+        // Don't logout on network errors - Safari closes connections aggressively
+      });
+    },
+
+    formatTokens(n) {
+      return (n || 0).toLocaleString();
+    },
+
+    formatBudget(used, budget) {
+      if (!budget) {
+        return `${this.formatTokens(used)} / unlimited`;
+      }
+      return `${this.formatTokens(used)} / ${this.formatTokens(budget)}`;
+    },
+
+    overBudget(used, budget) {
+      return budget > 0 && used >= budget;
+    },
+  };
+}
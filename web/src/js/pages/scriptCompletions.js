@@ -334,6 +334,13 @@ const scriptLibraries = [
         description: "Set pool target size. The sweep loop creates, drains, or deletes spaces asynchronously.",
         returns: "bool - True if successful",
       },
+      {
+        name: "set_autoscale",
+        signature:
+          "set_autoscale(name, enabled=True, min_count=None, max_count=None, target_cpu_percent=None, target_rps_per_member=None, scale_up_cooldown=None, scale_down_cooldown=None, target_metric=None, target_metric_value=None)",
+        description: "Enable, update or disable autoscaling. Settings left as None keep their current value.",
+        returns: "dict - The pool's autoscale policy",
+      },
       {
         name: "start",
         signature: "start(name)",
@@ -604,7 +611,7 @@ const scriptLibraries = [
           {
             name: "method",
             signature:
-              'method(name, *, local_name="", description="", scope="private", keywords=[], groups=[], mcp_tool=False, params=None, result=None, events=[], event_sinks=[])',
+              'method(name, *, local_name="", description="", scope="private", keywords=[], groups=[], mcp_tool=False, streaming=False, params=None, result=None, events=[], event_sinks=[])',
             description:
               "Add a method definition. name may be passed positionally or as a kwarg. params and result are schema dicts from knot.methods.schema (or raw JSON Schema dicts). events subscribes the method to matching event types (e.g. ['space.*']). event_sinks references named JSON-RPC sink formatters (e.g. ['my-formatter']).",
             returns: "bool - True when added",
@@ -954,7 +961,7 @@ const scriptLibraries = [
         name: "create",
         signature: "create(name, ...)",
         description:
-          "Create a new group (optional kwargs: max_spaces, compute_units, storage_units, max_tunnels)",
+          "Create a new group (optional kwargs: max_spaces, compute_units, storage_units, max_tunnels, daily_token_budget, monthly_token_budget, allowed_models)",
         returns: "str - UUID of the newly created group",
       },
       {
@@ -423,7 +423,8 @@ window.spaceForm = function (
       if (
         this.template &&
         this.template.platform !== "manual" &&
-        this.template.platform !== "nomad"
+        this.template.platform !== "nomad" &&
+        this.template.platform !== "kubernetes"
       ) {
         this.loadingNodes = true;
         const nodesResponse = await fetch(
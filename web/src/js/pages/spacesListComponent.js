@@ -16,6 +16,21 @@ function debounce(func, wait) {
   };
 }
 
+// Autoscaling settings for a new pool, off until the user enables it
+function defaultPoolAutoscale() {
+  return {
+    enabled: false,
+    min_count: 1,
+    max_count: 4,
+    target_cpu_percent: 70,
+    target_rps_per_member: 0,
+    target_metric: '',
+    target_metric_value: 0,
+    scale_up_cooldown: 60,
+    scale_down_cooldown: 300,
+  };
+}
+
 window.spacesListComponent = function (
   userId,
   username,
@@ -92,6 +107,13 @@ window.spacesListComponent = function (
       spaceId: "",
       spaceName: "",
     },
+    terminalSessions: {
+      show: false,
+      space: null,
+      sessions: [],
+      name: "",
+      readOnly: false,
+    },
 
     showingSpecificUser: userId !== forUserId,
     forUserId:
@@ -145,6 +167,7 @@ window.spacesListComponent = function (
       templateId: "",
       startupScriptId: "",
       desiredCount: 1,
+      autoscale: defaultPoolAutoscale(),
       active: true,
       error: "",
       submitting: false,
@@ -604,6 +627,7 @@ window.spacesListComponent = function (
         templateId: "",
         startupScriptId: "",
         desiredCount: 1,
+        autoscale: defaultPoolAutoscale(),
         active: true,
         error: "",
         submitting: false,
@@ -651,6 +675,7 @@ window.spacesListComponent = function (
         templateId: current.template_id || "",
         startupScriptId: current.startup_script_id || "",
         desiredCount: current.desired_count || 1,
+        autoscale: { ...defaultPoolAutoscale(), ...(current.autoscale || {}) },
         active: current.active === true,
         error: "",
         submitting: false,
@@ -678,6 +703,17 @@ window.spacesListComponent = function (
           template_id: modal.templateId,
           startup_script_id: modal.startupScriptId.trim(),
           desired_count: Number.parseInt(modal.desiredCount, 10),
+          autoscale: {
+            enabled: modal.autoscale.enabled === true,
+            min_count: Number.parseInt(modal.autoscale.min_count, 10) || 0,
+            max_count: Number.parseInt(modal.autoscale.max_count, 10) || 0,
+            target_cpu_percent: Number(modal.autoscale.target_cpu_percent) || 0,
+            target_rps_per_member: Number(modal.autoscale.target_rps_per_member) || 0,
+            target_metric: (modal.autoscale.target_metric || '').trim(),
+            target_metric_value: Number(modal.autoscale.target_metric_value) || 0,
+            scale_up_cooldown: Number.parseInt(modal.autoscale.scale_up_cooldown, 10) || 0,
+            scale_down_cooldown: Number.parseInt(modal.autoscale.scale_down_cooldown, 10) || 0,
+          },
           active: modal.active === true,
         };
         const response = await fetch(
@@ -1080,6 +1116,72 @@ window.spacesListComponent = function (
     openLogWindow(spaceId) {
       popup.openLogWindow(spaceId);
     },
+    openTerminalSessions(space) {
+      this.terminalSessions.space = space;
+      this.terminalSessions.sessions = [];
+      this.terminalSessions.name = "";
+      this.terminalSessions.readOnly = false;
+      this.terminalSessions.show = true;
+      this.getTerminalSessions();
+    },
+    async getTerminalSessions() {
+      const spaceId = this.terminalSessions.space.space_id;
+      await fetch(`/api/spaces/${spaceId}/terminal-sessions`, {
+        headers: {
+          "Content-Type": "application/json",
+        },
+      })
+        .then((response) => {
+          if (response.status === 200) {
+            response.json().then((data) => {
+              this.terminalSessions.sessions = data.sessions;
+            });
+          }
+        })
+        .catch(() => {});
+    },
+    joinTerminalSession(name, readOnly) {
+      if (!validate.name(name)) {
+        this.$dispatch("show-alert", {
+          msg: "Session names start with a letter and contain only letters, numbers and dashes",
+          type: "error",
+        });
+        return;
+      }
+      popup.openSharedTerminal(this.terminalSessions.space.space_id, name, readOnly);
+      this.terminalSessions.show = false;
+    },
+    async detachTerminalSession(session, attachmentId = "") {
+      const self = this;
+      const spaceId = this.terminalSessions.space.space_id;
+      const path = attachmentId
+        ? `/api/spaces/${spaceId}/terminal-sessions/${session}/attachments/${attachmentId}`
+        : `/api/spaces/${spaceId}/terminal-sessions/${session}`;
+
+      await fetch(path, {
+        method: "DELETE",
+        headers: {
+          "Content-Type": "application/json",
+        },
+      })
+        .then((response) => {
+          if (response.status === 200) {
+            self.$dispatch("show-alert", {
+              msg: attachmentId ? "User detached from session" : "Session closed",
+              type: "success",
+            });
+          } else {
+            response.json().then((data) => {
+              self.$dispatch("show-alert", {
+                msg: `Could not update session: ${data.error}`,
+                type: "error",
+              });
+            });
+          }
+          self.getTerminalSessions();
+        })
+        .catch(() => {});
+    },
     openSpaceUsage(spaceId) {
       const space = this.spaces.find((item) => item.space_id === spaceId);
       this.spaceUsageModal.spaceId = spaceId;
@@ -1587,6 +1689,7 @@ window.spacesListComponent = function (
         templateId: templateId || "",
         startupScriptId: "",
         desiredCount: 1,
+        autoscale: defaultPoolAutoscale(),
         active: true,
         error: "",
         submitting: false,
@@ -269,6 +269,98 @@ export const nomadVolumeSpecCompletions = [
   },
 ];
 
+export const kubernetesJobCompletions = [
+  {
+    caption: "pod",
+    value:
+      'apiVersion: v1\nkind: Pod\nmetadata:\n  name: ${{ .user.username }}-${{ .space.name }}\nspec:\n  containers:\n    - name: space\n      image: "registry-1.docker.io/library/ubuntu:24.04"\n',
+    meta: "kubernetes",
+    score: 1000,
+    docHTML: docs("Pod", "Pod running the space, the manifest must hold exactly one Pod or StatefulSet."),
+  },
+  {
+    caption: "statefulset",
+    value:
+      'apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: ${{ .user.username }}-${{ .space.name }}\nspec:\n  replicas: 1\n  serviceName: ${{ .space.name }}\n  selector:\n    matchLabels:\n      app: ${{ .space.name }}\n  template:\n    metadata:\n      labels:\n        app: ${{ .space.name }}\n    spec:\n      containers:\n        - name: space\n          image: "registry-1.docker.io/library/ubuntu:24.04"\n',
+    meta: "kubernetes",
+    score: 990,
+    docHTML: docs("StatefulSet", "StatefulSet running the space, recreates the pod if it fails."),
+  },
+  {
+    caption: "env",
+    value: 'env:\n  - name: TZ\n    value: "${{ .user.timezone }}"',
+    meta: "kubernetes",
+    score: 980,
+    docHTML: docs("env", "Environment variables passed to the container."),
+  },
+  {
+    caption: "ports",
+    value: "ports:\n  - name: http\n    containerPort: 80",
+    meta: "kubernetes",
+    score: 970,
+    docHTML: docs("ports", "Ports exposed by the container."),
+  },
+  {
+    caption: "resources",
+    value: "resources:\n  requests:\n    cpu: 500m\n    memory: 1Gi\n  limits:\n    memory: 2Gi",
+    meta: "kubernetes",
+    score: 960,
+    docHTML: docs("resources", "CPU and memory requests and limits, compute units are added as requests when not set."),
+  },
+  {
+    caption: "volumeMounts",
+    value: "volumeMounts:\n  - name: home\n    mountPath: /home/user",
+    meta: "kubernetes",
+    score: 950,
+    docHTML: docs("volumeMounts", "Mounts the pod volumes into the container."),
+  },
+  {
+    caption: "persistentVolumeClaim",
+    value: "volumes:\n  - name: home\n    persistentVolumeClaim:\n      claimName: ${{ .space.id }}-home",
+    meta: "kubernetes",
+    score: 940,
+    docHTML: docs("persistentVolumeClaim", "Pod volume backed by a claim from the Volume Definition."),
+  },
+  {
+    caption: "securityContext",
+    value: 'securityContext:\n  capabilities:\n    add: ["NET_ADMIN"]',
+    meta: "kubernetes",
+    score: 930,
+    docHTML: docs("securityContext", "Privileged mode and Linux capabilities for the container."),
+  },
+];
+
+export const kubernetesVolumeSpecCompletions = [
+  {
+    caption: "volumes",
+    value: "volumes:\n  - name: ${{ .space.id }}-home\n    size: 10Gi\n",
+    meta: "volume",
+    score: 1000,
+    docHTML: docs("volumes", "List of persistent volume claims."),
+  },
+  {
+    caption: "storage_class",
+    value: "storage_class: standard",
+    meta: "volume",
+    score: 990,
+    docHTML: docs("storage_class", "Storage class of the claim, the cluster default is used if not set."),
+  },
+  {
+    caption: "access_modes",
+    value: "access_modes:\n  - ReadWriteOnce",
+    meta: "volume",
+    score: 980,
+    docHTML: docs("access_modes", "ReadWriteOnce, ReadOnlyMany, ReadWriteMany or ReadWriteOncePod."),
+  },
+  {
+    caption: "namespace",
+    value: "namespace: default",
+    meta: "volume",
+    score: 970,
+    docHTML: docs("namespace", "Namespace of the claim, defaults to the namespace of the space."),
+  },
+];
+
 // System + custom template variables available in job and volume templates.
 // These resolve at deploy time via the Go template engine using the ${{
 // delimiters. Suggested across nomad job, container, and volume editors.
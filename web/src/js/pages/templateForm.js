@@ -16,6 +16,8 @@ import "./aceEditorCompleter.js";
 import { setSpecCompleter } from "./aceSpecCompleter.js";
 import {
   containerSpecCompletions,
+  kubernetesJobCompletions,
+  kubernetesVolumeSpecCompletions,
   localVolumeSpecCompletions,
   nomadJobCompletions,
   nomadVolumeSpecCompletions,
@@ -43,6 +45,9 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
       with_code_server: false,
       with_ssh: false,
       with_run_command: false,
+      disable_ssh_local_forward: false,
+      with_ssh_reverse_forward: false,
+      with_terminal_recording: false,
       allow_node_migration: false,
       startup_script_id: "",
       shutdown_script_id: "",
@@ -51,6 +56,12 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
       active: true,
       max_uptime: 0,
       max_uptime_unit: "disabled",
+      idle_policy: {
+        enabled: false,
+        timeout: 45,
+        cpu_threshold: 5,
+        warn_before: 10,
+      },
       schedule_enabled: false,
       auto_start: false,
       is_managed: false,
@@ -202,6 +213,9 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
           this.formData.with_code_server = template.with_code_server;
           this.formData.with_ssh = template.with_ssh;
           this.formData.with_run_command = template.with_run_command;
+          this.formData.disable_ssh_local_forward = template.disable_ssh_local_forward;
+          this.formData.with_ssh_reverse_forward = template.with_ssh_reverse_forward;
+          this.formData.with_terminal_recording = template.with_terminal_recording;
           this.formData.allow_node_migration =
             template.allow_node_migration || false;
           this.formData.compute_units = template.compute_units;
@@ -212,6 +226,9 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
           this.formData.schedule = template.schedule;
           this.formData.max_uptime = template.max_uptime;
           this.formData.max_uptime_unit = template.max_uptime_unit;
+          if (template.idle_policy && template.idle_policy.enabled) {
+            this.formData.idle_policy = template.idle_policy;
+          }
           this.formData.icon_url = template.icon_url;
           this.formData.custom_fields = template.custom_fields;
           this.formData.ports = template.ports || [];
@@ -389,6 +406,7 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
         "docker",
         "podman",
         "nomad",
+        "kubernetes",
         "apple",
         "container",
       ]);
@@ -453,28 +471,30 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
         return;
       }
 
+      const isKubernetes = this.formData.platform === "kubernetes";
+
       this.jobEditor.session.setMode(
-        this.isLocalContainer() ? "ace/mode/yaml" : "ace/mode/terraform",
+        this.isLocalContainer() || isKubernetes ? "ace/mode/yaml" : "ace/mode/terraform",
       );
       this.volumeEditor.session.setMode("ace/mode/yaml");
 
+      let jobCompletions = nomadJobCompletions;
+      let volumeCompletions = nomadVolumeSpecCompletions;
+      if (this.isLocalContainer()) {
+        jobCompletions = containerSpecCompletions;
+        volumeCompletions = localVolumeSpecCompletions;
+      } else if (isKubernetes) {
+        jobCompletions = kubernetesJobCompletions;
+        volumeCompletions = kubernetesVolumeSpecCompletions;
+      }
+
       setSpecCompleter(
         this.jobEditor,
-        [
-          ...(this.isLocalContainer()
-            ? containerSpecCompletions
-            : nomadJobCompletions),
-          ...templateVariableCompletions,
-        ],
+        [...jobCompletions, ...templateVariableCompletions],
       );
       setSpecCompleter(
         this.volumeEditor,
-        [
-          ...(this.isLocalContainer()
-            ? localVolumeSpecCompletions
-            : nomadVolumeSpecCompletions),
-          ...templateVariableCompletions,
-        ],
+        [...volumeCompletions, ...templateVariableCompletions],
       );
     },
     clearSpecFieldErrors(field) {
@@ -606,6 +626,9 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
         with_code_server: this.formData.with_code_server,
         with_ssh: this.formData.with_ssh,
         with_run_command: this.formData.with_run_command,
+        disable_ssh_local_forward: this.formData.disable_ssh_local_forward,
+        with_ssh_reverse_forward: this.formData.with_ssh_reverse_forward,
+        with_terminal_recording: this.formData.with_terminal_recording,
         allow_node_migration: this.isLocalContainer()
           ? this.formData.allow_node_migration
           : false,
@@ -630,6 +653,15 @@ window.templateForm = function (isEdit, templateId, isDuplicate = false) {
           this.formData.platform !== "manual"
             ? "disabled"
             : this.formData.max_uptime_unit,
+        idle_policy: {
+          enabled:
+            this.formData.idle_policy.enabled &&
+            this.formData.platform !== "manual",
+          timeout: parseInt(this.formData.idle_policy.timeout) || 0,
+          cpu_threshold:
+            parseFloat(this.formData.idle_policy.cpu_threshold) || 0,
+          warn_before: parseInt(this.formData.idle_policy.warn_before) || 0,
+        },
         platform: this.formData.platform,
         icon_url: this.formData.icon_url,
         custom_fields: this.formData.custom_fields,
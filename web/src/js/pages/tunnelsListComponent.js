@@ -2,6 +2,13 @@ window.tunnelsListComponent = function() {
   return {
     loading: true,
     tunnels: [],
+    inspect: {
+      show: false,
+      tunnel: '',
+      requests: [],
+      selected: null,
+      replaying: false,
+    },
 
     async init() {
       await this.getTunnels();
@@ -41,6 +48,95 @@ window.tunnelsListComponent = function() {
       });
     },
 
+    accessSummary(access) {
+      const methods = [];
+      if (access?.require_login) methods.push('Knot login');
+      if (access?.basic_auth) methods.push('Basic auth');
+      if (access?.bearer_token) methods.push('Bearer token');
+      return methods.length ? methods.join(' or ') : 'Public';
+    },
+
+    async inspectTunnel(tunnel) {
+      this.inspect.tunnel = tunnel;
+      this.inspect.requests = [];
+      this.inspect.selected = null;
+      this.inspect.show = true;
+      await this.loadRequests();
+    },
+
+    closeInspect() {
+      this.inspect.show = false;
+      this.inspect.selected = null;
+    },
+
+    async loadRequests() {
+      await fetch(`/api/tunnels/${this.inspect.tunnel}/requests`, {
+        headers: {
+          'Content-Type': 'application/json'
+        }
+      }).then((response) => {
+        if (response.status === 200) {
+          response.json().then((requests) => {
+            this.inspect.requests = requests;
+            if (this.inspect.selected) {
+              this.inspect.selected = requests.find(x => x.id === this.inspect.selected.id) || null;
+            }
+          });
+        } else if (response.status === 401) {
+          window.location.href = '/logout';
+        }
+      }).catch(() => {});
+    },
+
+    async clearRequests() {
+      await fetch(`/api/tunnels/${this.inspect.tunnel}/requests`, {
+        method: 'DELETE',
+        headers: {
+          'Content-Type': 'application/json'
+        }
+      }).then((response) => {
+        if (response.status === 200) {
+          this.inspect.requests = [];
+          this.inspect.selected = null;
+        } else if (response.status === 401) {
+          window.location.href = '/logout';
+        }
+      }).catch(() => {});
+    },
+
+    async replayRequest(req) {
+      const self = this;
+      self.inspect.replaying = true;
+
+      await fetch(`/api/tunnels/${self.inspect.tunnel}/requests/${req.id}/replay`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json'
+        }
+      }).then((response) => {
+        if (response.status === 200) {
+          response.json().then((result) => {
+            self.inspect.requests.unshift(result);
+            self.inspect.selected = result;
+          });
+        } else if (response.status === 401) {
+          window.location.href = '/logout';
+        } else {
+          response.json().then((data) => {
+            self.$dispatch('show-alert', { msg: "Failed to replay request: " + (data.error || response.status), type: 'error' });
+          });
+        }
+      }).catch(() => {
+        self.$dispatch('show-alert', { msg: "Failed to replay request", type: 'error' });
+      }).finally(() => {
+        self.inspect.replaying = false;
+      });
+    },
+
+    formatHeaders(headers) {
+      return Object.keys(headers || {}).sort().map(k => headers[k].map(v => `${k}: ${v}`).join('\n')).join('\n');
+    },
+
     async terminateTunnel(tunnel) {
       const self = this;
 
@@ -9,13 +9,18 @@ window.userGroupForm = function (isEdit, groupId) {
       compute_units: 0,
       storage_units: 0,
       max_tunnels: 0,
+      daily_token_budget: 0,
+      monthly_token_budget: 0,
     },
+    allowedModelsStr: "",
     loading: true,
     nameValid: true,
     maxSpacesValid: true,
     computeUnitsValid: true,
     storageUnitsValid: true,
     maxTunnelsValid: true,
+    dailyTokenBudgetValid: true,
+    monthlyTokenBudgetValid: true,
     isEdit,
     stayOnPage: true,
 
@@ -39,6 +44,9 @@ window.userGroupForm = function (isEdit, groupId) {
           this.formData.compute_units = group.compute_units;
           this.formData.storage_units = group.storage_units;
           this.formData.max_tunnels = group.max_tunnels;
+          this.formData.daily_token_budget = group.daily_token_budget || 0;
+          this.formData.monthly_token_budget = group.monthly_token_budget || 0;
+          this.allowedModelsStr = (group.allowed_models || []).join(", ");
         }
       }
 
@@ -82,6 +90,22 @@ window.userGroupForm = function (isEdit, groupId) {
       );
       return this.maxTunnelsValid;
     },
+    checkDailyTokenBudget() {
+      this.dailyTokenBudgetValid = validate.isNumber(
+        this.formData.daily_token_budget,
+        0,
+        Infinity,
+      );
+      return this.dailyTokenBudgetValid;
+    },
+    checkMonthlyTokenBudget() {
+      this.monthlyTokenBudgetValid = validate.isNumber(
+        this.formData.monthly_token_budget,
+        0,
+        Infinity,
+      );
+      return this.monthlyTokenBudgetValid;
+    },
 
     async submitData() {
       let err = false;
@@ -91,6 +115,8 @@ window.userGroupForm = function (isEdit, groupId) {
       err = !this.checkComputeUnits() || err;
       err = !this.checkStorageUnits() || err;
       err = !this.checkMaxTunnels() || err;
+      err = !this.checkDailyTokenBudget() || err;
+      err = !this.checkMonthlyTokenBudget() || err;
       if (err) {
         return;
       }
@@ -103,6 +129,12 @@ window.userGroupForm = function (isEdit, groupId) {
         compute_units: parseInt(this.formData.compute_units),
         storage_units: parseInt(this.formData.storage_units),
         max_tunnels: parseInt(this.formData.max_tunnels),
+        daily_token_budget: parseInt(this.formData.daily_token_budget),
+        monthly_token_budget: parseInt(this.formData.monthly_token_budget),
+        allowed_models: this.allowedModelsStr
+          .split(",")
+          .map((m) => m.trim())
+          .filter((m) => m.length > 0),
       };
 
       await fetch(isEdit ? `/api/groups/${groupId}` : "/api/groups", {
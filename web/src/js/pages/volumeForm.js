@@ -12,6 +12,7 @@ import "ace-builds/src-noconflict/ext-searchbox";
 import "ace-builds/src-noconflict/ext-language_tools";
 import { setSpecCompleter } from "./aceSpecCompleter.js";
 import {
+  kubernetesVolumeSpecCompletions,
   localVolumeSpecCompletions,
   nomadVolumeSpecCompletions,
   templateVariableCompletions,
@@ -110,7 +111,7 @@ window.volumeForm = function (isEdit, volumeId) {
     },
     async fetchNodes(clearIfNotFound = false) {
       const platform = this.formData.platform;
-      if (platform === 'nomad') {
+      if (platform === 'nomad' || platform === 'kubernetes') {
         this.availableNodes = [];
         return;
       }
@@ -158,14 +159,16 @@ window.volumeForm = function (isEdit, volumeId) {
         return;
       }
 
+      let volumeCompletions = localVolumeSpecCompletions;
+      if (this.formData.platform === "nomad") {
+        volumeCompletions = nomadVolumeSpecCompletions;
+      } else if (this.formData.platform === "kubernetes") {
+        volumeCompletions = kubernetesVolumeSpecCompletions;
+      }
+
       setSpecCompleter(
         this.volumeEditor,
-        [
-          ...(this.formData.platform === "nomad"
-            ? nomadVolumeSpecCompletions
-            : localVolumeSpecCompletions),
-          ...templateVariableCompletions,
-        ],
+        [...volumeCompletions, ...templateVariableCompletions],
       );
     },
     setEditorErrors(errors) {
@@ -213,6 +216,7 @@ window.volumeForm = function (isEdit, volumeId) {
         "docker",
         "podman",
         "nomad",
+        "kubernetes",
         "apple",
         "container",
       ]);
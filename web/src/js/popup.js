@@ -5,6 +5,12 @@ export const popup = {
     return false;
   },
 
+  openSharedTerminal(spaceId, session, readOnly) {
+    const query = `?session=${encodeURIComponent(session)}` + (readOnly ? '&read_only=true' : '');
+    window.open(`/terminal/${spaceId}${query}`, `spaces_${spaceId}_session_${session}`, 'width=800,height=500');
+    return false;
+  },
+
   openTerminalTunnel(spaceId) {
     const width = Math.min(screen.width, 900);
     window.open(`/terminal/${spaceId}/vscode-tunnel`, `spaces_${spaceId}_tunnel`, `width=${width},height=400`);
@@ -45,4 +51,9 @@ export const popup = {
     return false;
   },
 
+  openRecording(spaceId, recordingId) {
+    window.open(`/recordings/${spaceId}/${recordingId}`, `recording_${recordingId}`, 'width=900,height=560');
+    return false;
+  },
+
 };
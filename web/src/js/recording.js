@@ -0,0 +1,108 @@
+import { Terminal } from '@xterm/xterm';
+import { CanvasAddon } from '@xterm/addon-canvas';
+import { WebglAddon } from '@xterm/addon-webgl';
+import { Unicode11Addon } from '@xterm/addon-unicode11';
+
+// Replays an asciicast v2 recording into an xterm, output events are written
+// at their recorded offsets scaled by the playback speed.
+window.initializeRecordingPlayer = function(options) {
+  const terminal = new Terminal({
+    allowProposedApi: true,
+    cursorBlink: false,
+    disableStdin: true,
+    fontSize: 15,
+    fontFamily: 'JetBrains Mono, courier-new, courier, monospace',
+  });
+
+  if (options.renderer === "webgl") {
+    terminal.loadAddon(new WebglAddon());
+  } else {
+    terminal.loadAddon(new CanvasAddon());
+  }
+  terminal.loadAddon(new Unicode11Addon());
+  terminal.unicode.activeVersion = "11";
+  terminal.open(document.getElementById("terminal"));
+
+  const player = {
+    recording: null,
+    events: [],
+    position: 0,
+    offset: 0,
+    speed: 1,
+    playing: false,
+    timer: null,
+    error: '',
+
+    async load() {
+      const response = await fetch(`/api/spaces/${options.spaceId}/recordings/${options.recordingId}`, {
+        headers: { 'Content-Type': 'application/json' },
+      });
+      if (!response.ok) {
+        this.error = response.status === 404 ? 'Recording not found' : 'Failed to load the recording';
+        return;
+      }
+
+      this.recording = await response.json();
+      const lines = this.recording.cast.split('\n').filter((line) => line.length > 0);
+      const header = JSON.parse(lines.shift());
+      terminal.resize(header.width, header.height);
+      this.events = lines.map((line) => JSON.parse(line));
+      this.restart();
+    },
+
+    restart() {
+      this.pause();
+      terminal.reset();
+      this.position = 0;
+      this.offset = 0;
+      this.play();
+    },
+
+    play() {
+      if (this.playing || this.position >= this.events.length) {
+        return;
+      }
+      this.playing = true;
+      this.schedule();
+    },
+
+    pause() {
+      this.playing = false;
+      window.clearTimeout(this.timer);
+    },
+
+    setSpeed(speed) {
+      this.speed = speed;
+      if (this.playing) {
+        window.clearTimeout(this.timer);
+        this.schedule();
+      }
+    },
+
+    schedule() {
+      const [time] = this.events[this.position];
+      const delay = Math.max(0, (time - this.offset) * 1000 / this.speed);
+      this.timer = window.setTimeout(() => this.step(), delay);
+    },
+
+    step() {
+      const [time, code, data] = this.events[this.position++];
+      this.offset = time;
+
+      if (code === 'o') {
+        terminal.write(data);
+      } else if (code === 'r') {
+        const [cols, rows] = data.split('x').map(Number);
+        terminal.resize(cols, rows);
+      }
+
+      if (this.position >= this.events.length) {
+        this.playing = false;
+        return;
+      }
+      this.schedule();
+    },
+  };
+
+  return player;
+}
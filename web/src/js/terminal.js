@@ -18,7 +18,7 @@ window.initializeTerminal = function(options) {
     cols: 128,
     fontSize: options.logView ? 13 : 15,
     fontFamily: 'JetBrains Mono, courier-new, courier, monospace',
-    disableStdin: options.logView
+    disableStdin: options.logView || options.readOnly
   });
 
   if (options.renderer === "webgl") {
@@ -28,7 +28,13 @@ window.initializeTerminal = function(options) {
   }
 
   const protocol = (location.protocol === "https:") ? "wss://" : "ws://";
-  const url = protocol + location.host + (options.logView ? `/logs/${options.spaceId}/stream` : `/proxy/spaces/${options.spaceId}/terminal/${options.shell}`);
+  let url = protocol + location.host + (options.logView ? `/logs/${options.spaceId}/stream` : `/proxy/spaces/${options.spaceId}/terminal/${options.shell}`);
+  if (!options.logView && options.session) {
+    url += `?session=${encodeURIComponent(options.session)}` + (options.readOnly ? '&read_only=true' : '');
+  }
+  if (options.logView && options.query) {
+    url += `?${options.query}`;
+  }
   const ws = new WebSocket(url);
 
   const attachAddon = new AttachAddon(ws);
@@ -89,8 +95,16 @@ window.initializeTerminal = function(options) {
     closePopup();
   };
 
+  // A log view with a message handler decides what gets written, e.g. to hold
+  // live lines while older history is shown.
+  if (options.logView && options.onLogMessage) {
+    ws.addEventListener('message', (event) => options.onLogMessage(event.data));
+  }
+
   ws.onopen = () => {
-    terminal.loadAddon(attachAddon);
+    if (!(options.logView && options.onLogMessage)) {
+      terminal.loadAddon(attachAddon);
+    }
     terminal._initialized = true;
     terminal.focus();
 
@@ -161,3 +175,92 @@ window.initializeTerminal = function(options) {
 
   return terminal;
 }
+
+// Log window with filtering and paging back through the stored history. The
+// stream ends its history with a NUL marker carrying the id of the oldest line
+// shown, older pages are then fetched from the API.
+window.initializeLogView = function(options) {
+  let terminal = null;
+
+  const levelColors = { debug: '\x1b[94mDBG', info: '\x1b[92mINF', error: '\x1b[91mERR' };
+
+  function formatLine(entry) {
+    let line = '\x1b[90m' + new Date(entry.created_at).toLocaleString() + '\x1b[0m ';
+    if (entry.source) {
+      line += '\x1b[93m' + entry.source + '\x1b[0m ';
+    }
+    line += (levelColors[entry.level] || levelColors.debug) + '\x1b[0m ';
+    return line + entry.message + '\r\n';
+  }
+
+  return {
+    level: options.level,
+    source: options.source,
+    q: options.q,
+    cursor: '',
+    history: false,
+    loading: false,
+    error: '',
+
+    init() {
+      const query = this.query();
+      terminal = initializeTerminal({
+        shell: '',
+        renderer: options.renderer,
+        spaceId: options.spaceId,
+        logView: true,
+        query: (query ? query + '&' : '') + 'cursor=true',
+        onLogMessage: (data) => this.onMessage(data),
+      });
+    },
+
+    query() {
+      const params = new URLSearchParams();
+      if (this.level) params.set('level', this.level);
+      if (this.source) params.set('source', this.source);
+      if (this.q) params.set('q', this.q);
+      return params.toString();
+    },
+
+    onMessage(data) {
+      if (data.charCodeAt(0) === 0) {
+        this.cursor = data.slice(1);
+        return;
+      }
+      if (!this.history) {
+        terminal.write(data);
+      }
+    },
+
+    applyFilter() {
+      window.location.search = this.query();
+    },
+
+    async earlier() {
+      this.loading = true;
+      this.error = '';
+
+      const params = new URLSearchParams(this.query());
+      params.set('before', this.cursor);
+      params.set('limit', '1000');
+      const response = await fetch(`/api/spaces/${options.spaceId}/logs?${params}`, {
+        headers: { 'Content-Type': 'application/json' },
+      });
+      this.loading = false;
+      if (!response.ok) {
+        this.error = 'Failed to load older logs';
+        return;
+      }
+
+      const list = await response.json();
+      this.history = true;
+      terminal.reset();
+      list.logs.reverse().forEach((entry) => terminal.write(formatLine(entry)));
+      this.cursor = list.next || '';
+    },
+
+    live() {
+      window.location.reload();
+    },
+  };
+}
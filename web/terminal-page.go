@@ -56,10 +56,19 @@ func HandleTerminalPage(w http.ResponseWriter, r *http.Request) {
 		shell = "vscode-tunnel"
 	}
 
+	// Shared sessions are named, viewers attach read only
+	session := r.URL.Query().Get("session")
+	if session != "" && !validate.Name(session) {
+		showPageNotFound(w, r)
+		return
+	}
+
 	data := map[string]interface{}{
 		"shell":    shell,
 		"renderer": renderer,
 		"spaceId":  spaceId,
+		"session":  session,
+		"readOnly": session != "" && space.SharedTerminalReadOnly(user, r.URL.Query().Get("read_only") == "true"),
 		"version":  build.Version,
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/paularlott/knot/internal/agentapi/msg"
 	"github.com/paularlott/knot/internal/database"
 	"github.com/paularlott/knot/internal/database/model"
+	"github.com/paularlott/knot/internal/tunnel_server"
 	"github.com/paularlott/knot/internal/util/rest"
 	"github.com/paularlott/knot/internal/util/validate"
 
@@ -51,6 +52,10 @@ func HandleTunnelStart(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, r, http.StatusBadRequest, "Invalid name, must be all lowercase and only contain letters, numbers and dashes")
 		return
 	}
+	if _, err := tunnel_server.PolicyFromSpec(request.Policy); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "Invalid access policy: "+err.Error())
+		return
+	}
 
 	agentSession := agent_server.GetSession(spaceId)
 	if agentSession == nil {
@@ -62,6 +67,7 @@ func HandleTunnelStart(w http.ResponseWriter, r *http.Request) {
 		Protocol: request.Protocol,
 		Port:     request.Port,
 		Name:     request.Name,
+		Policy:   request.Policy,
 	})
 	if err != nil {
 		log.WithError(err).Error("Failed to send tunnel start command to agent")
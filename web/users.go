@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/paularlott/knot/internal/log"
+	"github.com/paularlott/knot/internal/oidc"
 )
 
 func HandleUserProfilePage(w http.ResponseWriter, r *http.Request) {
@@ -22,6 +23,16 @@ func HandleUserProfilePage(w http.ResponseWriter, r *http.Request) {
 		"id": user.Id,
 	}
 
+	allProviders := []map[string]string{}
+	for _, provider := range oidc.GetProviders() {
+		allProviders = append(allProviders, map[string]string{
+			"id":           provider.Id(),
+			"display_name": provider.DisplayName(),
+		})
+	}
+	data["allProviders"] = allProviders
+	data["loginError"] = oidcErrorMessages[r.URL.Query().Get("error")]
+
 	err = tmpl.Execute(w, data)
 	if err != nil {
 		log.Error(err.Error())
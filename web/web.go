@@ -21,6 +21,7 @@ import (
 	"github.com/paularlott/knot/internal/database/model"
 	"github.com/paularlott/knot/internal/middleware"
 	"github.com/paularlott/knot/internal/oauth2"
+	"github.com/paularlott/knot/internal/tunnel_server"
 
 	"github.com/paularlott/knot/internal/log"
 )
@@ -305,9 +306,19 @@ func Routes(router *http.ServeMux, cfg *config.ServerConfig) {
 		router.HandleFunc("GET /tunnels", middleware.WebAuth(checkPermissionUseTunnels(HandleSimplePage)))
 	}
 
+	// Login hand off for tunnels that require a knot login, the tunnel may be
+	// served by any server in the cluster
+	if cfg.TunnelDomain != "" {
+		router.HandleFunc("GET /tunnel-auth", middleware.WebAuth(tunnel_server.HandleTunnelAuth))
+	}
+
 	if database.GetInstance().HasAuditLog() && cfg.Audit.Routing != "external" {
 		router.HandleFunc("GET /audit-logs", middleware.WebAuth(checkPermissionViewAuditLogs(HandleSimplePage)))
 	}
+	if cfg.Chat.Enabled {
+		router.HandleFunc("GET /llm-usage", middleware.WebAuth(checkPermissionViewAIUsage(HandleSimplePage)))
+	}
+	router.HandleFunc("GET /recordings/{space_id}/{recording_id}", middleware.WebAuth(checkPermissionViewAuditLogs(HandleRecordingPage)))
 
 	router.HandleFunc("GET /logs/{space_id}/stream", middleware.ApiAuth(HandleLogsStream))
 	router.HandleFunc("GET /space-io/{space_id}/run", middleware.ApiAuth(middleware.ApiPermissionRunCommands(HandleRunCommandStream)))
@@ -328,6 +339,8 @@ func Routes(router *http.ServeMux, cfg *config.ServerConfig) {
 		router.HandleFunc("GET /initial-system-setup", HandleInitialSystemSetupPage)
 	}
 	router.HandleFunc("GET /login", HandleLoginPage)
+	router.HandleFunc("GET /auth/{provider}/start", HandleOIDCStart)
+	router.HandleFunc("GET /auth/{provider}/callback", HandleOIDCCallback)
 	router.HandleFunc("GET /oauth/grant", middleware.WebAuth(HandleOAuth2GrantPage))
 	router.HandleFunc("POST /oauth/grant", middleware.WebAuth(oauth2.HandleGrant))
 
@@ -589,7 +602,7 @@ func getCommonTemplateData(r *http.Request) (*model.User, map[string]interface{}
 // non-leaf deployments; on a leaf node they remain top-level entries, so they
 // are only treated as admin paths when leafNode is false.
 func isAdminPath(path string, leafNode bool) bool {
-	paths := []string{"/users", "/groups", "/roles", "/audit-logs", "/cluster-info"}
+	paths := []string{"/users", "/groups", "/roles", "/audit-logs", "/llm-usage", "/cluster-info"}
 	if !leafNode {
 		paths = append(paths, "/templates", "/variables")
 	}
@@ -609,7 +622,7 @@ func isMorePath(path string, leafNode bool) bool {
 	if !leafNode {
 		paths = append(paths, "/templates", "/variables")
 	}
-	paths = append(paths, "/users", "/groups", "/roles", "/audit-logs", "/cluster-info")
+	paths = append(paths, "/users", "/groups", "/roles", "/audit-logs", "/llm-usage", "/cluster-info")
 	for _, p := range paths {
 		if path == p || strings.HasPrefix(path, p+"/") {
 			return true